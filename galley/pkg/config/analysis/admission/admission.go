@@ -0,0 +1,234 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package admission runs config analysis against resources as they are admitted, so that findings can be returned to
+// the user at apply time.
+package admission
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"istio.io/api/annotation"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/galley/pkg/config/processing/snapshotter"
+	"istio.io/istio/galley/pkg/config/scope"
+	"istio.io/istio/galley/pkg/config/source/kube/rt"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+)
+
+// DefaultTimeout is the default upper bound on the time spent analyzing a single admission request.
+const DefaultTimeout = 2 * time.Second
+
+// SnapshotFn returns the latest snapshot of the cluster configuration, or nil if none is available.
+type SnapshotFn func() *snapshotter.Snapshot
+
+// ContextFn returns the analysis context of the latest snapshot, or nil if none is available.
+type ContextFn func() analysis.Context
+
+// Options for an Analyzer.
+type Options struct {
+	// Analyzers that are candidates to run at admission time. Only the analyzers that take the incoming resource's
+	// collection as an input are run.
+	Analyzers []analysis.Analyzer
+
+	// Snapshot provides the current cluster configuration to overlay incoming resources on.
+	Snapshot SnapshotFn
+
+	// Context provides the Istio version, clusters and pushed configuration of the analysis of the snapshot to the
+	// analyzers, see e.g. analysis.VersionProvider. Optional.
+	Context ContextFn
+
+	// LevelOverrides are the levels of messages by code, overriding the levels of their types. They are applied
+	// before DenyOnError is checked. Optional.
+	LevelOverrides map[string]diag.Level
+
+	// DenyOnError causes resources with Error level findings to be rejected, instead of admitted with a warning.
+	DenyOnError bool
+
+	// FastOnly restricts the analysis to the analyzers that can analyze the incoming resource on its own, see
+	// analysis.IncrementalAnalyzer. Instead of iterating over the whole configuration, they look up what they need
+	// in indexes of the snapshot, which are shared across requests.
+	FastOnly bool
+
+	// Limits bound the size of incoming resources. A resource exceeding them is not analyzed, and a ResourceTooLarge
+	// message is returned for it instead. By default, there are no limits.
+	Limits analysis.Limits
+
+	// Timeout is the upper bound on the time spent analyzing a single resource. Analyzers that have not run by then
+	// are skipped. Defaults to DefaultTimeout.
+	Timeout time.Duration
+}
+
+// Analyzer analyzes incoming resources against the current cluster configuration.
+type Analyzer struct {
+	o       Options
+	indexes *indexCache
+
+	// The analyzers that apply to the Istio version of the last request
+	applicableMu sync.Mutex
+	version      string
+	applicable   []analysis.Analyzer
+}
+
+// New returns a new Analyzer.
+func New(o Options) *Analyzer {
+	if o.Timeout == 0 {
+		o.Timeout = DefaultTimeout
+	}
+	return &Analyzer{
+		o:       o,
+		indexes: &indexCache{},
+	}
+}
+
+// Analyze runs the relevant analyzers against the current snapshot with the given resource overlaid on it, and
+// returns the findings that concern the resource, at their overridden levels. If no snapshot is available, no analysis is performed.
+func (a *Analyzer) Analyze(s collection.Schema, r *resource.Instance) diag.Messages {
+	sn := a.o.Snapshot()
+	if sn == nil {
+		scope.Analysis.Debugf("No snapshot available, skipping admission analysis of %v", r.Metadata.FullName)
+		return nil
+	}
+
+	if r.Origin == nil {
+		r.Origin = &rt.Origin{
+			Collection: s.Name(),
+			Kind:       s.Resource().Kind(),
+			FullName:   r.Metadata.FullName,
+			Version:    r.Metadata.Version,
+		}
+	}
+
+	if reason := a.o.Limits.Check(r); reason != "" {
+		return a.overrideLevels(suppress(diag.Messages{msg.NewResourceTooLarge(r, reason)}, r))
+	}
+
+	var base analysis.Context
+	if a.o.Context != nil {
+		base = a.o.Context()
+	}
+
+	ctx := newOverlayContext(sn, base, s.Name(), r, time.Now().Add(a.o.Timeout), a.indexes)
+	for _, an := range a.applicableTo(analysis.IstioVersion(ctx)) {
+		// Incremental analyzers only need to analyze the incoming resource
+		ia, incremental := an.(analysis.IncrementalAnalyzer)
+		incremental = incremental && ia.IncrementalInput() == s.Name()
+		if !incremental && (a.o.FastOnly || !consumes(an, s.Name())) {
+			continue
+		}
+		if ctx.Canceled() {
+			scope.Analysis.Warnf("Admission analysis of %v timed out before running %q", r.Metadata.FullName, an.Metadata().Name)
+			break
+		}
+		if incremental {
+			ia.AnalyzeResource(ctx, r)
+		} else {
+			an.Analyze(ctx)
+		}
+	}
+
+	return a.overrideLevels(suppress(ctx.messages, r))
+}
+
+// AnalyzeAdmission analyzes the resource and renders the findings as warnings. If DenyOnError is set and there are
+// Error level findings, an error describing them is returned.
+func (a *Analyzer) AnalyzeAdmission(s collection.Schema, r *resource.Instance) ([]string, error) {
+	msgs := a.Analyze(s, r).SortedDedupedCopy()
+
+	var warnings []string
+	var errs []string
+	for _, m := range msgs {
+		if a.o.DenyOnError && m.Type.Level() == diag.Error {
+			errs = append(errs, m.String())
+			continue
+		}
+		warnings = append(warnings, m.String())
+	}
+
+	if len(errs) > 0 {
+		return warnings, fmt.Errorf("configuration analysis failed: %s", strings.Join(errs, "; "))
+	}
+	return warnings, nil
+}
+
+// applicableTo returns the analyzers that apply to the given Istio version, see analysis.Metadata.AppliesTo. All
+// analyzers apply if the version is unknown or cannot be parsed.
+func (a *Analyzer) applicableTo(version string) []analysis.Analyzer {
+	if version == "" {
+		return a.o.Analyzers
+	}
+
+	a.applicableMu.Lock()
+	defer a.applicableMu.Unlock()
+	if a.applicable != nil && a.version == version {
+		return a.applicable
+	}
+
+	applicable := make([]analysis.Analyzer, 0, len(a.o.Analyzers))
+	for _, an := range a.o.Analyzers {
+		// Analyzers run regardless of their version bounds if the version or the bounds cannot be parsed.
+		if applies, err := an.Metadata().AppliesTo(version); err != nil || applies {
+			applicable = append(applicable, an)
+		}
+	}
+	a.version = version
+	a.applicable = applicable
+	return applicable
+}
+
+// overrideLevels sets the levels of the messages whose codes have overridden levels.
+func (a *Analyzer) overrideLevels(msgs diag.Messages) diag.Messages {
+	for i, m := range msgs {
+		if level, ok := a.o.LevelOverrides[m.Type.Code()]; ok {
+			msgs[i] = m.WithLevel(level)
+		}
+	}
+	return msgs
+}
+
+func consumes(a analysis.Analyzer, col collection.Name) bool {
+	for _, in := range a.Metadata().Inputs {
+		if in == col {
+			return true
+		}
+	}
+	return false
+}
+
+// suppress removes messages suppressed by the resource's annotations.
+func suppress(msgs diag.Messages, r *resource.Instance) diag.Messages {
+	codes := r.Metadata.Annotations[annotation.GalleyAnalyzeSuppress.Name]
+	if codes == "" {
+		return msgs
+	}
+
+	var result diag.Messages
+outer:
+	for _, m := range msgs {
+		for _, code := range strings.Split(codes, ",") {
+			if code == "*" || m.Type.Code() == code {
+				continue outer
+			}
+		}
+		result = append(result, m)
+	}
+	return result
+}
@@ -0,0 +1,389 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admission
+
+import (
+	gocontext "context"
+	"testing"
+
+	"github.com/gogo/protobuf/types"
+	. "github.com/onsi/gomega"
+
+	"istio.io/api/annotation"
+	"istio.io/api/mesh/v1alpha1"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/galley/pkg/config/analysis/pushed"
+	coll "istio.io/istio/galley/pkg/config/collection"
+	"istio.io/istio/galley/pkg/config/processing/snapshotter"
+	"istio.io/istio/galley/pkg/config/testing/basicmeta"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+)
+
+var testMessageType = diag.NewMessageType(diag.Error, "TEST0001", "Saw %d resources")
+
+// countingAnalyzer reports, for every resource in its input, the number of resources in the collection.
+type countingAnalyzer struct {
+	inputs collection.Names
+}
+
+// Metadata implements analysis.Analyzer
+func (a *countingAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:   "counting",
+		Inputs: a.inputs,
+	}
+}
+
+// Analyze implements analysis.Analyzer
+func (a *countingAnalyzer) Analyze(ctx analysis.Context) {
+	for _, in := range a.inputs {
+		var rs []*resource.Instance
+		ctx.ForEach(in, func(r *resource.Instance) bool {
+			rs = append(rs, r)
+			return true
+		})
+		for _, r := range rs {
+			ctx.Report(in, diag.NewMessage(testMessageType, r, len(rs)))
+		}
+	}
+}
+
+// funcAnalyzer calls a function with the context it is given.
+type funcAnalyzer struct {
+	inputs     collection.Names
+	minVersion string
+	fn         func(analysis.Context)
+}
+
+// Metadata implements analysis.Analyzer
+func (a *funcAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:       "func",
+		Inputs:     a.inputs,
+		MinVersion: a.minVersion,
+	}
+}
+
+// Analyze implements analysis.Analyzer
+func (a *funcAnalyzer) Analyze(ctx analysis.Context) {
+	a.fn(ctx)
+}
+
+// indexingAnalyzer reports, for the incoming resource, the number of resources in its collection. The count is taken
+// from an index, next to an index of another collection.
+type indexingAnalyzer struct {
+	builds  map[string]int
+	analyze int
+}
+
+var _ analysis.IncrementalAnalyzer = &indexingAnalyzer{}
+
+// Metadata implements analysis.Analyzer
+func (a *indexingAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:   "indexing",
+		Inputs: collection.Names{basicmeta.K8SCollection1.Name(), basicmeta.Collection2.Name()},
+	}
+}
+
+// Analyze implements analysis.Analyzer
+func (a *indexingAnalyzer) Analyze(analysis.Context) {
+	a.analyze++
+}
+
+// IncrementalInput implements analysis.IncrementalAnalyzer
+func (a *indexingAnalyzer) IncrementalInput() collection.Name {
+	return basicmeta.K8SCollection1.Name()
+}
+
+// AnalyzeResource implements analysis.IncrementalAnalyzer
+func (a *indexingAnalyzer) AnalyzeResource(ctx analysis.Context, r *resource.Instance) {
+	a.count(ctx, basicmeta.Collection2.Name())
+	n := a.count(ctx, basicmeta.K8SCollection1.Name())
+	ctx.Report(basicmeta.K8SCollection1.Name(), diag.NewMessage(testMessageType, r, n))
+}
+
+func (a *indexingAnalyzer) count(ctx analysis.Context, col collection.Name) int {
+	return analysis.Index(ctx, "admission.count/"+col.String(), func() interface{} {
+		a.builds[col.String()]++
+		n := 0
+		ctx.ForEach(col, func(*resource.Instance) bool {
+			n++
+			return true
+		})
+		return n
+	}).(int)
+}
+
+func newInstance(ns, name string, version resource.Version) *resource.Instance {
+	return &resource.Instance{
+		Metadata: resource.Metadata{
+			FullName:    resource.NewFullName(resource.Namespace(ns), resource.LocalName(name)),
+			Version:     version,
+			Schema:      basicmeta.K8SCollection1.Resource(),
+			Annotations: make(map[string]string),
+		},
+		Message: &types.Empty{},
+	}
+}
+
+func newTestSnapshot(rs ...*resource.Instance) *snapshotter.Snapshot {
+	c := coll.New(basicmeta.K8SCollection1)
+	for _, r := range rs {
+		c.Set(r)
+	}
+	return snapshotter.NewSnapshot([]*coll.Instance{c})
+}
+
+func TestAnalyze(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	sn := newTestSnapshot(newInstance("n1", "i1", "v1"))
+	a := New(Options{
+		Analyzers: []analysis.Analyzer{&countingAnalyzer{inputs: collection.Names{basicmeta.K8SCollection1.Name()}}},
+		Snapshot:  func() *snapshotter.Snapshot { return sn },
+	})
+
+	// A new resource is added to the collection, and only its own findings are returned.
+	r := newInstance("n2", "i2", "v1")
+	msgs := a.Analyze(basicmeta.K8SCollection1, r)
+	g.Expect(msgs).To(HaveLen(1))
+	g.Expect(msgs[0].Resource).To(BeIdenticalTo(r))
+	g.Expect(msgs[0].Parameters).To(Equal([]interface{}{2}))
+
+	// An existing resource is replaced.
+	r = newInstance("n1", "i1", "v2")
+	msgs = a.Analyze(basicmeta.K8SCollection1, r)
+	g.Expect(msgs).To(HaveLen(1))
+	g.Expect(msgs[0].Parameters).To(Equal([]interface{}{1}))
+}
+
+func TestAnalyzeSkipsUnrelatedAnalyzers(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	sn := newTestSnapshot()
+	a := New(Options{
+		Analyzers: []analysis.Analyzer{&countingAnalyzer{inputs: collection.Names{basicmeta.Collection2.Name()}}},
+		Snapshot:  func() *snapshotter.Snapshot { return sn },
+	})
+
+	g.Expect(a.Analyze(basicmeta.K8SCollection1, newInstance("n1", "i1", "v1"))).To(BeEmpty())
+}
+
+func TestAnalyzeNoSnapshot(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	a := New(Options{
+		Analyzers: []analysis.Analyzer{&countingAnalyzer{inputs: collection.Names{basicmeta.K8SCollection1.Name()}}},
+		Snapshot:  func() *snapshotter.Snapshot { return nil },
+	})
+
+	g.Expect(a.Analyze(basicmeta.K8SCollection1, newInstance("n1", "i1", "v1"))).To(BeEmpty())
+}
+
+func TestAnalyzeAdmission(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	sn := newTestSnapshot()
+	o := Options{
+		Analyzers: []analysis.Analyzer{&countingAnalyzer{inputs: collection.Names{basicmeta.K8SCollection1.Name()}}},
+		Snapshot:  func() *snapshotter.Snapshot { return sn },
+	}
+
+	warnings, err := New(o).AnalyzeAdmission(basicmeta.K8SCollection1, newInstance("n1", "i1", "v1"))
+	g.Expect(err).To(BeNil())
+	g.Expect(warnings).To(HaveLen(1))
+	g.Expect(warnings[0]).To(ContainSubstring("TEST0001"))
+
+	o.DenyOnError = true
+	warnings, err = New(o).AnalyzeAdmission(basicmeta.K8SCollection1, newInstance("n1", "i1", "v1"))
+	g.Expect(err).NotTo(BeNil())
+	g.Expect(err.Error()).To(ContainSubstring("TEST0001"))
+	g.Expect(warnings).To(BeEmpty())
+
+	// Suppressed findings do not cause a denial.
+	r := newInstance("n1", "i1", "v1")
+	r.Metadata.Annotations[annotation.GalleyAnalyzeSuppress.Name] = "TEST0001"
+	warnings, err = New(o).AnalyzeAdmission(basicmeta.K8SCollection1, r)
+	g.Expect(err).To(BeNil())
+	g.Expect(warnings).To(BeEmpty())
+}
+
+func TestAnalyzeAdmissionLevelOverrides(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	sn := newTestSnapshot()
+	a := New(Options{
+		Analyzers:      []analysis.Analyzer{&countingAnalyzer{inputs: collection.Names{basicmeta.K8SCollection1.Name()}}},
+		Snapshot:       func() *snapshotter.Snapshot { return sn },
+		LevelOverrides: map[string]diag.Level{"TEST0001": diag.Warning},
+		DenyOnError:    true,
+	})
+
+	// Findings downgraded from Error do not cause a denial.
+	warnings, err := a.AnalyzeAdmission(basicmeta.K8SCollection1, newInstance("n1", "i1", "v1"))
+	g.Expect(err).To(BeNil())
+	g.Expect(warnings).To(HaveLen(1))
+	g.Expect(warnings[0]).To(HavePrefix("Warning [TEST0001]"))
+}
+
+func TestAnalyzeIncremental(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	sn := newTestSnapshot(newInstance("n1", "i1", "v1"))
+	an := &indexingAnalyzer{builds: make(map[string]int)}
+	counting := &countingAnalyzer{inputs: collection.Names{basicmeta.K8SCollection1.Name()}}
+	a := New(Options{
+		Analyzers: []analysis.Analyzer{an, counting},
+		Snapshot:  func() *snapshotter.Snapshot { return sn },
+		FastOnly:  true,
+	})
+
+	// Only the incremental analyzer runs, against the overlaid resource.
+	msgs := a.Analyze(basicmeta.K8SCollection1, newInstance("n2", "i2", "v1"))
+	g.Expect(msgs).To(HaveLen(1))
+	g.Expect(msgs[0].Parameters).To(Equal([]interface{}{2}))
+	g.Expect(an.analyze).To(Equal(0))
+
+	// The index of the other collection is shared, the index of the overlaid collection is not.
+	msgs = a.Analyze(basicmeta.K8SCollection1, newInstance("n1", "i1", "v2"))
+	g.Expect(msgs).To(HaveLen(1))
+	g.Expect(msgs[0].Parameters).To(Equal([]interface{}{1}))
+	g.Expect(an.builds).To(Equal(map[string]int{
+		basicmeta.Collection2.Name().String():    1,
+		basicmeta.K8SCollection1.Name().String(): 2,
+	}))
+
+	// Shared indexes are rebuilt for a new snapshot.
+	sn = newTestSnapshot()
+	a.Analyze(basicmeta.K8SCollection1, newInstance("n1", "i1", "v1"))
+	g.Expect(an.builds[basicmeta.Collection2.Name().String()]).To(Equal(2))
+}
+
+func TestAnalyzeIncrementalAndFull(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	sn := newTestSnapshot()
+	an := &indexingAnalyzer{builds: make(map[string]int)}
+	a := New(Options{
+		Analyzers: []analysis.Analyzer{an, &countingAnalyzer{inputs: collection.Names{basicmeta.K8SCollection1.Name()}}},
+		Snapshot:  func() *snapshotter.Snapshot { return sn },
+	})
+
+	// Without FastOnly the other analyzers run too, but incremental ones still only analyze the incoming resource.
+	g.Expect(a.Analyze(basicmeta.K8SCollection1, newInstance("n1", "i1", "v1"))).To(HaveLen(2))
+	g.Expect(an.analyze).To(Equal(0))
+}
+
+// providerContext is the analysis context of a snapshot with all the optional providers
+type providerContext struct {
+	cfg *pushed.Config
+	ctx gocontext.Context
+}
+
+func (c *providerContext) Report(collection.Name, diag.Message)                       {}
+func (c *providerContext) Find(collection.Name, resource.FullName) *resource.Instance { return nil }
+func (c *providerContext) Exists(collection.Name, resource.FullName) bool             { return false }
+func (c *providerContext) ForEach(collection.Name, analysis.IteratorFn)               {}
+func (c *providerContext) Canceled() bool                                             { return false }
+func (c *providerContext) GoContext() gocontext.Context                               { return c.ctx }
+func (c *providerContext) IstioVersion() string                                       { return "1.7.0" }
+func (c *providerContext) Clusters() []string                                         { return []string{"east", "west"} }
+func (c *providerContext) PushedConfig() *pushed.Config                               { return c.cfg }
+
+func (c *providerContext) ResourceClusters(collection.Name, resource.FullName) []string {
+	return []string{"west"}
+}
+
+func (c *providerContext) ClusterMeshNetworks(cluster string) *v1alpha1.MeshNetworks {
+	return &v1alpha1.MeshNetworks{Networks: map[string]*v1alpha1.Network{cluster: {}}}
+}
+
+func TestAnalyzeForwardsProviders(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	sn := newTestSnapshot()
+	goCtx, cancel := gocontext.WithCancel(gocontext.Background())
+	defer cancel()
+	base := &providerContext{cfg: pushed.NewConfig(), ctx: goCtx}
+	var got analysis.Context
+	a := New(Options{
+		Analyzers: []analysis.Analyzer{&funcAnalyzer{
+			inputs: collection.Names{basicmeta.K8SCollection1.Name()},
+			fn:     func(ctx analysis.Context) { got = ctx },
+		}},
+		Snapshot: func() *snapshotter.Snapshot { return sn },
+		Context:  func() analysis.Context { return base },
+	})
+	r := newInstance("n1", "i1", "v1")
+	a.Analyze(basicmeta.K8SCollection1, r)
+
+	g.Expect(analysis.GoContext(got)).To(BeIdenticalTo(base.ctx))
+	g.Expect(analysis.IstioVersion(got)).To(Equal("1.7.0"))
+	g.Expect(analysis.Clusters(got)).To(Equal([]string{"east", "west"}))
+	g.Expect(analysis.ResourceClusters(got, basicmeta.K8SCollection1.Name(), r.Metadata.FullName)).To(
+		Equal([]string{"west"}))
+	g.Expect(analysis.ClusterMeshNetworks(got, "east").Networks).To(HaveKey("east"))
+	g.Expect(analysis.PushedConfig(got)).To(BeIdenticalTo(base.cfg))
+}
+
+func TestAnalyzeVersionBounds(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	sn := newTestSnapshot()
+	var ran []string
+	analyzer := func(minVersion string) analysis.Analyzer {
+		return &funcAnalyzer{
+			inputs:     collection.Names{basicmeta.K8SCollection1.Name()},
+			minVersion: minVersion,
+			fn:         func(analysis.Context) { ran = append(ran, minVersion) },
+		}
+	}
+	a := New(Options{
+		Analyzers: []analysis.Analyzer{analyzer("1.7"), analyzer("1.8")},
+		Snapshot:  func() *snapshotter.Snapshot { return sn },
+		Context:   func() analysis.Context { return &providerContext{} },
+	})
+
+	a.Analyze(basicmeta.K8SCollection1, newInstance("n1", "i1", "v1"))
+	g.Expect(ran).To(Equal([]string{"1.7"}))
+}
+
+func TestAnalyzeLimits(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	sn := newTestSnapshot()
+	counting := &countingAnalyzer{inputs: collection.Names{basicmeta.K8SCollection1.Name()}}
+	a := New(Options{
+		Analyzers: []analysis.Analyzer{counting},
+		Snapshot:  func() *snapshotter.Snapshot { return sn },
+		Limits:    analysis.Limits{MaxResourceBytes: 10},
+	})
+
+	// A large resource is reported instead of being analyzed.
+	r := newInstance("n1", "i1", "v1")
+	r.Message = &types.StringValue{Value: "a value that is too large"}
+	msgs := a.Analyze(basicmeta.K8SCollection1, r)
+	g.Expect(msgs).To(HaveLen(1))
+	g.Expect(msgs[0].Type).To(Equal(msg.ResourceTooLarge))
+
+	msgs = a.Analyze(basicmeta.K8SCollection1, newInstance("n1", "i1", "v1"))
+	g.Expect(msgs).To(HaveLen(1))
+	g.Expect(msgs[0].Type).To(Equal(testMessageType))
+}
@@ -0,0 +1,221 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admission
+
+import (
+	gocontext "context"
+	"sync"
+	"time"
+
+	"istio.io/api/mesh/v1alpha1"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+	"istio.io/istio/galley/pkg/config/analysis/pushed"
+	"istio.io/istio/galley/pkg/config/processing/snapshotter"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+)
+
+// overlayContext is an analysis.Context that presents a snapshot with a single resource added or replaced. Only the
+// messages reported against the overlaid resource are kept. The optional providers of the analysis context, such as
+// analysis.VersionProvider, are forwarded to the context of the snapshot, if any.
+type overlayContext struct {
+	sn       *snapshotter.Snapshot
+	base     analysis.Context
+	col      collection.Name
+	r        *resource.Instance
+	deadline time.Time
+	messages diag.Messages
+
+	// Indexes shared with other requests, and the indexes built from the overlaid collection, which are not.
+	shared  *indexCache
+	indexes map[string]interface{}
+	// The collections read while building an index, or nil if no index is being built.
+	reads map[collection.Name]bool
+}
+
+var _ analysis.Context = &overlayContext{}
+var _ analysis.IndexProvider = &overlayContext{}
+var _ analysis.GoContextProvider = &overlayContext{}
+var _ analysis.VersionProvider = &overlayContext{}
+var _ analysis.ClusterProvider = &overlayContext{}
+var _ analysis.PushedConfigProvider = &overlayContext{}
+
+func newOverlayContext(sn *snapshotter.Snapshot, base analysis.Context, col collection.Name, r *resource.Instance,
+	deadline time.Time, shared *indexCache) *overlayContext {
+
+	return &overlayContext{
+		sn:       sn,
+		base:     base,
+		col:      col,
+		r:        r,
+		deadline: deadline,
+		shared:   shared,
+		indexes:  make(map[string]interface{}),
+	}
+}
+
+// Report implements analysis.Context
+func (c *overlayContext) Report(_ collection.Name, m diag.Message) {
+	if m.Resource == c.r {
+		c.messages.Add(m)
+	}
+}
+
+// Find implements analysis.Context
+func (c *overlayContext) Find(col collection.Name, name resource.FullName) *resource.Instance {
+	c.read(col)
+	if col == c.col && name == c.r.Metadata.FullName {
+		return c.r
+	}
+	return c.sn.Find(col, name)
+}
+
+// Exists implements analysis.Context
+func (c *overlayContext) Exists(col collection.Name, name resource.FullName) bool {
+	return c.Find(col, name) != nil
+}
+
+// ForEach implements analysis.Context
+func (c *overlayContext) ForEach(col collection.Name, fn analysis.IteratorFn) {
+	c.read(col)
+	if col != c.col {
+		c.sn.ForEach(col, fn)
+		return
+	}
+
+	done := false
+	c.sn.ForEach(col, func(r *resource.Instance) bool {
+		if r.Metadata.FullName == c.r.Metadata.FullName {
+			return true
+		}
+		if !fn(r) {
+			done = true
+			return false
+		}
+		return true
+	})
+	if !done {
+		fn(c.r)
+	}
+}
+
+// Canceled implements analysis.Context
+func (c *overlayContext) Canceled() bool {
+	return time.Now().After(c.deadline)
+}
+
+// Index implements analysis.IndexProvider. An index that is not built from the overlaid collection is the same as for
+// the snapshot alone, so it is shared with the other requests against the snapshot.
+func (c *overlayContext) Index(key string, build func() interface{}) interface{} {
+	if v, ok := c.indexes[key]; ok {
+		return v
+	}
+	if v, ok := c.shared.get(c.sn, key, c.col); ok {
+		return v
+	}
+
+	outer := c.reads
+	c.reads = make(map[collection.Name]bool)
+	v := build()
+	reads := c.reads
+	c.reads = outer
+	for col := range reads {
+		c.read(col)
+	}
+
+	if reads[c.col] {
+		c.indexes[key] = v
+	} else {
+		c.shared.put(c.sn, key, v, reads)
+	}
+	return v
+}
+
+// GoContext implements analysis.GoContextProvider
+func (c *overlayContext) GoContext() gocontext.Context {
+	return analysis.GoContext(c.base)
+}
+
+// IstioVersion implements analysis.VersionProvider
+func (c *overlayContext) IstioVersion() string {
+	return analysis.IstioVersion(c.base)
+}
+
+// Clusters implements analysis.ClusterProvider
+func (c *overlayContext) Clusters() []string {
+	return analysis.Clusters(c.base)
+}
+
+// ResourceClusters implements analysis.ClusterProvider
+func (c *overlayContext) ResourceClusters(col collection.Name, name resource.FullName) []string {
+	return analysis.ResourceClusters(c.base, col, name)
+}
+
+// ClusterMeshNetworks implements analysis.ClusterProvider
+func (c *overlayContext) ClusterMeshNetworks(cluster string) *v1alpha1.MeshNetworks {
+	return analysis.ClusterMeshNetworks(c.base, cluster)
+}
+
+// PushedConfig implements analysis.PushedConfigProvider
+func (c *overlayContext) PushedConfig() *pushed.Config {
+	return analysis.PushedConfig(c.base)
+}
+
+func (c *overlayContext) read(col collection.Name) {
+	if c.reads != nil {
+		c.reads[col] = true
+	}
+}
+
+// indexCache holds the indexes built by analyzers at admission time, for the latest snapshot.
+type indexCache struct {
+	mu      sync.Mutex
+	sn      *snapshotter.Snapshot
+	entries map[string]indexEntry
+}
+
+type indexEntry struct {
+	value interface{}
+	// The collections the index was built from
+	reads map[collection.Name]bool
+}
+
+// get returns the index with the given key, if it was built for the snapshot and not from the overlaid collection.
+func (c *indexCache) get(sn *snapshotter.Snapshot, key string, overlaid collection.Name) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.sn != sn {
+		return nil, false
+	}
+	e, ok := c.entries[key]
+	if !ok || e.reads[overlaid] {
+		return nil, false
+	}
+	return e.value, true
+}
+
+// put stores the index with the given key, built from the given collections of the snapshot. Indexes of previous
+// snapshots are dropped.
+func (c *indexCache) put(sn *snapshotter.Snapshot, key string, value interface{}, reads map[collection.Name]bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.sn != sn {
+		c.sn = sn
+		c.entries = make(map[string]indexEntry)
+	}
+	c.entries[key] = indexEntry{value: value, reads: reads}
+}
@@ -0,0 +1,192 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package alerts generates Prometheus alerting rules from the analysis message catalog. The rules alert on the
+// galley_analysis_messages metric, which holds the number of messages of the last analysis run per code and level.
+package alerts
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ghodss/yaml"
+
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+)
+
+const (
+	// Metric is the name of the metric the generated rules alert on.
+	Metric = "galley_analysis_messages"
+
+	// DefaultName is the default name of the generated PrometheusRule.
+	DefaultName = "istio-config-analysis"
+
+	// DefaultFor is the default time a message must be reported before its alert fires.
+	DefaultFor = 10 * time.Minute
+)
+
+// Options for generating alerting rules.
+type Options struct {
+	// Name of the PrometheusRule. Defaults to DefaultName.
+	Name string
+
+	// Namespace of the PrometheusRule. Omitted if empty.
+	Namespace string
+
+	// Levels of the messages to alert on. Defaults to Error.
+	Levels []diag.Level
+
+	// Codes of the messages to alert on. If empty, all messages of the selected levels are alerted on.
+	Codes []string
+
+	// For is the time a message must be reported before its alert fires. Defaults to DefaultFor.
+	For time.Duration
+}
+
+// severities maps message levels to the conventional severity labels of Prometheus alerts.
+var severities = map[diag.Level]string{
+	diag.Error:   "critical",
+	diag.Warning: "warning",
+	diag.Info:    "info",
+}
+
+// Generate returns a PrometheusRule with one alerting rule per selected message type, as an unstructured object.
+func Generate(o Options) (map[string]interface{}, error) {
+	if o.Name == "" {
+		o.Name = DefaultName
+	}
+	if len(o.Levels) == 0 {
+		o.Levels = []diag.Level{diag.Error}
+	}
+	if o.For == 0 {
+		o.For = DefaultFor
+	}
+
+	types, err := selectTypes(o.Levels, o.Codes)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := make([]interface{}, 0, len(types))
+	for _, t := range types {
+		rules = append(rules, rule(t, o.For))
+	}
+
+	metadata := map[string]interface{}{
+		"name": o.Name,
+		"labels": map[string]interface{}{
+			"app": "istio",
+		},
+	}
+	if o.Namespace != "" {
+		metadata["namespace"] = o.Namespace
+	}
+
+	return map[string]interface{}{
+		"apiVersion": "monitoring.coreos.com/v1",
+		"kind":       "PrometheusRule",
+		"metadata":   metadata,
+		"spec": map[string]interface{}{
+			"groups": []interface{}{
+				map[string]interface{}{
+					"name":  "istio-config-analysis.rules",
+					"rules": rules,
+				},
+			},
+		},
+	}, nil
+}
+
+// GenerateYAML is like Generate, but renders the PrometheusRule as YAML.
+func GenerateYAML(o Options) ([]byte, error) {
+	r, err := Generate(o)
+	if err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(r)
+}
+
+// selectTypes returns the message types of the given levels, restricted to the given codes if any, sorted by code.
+func selectTypes(levels []diag.Level, codes []string) ([]*diag.MessageType, error) {
+	byCode := make(map[string]*diag.MessageType)
+	for _, t := range msg.All() {
+		byCode[t.Code()] = t
+	}
+
+	var unknown []string
+	for _, c := range codes {
+		if _, ok := byCode[c]; !ok {
+			unknown = append(unknown, c)
+		}
+	}
+	if len(unknown) > 0 {
+		return nil, fmt.Errorf("unknown message codes: %s", strings.Join(unknown, ", "))
+	}
+
+	allowed := make(map[string]bool)
+	for _, c := range codes {
+		allowed[c] = true
+	}
+
+	var result []*diag.MessageType
+	for code, t := range byCode {
+		if len(codes) > 0 && !allowed[code] {
+			continue
+		}
+		for _, l := range levels {
+			if t.Level() == l {
+				result = append(result, t)
+				break
+			}
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Code() < result[j].Code()
+	})
+	return result, nil
+}
+
+func rule(t *diag.MessageType, forDuration time.Duration) map[string]interface{} {
+	f := promDuration(forDuration)
+	return map[string]interface{}{
+		"alert": "IstioConfigAnalysis" + t.Code(),
+		"expr":  fmt.Sprintf(`sum(%s{code=%q,level=%q}) > 0`, Metric, t.Code(), t.Level()),
+		"for":   f,
+		"labels": map[string]interface{}{
+			"severity": severities[t.Level()],
+			"code":     t.Code(),
+		},
+		"annotations": map[string]interface{}{
+			"summary":     fmt.Sprintf("Istio config analysis reports %s messages", t.Code()),
+			"description": fmt.Sprintf("Config analysis has reported {{ $value }} %s %s message(s) for more than %s.",
+				t.Level(), t.Code(), f),
+			"runbook_url": t.DocumentationURL(),
+		},
+	}
+}
+
+// promDuration formats a duration the way Prometheus expects it, e.g. "10m" rather than "10m0s".
+func promDuration(d time.Duration) string {
+	switch {
+	case d%time.Hour == 0:
+		return fmt.Sprintf("%dh", d/time.Hour)
+	case d%time.Minute == 0:
+		return fmt.Sprintf("%dm", d/time.Minute)
+	default:
+		return fmt.Sprintf("%ds", d/time.Second)
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package alerts
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+)
+
+func rules(g *GomegaWithT, r map[string]interface{}) []interface{} {
+	groups := r["spec"].(map[string]interface{})["groups"].([]interface{})
+	g.Expect(groups).To(HaveLen(1))
+	return groups[0].(map[string]interface{})["rules"].([]interface{})
+}
+
+func TestGenerateDefaults(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	r, err := Generate(Options{})
+	g.Expect(err).To(BeNil())
+	g.Expect(r["kind"]).To(Equal("PrometheusRule"))
+	g.Expect(r["metadata"]).To(HaveKeyWithValue("name", DefaultName))
+	g.Expect(r["metadata"]).NotTo(HaveKey("namespace"))
+
+	var errors int
+	for _, t := range msg.All() {
+		if t.Level() == diag.Error {
+			errors++
+		}
+	}
+	rs := rules(g, r)
+	g.Expect(rs).To(HaveLen(errors))
+	for _, rule := range rs {
+		rule := rule.(map[string]interface{})
+		g.Expect(rule["for"]).To(Equal("10m"))
+		g.Expect(rule["labels"]).To(HaveKeyWithValue("severity", "critical"))
+	}
+}
+
+func TestGenerateCodes(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	code := msg.ReferencedResourceNotFound.Code()
+	r, err := Generate(Options{
+		Namespace: "istio-system",
+		Levels:    []diag.Level{diag.Error, diag.Warning},
+		Codes:     []string{code, msg.PortNameIsNotUnderNamingConvention.Code()},
+		For:       time.Hour,
+	})
+	g.Expect(err).To(BeNil())
+	g.Expect(r["metadata"]).To(HaveKeyWithValue("namespace", "istio-system"))
+
+	// PortNameIsNotUnderNamingConvention is an Info message, and so is not selected.
+	rs := rules(g, r)
+	g.Expect(rs).To(HaveLen(1))
+	rule := rs[0].(map[string]interface{})
+	g.Expect(rule["alert"]).To(Equal("IstioConfigAnalysis" + code))
+	g.Expect(rule["expr"]).To(Equal(`sum(galley_analysis_messages{code="` + code + `",level="Error"}) > 0`))
+	g.Expect(rule["for"]).To(Equal("1h"))
+	g.Expect(rule["annotations"]).To(HaveKeyWithValue("runbook_url", diag.DocPrefix+"/"+code))
+}
+
+func TestGenerateUnknownCode(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	_, err := Generate(Options{Codes: []string{"IST9999"}})
+	g.Expect(err).To(MatchError("unknown message codes: IST9999"))
+}
+
+func TestGenerateYAML(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	y, err := GenerateYAML(Options{For: 90 * time.Second})
+	g.Expect(err).To(BeNil())
+	g.Expect(string(y)).To(ContainSubstring("kind: PrometheusRule"))
+	g.Expect(string(y)).To(ContainSubstring("for: 90s"))
+}
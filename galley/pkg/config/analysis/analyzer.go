@@ -15,8 +15,16 @@
 package analysis
 
 import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"istio.io/istio/galley/pkg/config/analysis/diag"
 	"istio.io/istio/galley/pkg/config/processing/transformer"
 	"istio.io/istio/galley/pkg/config/scope"
+	"istio.io/istio/pkg/config/resource"
 	"istio.io/istio/pkg/config/schema/collection"
 )
 
@@ -26,10 +34,34 @@ type Analyzer interface {
 	Analyze(c Context)
 }
 
+// Configurable is implemented by analyzers that take parameters, e.g. thresholds, from the user's analysis
+// configuration.
+type Configurable interface {
+	// Configure sets the given parameters. Parameters that are not given keep their current values.
+	Configure(params map[string]string) error
+}
+
+// IncrementalAnalyzer is implemented by analyzers that analyze each resource of one of their inputs on its own: the
+// messages about a resource only depend on the resource itself and on the other inputs, and Analyze is equivalent to
+// calling AnalyzeResource for each resource of that input. When only resources of that input changed since the
+// previous run, a ResultCache re-evaluates just the changed resources instead of running the whole analyzer.
+type IncrementalAnalyzer interface {
+	Analyzer
+
+	// IncrementalInput returns the input collection whose resources are analyzed one by one.
+	IncrementalInput() collection.Name
+
+	// AnalyzeResource reports the messages about a single resource of the incremental input.
+	AnalyzeResource(c Context, r *resource.Instance)
+}
+
 // CombinedAnalyzer is a special Analyzer that combines multiple analyzers into one
 type CombinedAnalyzer struct {
-	name      string
-	analyzers []Analyzer
+	name        string
+	analyzers   []Analyzer
+	cache       *ResultCache
+	limits      Limits
+	parallelism int
 }
 
 // Combine multiple analyzers into a single one.
@@ -46,22 +78,199 @@ func (c *CombinedAnalyzer) Metadata() Metadata {
 	return Metadata{
 		Name:   c.name,
 		Inputs: combineInputs(c.analyzers),
+		Fields: combineFields(c.analyzers),
 	}
 }
 
+// SetResultCache sets a cache used to memoize the results of the component analyzers across runs. Analyzers whose
+// input collections are unchanged since the previous run will not be executed again, and IncrementalAnalyzers only
+// re-evaluate changed resources. A nil cache disables memoization.
+func (c *CombinedAnalyzer) SetResultCache(cache *ResultCache) {
+	c.cache = cache
+}
+
+// SetLimits sets the limits on the resources the component analyzers iterate over. Resources exceeding them are
+// reported with a ResourceTooLarge message instead of being analyzed. By default, there are no limits.
+func (c *CombinedAnalyzer) SetLimits(limits Limits) {
+	c.limits = limits
+}
+
+// SetParallelism sets how many of the component analyzers may run concurrently. Values below 2 run them one after the
+// other, which is the default. With parallelism, the Context the analyzers run with must be safe for concurrent use,
+// including any hooks it calls, such as collection reporters. The callbacks of RunOptions are never called
+// concurrently.
+func (c *CombinedAnalyzer) SetParallelism(n int) {
+	c.parallelism = n
+}
+
+// AnalyzerDoneFn is called each time an analyzer completes, with the name of the analyzer and the messages it reported.
+type AnalyzerDoneFn func(analyzer string, messages diag.Messages)
+
+// AnalyzerStatsFn is called each time an analyzer completes, with the name of the analyzer, the time it took and the
+// number of messages it reported.
+type AnalyzerStatsFn func(analyzer string, duration time.Duration, messages int)
+
+// RunOptions are optional settings for a single run of a CombinedAnalyzer.
+type RunOptions struct {
+	// Profile, if set, records per-analyzer time and allocation measurements.
+	Profile *Profile
+
+	// OnAnalyzerDone, if set, is called as each analyzer completes. This allows consumers to process findings while
+	// slower analyzers are still running. Messages are still reported to the Context as usual.
+	OnAnalyzerDone AnalyzerDoneFn
+
+	// OnAnalyzerStats, if set, is called as each analyzer completes. Unlike Profile, it does not measure allocations,
+	// so it is cheap enough to be used on every run, e.g. for metrics.
+	OnAnalyzerStats AnalyzerStatsFn
+
+	// Changed, if set, are the collections that changed since the previous run. Analyzers with any of them as input
+	// are run first, so that their up to date findings become available as early as possible.
+	Changed collection.Names
+}
+
+// Configure passes parameters to the component analyzers, by analyzer name. It fails for analyzers that are not part
+// of the combined analyzer or do not take parameters, so that misspelled names are not silently ignored.
+func (c *CombinedAnalyzer) Configure(params map[string]map[string]string) error {
+	byName := make(map[string]Analyzer, len(c.analyzers))
+	for _, a := range c.analyzers {
+		byName[a.Metadata().Name] = a
+	}
+
+	for name, p := range params {
+		a, ok := byName[name]
+		if !ok {
+			return fmt.Errorf("analyzer %q is not enabled", name)
+		}
+		ca, ok := a.(Configurable)
+		if !ok {
+			return fmt.Errorf("analyzer %q does not take parameters", name)
+		}
+		if err := ca.Configure(p); err != nil {
+			return fmt.Errorf("analyzer %q: %v", name, err)
+		}
+	}
+	return nil
+}
+
 // Analyze implements Analyzer
 func (c *CombinedAnalyzer) Analyze(ctx Context) {
-	for _, a := range c.analyzers {
-		scope.Analysis.Debugf("Started analyzer %q...", a.Metadata().Name)
+	c.AnalyzeWithOptions(ctx, RunOptions{})
+}
+
+// AnalyzeWithOptions runs the analysis with the given options.
+func (c *CombinedAnalyzer) AnalyzeWithOptions(ctx Context, o RunOptions) {
+	r := &analysisRun{ctx: ctx, o: o, cache: c.cache}
+	if o.Profile != nil {
+		r.pr = newProfiler(o.Profile)
+		defer r.pr.done()
+	}
+
+	if c.limits.enabled() {
+		r.limiter = newLimiter(ctx, c.limits)
+		r.ctx = r.limiter
+	}
+
+	analyzers := prioritize(c.analyzers, o.Changed)
+	if c.parallelism < 2 || len(analyzers) < 2 {
+		for _, a := range analyzers {
+			if ctx.Canceled() {
+				scope.Analysis.Debugf("Analyzer %q has been cancelled...", c.Metadata().Name)
+				return
+			}
+			r.analyze(a)
+		}
+		return
+	}
+
+	// The workers take the analyzers in order, so that prioritized analyzers still start first.
+	next := make(chan Analyzer)
+	var wg sync.WaitGroup
+	for i := 0; i < c.parallelism && i < len(analyzers); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for a := range next {
+				r.analyze(a)
+			}
+		}()
+	}
+	for _, a := range analyzers {
 		if ctx.Canceled() {
 			scope.Analysis.Debugf("Analyzer %q has been cancelled...", c.Metadata().Name)
-			return
+			break
 		}
-		a.Analyze(ctx)
-		scope.Analysis.Debugf("Completed analyzer %q...", a.Metadata().Name)
+		next <- a
+	}
+	close(next)
+	wg.Wait()
+}
+
+// analysisRun is a single run of a CombinedAnalyzer.
+type analysisRun struct {
+	ctx     Context
+	o       RunOptions
+	cache   *ResultCache
+	limiter *limiter
+	pr      *profiler
+
+	// callbackMu serializes the callbacks of concurrently running analyzers.
+	callbackMu sync.Mutex
+}
+
+func (r *analysisRun) analyze(a Analyzer) {
+	name := a.Metadata().Name
+	scope.Analysis.Debugf("Started analyzer %q...", name)
+
+	actx := r.ctx
+	var rctx *recordingContext
+	if r.o.OnAnalyzerDone != nil {
+		rctx = &recordingContext{Context: actx}
+		actx = rctx
+	}
+
+	var cctx *countingContext
+	if r.pr != nil || r.o.OnAnalyzerStats != nil {
+		cctx = &countingContext{Context: actx}
+		actx = cctx
+	}
+
+	var m *measurement
+	if r.pr != nil {
+		m = r.pr.begin(name)
+	}
+	start := time.Now()
+	if r.cache != nil {
+		r.cache.analyze(a, actx, r.limit)
+	} else {
+		a.Analyze(r.limit(actx))
+	}
+	duration := time.Since(start)
+	if r.pr != nil {
+		r.pr.end(m, cctx)
+	}
+	scope.Analysis.Debugf("Completed analyzer %q...", name)
+
+	if r.ctx.Canceled() {
+		return
+	}
+	r.callbackMu.Lock()
+	defer r.callbackMu.Unlock()
+	if r.o.OnAnalyzerStats != nil {
+		r.o.OnAnalyzerStats(name, duration, cctx.messages)
+	}
+	if rctx != nil {
+		r.o.OnAnalyzerDone(name, rctx.messages())
 	}
 }
 
+// limit returns the Context to give an analyzer, which hides the resources exceeding the limits of the run, if any.
+func (r *analysisRun) limit(ctx Context) Context {
+	if r.limiter == nil {
+		return ctx
+	}
+	return &limitingContext{Context: ctx, l: r.limiter}
+}
+
 // RemoveSkipped removes analyzers that should be skipped, meaning they meet one of the following criteria:
 // 1. The analyzer requires disabled input collections. The names of removed analyzers are returned.
 // Transformer information is used to determine, based on the disabled input collections, which output collections
@@ -111,6 +320,70 @@ func (c *CombinedAnalyzer) AnalyzerNames() []string {
 	return result
 }
 
+// Subset returns a new combined analyzer containing only the named analyzers, in their original order. If no names
+// are given, all analyzers are included. An error is returned if any of the names is unknown. The limits are carried
+// over, but the result cache is not.
+func (c *CombinedAnalyzer) Subset(names ...string) (*CombinedAnalyzer, error) {
+	if len(names) == 0 {
+		sub := Combine(c.name, c.analyzers...)
+		sub.limits = c.limits
+		return sub, nil
+	}
+
+	wanted := make(map[string]bool, len(names))
+	for _, n := range names {
+		wanted[n] = true
+	}
+
+	var selected []Analyzer
+	for _, a := range c.analyzers {
+		if wanted[a.Metadata().Name] {
+			selected = append(selected, a)
+			delete(wanted, a.Metadata().Name)
+		}
+	}
+
+	if len(wanted) > 0 {
+		var unknown []string
+		for n := range wanted {
+			unknown = append(unknown, n)
+		}
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("unknown analyzers: %s", strings.Join(unknown, ", "))
+	}
+
+	sub := Combine(c.name, selected...)
+	sub.limits = c.limits
+	return sub, nil
+}
+
+// prioritize returns the analyzers with any of the changed collections as input, followed by the others. The order
+// within each group is kept.
+func prioritize(analyzers []Analyzer, changed collection.Names) []Analyzer {
+	if len(changed) == 0 {
+		return analyzers
+	}
+
+	changedSet := make(map[collection.Name]struct{}, len(changed))
+	for _, n := range changed {
+		changedSet[n] = struct{}{}
+	}
+
+	result := make([]Analyzer, 0, len(analyzers))
+	var unaffected []Analyzer
+outer:
+	for _, a := range analyzers {
+		for _, in := range a.Metadata().Inputs {
+			if _, ok := changedSet[in]; ok {
+				result = append(result, a)
+				continue outer
+			}
+		}
+		unaffected = append(unaffected, a)
+	}
+	return append(result, unaffected...)
+}
+
 func combineInputs(analyzers []Analyzer) collection.Names {
 	result := make([]collection.Name, 0)
 	for _, a := range analyzers {
@@ -120,6 +393,21 @@ func combineInputs(analyzers []Analyzer) collection.Names {
 	return result
 }
 
+func combineFields(analyzers []Analyzer) []string {
+	seen := make(map[string]bool)
+	var result []string
+	for _, a := range analyzers {
+		for _, f := range a.Metadata().Fields {
+			if !seen[f] {
+				seen[f] = true
+				result = append(result, f)
+			}
+		}
+	}
+
+	return result
+}
+
 func getDisabledOutputs(disabledInputs collection.Names, xformProviders transformer.Providers) map[collection.Name]struct{} {
 	// Get disabledCollections as a set
 	disabledInputSet := make(map[collection.Name]struct{})
@@ -15,7 +15,10 @@
 package analysis
 
 import (
+	"errors"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	. "github.com/onsi/gomega"
 
@@ -89,6 +92,254 @@ func TestCombinedAnalyzer(t *testing.T) {
 	g.Expect(a4.ran).To(BeFalse())
 }
 
+func TestCombinedAnalyzerWithProfile(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	col1 := newSchema("col1")
+
+	a1 := &analyzer{name: "a1", inputs: collection.Names{col1.Name()}}
+	a2 := &analyzer{name: "a2", inputs: collection.Names{col1.Name()}}
+
+	p := NewProfile()
+	p.SetCollectionCount(col1.Name(), 3)
+
+	a := Combine("combined", a1, a2)
+	a.AnalyzeWithOptions(&context{}, RunOptions{Profile: p})
+
+	g.Expect(a1.ran).To(BeTrue())
+	g.Expect(a2.ran).To(BeTrue())
+	g.Expect(p.Analyzers).To(HaveLen(2))
+	g.Expect(p.Analyzers[0].Name).To(Equal("a1"))
+	g.Expect(p.Analyzers[1].Name).To(Equal("a2"))
+	g.Expect(p.PeakHeapBytes).To(BeNumerically(">", 0))
+	g.Expect(p.TotalEntries()).To(Equal(3))
+	g.Expect(p.String()).To(ContainSubstring("col1"))
+}
+
+func TestCombinedAnalyzerProfileCounts(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	col1 := newSchema("col1")
+	col2 := newSchema("col2")
+
+	a1 := &reportingAnalyzer{name: "a1", inputs: collection.Names{col1.Name()}}
+	a2 := &reportingAnalyzer{name: "a2", inputs: collection.Names{col2.Name()}}
+	ctx := &resourceContext{resources: map[collection.Name][]*resource.Instance{
+		col1.Name(): {newInstance("r1", "v1"), newInstance("r2", "v1")},
+	}}
+
+	p := NewProfile()
+	Combine("combined", a1, a2).AnalyzeWithOptions(ctx, RunOptions{Profile: p})
+
+	g.Expect(p.Started.IsZero()).To(BeFalse())
+	g.Expect(p.Analyzers).To(HaveLen(2))
+	g.Expect(p.Analyzers[0].Resources).To(Equal(2))
+	g.Expect(p.Analyzers[0].Messages).To(Equal(2))
+	g.Expect(p.Analyzers[1].Resources).To(Equal(0))
+	g.Expect(p.Analyzers[1].Messages).To(Equal(0))
+	g.Expect(ctx.reports).To(HaveLen(2))
+
+	timings := p.Timings()
+	g.Expect(timings).To(ContainSubstring("Analyzer timings"))
+	g.Expect(timings).To(MatchRegexp(`a1\s+\S+\s+2 resources\s+2 messages`))
+}
+
+func TestCombinedAnalyzerSubset(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	col1 := newSchema("col1")
+
+	a1 := &analyzer{name: "a1", inputs: collection.Names{col1.Name()}}
+	a2 := &analyzer{name: "a2", inputs: collection.Names{col1.Name()}}
+	a3 := &analyzer{name: "a3", inputs: collection.Names{col1.Name()}}
+	a := Combine("combined", a1, a2, a3)
+
+	sub, err := a.Subset("a3", "a1")
+	g.Expect(err).To(BeNil())
+	g.Expect(sub.AnalyzerNames()).To(Equal([]string{"a1", "a3"}))
+
+	sub, err = a.Subset()
+	g.Expect(err).To(BeNil())
+	g.Expect(sub.AnalyzerNames()).To(Equal([]string{"a1", "a2", "a3"}))
+
+	_, err = a.Subset("a1", "b2", "b1")
+	g.Expect(err).To(MatchError("unknown analyzers: b1, b2"))
+}
+
+type configurableAnalyzer struct {
+	analyzer
+	params map[string]string
+}
+
+// Configure implements Configurable
+func (a *configurableAnalyzer) Configure(params map[string]string) error {
+	if _, ok := params["invalid"]; ok {
+		return errors.New("invalid parameter")
+	}
+	a.params = params
+	return nil
+}
+
+func TestCombinedAnalyzerConfigure(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	col1 := newSchema("col1")
+	a1 := &configurableAnalyzer{analyzer: analyzer{name: "a1", inputs: collection.Names{col1.Name()}}}
+	a2 := &analyzer{name: "a2", inputs: collection.Names{col1.Name()}}
+	a := Combine("combined", a1, a2)
+
+	g.Expect(a.Configure(nil)).To(Succeed())
+	g.Expect(a.Configure(map[string]map[string]string{"a1": {"threshold": "1"}})).To(Succeed())
+	g.Expect(a1.params).To(Equal(map[string]string{"threshold": "1"}))
+
+	g.Expect(a.Configure(map[string]map[string]string{"a1": {"invalid": ""}})).To(MatchError(ContainSubstring("invalid parameter")))
+	g.Expect(a.Configure(map[string]map[string]string{"a2": {}})).To(MatchError(ContainSubstring("does not take parameters")))
+	g.Expect(a.Configure(map[string]map[string]string{"a3": {}})).To(MatchError(ContainSubstring("not enabled")))
+}
+
+func TestAnalyzeWithOptionsStreamsResults(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	col1 := newSchema("col1")
+	col2 := newSchema("col2")
+
+	a1 := &reportingAnalyzer{name: "a1", inputs: collection.Names{col1.Name()}}
+	a2 := &reportingAnalyzer{name: "a2", inputs: collection.Names{col2.Name()}}
+
+	ctx := &resourceContext{
+		resources: map[collection.Name][]*resource.Instance{
+			col1.Name(): {newInstance("r1", "v1"), newInstance("r2", "v1")},
+			col2.Name(): {newInstance("r3", "v1")},
+		},
+	}
+
+	var done []string
+	counts := make(map[string]int)
+	a := Combine("combined", a1, a2)
+	a.AnalyzeWithOptions(ctx, RunOptions{
+		OnAnalyzerDone: func(analyzer string, messages diag.Messages) {
+			done = append(done, analyzer)
+			counts[analyzer] = len(messages)
+		},
+	})
+
+	g.Expect(done).To(Equal([]string{"a1", "a2"}))
+	g.Expect(counts).To(Equal(map[string]int{"a1": 2, "a2": 1}))
+	g.Expect(ctx.reports).To(HaveLen(3))
+}
+
+func TestAnalyzeWithOptionsPrioritizesChanged(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	col1 := newSchema("col1")
+	col2 := newSchema("col2")
+	col3 := newSchema("col3")
+
+	a1 := &analyzer{name: "a1", inputs: collection.Names{col1.Name()}}
+	a2 := &analyzer{name: "a2", inputs: collection.Names{col2.Name()}}
+	a3 := &analyzer{name: "a3", inputs: collection.Names{col1.Name(), col3.Name()}}
+	a4 := &analyzer{name: "a4", inputs: collection.Names{col3.Name()}}
+	a := Combine("combined", a1, a2, a3, a4)
+
+	run := func(changed ...collection.Name) []string {
+		var done []string
+		a.AnalyzeWithOptions(&context{}, RunOptions{
+			Changed: changed,
+			OnAnalyzerDone: func(analyzer string, _ diag.Messages) {
+				done = append(done, analyzer)
+			},
+		})
+		return done
+	}
+
+	g.Expect(run()).To(Equal([]string{"a1", "a2", "a3", "a4"}))
+	g.Expect(run(col3.Name())).To(Equal([]string{"a3", "a4", "a1", "a2"}))
+	g.Expect(run(col2.Name(), col3.Name())).To(Equal([]string{"a2", "a3", "a4", "a1"}))
+	g.Expect(run(newSchema("other").Name())).To(Equal([]string{"a1", "a2", "a3", "a4"}))
+}
+
+type concurrentAnalyzer struct {
+	analyzer
+	running    *int32
+	maxRunning *int32
+}
+
+// Analyze implements Analyzer
+func (a *concurrentAnalyzer) Analyze(ctx Context) {
+	n := atomic.AddInt32(a.running, 1)
+	defer atomic.AddInt32(a.running, -1)
+	for {
+		max := atomic.LoadInt32(a.maxRunning)
+		if n <= max || atomic.CompareAndSwapInt32(a.maxRunning, max, n) {
+			break
+		}
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	ctx.Report(collection.NewName("col1"), diag.NewMessage(testMessageType, nil))
+	a.ran = true
+}
+
+func TestAnalyzeWithOptionsParallelism(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	var running, maxRunning int32
+	var analyzers []Analyzer
+	var all []*concurrentAnalyzer
+	for _, name := range []string{"a1", "a2", "a3", "a4"} {
+		a := &concurrentAnalyzer{analyzer: analyzer{name: name}, running: &running, maxRunning: &maxRunning}
+		analyzers = append(analyzers, a)
+		all = append(all, a)
+	}
+	a := Combine("combined", analyzers...)
+	a.SetParallelism(2)
+
+	// Callbacks are not called concurrently, so they need no locking
+	var done []string
+	messages := make(map[string]int)
+	var durations []time.Duration
+	p := NewProfile()
+	a.AnalyzeWithOptions(&context{}, RunOptions{
+		Profile: p,
+		OnAnalyzerDone: func(analyzer string, _ diag.Messages) {
+			done = append(done, analyzer)
+		},
+		OnAnalyzerStats: func(analyzer string, duration time.Duration, n int) {
+			messages[analyzer] = n
+			durations = append(durations, duration)
+		},
+	})
+
+	for _, a := range all {
+		g.Expect(a.ran).To(BeTrue())
+	}
+	g.Expect(atomic.LoadInt32(&maxRunning)).To(Equal(int32(2)))
+	g.Expect(done).To(ConsistOf("a1", "a2", "a3", "a4"))
+	g.Expect(messages).To(Equal(map[string]int{"a1": 1, "a2": 1, "a3": 1, "a4": 1}))
+	for _, d := range durations {
+		g.Expect(d).To(BeNumerically(">=", 50*time.Millisecond))
+	}
+	g.Expect(p.Analyzers).To(HaveLen(4))
+}
+
+func TestAnalyzeWithOptionsStats(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	col1 := newSchema("col1")
+	a1 := &reportingAnalyzer{name: "a1", inputs: collection.Names{col1.Name()}}
+	ctx := &resourceContext{resources: map[collection.Name][]*resource.Instance{
+		col1.Name(): {newInstance("r1", "v1"), newInstance("r2", "v1")},
+	}}
+
+	messages := make(map[string]int)
+	Combine("combined", a1).AnalyzeWithOptions(ctx, RunOptions{
+		OnAnalyzerStats: func(analyzer string, _ time.Duration, n int) {
+			messages[analyzer] = n
+		},
+	})
+	g.Expect(messages).To(Equal(map[string]int{"a1": 2}))
+}
+
 func TestGetDisabledOutputs(t *testing.T) {
 	g := NewGomegaWithT(t)
 
@@ -15,17 +15,31 @@
 package analyzers
 
 import (
+	"fmt"
+
 	"istio.io/istio/galley/pkg/config/analysis"
 	"istio.io/istio/galley/pkg/config/analysis/analyzers/annotations"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/auth"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/controlplane"
 	"istio.io/istio/galley/pkg/config/analysis/analyzers/deployment"
 	"istio.io/istio/galley/pkg/config/analysis/analyzers/deprecation"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/destinationrule"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/envoyfilter"
 	"istio.io/istio/galley/pkg/config/analysis/analyzers/gateway"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/gatewayapi"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/hostname"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/ingress"
 	"istio.io/istio/galley/pkg/config/analysis/analyzers/injection"
 	"istio.io/istio/galley/pkg/config/analysis/analyzers/multicluster"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/rootnamespace"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/scale"
 	"istio.io/istio/galley/pkg/config/analysis/analyzers/schema"
 	"istio.io/istio/galley/pkg/config/analysis/analyzers/service"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/serviceentry"
 	"istio.io/istio/galley/pkg/config/analysis/analyzers/sidecar"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/unreferenced"
 	"istio.io/istio/galley/pkg/config/analysis/analyzers/virtualservice"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/workload"
 )
 
 // All returns all analyzers
@@ -33,21 +47,69 @@ func All() []analysis.Analyzer {
 	analyzers := []analysis.Analyzer{
 		// Please keep this list sorted alphabetically by pkg.name for convenience
 		&annotations.K8sAnalyzer{},
+		&auth.AuthorizationPoliciesAnalyzer{},
+		&auth.MTLSAnalyzer{},
+		&auth.RequestAuthenticationAnalyzer{},
+		&auth.TrustDomainAnalyzer{},
+		&controlplane.RevisionAnalyzer{},
+		&controlplane.RootCertAnalyzer{},
+		&controlplane.SkewAnalyzer{},
+		&controlplane.WebhookAnalyzer{},
 		&deployment.ServiceAssociationAnalyzer{},
+		&deprecation.AnnotationAnalyzer{},
 		&deprecation.FieldAnalyzer{},
+		&destinationrule.LocalityLoadBalancingAnalyzer{},
+		&destinationrule.OutlierDetectionAnalyzer{},
+		&destinationrule.TrafficPolicyAnalyzer{},
+		&envoyfilter.ConflictingPatchAnalyzer{},
+		&envoyfilter.PatchMatchAnalyzer{},
+		&envoyfilter.SelectorAnalyzer{},
+		&gateway.ConflictingServersAnalyzer{},
 		&gateway.IngressGatewayPortAnalyzer{},
+		&gateway.PushedSecretsAnalyzer{},
+		&gateway.SNICollisionAnalyzer{},
 		&gateway.SecretAnalyzer{},
+		&gatewayapi.GatewayAnalyzer{},
+		&gatewayapi.RouteAnalyzer{},
+		&gatewayapi.SecretAnalyzer{},
+		&hostname.NormalizationAnalyzer{},
+		&ingress.BackendAnalyzer{},
+		&ingress.ClassAnalyzer{},
+		&ingress.HostConflictAnalyzer{},
+		&ingress.SecretAnalyzer{},
 		&injection.Analyzer{},
 		&injection.ImageAnalyzer{},
+		&injection.NonMeshNamespaceAnalyzer{},
+		&injection.ProxyVersionAnalyzer{},
 		&multicluster.MeshNetworksAnalyzer{},
+		&multicluster.MeshNetworksConsistencyAnalyzer{},
+		&multicluster.RemoteServiceAnalyzer{},
+		&schema.BoundsAnalyzer{},
+		&service.AppProtocolAnalyzer{},
 		&service.PortNameAnalyzer{},
+		&service.PortProtocolAnalyzer{},
+		&serviceentry.EndpointAddressAnalyzer{},
+		&serviceentry.InterceptionAnalyzer{},
+		&serviceentry.OverlapAnalyzer{},
 		&sidecar.DefaultSelectorAnalyzer{},
+		&sidecar.EgressHostAnalyzer{},
+		&sidecar.IngressPortAnalyzer{},
+		&sidecar.RegistryOnlyAnalyzer{},
 		&sidecar.SelectorAnalyzer{},
+		&unreferenced.Analyzer{},
 		&virtualservice.ConflictingMeshGatewayHostsAnalyzer{},
 		&virtualservice.DestinationHostAnalyzer{},
 		&virtualservice.DestinationRuleAnalyzer{},
+		&virtualservice.ExportToAnalyzer{},
 		&virtualservice.GatewayAnalyzer{},
+		&virtualservice.GatewayRouteConflictAnalyzer{},
+		&virtualservice.HeaderMatchAnalyzer{},
+		&virtualservice.PushedRoutesAnalyzer{},
 		&virtualservice.RegexAnalyzer{},
+		&virtualservice.ResilienceAnalyzer{},
+		&virtualservice.TLSRouteAnalyzer{},
+		&virtualservice.TimeoutAnalyzer{},
+		&workload.SelectorAnalyzer{},
 	}
 
 	analyzers = append(analyzers, schema.AllValidationAnalyzers()...)
@@ -55,6 +117,63 @@ func All() []analysis.Analyzer {
 	return analyzers
 }
 
+// Optional returns the analyzers that are not run by default, e.g. because they are based on heuristics that also
+// match deliberate configurations. They need to be enabled explicitly.
+func Optional() []analysis.Analyzer {
+	return []analysis.Analyzer{
+		// Please keep this list sorted alphabetically by pkg.name for convenience
+		&destinationrule.ConnectionPoolAnalyzer{},
+		&gateway.ExposureAnalyzer{},
+		&rootnamespace.MeshWideAnalyzer{},
+		&scale.ConfigSizeAnalyzer{},
+	}
+}
+
+// Select returns the analyzers of All without the disabled ones, and with the enabled analyzers of Optional. This allows
+// conservative environments to roll out analysis one analyzer at a time. An error is returned for names that are not
+// analyzers of All or Optional, so that misspelled names are not silently ignored. Disabling takes precedence over
+// enabling.
+func Select(enable, disable []string) ([]analysis.Analyzer, error) {
+	all := All()
+	known := make(map[string]bool)
+	optional := make(map[string]analysis.Analyzer)
+	for _, a := range all {
+		known[a.Metadata().Name] = true
+	}
+	for _, a := range Optional() {
+		known[a.Metadata().Name] = true
+		optional[a.Metadata().Name] = a
+	}
+
+	disabled := make(map[string]bool, len(disable))
+	for _, name := range disable {
+		if !known[name] {
+			return nil, fmt.Errorf("%s is not an analyzer", name)
+		}
+		disabled[name] = true
+	}
+
+	var result []analysis.Analyzer
+	for _, a := range all {
+		if !disabled[a.Metadata().Name] {
+			result = append(result, a)
+		}
+	}
+	for _, name := range enable {
+		a, ok := optional[name]
+		if !ok {
+			return nil, fmt.Errorf("%s is not an optional analyzer", name)
+		}
+		if disabled[name] {
+			continue
+		}
+		// Enabling an analyzer more than once has no effect.
+		disabled[name] = true
+		result = append(result, a)
+	}
+	return result, nil
+}
+
 // AllCombined returns all analyzers combined as one
 func AllCombined() *analysis.CombinedAnalyzer {
 	return analysis.Combine("all", All()...)
@@ -22,6 +22,7 @@ import (
 
 	"istio.io/istio/galley/pkg/config/analysis"
 	"istio.io/istio/galley/pkg/config/analysis/diag"
+	"istio.io/istio/galley/pkg/config/analysis/testing/fixtures"
 	coll "istio.io/istio/galley/pkg/config/collection"
 	"istio.io/istio/pkg/config/resource"
 	"istio.io/istio/pkg/config/schema"
@@ -108,6 +109,31 @@ func BenchmarkAnalyzers(b *testing.B) {
 	}
 }
 
+func BenchmarkAnalyzersTopology10(b *testing.B) {
+	benchmarkAnalyzersTopology(fixtures.Topology{Namespaces: 10, Services: 5, Versions: 2, Replicas: 2, Gateways: 2}, b)
+}
+
+func BenchmarkAnalyzersTopology50(b *testing.B) {
+	benchmarkAnalyzersTopology(fixtures.Topology{Namespaces: 50, Services: 5, Versions: 2, Replicas: 2, Gateways: 10}, b)
+}
+
+// Benchmark all analyzers against a generated topology, which is closer to a real cluster than blank data.
+func benchmarkAnalyzersTopology(t fixtures.Topology, b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		sa, err := setupAnalyzerForTopology(t, AllCombined())
+		if err != nil {
+			b.Fatalf("Error setting up analysis for benchmark: %v", err)
+		}
+		b.StartTimer()
+
+		_, err = runAnalyzer(sa)
+		if err != nil {
+			b.Fatalf("Error running analysis for benchmark: %v", err)
+		}
+	}
+}
+
 func BenchmarkAnalyzersArtificialBlankData100(b *testing.B) {
 	benchmarkAnalyzersArtificialBlankData(100, b)
 }
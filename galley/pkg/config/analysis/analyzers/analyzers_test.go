@@ -16,7 +16,6 @@ package analyzers
 
 import (
 	"fmt"
-	"os"
 	"regexp"
 	"strings"
 	"testing"
@@ -24,24 +23,33 @@ import (
 
 	. "github.com/onsi/gomega"
 
-	"istio.io/pkg/log"
-
 	"istio.io/istio/galley/pkg/config/analysis"
 	"istio.io/istio/galley/pkg/config/analysis/analyzers/annotations"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/auth"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/controlplane"
 	"istio.io/istio/galley/pkg/config/analysis/analyzers/deployment"
 	"istio.io/istio/galley/pkg/config/analysis/analyzers/deprecation"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/destinationrule"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/envoyfilter"
 	"istio.io/istio/galley/pkg/config/analysis/analyzers/gateway"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/gatewayapi"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/hostname"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/ingress"
 	"istio.io/istio/galley/pkg/config/analysis/analyzers/injection"
 	"istio.io/istio/galley/pkg/config/analysis/analyzers/multicluster"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/rootnamespace"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/scale"
+	schemaanalyzer "istio.io/istio/galley/pkg/config/analysis/analyzers/schema"
 	"istio.io/istio/galley/pkg/config/analysis/analyzers/service"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/serviceentry"
 	"istio.io/istio/galley/pkg/config/analysis/analyzers/sidecar"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/unreferenced"
 	"istio.io/istio/galley/pkg/config/analysis/analyzers/virtualservice"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/workload"
 	"istio.io/istio/galley/pkg/config/analysis/diag"
-	"istio.io/istio/galley/pkg/config/analysis/local"
 	"istio.io/istio/galley/pkg/config/analysis/msg"
-	"istio.io/istio/galley/pkg/config/processing/snapshotter"
-	"istio.io/istio/galley/pkg/config/scope"
-	"istio.io/istio/pkg/config/schema"
+	"istio.io/istio/galley/pkg/config/analysis/testing/analysistest"
+	"istio.io/istio/galley/pkg/config/analysis/testing/fixtures"
 	"istio.io/istio/pkg/config/schema/collection"
 )
 
@@ -50,6 +58,10 @@ type message struct {
 	origin      string
 }
 
+// referencedResourceNotFoundWarning is ReferencedResourceNotFound, reported as a warning
+var referencedResourceNotFoundWarning = diag.NewMessageType(diag.Warning, msg.ReferencedResourceNotFound.Code(),
+	msg.ReferencedResourceNotFound.Template())
+
 type testCase struct {
 	name             string
 	inputFiles       []string
@@ -57,6 +69,13 @@ type testCase struct {
 	meshNetworksFile string // Optional
 	analyzer         analysis.Analyzer
 	expected         []message
+
+	// Optional, for multi-cluster analysis: the input files and mesh networks file of each cluster
+	clusterInputFiles        map[string][]string
+	clusterMeshNetworksFiles map[string]string
+
+	// Optional, for analyzers of the configuration pushed to proxies: the Envoy config dumps of the proxies
+	configDumpFiles []string
 }
 
 // Some notes on setting up tests for Analyzers:
@@ -80,12 +99,127 @@ var testGrid = []testCase{
 			{msg.MisplacedAnnotation, "Namespace staging"},
 		},
 	},
+	{
+		name:       "destinationRuleConnectionPool",
+		inputFiles: []string{"testdata/destinationrule-connection-pool.yaml"},
+		analyzer:   &destinationrule.ConnectionPoolAnalyzer{},
+		expected: []message{
+			{msg.ConnectionPoolRisk, "DestinationRule grpc-one-request.default"},
+			{msg.ConnectionPoolRisk, "DestinationRule grpc-one-request.default"},
+			{msg.ConnectionPoolRisk, "DestinationRule upgrade.default"},
+			{msg.ConnectionPoolRisk, "DestinationRule small-queue.default"},
+		},
+	},
+	{
+		name:       "destinationRuleOutlierDetection",
+		inputFiles: []string{"testdata/destinationrule-outlier-detection.yaml"},
+		analyzer:   &destinationrule.OutlierDetectionAnalyzer{},
+		expected: []message{
+			{msg.AggressiveOutlierDetection, "DestinationRule single-error.default"},
+			{msg.AggressiveOutlierDetection, "DestinationRule eject-all.default"},
+			{msg.AggressiveOutlierDetection, "DestinationRule long-ejection.default"},
+			{msg.AggressiveOutlierDetection, "DestinationRule long-ejection.default"},
+		},
+	},
+	{
+		name:       "destinationRuleTrafficPolicy",
+		inputFiles: []string{"testdata/destinationrule-traffic-policy.yaml"},
+		analyzer:   &destinationrule.TrafficPolicyAnalyzer{},
+		expected: []message{
+			{msg.ZeroTrafficPolicyDuration, "DestinationRule zero-connect-timeout.default"},
+			{msg.ZeroTrafficPolicyDuration, "DestinationRule zero-outlier-durations.default"},
+			{msg.ZeroTrafficPolicyDuration, "DestinationRule zero-outlier-durations.default"},
+			{msg.IstioMutualWithCertificates, "DestinationRule istio-mutual-certs.default"},
+			{msg.IstioMutualWithCertificates, "DestinationRule istio-mutual-certs.default"},
+			{msg.SubsetTLSModeContradiction, "DestinationRule subset-tls-contradiction.default"},
+		},
+	},
+	{
+		name:       "mtlsModeMismatch",
+		inputFiles: []string{"testdata/mtls-mode-mismatch.yaml"},
+		analyzer:   &auth.MTLSAnalyzer{},
+		expected: []message{
+			{msg.MTLSModeMismatch, "DestinationRule reviews.default"},
+			{msg.MTLSModeMismatch, "DestinationRule db.default"},
+		},
+	},
+	{
+		name:           "mtlsModeMismatchAutoMtlsDisabled",
+		inputFiles:     []string{"testdata/mtls-auto-mtls-disabled.yaml"},
+		meshConfigFile: "testdata/mesh-auto-mtls-disabled.yaml",
+		analyzer:       &auth.MTLSAnalyzer{},
+		expected: []message{
+			{msg.MTLSModeMismatch, "DestinationRule reviews.default"},
+		},
+	},
+	{
+		name:       "authorizationPolicies",
+		inputFiles: []string{"testdata/authorizationpolicies.yaml"},
+		analyzer:   &auth.AuthorizationPoliciesAnalyzer{},
+		expected: []message{
+			{msg.ReferencedResourceNotFound, "AuthorizationPolicy ratings.default"},
+			{referencedResourceNotFoundWarning, "AuthorizationPolicy reviews-typos.default"},
+			{msg.ReferencedResourceNotFound, "AuthorizationPolicy reviews-typos.default"},
+			{msg.AuthorizationPolicyDeniesAll, "AuthorizationPolicy allow-nothing.frontend"},
+		},
+	},
+	{
+		name:       "requestAuthentication",
+		inputFiles: []string{"testdata/requestauthentication.yaml"},
+		analyzer:   &auth.RequestAuthenticationAnalyzer{},
+		expected: []message{
+			{msg.DuplicateJwtIssuer, "RequestAuthentication default.istio-system"},
+			{msg.DuplicateJwtIssuer, "RequestAuthentication productpage.default"},
+			{msg.InsecureJwksURI, "RequestAuthentication reviews.default"},
+			{msg.InvalidJwksURI, "RequestAuthentication reviews.default"},
+			{msg.ReferencedResourceNotFound, "RequestAuthentication ratings.default"},
+		},
+	},
 	{
 		name:       "deprecation",
 		inputFiles: []string{"testdata/deprecation.yaml"},
 		analyzer:   &deprecation.FieldAnalyzer{},
 		expected: []message{
 			{msg.Deprecated, "VirtualService productpage.foo"},
+			{msg.Deprecated, "DestinationRule reviews.foo"},
+		},
+	},
+	{
+		name:       "deprecationAnnotations",
+		inputFiles: []string{"testdata/deprecation.yaml"},
+		analyzer:   &deprecation.AnnotationAnalyzer{},
+		expected: []message{
+			{msg.Deprecated, "Service vm-app.foo"},
+		},
+	},
+	{
+		name:       "envoyFilterConflictingPatches",
+		inputFiles: []string{"testdata/envoyfilter-conflicting-patches.yaml"},
+		analyzer:   &envoyfilter.ConflictingPatchAnalyzer{},
+		expected: []message{
+			{msg.ConflictingEnvoyFilterPatches, "EnvoyFilter lua-a.default"},
+			{msg.ConflictingEnvoyFilterPatches, "EnvoyFilter lua-b.default"},
+		},
+	},
+	{
+		name:       "envoyFilterPatchMatch",
+		inputFiles: []string{"testdata/envoyfilter-patch-match.yaml"},
+		analyzer:   &envoyfilter.PatchMatchAnalyzer{},
+		expected: []message{
+			{msg.EnvoyFilterRenamedMatch, "EnvoyFilter lua-deprecated.default"},
+			{msg.EnvoyFilterRenamedMatch, "EnvoyFilter lua-deprecated.default"},
+			{msg.EnvoyFilterRelativePatchWithoutMatch, "EnvoyFilter lua-append.default"},
+			{msg.EnvoyFilterRenamedMatch, "EnvoyFilter tap.default"},
+			{msg.EnvoyFilterRelativePatchWithoutMatch, "EnvoyFilter tap.default"},
+		},
+	},
+	{
+		name:       "envoyFilterSelector",
+		inputFiles: []string{"testdata/envoyfilter-selector.yaml"},
+		analyzer:   &envoyfilter.SelectorAnalyzer{},
+		expected: []message{
+			{msg.UnmatchedWorkloadSelector, "EnvoyFilter reviews-v2.default"},
+			{msg.UnmatchedWorkloadSelector, "EnvoyFilter reviews-other.other"},
 		},
 	},
 	{
@@ -136,6 +270,17 @@ var testGrid = []testCase{
 			{msg.GatewayPortNotOnWorkload, "Gateway httpbin8002-gateway"},
 		},
 	},
+	{
+		name:       "gatewayConflictingServers",
+		inputFiles: []string{"testdata/gateway-conflicting-servers.yaml"},
+		analyzer:   &gateway.ConflictingServersAnalyzer{},
+		expected: []message{
+			{msg.ConflictingGatewayServers, "Gateway web.default"},
+			{msg.ConflictingGatewayServers, "Gateway shop.shop"},
+			{msg.ConflictingGatewayServers, "Gateway web.default"},
+			{msg.ConflictingGatewayServers, "Gateway legacy.default"},
+		},
+	},
 	{
 		name:       "gatewaySecret",
 		inputFiles: []string{"testdata/gateway-secrets.yaml"},
@@ -144,6 +289,52 @@ var testGrid = []testCase{
 			{msg.ReferencedResourceNotFound, "Gateway defaultgateway-bogusCredentialName"},
 			{msg.ReferencedResourceNotFound, "Gateway customgateway-wrongnamespace"},
 			{msg.ReferencedResourceNotFound, "Gateway bogusgateway"},
+			{msg.ReferencedResourceNotFound, "Gateway defaultgateway-unqualified.httpbin"},
+			{msg.GatewaySecretNotFound, "Gateway multinamespacegateway"},
+			{msg.InvalidGatewayCredential, "Gateway defaultgateway-expired"},
+			{msg.InvalidGatewayCredential, "Gateway defaultgateway-mismatched"},
+			{msg.InvalidGatewayCredential, "Gateway defaultgateway-malformed"},
+			{msg.InvalidGatewayCredential, "Gateway defaultgateway-keyless"},
+		},
+	},
+	{
+		// The valid certificates expire in 2120
+		name:       "gatewaySecretExpiring",
+		inputFiles: []string{"testdata/gateway-secrets.yaml"},
+		analyzer:   &gateway.SecretAnalyzer{ExpiryWindow: 200 * 365 * 24 * time.Hour},
+		expected: []message{
+			{msg.ReferencedResourceNotFound, "Gateway defaultgateway-bogusCredentialName"},
+			{msg.ReferencedResourceNotFound, "Gateway customgateway-wrongnamespace"},
+			{msg.ReferencedResourceNotFound, "Gateway bogusgateway"},
+			{msg.ReferencedResourceNotFound, "Gateway defaultgateway-unqualified.httpbin"},
+			{msg.GatewaySecretNotFound, "Gateway multinamespacegateway"},
+			{msg.GatewayCertificateExpiring, "Gateway defaultgateway-noerrors"},
+			{msg.InvalidGatewayCredential, "Gateway defaultgateway-expired"},
+			{msg.InvalidGatewayCredential, "Gateway defaultgateway-mismatched"},
+			{msg.InvalidGatewayCredential, "Gateway defaultgateway-malformed"},
+			{msg.InvalidGatewayCredential, "Gateway defaultgateway-keyless"},
+		},
+	},
+	{
+		name:       "gatewayExposure",
+		inputFiles: []string{"testdata/gateway-exposure.yaml"},
+		analyzer:   &gateway.ExposureAnalyzer{},
+		expected: []message{
+			{msg.UnprotectedGatewayExposure, "Gateway public.default"},
+		},
+	},
+	{
+		name:       "hostnameNormalization",
+		inputFiles: []string{"testdata/hostname-normalization.yaml"},
+		analyzer:   &hostname.NormalizationAnalyzer{},
+		expected: []message{
+			{msg.InconsistentHostForm, "VirtualService reviews.default"},
+			{msg.HostNotCanonical, "ServiceEntry trailing-dot.default"},
+			{msg.HostNotCanonical, "ServiceEntry upper-case.default"},
+			{msg.InvalidHostCharacters, "DestinationRule underscore.default"},
+			{msg.InvalidHostLength, "VirtualService long-label.default"},
+			{msg.InvalidHostCharacters, "Gateway underscore.default"},
+			{msg.InvalidHostCharacters, "Service legacy-db.default"},
 		},
 	},
 	{
@@ -154,6 +345,24 @@ var testGrid = []testCase{
 			{msg.NamespaceNotInjected, "Namespace bar"},
 			{msg.PodMissingProxy, "Pod noninjectedpod.default"},
 			{msg.NamespaceMultipleInjectionLabels, "Namespace busted"},
+			{msg.PodProxyInNonInjectedNamespace, "Pod stale-injectedpod.bar"},
+			{msg.PodProxyInNonInjectedNamespace, "Pod disabled-injectedpod.foo"},
+		},
+	},
+	{
+		name:       "istioInjectionEnabledByDefault",
+		inputFiles: []string{"testdata/injection-enabled-by-default.yaml"},
+		analyzer:   &injection.Analyzer{},
+		expected: []message{
+			{msg.PodMissingProxy, "Pod noninjectedpod.default"},
+		},
+	},
+	{
+		name:       "istioInjectionNonMeshNamespace",
+		inputFiles: []string{"testdata/injection-non-mesh-namespace.yaml"},
+		analyzer:   &injection.NonMeshNamespaceAnalyzer{},
+		expected: []message{
+			{msg.IstioConfigInNonMeshNamespace, "Namespace wrong"},
 		},
 	},
 	{
@@ -167,6 +376,36 @@ var testGrid = []testCase{
 			{msg.IstioProxyImageMismatch, "Pod details-v1-pod-old.enabled-namespace"},
 		},
 	},
+	{
+		name: "istioInjectionProxyVersion",
+		inputFiles: []string{
+			"testdata/injection-proxy-version.yaml",
+			"testdata/common/sidecar-injector-configmap.yaml",
+		},
+		analyzer: &injection.ProxyVersionAnalyzer{},
+		expected: []message{
+			{msg.ProxyVersionSkew, "Pod stale.default"},
+		},
+	},
+	{
+		name:       "serviceAppProtocol",
+		inputFiles: []string{"testdata/service-app-protocol.yaml"},
+		analyzer:   &service.AppProtocolAnalyzer{},
+		expected: []message{
+			{msg.PortProtocolConflict, "Service conflicting.default"},
+			{msg.PortProtocolConflict, "Service conflicting.default"},
+		},
+	},
+	{
+		name:       "servicePortProtocol",
+		inputFiles: []string{"testdata/service-port-protocol.yaml"},
+		analyzer:   &service.PortProtocolAnalyzer{},
+		expected: []message{
+			{msg.TargetPortProtocolConflict, "Service conflicting.default"},
+			{msg.TargetPortProtocolConflict, "Service conflicting-named-target.default"},
+			{msg.HeadlessServicePortProtocolNotDeclared, "Service headless.default"},
+		},
+	},
 	{
 		name:       "portNameNotFollowConvention",
 		inputFiles: []string{"testdata/service-no-port-name.yaml"},
@@ -189,6 +428,64 @@ var testGrid = []testCase{
 		analyzer:   &service.PortNameAnalyzer{},
 		expected:   []message{},
 	},
+	{
+		name:       "schemaBounds",
+		inputFiles: []string{"testdata/schema-bounds.yaml"},
+		analyzer:   &schemaanalyzer.BoundsAnalyzer{},
+		expected: []message{
+			{msg.FieldValueOutOfRange, "VirtualService out-of-range.default"},
+			{msg.FieldValueOutOfRange, "VirtualService out-of-range.default"},
+			{msg.FieldValueOutOfRange, "VirtualService out-of-range.default"},
+			{msg.FieldValueOutOfRange, "VirtualService out-of-range.default"},
+			{msg.FieldValueOutOfRange, "DestinationRule out-of-range.default"},
+			{msg.FieldValueOutOfRange, "DestinationRule out-of-range.default"},
+			{msg.FieldValueOutOfRange, "ServiceEntry out-of-range.default"},
+		},
+	},
+	{
+		name:       "serviceEntryEndpointAddresses",
+		inputFiles: []string{"testdata/serviceentry-endpoint-addresses.yaml"},
+		analyzer:   &serviceentry.EndpointAddressAnalyzer{},
+		expected: []message{
+			{msg.ConflictingEndpointAddress, "ServiceEntry vm-app.default"},
+			{msg.ConflictingEndpointAddress, "WorkloadEntry vm-other.default"},
+			{msg.ConflictingEndpointAddress, "ServiceEntry vm-ports.other"},
+		},
+	},
+	{
+		name:       "serviceEntryOverlap",
+		inputFiles: []string{"testdata/serviceentry-overlap.yaml"},
+		analyzer:   &serviceentry.OverlapAnalyzer{},
+		expected: []message{
+			{msg.OverlappingServiceEntry, "ServiceEntry reviews-override.default"},
+			{msg.OverlappingServiceEntry, "ServiceEntry legacy-vip.default"},
+			{msg.OverlappingServiceEntry, "ServiceEntry api.default"},
+			{msg.OverlappingServiceEntry, "ServiceEntry api-internal.other"},
+			{msg.OverlappingServiceEntry, "ServiceEntry payments.default"},
+			{msg.OverlappingServiceEntry, "ServiceEntry billing.default"},
+		},
+	},
+	{
+		name:       "serviceEntryInterception",
+		inputFiles: []string{"testdata/serviceentry-interception.yaml"},
+		analyzer:   &serviceentry.InterceptionAnalyzer{},
+		expected: []message{
+			{msg.ServiceEntryAddressNotIntercepted, "ServiceEntry database.default"},
+			{msg.ServiceEntryAddressNotIntercepted, "ServiceEntry external-api.default"},
+		},
+	},
+	{
+		name:       "rootNamespaceMeshWide",
+		inputFiles: []string{"testdata/root-namespace-mesh-wide.yaml"},
+		analyzer:   &rootnamespace.MeshWideAnalyzer{},
+		expected: []message{
+			{msg.MeshWideResource, "VirtualService reviews.istio-system"},
+			{msg.MeshWideResource, "DestinationRule reviews.istio-system"},
+			{msg.MeshWideResource, "Sidecar default.istio-system"},
+			{msg.MeshWideResource, "EnvoyFilter lua.istio-system"},
+			{msg.MeshWideResource, "PeerAuthentication default.istio-system"},
+		},
+	},
 	{
 		name:       "sidecarDefaultSelector",
 		inputFiles: []string{"testdata/sidecar-default-selector.yaml"},
@@ -198,6 +495,25 @@ var testGrid = []testCase{
 			{msg.MultipleSidecarsWithoutWorkloadSelectors, "Sidecar has-conflict-1.ns2"},
 		},
 	},
+	{
+		name:       "sidecarEgressHosts",
+		inputFiles: []string{"testdata/sidecar-egress-hosts.yaml"},
+		analyzer:   &sidecar.EgressHostAnalyzer{},
+		expected: []message{
+			{msg.ReferencedResourceNotFound, "Sidecar missing-namespace.default"},
+			{msg.ReferencedResourceNotFound, "Sidecar missing-service.default"},
+		},
+	},
+	{
+		name:           "sidecarRegistryOnly",
+		inputFiles:     []string{"testdata/sidecar-registry-only.yaml"},
+		meshConfigFile: "testdata/mesh-registry-only.yaml",
+		analyzer:       &sidecar.RegistryOnlyAnalyzer{},
+		expected: []message{
+			{msg.UnregisteredExternalHost, "VirtualService httpbin.default"},
+			{msg.UnregisteredExternalHost, "Sidecar github.default"},
+		},
+	},
 	{
 		name:       "sidecarSelector",
 		inputFiles: []string{"testdata/sidecar-selector.yaml"},
@@ -211,6 +527,16 @@ var testGrid = []testCase{
 			{msg.ConflictingSidecarWorkloadSelectors, "Sidecar overlap-2.default"},
 		},
 	},
+	{
+		name:       "unreferenced",
+		inputFiles: []string{"testdata/unreferenced.yaml"},
+		analyzer:   &unreferenced.Analyzer{},
+		expected: []message{
+			{msg.UnreferencedGateway, "Gateway old-gateway.default"},
+			{msg.UnusedSubset, "DestinationRule reviews.default"},
+			{msg.UnreferencedDestinationRule, "DestinationRule details.default"},
+		},
+	},
 	{
 		name:       "virtualServiceConflictingMeshGatewayHosts",
 		inputFiles: []string{"testdata/virtualservice_conflictingmeshgatewayhosts.yaml"},
@@ -246,6 +572,17 @@ var testGrid = []testCase{
 			{msg.ReferencedResourceNotFound, "VirtualService reviews-mirror-bogussubset.default"},
 		},
 	},
+	{
+		name:       "virtualServiceExportTo",
+		inputFiles: []string{"testdata/virtualservice-exportto.yaml"},
+		analyzer:   &virtualservice.ExportToAnalyzer{},
+		expected: []message{
+			{msg.DestinationHostNotExported, "VirtualService reviews-exported.default"},
+			{msg.DestinationRuleNotExported, "VirtualService reviews-exported.default"},
+			{msg.DestinationRuleNotExported, "VirtualService ratings-exported.default"},
+			{msg.DestinationRuleNotExported, "VirtualService details-local.default"},
+		},
+	},
 	{
 		name:       "virtualServiceGateways",
 		inputFiles: []string{"testdata/virtualservice_gateways.yaml"},
@@ -280,6 +617,39 @@ var testGrid = []testCase{
 			{msg.InvalidRegexp, "VirtualService lots-of-regexes"},
 			{msg.InvalidRegexp, "VirtualService lots-of-regexes"},
 			{msg.InvalidRegexp, "VirtualService lots-of-regexes"},
+			{msg.RegexTooComplex, "VirtualService complex-regex"},
+		},
+	},
+	{
+		name:       "virtualServiceTLSRoutes",
+		inputFiles: []string{"testdata/virtualservice_tlsroutes.yaml"},
+		analyzer:   &virtualservice.TLSRouteAnalyzer{},
+		expected: []message{
+			{msg.TLSRouteMissingSNIHosts, "VirtualService no-sni.default"},
+			{msg.SNIHostNotInGatewayServer, "VirtualService other-sni.default"},
+			{msg.TLSRouteNotOnPassthroughServer, "VirtualService http-port.default"},
+			{msg.TLSRouteNotOnPassthroughServer, "VirtualService terminated.default"},
+		},
+	},
+	{
+		name:       "virtualServiceGatewayRoutes",
+		inputFiles: []string{"testdata/virtualservice_gatewayroutes.yaml"},
+		analyzer:   &virtualservice.GatewayRouteConflictAnalyzer{},
+		expected: []message{
+			{msg.GatewayRoutesShadowed, "VirtualService bookinfo.default"},
+			{msg.GatewayRoutesShadowed, "VirtualService bookinfo-api.default"},
+			{msg.GatewayRoutesShadowed, "VirtualService shop.default"},
+			{msg.GatewayRoutesShadowed, "VirtualService cart.istio-system"},
+		},
+	},
+	{
+		name:       "virtualServiceTimeouts",
+		inputFiles: []string{"testdata/virtualservice_timeouts.yaml"},
+		analyzer:   &virtualservice.TimeoutAnalyzer{},
+		expected: []message{
+			{msg.ZeroRouteTimeout, "VirtualService reviews-zero.default"},
+			{msg.RouteTimeoutNotLongerThanConnectTimeout, "VirtualService reviews-v2.default"},
+			{msg.RouteTimeoutNotLongerThanConnectTimeout, "VirtualService reviews-fast.default"},
 		},
 	},
 	{
@@ -294,6 +664,263 @@ var testGrid = []testCase{
 			{msg.UnknownMeshNetworksServiceRegistry, "MeshNetworks meshnetworks.istio-system"},
 		},
 	},
+	{
+		name: "multiClusterRemoteServices",
+		clusterInputFiles: map[string][]string{
+			"east": {"testdata/multicluster-east.yaml"},
+			"west": {"testdata/multicluster-west.yaml"},
+		},
+		analyzer: &multicluster.RemoteServiceAnalyzer{},
+		expected: []message{
+			{msg.RemoteServiceNotFound, "ServiceEntry east-remote.default"},
+			{msg.RemoteServiceNotFound, "ServiceEntry west-loopback.default"},
+		},
+	},
+	{
+		name: "multiClusterMeshNetworks",
+		clusterInputFiles: map[string][]string{
+			"east": {"testdata/multicluster-east.yaml"},
+			"west": {"testdata/multicluster-west.yaml"},
+		},
+		clusterMeshNetworksFiles: map[string]string{
+			"east": "testdata/common/meshnetworks.yaml",
+			"west": "testdata/multicluster-meshnetworks-west.yaml",
+		},
+		analyzer: &multicluster.MeshNetworksConsistencyAnalyzer{},
+		expected: []message{
+			{msg.MeshNetworksMismatch, "MeshNetworks meshnetworks.istio-system"},
+		},
+	},
+	{
+		name:       "workloadSelector",
+		inputFiles: []string{"testdata/workload-selector.yaml"},
+		analyzer:   &workload.SelectorAnalyzer{},
+		expected: []message{
+			{msg.WorkloadSelectorSpansApplications, "Gateway ingress.istio-system"},
+			{msg.WorkloadSelectorSpansApplications, "Sidecar backend.default"},
+			{msg.WorkloadSelectorSpansApplications, "AuthorizationPolicy backend.default"},
+			{msg.ConflictingPeerAuthentications, "PeerAuthentication reviews.default"},
+			{msg.ConflictingPeerAuthentications, "PeerAuthentication reviews-v1.default"},
+			{msg.UnmatchedWorkloadSelector, "PeerAuthentication details.default"},
+		},
+	},
+	{
+		name:       "gatewayApiGateway",
+		inputFiles: []string{"testdata/gateway-api.yaml"},
+		analyzer:   &gatewayapi.GatewayAnalyzer{},
+		expected: []message{
+			{msg.ListenerRouteKindIncompatible, "Gateway public.istio-system"},
+			{msg.ReferencedResourceNotFound, "Gateway broken.istio-system"},
+		},
+	},
+	{
+		name:       "gatewayApiRoute",
+		inputFiles: []string{"testdata/gateway-api.yaml"},
+		analyzer:   &gatewayapi.RouteAnalyzer{},
+		expected: []message{
+			{msg.RouteParentRefNotAccepted, "HTTPRoute reviews.default"},
+			{msg.RouteParentRefNotAccepted, "HTTPRoute internal.default"},
+			{msg.RouteParentRefNotAccepted, "HTTPRoute tcp.default"},
+			{msg.RouteParentRefNotAccepted, "HTTPRoute noport.default"},
+			{msg.ReferencedResourceNotFound, "HTTPRoute missing.default"},
+		},
+	},
+	{
+		name:       "gatewayApiSecret",
+		inputFiles: []string{"testdata/gateway-api.yaml", "testdata/gateway-secrets.yaml"},
+		analyzer:   &gatewayapi.SecretAnalyzer{},
+		expected: []message{
+			{msg.ListenerCertificateMissing, "Gateway broken.istio-system"},
+			{msg.ReferencedResourceNotFound, "Gateway broken.istio-system"},
+			{msg.InvalidGatewayCredential, "Gateway broken.istio-system"},
+		},
+	},
+	{
+		name:       "controlPlaneWebhook",
+		inputFiles: []string{"testdata/control-plane.yaml"},
+		analyzer:   &controlplane.WebhookAnalyzer{},
+		expected: []message{
+			{msg.ReferencedResourceNotFound, "MutatingWebhookConfiguration istio-sidecar-injector-canary"},
+			{msg.ReferencedResourceNotFound, "ValidatingWebhookConfiguration istiod-canary"},
+			{msg.InconsistentWebhookFailurePolicy, "ValidatingWebhookConfiguration istiod-canary"},
+		},
+	},
+	{
+		name:       "controlPlaneRootCert",
+		inputFiles: []string{"testdata/control-plane.yaml"},
+		analyzer:   &controlplane.RootCertAnalyzer{},
+		expected: []message{
+			{msg.RootCertificateExpired, "Secret cacerts.istio-system"},
+		},
+	},
+	{
+		name:       "controlPlaneMissingRootCA",
+		inputFiles: []string{"testdata/control-plane-missing-ca.yaml"},
+		analyzer:   &controlplane.RootCertAnalyzer{},
+		expected: []message{
+			{msg.RootCASecretMissing, "Deployment istiod.istio-system"},
+		},
+	},
+	{
+		name:       "controlPlaneRevisions",
+		inputFiles: []string{"testdata/control-plane.yaml"},
+		analyzer:   &controlplane.RevisionAnalyzer{},
+		expected: []message{
+			{msg.OverlappingInjectionRevisions, "Namespace default"},
+		},
+	},
+	{
+		name:       "virtualServiceHeaderMatches",
+		inputFiles: []string{"testdata/virtualservice_headermatches.yaml"},
+		analyzer:   &virtualservice.HeaderMatchAnalyzer{},
+		expected: []message{
+			{msg.InvalidHeaderName, "VirtualService invalid-headers"},
+			{msg.InvalidHeaderName, "VirtualService invalid-headers"},
+			{msg.InvalidHeaderName, "VirtualService invalid-headers"},
+		},
+	},
+	{
+		name:       "controlPlaneSkew",
+		inputFiles: []string{"testdata/control-plane-skew.yaml"},
+		analyzer:   &controlplane.SkewAnalyzer{},
+		expected: []message{
+			{msg.MixedProxyRevisions, "Namespace upgrading"},
+			{msg.ProxyRevisionVersionSkew, "Pod stale.legacy"},
+			{msg.ProxyRevisionNotInstalled, "Pod orphan.orphaned"},
+		},
+	},
+	{
+		name:       "controlPlaneSkewAllowed",
+		inputFiles: []string{"testdata/control-plane-skew.yaml"},
+		analyzer:   &controlplane.SkewAnalyzer{MaxMinorVersionsBehind: 2},
+		expected: []message{
+			{msg.MixedProxyRevisions, "Namespace upgrading"},
+			{msg.ProxyRevisionNotInstalled, "Pod orphan.orphaned"},
+		},
+	},
+	{
+		name:       "sidecarIngressPorts",
+		inputFiles: []string{"testdata/sidecar-ingress.yaml"},
+		analyzer:   &sidecar.IngressPortAnalyzer{},
+		expected: []message{
+			{msg.SidecarIngressProtocolMismatch, "Sidecar ratings-ingress.default"},
+			{msg.SidecarIngressPortNotExposed, "Sidecar ratings-ingress.default"},
+			{msg.SidecarIngressDuplicatePort, "Sidecar ratings-ingress.default"},
+		},
+	},
+	{
+		name:       "destinationRuleLocality",
+		inputFiles: []string{"testdata/destinationrule-locality.yaml"},
+		analyzer:   &destinationrule.LocalityLoadBalancingAnalyzer{},
+		expected: []message{
+			{msg.LocalityWeightsNotSumTo100, "DestinationRule reviews-distribute.default"},
+			{msg.LocalityWithoutEndpoints, "DestinationRule reviews-distribute.default"},
+			{msg.LocalityWithoutEndpoints, "DestinationRule reviews-failover.default"},
+			{msg.LocalityWithoutEndpoints, "DestinationRule reviews-failover.default"},
+		},
+	},
+	{
+		name:       "virtualServiceResilience",
+		inputFiles: []string{"testdata/virtualservice_resilience.yaml"},
+		analyzer:   &virtualservice.ResilienceAnalyzer{},
+		expected: []message{
+			{msg.PerTryTimeoutNotShorterThanTimeout, "VirtualService ratings-pertry.default"},
+			{msg.NonIdempotentRetry, "VirtualService ratings-post.default"},
+			{msg.GrpcRetryOnNonGrpcDestination, "VirtualService ratings-grpc.default"},
+			{msg.MeshWideFaultInjection, "VirtualService fault-mesh.default"},
+		},
+	},
+	{
+		name:           "authTrustDomain",
+		inputFiles:     []string{"testdata/trustdomain.yaml"},
+		meshConfigFile: "testdata/mesh-trust-domain.yaml",
+		analyzer:       &auth.TrustDomainAnalyzer{},
+		expected: []message{
+			{msg.IdentityTrustDomainMismatch, "AuthorizationPolicy typo.default"},
+			{msg.MalformedPrincipal, "AuthorizationPolicy typo.default"},
+			{msg.MalformedPrincipal, "AuthorizationPolicy typo.default"},
+			{msg.IdentityTrustDomainMismatch, "DestinationRule reviews.default"},
+			{msg.IdentityTrustDomainMismatch, "ServiceEntry ratings.default"},
+		},
+	},
+	{
+		name:       "gatewaySNICollisions",
+		inputFiles: []string{"testdata/gateway-sni.yaml"},
+		analyzer:   &gateway.SNICollisionAnalyzer{},
+		expected: []message{
+			{msg.GatewayServerHostsOverlap, "Gateway wildcard-modes.istio-system"},
+			{msg.PassthroughHostCollision, "Gateway passthrough.istio-system"},
+		},
+	},
+	{
+		name:            "gatewayPushedSecrets",
+		inputFiles:      []string{"testdata/gateway-pushedsecrets.yaml"},
+		configDumpFiles: []string{"testdata/configdump/ingressgateway-secrets.json"},
+		analyzer:        &gateway.PushedSecretsAnalyzer{},
+		expected: []message{
+			{msg.GatewaySecretNotLoaded, "Gateway httpbin.istio-system"},
+			{msg.GatewaySecretNotLoaded, "Gateway mtls.istio-system"},
+		},
+	},
+	{
+		name:       "virtualServicePushedRoutes",
+		inputFiles: []string{"testdata/virtualservice_pushedroutes.yaml"},
+		configDumpFiles: []string{
+			"testdata/configdump/productpage-sidecar.json",
+			"testdata/configdump/ingressgateway.json",
+		},
+		analyzer: &virtualservice.PushedRoutesAnalyzer{},
+		expected: []message{
+			{msg.VirtualServiceNotPushed, "VirtualService ratings.default"},
+			{msg.VirtualServiceNotPushed, "VirtualService httpbin.default"},
+		},
+	},
+	{
+		name:       "scaleConfigSize",
+		inputFiles: []string{"testdata/scale-config-size.yaml"},
+		analyzer:   &scale.ConfigSizeAnalyzer{MaxServicePorts: 5, MaxRoutes: 3},
+		expected: []message{
+			{msg.UnscopedProxyConfig, "Namespace default"},
+			{msg.WildcardSidecarEgress, "Sidecar default.wildcard"},
+			{msg.LargeVirtualService, "VirtualService many-routes.default"},
+			{msg.MeshWideEnvoyFilter, "EnvoyFilter mesh-wide.istio-system"},
+		},
+	},
+	{
+		name:       "ingressClass",
+		inputFiles: []string{"testdata/ingress.yaml"},
+		analyzer:   &ingress.ClassAnalyzer{},
+		expected: []message{
+			{msg.IngressNotHandled, "Ingress no-class.bookinfo"},
+		},
+	},
+	{
+		name:       "ingressBackend",
+		inputFiles: []string{"testdata/ingress.yaml"},
+		analyzer:   &ingress.BackendAnalyzer{},
+		expected: []message{
+			{msg.IngressBackendPortNotFound, "Ingress main.bookinfo"},
+			{msg.ReferencedResourceNotFound, "Ingress main.bookinfo"},
+		},
+	},
+	{
+		name:       "ingressSecret",
+		inputFiles: []string{"testdata/ingress.yaml", "testdata/gateway-secrets.yaml"},
+		analyzer:   &ingress.SecretAnalyzer{},
+		expected: []message{
+			{msg.IngressSecretNotInGatewayNamespace, "Ingress main.bookinfo"},
+			{msg.ReferencedResourceNotFound, "Ingress main.bookinfo"},
+			{msg.InvalidGatewayCredential, "Ingress main.bookinfo"},
+		},
+	},
+	{
+		name:       "ingressHostConflict",
+		inputFiles: []string{"testdata/ingress.yaml"},
+		analyzer:   &ingress.HostConflictAnalyzer{},
+		expected: []message{
+			{msg.IngressHostConflict, "Ingress main.bookinfo"},
+		},
+	},
 }
 
 // regex patterns for analyzer names that should be explicitly ignored for testing
@@ -314,25 +941,25 @@ func TestAnalyzers(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			g := NewGomegaWithT(t)
 
-			// Set up a hook to record which collections are accessed by each analyzer
-			analyzerName := tc.analyzer.Metadata().Name
-			cr := func(col collection.Name) {
-				if _, ok := requestedInputsByAnalyzer[analyzerName]; !ok {
-					requestedInputsByAnalyzer[analyzerName] = make(map[collection.Name]struct{})
-				}
-				requestedInputsByAnalyzer[analyzerName][col] = struct{}{}
-			}
-
-			// Set up Analyzer for this test case
-			sa, err := setupAnalyzerForCase(tc, cr)
+			result, err := analysistest.Run(tc.analyzer, analysistest.Case{
+				Files:                    tc.inputFiles,
+				MeshConfigFile:           tc.meshConfigFile,
+				MeshNetworksFile:         tc.meshNetworksFile,
+				ClusterFiles:             tc.clusterInputFiles,
+				ClusterMeshNetworksFiles: tc.clusterMeshNetworksFiles,
+				ConfigDumpFiles:          tc.configDumpFiles,
+			})
 			if err != nil {
-				t.Fatalf("Error setting up analysis for testcase %s: %v", tc.name, err)
+				t.Fatalf("Error running analysis on testcase %s: %v", tc.name, err)
 			}
 
-			// Run the analysis
-			result, err := runAnalyzer(sa)
-			if err != nil {
-				t.Fatalf("Error running analysis on testcase %s: %v", tc.name, err)
+			// Record which collections are accessed by each analyzer
+			analyzerName := tc.analyzer.Metadata().Name
+			if _, ok := requestedInputsByAnalyzer[analyzerName]; !ok {
+				requestedInputsByAnalyzer[analyzerName] = make(map[collection.Name]struct{})
+			}
+			for _, col := range result.Accessed {
+				requestedInputsByAnalyzer[analyzerName][col] = struct{}{}
 			}
 
 			g.Expect(extractFields(result.Messages)).To(ConsistOf(tc.expected), "%v", prettyPrintMessages(result.Messages))
@@ -344,7 +971,7 @@ func TestAnalyzers(t *testing.T) {
 	t.Run("CheckMetadataInputs", func(t *testing.T) {
 		g := NewGomegaWithT(t)
 	outer:
-		for _, a := range All() {
+		for _, a := range allWithOptional() {
 			analyzerName := a.Metadata().Name
 
 			// Skip this check for explicitly ignored analyzers
@@ -370,12 +997,12 @@ func TestAnalyzers(t *testing.T) {
 	})
 }
 
-// Verify that all of the analyzers tested here are also registered in All()
+// Verify that all of the analyzers tested here are also registered in All() or Optional()
 func TestAnalyzersInAll(t *testing.T) {
 	g := NewGomegaWithT(t)
 
 	var allNames []string
-	for _, a := range All() {
+	for _, a := range allWithOptional() {
 		allNames = append(allNames, a.Metadata().Name)
 	}
 
@@ -388,7 +1015,7 @@ func TestAnalyzersHaveUniqueNames(t *testing.T) {
 	g := NewGomegaWithT(t)
 
 	existingNames := make(map[string]struct{})
-	for _, a := range All() {
+	for _, a := range allWithOptional() {
 		n := a.Metadata().Name
 		_, ok := existingNames[n]
 		// TODO (Nino-K): remove this condition once metadata is clean up
@@ -396,7 +1023,7 @@ func TestAnalyzersHaveUniqueNames(t *testing.T) {
 			continue
 		}
 		g.Expect(ok).To(BeFalse(), fmt.Sprintf("Analyzer name %q is used more than once. "+
-			"Analyzers should be registered in All() exactly once and have a unique name.", n))
+			"Analyzers should be registered in All() or Optional() exactly once and have a unique name.", n))
 
 		existingNames[n] = struct{}{}
 	}
@@ -405,66 +1032,65 @@ func TestAnalyzersHaveUniqueNames(t *testing.T) {
 func TestAnalyzersHaveDescription(t *testing.T) {
 	g := NewGomegaWithT(t)
 
-	for _, a := range All() {
+	for _, a := range allWithOptional() {
 		g.Expect(a.Metadata().Description).ToNot(Equal(""))
 	}
 }
 
-func setupAnalyzerForCase(tc testCase, cr snapshotter.CollectionReporterFn) (*local.SourceAnalyzer, error) {
-	sa := local.NewSourceAnalyzer(schema.MustGet(), analysis.Combine("testCase", tc.analyzer), "", "istio-system", cr, true, 10*time.Second)
+func TestAnalyzersHaveValidVersionBounds(t *testing.T) {
+	g := NewGomegaWithT(t)
 
-	// If a mesh config file is specified, use it instead of the defaults
-	if tc.meshConfigFile != "" {
-		err := sa.AddFileKubeMeshConfig(tc.meshConfigFile)
-		if err != nil {
-			return nil, fmt.Errorf("error applying mesh config file %s: %v", tc.meshConfigFile, err)
-		}
+	for _, a := range allWithOptional() {
+		_, err := a.Metadata().AppliesTo("1.6.0")
+		g.Expect(err).To(BeNil(), "analyzer %s", a.Metadata().Name)
 	}
+}
 
-	if tc.meshNetworksFile != "" {
-		err := sa.AddFileKubeMeshNetworks(tc.meshNetworksFile)
-		if err != nil {
-			return nil, fmt.Errorf("error apply mesh networks file %s: %v", tc.meshNetworksFile, err)
-		}
-	}
+func allWithOptional() []analysis.Analyzer {
+	return append(All(), Optional()...)
+}
 
-	// Include default resources
-	err := sa.AddDefaultResources()
-	if err != nil {
-		return nil, fmt.Errorf("error adding default resources: %v", err)
-	}
+// TestAnalyzersOnTopology verifies that all analyzers are quiet on a generated, coherent topology, and that each of the
+// defects injected into it is reported as expected.
+func TestAnalyzersOnTopology(t *testing.T) {
+	topology := fixtures.Topology{Namespaces: 2, Services: 2, Versions: 2, Replicas: 1, Gateways: 1}
 
-	// Gather test files
-	var files []local.ReaderSource
-	for _, f := range tc.inputFiles {
-		of, err := os.Open(f)
-		if err != nil {
-			return nil, fmt.Errorf("error opening test file: %q", f)
-		}
-		files = append(files, local.ReaderSource{Name: f, Reader: of})
+	cases := []struct {
+		defect   fixtures.Defect
+		expected []message
+	}{
+		{"", []message{}},
+		{fixtures.DefectMissingDestinationHost, []message{
+			{msg.ReferencedResourceNotFound, "VirtualService svc-0.ns-0"},
+			{msg.UnusedSubset, "DestinationRule svc-0.ns-0"},
+			{msg.UnusedSubset, "DestinationRule svc-0.ns-0"},
+		}},
+		{fixtures.DefectMissingSubset, []message{{msg.ReferencedResourceNotFound, "VirtualService svc-0.ns-0"}}},
+		{fixtures.DefectMissingGateway, []message{{msg.ReferencedResourceNotFound, "VirtualService svc-0-ingress.ns-0"}}},
+		{fixtures.DefectMissingGatewaySecret, []message{{msg.ReferencedResourceNotFound, "Gateway gateway-0.ns-0"}}},
+		{fixtures.DefectUnnamedServicePort, []message{{msg.PortNameIsNotUnderNamingConvention, "Service svc-0.ns-0"}}},
+		{fixtures.DefectUninjectedNamespace, []message{{msg.NamespaceNotInjected, "Namespace ns-1"}}},
+		{fixtures.DefectMissingProxy, []message{{msg.PodMissingProxy, "Pod svc-0-v1-0.ns-0"}}},
+		{fixtures.DefectProxyImageMismatch, []message{{msg.IstioProxyImageMismatch, "Pod svc-1-v2-0.ns-0"}}},
 	}
+	for _, c := range cases {
+		c := c
+		name := string(c.defect)
+		if name == "" {
+			name = "NoDefects"
+		}
+		t.Run(name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
 
-	// Include resources from test files
-	err = sa.AddReaderKubeSource(files)
-	if err != nil {
-		return nil, fmt.Errorf("error setting up file kube source on testcase %s: %v", tc.name, err)
-	}
-
-	return sa, nil
-}
-
-func runAnalyzer(sa *local.SourceAnalyzer) (local.AnalysisResult, error) {
-	// Default processing log level is too chatty for these tests
-	prevLogLevel := scope.Processing.GetOutputLevel()
-	scope.Processing.SetOutputLevel(log.ErrorLevel)
-	defer scope.Processing.SetOutputLevel(prevLogLevel)
+			topology.Defects = []fixtures.Defect{c.defect}
+			result, err := analysistest.Run(AllCombined(), analysistest.Case{Content: []string{topology.Generate()}})
+			if err != nil {
+				t.Fatalf("Error running analysis: %v", err)
+			}
 
-	cancel := make(chan struct{})
-	result, err := sa.Analyze(cancel)
-	if err != nil {
-		return local.AnalysisResult{}, err
+			g.Expect(extractFields(result.Messages)).To(ConsistOf(c.expected), "%v", prettyPrintMessages(result.Messages))
+		})
 	}
-	return result, err
 }
 
 // Pull just the fields we want to check out of diag.Message
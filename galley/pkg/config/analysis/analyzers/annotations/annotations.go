@@ -101,8 +101,7 @@ outer:
 			continue
 		}
 
-		// TODO: Check annotation.Deprecated.  Not implemented because no
-		// deprecations in the table have yet been deprecated!
+		// Deprecated annotations are reported by deprecation.AnnotationAnalyzer
 		validationFunction := inject.AnnotationValidation[ann]
 		if validationFunction != nil {
 			if err := validationFunction(value); err != nil {
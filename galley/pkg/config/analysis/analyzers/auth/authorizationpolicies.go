@@ -0,0 +1,167 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"fmt"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"istio.io/api/networking/v1alpha3"
+	"istio.io/api/security/v1beta1"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/util"
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/constants"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+// AuthorizationPoliciesAnalyzer checks the references of authorization policies, as policies that refer to something
+// that does not exist fail closed without any indication why. It reports:
+// * workload selectors that match no pods
+// * principals of the mesh's trust domain whose service account no pod, deployment or workload entry runs as
+// * source namespaces that do not exist
+// * ALLOW policies without rules, which deny all requests to the workloads they apply to
+type AuthorizationPoliciesAnalyzer struct{}
+
+var _ analysis.Analyzer = &AuthorizationPoliciesAnalyzer{}
+
+// Metadata implements Analyzer
+func (a *AuthorizationPoliciesAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:        "auth.AuthorizationPoliciesAnalyzer",
+		Description: "Checks the workloads, principals and namespaces referenced by authorization policies",
+		Inputs: collection.Names{
+			collections.IstioMeshV1Alpha1MeshConfig.Name(),
+			collections.IstioSecurityV1Beta1Authorizationpolicies.Name(),
+			collections.K8SCoreV1Namespaces.Name(),
+			collections.K8SCoreV1Pods.Name(),
+			collections.K8SAppsV1Deployments.Name(),
+			collections.IstioNetworkingV1Alpha3Workloadentries.Name(),
+		},
+	}
+}
+
+// Analyze implements Analyzer
+func (a *AuthorizationPoliciesAnalyzer) Analyze(ctx analysis.Context) {
+	rootNamespace := resource.Namespace(analysis.MeshConfig(ctx).GetRootNamespace())
+	td := meshTrustDomains(ctx)
+
+	// Service accounts are not part of the analyzed collections, so use the ones that workloads run as, including
+	// deployments that are scaled to zero.
+	serviceAccounts := make(map[string]bool)
+	add := func(ns resource.Namespace, sa string) {
+		if sa == "" {
+			sa = "default"
+		}
+		serviceAccounts[serviceAccountKey(ns.String(), sa)] = true
+	}
+	ctx.ForEach(collections.K8SCoreV1Pods.Name(), func(r *resource.Instance) bool {
+		add(r.Metadata.FullName.Namespace, r.Message.(*v1.Pod).Spec.ServiceAccountName)
+		return true
+	})
+	ctx.ForEach(collections.K8SAppsV1Deployments.Name(), func(r *resource.Instance) bool {
+		add(r.Metadata.FullName.Namespace, r.Message.(*appsv1.Deployment).Spec.Template.Spec.ServiceAccountName)
+		return true
+	})
+	ctx.ForEach(collections.IstioNetworkingV1Alpha3Workloadentries.Name(), func(r *resource.Instance) bool {
+		add(r.Metadata.FullName.Namespace, r.Message.(*v1alpha3.WorkloadEntry).GetServiceAccount())
+		return true
+	})
+
+	ctx.ForEach(collections.IstioSecurityV1Beta1Authorizationpolicies.Name(), func(r *resource.Instance) bool {
+		ap := r.Message.(*v1beta1.AuthorizationPolicy)
+
+		// Policies in the root namespace apply to workloads in all namespaces
+		selectNamespace := util.WorkloadSelectorNamespace(r, rootNamespace)
+		selector := util.WorkloadSelector(r)
+		if len(selector) > 0 && len(util.SelectPods(ctx, selectNamespace, selector)) == 0 {
+			ctx.Report(collections.IstioSecurityV1Beta1Authorizationpolicies.Name(),
+				msg.NewReferencedResourceNotFound(r, "selector", labels.SelectorFromSet(selector).String()))
+		}
+
+		if ap.GetAction() == v1beta1.AuthorizationPolicy_ALLOW && len(ap.GetRules()) == 0 {
+			ctx.Report(collections.IstioSecurityV1Beta1Authorizationpolicies.Name(),
+				msg.NewAuthorizationPolicyDeniesAll(r, describeWorkloads(selectNamespace, selector)))
+		}
+
+		for _, rule := range ap.GetRules() {
+			for _, from := range rule.GetFrom() {
+				source := from.GetSource()
+				// The workloads of a principal may run in other clusters, so it is only a warning. Principals that
+				// match no workload are harmless in notPrincipals.
+				for _, p := range source.GetPrincipals() {
+					if key := principalServiceAccount(p, td); key != "" && !serviceAccounts[key] {
+						ctx.Report(collections.IstioSecurityV1Beta1Authorizationpolicies.Name(),
+							msg.NewReferencedResourceNotFound(r, "principal", p).WithLevel(diag.Warning))
+					}
+				}
+				for _, namespaces := range [][]string{source.GetNamespaces(), source.GetNotNamespaces()} {
+					for _, n := range namespaces {
+						if strings.Contains(n, "*") {
+							continue
+						}
+						if !ctx.Exists(collections.K8SCoreV1Namespaces.Name(), resource.NewFullName("", resource.LocalName(n))) {
+							ctx.Report(collections.IstioSecurityV1Beta1Authorizationpolicies.Name(),
+								msg.NewReferencedResourceNotFound(r, "source namespace", n))
+						}
+					}
+				}
+			}
+		}
+
+		return true
+	})
+}
+
+// principalServiceAccount returns the service account key of a principal of the form
+// <trust domain>/ns/<namespace>/sa/<service account> in the trust domain of the mesh, or the empty string for
+// principals of any other form, of other trust domains, and principals with wildcards.
+func principalServiceAccount(principal string, td trustDomains) string {
+	if strings.Contains(principal, "*") {
+		return ""
+	}
+	parts := strings.Split(principal, "/")
+	if len(parts) != 5 || parts[1] != "ns" || parts[3] != "sa" {
+		return ""
+	}
+	if parts[0] != constants.DefaultKubernetesDomain && !td.contains(parts[0]) {
+		return ""
+	}
+	return serviceAccountKey(parts[2], parts[4])
+}
+
+func serviceAccountKey(ns, sa string) string {
+	return ns + "/" + sa
+}
+
+// describeWorkloads describes the workloads that a policy applies to, given the namespace it selects workloads in.
+func describeWorkloads(ns resource.Namespace, selector map[string]string) string {
+	scope := "the mesh"
+	if ns != "" {
+		scope = fmt.Sprintf("namespace %s", ns)
+	}
+	if len(selector) == 0 {
+		return "all workloads in " + scope
+	}
+	return fmt.Sprintf("workloads matching %s in %s", labels.SelectorFromSet(selector), scope)
+}
@@ -0,0 +1,171 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"istio.io/api/networking/v1alpha3"
+	"istio.io/api/security/v1beta1"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/util"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+// MTLSAnalyzer cross-references the TLS mode that destination rules set for a host with the mTLS mode that peer
+// authentications set for the workloads behind it, and reports combinations under which connections fail: plaintext
+// or non-Istio TLS to workloads that require mTLS, and Istio mTLS to workloads that have it disabled. Destination rules
+// without TLS settings are checked as plaintext when auto mTLS is disabled in the mesh config.
+type MTLSAnalyzer struct{}
+
+var _ analysis.Analyzer = &MTLSAnalyzer{}
+
+// Metadata implements Analyzer
+func (a *MTLSAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:        "auth.MTLSAnalyzer",
+		Description: "Checks for destination rules whose TLS mode does not match the mTLS mode of the destination",
+		Inputs: collection.Names{
+			collections.IstioMeshV1Alpha1MeshConfig.Name(),
+			collections.IstioNetworkingV1Alpha3Destinationrules.Name(),
+			collections.IstioSecurityV1Beta1Peerauthentications.Name(),
+			collections.K8SCoreV1Pods.Name(),
+			collections.K8SCoreV1Services.Name(),
+		},
+	}
+}
+
+// Analyze implements Analyzer
+func (a *MTLSAnalyzer) Analyze(ctx analysis.Context) {
+	pods := util.BuildWorkloadIndex(ctx, collections.K8SCoreV1Pods.Name())
+	policies := newPeerAuthentications(ctx)
+	autoMtls := analysis.MeshConfig(ctx).GetEnableAutoMtls().GetValue()
+
+	ctx.ForEach(collections.IstioNetworkingV1Alpha3Destinationrules.Name(), func(r *resource.Instance) bool {
+		dr := r.Message.(*v1alpha3.DestinationRule)
+		tlsMode := dr.GetTrafficPolicy().GetTls().GetMode()
+		if dr.GetTrafficPolicy().GetTls() == nil {
+			// With auto mTLS, clients pick the mode the workload accepts. Without it, they send plaintext.
+			if autoMtls {
+				return true
+			}
+			tlsMode = v1alpha3.ClientTLSSettings_DISABLE
+		}
+
+		svc := ctx.Find(collections.K8SCoreV1Services.Name(), util.GetResourceNameFromHost(r.Metadata.FullName.Namespace, dr.GetHost()))
+		if svc == nil {
+			return true
+		}
+		selector := svc.Message.(*v1.ServiceSpec).Selector
+		if len(selector) == 0 {
+			return true
+		}
+
+		// Report each peer authentication at most once per destination rule, for the first workload it conflicts on.
+		reported := make(map[string]bool)
+		for _, pod := range pods.Select(svc.Metadata.FullName.Namespace, selector) {
+			mode, source := policies.modeFor(pod)
+			consequence := mismatch(tlsMode, mode)
+			if consequence == "" || reported[source] {
+				continue
+			}
+			reported[source] = true
+			ctx.Report(collections.IstioNetworkingV1Alpha3Destinationrules.Name(), msg.NewMTLSModeMismatch(r,
+				tlsMode.String(), dr.GetHost(), pod.Metadata.FullName.String(), mode.String(), source, consequence))
+		}
+		return true
+	})
+}
+
+// mismatch returns the consequence of a client TLS mode for a workload with the given mTLS mode, or the empty string
+// if connections succeed.
+func mismatch(tls v1alpha3.ClientTLSSettings_TLSmode, mtls v1beta1.PeerAuthentication_MutualTLS_Mode) string {
+	switch {
+	case tls == v1alpha3.ClientTLSSettings_DISABLE && mtls == v1beta1.PeerAuthentication_MutualTLS_STRICT:
+		return "Clients send plaintext, which the workload rejects."
+	case (tls == v1alpha3.ClientTLSSettings_SIMPLE || tls == v1alpha3.ClientTLSSettings_MUTUAL) &&
+		mtls == v1beta1.PeerAuthentication_MutualTLS_STRICT:
+		return "Clients originate TLS with their own certificates instead of Istio mTLS, which the workload rejects."
+	case tls == v1alpha3.ClientTLSSettings_ISTIO_MUTUAL && mtls == v1beta1.PeerAuthentication_MutualTLS_DISABLE:
+		return "Clients send Istio mTLS, which the workload does not accept."
+	}
+	return ""
+}
+
+// peerAuthentications resolves the mTLS mode of workloads from the workload, namespace and mesh-wide peer
+// authentications.
+type peerAuthentications struct {
+	rootNamespace resource.Namespace
+	byNamespace   map[resource.Namespace][]*resource.Instance
+}
+
+func newPeerAuthentications(ctx analysis.Context) *peerAuthentications {
+	p := &peerAuthentications{
+		rootNamespace: resource.Namespace(analysis.MeshConfig(ctx).GetRootNamespace()),
+		byNamespace:   make(map[resource.Namespace][]*resource.Instance),
+	}
+	ctx.ForEach(collections.IstioSecurityV1Beta1Peerauthentications.Name(), func(r *resource.Instance) bool {
+		ns := r.Metadata.FullName.Namespace
+		p.byNamespace[ns] = append(p.byNamespace[ns], r)
+		return true
+	})
+	// Collections are iterated in no particular order, so sort to resolve conflicting policies consistently.
+	for _, l := range p.byNamespace {
+		l := l
+		sort.Slice(l, func(i, j int) bool {
+			return l[i].Metadata.FullName.String() < l[j].Metadata.FullName.String()
+		})
+	}
+	return p
+}
+
+// modeFor returns the mTLS mode of a workload, and the name of the peer authentication that sets it. A mode that is
+// unset is inherited from the next broader policy: workload, namespace, then mesh-wide. Without any policy, the mode
+// is PERMISSIVE, without a policy name. Workloads whose policy sets port level modes are reported as UNSET, as the mode differs by port.
+func (p *peerAuthentications) modeFor(pod *resource.Instance) (v1beta1.PeerAuthentication_MutualTLS_Mode, string) {
+	for _, scope := range []struct {
+		ns       resource.Namespace
+		workload bool
+	}{
+		{pod.Metadata.FullName.Namespace, true},
+		{pod.Metadata.FullName.Namespace, false},
+		{p.rootNamespace, false},
+	} {
+		for _, r := range p.byNamespace[scope.ns] {
+			pa := r.Message.(*v1beta1.PeerAuthentication)
+			selector := pa.GetSelector().GetMatchLabels()
+			if scope.workload != (len(selector) > 0) ||
+				!labels.SelectorFromSet(selector).Matches(labels.Set(pod.Metadata.Labels)) {
+				continue
+			}
+			if len(pa.GetPortLevelMtls()) > 0 {
+				return v1beta1.PeerAuthentication_MutualTLS_UNSET, r.Metadata.FullName.String()
+			}
+			if mode := pa.GetMtls().GetMode(); mode != v1beta1.PeerAuthentication_MutualTLS_UNSET {
+				return mode, r.Metadata.FullName.String()
+			}
+			// Only the first matching policy of a scope applies.
+			break
+		}
+	}
+	return v1beta1.PeerAuthentication_MutualTLS_PERMISSIVE, ""
+}
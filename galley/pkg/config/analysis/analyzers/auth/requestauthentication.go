@@ -0,0 +1,158 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/labels"
+
+	"istio.io/api/security/v1beta1"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/util"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+// RequestAuthenticationAnalyzer checks request authentications for:
+// * workload selectors that match no pods
+// * jwksUri values that are not valid URLs, or use plaintext HTTP
+// * issuers with more than one JWT rule for the same workload, within a policy or across the policies that apply to it
+type RequestAuthenticationAnalyzer struct{}
+
+var _ analysis.Analyzer = &RequestAuthenticationAnalyzer{}
+
+// Metadata implements Analyzer
+func (a *RequestAuthenticationAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:        "auth.RequestAuthenticationAnalyzer",
+		Description: "Checks the selectors, JWKS URIs and issuers of request authentications",
+		Inputs: collection.Names{
+			collections.IstioMeshV1Alpha1MeshConfig.Name(),
+			collections.IstioSecurityV1Beta1Requestauthentications.Name(),
+			collections.K8SCoreV1Pods.Name(),
+		},
+	}
+}
+
+// Analyze implements Analyzer
+func (a *RequestAuthenticationAnalyzer) Analyze(ctx analysis.Context) {
+	rootNamespace := resource.Namespace(analysis.MeshConfig(ctx).GetRootNamespace())
+	pods := util.BuildWorkloadIndex(ctx, collections.K8SCoreV1Pods.Name())
+
+	// issuers maps the pods that a policy applies to, to the policies that configure each issuer for it
+	issuers := make(map[resource.FullName]map[string][]*resource.Instance)
+
+	ctx.ForEach(collections.IstioSecurityV1Beta1Requestauthentications.Name(), func(r *resource.Instance) bool {
+		ra := r.Message.(*v1beta1.RequestAuthentication)
+
+		// Policies in the root namespace apply to workloads in all namespaces
+		selector := util.WorkloadSelector(r)
+		selected := util.SelectWorkloads(pods, r, rootNamespace)
+		if len(selector) > 0 && len(selected) == 0 {
+			ctx.Report(collections.IstioSecurityV1Beta1Requestauthentications.Name(),
+				msg.NewReferencedResourceNotFound(r, "selector", labels.SelectorFromSet(selector).String()))
+		}
+
+		for _, rule := range ra.GetJwtRules() {
+			if uri := rule.GetJwksUri(); uri != "" {
+				if problem := jwksURIProblem(uri); problem != "" {
+					ctx.Report(collections.IstioSecurityV1Beta1Requestauthentications.Name(),
+						msg.NewInvalidJwksURI(r, uri, rule.GetIssuer(), problem))
+				} else if strings.HasPrefix(strings.ToLower(uri), "http:") {
+					ctx.Report(collections.IstioSecurityV1Beta1Requestauthentications.Name(),
+						msg.NewInsecureJwksURI(r, uri, rule.GetIssuer()))
+				}
+			}
+
+			if rule.GetIssuer() == "" {
+				continue
+			}
+			for _, pod := range selected {
+				byIssuer := issuers[pod.Metadata.FullName]
+				if byIssuer == nil {
+					byIssuer = make(map[string][]*resource.Instance)
+					issuers[pod.Metadata.FullName] = byIssuer
+				}
+				byIssuer[rule.GetIssuer()] = append(byIssuer[rule.GetIssuer()], r)
+			}
+		}
+
+		return true
+	})
+
+	// Report each policy at most once per issuer, for the first workload it conflicts on.
+	var podNames []resource.FullName
+	for name := range issuers {
+		podNames = append(podNames, name)
+	}
+	sort.Slice(podNames, func(i, j int) bool {
+		return podNames[i].String() < podNames[j].String()
+	})
+	reported := make(map[string]bool)
+	for _, pod := range podNames {
+		for issuer, policies := range issuers[pod] {
+			if len(policies) < 2 {
+				continue
+			}
+			names := policyNames(policies)
+			for _, r := range policies {
+				key := r.Metadata.FullName.String() + "/" + issuer
+				if reported[key] {
+					continue
+				}
+				reported[key] = true
+				ctx.Report(collections.IstioSecurityV1Beta1Requestauthentications.Name(),
+					msg.NewDuplicateJwtIssuer(r, issuer, pod.String(), names))
+			}
+		}
+	}
+}
+
+// jwksURIProblem returns why a JWKS URI is invalid, or the empty string if it is valid.
+func jwksURIProblem(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return err.Error()
+	}
+	if !strings.EqualFold(u.Scheme, "http") && !strings.EqualFold(u.Scheme, "https") {
+		return "the scheme must be http or https"
+	}
+	if u.Host == "" {
+		return "the host is missing"
+	}
+	return ""
+}
+
+// policyNames returns the sorted, distinct names of the given policies. A policy is listed once even if it configures
+// an issuer more than once.
+func policyNames(policies []*resource.Instance) string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, r := range policies {
+		name := r.Metadata.FullName.String()
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,207 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"fmt"
+	"strings"
+
+	"istio.io/api/networking/v1alpha3"
+	"istio.io/api/security/v1beta1"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/constants"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
+	"istio.io/istio/pkg/spiffe"
+)
+
+// TrustDomainAnalyzer checks the identities in authorization policies, destination rules and service entries against
+// the trust domain of the mesh and its aliases. An identity with a typo in its trust domain never matches, which
+// shows up as requests denied by authorization policies, or as failing TLS handshakes, without an indication why.
+// It reports:
+// * principals of authorization policies whose trust domain is not the one of the mesh nor one of its aliases
+// * principals that do not have the form of the identities of workloads
+// * SPIFFE subject alt names of destination rules and service entries with such a trust domain
+type TrustDomainAnalyzer struct{}
+
+var _ analysis.Analyzer = &TrustDomainAnalyzer{}
+
+// Metadata implements Analyzer
+func (a *TrustDomainAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:        "auth.TrustDomainAnalyzer",
+		Description: "Checks identities against the trust domain of the mesh and its aliases",
+		Inputs: collection.Names{
+			collections.IstioMeshV1Alpha1MeshConfig.Name(),
+			collections.IstioNetworkingV1Alpha3Destinationrules.Name(),
+			collections.IstioNetworkingV1Alpha3Serviceentries.Name(),
+			collections.IstioSecurityV1Beta1Authorizationpolicies.Name(),
+		},
+	}
+}
+
+// Analyze implements Analyzer
+func (a *TrustDomainAnalyzer) Analyze(ctx analysis.Context) {
+	td := meshTrustDomains(ctx)
+
+	ctx.ForEach(collections.IstioSecurityV1Beta1Authorizationpolicies.Name(), func(r *resource.Instance) bool {
+		a.analyzeAuthorizationPolicy(ctx, r, td)
+		return true
+	})
+
+	ctx.ForEach(collections.IstioNetworkingV1Alpha3Destinationrules.Name(), func(r *resource.Instance) bool {
+		dr := r.Message.(*v1alpha3.DestinationRule)
+		a.analyzeTrafficPolicy(ctx, r, td, "spec.trafficPolicy", dr.GetTrafficPolicy())
+		for i, s := range dr.GetSubsets() {
+			a.analyzeTrafficPolicy(ctx, r, td, fmt.Sprintf("spec.subsets[%d].trafficPolicy", i), s.GetTrafficPolicy())
+		}
+		return true
+	})
+
+	ctx.ForEach(collections.IstioNetworkingV1Alpha3Serviceentries.Name(), func(r *resource.Instance) bool {
+		se := r.Message.(*v1alpha3.ServiceEntry)
+		a.analyzeSubjectAltNames(ctx, r, collections.IstioNetworkingV1Alpha3Serviceentries.Name(), td,
+			"spec.subjectAltNames", se.GetSubjectAltNames())
+		return true
+	})
+}
+
+func (a *TrustDomainAnalyzer) analyzeAuthorizationPolicy(ctx analysis.Context, r *resource.Instance, td trustDomains) {
+	ap := r.Message.(*v1beta1.AuthorizationPolicy)
+	for i, rule := range ap.GetRules() {
+		for j, from := range rule.GetFrom() {
+			source := from.GetSource()
+			for _, f := range []struct {
+				name       string
+				principals []string
+			}{
+				{"principals", source.GetPrincipals()},
+				{"notPrincipals", source.GetNotPrincipals()},
+			} {
+				for k, p := range f.principals {
+					path := fmt.Sprintf("spec.rules[%d].from[%d].source.%s[%d]", i, j, f.name, k)
+					if m, ok := principalMessage(r, td, p); ok {
+						ctx.Report(collections.IstioSecurityV1Beta1Authorizationpolicies.Name(), m.WithFieldPath(path))
+					}
+				}
+			}
+		}
+	}
+}
+
+func (a *TrustDomainAnalyzer) analyzeTrafficPolicy(ctx analysis.Context, r *resource.Instance, td trustDomains,
+	path string, policy *v1alpha3.TrafficPolicy) {
+
+	a.analyzeSubjectAltNames(ctx, r, collections.IstioNetworkingV1Alpha3Destinationrules.Name(), td,
+		path+".tls.subjectAltNames", policy.GetTls().GetSubjectAltNames())
+	for i, pls := range policy.GetPortLevelSettings() {
+		a.analyzeSubjectAltNames(ctx, r, collections.IstioNetworkingV1Alpha3Destinationrules.Name(), td,
+			fmt.Sprintf("%s.portLevelSettings[%d].tls.subjectAltNames", path, i), pls.GetTls().GetSubjectAltNames())
+	}
+}
+
+func (a *TrustDomainAnalyzer) analyzeSubjectAltNames(ctx analysis.Context, r *resource.Instance,
+	c collection.Name, td trustDomains, path string, sans []string) {
+
+	for i, san := range sans {
+		if !strings.HasPrefix(san, spiffe.URIPrefix) {
+			continue
+		}
+		domain := strings.SplitN(strings.TrimPrefix(san, spiffe.URIPrefix), "/", 2)[0]
+		// Certificates are only issued for the trust domain and its aliases, so unlike principals, SPIFFE IDs of
+		// subject alt names are not rewritten from cluster.local.
+		if !td.contains(domain) {
+			ctx.Report(c, msg.NewIdentityTrustDomainMismatch(r, "subject alt name", san, domain, td.domain,
+				td.aliases).WithFieldPath(fmt.Sprintf("%s[%d]", path, i)))
+		}
+	}
+}
+
+// principalMessage returns the message about a principal of an authorization policy that can never match the identity
+// of a workload, if any. Principals have the form <trust domain>/ns/<namespace>/sa/<service account>, and may use a
+// wildcard as prefix or suffix.
+func principalMessage(r *resource.Instance, td trustDomains, principal string) (diag.Message, bool) {
+	if strings.HasPrefix(principal, spiffe.URIPrefix) {
+		return msg.NewMalformedPrincipal(r, principal,
+			fmt.Sprintf("it has a %s prefix, which the principals of authorization policies omit", spiffe.URIPrefix)), true
+	}
+
+	parts := strings.Split(principal, "/")
+	if len(parts) != 5 || parts[1] != "ns" || parts[3] != "sa" {
+		if strings.Contains(principal, "*") {
+			// Wildcards can match any part of identities
+			return diag.Message{}, false
+		}
+		return msg.NewMalformedPrincipal(r, principal,
+			"it does not have the form <trust domain>/ns/<namespace>/sa/<service account>"), true
+	}
+
+	// Authorization policies treat cluster.local as the trust domain of the mesh and its aliases, and do not check
+	// the trust domain if it is a wildcard.
+	domain := parts[0]
+	if domain == "*" || domain == constants.DefaultKubernetesDomain || td.matches(domain) {
+		return diag.Message{}, false
+	}
+	return msg.NewIdentityTrustDomainMismatch(r, "principal", principal, domain, td.domain, td.aliases), true
+}
+
+// trustDomains holds the trust domain of the mesh and its aliases.
+type trustDomains struct {
+	domain  string
+	aliases []string
+}
+
+// meshTrustDomains returns the trust domain of the mesh and its aliases, from the mesh config of the analysis.
+func meshTrustDomains(ctx analysis.Context) trustDomains {
+	mc := analysis.MeshConfig(ctx)
+	td := trustDomains{domain: mc.GetTrustDomain(), aliases: mc.GetTrustDomainAliases()}
+	if td.domain == "" {
+		td.domain = constants.DefaultKubernetesDomain
+	}
+	return td
+}
+
+// contains returns whether the given trust domain is the one of the mesh or one of its aliases.
+func (t trustDomains) contains(domain string) bool {
+	if domain == t.domain {
+		return true
+	}
+	for _, a := range t.aliases {
+		if domain == a {
+			return true
+		}
+	}
+	return false
+}
+
+// matches returns whether the trust domain of a principal, which may have a wildcard prefix or suffix, matches the
+// trust domain of the mesh or one of its aliases, the same way authorization policies match them.
+func (t trustDomains) matches(pattern string) bool {
+	for _, d := range append([]string{t.domain}, t.aliases...) {
+		switch {
+		case pattern == d:
+			return true
+		case strings.HasPrefix(pattern, "*") && strings.HasSuffix(d, strings.TrimPrefix(pattern, "*")):
+			return true
+		case strings.HasSuffix(pattern, "*") && strings.HasPrefix(d, strings.TrimSuffix(pattern, "*")):
+			return true
+		}
+	}
+	return false
+}
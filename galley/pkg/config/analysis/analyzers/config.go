@@ -0,0 +1,102 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package analyzers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/ghodss/yaml"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+)
+
+// Config selects and configures the analyzers of continuous analysis, e.g. as read from a ConfigMap mounted into
+// istiod:
+//
+//   enable:
+//   - destinationrule.ConnectionPoolAnalyzer
+//   disable:
+//   - deprecation.DeprecationAnalyzer
+//   parameters:
+//     gateway.SecretAnalyzer:
+//       certExpiryWindow: 720h
+//     controlplane.SkewAnalyzer:
+//       maxMinorVersionsBehind: "2"
+//   severityOverrides:
+//     IST0118: Error
+type Config struct {
+	// Enable are the optional analyzers to run in addition to the default ones.
+	Enable []string `json:"enable,omitempty"`
+
+	// Disable are the analyzers not to run.
+	Disable []string `json:"disable,omitempty"`
+
+	// Parameters holds the parameters of analyzers, by analyzer name.
+	Parameters map[string]map[string]string `json:"parameters,omitempty"`
+
+	// SeverityOverrides changes the level of messages, by message code.
+	SeverityOverrides map[string]string `json:"severityOverrides,omitempty"`
+}
+
+// ReadConfigFile reads a Config from a yaml file. Unknown fields are rejected, so that misspelled settings are not
+// silently ignored.
+func ReadConfigFile(path string) (*Config, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading analyzer config %q: %v", path, err)
+	}
+	js, err := yaml.YAMLToJSON(b)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing analyzer config %q: %v", path, err)
+	}
+
+	c := &Config{}
+	d := json.NewDecoder(bytes.NewReader(js))
+	d.DisallowUnknownFields()
+	if err := d.Decode(c); err != nil {
+		return nil, fmt.Errorf("error parsing analyzer config %q: %v", path, err)
+	}
+	return c, nil
+}
+
+// Analyzers returns the analyzers selected by the configuration (see Select), with their parameters set.
+func (c *Config) Analyzers() ([]analysis.Analyzer, error) {
+	selected, err := Select(c.Enable, c.Disable)
+	if err != nil {
+		return nil, err
+	}
+	if err := analysis.Combine("config", selected...).Configure(c.Parameters); err != nil {
+		return nil, err
+	}
+	return selected, nil
+}
+
+// Levels returns the levels of the severity overrides of the configuration, by message code.
+func (c *Config) Levels() (map[string]diag.Level, error) {
+	result := make(map[string]diag.Level, len(c.SeverityOverrides))
+	for code, level := range c.SeverityOverrides {
+		l, ok := diag.ParseLevel(level)
+		if !ok || level == "" {
+			return nil, fmt.Errorf("invalid severity override %q for %s: must be one of %v", level, code,
+				diag.GetAllLevelStrings())
+		}
+		result[code] = l
+	}
+	return result, nil
+}
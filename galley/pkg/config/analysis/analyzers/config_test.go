@@ -0,0 +1,128 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package analyzers
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/gateway"
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+)
+
+func names(analyzers []analysis.Analyzer) []string {
+	var result []string
+	for _, a := range analyzers {
+		result = append(result, a.Metadata().Name)
+	}
+	return result
+}
+
+func TestSelect(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	selected, err := Select(nil, nil)
+	g.Expect(err).To(BeNil())
+	g.Expect(names(selected)).To(Equal(names(All())))
+
+	selected, err = Select(
+		[]string{"destinationrule.ConnectionPoolAnalyzer", "gateway.ExposureAnalyzer", "gateway.ExposureAnalyzer"},
+		[]string{"gateway.SecretAnalyzer", "gateway.ExposureAnalyzer"})
+	g.Expect(err).To(BeNil())
+	g.Expect(names(selected)).NotTo(ContainElement("gateway.SecretAnalyzer"))
+	g.Expect(names(selected)).NotTo(ContainElement("gateway.ExposureAnalyzer"))
+	g.Expect(names(selected)).To(ContainElement("destinationrule.ConnectionPoolAnalyzer"))
+	g.Expect(selected).To(HaveLen(len(All())))
+
+	_, err = Select([]string{"gateway.SecretAnalyzer"}, nil)
+	g.Expect(err).To(MatchError(ContainSubstring("not an optional analyzer")))
+
+	_, err = Select(nil, []string{"gateway.NoSuchAnalyzer"})
+	g.Expect(err).To(MatchError(ContainSubstring("not an analyzer")))
+}
+
+func writeConfig(t *testing.T, dir, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, "config.yaml")
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func tempDir(t *testing.T) string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "analyzers")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestReadConfigFile(t *testing.T) {
+	g := NewGomegaWithT(t)
+	dir := tempDir(t)
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	c, err := ReadConfigFile(writeConfig(t, dir, `
+disable:
+- gateway.ConflictingServersAnalyzer
+parameters:
+  gateway.SecretAnalyzer:
+    certExpiryWindow: 720h
+severityOverrides:
+  IST0101: Info
+`))
+	g.Expect(err).To(BeNil())
+
+	selected, err := c.Analyzers()
+	g.Expect(err).To(BeNil())
+	g.Expect(names(selected)).NotTo(ContainElement("gateway.ConflictingServersAnalyzer"))
+	for _, a := range selected {
+		if s, ok := a.(*gateway.SecretAnalyzer); ok {
+			g.Expect(s.ExpiryWindow).To(Equal(720 * time.Hour))
+		}
+	}
+
+	levels, err := c.Levels()
+	g.Expect(err).To(BeNil())
+	g.Expect(levels).To(Equal(map[string]diag.Level{"IST0101": diag.Info}))
+}
+
+func TestReadConfigFileErrors(t *testing.T) {
+	g := NewGomegaWithT(t)
+	dir := tempDir(t)
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	_, err := ReadConfigFile(writeConfig(t, dir, "disabled: [gateway.SecretAnalyzer]"))
+	g.Expect(err).To(MatchError(ContainSubstring("unknown field")))
+
+	_, err = ReadConfigFile("nonexistent.yaml")
+	g.Expect(err).NotTo(BeNil())
+
+	c := &Config{Parameters: map[string]map[string]string{"gateway.SecretAnalyzer": {"unknown": "x"}}}
+	_, err = c.Analyzers()
+	g.Expect(err).To(MatchError(ContainSubstring("unknown parameter")))
+
+	c = &Config{SeverityOverrides: map[string]string{"IST0101": "Fatal"}}
+	_, err = c.Levels()
+	g.Expect(err).To(MatchError(ContainSubstring("IST0101")))
+}
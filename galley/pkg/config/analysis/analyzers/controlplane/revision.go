@@ -0,0 +1,108 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controlplane
+
+import (
+	"sort"
+	"strings"
+
+	admissionv1beta1 "k8s.io/api/admissionregistration/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+// RevisionAnalyzer checks that the sidecar injectors of different control plane revisions don't select the same
+// namespaces. Injectors that also select pods by an object selector are not considered, as they may still be disjoint.
+type RevisionAnalyzer struct{}
+
+var _ analysis.Analyzer = &RevisionAnalyzer{}
+
+const (
+	// The names of the sidecar injection webhooks end with this suffix, e.g. namespace.sidecar-injector.istio.io.
+	injectionWebhookSuffix = "sidecar-injector.istio.io"
+
+	// The revision of injectors without a revision label.
+	defaultRevision = "default"
+)
+
+// Metadata implements analysis.Analyzer
+func (a *RevisionAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:        "controlplane.RevisionAnalyzer",
+		Description: "Checks that the sidecar injectors of different control plane revisions select different namespaces",
+		Inputs: collection.Names{
+			collections.K8SAdmissionregistrationK8SIoV1Beta1Mutatingwebhookconfigurations.Name(),
+			collections.K8SCoreV1Namespaces.Name(),
+		},
+	}
+}
+
+// Analyze implements analysis.Analyzer
+func (a *RevisionAnalyzer) Analyze(ctx analysis.Context) {
+	selectors := make(map[string][]labels.Selector)
+	ctx.ForEach(collections.K8SAdmissionregistrationK8SIoV1Beta1Mutatingwebhookconfigurations.Name(),
+		func(r *resource.Instance) bool {
+			revision := revisionOf(r.Metadata.Labels)
+			for _, w := range r.Message.(*admissionv1beta1.MutatingWebhookConfiguration).Webhooks {
+				if !strings.HasSuffix(w.Name, injectionWebhookSuffix) || !emptySelector(w.ObjectSelector) {
+					continue
+				}
+				if s, err := namespaceSelector(w.NamespaceSelector); err == nil {
+					selectors[revision] = append(selectors[revision], s)
+				}
+			}
+			return true
+		})
+	if len(selectors) < 2 {
+		return
+	}
+
+	ctx.ForEach(collections.K8SCoreV1Namespaces.Name(), func(r *resource.Instance) bool {
+		nsLabels := labels.Set(r.Metadata.Labels)
+		var revisions []string
+		for revision, ss := range selectors {
+			for _, s := range ss {
+				if s.Matches(nsLabels) {
+					revisions = append(revisions, revision)
+					break
+				}
+			}
+		}
+		if len(revisions) > 1 {
+			sort.Strings(revisions)
+			ctx.Report(collections.K8SCoreV1Namespaces.Name(), msg.NewOverlappingInjectionRevisions(r, revisions))
+		}
+		return true
+	})
+}
+
+// namespaceSelector converts the namespace selector of a webhook. Like for the API server, a webhook without one
+// selects all namespaces.
+func namespaceSelector(s *metav1.LabelSelector) (labels.Selector, error) {
+	if s == nil {
+		return labels.Everything(), nil
+	}
+	return metav1.LabelSelectorAsSelector(s)
+}
+
+func emptySelector(s *metav1.LabelSelector) bool {
+	return s == nil || (len(s.MatchLabels) == 0 && len(s.MatchExpressions) == 0)
+}
@@ -0,0 +1,145 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controlplane
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+// RootCertAnalyzer checks the root CA of the mesh: when istiod runs in the root namespace, a root CA secret must
+// exist there, and its root certificate must not expire soon.
+type RootCertAnalyzer struct {
+	// ExpiryWindow is the time before the expiry of the root certificate from which it is reported. Defaults to 90
+	// days, as rotating a root certificate takes longer than rotating other certificates.
+	ExpiryWindow time.Duration
+}
+
+var _ analysis.Analyzer = &RootCertAnalyzer{}
+var _ analysis.Configurable = &RootCertAnalyzer{}
+
+const (
+	defaultRootExpiryWindow = 90 * 24 * time.Hour
+
+	// The secret with a CA certificate and key plugged in by the user, which istiod prefers, and the one with the
+	// self-signed CA that istiod creates otherwise.
+	pluggedInCASecret  = "cacerts"
+	selfSignedCASecret = "istio-ca-secret"
+
+	pluggedInRootCertKey  = "root-cert.pem"
+	selfSignedRootCertKey = "ca-cert.pem"
+
+	istiodAppLabel = "istiod"
+)
+
+// Metadata implements analysis.Analyzer
+func (a *RootCertAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:        "controlplane.RootCertAnalyzer",
+		Description: "Checks that the root CA secret of the mesh exists and its certificate does not expire soon",
+		Inputs: collection.Names{
+			collections.IstioMeshV1Alpha1MeshConfig.Name(),
+			collections.K8SAppsV1Deployments.Name(),
+			collections.K8SCoreV1Secrets.Name(),
+		},
+		TimeDependent: true,
+	}
+}
+
+// Configure implements analysis.Configurable. The certExpiryWindow parameter sets ExpiryWindow.
+func (a *RootCertAnalyzer) Configure(params map[string]string) error {
+	for k, v := range params {
+		switch k {
+		case "certExpiryWindow":
+			d, err := time.ParseDuration(v)
+			if err != nil || d < 0 {
+				return fmt.Errorf("invalid certExpiryWindow %q: must be a non-negative duration", v)
+			}
+			a.ExpiryWindow = d
+		default:
+			return fmt.Errorf("unknown parameter %q", k)
+		}
+	}
+	return nil
+}
+
+// Analyze implements analysis.Analyzer
+func (a *RootCertAnalyzer) Analyze(ctx analysis.Context) {
+	rootNamespace := resource.Namespace(analysis.MeshConfig(ctx).GetRootNamespace())
+
+	secret, key := ctx.Find(collections.K8SCoreV1Secrets.Name(),
+		resource.NewFullName(rootNamespace, pluggedInCASecret)), pluggedInRootCertKey
+	if secret == nil {
+		secret, key = ctx.Find(collections.K8SCoreV1Secrets.Name(),
+			resource.NewFullName(rootNamespace, selfSignedCASecret)), selfSignedRootCertKey
+	}
+
+	if secret == nil {
+		// Without istiod, e.g. when analyzing files, the CA may just not be part of the analyzed resources.
+		ctx.ForEach(collections.K8SAppsV1Deployments.Name(), func(r *resource.Instance) bool {
+			d := r.Message.(*appsv1.Deployment)
+			if r.Metadata.FullName.Namespace == rootNamespace && d.Spec.Template.Labels["app"] == istiodAppLabel {
+				ctx.Report(collections.K8SAppsV1Deployments.Name(), msg.NewRootCASecretMissing(r, rootNamespace.String()))
+			}
+			return true
+		})
+		return
+	}
+
+	cert := rootCertificate(secret.Message.(*v1.Secret), key)
+	if cert == nil {
+		return
+	}
+
+	window := a.ExpiryWindow
+	if window == 0 {
+		window = defaultRootExpiryWindow
+	}
+	now := time.Now()
+	expiry := cert.NotAfter.UTC().Format(time.RFC3339)
+	switch {
+	case now.After(cert.NotAfter):
+		ctx.Report(collections.K8SCoreV1Secrets.Name(),
+			msg.NewRootCertificateExpired(secret, secret.Metadata.FullName.String(), expiry))
+	case now.Add(window).After(cert.NotAfter):
+		ctx.Report(collections.K8SCoreV1Secrets.Name(),
+			msg.NewRootCertificateExpiring(secret, secret.Metadata.FullName.String(), expiry, window.String()))
+	}
+}
+
+// rootCertificate returns the first certificate under the key of the secret, or nil if there is none. Malformed
+// secrets are left to istiod, which refuses to start with them.
+func rootCertificate(secret *v1.Secret, key string) *x509.Certificate {
+	block, _ := pem.Decode(secret.Data[key])
+	if block == nil {
+		return nil
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil
+	}
+	return cert
+}
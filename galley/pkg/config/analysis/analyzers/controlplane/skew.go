@@ -0,0 +1,197 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controlplane
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	goversion "github.com/hashicorp/go-version"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+
+	"istio.io/api/label"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/util"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+// SkewAnalyzer checks the versions of the proxies in the mesh against the istiod deployments of their revisions:
+// proxies must not be too many minor versions behind their control plane, their revision must still be installed, and
+// the pods of a namespace should all be injected by the same revision. Versions are taken from image tags, so images
+// without a version tag are not compared.
+type SkewAnalyzer struct {
+	// MaxMinorVersionsBehind is the number of minor versions a proxy may be behind its control plane. Defaults to 1.
+	MaxMinorVersionsBehind int
+}
+
+var _ analysis.Analyzer = &SkewAnalyzer{}
+var _ analysis.Configurable = &SkewAnalyzer{}
+
+const (
+	defaultMaxMinorVersionsBehind = 1
+
+	istioProxyName = "istio-proxy"
+	discoveryName  = "discovery"
+)
+
+// Metadata implements analysis.Analyzer
+func (a *SkewAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:        "controlplane.SkewAnalyzer",
+		Description: "Checks that proxies run versions and revisions of an installed control plane",
+		Inputs: collection.Names{
+			collections.K8SAppsV1Deployments.Name(),
+			collections.K8SCoreV1Namespaces.Name(),
+			collections.K8SCoreV1Pods.Name(),
+		},
+	}
+}
+
+// Configure implements analysis.Configurable. The maxMinorVersionsBehind parameter sets MaxMinorVersionsBehind.
+func (a *SkewAnalyzer) Configure(params map[string]string) error {
+	for k, v := range params {
+		switch k {
+		case "maxMinorVersionsBehind":
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				return fmt.Errorf("invalid maxMinorVersionsBehind %q: must be a non-negative integer", v)
+			}
+			a.MaxMinorVersionsBehind = n
+		default:
+			return fmt.Errorf("unknown parameter %q", k)
+		}
+	}
+	return nil
+}
+
+// Analyze implements analysis.Analyzer
+func (a *SkewAnalyzer) Analyze(ctx analysis.Context) {
+	controlPlanes := getControlPlaneVersions(ctx)
+	if len(controlPlanes) == 0 {
+		// Without istiod, e.g. when analyzing files, nothing is known about the installed revisions.
+		return
+	}
+	installed := make([]string, 0, len(controlPlanes))
+	for revision := range controlPlanes {
+		installed = append(installed, revision)
+	}
+	sort.Strings(installed)
+
+	maxBehind := a.MaxMinorVersionsBehind
+	if maxBehind == 0 {
+		maxBehind = defaultMaxMinorVersionsBehind
+	}
+
+	nsRevisions := make(map[resource.Namespace]map[string]bool)
+	ctx.ForEach(collections.K8SCoreV1Pods.Name(), func(r *resource.Instance) bool {
+		tag, ok := proxyImageTag(r.Message.(*v1.Pod))
+		if !ok {
+			return true
+		}
+
+		revision := revisionOf(r.Metadata.Labels)
+		ns := r.Metadata.FullName.Namespace
+		if nsRevisions[ns] == nil {
+			nsRevisions[ns] = make(map[string]bool)
+		}
+		nsRevisions[ns][revision] = true
+
+		controlPlane, ok := controlPlanes[revision]
+		if !ok {
+			ctx.Report(collections.K8SCoreV1Pods.Name(), msg.NewProxyRevisionNotInstalled(r, revision, installed))
+			return true
+		}
+		proxy, err := goversion.NewVersion(tag)
+		if err != nil || controlPlane == nil {
+			return true
+		}
+
+		// Only minor versions within the same major version are compared
+		if proxy.Segments()[0] != controlPlane.Segments()[0] {
+			return true
+		}
+		if behind := controlPlane.Segments()[1] - proxy.Segments()[1]; behind > maxBehind {
+			ctx.Report(collections.K8SCoreV1Pods.Name(),
+				msg.NewProxyRevisionVersionSkew(r, tag, behind, controlPlane.Original(), revision, maxBehind))
+		}
+		return true
+	})
+
+	for ns, revisions := range nsRevisions {
+		if len(revisions) < 2 {
+			continue
+		}
+		r := ctx.Find(collections.K8SCoreV1Namespaces.Name(), resource.NewFullName("", resource.LocalName(ns)))
+		if r == nil {
+			continue
+		}
+		names := make([]string, 0, len(revisions))
+		for revision := range revisions {
+			names = append(names, revision)
+		}
+		sort.Strings(names)
+		ctx.Report(collections.K8SCoreV1Namespaces.Name(), msg.NewMixedProxyRevisions(r, names))
+	}
+}
+
+// getControlPlaneVersions returns the versions of the istiod deployments by revision. The version is nil if the
+// image of a deployment has no version tag.
+func getControlPlaneVersions(ctx analysis.Context) map[string]*goversion.Version {
+	versions := make(map[string]*goversion.Version)
+	ctx.ForEach(collections.K8SAppsV1Deployments.Name(), func(r *resource.Instance) bool {
+		d := r.Message.(*appsv1.Deployment)
+		if d.Spec.Template.Labels["app"] != istiodAppLabel {
+			return true
+		}
+
+		revision := revisionOf(d.Spec.Template.Labels)
+		var version *goversion.Version
+		for _, c := range d.Spec.Template.Spec.Containers {
+			if c.Name == discoveryName {
+				version, _ = goversion.NewVersion(util.ImageTag(c.Image))
+			}
+		}
+		// Keep the oldest known version while a revision is rolled out, as its proxies are checked against it
+		if prev, ok := versions[revision]; !ok || (version != nil && (prev == nil || version.LessThan(prev))) {
+			versions[revision] = version
+		}
+		return true
+	})
+	return versions
+}
+
+// proxyImageTag returns the image tag of the proxy container of the pod, and whether the pod has a proxy.
+func proxyImageTag(pod *v1.Pod) (string, bool) {
+	for _, c := range pod.Spec.Containers {
+		if c.Name == istioProxyName {
+			return util.ImageTag(c.Image), true
+		}
+	}
+	return "", false
+}
+
+// revisionOf returns the control plane revision given by the labels of a resource.
+func revisionOf(labels map[string]string) string {
+	if revision := labels[label.IstioRev]; revision != "" {
+		return revision
+	}
+	return defaultRevision
+}
@@ -0,0 +1,158 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package controlplane contains analyzers for the cluster-level preconditions of the Istio control plane: its
+// admission webhooks, its root CA, its revisions and the versions of the proxies connected to them.
+package controlplane
+
+import (
+	"fmt"
+	"strings"
+
+	admissionv1beta1 "k8s.io/api/admissionregistration/v1beta1"
+	v1 "k8s.io/api/core/v1"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+// WebhookAnalyzer checks the admission webhooks of Istio: the services they call must exist and expose the called
+// port, and the validating webhooks must agree on their failure policy.
+type WebhookAnalyzer struct{}
+
+var _ analysis.Analyzer = &WebhookAnalyzer{}
+
+const (
+	// The names of Istio's webhooks end with this suffix, e.g. sidecar-injector.istio.io and validation.istio.io.
+	istioWebhookSuffix = ".istio.io"
+
+	// The port a webhook service is called on if the webhook does not give one.
+	defaultWebhookPort = 443
+)
+
+// webhook is an admission webhook of either kind.
+type webhook struct {
+	config        *resource.Instance
+	col           collection.Name
+	name          string
+	service       *admissionv1beta1.ServiceReference
+	failurePolicy admissionv1beta1.FailurePolicyType
+}
+
+// Metadata implements analysis.Analyzer
+func (a *WebhookAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:        "controlplane.WebhookAnalyzer",
+		Description: "Checks that the admission webhooks of Istio call existing services and fail consistently",
+		Inputs: collection.Names{
+			collections.K8SAdmissionregistrationK8SIoV1Beta1Mutatingwebhookconfigurations.Name(),
+			collections.K8SAdmissionregistrationK8SIoV1Beta1Validatingwebhookconfigurations.Name(),
+			collections.K8SCoreV1Services.Name(),
+		},
+	}
+}
+
+// Analyze implements analysis.Analyzer
+func (a *WebhookAnalyzer) Analyze(ctx analysis.Context) {
+	var validating []webhook
+	for _, w := range istioWebhooks(ctx) {
+		checkService(ctx, w)
+		if w.col == collections.K8SAdmissionregistrationK8SIoV1Beta1Validatingwebhookconfigurations.Name() {
+			validating = append(validating, w)
+		}
+	}
+
+	// Webhooks that ignore failures are reported if others fail, as they let invalid configuration through while
+	// the control plane is unavailable.
+	var failing *webhook
+	for i := range validating {
+		if validating[i].failurePolicy == admissionv1beta1.Fail {
+			failing = &validating[i]
+			break
+		}
+	}
+	if failing == nil {
+		return
+	}
+	for _, w := range validating {
+		if w.failurePolicy != admissionv1beta1.Fail {
+			ctx.Report(w.col, msg.NewInconsistentWebhookFailurePolicy(w.config, w.name, string(w.failurePolicy),
+				failing.name, string(failing.failurePolicy)))
+		}
+	}
+}
+
+// istioWebhooks returns the webhooks of Istio in all webhook configurations.
+func istioWebhooks(ctx analysis.Context) []webhook {
+	var result []webhook
+	add := func(r *resource.Instance, col collection.Name, name string, config admissionv1beta1.WebhookClientConfig,
+		failurePolicy *admissionv1beta1.FailurePolicyType) {
+
+		if !strings.HasSuffix(name, istioWebhookSuffix) {
+			return
+		}
+		// Ignore is the default failure policy of v1beta1 webhooks
+		policy := admissionv1beta1.Ignore
+		if failurePolicy != nil {
+			policy = *failurePolicy
+		}
+		result = append(result, webhook{config: r, col: col, name: name, service: config.Service, failurePolicy: policy})
+	}
+
+	mutating := collections.K8SAdmissionregistrationK8SIoV1Beta1Mutatingwebhookconfigurations.Name()
+	ctx.ForEach(mutating, func(r *resource.Instance) bool {
+		for _, w := range r.Message.(*admissionv1beta1.MutatingWebhookConfiguration).Webhooks {
+			add(r, mutating, w.Name, w.ClientConfig, w.FailurePolicy)
+		}
+		return true
+	})
+	validating := collections.K8SAdmissionregistrationK8SIoV1Beta1Validatingwebhookconfigurations.Name()
+	ctx.ForEach(validating, func(r *resource.Instance) bool {
+		for _, w := range r.Message.(*admissionv1beta1.ValidatingWebhookConfiguration).Webhooks {
+			add(r, validating, w.Name, w.ClientConfig, w.FailurePolicy)
+		}
+		return true
+	})
+	return result
+}
+
+// checkService reports webhooks calling a service that does not exist or does not expose the called port. Webhooks
+// calling a URL are not checked.
+func checkService(ctx analysis.Context, w webhook) {
+	if w.service == nil {
+		return
+	}
+
+	name := resource.NewFullName(resource.Namespace(w.service.Namespace), resource.LocalName(w.service.Name))
+	svc := ctx.Find(collections.K8SCoreV1Services.Name(), name)
+	if svc == nil {
+		ctx.Report(w.col, msg.NewReferencedResourceNotFound(w.config, "webhook service", name.String()))
+		return
+	}
+
+	port := int32(defaultWebhookPort)
+	if w.service.Port != nil {
+		port = *w.service.Port
+	}
+	for _, p := range svc.Message.(*v1.ServiceSpec).Ports {
+		if p.Port == port {
+			return
+		}
+	}
+	ctx.Report(w.col, msg.NewReferencedResourceNotFound(w.config, "webhook service port",
+		fmt.Sprintf("%s:%d", name, port)))
+}
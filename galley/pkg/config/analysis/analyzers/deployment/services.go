@@ -16,12 +16,12 @@ package deployment
 import (
 	apps_v1 "k8s.io/api/apps/v1"
 	core_v1 "k8s.io/api/core/v1"
-	k8s_labels "k8s.io/apimachinery/pkg/labels"
 
 	"istio.io/api/annotation"
 
 	"istio.io/istio/galley/pkg/config/analysis"
 	"istio.io/istio/galley/pkg/config/analysis/analyzers/injection"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/util"
 	"istio.io/istio/galley/pkg/config/analysis/msg"
 	"istio.io/istio/pkg/config/resource"
 	"istio.io/istio/pkg/config/schema/collection"
@@ -30,7 +30,7 @@ import (
 
 type ServiceAssociationAnalyzer struct{}
 
-var _ analysis.Analyzer = &ServiceAssociationAnalyzer{}
+var _ analysis.IncrementalAnalyzer = &ServiceAssociationAnalyzer{}
 
 type PortMap map[int32]ProtocolMap
 type ProtocolMap map[core_v1.Protocol]ServiceNames
@@ -53,13 +53,23 @@ func (s *ServiceAssociationAnalyzer) Metadata() analysis.Metadata {
 }
 func (s *ServiceAssociationAnalyzer) Analyze(c analysis.Context) {
 	c.ForEach(collections.K8SAppsV1Deployments.Name(), func(r *resource.Instance) bool {
-		if inMesh(r, c) {
-			s.analyzeDeployment(r, c)
-		}
+		s.AnalyzeResource(c, r)
 		return true
 	})
 }
 
+// IncrementalInput implements analysis.IncrementalAnalyzer
+func (s *ServiceAssociationAnalyzer) IncrementalInput() collection.Name {
+	return collections.K8SAppsV1Deployments.Name()
+}
+
+// AnalyzeResource implements analysis.IncrementalAnalyzer
+func (s *ServiceAssociationAnalyzer) AnalyzeResource(c analysis.Context, r *resource.Instance) {
+	if inMesh(r, c) {
+		s.analyzeDeployment(r, c)
+	}
+}
+
 // analyzeDeployment analyzes the specific service mesh deployment
 func (s *ServiceAssociationAnalyzer) analyzeDeployment(r *resource.Instance, c analysis.Context) {
 	d := r.Message.(*apps_v1.Deployment)
@@ -99,17 +109,9 @@ func (s *ServiceAssociationAnalyzer) analyzeDeployment(r *resource.Instance, c a
 func (s *ServiceAssociationAnalyzer) findMatchingServices(d *apps_v1.Deployment, c analysis.Context) []ServiceSpecWithName {
 	matchingSvcs := make([]ServiceSpecWithName, 0)
 
-	c.ForEach(collections.K8SCoreV1Services.Name(), func(r *resource.Instance) bool {
-		s := r.Message.(*core_v1.ServiceSpec)
-
-		sSelector := k8s_labels.SelectorFromSet(s.Selector)
-		pLabels := k8s_labels.Set(d.Spec.Template.Labels)
-		if sSelector.Matches(pLabels) {
-			matchingSvcs = append(matchingSvcs, ServiceSpecWithName{r.Metadata.FullName.String(), s})
-		}
-
-		return true
-	})
+	for _, r := range util.BuildServiceSelectorIndex(c).Matches(d.Spec.Template.Labels) {
+		matchingSvcs = append(matchingSvcs, ServiceSpecWithName{r.Metadata.FullName.String(), r.Message.(*core_v1.ServiceSpec)})
+	}
 
 	return matchingSvcs
 }
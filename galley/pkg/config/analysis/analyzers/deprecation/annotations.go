@@ -0,0 +1,79 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deprecation
+
+import (
+	"fmt"
+
+	"istio.io/api/annotation"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+// AnnotationAnalyzer checks for deprecated and alpha Istio annotations in Kubernetes resources
+type AnnotationAnalyzer struct{}
+
+var _ analysis.Analyzer = &AnnotationAnalyzer{}
+
+// deprecatedAnnotations maps annotations that are deprecated, or alpha annotations that are being replaced, to
+// guidance on how to upgrade. Annotations marked as deprecated in the annotation table of the API are reported as
+// well. To report another annotation, add it here.
+var deprecatedAnnotations = map[string]string{
+	annotation.AlphaKubernetesServiceAccounts.Name: "add VM workloads as WorkloadEntries and set their serviceAccount",
+	annotation.AlphaCanonicalServiceAccounts.Name:  "add VM workloads as WorkloadEntries and set their serviceAccount",
+}
+
+// Metadata implements analyzer.Analyzer
+func (*AnnotationAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:        "deprecation.AnnotationAnalyzer",
+		Description: "Checks for deprecated Istio annotations in Kubernetes resources",
+		Inputs: collection.Names{
+			collections.K8SCoreV1Namespaces.Name(),
+			collections.K8SCoreV1Services.Name(),
+			collections.K8SCoreV1Pods.Name(),
+			collections.K8SAppsV1Deployments.Name(),
+		},
+	}
+}
+
+// Analyze implements analysis.Analyzer
+func (a *AnnotationAnalyzer) Analyze(ctx analysis.Context) {
+	deprecated := make(map[string]string)
+	for _, ann := range annotation.AllResourceAnnotations() {
+		if ann.Deprecated {
+			deprecated[ann.Name] = "see the annotation reference for its replacement"
+		}
+	}
+	for name, guidance := range deprecatedAnnotations {
+		deprecated[name] = guidance
+	}
+
+	for _, col := range a.Metadata().Inputs {
+		col := col
+		ctx.ForEach(col, func(r *resource.Instance) bool {
+			for ann := range r.Metadata.Annotations {
+				if guidance, ok := deprecated[ann]; ok {
+					ctx.Report(col, msg.NewDeprecated(r, deprecatedMessage(fmt.Sprintf("annotation %s", ann), guidance)))
+				}
+			}
+			return true
+		})
+	}
+}
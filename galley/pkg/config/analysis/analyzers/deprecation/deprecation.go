@@ -17,8 +17,12 @@ package deprecation
 import (
 	"fmt"
 
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/types"
+
 	"istio.io/api/networking/v1alpha3"
 	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/util"
 	"istio.io/istio/galley/pkg/config/analysis/msg"
 	"istio.io/istio/pkg/config/resource"
 	"istio.io/istio/pkg/config/schema/collection"
@@ -32,41 +36,134 @@ type FieldAnalyzer struct{}
 // Run `find . -name "*.proto" -exec grep -i "deprecated=true" \{\} \; -print`
 // to see what is deprecated.  This analyzer is hand-crafted.
 
+// deprecatedField is a deprecated field of an Istio resource, along with guidance on how to upgrade.
+type deprecatedField struct {
+	collection collection.Name
+	// field is the path of the field, as shown to users
+	field    string
+	guidance string
+	// used returns whether the resource sets the field
+	used func(r *resource.Instance) bool
+	// fix, if set, moves the value of the field to its replacement in the given copy of the spec, as described by
+	// fixDescription
+	fix            func(spec proto.Message)
+	fixDescription string
+}
+
+// deprecatedFields are the deprecated fields reported by the FieldAnalyzer. To report another deprecated field,
+// add it here.
+var deprecatedFields = []deprecatedField{
+	{
+		collection: collections.IstioNetworkingV1Alpha3Virtualservices.Name(),
+		field:      "HTTPRoute.fault.delay.percent",
+		guidance:   "use HTTPRoute.fault.delay.percentage",
+		used: func(r *resource.Instance) bool {
+			for _, httpRoute := range r.Message.(*v1alpha3.VirtualService).GetHttp() {
+				if httpRoute.GetFault().GetDelay().GetPercent() > 0 {
+					return true
+				}
+			}
+			return false
+		},
+		fixDescription: "Move the value of HTTPRoute.fault.delay.percent to HTTPRoute.fault.delay.percentage",
+		fix: func(spec proto.Message) {
+			for _, httpRoute := range spec.(*v1alpha3.VirtualService).GetHttp() {
+				delay := httpRoute.GetFault().GetDelay()
+				if delay.GetPercent() == 0 {
+					continue
+				}
+				if delay.Percentage == nil {
+					delay.Percentage = &v1alpha3.Percent{Value: float64(delay.Percent)}
+				}
+				delay.Percent = 0
+			}
+		},
+	},
+	{
+		collection: collections.IstioNetworkingV1Alpha3Destinationrules.Name(),
+		field:      "TrafficPolicy.outlierDetection.consecutiveErrors",
+		guidance:   "use TrafficPolicy.outlierDetection.consecutive5xxErrors or consecutiveGatewayErrors",
+		used: func(r *resource.Instance) bool {
+			dr := r.Message.(*v1alpha3.DestinationRule)
+			policies := []*v1alpha3.TrafficPolicy{dr.GetTrafficPolicy()}
+			for _, subset := range dr.GetSubsets() {
+				policies = append(policies, subset.GetTrafficPolicy())
+			}
+			for _, policy := range policies {
+				if policy.GetOutlierDetection().GetConsecutiveErrors() > 0 {
+					return true
+				}
+				for _, port := range policy.GetPortLevelSettings() {
+					if port.GetOutlierDetection().GetConsecutiveErrors() > 0 {
+						return true
+					}
+				}
+			}
+			return false
+		},
+		fixDescription: "Move the value of TrafficPolicy.outlierDetection.consecutiveErrors to " +
+			"TrafficPolicy.outlierDetection.consecutiveGatewayErrors",
+		fix: func(spec proto.Message) {
+			// consecutiveErrors counted 502, 503 and 504 responses, which is what consecutiveGatewayErrors counts.
+			fix := func(od *v1alpha3.OutlierDetection) {
+				if od.GetConsecutiveErrors() == 0 {
+					return
+				}
+				if od.ConsecutiveGatewayErrors == nil {
+					od.ConsecutiveGatewayErrors = &types.UInt32Value{Value: uint32(od.ConsecutiveErrors)}
+				}
+				od.ConsecutiveErrors = 0
+			}
+			dr := spec.(*v1alpha3.DestinationRule)
+			policies := []*v1alpha3.TrafficPolicy{dr.GetTrafficPolicy()}
+			for _, subset := range dr.GetSubsets() {
+				policies = append(policies, subset.GetTrafficPolicy())
+			}
+			for _, policy := range policies {
+				fix(policy.GetOutlierDetection())
+				for _, port := range policy.GetPortLevelSettings() {
+					fix(port.GetOutlierDetection())
+				}
+			}
+		},
+	},
+}
+
 // Metadata implements analyzer.Analyzer
 func (*FieldAnalyzer) Metadata() analysis.Metadata {
+	var inputs collection.Names
+	seen := make(map[collection.Name]bool)
+	for _, f := range deprecatedFields {
+		if !seen[f.collection] {
+			seen[f.collection] = true
+			inputs = append(inputs, f.collection)
+		}
+	}
+
 	return analysis.Metadata{
 		Name:        "deprecation.DeprecationAnalyzer",
 		Description: "Checks for deprecated Istio types and fields",
-		Inputs: collection.Names{
-			collections.IstioNetworkingV1Alpha3Virtualservices.Name(),
-		},
+		Inputs:      inputs,
 	}
 }
 
 // Analyze implements analysis.Analyzer
 func (fa *FieldAnalyzer) Analyze(ctx analysis.Context) {
-	ctx.ForEach(collections.IstioNetworkingV1Alpha3Virtualservices.Name(), func(r *resource.Instance) bool {
-		fa.analyzeVirtualService(r, ctx)
-		return true
-	})
-}
-
-func (*FieldAnalyzer) analyzeVirtualService(r *resource.Instance, ctx analysis.Context) {
-
-	vs := r.Message.(*v1alpha3.VirtualService)
-
-	for _, httpRoute := range vs.Http {
-		if httpRoute.Fault != nil {
-			if httpRoute.Fault.Delay != nil {
-				if httpRoute.Fault.Delay.Percent > 0 {
-					ctx.Report(collections.IstioNetworkingV1Alpha3Virtualservices.Name(),
-						msg.NewDeprecated(r, replacedMessage("HTTPRoute.fault.delay.percent", "HTTPRoute.fault.delay.percentage")))
+	for _, f := range deprecatedFields {
+		f := f
+		ctx.ForEach(f.collection, func(r *resource.Instance) bool {
+			if f.used(r) {
+				m := msg.NewDeprecated(r, deprecatedMessage(f.field, f.guidance))
+				if f.fix != nil {
+					m = util.WithSpecFix(m, f.fixDescription, f.fix)
 				}
+				ctx.Report(f.collection, m)
 			}
-		}
+			return true
+		})
 	}
 }
 
-func replacedMessage(deprecated, replacement string) string {
-	return fmt.Sprintf("%s is deprecated; use %s", deprecated, replacement)
+func deprecatedMessage(deprecated, guidance string) string {
+	return fmt.Sprintf("%s is deprecated; %s", deprecated, guidance)
 }
@@ -0,0 +1,122 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package destinationrule
+
+import (
+	"fmt"
+
+	"istio.io/api/networking/v1alpha3"
+	v1 "k8s.io/api/core/v1"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/util"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	configKube "istio.io/istio/pkg/config/kube"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+// ConnectionPoolAnalyzer flags destination rule connection pool settings that are known to cause bursts of 503 errors.
+// These are heuristics that also match deliberate configurations, so the analyzer is optional.
+type ConnectionPoolAnalyzer struct{}
+
+var _ analysis.Analyzer = &ConnectionPoolAnalyzer{}
+
+// The ratio of maxConnections to http1MaxPendingRequests above which the pending queue is considered too small
+const pendingRequestsRatio = 10
+
+// upstream describes the protocols used by the destination of a destination rule
+type upstream struct {
+	http2 bool
+	grpc  bool
+}
+
+// Metadata implements Analyzer
+func (a *ConnectionPoolAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:        "destinationrule.ConnectionPoolAnalyzer",
+		Description: "Checks for destination rule connection pool settings that are likely to cause 503 errors",
+		Inputs: collection.Names{
+			collections.IstioMeshV1Alpha1MeshConfig.Name(),
+			collections.IstioNetworkingV1Alpha3Destinationrules.Name(),
+			collections.K8SCoreV1Services.Name(),
+		},
+	}
+}
+
+// Analyze implements Analyzer
+func (a *ConnectionPoolAnalyzer) Analyze(c analysis.Context) {
+	meshKeepalive := analysis.MeshConfig(c).GetTcpKeepalive() != nil
+
+	c.ForEach(collections.IstioNetworkingV1Alpha3Destinationrules.Name(), func(r *resource.Instance) bool {
+		dr := r.Message.(*v1alpha3.DestinationRule)
+		up := destinationProtocols(c, r.Metadata.FullName.Namespace, dr.GetHost())
+
+		a.analyzePolicy(c, r, "the traffic policy", dr.GetTrafficPolicy(), up, meshKeepalive)
+		for _, s := range dr.GetSubsets() {
+			a.analyzePolicy(c, r, fmt.Sprintf("subset %s", s.GetName()), s.GetTrafficPolicy(), up, meshKeepalive)
+		}
+		return true
+	})
+}
+
+func (a *ConnectionPoolAnalyzer) analyzePolicy(c analysis.Context, r *resource.Instance, name string,
+	policy *v1alpha3.TrafficPolicy, up upstream, meshKeepalive bool) {
+
+	pool := policy.GetConnectionPool()
+	if pool == nil {
+		return
+	}
+	http := pool.GetHttp()
+	tcp := pool.GetTcp()
+
+	if http.GetMaxRequestsPerConnection() == 1 &&
+		(up.http2 || http.GetH2UpgradePolicy() == v1alpha3.ConnectionPoolSettings_HTTPSettings_UPGRADE) {
+		c.Report(collections.IstioNetworkingV1Alpha3Destinationrules.Name(), msg.NewConnectionPoolRisk(r, name,
+			"maxRequestsPerConnection is 1 for an HTTP/2 destination. HTTP/2 multiplexes requests over few "+
+				"connections, so every request opens a new connection, which exhausts the connection limit under load."))
+	}
+
+	pending := http.GetHttp1MaxPendingRequests()
+	if max := tcp.GetMaxConnections(); pending > 0 && max > pending*pendingRequestsRatio {
+		c.Report(collections.IstioNetworkingV1Alpha3Destinationrules.Name(), msg.NewConnectionPoolRisk(r, name,
+			fmt.Sprintf("http1MaxPendingRequests (%d) is tiny compared to maxConnections (%d). Requests arriving "+
+				"while all connections are busy overflow the pending queue right away and fail instead of waiting.",
+				pending, max)))
+	}
+
+	if up.grpc && tcp.GetTcpKeepalive() == nil && !meshKeepalive {
+		c.Report(collections.IstioNetworkingV1Alpha3Destinationrules.Name(), msg.NewConnectionPoolRisk(r, name,
+			"tcpKeepalive is not set for a gRPC destination, neither here nor in the mesh config. Long-lived gRPC connections that stay idle can be "+
+				"dropped silently by load balancers or NAT, and requests sent on them fail until the connection is "+
+				"detected as dead."))
+	}
+}
+
+// destinationProtocols returns the protocols of the ports of the Kubernetes service that the host refers to, if any.
+func destinationProtocols(c analysis.Context, ns resource.Namespace, host string) upstream {
+	var up upstream
+	svc := c.Find(collections.K8SCoreV1Services.Name(), util.GetResourceNameFromHost(ns, host))
+	if svc == nil {
+		return up
+	}
+	for _, port := range svc.Message.(*v1.ServiceSpec).Ports {
+		p := configKube.ConvertProtocol(port.Port, port.Name, port.Protocol, port.AppProtocol)
+		up.http2 = up.http2 || p.IsHTTP2()
+		up.grpc = up.grpc || p.IsGRPC()
+	}
+	return up
+}
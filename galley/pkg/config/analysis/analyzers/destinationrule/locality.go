@@ -0,0 +1,234 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package destinationrule
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+
+	"istio.io/api/networking/v1alpha3"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/util"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+// LocalityLoadBalancingAnalyzer checks the locality load balancing settings of destination rules against the
+// localities of the endpoints of their host. Distributions must have weights summing to 100, and traffic must not be
+// distributed or failed over to localities that have no endpoints, which silently drops it, e.g. during failover
+// tests. Endpoint localities are only known for hosts that are Kubernetes services selecting pods.
+type LocalityLoadBalancingAnalyzer struct{}
+
+var _ analysis.Analyzer = &LocalityLoadBalancingAnalyzer{}
+
+// Labels pilot reads the locality of a pod from, either from the pod itself or from its node.
+const (
+	podLocalityLabel     = "istio-locality"
+	nodeRegionLabel      = "failure-domain.beta.kubernetes.io/region"
+	nodeZoneLabel        = "failure-domain.beta.kubernetes.io/zone"
+	nodeRegionLabelGA    = "topology.kubernetes.io/region"
+	nodeZoneLabelGA      = "topology.kubernetes.io/zone"
+	nodeSubzoneLabel     = "topology.istio.io/subzone"
+	localityLabelDivider = "."
+)
+
+// Metadata implements Analyzer
+func (a *LocalityLoadBalancingAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:        "destinationrule.LocalityLoadBalancingAnalyzer",
+		Description: "Checks destination rule locality load balancing settings against the localities of endpoints",
+		Inputs: collection.Names{
+			collections.IstioNetworkingV1Alpha3Destinationrules.Name(),
+			collections.K8SCoreV1Nodes.Name(),
+			collections.K8SCoreV1Pods.Name(),
+			collections.K8SCoreV1Services.Name(),
+		},
+	}
+}
+
+// Analyze implements Analyzer
+func (a *LocalityLoadBalancingAnalyzer) Analyze(c analysis.Context) {
+	workloads := util.BuildWorkloadIndex(c, collections.K8SCoreV1Pods.Name())
+
+	c.ForEach(collections.IstioNetworkingV1Alpha3Destinationrules.Name(), func(r *resource.Instance) bool {
+		dr := r.Message.(*v1alpha3.DestinationRule)
+
+		// The service behind the host, if the host is a Kubernetes service selecting pods.
+		var svcName resource.FullName
+		var selector map[string]string
+		if !strings.Contains(dr.GetHost(), util.Wildcard) {
+			svcName = util.GetResourceNameFromHost(r.Metadata.FullName.Namespace, dr.GetHost())
+			if svc := c.Find(collections.K8SCoreV1Services.Name(), svcName); svc != nil {
+				selector = svc.Message.(*v1.ServiceSpec).Selector
+			}
+		}
+
+		// Endpoint localities of the host, or nil if they are unknown.
+		localitiesOf := func(subsetLabels map[string]string) []string {
+			if len(selector) == 0 {
+				return nil
+			}
+			merged := make(map[string]string, len(selector)+len(subsetLabels))
+			for k, v := range selector {
+				merged[k] = v
+			}
+			for k, v := range subsetLabels {
+				merged[k] = v
+			}
+			return podLocalities(c, workloads.Select(svcName.Namespace, merged))
+		}
+
+		a.analyzePolicy(c, r, "traffic policy", dr.GetHost(), localitiesOf(nil), dr.GetTrafficPolicy())
+		for _, s := range dr.GetSubsets() {
+			a.analyzePolicy(c, r, fmt.Sprintf("traffic policy of subset %s", s.GetName()), dr.GetHost(),
+				localitiesOf(s.GetLabels()), s.GetTrafficPolicy())
+		}
+		return true
+	})
+}
+
+func (a *LocalityLoadBalancingAnalyzer) analyzePolicy(c analysis.Context, r *resource.Instance, name, host string,
+	localities []string, policy *v1alpha3.TrafficPolicy) {
+
+	a.analyzeSetting(c, r, name, host, localities, policy.GetLoadBalancer().GetLocalityLbSetting())
+	for _, pls := range policy.GetPortLevelSettings() {
+		a.analyzeSetting(c, r, fmt.Sprintf("port %d settings of the %s", pls.GetPort().GetNumber(), name), host,
+			localities, pls.GetLoadBalancer().GetLocalityLbSetting())
+	}
+}
+
+func (a *LocalityLoadBalancingAnalyzer) analyzeSetting(c analysis.Context, r *resource.Instance, name, host string,
+	localities []string, lb *v1alpha3.LocalityLoadBalancerSetting) {
+
+	if lb == nil || (lb.GetEnabled() != nil && !lb.GetEnabled().GetValue()) {
+		return
+	}
+
+	for _, d := range lb.GetDistribute() {
+		targets := make([]string, 0, len(d.GetTo()))
+		var total uint32
+		for to, weight := range d.GetTo() {
+			targets = append(targets, to)
+			total += weight
+		}
+		if total != 100 {
+			c.Report(collections.IstioNetworkingV1Alpha3Destinationrules.Name(),
+				msg.NewLocalityWeightsNotSumTo100(r, name, d.GetFrom(), int(total)))
+		}
+
+		if localities == nil {
+			continue
+		}
+		sort.Strings(targets)
+		for _, to := range targets {
+			if d.GetTo()[to] > 0 && !anyLocalityMatches(localities, to) {
+				c.Report(collections.IstioNetworkingV1Alpha3Destinationrules.Name(), msg.NewLocalityWithoutEndpoints(r,
+					name, fmt.Sprintf("distributes traffic from %s", d.GetFrom()), to, host))
+			}
+		}
+	}
+
+	if localities == nil {
+		return
+	}
+	for _, f := range lb.GetFailover() {
+		if !anyLocalityMatches(localities, f.GetTo()) {
+			c.Report(collections.IstioNetworkingV1Alpha3Destinationrules.Name(), msg.NewLocalityWithoutEndpoints(r,
+				name, fmt.Sprintf("fails over from %s", f.GetFrom()), f.GetTo(), host))
+		}
+	}
+}
+
+// podLocalities returns the localities of the given pods, in the region/zone/subzone form of locality load balancing
+// settings. Nil is returned if there are no pods, or the locality of any of them is unknown, as endpoints may then be
+// in any locality.
+func podLocalities(c analysis.Context, pods []*resource.Instance) []string {
+	if len(pods) == 0 {
+		return nil
+	}
+
+	result := make([]string, 0, len(pods))
+	for _, p := range pods {
+		l := podLocality(c, p)
+		if l == "" {
+			return nil
+		}
+		result = append(result, l)
+	}
+	return result
+}
+
+// podLocality returns the locality of a pod the same way pilot does: from its locality label if it has one, and from
+// the labels of its node otherwise. The empty string is returned if the locality is unknown.
+func podLocality(c analysis.Context, r *resource.Instance) string {
+	if l := r.Metadata.Labels[podLocalityLabel]; l != "" {
+		return strings.Replace(l, localityLabelDivider, "/", -1)
+	}
+
+	pod := r.Message.(*v1.Pod)
+	if pod.Spec.NodeName == "" {
+		return ""
+	}
+	node := c.Find(collections.K8SCoreV1Nodes.Name(), resource.NewFullName("", resource.LocalName(pod.Spec.NodeName)))
+	if node == nil {
+		return ""
+	}
+
+	labels := node.Metadata.Labels
+	region := labelValue(labels, nodeRegionLabel, nodeRegionLabelGA)
+	if region == "" {
+		return ""
+	}
+	return strings.Join([]string{region, labelValue(labels, nodeZoneLabel, nodeZoneLabelGA), labels[nodeSubzoneLabel]},
+		"/")
+}
+
+// labelValue returns the value of the GA label, falling back to the beta label.
+func labelValue(labels map[string]string, beta, ga string) string {
+	if v := labels[ga]; v != "" {
+		return v
+	}
+	return labels[beta]
+}
+
+func anyLocalityMatches(localities []string, pattern string) bool {
+	for _, l := range localities {
+		if localityMatches(l, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// localityMatches returns whether a region/zone/subzone locality matches a locality of a locality load balancing
+// setting, which may omit trailing parts or use "*" for them.
+func localityMatches(locality, pattern string) bool {
+	parts := strings.SplitN(locality, "/", 3)
+	for i, p := range strings.SplitN(pattern, "/", 3) {
+		if p == util.Wildcard {
+			return true
+		}
+		if i >= len(parts) || parts[i] != p {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,135 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package destinationrule
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gogo/protobuf/types"
+
+	"istio.io/api/networking/v1alpha3"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+// OutlierDetectionAnalyzer flags destination rule outlier detection settings that eject hosts so eagerly, so many or
+// for so long that transient errors turn into outages.
+type OutlierDetectionAnalyzer struct {
+	// MaxBaseEjectionTime is the base ejection time above which ejections are reported. Defaults to 5 minutes.
+	MaxBaseEjectionTime time.Duration
+}
+
+var _ analysis.Analyzer = &OutlierDetectionAnalyzer{}
+var _ analysis.Configurable = &OutlierDetectionAnalyzer{}
+
+// The default base ejection time above which a single ejection removes a host for minutes. The actual ejection time
+// grows with the number of times a host has been ejected.
+const defaultMaxBaseEjectionTime = 5 * time.Minute
+
+// Metadata implements Analyzer
+func (a *OutlierDetectionAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:        "destinationrule.OutlierDetectionAnalyzer",
+		Description: "Checks for destination rule outlier detection settings that eject healthy hosts",
+		Inputs: collection.Names{
+			collections.IstioNetworkingV1Alpha3Destinationrules.Name(),
+		},
+	}
+}
+
+// Configure implements Configurable. The maxBaseEjectionTime parameter sets MaxBaseEjectionTime.
+func (a *OutlierDetectionAnalyzer) Configure(params map[string]string) error {
+	for k, v := range params {
+		switch k {
+		case "maxBaseEjectionTime":
+			d, err := time.ParseDuration(v)
+			if err != nil || d <= 0 {
+				return fmt.Errorf("invalid maxBaseEjectionTime %q: must be a positive duration", v)
+			}
+			a.MaxBaseEjectionTime = d
+		default:
+			return fmt.Errorf("unknown parameter %q", k)
+		}
+	}
+	return nil
+}
+
+// Analyze implements Analyzer
+func (a *OutlierDetectionAnalyzer) Analyze(c analysis.Context) {
+	c.ForEach(collections.IstioNetworkingV1Alpha3Destinationrules.Name(), func(r *resource.Instance) bool {
+		dr := r.Message.(*v1alpha3.DestinationRule)
+
+		a.analyzePolicy(c, r, "the traffic policy", dr.GetTrafficPolicy())
+		for _, s := range dr.GetSubsets() {
+			a.analyzePolicy(c, r, fmt.Sprintf("subset %s", s.GetName()), s.GetTrafficPolicy())
+		}
+		return true
+	})
+}
+
+func (a *OutlierDetectionAnalyzer) analyzePolicy(c analysis.Context, r *resource.Instance, name string, policy *v1alpha3.TrafficPolicy) {
+	a.analyzeOutlierDetection(c, r, name, policy.GetOutlierDetection())
+	for _, pls := range policy.GetPortLevelSettings() {
+		a.analyzeOutlierDetection(c, r, fmt.Sprintf("port %d of %s", pls.GetPort().GetNumber(), name), pls.GetOutlierDetection())
+	}
+}
+
+func (a *OutlierDetectionAnalyzer) analyzeOutlierDetection(c analysis.Context, r *resource.Instance, name string,
+	od *v1alpha3.OutlierDetection) {
+
+	if od == nil {
+		return
+	}
+
+	for _, e := range []struct {
+		field string
+		value *types.UInt32Value
+	}{
+		{"consecutive5xxErrors", od.GetConsecutive_5XxErrors()},
+		{"consecutiveGatewayErrors", od.GetConsecutiveGatewayErrors()},
+	} {
+		if e.value != nil && e.value.GetValue() == 1 {
+			c.Report(collections.IstioNetworkingV1Alpha3Destinationrules.Name(), msg.NewAggressiveOutlierDetection(r, name,
+				fmt.Sprintf("ejects hosts on a single error (%s is 1), so any transient failure removes a healthy "+
+					"host from the load balancing pool.", e.field)))
+		}
+	}
+
+	if od.GetMaxEjectionPercent() == 100 {
+		c.Report(collections.IstioNetworkingV1Alpha3Destinationrules.Name(), msg.NewAggressiveOutlierDetection(r, name,
+			"can eject all hosts (maxEjectionPercent is 100), so errors affecting every host, e.g. a failing "+
+				"dependency, leave no host to send requests to."))
+	}
+
+	if t := od.GetBaseEjectionTime(); t != nil {
+		if d, err := types.DurationFromProto(t); err == nil && d > a.maxBaseEjectionTime() {
+			c.Report(collections.IstioNetworkingV1Alpha3Destinationrules.Name(), msg.NewAggressiveOutlierDetection(r, name,
+				fmt.Sprintf("ejects hosts for at least %s (baseEjectionTime), and longer on repeated ejections, so a "+
+					"transient error removes capacity for minutes.", d)))
+		}
+	}
+}
+
+func (a *OutlierDetectionAnalyzer) maxBaseEjectionTime() time.Duration {
+	if a.MaxBaseEjectionTime > 0 {
+		return a.MaxBaseEjectionTime
+	}
+	return defaultMaxBaseEjectionTime
+}
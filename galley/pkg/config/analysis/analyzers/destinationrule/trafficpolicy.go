@@ -0,0 +1,128 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package destinationrule
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gogo/protobuf/types"
+
+	"istio.io/api/networking/v1alpha3"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+// TrafficPolicyAnalyzer flags destination rule traffic policy settings that pass validation but break the
+// destination: durations that proxies reject, ISTIO_MUTUAL TLS with certificates that are ignored, and subsets whose
+// TLS mode contradicts the one of the destination rule. Outlier detection that can eject all hosts is reported by the
+// OutlierDetectionAnalyzer.
+type TrafficPolicyAnalyzer struct{}
+
+var _ analysis.Analyzer = &TrafficPolicyAnalyzer{}
+
+// Metadata implements Analyzer
+func (a *TrafficPolicyAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:        "destinationrule.TrafficPolicyAnalyzer",
+		Description: "Checks for destination rule traffic policy settings that break the destination",
+		Inputs: collection.Names{
+			collections.IstioNetworkingV1Alpha3Destinationrules.Name(),
+		},
+	}
+}
+
+// Analyze implements Analyzer
+func (a *TrafficPolicyAnalyzer) Analyze(c analysis.Context) {
+	c.ForEach(collections.IstioNetworkingV1Alpha3Destinationrules.Name(), func(r *resource.Instance) bool {
+		dr := r.Message.(*v1alpha3.DestinationRule)
+
+		a.analyzePolicy(c, r, "traffic policy", dr.GetTrafficPolicy())
+		for _, s := range dr.GetSubsets() {
+			a.analyzePolicy(c, r, fmt.Sprintf("traffic policy of subset %s", s.GetName()), s.GetTrafficPolicy())
+			a.analyzeSubsetTLS(c, r, s, dr.GetTrafficPolicy())
+		}
+		return true
+	})
+}
+
+func (a *TrafficPolicyAnalyzer) analyzePolicy(c analysis.Context, r *resource.Instance, name string,
+	policy *v1alpha3.TrafficPolicy) {
+
+	a.analyzeSettings(c, r, name, policy.GetConnectionPool(), policy.GetOutlierDetection(), policy.GetTls())
+	for _, pls := range policy.GetPortLevelSettings() {
+		a.analyzeSettings(c, r, fmt.Sprintf("port %d settings of the %s", pls.GetPort().GetNumber(), name),
+			pls.GetConnectionPool(), pls.GetOutlierDetection(), pls.GetTls())
+	}
+}
+
+func (a *TrafficPolicyAnalyzer) analyzeSettings(c analysis.Context, r *resource.Instance, name string,
+	pool *v1alpha3.ConnectionPoolSettings, od *v1alpha3.OutlierDetection, tls *v1alpha3.ClientTLSSettings) {
+
+	// Unset durations use the defaults, but durations that are set must be positive.
+	for _, d := range []struct {
+		field string
+		value *types.Duration
+	}{
+		{"connectionPool.tcp.connectTimeout", pool.GetTcp().GetConnectTimeout()},
+		{"outlierDetection.interval", od.GetInterval()},
+		{"outlierDetection.baseEjectionTime", od.GetBaseEjectionTime()},
+	} {
+		if d.value != nil && d.value.GetSeconds() == 0 && d.value.GetNanos() == 0 {
+			c.Report(collections.IstioNetworkingV1Alpha3Destinationrules.Name(), msg.NewZeroTrafficPolicyDuration(r, name, d.field))
+		}
+	}
+
+	if tls.GetMode() == v1alpha3.ClientTLSSettings_ISTIO_MUTUAL {
+		var fields []string
+		for _, f := range []struct {
+			field string
+			set   bool
+		}{
+			{"clientCertificate", tls.GetClientCertificate() != ""},
+			{"privateKey", tls.GetPrivateKey() != ""},
+			{"caCertificates", tls.GetCaCertificates() != ""},
+			{"subjectAltNames", len(tls.GetSubjectAltNames()) > 0},
+		} {
+			if f.set {
+				fields = append(fields, f.field)
+			}
+		}
+		if len(fields) > 0 {
+			c.Report(collections.IstioNetworkingV1Alpha3Destinationrules.Name(),
+				msg.NewIstioMutualWithCertificates(r, name, strings.Join(fields, ", ")))
+		}
+	}
+}
+
+// analyzeSubsetTLS reports subsets that use Istio mTLS while the traffic policy of the destination rule does not, or
+// the other way around. A subset policy overrides the destination rule policy, so both are used for the same host, and
+// the workloads behind it accept either Istio mTLS or other traffic, depending on their mTLS mode.
+func (a *TrafficPolicyAnalyzer) analyzeSubsetTLS(c analysis.Context, r *resource.Instance, s *v1alpha3.Subset,
+	policy *v1alpha3.TrafficPolicy) {
+
+	tls := policy.GetTls()
+	subsetTLS := s.GetTrafficPolicy().GetTls()
+	if tls == nil || subsetTLS == nil ||
+		(tls.GetMode() == v1alpha3.ClientTLSSettings_ISTIO_MUTUAL) == (subsetTLS.GetMode() == v1alpha3.ClientTLSSettings_ISTIO_MUTUAL) {
+		return
+	}
+	c.Report(collections.IstioNetworkingV1Alpha3Destinationrules.Name(),
+		msg.NewSubsetTLSModeContradiction(r, s.GetName(), subsetTLS.GetMode().String(), tls.GetMode().String()))
+}
@@ -0,0 +1,129 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoyfilter
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/gogo/protobuf/proto"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"istio.io/api/networking/v1alpha3"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+// ConflictingPatchAnalyzer checks for EnvoyFilters that insert at the same location of the same workloads' proxy
+// configuration. EnvoyFilters are applied in the order of their creation time, so the resulting order of the
+// inserted objects can differ between clusters where the resources were created in a different order.
+type ConflictingPatchAnalyzer struct{}
+
+var _ analysis.Analyzer = &ConflictingPatchAnalyzer{}
+
+// patchKey identifies the location an EnvoyFilter config patch inserts at, for a set of workloads.
+type patchKey struct {
+	namespace resource.Namespace
+	selector  string
+	location  string
+}
+
+// Metadata implements Analyzer
+func (a *ConflictingPatchAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:        "envoyfilter.ConflictingPatchAnalyzer",
+		Description: "Checks for EnvoyFilters inserting at the same location, whose order depends on creation time",
+		Inputs: collection.Names{
+			collections.IstioNetworkingV1Alpha3Envoyfilters.Name(),
+		},
+	}
+}
+
+// Analyze implements Analyzer
+func (a *ConflictingPatchAnalyzer) Analyze(c analysis.Context) {
+	filtersByKey := make(map[patchKey][]*resource.Instance)
+
+	c.ForEach(collections.IstioNetworkingV1Alpha3Envoyfilters.Name(), func(r *resource.Instance) bool {
+		ef := r.Message.(*v1alpha3.EnvoyFilter)
+
+		var selector string
+		if ef.WorkloadSelector != nil {
+			selector = labels.SelectorFromSet(ef.WorkloadSelector.Labels).String()
+		}
+
+		// Patches of a single EnvoyFilter are applied in order, so only count each filter once per location
+		seen := make(map[patchKey]bool)
+		for _, cp := range ef.ConfigPatches {
+			if !isInsert(cp.GetPatch().GetOperation()) {
+				continue
+			}
+			key := patchKey{
+				namespace: r.Metadata.FullName.Namespace,
+				selector:  selector,
+				location:  location(cp),
+			}
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			filtersByKey[key] = append(filtersByKey[key], r)
+		}
+		return true
+	})
+
+	for key, filters := range filtersByKey {
+		if len(filters) < 2 {
+			continue
+		}
+
+		names := getNames(filters)
+		for _, r := range filters {
+			c.Report(collections.IstioNetworkingV1Alpha3Envoyfilters.Name(),
+				msg.NewConflictingEnvoyFilterPatches(r, names, key.location))
+		}
+	}
+}
+
+func isInsert(op v1alpha3.EnvoyFilter_Patch_Operation) bool {
+	switch op {
+	case v1alpha3.EnvoyFilter_Patch_INSERT_BEFORE, v1alpha3.EnvoyFilter_Patch_INSERT_AFTER,
+		v1alpha3.EnvoyFilter_Patch_INSERT_FIRST:
+		return true
+	default:
+		return false
+	}
+}
+
+// location describes the object a config patch applies to, e.g. "HTTP_FILTER {context:SIDECAR_INBOUND ...}".
+func location(cp *v1alpha3.EnvoyFilter_EnvoyConfigObjectPatch) string {
+	if cp.Match == nil {
+		return cp.ApplyTo.String()
+	}
+	return fmt.Sprintf("%s {%s}", cp.ApplyTo, proto.CompactTextString(cp.Match))
+}
+
+// getNames returns the sorted names of the entries, so that messages do not depend on collection iteration order.
+func getNames(entries []*resource.Instance) []string {
+	names := make([]string, 0, len(entries))
+	for _, r := range entries {
+		names = append(names, string(r.Metadata.FullName.Name))
+	}
+	sort.Strings(names)
+	return names
+}
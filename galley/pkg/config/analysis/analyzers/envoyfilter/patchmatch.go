@@ -0,0 +1,126 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoyfilter
+
+import (
+	"istio.io/api/networking/v1alpha3"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+// PatchMatchAnalyzer checks the matches of EnvoyFilter patches for problems that make them apply differently than
+// intended, often only after an upgrade:
+// * matches by the names of listeners and filters that changed between versions
+// * INSERT_BEFORE and INSERT_AFTER patches without a match for the object to insert relative to
+type PatchMatchAnalyzer struct{}
+
+var _ analysis.Analyzer = &PatchMatchAnalyzer{}
+
+// renamedListeners maps listener names to the names used by newer versions.
+var renamedListeners = map[string]string{
+	"virtual": "virtualOutbound",
+}
+
+// renamedNetworkFilters maps deprecated Envoy network filter names to their canonical names.
+var renamedNetworkFilters = map[string]string{
+	"envoy.client_ssl_auth":         "envoy.filters.network.client_ssl_auth",
+	"envoy.echo":                    "envoy.filters.network.echo",
+	"envoy.ext_authz":               "envoy.filters.network.ext_authz",
+	"envoy.http_connection_manager": "envoy.filters.network.http_connection_manager",
+	"envoy.mongo_proxy":             "envoy.filters.network.mongo_proxy",
+	"envoy.ratelimit":               "envoy.filters.network.ratelimit",
+	"envoy.redis_proxy":             "envoy.filters.network.redis_proxy",
+	"envoy.tcp_proxy":               "envoy.filters.network.tcp_proxy",
+}
+
+// renamedHTTPFilters maps deprecated Envoy HTTP filter names to their canonical names.
+var renamedHTTPFilters = map[string]string{
+	"envoy.buffer":       "envoy.filters.http.buffer",
+	"envoy.cors":         "envoy.filters.http.cors",
+	"envoy.ext_authz":    "envoy.filters.http.ext_authz",
+	"envoy.fault":        "envoy.filters.http.fault",
+	"envoy.grpc_web":     "envoy.filters.http.grpc_web",
+	"envoy.gzip":         "envoy.filters.http.gzip",
+	"envoy.health_check": "envoy.filters.http.health_check",
+	"envoy.lua":          "envoy.filters.http.lua",
+	"envoy.rate_limit":   "envoy.filters.http.ratelimit",
+	"envoy.router":       "envoy.filters.http.router",
+}
+
+// Metadata implements Analyzer
+func (a *PatchMatchAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:        "envoyfilter.PatchMatchAnalyzer",
+		Description: "Checks for EnvoyFilter patches that match by renamed objects, or insert relative to nothing",
+		Inputs: collection.Names{
+			collections.IstioNetworkingV1Alpha3Envoyfilters.Name(),
+		},
+	}
+}
+
+// Analyze implements Analyzer
+func (a *PatchMatchAnalyzer) Analyze(c analysis.Context) {
+	c.ForEach(collections.IstioNetworkingV1Alpha3Envoyfilters.Name(), func(r *resource.Instance) bool {
+		ef := r.Message.(*v1alpha3.EnvoyFilter)
+
+		for _, cp := range ef.ConfigPatches {
+			applyTo := cp.GetApplyTo().String()
+			listener := cp.GetMatch().GetListener()
+			filter := listener.GetFilterChain().GetFilter()
+
+			for _, n := range []struct {
+				field   string
+				name    string
+				renamed map[string]string
+			}{
+				{"listener", listener.GetName(), renamedListeners},
+				{"network filter", filter.GetName(), renamedNetworkFilters},
+				{"HTTP filter", filter.GetSubFilter().GetName(), renamedHTTPFilters},
+			} {
+				if newName, ok := n.renamed[n.name]; ok {
+					c.Report(collections.IstioNetworkingV1Alpha3Envoyfilters.Name(),
+						msg.NewEnvoyFilterRenamedMatch(r, applyTo, n.field, n.name, newName))
+				}
+			}
+
+			var field string
+			var matched bool
+			switch cp.GetApplyTo() {
+			case v1alpha3.EnvoyFilter_NETWORK_FILTER:
+				field, matched = "filterChain.filter", filter != nil
+			case v1alpha3.EnvoyFilter_HTTP_FILTER:
+				field, matched = "filterChain.filter.subFilter", filter.GetSubFilter() != nil
+			default:
+				continue
+			}
+			if matched {
+				continue
+			}
+			switch op := cp.GetPatch().GetOperation(); op {
+			case v1alpha3.EnvoyFilter_Patch_INSERT_BEFORE:
+				c.Report(collections.IstioNetworkingV1Alpha3Envoyfilters.Name(),
+					msg.NewEnvoyFilterRelativePatchWithoutMatch(r, op.String(), applyTo, field, v1alpha3.EnvoyFilter_Patch_INSERT_FIRST.String()))
+			case v1alpha3.EnvoyFilter_Patch_INSERT_AFTER:
+				c.Report(collections.IstioNetworkingV1Alpha3Envoyfilters.Name(),
+					msg.NewEnvoyFilterRelativePatchWithoutMatch(r, op.String(), applyTo, field, v1alpha3.EnvoyFilter_Patch_ADD.String()))
+			}
+		}
+		return true
+	})
+}
@@ -0,0 +1,68 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoyfilter
+
+import (
+	"k8s.io/apimachinery/pkg/labels"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/util"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+// SelectorAnalyzer checks that the workload selectors of EnvoyFilters match at least one pod (including gateway
+// pods) in scope: the EnvoyFilter's namespace, or all namespaces for EnvoyFilters in the root namespace. For
+// selectors matching nothing, the pod matching most of the selector's labels is suggested.
+type SelectorAnalyzer struct{}
+
+var _ analysis.Analyzer = &SelectorAnalyzer{}
+
+// Metadata implements Analyzer
+func (a *SelectorAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:        "envoyfilter.SelectorAnalyzer",
+		Description: "Checks that EnvoyFilters that define a workload selector match at least one pod",
+		Inputs: collection.Names{
+			collections.IstioMeshV1Alpha1MeshConfig.Name(),
+			collections.IstioNetworkingV1Alpha3Envoyfilters.Name(),
+			collections.K8SCoreV1Pods.Name(),
+		},
+	}
+}
+
+// Analyze implements Analyzer
+func (a *SelectorAnalyzer) Analyze(c analysis.Context) {
+	rootNamespace := resource.Namespace(analysis.MeshConfig(c).GetRootNamespace())
+
+	c.ForEach(collections.IstioNetworkingV1Alpha3Envoyfilters.Name(), func(r *resource.Instance) bool {
+		selector := util.WorkloadSelector(r)
+		if len(selector) == 0 {
+			return true
+		}
+
+		ns := util.WorkloadSelectorNamespace(r, rootNamespace)
+		if len(util.SelectPods(c, ns, selector)) > 0 {
+			return true
+		}
+
+		sel := labels.SelectorFromSet(selector).String()
+		c.Report(collections.IstioNetworkingV1Alpha3Envoyfilters.Name(),
+			msg.NewUnmatchedWorkloadSelector(r, sel, util.ClosestMatch(util.SelectPods(c, ns, nil), selector)))
+		return true
+	})
+}
@@ -0,0 +1,41 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build gofuzz
+
+package fuzz
+
+import (
+	"hash/crc32"
+	"math/rand"
+)
+
+// Fuzz is the entry point for go-fuzz, e.g.
+//
+//   go-fuzz-build istio.io/istio/galley/pkg/config/analysis/analyzers/fuzz
+//   mkdir -p corpus && cp ../testdata/*.yaml corpus/
+//   go-fuzz -bin fuzz-fuzz.zip -workdir .
+//
+// The input is analyzed as is, and after a structure-aware mutation seeded by the input, since byte-level mutations of
+// YAML rarely result in schema-valid resources.
+func Fuzz(data []byte) int {
+	if err := Check(data); err != nil {
+		panic(err)
+	}
+	r := rand.New(rand.NewSource(int64(crc32.ChecksumIEEE(data))))
+	if err := Check(Mutate(data, r)); err != nil {
+		panic(err)
+	}
+	return 1
+}
@@ -0,0 +1,168 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package fuzz is a fuzzing harness for analyzers. It feeds Istio resources into every registered analyzer and checks
+// that no analyzer panics, that all of them complete, and that they report the same messages when run twice on the
+// same input. Inputs are YAML; resources that do not match their schema are dropped, just like istioctl analyze does,
+// so that analyzers only see schema-valid resources. Mutate derives such inputs from a seed corpus.
+package fuzz
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"istio.io/pkg/log"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers"
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+	"istio.io/istio/galley/pkg/config/analysis/local"
+	"istio.io/istio/galley/pkg/config/schema"
+	"istio.io/istio/galley/pkg/config/scope"
+)
+
+// DefaultTimeout is the time all analyzers together may take on a single input before they are considered to loop.
+const DefaultTimeout = 30 * time.Second
+
+// Check runs all registered analyzers twice on the resources in the given YAML, and returns an error if an analyzer
+// panics, if the analyzers do not complete within DefaultTimeout, or if the second run reports different messages.
+func Check(input []byte) error {
+	return check(input, analyzers.All(), DefaultTimeout)
+}
+
+func check(input []byte, as []analysis.Analyzer, timeout time.Duration) error {
+	first, err := analyze(input, as, timeout)
+	if err != nil {
+		return err
+	}
+	second, err := analyze(input, as, timeout)
+	if err != nil {
+		return err
+	}
+	if a, b := strings.Join(first, "\n"), strings.Join(second, "\n"); a != b {
+		return fmt.Errorf("analysis is not deterministic, first run reported:\n%s\nsecond run reported:\n%s", a, b)
+	}
+	return nil
+}
+
+// analyze runs the analyzers on the input and returns the reported messages as sorted strings.
+func analyze(input []byte, as []analysis.Analyzer, timeout time.Duration) ([]string, error) {
+	// Processing complains loudly about invalid resources, which are expected here.
+	prevLogLevel := scope.Processing.GetOutputLevel()
+	scope.Processing.SetOutputLevel(log.NoneLevel)
+	defer scope.Processing.SetOutputLevel(prevLogLevel)
+
+	st := &runState{running: make(map[string]struct{})}
+	guarded := make([]analysis.Analyzer, 0, len(as))
+	for _, a := range as {
+		guarded = append(guarded, &guard{Analyzer: a, st: st})
+	}
+
+	sa := local.NewSourceAnalyzer(schema.MustGet(), analysis.Combine("fuzz", guarded...), "", "istio-system", nil,
+		true, timeout)
+	if err := sa.AddDefaultResources(); err != nil {
+		return nil, err
+	}
+	// Resources that cannot be parsed or do not match their schema are skipped, the rest is analyzed.
+	_ = sa.AddReaderKubeSource([]local.ReaderSource{{Name: "fuzz", Reader: bytes.NewReader(input)}})
+
+	result, err := sa.Analyze(context.Background())
+	if panics := st.getPanics(); len(panics) > 0 {
+		return nil, fmt.Errorf("analyzer panicked: %s", strings.Join(panics, "\n"))
+	}
+	if err != nil {
+		if running := st.getRunning(); len(running) > 0 {
+			return nil, fmt.Errorf("analyzers did not complete within %v: %s", timeout, strings.Join(running, ", "))
+		}
+		return nil, err
+	}
+
+	return messageStrings(result.Messages), nil
+}
+
+func messageStrings(msgs diag.Messages) []string {
+	result := make([]string, 0, len(msgs))
+	for _, m := range msgs {
+		result = append(result, m.String())
+	}
+	sort.Strings(result)
+	return result
+}
+
+// runState tracks the analyzers of a single run.
+type runState struct {
+	mu      sync.Mutex
+	running map[string]struct{}
+	panics  []string
+}
+
+func (s *runState) start(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.running[name] = struct{}{}
+}
+
+func (s *runState) done(name string, recovered interface{}, stack []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.running, name)
+	if recovered != nil {
+		s.panics = append(s.panics, fmt.Sprintf("%s: %v\n%s", name, recovered, stack))
+	}
+}
+
+func (s *runState) getRunning() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := make([]string, 0, len(s.running))
+	for name := range s.running {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func (s *runState) getPanics() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.panics...)
+}
+
+// guard wraps an analyzer to record panics and analyzers that do not complete. Analyzers run on a goroutine of the
+// analysis pipeline, so a panic would otherwise take down the whole process without naming the analyzer.
+type guard struct {
+	analysis.Analyzer
+	st *runState
+}
+
+// Analyze implements analysis.Analyzer
+func (g *guard) Analyze(ctx analysis.Context) {
+	name := g.Metadata().Name
+	g.st.start(name)
+	defer func() {
+		r := recover()
+		var stack []byte
+		if r != nil {
+			stack = debug.Stack()
+		}
+		g.st.done(name, r, stack)
+	}()
+	g.Analyzer.Analyze(ctx)
+}
@@ -0,0 +1,154 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fuzz
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+// mutationsPerFile is the number of mutations of each seed file that are checked as part of the unit tests. Longer
+// runs should use go-fuzz, see Fuzz.
+const mutationsPerFile = 2
+
+const namespaceYAML = `
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: default
+`
+
+func TestAnalyzersOnMutatedResources(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping fuzzing in short mode")
+	}
+
+	files, err := filepath.Glob("../testdata/*.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range files {
+		input, err := ioutil.ReadFile(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for seed := int64(0); seed < mutationsPerFile; seed++ {
+			mutated := Mutate(input, rand.New(rand.NewSource(seed)))
+			if err := Check(mutated); err != nil {
+				t.Fatalf("%s mutated with seed %d: %v\ninput:\n%s", f, seed, err, mutated)
+			}
+		}
+	}
+}
+
+func TestMutate(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	input, err := ioutil.ReadFile("../testdata/virtualservice_gateways.yaml")
+	g.Expect(err).To(BeNil())
+
+	a := Mutate(input, rand.New(rand.NewSource(1)))
+	b := Mutate(input, rand.New(rand.NewSource(1)))
+	g.Expect(string(a)).To(Equal(string(b)))
+
+	// The types of the resources are preserved.
+	for seed := int64(0); seed < 20; seed++ {
+		mutated := string(Mutate(input, rand.New(rand.NewSource(seed))))
+		g.Expect(strings.Count(mutated, "kind: VirtualService")).To(BeNumerically(">=",
+			strings.Count(string(input), "kind: VirtualService")))
+	}
+
+	g.Expect(Mutate([]byte("not: [yaml"), rand.New(rand.NewSource(1)))).To(Equal([]byte("not: [yaml")))
+}
+
+type testAnalyzer struct {
+	name string
+	fn   func(ctx analysis.Context)
+}
+
+// Metadata implements analysis.Analyzer
+func (a *testAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:   a.name,
+		Inputs: collection.Names{collections.IstioMeshV1Alpha1MeshConfig.Name()},
+	}
+}
+
+// Analyze implements analysis.Analyzer
+func (a *testAnalyzer) Analyze(ctx analysis.Context) {
+	a.fn(ctx)
+}
+
+func TestCheck(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	ok := &testAnalyzer{name: "test.OK", fn: func(ctx analysis.Context) {
+		ctx.Report(collections.IstioMeshV1Alpha1MeshConfig.Name(), msg.NewInternalError(nil, "stable"))
+	}}
+	g.Expect(check([]byte(namespaceYAML), []analysis.Analyzer{ok}, 10*time.Second)).To(Succeed())
+}
+
+func TestCheckReportsPanics(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	a := &testAnalyzer{name: "test.Panics", fn: func(analysis.Context) {
+		var m map[string]interface{}
+		_ = m["selector"].(string)
+	}}
+	err := check([]byte(namespaceYAML), []analysis.Analyzer{a}, 10*time.Second)
+	g.Expect(err).NotTo(BeNil())
+	g.Expect(err.Error()).To(ContainSubstring("analyzer panicked: test.Panics"))
+}
+
+func TestCheckReportsHangs(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	release := make(chan struct{})
+	defer close(release)
+	a := &testAnalyzer{name: "test.Hangs", fn: func(analysis.Context) {
+		<-release
+	}}
+	err := check([]byte(namespaceYAML), []analysis.Analyzer{a}, 500*time.Millisecond)
+	g.Expect(err).To(MatchError(ContainSubstring("did not complete within 500ms: test.Hangs")))
+}
+
+func TestCheckReportsNondeterminism(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	var mu sync.Mutex
+	runs := 0
+	a := &testAnalyzer{name: "test.Nondeterministic", fn: func(ctx analysis.Context) {
+		mu.Lock()
+		runs++
+		n := runs
+		mu.Unlock()
+		ctx.Report(collections.IstioMeshV1Alpha1MeshConfig.Name(), msg.NewInternalError(nil, fmt.Sprintf("run %d", n)))
+	}}
+	err := check([]byte(namespaceYAML), []analysis.Analyzer{a}, 10*time.Second)
+	g.Expect(err).To(MatchError(ContainSubstring("analysis is not deterministic")))
+}
@@ -0,0 +1,216 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fuzz
+
+import (
+	"bytes"
+	"math/rand"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+)
+
+// maxMutations is the maximum number of mutations Mutate applies to an input.
+const maxMutations = 4
+
+var documentSeparator = regexp.MustCompile(`(?m)^---\s*$`)
+
+// Mutate returns a variant of the resources in the given multi-document YAML. Up to maxMutations random mutations are
+// applied that keep the documents well-formed: fields are removed, emptied, duplicated or replaced by values of the
+// same type, including values taken from elsewhere in the input, so that references between resources may or may not
+// resolve. Whole documents may be duplicated. The apiVersion and kind of each document are preserved. The result only
+// depends on the input and the state of r.
+func Mutate(input []byte, r *rand.Rand) []byte {
+	var docs []interface{}
+	for _, d := range documentSeparator.Split(string(input), -1) {
+		var obj map[string]interface{}
+		if err := yaml.Unmarshal([]byte(d), &obj); err != nil || len(obj) == 0 {
+			continue
+		}
+		docs = append(docs, obj)
+	}
+	if len(docs) == 0 {
+		return input
+	}
+	pool := stringValues(docs)
+
+	for n := 1 + r.Intn(maxMutations); n > 0; n-- {
+		if r.Intn(8) == 0 {
+			docs = append(docs, deepCopy(docs[r.Intn(len(docs))]))
+			continue
+		}
+
+		var slots []slot
+		walk(docs[r.Intn(len(docs))], func(interface{}) {}, &slots, 0)
+		if len(slots) == 0 {
+			continue
+		}
+		s := slots[r.Intn(len(slots))]
+		switch r.Intn(4) {
+		case 0:
+			s.del()
+		case 1:
+			s.set(replacement(s.value, r, pool))
+		case 2:
+			s.set(empty(s.value))
+		default:
+			if l, ok := s.value.([]interface{}); ok && len(l) > 0 {
+				s.set(append(l, deepCopy(l[r.Intn(len(l))])))
+			} else {
+				s.set(replacement(s.value, r, pool))
+			}
+		}
+	}
+
+	var b bytes.Buffer
+	for i, d := range docs {
+		y, err := yaml.Marshal(d)
+		if err != nil {
+			continue
+		}
+		if i > 0 {
+			b.WriteString("---\n")
+		}
+		b.Write(y)
+	}
+	return b.Bytes()
+}
+
+// slot is a mutable location in a document.
+type slot struct {
+	value interface{}
+	set   func(interface{})
+	del   func()
+}
+
+// walk collects the slots of v, in a stable order. set replaces v in its parent.
+func walk(v interface{}, set func(interface{}), slots *[]slot, depth int) {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			// Keep the type of the resource, so that it is still matched to its schema.
+			if depth == 0 && (k == "apiVersion" || k == "kind") {
+				continue
+			}
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			k := k
+			setter := func(n interface{}) { t[k] = n }
+			*slots = append(*slots, slot{value: t[k], set: setter, del: func() { delete(t, k) }})
+			walk(t[k], setter, slots, depth+1)
+		}
+
+	case []interface{}:
+		for i := range t {
+			i := i
+			setter := func(n interface{}) { t[i] = n }
+			del := func() { set(append(append([]interface{}(nil), t[:i]...), t[i+1:]...)) }
+			*slots = append(*slots, slot{value: t[i], set: setter, del: del})
+			walk(t[i], setter, slots, depth+1)
+		}
+	}
+}
+
+// interestingStrings are string values that analyzers tend to treat specially.
+var interestingStrings = []string{
+	"", "*", "~", "mesh", "*.example.com", "a/b/c", "a.b.svc.cluster.local", ":", "=", strings.Repeat("a", 300),
+}
+
+// interestingNumbers are numeric values that analyzers tend to treat specially, e.g. as ports.
+var interestingNumbers = []float64{0, -1, 1.5, 65535, 65536, 4294967296}
+
+func replacement(v interface{}, r *rand.Rand, pool []string) interface{} {
+	switch t := v.(type) {
+	case string:
+		if len(pool) > 0 && r.Intn(2) == 0 {
+			return pool[r.Intn(len(pool))]
+		}
+		return interestingStrings[r.Intn(len(interestingStrings))]
+	case float64:
+		return interestingNumbers[r.Intn(len(interestingNumbers))]
+	case bool:
+		return !t
+	default:
+		return empty(v)
+	}
+}
+
+func empty(v interface{}) interface{} {
+	switch v.(type) {
+	case map[string]interface{}:
+		return map[string]interface{}{}
+	case []interface{}:
+		return []interface{}{}
+	case float64:
+		return float64(0)
+	case bool:
+		return false
+	default:
+		return ""
+	}
+}
+
+// stringValues returns the distinct string values in the documents, sorted.
+func stringValues(docs []interface{}) []string {
+	seen := make(map[string]struct{})
+	var collect func(v interface{})
+	collect = func(v interface{}) {
+		switch t := v.(type) {
+		case string:
+			seen[t] = struct{}{}
+		case map[string]interface{}:
+			for _, c := range t {
+				collect(c)
+			}
+		case []interface{}:
+			for _, c := range t {
+				collect(c)
+			}
+		}
+	}
+	for _, d := range docs {
+		collect(d)
+	}
+	result := make([]string, 0, len(seen))
+	for s := range seen {
+		result = append(result, s)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func deepCopy(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(t))
+		for k, c := range t {
+			result[k] = deepCopy(c)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, 0, len(t))
+		for _, c := range t {
+			result = append(result, deepCopy(c))
+		}
+		return result
+	default:
+		return v
+	}
+}
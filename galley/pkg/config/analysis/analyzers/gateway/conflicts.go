@@ -0,0 +1,142 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gateway
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gogo/protobuf/proto"
+
+	"istio.io/api/networking/v1alpha3"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/util"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/host"
+	"istio.io/istio/pkg/config/protocol"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+// ConflictingServersAnalyzer checks gateways that select the same workload for servers on the same port that cannot
+// be merged: servers with different protocols, or servers with overlapping hosts but different TLS settings. The
+// proxy keeps only one of them, without any indication of which.
+type ConflictingServersAnalyzer struct{}
+
+var _ analysis.Analyzer = &ConflictingServersAnalyzer{}
+
+// Metadata implements analysis.Analyzer
+func (*ConflictingServersAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:        "gateway.ConflictingServersAnalyzer",
+		Description: "Checks for conflicting servers of gateways that select the same workload",
+		Inputs: collection.Names{
+			collections.IstioNetworkingV1Alpha3Gateways.Name(),
+			collections.K8SCoreV1Pods.Name(),
+		},
+	}
+}
+
+// Analyze implements analysis.Analyzer
+func (a *ConflictingServersAnalyzer) Analyze(c analysis.Context) {
+	// Gateways by the workloads they select
+	gateways := make(map[resource.FullName][]*resource.Instance)
+	var workloads []resource.FullName
+	c.ForEach(collections.IstioNetworkingV1Alpha3Gateways.Name(), func(r *resource.Instance) bool {
+		for _, pod := range util.SelectPods(c, "", r.Message.(*v1alpha3.Gateway).GetSelector()) {
+			name := pod.Metadata.FullName
+			if _, ok := gateways[name]; !ok {
+				workloads = append(workloads, name)
+			}
+			gateways[name] = append(gateways[name], r)
+		}
+		return true
+	})
+	sort.Slice(workloads, func(i, j int) bool {
+		return workloads[i].String() < workloads[j].String()
+	})
+
+	// Each conflict is reported once, for the first workload the gateways have in common
+	reported := make(map[string]bool)
+	for _, workload := range workloads {
+		gws := gateways[workload]
+		for i := range gws {
+			for j := i + 1; j < len(gws); j++ {
+				a.compare(c, gws[i], gws[j], workload, reported)
+			}
+		}
+	}
+}
+
+func (*ConflictingServersAnalyzer) compare(c analysis.Context, r1, r2 *resource.Instance, workload resource.FullName,
+	reported map[string]bool) {
+
+	gw1 := r1.Message.(*v1alpha3.Gateway)
+	gw2 := r2.Message.(*v1alpha3.Gateway)
+	for _, s1 := range gw1.GetServers() {
+		for _, s2 := range gw2.GetServers() {
+			if s1.GetPort() == nil || s2.GetPort() == nil || s1.Port.Number != s2.Port.Number {
+				continue
+			}
+			reason := conflict(s1, s2)
+			if reason == "" {
+				continue
+			}
+			key := fmt.Sprintf("%s/%s/%d", r1.Metadata.FullName, r2.Metadata.FullName, s1.Port.Number)
+			if reported[key] {
+				continue
+			}
+			reported[key] = true
+
+			port := int(s1.Port.Number)
+			c.Report(collections.IstioNetworkingV1Alpha3Gateways.Name(),
+				msg.NewConflictingGatewayServers(r1, port, r2.Metadata.FullName.String(), workload.String(), reason))
+			c.Report(collections.IstioNetworkingV1Alpha3Gateways.Name(),
+				msg.NewConflictingGatewayServers(r2, port, r1.Metadata.FullName.String(), workload.String(), reason))
+		}
+	}
+}
+
+// conflict returns why two servers on the same port cannot be merged, or "" if they can.
+func conflict(s1, s2 *v1alpha3.Server) string {
+	p1 := protocol.Parse(s1.Port.Protocol)
+	p2 := protocol.Parse(s2.Port.Protocol)
+	if p1 != p2 {
+		return fmt.Sprintf("protocol %s differs from protocol %s", s1.Port.Protocol, s2.Port.Protocol)
+	}
+
+	if proto.Equal(s1.GetTls(), s2.GetTls()) {
+		return ""
+	}
+	for _, h1 := range s1.GetHosts() {
+		for _, h2 := range s2.GetHosts() {
+			if host.Name(serverHost(h1)).Matches(host.Name(serverHost(h2))) {
+				return fmt.Sprintf("hosts %s and %s overlap, but the TLS settings differ", h1, h2)
+			}
+		}
+	}
+	return ""
+}
+
+// serverHost strips the namespace from a server host of the form namespace/host.
+func serverHost(h string) string {
+	if i := strings.Index(h, "/"); i >= 0 {
+		return h[i+1:]
+	}
+	return h
+}
@@ -0,0 +1,149 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gateway
+
+import (
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+	k8s_labels "k8s.io/apimachinery/pkg/labels"
+
+	"istio.io/api/networking/v1alpha3"
+	"istio.io/api/security/v1beta1"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/util"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+// ExposureAnalyzer reports gateways that expose hosts to the internet through a LoadBalancer service, while their
+// workloads are not covered by any AuthorizationPolicy or RequestAuthentication. This is not necessarily a problem,
+// so the findings are meant for a security review.
+type ExposureAnalyzer struct{}
+
+var _ analysis.Analyzer = &ExposureAnalyzer{}
+
+// policy is the workload selector of an AuthorizationPolicy or RequestAuthentication
+type policy struct {
+	namespace resource.Namespace
+	selector  k8s_labels.Selector
+}
+
+// Metadata implements analysis.Analyzer
+func (*ExposureAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:        "gateway.ExposureAnalyzer",
+		Description: "Checks for gateways exposed to the internet without authorization or request authentication",
+		Inputs: collection.Names{
+			collections.IstioMeshV1Alpha1MeshConfig.Name(),
+			collections.IstioNetworkingV1Alpha3Gateways.Name(),
+			collections.IstioSecurityV1Beta1Authorizationpolicies.Name(),
+			collections.IstioSecurityV1Beta1Requestauthentications.Name(),
+			collections.K8SCoreV1Pods.Name(),
+			collections.K8SCoreV1Services.Name(),
+		},
+	}
+}
+
+// Analyze implements analysis.Analyzer
+func (a *ExposureAnalyzer) Analyze(c analysis.Context) {
+	rootNamespace := resource.Namespace(analysis.MeshConfig(c).GetRootNamespace())
+
+	// Group load balancer services by namespace, since services only select pods in their namespace
+	loadBalancers := make(map[resource.Namespace][]*resource.Instance)
+	c.ForEach(collections.K8SCoreV1Services.Name(), func(r *resource.Instance) bool {
+		if r.Message.(*v1.ServiceSpec).Type == v1.ServiceTypeLoadBalancer {
+			ns := r.Metadata.FullName.Namespace
+			loadBalancers[ns] = append(loadBalancers[ns], r)
+		}
+		return true
+	})
+
+	var policies []policy
+	addPolicy := func(r *resource.Instance, selector map[string]string) {
+		policies = append(policies, policy{
+			namespace: r.Metadata.FullName.Namespace,
+			selector:  k8s_labels.SelectorFromSet(selector),
+		})
+	}
+	c.ForEach(collections.IstioSecurityV1Beta1Authorizationpolicies.Name(), func(r *resource.Instance) bool {
+		addPolicy(r, r.Message.(*v1beta1.AuthorizationPolicy).GetSelector().GetMatchLabels())
+		return true
+	})
+	c.ForEach(collections.IstioSecurityV1Beta1Requestauthentications.Name(), func(r *resource.Instance) bool {
+		addPolicy(r, r.Message.(*v1beta1.RequestAuthentication).GetSelector().GetMatchLabels())
+		return true
+	})
+
+	c.ForEach(collections.IstioNetworkingV1Alpha3Gateways.Name(), func(r *resource.Instance) bool {
+		gw := r.Message.(*v1alpha3.Gateway)
+		for _, pod := range util.SelectPods(c, "", gw.GetSelector()) {
+			service := selectingService(loadBalancers[pod.Metadata.FullName.Namespace], pod)
+			if service == nil || isProtected(pod, policies, rootNamespace) {
+				continue
+			}
+			c.Report(collections.IstioNetworkingV1Alpha3Gateways.Name(), msg.NewUnprotectedGatewayExposure(r,
+				gatewayHosts(gw), service.Metadata.FullName.String(), pod.Metadata.FullName.String()))
+			// One unprotected workload is enough to point out the gateway
+			break
+		}
+		return true
+	})
+}
+
+// selectingService returns the first of the services that selects the pod, if any.
+func selectingService(services []*resource.Instance, pod *resource.Instance) *resource.Instance {
+	podLabels := k8s_labels.Set(pod.Metadata.Labels)
+	for _, service := range services {
+		selector := service.Message.(*v1.ServiceSpec).Selector
+		if len(selector) > 0 && k8s_labels.SelectorFromSet(selector).Matches(podLabels) {
+			return service
+		}
+	}
+	return nil
+}
+
+// isProtected returns whether any of the policies applies to the pod. Policies apply to the workloads in their
+// namespace, or in all namespaces if they are in the root namespace, that match their selector.
+func isProtected(pod *resource.Instance, policies []policy, rootNamespace resource.Namespace) bool {
+	podLabels := k8s_labels.Set(pod.Metadata.Labels)
+	for _, p := range policies {
+		if p.namespace != pod.Metadata.FullName.Namespace && p.namespace != rootNamespace {
+			continue
+		}
+		if p.selector.Matches(podLabels) {
+			return true
+		}
+	}
+	return false
+}
+
+func gatewayHosts(gw *v1alpha3.Gateway) []string {
+	seen := make(map[string]bool)
+	var hosts []string
+	for _, server := range gw.GetServers() {
+		for _, h := range server.GetHosts() {
+			if !seen[h] {
+				seen[h] = true
+				hosts = append(hosts, h)
+			}
+		}
+	}
+	sort.Strings(hosts)
+	return hosts
+}
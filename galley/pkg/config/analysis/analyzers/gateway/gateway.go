@@ -21,6 +21,7 @@ import (
 	"istio.io/api/networking/v1alpha3"
 
 	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/util"
 	"istio.io/istio/galley/pkg/config/analysis/msg"
 	"istio.io/istio/pkg/config/resource"
 	"istio.io/istio/pkg/config/schema/collection"
@@ -48,13 +49,22 @@ func (*IngressGatewayPortAnalyzer) Metadata() analysis.Metadata {
 
 // Analyze implements analysis.Analyzer
 func (s *IngressGatewayPortAnalyzer) Analyze(c analysis.Context) {
+	// Group services by namespace, since services only select pods in their namespace
+	services := make(map[resource.Namespace][]*v1.ServiceSpec)
+	c.ForEach(collections.K8SCoreV1Services.Name(), func(rSvc *resource.Instance) bool {
+		ns := rSvc.Metadata.FullName.Namespace
+		services[ns] = append(services[ns], rSvc.Message.(*v1.ServiceSpec))
+		return true
+	})
+
 	c.ForEach(collections.IstioNetworkingV1Alpha3Gateways.Name(), func(r *resource.Instance) bool {
-		s.analyzeGateway(r, c)
+		s.analyzeGateway(r, c, services)
 		return true
 	})
 }
 
-func (*IngressGatewayPortAnalyzer) analyzeGateway(r *resource.Instance, c analysis.Context) {
+func (*IngressGatewayPortAnalyzer) analyzeGateway(r *resource.Instance, c analysis.Context,
+	services map[resource.Namespace][]*v1.ServiceSpec) {
 
 	gw := r.Message.(*v1alpha3.Gateway)
 
@@ -63,39 +73,26 @@ func (*IngressGatewayPortAnalyzer) analyzeGateway(r *resource.Instance, c analys
 	// Kubernetes services, and they offer different TCP port combinations, this validator will
 	// not report a problem if *any* selecting service exposes the Gateway's port.
 	servicePorts := map[uint32]bool{}
-	gwSelectorMatches := 0
 
 	// For pods selected by gw.Selector, find Services that select them and remember those ports
 	gwSelector := k8s_labels.SelectorFromSet(gw.Selector)
-	c.ForEach(collections.K8SCoreV1Pods.Name(), func(rPod *resource.Instance) bool {
-		pod := rPod.Message.(*v1.Pod)
-		podLabels := k8s_labels.Set(pod.ObjectMeta.Labels)
-		if gwSelector.Matches(podLabels) {
-			gwSelectorMatches++
-			c.ForEach(collections.K8SCoreV1Services.Name(), func(rSvc *resource.Instance) bool {
-				nsSvc := string(rSvc.Metadata.FullName.Namespace)
-				if nsSvc != pod.ObjectMeta.Namespace {
-					return true // Services only select pods in their namespace
-				}
-
-				service := rSvc.Message.(*v1.ServiceSpec)
-				// TODO I want to match service.Namespace to pod.ObjectMeta.Namespace
-				svcSelector := k8s_labels.SelectorFromSet(service.Selector)
-				if svcSelector.Matches(podLabels) {
-					for _, port := range service.Ports {
-						if port.Protocol == "TCP" {
-							servicePorts[uint32(port.Port)] = true
-						}
+	gwSelected := util.SelectPods(c, "", gw.Selector)
+	for _, rPod := range gwSelected {
+		podLabels := k8s_labels.Set(rPod.Metadata.Labels)
+		for _, service := range services[rPod.Metadata.FullName.Namespace] {
+			svcSelector := k8s_labels.SelectorFromSet(service.Selector)
+			if svcSelector.Matches(podLabels) {
+				for _, port := range service.Ports {
+					if port.Protocol == "TCP" {
+						servicePorts[uint32(port.Port)] = true
 					}
 				}
-				return true
-			})
+			}
 		}
-		return true
-	})
+	}
 
 	// Report if we found no pods matching this gateway's selector
-	if gwSelectorMatches == 0 {
+	if len(gwSelected) == 0 {
 		c.Report(collections.IstioNetworkingV1Alpha3Gateways.Name(), msg.NewReferencedResourceNotFound(r, "selector", gwSelector.String()))
 		return
 	}
@@ -0,0 +1,112 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gateway
+
+import (
+	"sort"
+	"strings"
+
+	"istio.io/api/networking/v1alpha3"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/util"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/galley/pkg/config/analysis/pushed"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+// sdsCaSuffix is appended to the credential name of a gateway server in mutual TLS mode, to name the SDS secret with
+// its CA certificate.
+const sdsCaSuffix = "-cacert"
+
+// PushedSecretsAnalyzer checks gateways against the secrets loaded by gateway proxies, when Envoy config dumps of
+// proxies are part of the analysis. A server whose credential a proxy requested through SDS, but never received or
+// rejected, does not accept TLS connections on that proxy.
+//
+// Only the gateway proxies that config dumps were given for are considered. A proxy is only checked against the
+// gateways that select its pod, unless its pod is not part of the analysis.
+type PushedSecretsAnalyzer struct{}
+
+var _ analysis.Analyzer = &PushedSecretsAnalyzer{}
+
+// Metadata implements analysis.Analyzer
+func (a *PushedSecretsAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:        "gateway.PushedSecretsAnalyzer",
+		Description: "Checks that gateway proxies loaded the secrets of their gateways",
+		Inputs: collection.Names{
+			collections.IstioNetworkingV1Alpha3Gateways.Name(),
+			collections.K8SCoreV1Pods.Name(),
+		},
+	}
+}
+
+// Analyze implements analysis.Analyzer
+func (a *PushedSecretsAnalyzer) Analyze(ctx analysis.Context) {
+	cfg := analysis.PushedConfig(ctx)
+	if cfg == nil {
+		return
+	}
+	proxies := make(map[string]*pushed.Proxy)
+	for _, p := range cfg.Proxies() {
+		proxies[p.ID] = p
+	}
+
+	ctx.ForEach(collections.IstioNetworkingV1Alpha3Gateways.Name(), func(r *resource.Instance) bool {
+		gw := r.Message.(*v1alpha3.Gateway)
+
+		selected := make(map[resource.FullName]bool)
+		for _, pod := range util.SelectPods(ctx, "", gw.Selector) {
+			selected[pod.Metadata.FullName] = true
+		}
+
+		for i, srv := range gw.GetServers() {
+			cn := srv.GetTls().GetCredentialName()
+			if cn == "" {
+				continue
+			}
+
+			var waiting []string
+			seen := make(map[string]bool)
+			for _, name := range []string{cn, cn + sdsCaSuffix} {
+				for _, id := range cfg.ProxiesWaitingFor(name) {
+					if !seen[id] && appliesToProxy(ctx, selected, proxies[id]) {
+						seen[id] = true
+						waiting = append(waiting, id)
+					}
+				}
+			}
+			sort.Strings(waiting)
+			if len(waiting) > 0 {
+				ctx.Report(collections.IstioNetworkingV1Alpha3Gateways.Name(),
+					msg.NewGatewaySecretNotLoaded(r, cn, strings.Join(waiting, ", ")).
+						WithFieldPath(credentialNamePath(i)))
+			}
+		}
+		return true
+	})
+}
+
+// appliesToProxy returns whether a gateway that selects the given pods applies to the proxy: it must be a gateway
+// proxy, and its pod must be selected, unless the pod is unknown.
+func appliesToProxy(ctx analysis.Context, selected map[resource.FullName]bool, p *pushed.Proxy) bool {
+	if p == nil || p.Type() != pushed.RouterProxy {
+		return false
+	}
+	pod := resource.NewFullName(resource.Namespace(p.Namespace()), resource.LocalName(p.PodName()))
+	return selected[pod] || ctx.Find(collections.K8SCoreV1Pods.Name(), pod) == nil
+}
@@ -15,22 +15,49 @@
 package gateway
 
 import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/labels"
 
 	"istio.io/api/networking/v1alpha3"
 
 	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/util"
+	"istio.io/istio/galley/pkg/config/analysis/diag"
 	"istio.io/istio/galley/pkg/config/analysis/msg"
 	"istio.io/istio/pkg/config/resource"
 	"istio.io/istio/pkg/config/schema/collection"
 	"istio.io/istio/pkg/config/schema/collections"
 )
 
-// SecretAnalyzer checks a gateway's referenced secrets for correctness
-type SecretAnalyzer struct{}
+// SecretAnalyzer checks a gateway's referenced secrets for correctness: they must exist and hold a certificate and a
+// matching private key, and the certificate must not expire soon.
+type SecretAnalyzer struct {
+	// ExpiryWindow is the time before the expiry of a certificate from which it is reported. Defaults to 30 days.
+	ExpiryWindow time.Duration
+}
+
+var _ analysis.Configurable = &SecretAnalyzer{}
+var _ analysis.IncrementalAnalyzer = &SecretAnalyzer{}
 
-var _ analysis.Analyzer = &SecretAnalyzer{}
+const defaultExpiryWindow = 30 * 24 * time.Hour
+
+// The keys of the certificate and the private key, in generic and in kubernetes.io/tls secrets, as read by the
+// gateway's secret fetcher.
+const (
+	genericCertKey = "cert"
+	genericKeyKey  = "key"
+	tlsCertKey     = "tls.crt"
+	tlsKeyKey      = "tls.key"
+)
 
 // Metadata implements analysis.Analyzer
 func (a *SecretAnalyzer) Metadata() analysis.Metadata {
@@ -42,56 +69,221 @@ func (a *SecretAnalyzer) Metadata() analysis.Metadata {
 			collections.K8SCoreV1Pods.Name(),
 			collections.K8SCoreV1Secrets.Name(),
 		},
+		TimeDependent: true,
 	}
 }
 
+// Configure implements analysis.Configurable. The certExpiryWindow parameter sets ExpiryWindow.
+func (a *SecretAnalyzer) Configure(params map[string]string) error {
+	for k, v := range params {
+		switch k {
+		case "certExpiryWindow":
+			d, err := time.ParseDuration(v)
+			if err != nil || d < 0 {
+				return fmt.Errorf("invalid certExpiryWindow %q: must be a non-negative duration", v)
+			}
+			a.ExpiryWindow = d
+		default:
+			return fmt.Errorf("unknown parameter %q", k)
+		}
+	}
+	return nil
+}
+
 // Analyze implements analysis.Analyzer
 func (a *SecretAnalyzer) Analyze(ctx analysis.Context) {
 	ctx.ForEach(collections.IstioNetworkingV1Alpha3Gateways.Name(), func(r *resource.Instance) bool {
-		gw := r.Message.(*v1alpha3.Gateway)
+		a.analyzeGateway(ctx, r)
+		return true
+	})
+}
+
+// IncrementalInput implements analysis.IncrementalAnalyzer
+func (a *SecretAnalyzer) IncrementalInput() collection.Name {
+	return collections.IstioNetworkingV1Alpha3Gateways.Name()
+}
+
+// AnalyzeResource implements analysis.IncrementalAnalyzer
+func (a *SecretAnalyzer) AnalyzeResource(ctx analysis.Context, r *resource.Instance) {
+	a.analyzeGateway(ctx, r)
+}
 
-		gwNs := getGatewayNamespace(ctx, gw)
+func (a *SecretAnalyzer) analyzeGateway(ctx analysis.Context, r *resource.Instance) {
+	gw := r.Message.(*v1alpha3.Gateway)
 
-		// If we can't find a namespace for the gateway, it's because there's no matching selector. Exit early with a different message.
-		if gwNs == "" {
-			ctx.Report(collections.IstioNetworkingV1Alpha3Gateways.Name(),
-				msg.NewReferencedResourceNotFound(r, "selector", labels.SelectorFromSet(gw.Selector).String()))
-			return true
+	gwNamespaces := getGatewayNamespaces(ctx, gw)
+
+	// If we can't find a namespace for the gateway, it's because there's no matching selector. Exit early with a different message.
+	if len(gwNamespaces) == 0 {
+		ctx.Report(collections.IstioNetworkingV1Alpha3Gateways.Name(),
+			msg.NewReferencedResourceNotFound(r, "selector", labels.SelectorFromSet(gw.Selector).String()).
+				WithFieldPath("spec.selector"))
+		return
+	}
+
+	for i, srv := range gw.GetServers() {
+		tls := srv.GetTls()
+		if tls == nil {
+			continue
+		}
+
+		cn := tls.GetCredentialName()
+		if cn == "" {
+			continue
 		}
 
-		for _, srv := range gw.GetServers() {
-			tls := srv.GetTls()
-			if tls == nil {
+		// Every workload of the gateway reads the secret from its own namespace, unless the name is qualified
+		checked := make(map[resource.FullName]bool)
+		var missing []resource.Namespace
+		for _, gwNs := range gwNamespaces {
+			name := resource.NewShortOrFullName(gwNs, cn)
+			if checked[name] {
 				continue
 			}
+			checked[name] = true
 
-			cn := tls.GetCredentialName()
-			if cn == "" {
+			secret := ctx.Find(collections.K8SCoreV1Secrets.Name(), name)
+			if secret == nil {
+				missing = append(missing, gwNs)
 				continue
 			}
+			a.analyzeCredential(ctx, r, credentialNamePath(i), cn, secret.Message.(*v1.Secret))
+		}
 
-			if !ctx.Exists(collections.K8SCoreV1Secrets.Name(), resource.NewShortOrFullName(gwNs, cn)) {
-				ctx.Report(collections.IstioNetworkingV1Alpha3Gateways.Name(), msg.NewReferencedResourceNotFound(r, "credentialName", cn))
-			}
+		// A credential that exists in none of the namespaces is a missing reference. Only one that is missing from
+		// some of the namespaces of the gateway workloads is reported per namespace.
+		if len(missing) > 0 && len(missing) == len(checked) {
+			m := msg.NewReferencedResourceNotFound(r, "credentialName", cn).WithFieldPath(credentialNamePath(i))
+			ctx.Report(collections.IstioNetworkingV1Alpha3Gateways.Name(), withQualifyFix(ctx, r, i, missing[0], m))
+			continue
 		}
-		return true
-	})
+		for _, gwNs := range missing {
+			m := msg.NewGatewaySecretNotFound(r, cn, srv.GetPort().GetNumber(), srv.GetHosts(), gwNs.String()).
+				WithFieldPath(credentialNamePath(i))
+			ctx.Report(collections.IstioNetworkingV1Alpha3Gateways.Name(), withQualifyFix(ctx, r, i, gwNs, m))
+		}
+	}
 }
 
-// Gets the namespace for the gateway (in terms of the actual workload selected by the gateway, NOT the namespace of the Gateway CRD)
-// Assumes that all selected workloads are in the same namespace, if this is not the case which one's namespace gets returned is undefined.
-func getGatewayNamespace(ctx analysis.Context, gw *v1alpha3.Gateway) resource.Namespace {
-	var ns resource.Namespace
-
-	gwSelector := labels.SelectorFromSet(gw.Selector)
-	ctx.ForEach(collections.K8SCoreV1Pods.Name(), func(rPod *resource.Instance) bool {
-		pod := rPod.Message.(*v1.Pod)
-		if gwSelector.Matches(labels.Set(pod.ObjectMeta.Labels)) {
-			ns = rPod.Metadata.FullName.Namespace
-			return false
+// withQualifyFix adds a fix to the message m about the credential of the i-th server of the gateway r, which does not
+// exist in the namespace gwNs of some of the gateway's workloads, if qualifying its name makes the reference work.
+func withQualifyFix(ctx analysis.Context, r *resource.Instance, i int, gwNs resource.Namespace,
+	m diag.Message) diag.Message {
+
+	cn := r.Message.(*v1alpha3.Gateway).GetServers()[i].GetTls().GetCredentialName()
+
+	// The secret is often created in the namespace of the Gateway resource, or in some other namespace, instead of the
+	// namespace of the gateway workload. Qualifying the name with the namespace makes the reference work.
+	secrets := util.BuildSecretIndex(ctx)
+	name := resource.NewShortOrFullName(r.Metadata.FullName.Namespace, cn)
+	if !strings.Contains(cn, "/") && !secrets.Exists(name) {
+		if namespaces := secrets.Namespaces(name.Name); len(namespaces) == 1 {
+			name = resource.NewFullName(namespaces[0], name.Name)
 		}
-		return true
-	})
+	}
+	if name.Namespace != gwNs && secrets.Exists(name) {
+		m = util.WithSpecFix(m, fmt.Sprintf("Qualify credentialName %s with the namespace of the secret", cn),
+			func(spec proto.Message) {
+				spec.(*v1alpha3.Gateway).Servers[i].Tls.CredentialName = name.String()
+			})
+	}
+	return m
+}
+
+func (a *SecretAnalyzer) analyzeCredential(ctx analysis.Context, r *resource.Instance, path, cn string,
+	secret *v1.Secret) {
+
+	for _, m := range CredentialMessages(r, cn, secret, a.ExpiryWindow) {
+		ctx.Report(collections.IstioNetworkingV1Alpha3Gateways.Name(), m.WithFieldPath(path))
+	}
+}
+
+// credentialNamePath returns the field path of the credential name of the i-th server of a gateway.
+func credentialNamePath(i int) string {
+	return fmt.Sprintf("spec.servers[%d].tls.credentialName", i)
+}
+
+// CredentialMessages checks the certificate and private key of the secret that r references as cn. It returns a
+// message if they are unusable, or if the certificate expires within the window, which defaults to 30 days.
+func CredentialMessages(r *resource.Instance, cn string, secret *v1.Secret, window time.Duration) []diag.Message {
+	cert, key, err := certAndKey(secret)
+	if err == nil {
+		var leaf *x509.Certificate
+		if leaf, err = parseCertAndKey(cert, key); err == nil {
+			return expiryMessages(r, cn, leaf, window)
+		}
+	}
+	return []diag.Message{msg.NewInvalidGatewayCredential(r, cn, err.Error())}
+}
+
+func expiryMessages(r *resource.Instance, cn string, leaf *x509.Certificate, window time.Duration) []diag.Message {
+	if window == 0 {
+		window = defaultExpiryWindow
+	}
+
+	now := time.Now()
+	expiry := leaf.NotAfter.UTC().Format(time.RFC3339)
+	switch {
+	case now.After(leaf.NotAfter):
+		return []diag.Message{msg.NewInvalidGatewayCredential(r, cn, fmt.Sprintf("the certificate expired on %s", expiry))}
+	case now.Add(window).After(leaf.NotAfter):
+		return []diag.Message{msg.NewGatewayCertificateExpiring(r, cn, expiry, window.String())}
+	}
+	return nil
+}
+
+// certAndKey returns the certificate and private key of a secret, in the generic format if it has a cert key, and in
+// the kubernetes.io/tls format otherwise.
+func certAndKey(secret *v1.Secret) ([]byte, []byte, error) {
+	certKey, keyKey := tlsCertKey, tlsKeyKey
+	if _, ok := secret.Data[genericCertKey]; ok {
+		certKey, keyKey = genericCertKey, genericKeyKey
+	}
+
+	var missing []string
+	for _, k := range []string{certKey, keyKey} {
+		if len(secret.Data[k]) == 0 {
+			missing = append(missing, k)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, nil, fmt.Errorf("the secret has no %v", missing)
+	}
+	return secret.Data[certKey], secret.Data[keyKey], nil
+}
 
-	return ns
+// parseCertAndKey checks that the certificate chain and private key are well-formed PEM and match, and returns the leaf
+// certificate.
+func parseCertAndKey(cert, key []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(cert)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("the certificate is not a PEM encoded certificate")
+	}
+	leaf, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("the certificate cannot be parsed: %v", err)
+	}
+	if block, _ = pem.Decode(key); block == nil {
+		return nil, fmt.Errorf("the private key is not PEM encoded")
+	}
+	if _, err := tls.X509KeyPair(cert, key); err != nil {
+		return nil, fmt.Errorf("the private key is invalid or does not match the certificate: %v", err)
+	}
+	return leaf, nil
+}
+
+// getGatewayNamespaces returns the namespaces of the workloads selected by the gateway (NOT the namespace of the
+// Gateway resource), sorted. Each of them must hold the credentials of the gateway.
+func getGatewayNamespaces(ctx analysis.Context, gw *v1alpha3.Gateway) []resource.Namespace {
+	var namespaces []resource.Namespace
+	seen := make(map[resource.Namespace]bool)
+	for _, p := range util.SelectPods(ctx, "", gw.Selector) {
+		ns := p.Metadata.FullName.Namespace
+		if !seen[ns] {
+			seen[ns] = true
+			namespaces = append(namespaces, ns)
+		}
+	}
+	sort.Slice(namespaces, func(i, j int) bool { return namespaces[i] < namespaces[j] })
+	return namespaces
 }
@@ -0,0 +1,111 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gateway
+
+import (
+	"fmt"
+
+	"istio.io/api/networking/v1alpha3"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/host"
+	"istio.io/istio/pkg/config/protocol"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+// SNICollisionAnalyzer checks the TLS servers of a gateway on the same port for overlapping hosts, e.g. *.example.com
+// and api.example.com, with different TLS modes. Connections are matched to servers by their SNI, preferring exact
+// hosts over wildcards, so some hosts silently get the TLS mode of another server. Collisions of passthrough servers
+// with servers terminating TLS are reported separately, as they decide whether TLS is terminated at all.
+//
+// Conflicts between the servers of different gateways that select the same workload are reported by the
+// ConflictingServersAnalyzer.
+type SNICollisionAnalyzer struct{}
+
+var _ analysis.Analyzer = &SNICollisionAnalyzer{}
+
+// Metadata implements analysis.Analyzer
+func (*SNICollisionAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:        "gateway.SNICollisionAnalyzer",
+		Description: "Checks for overlapping hosts of gateway TLS servers on the same port with different TLS modes",
+		Inputs: collection.Names{
+			collections.IstioNetworkingV1Alpha3Gateways.Name(),
+		},
+	}
+}
+
+// Analyze implements analysis.Analyzer
+func (a *SNICollisionAnalyzer) Analyze(c analysis.Context) {
+	c.ForEach(collections.IstioNetworkingV1Alpha3Gateways.Name(), func(r *resource.Instance) bool {
+		servers := r.Message.(*v1alpha3.Gateway).GetServers()
+		for i := range servers {
+			for j := i + 1; j < len(servers); j++ {
+				a.compare(c, r, servers, i, j)
+			}
+		}
+		return true
+	})
+}
+
+// compare reports the first overlapping host of the i-th and j-th servers of the gateway r, if they are TLS servers
+// on the same port with different TLS modes.
+func (*SNICollisionAnalyzer) compare(c analysis.Context, r *resource.Instance, servers []*v1alpha3.Server, i, j int) {
+	s1, s2 := servers[i], servers[j]
+	if !isTLSServer(s1) || !isTLSServer(s2) || s1.GetPort().GetNumber() != s2.GetPort().GetNumber() {
+		return
+	}
+	m1, m2 := s1.GetTls().GetMode(), s2.GetTls().GetMode()
+	if m1 == m2 {
+		return
+	}
+
+	for _, h1 := range s1.GetHosts() {
+		for k, h2 := range s2.GetHosts() {
+			if !host.Name(serverHost(h1)).Matches(host.Name(serverHost(h2))) {
+				continue
+			}
+
+			port := int(s1.GetPort().GetNumber())
+			var m diag.Message
+			switch {
+			case passthrough(m1) && !passthrough(m2):
+				m = msg.NewPassthroughHostCollision(r, m1.String(), port, h1, h2, m2.String())
+			case passthrough(m2) && !passthrough(m1):
+				m = msg.NewPassthroughHostCollision(r, m2.String(), port, h2, h1, m1.String())
+			default:
+				m = msg.NewGatewayServerHostsOverlap(r, port, h1, m1.String(), h2, m2.String())
+			}
+			c.Report(collections.IstioNetworkingV1Alpha3Gateways.Name(),
+				m.WithFieldPath(fmt.Sprintf("spec.servers[%d].hosts[%d]", j, k)))
+			return
+		}
+	}
+}
+
+// passthrough returns whether a TLS mode passes TLS through to the destination instead of terminating it.
+func passthrough(mode v1alpha3.ServerTLSSettings_TLSmode) bool {
+	return mode == v1alpha3.ServerTLSSettings_PASSTHROUGH || mode == v1alpha3.ServerTLSSettings_AUTO_PASSTHROUGH
+}
+
+// isTLSServer returns whether the server accepts TLS connections, which are matched to servers by SNI. Plain text
+// servers may have TLS settings too, to redirect to HTTPS.
+func isTLSServer(s *v1alpha3.Server) bool {
+	return s.GetTls() != nil && protocol.Parse(s.GetPort().GetProtocol()).IsTLS()
+}
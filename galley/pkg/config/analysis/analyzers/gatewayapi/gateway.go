@@ -0,0 +1,93 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gatewayapi
+
+import (
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+// GatewayAnalyzer checks that Gateway API gateways reference an existing GatewayClass, and that the route kinds their
+// listeners allow are compatible with the listener protocols.
+type GatewayAnalyzer struct{}
+
+var _ analysis.Analyzer = &GatewayAnalyzer{}
+
+// routeKindsByProtocol are the Gateway API route kinds that can attach to listeners of each protocol.
+var routeKindsByProtocol = map[string][]string{
+	"HTTP":  {"HTTPRoute", "GRPCRoute"},
+	"HTTPS": {"HTTPRoute", "GRPCRoute"},
+	"TLS":   {"TLSRoute", "TCPRoute"},
+	"TCP":   {"TCPRoute"},
+	"UDP":   {"UDPRoute"},
+}
+
+// Metadata implements analysis.Analyzer
+func (a *GatewayAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name: "gatewayapi.GatewayAnalyzer",
+		Description: "Checks that Gateway API gateways reference an existing GatewayClass, and that their listeners " +
+			"allow route kinds compatible with their protocol",
+		Inputs: collection.Names{
+			collections.K8SGatewayNetworkingK8SIoV1Beta1Gatewayclasses.Name(),
+			collections.K8SGatewayNetworkingK8SIoV1Beta1Gateways.Name(),
+		},
+	}
+}
+
+// Analyze implements analysis.Analyzer
+func (a *GatewayAnalyzer) Analyze(ctx analysis.Context) {
+	ctx.ForEach(collections.K8SGatewayNetworkingK8SIoV1Beta1Gateways.Name(), func(r *resource.Instance) bool {
+		var gw gatewaySpec
+		if !decodeSpec(r, &gw) {
+			return true
+		}
+
+		class := resource.NewFullName("", resource.LocalName(gw.GatewayClassName))
+		if !ctx.Exists(collections.K8SGatewayNetworkingK8SIoV1Beta1Gatewayclasses.Name(), class) {
+			ctx.Report(collections.K8SGatewayNetworkingK8SIoV1Beta1Gateways.Name(),
+				msg.NewReferencedResourceNotFound(r, "gatewayClassName", gw.GatewayClassName))
+		}
+
+		for _, l := range gw.Listeners {
+			compatible, known := routeKindsByProtocol[l.Protocol]
+			if !known || l.AllowedRoutes == nil {
+				continue
+			}
+			for _, k := range l.AllowedRoutes.Kinds {
+				if k.Group != nil && *k.Group != group {
+					continue
+				}
+				if !contains(compatible, k.Kind) {
+					ctx.Report(collections.K8SGatewayNetworkingK8SIoV1Beta1Gateways.Name(),
+						msg.NewListenerRouteKindIncompatible(r, l.Name, k.Kind, l.Protocol))
+				}
+			}
+		}
+		return true
+	})
+}
+
+func contains(l []string, s string) bool {
+	for _, e := range l {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
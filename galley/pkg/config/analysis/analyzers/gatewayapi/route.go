@@ -0,0 +1,169 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gatewayapi
+
+import (
+	"fmt"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+// RouteAnalyzer checks that the parent references of HTTPRoutes are accepted: the referenced gateway exists, and has
+// a listener matching the reference that allows HTTPRoutes from the namespace of the route over its protocol.
+type RouteAnalyzer struct{}
+
+var _ analysis.Analyzer = &RouteAnalyzer{}
+
+// Metadata implements analysis.Analyzer
+func (a *RouteAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:        "gatewayapi.RouteAnalyzer",
+		Description: "Checks that the gateways referenced by HTTPRoutes exist and accept the routes",
+		Inputs: collection.Names{
+			collections.K8SCoreV1Namespaces.Name(),
+			collections.K8SGatewayNetworkingK8SIoV1Beta1Gateways.Name(),
+			collections.K8SGatewayNetworkingK8SIoV1Beta1Httproutes.Name(),
+		},
+	}
+}
+
+// Analyze implements analysis.Analyzer
+func (a *RouteAnalyzer) Analyze(ctx analysis.Context) {
+	ctx.ForEach(collections.K8SGatewayNetworkingK8SIoV1Beta1Httproutes.Name(), func(r *resource.Instance) bool {
+		var route httpRouteSpec
+		if !decodeSpec(r, &route) {
+			return true
+		}
+
+		for _, ref := range route.ParentRefs {
+			// Routes can also attach to other kinds of parents, e.g. services for mesh traffic.
+			if !ref.refersTo(group, kindGateway) {
+				continue
+			}
+
+			name := ref.fullName(r.Metadata.FullName.Namespace)
+			gw := ctx.Find(collections.K8SGatewayNetworkingK8SIoV1Beta1Gateways.Name(), name)
+			if gw == nil {
+				ctx.Report(collections.K8SGatewayNetworkingK8SIoV1Beta1Httproutes.Name(),
+					msg.NewReferencedResourceNotFound(r, "parentRef", name.String()))
+				continue
+			}
+
+			var spec gatewaySpec
+			if !decodeSpec(gw, &spec) {
+				continue
+			}
+			if reason := rejectionReason(ctx, r, ref, gw, spec); reason != "" {
+				ctx.Report(collections.K8SGatewayNetworkingK8SIoV1Beta1Httproutes.Name(),
+					msg.NewRouteParentRefNotAccepted(r, describeParentRef(name, ref), reason))
+			}
+		}
+		return true
+	})
+}
+
+// rejectionReason returns why none of the gateway's listeners that match the parent reference accept the route, or
+// the empty string if one of them does.
+func rejectionReason(ctx analysis.Context, route *resource.Instance, ref parentReference, gw *resource.Instance,
+	spec gatewaySpec) string {
+
+	var reasons []string
+	matched := false
+	for _, l := range spec.Listeners {
+		if (ref.SectionName != nil && *ref.SectionName != l.Name) || (ref.Port != nil && *ref.Port != l.Port) {
+			continue
+		}
+		matched = true
+
+		reason := listenerRejectionReason(ctx, route, gw, l)
+		if reason == "" {
+			return ""
+		}
+		reasons = append(reasons, fmt.Sprintf("listener %s %s", l.Name, reason))
+	}
+
+	if !matched {
+		return "the gateway has no matching listener"
+	}
+	return strings.Join(reasons, ", ")
+}
+
+// listenerRejectionReason returns why the listener does not accept the route, or the empty string if it does.
+func listenerRejectionReason(ctx analysis.Context, route *resource.Instance, gw *resource.Instance, l listener) string {
+	if !contains(routeKindsByProtocol[l.Protocol], kindHTTPRoute) {
+		return fmt.Sprintf("uses protocol %s", l.Protocol)
+	}
+
+	allowed := l.AllowedRoutes
+	if allowed == nil {
+		allowed = &allowedRoutes{}
+	}
+	if len(allowed.Kinds) > 0 {
+		found := false
+		for _, k := range allowed.Kinds {
+			found = found || k.is(kindHTTPRoute)
+		}
+		if !found {
+			return "does not allow HTTPRoutes"
+		}
+	}
+
+	from := fromSame
+	var selector *metav1.LabelSelector
+	if allowed.Namespaces != nil {
+		if allowed.Namespaces.From != "" {
+			from = allowed.Namespaces.From
+		}
+		selector = allowed.Namespaces.Selector
+	}
+
+	routeNs := route.Metadata.FullName.Namespace
+	switch from {
+	case fromSame:
+		if gwNs := gw.Metadata.FullName.Namespace; routeNs != gwNs {
+			return fmt.Sprintf("only allows routes from namespace %s", gwNs)
+		}
+	case fromSelector:
+		sel, err := metav1.LabelSelectorAsSelector(selector)
+		if err != nil || selector == nil {
+			return "has an invalid namespace selector"
+		}
+		ns := ctx.Find(collections.K8SCoreV1Namespaces.Name(), resource.NewFullName("", resource.LocalName(routeNs)))
+		if ns == nil || !sel.Matches(labels.Set(ns.Metadata.Labels)) {
+			return fmt.Sprintf("only allows routes from namespaces matching %s", sel)
+		}
+	}
+	return ""
+}
+
+// describeParentRef describes a parent reference, e.g. "gateway default/gw, listener https".
+func describeParentRef(name resource.FullName, ref parentReference) string {
+	s := "gateway " + name.String()
+	if ref.SectionName != nil {
+		s += ", listener " + *ref.SectionName
+	}
+	if ref.Port != nil {
+		s += fmt.Sprintf(", port %d", *ref.Port)
+	}
+	return s
+}
@@ -0,0 +1,104 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gatewayapi
+
+import (
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/gateway"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+// SecretAnalyzer checks the certificates referenced by the listeners of Gateway API gateways, like
+// gateway.SecretAnalyzer does for Istio gateways: listeners terminating TLS must reference a certificate, and the
+// referenced secrets must exist and hold a certificate and a matching private key that do not expire soon.
+type SecretAnalyzer struct {
+	// ExpiryWindow is the time before the expiry of a certificate from which it is reported. Defaults to 30 days.
+	ExpiryWindow time.Duration
+}
+
+var _ analysis.Analyzer = &SecretAnalyzer{}
+var _ analysis.Configurable = &SecretAnalyzer{}
+
+// Metadata implements analysis.Analyzer
+func (a *SecretAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:        "gatewayapi.SecretAnalyzer",
+		Description: "Checks the secrets referenced by Gateway API gateways for correctness",
+		Inputs: collection.Names{
+			collections.K8SCoreV1Secrets.Name(),
+			collections.K8SGatewayNetworkingK8SIoV1Beta1Gateways.Name(),
+		},
+		TimeDependent: true,
+	}
+}
+
+// Configure implements analysis.Configurable. The certExpiryWindow parameter sets ExpiryWindow.
+func (a *SecretAnalyzer) Configure(params map[string]string) error {
+	var g gateway.SecretAnalyzer
+	if err := g.Configure(params); err != nil {
+		return err
+	}
+	a.ExpiryWindow = g.ExpiryWindow
+	return nil
+}
+
+// Analyze implements analysis.Analyzer
+func (a *SecretAnalyzer) Analyze(ctx analysis.Context) {
+	ctx.ForEach(collections.K8SGatewayNetworkingK8SIoV1Beta1Gateways.Name(), func(r *resource.Instance) bool {
+		var gw gatewaySpec
+		if !decodeSpec(r, &gw) {
+			return true
+		}
+
+		for _, l := range gw.Listeners {
+			if l.Protocol != "HTTPS" && l.Protocol != "TLS" {
+				continue
+			}
+			// TLS is terminated unless the listener passes it through
+			if l.TLS != nil && l.TLS.Mode != "" && l.TLS.Mode != tlsModeTerminate {
+				continue
+			}
+			if l.TLS == nil || len(l.TLS.CertificateRefs) == 0 {
+				ctx.Report(collections.K8SGatewayNetworkingK8SIoV1Beta1Gateways.Name(),
+					msg.NewListenerCertificateMissing(r, l.Name, l.Protocol))
+				continue
+			}
+
+			for _, ref := range l.TLS.CertificateRefs {
+				if !ref.refersTo("", kindSecret) {
+					continue
+				}
+				name := ref.fullName(r.Metadata.FullName.Namespace)
+				secret := ctx.Find(collections.K8SCoreV1Secrets.Name(), name)
+				if secret == nil {
+					ctx.Report(collections.K8SGatewayNetworkingK8SIoV1Beta1Gateways.Name(),
+						msg.NewReferencedResourceNotFound(r, "certificateRef", name.String()))
+					continue
+				}
+				for _, m := range gateway.CredentialMessages(r, name.String(), secret.Message.(*v1.Secret), a.ExpiryWindow) {
+					ctx.Report(collections.K8SGatewayNetworkingK8SIoV1Beta1Gateways.Name(), m)
+				}
+			}
+		}
+		return true
+	})
+}
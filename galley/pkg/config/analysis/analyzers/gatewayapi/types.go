@@ -0,0 +1,126 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package gatewayapi contains analyzers for the resources of the Kubernetes Gateway API (gateway.networking.k8s.io).
+// There are no proto definitions for them, so their specs are read as structs, and decoded into the types below,
+// which only hold the fields the analyzers need.
+package gatewayapi
+
+import (
+	"encoding/json"
+
+	gogotypes "github.com/gogo/protobuf/types"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/util/gogoprotomarshal"
+)
+
+const (
+	// group is the API group of the Gateway API resources, and the default group of references.
+	group = "gateway.networking.k8s.io"
+
+	kindGateway   = "Gateway"
+	kindHTTPRoute = "HTTPRoute"
+	kindSecret    = "Secret"
+
+	fromAll      = "All"
+	fromSame     = "Same"
+	fromSelector = "Selector"
+
+	tlsModeTerminate = "Terminate"
+)
+
+type gatewaySpec struct {
+	GatewayClassName string     `json:"gatewayClassName"`
+	Listeners        []listener `json:"listeners"`
+}
+
+type listener struct {
+	Name          string         `json:"name"`
+	Port          int32          `json:"port"`
+	Protocol      string         `json:"protocol"`
+	TLS           *tlsConfig     `json:"tls"`
+	AllowedRoutes *allowedRoutes `json:"allowedRoutes"`
+}
+
+type tlsConfig struct {
+	Mode            string            `json:"mode"`
+	CertificateRefs []objectReference `json:"certificateRefs"`
+}
+
+type allowedRoutes struct {
+	Namespaces *routeNamespaces `json:"namespaces"`
+	Kinds      []groupKind      `json:"kinds"`
+}
+
+type routeNamespaces struct {
+	From     string                `json:"from"`
+	Selector *metav1.LabelSelector `json:"selector"`
+}
+
+// groupKind is a kind of resource. An unset group is the Gateway API group, while an empty one is the core group.
+type groupKind struct {
+	Group *string `json:"group"`
+	Kind  string  `json:"kind"`
+}
+
+func (g groupKind) is(kind string) bool {
+	return g.Kind == kind && (g.Group == nil || *g.Group == group)
+}
+
+// objectReference references a resource, in the namespace of the referencing resource unless the namespace is set.
+type objectReference struct {
+	Group     *string `json:"group"`
+	Kind      *string `json:"kind"`
+	Name      string  `json:"name"`
+	Namespace *string `json:"namespace"`
+}
+
+// refersTo returns whether the reference is to a resource of the given group and kind. An unset group or kind is taken
+// to be the given one, as the analyzers only check references whose defaults they are.
+func (o objectReference) refersTo(g, kind string) bool {
+	return (o.Group == nil || *o.Group == g) && (o.Kind == nil || *o.Kind == kind)
+}
+
+func (o objectReference) fullName(ns resource.Namespace) resource.FullName {
+	if o.Namespace != nil && *o.Namespace != "" {
+		ns = resource.Namespace(*o.Namespace)
+	}
+	return resource.NewFullName(ns, resource.LocalName(o.Name))
+}
+
+type httpRouteSpec struct {
+	ParentRefs []parentReference `json:"parentRefs"`
+}
+
+type parentReference struct {
+	objectReference
+	SectionName *string `json:"sectionName"`
+	Port        *int32  `json:"port"`
+}
+
+// decodeSpec decodes the spec of a Gateway API resource into out. It returns false if the spec does not have the
+// expected structure, which is reported by schema validation instead.
+func decodeSpec(r *resource.Instance, out interface{}) bool {
+	s, ok := r.Message.(*gogotypes.Struct)
+	if !ok {
+		return false
+	}
+	js, err := gogoprotomarshal.ToJSON(s)
+	if err != nil {
+		return false
+	}
+	return json.Unmarshal([]byte(js), out) == nil
+}
@@ -0,0 +1,271 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hostname
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"istio.io/api/networking/v1alpha3"
+	v1 "k8s.io/api/core/v1"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/util"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+// NormalizationAnalyzer checks the hosts of virtual services, destination rules and service entries. It reports hosts
+// that are not in canonical form (trailing dots or upper case letters), hosts with characters or labels that are not
+// valid in DNS names, and services that are referred to by their short name in some resources but by their FQDN in
+// others. The hosts of gateways and sidecars, and the names of Kubernetes services, are checked for validity only.
+type NormalizationAnalyzer struct{}
+
+var _ analysis.Analyzer = &NormalizationAnalyzer{}
+
+const (
+	maxHostLength  = 253
+	maxLabelLength = 63
+)
+
+// reference is a host as written in a resource
+type reference struct {
+	col  collection.Name
+	r    *resource.Instance
+	host string
+	// Whether the host is only checked for validity, and not compared with the hosts of other resources
+	validateOnly bool
+}
+
+// Metadata implements Analyzer
+func (a *NormalizationAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:        "hostname.NormalizationAnalyzer",
+		Description: "Checks that hosts are valid DNS names, written in canonical form and consistently",
+		Inputs: collection.Names{
+			collections.IstioNetworkingV1Alpha3Destinationrules.Name(),
+			collections.IstioNetworkingV1Alpha3Gateways.Name(),
+			collections.IstioNetworkingV1Alpha3Serviceentries.Name(),
+			collections.IstioNetworkingV1Alpha3Sidecars.Name(),
+			collections.IstioNetworkingV1Alpha3Virtualservices.Name(),
+			collections.K8SCoreV1Services.Name(),
+		},
+	}
+}
+
+// Analyze implements Analyzer
+func (a *NormalizationAnalyzer) Analyze(c analysis.Context) {
+	// The short and FQDN references to each Kubernetes service, by FQDN
+	short := make(map[string][]reference)
+	full := make(map[string][]reference)
+
+	for _, ref := range collectReferences(c) {
+		canonical := ref.host
+		if problem, fixed := canonicalize(ref.host); problem != "" {
+			c.Report(ref.col, msg.NewHostNotCanonical(ref.r, ref.host, problem, fixed))
+			canonical = fixed
+		}
+		if invalid := invalidCharacters(canonical); invalid != "" {
+			c.Report(ref.col, msg.NewInvalidHostCharacters(ref.r, ref.host, invalid))
+			continue
+		}
+		if problem := invalidLength(canonical); problem != "" {
+			c.Report(ref.col, msg.NewInvalidHostLength(ref.r, ref.host, problem))
+			continue
+		}
+
+		if ref.validateOnly || strings.HasPrefix(canonical, util.Wildcard) {
+			continue
+		}
+		fqdn := util.ConvertHostToFQDN(ref.r.Metadata.FullName.Namespace, canonical)
+		if !strings.HasSuffix(fqdn, "."+util.DefaultKubernetesDomain) {
+			continue
+		}
+		if fqdn == canonical {
+			full[fqdn] = append(full[fqdn], ref)
+		} else {
+			short[fqdn] = append(short[fqdn], ref)
+		}
+	}
+
+	for fqdn, refs := range short {
+		if len(full[fqdn]) == 0 {
+			continue
+		}
+		resources := resourceNames(full[fqdn])
+		for _, ref := range refs {
+			c.Report(ref.col, msg.NewInconsistentHostForm(ref.r, ref.host, fqdn, resources, fqdn))
+		}
+	}
+}
+
+// collectReferences returns the distinct, non-empty hosts of each resource.
+func collectReferences(c analysis.Context) []reference {
+	var refs []reference
+	seen := make(map[reference]bool)
+	add := func(col collection.Name, r *resource.Instance, host string, validateOnly bool) {
+		ref := reference{col: col, r: r, host: host, validateOnly: validateOnly}
+		if host != "" && !seen[ref] {
+			seen[ref] = true
+			refs = append(refs, ref)
+		}
+	}
+
+	c.ForEach(collections.IstioNetworkingV1Alpha3Virtualservices.Name(), func(r *resource.Instance) bool {
+		vs := r.Message.(*v1alpha3.VirtualService)
+		addHost := func(host string) {
+			add(collections.IstioNetworkingV1Alpha3Virtualservices.Name(), r, host, false)
+		}
+		for _, h := range vs.GetHosts() {
+			addHost(h)
+		}
+		for _, route := range vs.GetHttp() {
+			for _, rd := range route.GetRoute() {
+				addHost(rd.GetDestination().GetHost())
+			}
+			if m := route.GetMirror(); m != nil {
+				addHost(m.GetHost())
+			}
+		}
+		for _, route := range vs.GetTls() {
+			for _, rd := range route.GetRoute() {
+				addHost(rd.GetDestination().GetHost())
+			}
+		}
+		for _, route := range vs.GetTcp() {
+			for _, rd := range route.GetRoute() {
+				addHost(rd.GetDestination().GetHost())
+			}
+		}
+		return true
+	})
+
+	c.ForEach(collections.IstioNetworkingV1Alpha3Destinationrules.Name(), func(r *resource.Instance) bool {
+		dr := r.Message.(*v1alpha3.DestinationRule)
+		add(collections.IstioNetworkingV1Alpha3Destinationrules.Name(), r, dr.GetHost(), false)
+		return true
+	})
+
+	c.ForEach(collections.IstioNetworkingV1Alpha3Serviceentries.Name(), func(r *resource.Instance) bool {
+		se := r.Message.(*v1alpha3.ServiceEntry)
+		for _, h := range se.GetHosts() {
+			add(collections.IstioNetworkingV1Alpha3Serviceentries.Name(), r, h, false)
+		}
+		return true
+	})
+
+	// Gateway and sidecar hosts may be qualified with a namespace, as in "namespace/host"
+	c.ForEach(collections.IstioNetworkingV1Alpha3Gateways.Name(), func(r *resource.Instance) bool {
+		gw := r.Message.(*v1alpha3.Gateway)
+		for _, server := range gw.GetServers() {
+			for _, h := range server.GetHosts() {
+				add(collections.IstioNetworkingV1Alpha3Gateways.Name(), r, unqualified(h), true)
+			}
+		}
+		return true
+	})
+
+	c.ForEach(collections.IstioNetworkingV1Alpha3Sidecars.Name(), func(r *resource.Instance) bool {
+		sc := r.Message.(*v1alpha3.Sidecar)
+		for _, egress := range sc.GetEgress() {
+			for _, h := range egress.GetHosts() {
+				add(collections.IstioNetworkingV1Alpha3Sidecars.Name(), r, unqualified(h), true)
+			}
+		}
+		return true
+	})
+
+	c.ForEach(collections.K8SCoreV1Services.Name(), func(r *resource.Instance) bool {
+		svc := r.Message.(*v1.ServiceSpec)
+		add(collections.K8SCoreV1Services.Name(), r, string(r.Metadata.FullName.Name), true)
+		add(collections.K8SCoreV1Services.Name(), r, svc.ExternalName, true)
+		return true
+	})
+
+	return refs
+}
+
+// canonicalize returns a description of how a host differs from its canonical form, if it does, and the canonical form.
+func canonicalize(host string) (string, string) {
+	var problems []string
+	canonical := host
+	if strings.HasSuffix(canonical, ".") && canonical != "." {
+		problems = append(problems, "a trailing dot")
+		canonical = strings.TrimSuffix(canonical, ".")
+	}
+	if lower := strings.ToLower(canonical); lower != canonical {
+		problems = append(problems, "upper case letters")
+		canonical = lower
+	}
+	return strings.Join(problems, " and "), canonical
+}
+
+// invalidCharacters returns the characters of a host that are not valid in DNS names, if any. A leading wildcard
+// label is allowed.
+func invalidCharacters(host string) string {
+	if host == util.Wildcard {
+		return ""
+	}
+	name := strings.TrimPrefix(host, util.Wildcard+".")
+
+	seen := make(map[rune]bool)
+	var invalid []string
+	for _, ch := range name {
+		if (ch >= 'a' && ch <= 'z') || (ch >= '0' && ch <= '9') || ch == '-' || ch == '.' || seen[ch] {
+			continue
+		}
+		seen[ch] = true
+		invalid = append(invalid, string(ch))
+	}
+	return strings.Join(invalid, " ")
+}
+
+// invalidLength returns why a host is too long to be a DNS name, if it is.
+func invalidLength(host string) string {
+	if len(host) > maxHostLength {
+		return fmt.Sprintf("it is longer than %d characters", maxHostLength)
+	}
+	for _, label := range strings.Split(host, ".") {
+		if len(label) > maxLabelLength {
+			return fmt.Sprintf("its label %q is longer than %d characters", label, maxLabelLength)
+		}
+	}
+	return ""
+}
+
+// unqualified returns a host without its namespace qualifier, if any.
+func unqualified(host string) string {
+	if i := strings.Index(host, "/"); i >= 0 {
+		return host[i+1:]
+	}
+	return host
+}
+
+func resourceNames(refs []reference) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, ref := range refs {
+		name := ref.r.Origin.FriendlyName()
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
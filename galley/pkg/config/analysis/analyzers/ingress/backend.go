@@ -0,0 +1,97 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ingress
+
+import (
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+// BackendAnalyzer checks that the backends of Ingress resources handled by Istio reference existing services in the
+// namespace of the Ingress, and ports that the services expose, by number or name.
+type BackendAnalyzer struct{}
+
+var _ analysis.Analyzer = &BackendAnalyzer{}
+
+// Metadata implements Analyzer
+func (a *BackendAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:        "ingress.BackendAnalyzer",
+		Description: "Checks that the backends of Kubernetes Ingresses handled by Istio exist",
+		Inputs: collection.Names{
+			collections.IstioMeshV1Alpha1MeshConfig.Name(),
+			collections.K8SCoreV1Services.Name(),
+			collections.K8SExtensionsV1Beta1Ingresses.Name(),
+		},
+	}
+}
+
+// Analyze implements Analyzer
+func (a *BackendAnalyzer) Analyze(ctx analysis.Context) {
+	forEachHandledIngress(ctx, func(r *resource.Instance, ing *v1beta1.IngressSpec) {
+		if ing.Backend != nil {
+			analyzeBackend(ctx, r, ing.Backend, "spec.backend")
+		}
+		for i, rule := range ing.Rules {
+			if rule.HTTP == nil {
+				continue
+			}
+			for j := range rule.HTTP.Paths {
+				path := fmt.Sprintf("spec.rules[%d].http.paths[%d].backend", i, j)
+				analyzeBackend(ctx, r, &rule.HTTP.Paths[j].Backend, path)
+			}
+		}
+	})
+}
+
+func analyzeBackend(ctx analysis.Context, r *resource.Instance, b *v1beta1.IngressBackend, path string) {
+	// Backends referencing other resources than services are not supported by Istio, which the schema validation
+	// reports.
+	if b.ServiceName == "" {
+		return
+	}
+
+	name := resource.NewFullName(r.Metadata.FullName.Namespace, resource.LocalName(b.ServiceName))
+	svc := ctx.Find(collections.K8SCoreV1Services.Name(), name)
+	if svc == nil {
+		ctx.Report(collections.K8SExtensionsV1Beta1Ingresses.Name(),
+			msg.NewReferencedResourceNotFound(r, "service", name.String()).WithFieldPath(path+".serviceName"))
+		return
+	}
+
+	if !hasPort(svc.Message.(*v1.ServiceSpec), b.ServicePort) {
+		ctx.Report(collections.K8SExtensionsV1Beta1Ingresses.Name(),
+			msg.NewIngressBackendPortNotFound(r, b.ServicePort.String(), name.String()).WithFieldPath(path+".servicePort"))
+	}
+}
+
+// hasPort returns whether the service exposes the port, given as number or name.
+func hasPort(svc *v1.ServiceSpec, port intstr.IntOrString) bool {
+	for _, p := range svc.Ports {
+		if port.Type == intstr.Int && p.Port == port.IntVal || port.Type == intstr.String && p.Name == port.StrVal {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,78 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ingress
+
+import (
+	"fmt"
+
+	"k8s.io/api/extensions/v1beta1"
+
+	"istio.io/api/annotation"
+	"istio.io/api/mesh/v1alpha1"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+// ClassAnalyzer checks that Ingress resources meant for Istio are handled by it: with ingressControllerMode STRICT,
+// the default, Istio only handles Ingresses whose kubernetes.io/ingress.class annotation is the ingress class of the
+// mesh, and with OFF it handles none. Ingresses of other classes are meant for other controllers and not reported.
+type ClassAnalyzer struct{}
+
+var _ analysis.Analyzer = &ClassAnalyzer{}
+
+// Metadata implements Analyzer
+func (a *ClassAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:        "ingress.ClassAnalyzer",
+		Description: "Checks that Kubernetes Ingresses meant for Istio are handled by it",
+		Inputs: collection.Names{
+			collections.IstioMeshV1Alpha1MeshConfig.Name(),
+			collections.K8SExtensionsV1Beta1Ingresses.Name(),
+		},
+	}
+}
+
+// Analyze implements Analyzer
+func (a *ClassAnalyzer) Analyze(ctx analysis.Context) {
+	m := analysis.MeshConfig(ctx)
+	ctx.ForEach(collections.K8SExtensionsV1Beta1Ingresses.Name(), func(r *resource.Instance) bool {
+		if reason := notHandledReason(m, r); reason != "" {
+			ctx.Report(collections.K8SExtensionsV1Beta1Ingresses.Name(), msg.NewIngressNotHandled(r, reason))
+		}
+		return true
+	})
+}
+
+// notHandledReason returns why Istio does not handle the Ingress, if it looks meant for Istio.
+func notHandledReason(m *v1alpha1.MeshConfig, r *resource.Instance) string {
+	class, ok := r.Metadata.Annotations[annotation.IoKubernetesIngressClass.Name]
+	switch {
+	case ok:
+		if class == m.GetIngressClass() && m.GetIngressControllerMode() == v1alpha1.MeshConfig_OFF {
+			return fmt.Sprintf("its %s annotation is %q, but ingressControllerMode is OFF",
+				annotation.IoKubernetesIngressClass.Name, class)
+		}
+	case r.Message.(*v1beta1.IngressSpec).IngressClassName != nil:
+		// The controller is selected by an IngressClass resource
+	case m.GetIngressControllerMode() == v1alpha1.MeshConfig_STRICT:
+		return fmt.Sprintf("it has no %s annotation, which ingressControllerMode STRICT requires. Set it to %q if "+
+			"Istio should handle the Ingress", annotation.IoKubernetesIngressClass.Name, m.GetIngressClass())
+	}
+	return ""
+}
@@ -0,0 +1,74 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ingress contains analyzers for Kubernetes Ingress resources that are handled by Istio, e.g. while
+// migrating them to Istio gateways and virtual services.
+package ingress
+
+import (
+	"k8s.io/api/extensions/v1beta1"
+
+	"istio.io/api/annotation"
+	"istio.io/api/mesh/v1alpha1"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/util"
+	"istio.io/istio/pkg/config/constants"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+// ingressGatewayLabels are the labels of the gateway workload that pilot configures for Ingress resources.
+var ingressGatewayLabels = map[string]string{constants.IstioLabel: constants.IstioIngressLabelValue}
+
+// handledByIstio returns whether pilot handles the Ingress, following the ingress controller mode and class of the
+// mesh configuration. Ingresses that select their controller with an IngressClass resource are not considered handled,
+// as the controllers of IngressClass resources are not known to the analysis.
+func handledByIstio(m *v1alpha1.MeshConfig, r *resource.Instance) bool {
+	if class, ok := r.Metadata.Annotations[annotation.IoKubernetesIngressClass.Name]; ok {
+		return m.GetIngressControllerMode() != v1alpha1.MeshConfig_OFF && class == m.GetIngressClass()
+	}
+	if r.Message.(*v1beta1.IngressSpec).IngressClassName != nil {
+		return false
+	}
+	return m.GetIngressControllerMode() == v1alpha1.MeshConfig_DEFAULT
+}
+
+// forEachHandledIngress calls fn for each Ingress handled by Istio.
+// Analyzers that call this should include collections.IstioMeshV1Alpha1MeshConfig and
+// collections.K8SExtensionsV1Beta1Ingresses as inputs in their Metadata.
+func forEachHandledIngress(ctx analysis.Context, fn func(r *resource.Instance, ing *v1beta1.IngressSpec)) {
+	m := analysis.MeshConfig(ctx)
+	ctx.ForEach(collections.K8SExtensionsV1Beta1Ingresses.Name(), func(r *resource.Instance) bool {
+		if handledByIstio(m, r) {
+			fn(r, r.Message.(*v1beta1.IngressSpec))
+		}
+		return true
+	})
+}
+
+// ingressGatewayNamespaces returns the sorted namespaces of the workloads of the Istio ingress gateway.
+// Analyzers that call this should include collections.K8SCoreV1Pods as an input in their Metadata.
+func ingressGatewayNamespaces(ctx analysis.Context) []resource.Namespace {
+	var namespaces []resource.Namespace
+	seen := make(map[resource.Namespace]bool)
+	for _, p := range util.SelectPods(ctx, "", ingressGatewayLabels) {
+		ns := p.Metadata.FullName.Namespace
+		if !seen[ns] {
+			seen[ns] = true
+			namespaces = append(namespaces, ns)
+		}
+	}
+	return namespaces
+}
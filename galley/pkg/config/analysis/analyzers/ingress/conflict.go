@@ -0,0 +1,138 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ingress
+
+import (
+	"strings"
+
+	"k8s.io/api/extensions/v1beta1"
+
+	"istio.io/api/networking/v1alpha3"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/util"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	ingresstransform "istio.io/istio/galley/pkg/config/processor/transforms/ingress"
+	"istio.io/istio/pkg/config/host"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+// HostConflictAnalyzer checks for hosts of Ingress resources handled by Istio that virtual services bound to gateways
+// of the Istio ingress gateway workload route as well. Pilot generates routes for both on the same gateway, so requests
+// are routed by whichever configuration takes precedence, which commonly happens while migrating Ingresses to Istio
+// gateways and virtual services.
+type HostConflictAnalyzer struct{}
+
+var _ analysis.Analyzer = &HostConflictAnalyzer{}
+
+// Metadata implements Analyzer
+func (a *HostConflictAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:        "ingress.HostConflictAnalyzer",
+		Description: "Checks for hosts of Kubernetes Ingresses that virtual services on the Istio ingress gateway route too",
+		Inputs: collection.Names{
+			collections.IstioMeshV1Alpha1MeshConfig.Name(),
+			collections.IstioNetworkingV1Alpha3Gateways.Name(),
+			collections.IstioNetworkingV1Alpha3Virtualservices.Name(),
+			collections.K8SCoreV1Pods.Name(),
+			collections.K8SExtensionsV1Beta1Ingresses.Name(),
+		},
+	}
+}
+
+// claim is a host that a virtual service routes on a gateway of the Istio ingress gateway workload.
+type claim struct {
+	host           host.Name
+	virtualService resource.FullName
+	gateway        resource.FullName
+}
+
+// Analyze implements Analyzer
+func (a *HostConflictAnalyzer) Analyze(ctx analysis.Context) {
+	var claims []claim
+	forEachHandledIngress(ctx, func(r *resource.Instance, ing *v1beta1.IngressSpec) {
+		if claims == nil {
+			claims = gatewayClaims(ctx)
+		}
+
+		seen := make(map[string]bool)
+		for _, rule := range ing.Rules {
+			// Rules without a host match all hosts, which is not a conflict with a particular virtual service.
+			if rule.Host == "" || seen[rule.Host] {
+				continue
+			}
+			seen[rule.Host] = true
+
+			for _, c := range claims {
+				if host.Name(rule.Host).Matches(c.host) {
+					ctx.Report(collections.K8SExtensionsV1Beta1Ingresses.Name(),
+						msg.NewIngressHostConflict(r, rule.Host, c.virtualService.String(), c.gateway.String()))
+				}
+			}
+		}
+	})
+}
+
+// gatewayClaims returns the hosts that virtual services route on the gateways of the Istio ingress gateway workload. It
+// returns an empty, non-nil slice if there are none.
+func gatewayClaims(ctx analysis.Context) []claim {
+	claims := []claim{}
+
+	pods := make(map[resource.FullName]bool)
+	for _, p := range util.SelectPods(ctx, "", ingressGatewayLabels) {
+		pods[p.Metadata.FullName] = true
+	}
+	if len(pods) == 0 {
+		return claims
+	}
+
+	gateways := make(map[resource.FullName]bool)
+	ctx.ForEach(collections.IstioNetworkingV1Alpha3Gateways.Name(), func(r *resource.Instance) bool {
+		// Skip the gateways that galley generates for Ingresses themselves
+		if strings.HasSuffix(string(r.Metadata.FullName.Name), "-"+ingresstransform.IstioIngressGatewayName) {
+			return true
+		}
+		for _, p := range util.SelectPods(ctx, "", r.Message.(*v1alpha3.Gateway).GetSelector()) {
+			if pods[p.Metadata.FullName] {
+				gateways[r.Metadata.FullName] = true
+				break
+			}
+		}
+		return true
+	})
+	if len(gateways) == 0 {
+		return claims
+	}
+
+	ctx.ForEach(collections.IstioNetworkingV1Alpha3Virtualservices.Name(), func(r *resource.Instance) bool {
+		vs := r.Message.(*v1alpha3.VirtualService)
+		for _, gw := range vs.GetGateways() {
+			if gw == util.MeshGateway {
+				continue
+			}
+			name := resource.NewShortOrFullName(r.Metadata.FullName.Namespace, gw)
+			if !gateways[name] {
+				continue
+			}
+			for _, h := range vs.GetHosts() {
+				claims = append(claims, claim{host: host.Name(h), virtualService: r.Metadata.FullName, gateway: name})
+			}
+		}
+		return true
+	})
+	return claims
+}
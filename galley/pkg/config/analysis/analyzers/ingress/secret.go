@@ -0,0 +1,101 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ingress
+
+import (
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/gateway"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/util"
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+// SecretAnalyzer checks the TLS secrets of Ingress resources handled by Istio. Unlike most ingress controllers, the
+// Istio ingress gateway reads them from its own namespace rather than from the namespace of the Ingress, so the
+// secrets must exist there, and hold a certificate and a matching private key that do not expire soon.
+type SecretAnalyzer struct{}
+
+var _ analysis.Analyzer = &SecretAnalyzer{}
+
+// Metadata implements Analyzer
+func (a *SecretAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:        "ingress.SecretAnalyzer",
+		Description: "Checks that the TLS secrets of Kubernetes Ingresses handled by Istio are in the gateway namespace",
+		Inputs: collection.Names{
+			collections.IstioMeshV1Alpha1MeshConfig.Name(),
+			collections.K8SCoreV1Pods.Name(),
+			collections.K8SCoreV1Secrets.Name(),
+			collections.K8SExtensionsV1Beta1Ingresses.Name(),
+		},
+		TimeDependent: true,
+	}
+}
+
+// Analyze implements Analyzer
+func (a *SecretAnalyzer) Analyze(ctx analysis.Context) {
+	forEachHandledIngress(ctx, func(r *resource.Instance, ing *v1beta1.IngressSpec) {
+		if len(ing.TLS) == 0 {
+			return
+		}
+		// Without a gateway workload, there is no namespace to check the secrets against, and they are not checked.
+		gwNamespaces := ingressGatewayNamespaces(ctx)
+
+		for i, tls := range ing.TLS {
+			if tls.SecretName == "" {
+				continue
+			}
+			path := fmt.Sprintf("spec.tls[%d].secretName", i)
+			for _, gwNs := range gwNamespaces {
+				name := resource.NewFullName(gwNs, resource.LocalName(tls.SecretName))
+				secret := ctx.Find(collections.K8SCoreV1Secrets.Name(), name)
+				if secret == nil {
+					ctx.Report(collections.K8SExtensionsV1Beta1Ingresses.Name(),
+						secretNotFound(ctx, r, tls.SecretName, gwNs).WithFieldPath(path))
+					continue
+				}
+				for _, m := range gateway.CredentialMessages(r, name.String(), secret.Message.(*v1.Secret), 0) {
+					ctx.Report(collections.K8SExtensionsV1Beta1Ingresses.Name(), m.WithFieldPath(path))
+				}
+			}
+		}
+	})
+}
+
+// secretNotFound returns the message for a TLS secret of the Ingress r that does not exist in the namespace gwNs of the
+// Istio ingress gateway. The secret is often created in the namespace of the Ingress instead, as other ingress
+// controllers expect.
+func secretNotFound(ctx analysis.Context, r *resource.Instance, secretName string,
+	gwNs resource.Namespace) diag.Message {
+
+	namespaces := util.BuildSecretIndex(ctx).Namespaces(resource.LocalName(secretName))
+	if len(namespaces) == 0 {
+		name := resource.NewFullName(gwNs, resource.LocalName(secretName))
+		return msg.NewReferencedResourceNotFound(r, "secret", name.String())
+	}
+	names := make([]string, 0, len(namespaces))
+	for _, ns := range namespaces {
+		names = append(names, ns.String())
+	}
+	return msg.NewIngressSecretNotInGatewayNamespace(r, secretName, gwNs.String(), names)
+}
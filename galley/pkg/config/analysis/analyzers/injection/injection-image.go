@@ -36,7 +36,8 @@ const sidecarInjectorConfigName = "istio-sidecar-injector"
 
 // injectionConfigMap is a snippet of the sidecar injection ConfigMap
 type injectionConfigMap struct {
-	Global global `json:"global"`
+	Global                 global                 `json:"global"`
+	SidecarInjectorWebhook sidecarInjectorWebhook `json:"sidecarInjectorWebhook"`
 }
 
 type global struct {
@@ -49,6 +50,11 @@ type proxy struct {
 	Image string `json:"image"`
 }
 
+type sidecarInjectorWebhook struct {
+	// EnableNamespacesByDefault makes the injector webhook inject pods in namespaces without an injection label.
+	EnableNamespacesByDefault bool `json:"enableNamespacesByDefault"`
+}
+
 // Metadata implements Analyzer.
 func (a *ImageAnalyzer) Metadata() analysis.Metadata {
 	return analysis.Metadata{
@@ -64,23 +70,11 @@ func (a *ImageAnalyzer) Metadata() analysis.Metadata {
 
 // Analyze implements Analyzer.
 func (a *ImageAnalyzer) Analyze(c analysis.Context) {
-	var proxyImage string
-
-	// TODO: when multiple injector configmaps exist, we may need to assess them respectively.
-	c.ForEach(collections.K8SCoreV1Configmaps.Name(), func(r *resource.Instance) bool {
-		if r.Metadata.FullName.Name.String() == sidecarInjectorConfigName {
-			cm := r.Message.(*v1.ConfigMap)
-
-			proxyImage = getIstioProxyImage(cm)
-
-			return false
-		}
-		return true
-	})
-
-	if proxyImage == "" {
+	config := getInjectorConfig(c)
+	if config == nil {
 		return
 	}
+	proxyImage := config.proxyImage()
 
 	injectedNamespaces := make(map[string]struct{})
 
@@ -120,12 +114,30 @@ func (a *ImageAnalyzer) Analyze(c analysis.Context) {
 	})
 }
 
-// getIstioProxyImage retrieves the proxy image name defined in the sidecar injector
-// configuration.
-func getIstioProxyImage(cm *v1.ConfigMap) string {
-	var m injectionConfigMap
-	if err := json.Unmarshal([]byte(cm.Data["values"]), &m); err != nil {
-		return ""
-	}
+// getInjectorConfig retrieves the values of the sidecar injector configuration, or nil if there is no sidecar
+// injector ConfigMap or its values cannot be parsed.
+func getInjectorConfig(c analysis.Context) *injectionConfigMap {
+	var config *injectionConfigMap
+
+	// TODO: when multiple injector configmaps exist, we may need to assess them respectively.
+	c.ForEach(collections.K8SCoreV1Configmaps.Name(), func(r *resource.Instance) bool {
+		if r.Metadata.FullName.Name.String() == sidecarInjectorConfigName {
+			cm := r.Message.(*v1.ConfigMap)
+
+			var m injectionConfigMap
+			if err := json.Unmarshal([]byte(cm.Data["values"]), &m); err == nil {
+				config = &m
+			}
+
+			return false
+		}
+		return true
+	})
+
+	return config
+}
+
+// proxyImage returns the proxy image name defined in the sidecar injector configuration.
+func (m *injectionConfigMap) proxyImage() string {
 	return fmt.Sprintf("%s/%s:%s", m.Global.Hub, m.Global.Proxy.Image, m.Global.Tag)
 }
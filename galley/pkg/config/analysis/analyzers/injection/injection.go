@@ -55,6 +55,7 @@ func (a *Analyzer) Metadata() analysis.Metadata {
 		Inputs: collection.Names{
 			collections.K8SCoreV1Namespaces.Name(),
 			collections.K8SCoreV1Pods.Name(),
+			collections.K8SCoreV1Configmaps.Name(),
 		},
 	}
 }
@@ -62,6 +63,12 @@ func (a *Analyzer) Metadata() analysis.Metadata {
 // Analyze implements Analyzer
 func (a *Analyzer) Analyze(c analysis.Context) {
 	injectedNamespaces := make(map[string]bool)
+	otherNamespaces := make(map[string]bool)
+
+	enabledByDefault := false
+	if config := getInjectorConfig(c); config != nil {
+		enabledByDefault = config.SidecarInjectorWebhook.EnableNamespacesByDefault
+	}
 
 	c.ForEach(collections.K8SCoreV1Namespaces.Name(), func(r *resource.Instance) bool {
 
@@ -74,9 +81,14 @@ func (a *Analyzer) Analyze(c analysis.Context) {
 		_, okNewInjectionLabel := r.Metadata.Labels[RevisionInjectionLabelName]
 
 		if injectionLabel == "" && !okNewInjectionLabel {
-			// TODO: if Istio is installed with sidecarInjectorWebhook.enableNamespacesByDefault=true
-			// (in the istio-sidecar-injector configmap), we need to reverse this logic and treat this as an injected namespace
+			// With sidecarInjectorWebhook.enableNamespacesByDefault=true (in the istio-sidecar-injector configmap), the
+			// injector webhook selects all namespaces that do not disable injection
+			if enabledByDefault {
+				injectedNamespaces[ns] = true
+				return true
+			}
 
+			otherNamespaces[ns] = true
 			c.Report(collections.K8SCoreV1Namespaces.Name(), msg.NewNamespaceNotInjected(r, r.Metadata.FullName.String(), r.Metadata.FullName.String()))
 			return true
 		}
@@ -91,6 +103,7 @@ func (a *Analyzer) Analyze(c analysis.Context) {
 			}
 		} else if injectionLabel != InjectionLabelEnableValue {
 			// If legacy label has any value other than the enablement value, they are deliberately not injecting it, so ignore
+			otherNamespaces[ns] = true
 			return true
 		}
 
@@ -102,6 +115,14 @@ func (a *Analyzer) Analyze(c analysis.Context) {
 	c.ForEach(collections.K8SCoreV1Pods.Name(), func(r *resource.Instance) bool {
 		pod := r.Message.(*v1.Pod)
 
+		if otherNamespaces[pod.GetNamespace()] {
+			// Unless the sidecar was injected manually, it is not injected again once the pod is recreated
+			if _, ok := pod.GetAnnotations()[annotation.SidecarStatus.Name]; ok {
+				c.Report(collections.K8SCoreV1Pods.Name(), msg.NewPodProxyInNonInjectedNamespace(r, pod.GetNamespace()))
+			}
+			return true
+		}
+
 		if !injectedNamespaces[pod.GetNamespace()] {
 			return true
 		}
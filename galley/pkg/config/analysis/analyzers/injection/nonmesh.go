@@ -0,0 +1,136 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package injection
+
+import (
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+
+	"istio.io/api/networking/v1alpha3"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/util"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+// NonMeshNamespaceAnalyzer checks for Istio configuration in namespaces that are not enabled for injection and have
+// no workloads with a sidecar. Such resources were usually applied to the wrong namespace.
+type NonMeshNamespaceAnalyzer struct{}
+
+var _ analysis.Analyzer = &NonMeshNamespaceAnalyzer{}
+
+var nonMeshConfig = []struct {
+	col  collection.Name
+	kind string
+}{
+	{collections.IstioNetworkingV1Alpha3Destinationrules.Name(), "DestinationRule"},
+	{collections.IstioNetworkingV1Alpha3Virtualservices.Name(), "VirtualService"},
+	{collections.IstioSecurityV1Beta1Authorizationpolicies.Name(), "AuthorizationPolicy"},
+	{collections.IstioSecurityV1Beta1Peerauthentications.Name(), "PeerAuthentication"},
+	{collections.IstioSecurityV1Beta1Requestauthentications.Name(), "RequestAuthentication"},
+}
+
+// Metadata implements Analyzer
+func (a *NonMeshNamespaceAnalyzer) Metadata() analysis.Metadata {
+	inputs := collection.Names{
+		collections.IstioMeshV1Alpha1MeshConfig.Name(),
+		collections.K8SCoreV1Namespaces.Name(),
+		collections.K8SCoreV1Pods.Name(),
+	}
+	for _, c := range nonMeshConfig {
+		inputs = append(inputs, c.col)
+	}
+
+	return analysis.Metadata{
+		Name:        "injection.NonMeshNamespaceAnalyzer",
+		Description: "Checks for Istio configuration in namespaces without mesh workloads",
+		Inputs:      inputs,
+	}
+}
+
+// Analyze implements Analyzer
+func (a *NonMeshNamespaceAnalyzer) Analyze(c analysis.Context) {
+	rootNamespace := resource.Namespace(analysis.MeshConfig(c).GetRootNamespace())
+
+	// Namespaces that are not enabled for injection
+	candidates := make(map[resource.Namespace]*resource.Instance)
+	c.ForEach(collections.K8SCoreV1Namespaces.Name(), func(r *resource.Instance) bool {
+		ns := resource.Namespace(r.Metadata.FullName.String())
+		if util.IsSystemNamespace(ns) || ns == rootNamespace {
+			return true
+		}
+		_, revision := r.Metadata.Labels[RevisionInjectionLabelName]
+		if r.Metadata.Labels[InjectionLabelName] != InjectionLabelEnableValue && !revision {
+			candidates[ns] = r
+		}
+		return true
+	})
+
+	// Workloads with a sidecar make a namespace part of the mesh, whatever its labels, e.g. gateways or manually
+	// injected pods.
+	c.ForEach(collections.K8SCoreV1Pods.Name(), func(r *resource.Instance) bool {
+		for _, container := range r.Message.(*v1.Pod).Spec.Containers {
+			if container.Name == istioProxyName {
+				delete(candidates, r.Metadata.FullName.Namespace)
+				break
+			}
+		}
+		return true
+	})
+	if len(candidates) == 0 {
+		return
+	}
+
+	resources := make(map[resource.Namespace][]string)
+	var order []resource.Namespace
+	for _, cfg := range nonMeshConfig {
+		cfg := cfg
+		c.ForEach(cfg.col, func(r *resource.Instance) bool {
+			ns := r.Metadata.FullName.Namespace
+			if _, ok := candidates[ns]; !ok || gatewayOnly(r) {
+				return true
+			}
+			if _, ok := resources[ns]; !ok {
+				order = append(order, ns)
+			}
+			resources[ns] = append(resources[ns], fmt.Sprintf("%s %s", cfg.kind, r.Metadata.FullName.Name))
+			return true
+		})
+	}
+
+	for _, ns := range order {
+		c.Report(collections.K8SCoreV1Namespaces.Name(),
+			msg.NewIstioConfigInNonMeshNamespace(candidates[ns], ns.String(), resources[ns]))
+	}
+}
+
+// gatewayOnly returns whether the resource is a virtual service that is bound to gateways only. It configures the
+// gateways, wherever they run, and does not need mesh workloads in its own namespace.
+func gatewayOnly(r *resource.Instance) bool {
+	vs, ok := r.Message.(*v1alpha3.VirtualService)
+	if !ok || len(vs.GetGateways()) == 0 {
+		return false
+	}
+	for _, g := range vs.GetGateways() {
+		if g == util.MeshGateway {
+			return false
+		}
+	}
+	return true
+}
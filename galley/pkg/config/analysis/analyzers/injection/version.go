@@ -0,0 +1,87 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package injection
+
+import (
+	goversion "github.com/hashicorp/go-version"
+	v1 "k8s.io/api/core/v1"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/util"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+// ProxyVersionAnalyzer checks that the proxies of pods are at most one minor version behind the control plane. The
+// control plane version is the one the configuration is analyzed for if known, and otherwise the tag configured in
+// the sidecar injector. Proxy versions are taken from the image tags of the proxy containers, so pods running images
+// without a version tag are not checked.
+type ProxyVersionAnalyzer struct{}
+
+var _ analysis.Analyzer = &ProxyVersionAnalyzer{}
+
+// Metadata implements Analyzer.
+func (a *ProxyVersionAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:        "injection.ProxyVersionAnalyzer",
+		Description: "Checks that the proxies of pods are not too far behind the control plane version",
+		Inputs: collection.Names{
+			collections.K8SCoreV1Pods.Name(),
+			collections.K8SCoreV1Configmaps.Name(),
+		},
+	}
+}
+
+// Analyze implements Analyzer.
+func (a *ProxyVersionAnalyzer) Analyze(c analysis.Context) {
+	controlPlaneVersion := analysis.IstioVersion(c)
+	if controlPlaneVersion == "" {
+		if config := getInjectorConfig(c); config != nil {
+			controlPlaneVersion = config.Global.Tag
+		}
+	}
+	controlPlane, err := goversion.NewVersion(controlPlaneVersion)
+	if err != nil {
+		return
+	}
+
+	c.ForEach(collections.K8SCoreV1Pods.Name(), func(r *resource.Instance) bool {
+		pod := r.Message.(*v1.Pod)
+
+		for _, container := range pod.Spec.Containers {
+			if container.Name != istioProxyName {
+				continue
+			}
+
+			tag := util.ImageTag(container.Image)
+			proxy, err := goversion.NewVersion(tag)
+			if err != nil {
+				continue
+			}
+
+			// Only minor versions within the same major version are compared
+			if proxy.Segments()[0] != controlPlane.Segments()[0] {
+				continue
+			}
+			if behind := controlPlane.Segments()[1] - proxy.Segments()[1]; behind > 1 {
+				c.Report(collections.K8SCoreV1Pods.Name(), msg.NewProxyVersionSkew(r, tag, behind, controlPlaneVersion))
+			}
+		}
+
+		return true
+	})
+}
@@ -0,0 +1,155 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package multicluster
+
+import (
+	"strings"
+
+	"github.com/gogo/protobuf/proto"
+
+	"istio.io/api/networking/v1alpha3"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+// globalSuffix is the suffix of the hosts that refer to the services of remote clusters in a multi-cluster mesh with
+// replicated control planes, in the form <name>.<namespace>.global.
+const globalSuffix = ".global"
+
+// RemoteServiceAnalyzer checks, in a multi-cluster analysis, that the service entries for <name>.<namespace>.global
+// hosts refer to services that exist in a cluster other than the one the service entry is in. Such service entries
+// are left behind when the service is removed from the remote cluster.
+type RemoteServiceAnalyzer struct{}
+
+var _ analysis.Analyzer = &RemoteServiceAnalyzer{}
+
+// Metadata implements Analyzer
+func (a *RemoteServiceAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:        "multicluster.RemoteServiceAnalyzer",
+		Description: "Checks that service entries for the services of remote clusters refer to existing services",
+		Inputs: collection.Names{
+			collections.IstioNetworkingV1Alpha3Serviceentries.Name(),
+			collections.K8SCoreV1Services.Name(),
+		},
+	}
+}
+
+// Analyze implements Analyzer
+func (a *RemoteServiceAnalyzer) Analyze(c analysis.Context) {
+	if len(analysis.Clusters(c)) < 2 {
+		return
+	}
+
+	c.ForEach(collections.IstioNetworkingV1Alpha3Serviceentries.Name(), func(r *resource.Instance) bool {
+		seClusters := analysis.ResourceClusters(c, collections.IstioNetworkingV1Alpha3Serviceentries.Name(), r.Metadata.FullName)
+
+		for _, h := range r.Message.(*v1alpha3.ServiceEntry).GetHosts() {
+			svc, ok := remoteService(h)
+			if !ok {
+				continue
+			}
+			var svcClusters []string
+			if c.Exists(collections.K8SCoreV1Services.Name(), svc) {
+				svcClusters = analysis.ResourceClusters(c, collections.K8SCoreV1Services.Name(), svc)
+			}
+			for _, cluster := range seClusters {
+				if !containsOther(svcClusters, cluster) {
+					c.Report(collections.IstioNetworkingV1Alpha3Serviceentries.Name(),
+						msg.NewRemoteServiceNotFound(r, h, svc.String(), cluster))
+					break
+				}
+			}
+		}
+		return true
+	})
+}
+
+// remoteService returns the name of the service that a <name>.<namespace>.global host refers to.
+func remoteService(host string) (resource.FullName, bool) {
+	if !strings.HasSuffix(host, globalSuffix) {
+		return resource.FullName{}, false
+	}
+	parts := strings.Split(strings.TrimSuffix(host, globalSuffix), ".")
+	if len(parts) != 2 || parts[0] == "*" {
+		return resource.FullName{}, false
+	}
+	return resource.NewFullName(resource.Namespace(parts[1]), resource.LocalName(parts[0])), true
+}
+
+// containsOther returns whether the clusters contain a cluster other than the given one.
+func containsOther(clusters []string, cluster string) bool {
+	for _, c := range clusters {
+		if c != cluster {
+			return true
+		}
+	}
+	return false
+}
+
+// MeshNetworksConsistencyAnalyzer checks, in a multi-cluster analysis, that all clusters have the same mesh networks
+// configuration.
+type MeshNetworksConsistencyAnalyzer struct{}
+
+var _ analysis.Analyzer = &MeshNetworksConsistencyAnalyzer{}
+
+// Metadata implements Analyzer
+func (a *MeshNetworksConsistencyAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:        "multicluster.MeshNetworksConsistencyAnalyzer",
+		Description: "Checks that all clusters of a multi-cluster mesh have the same mesh networks configuration",
+		Inputs: collection.Names{
+			collections.IstioMeshV1Alpha1MeshNetworks.Name(),
+		},
+	}
+}
+
+// Analyze implements Analyzer
+func (a *MeshNetworksConsistencyAnalyzer) Analyze(c analysis.Context) {
+	clusters := analysis.Clusters(c)
+	if len(clusters) < 2 {
+		return
+	}
+
+	// Messages need a resource, so report on the mesh networks of the analysis. There should be exactly one.
+	var r *resource.Instance
+	c.ForEach(collections.IstioMeshV1Alpha1MeshNetworks.Name(), func(mn *resource.Instance) bool {
+		r = mn
+		return false
+	})
+	if r == nil {
+		return
+	}
+
+	// Compare with the first cluster whose configuration is known
+	reference := ""
+	for _, cluster := range clusters {
+		mn := analysis.ClusterMeshNetworks(c, cluster)
+		if mn == nil {
+			continue
+		}
+		if reference == "" {
+			reference = cluster
+			continue
+		}
+		if !proto.Equal(mn, analysis.ClusterMeshNetworks(c, reference)) {
+			c.Report(collections.IstioMeshV1Alpha1MeshNetworks.Name(), msg.NewMeshNetworksMismatch(r, cluster, reference))
+		}
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rootnamespace
+
+import (
+	"istio.io/api/networking/v1alpha3"
+	"istio.io/api/security/v1beta1"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+// MeshWideAnalyzer reports resources in the root namespace that apply to the whole mesh because they set neither
+// exportTo nor a workload selector. This is often unintended, and a change to such a resource
+// affects every workload in the mesh.
+type MeshWideAnalyzer struct{}
+
+var _ analysis.Analyzer = &MeshWideAnalyzer{}
+
+// scope returns the field that would restrict a resource to part of the mesh, if the resource does not set it.
+type scope func(r *resource.Instance) string
+
+var scopes = []struct {
+	col   collection.Name
+	scope scope
+}{
+	{collections.IstioNetworkingV1Alpha3Destinationrules.Name(), func(r *resource.Instance) string {
+		return missingExportTo(r.Message.(*v1alpha3.DestinationRule).GetExportTo())
+	}},
+	{collections.IstioNetworkingV1Alpha3Envoyfilters.Name(), func(r *resource.Instance) string {
+		if len(r.Message.(*v1alpha3.EnvoyFilter).GetWorkloadSelector().GetLabels()) == 0 {
+			return "workloadSelector"
+		}
+		return ""
+	}},
+	{collections.IstioNetworkingV1Alpha3Sidecars.Name(), func(r *resource.Instance) string {
+		if len(r.Message.(*v1alpha3.Sidecar).GetWorkloadSelector().GetLabels()) == 0 {
+			return "workloadSelector"
+		}
+		return ""
+	}},
+	{collections.IstioNetworkingV1Alpha3Virtualservices.Name(), func(r *resource.Instance) string {
+		return missingExportTo(r.Message.(*v1alpha3.VirtualService).GetExportTo())
+	}},
+	{collections.IstioSecurityV1Beta1Authorizationpolicies.Name(), func(r *resource.Instance) string {
+		return missingSelector(len(r.Message.(*v1beta1.AuthorizationPolicy).GetSelector().GetMatchLabels()))
+	}},
+	{collections.IstioSecurityV1Beta1Peerauthentications.Name(), func(r *resource.Instance) string {
+		return missingSelector(len(r.Message.(*v1beta1.PeerAuthentication).GetSelector().GetMatchLabels()))
+	}},
+	{collections.IstioSecurityV1Beta1Requestauthentications.Name(), func(r *resource.Instance) string {
+		return missingSelector(len(r.Message.(*v1beta1.RequestAuthentication).GetSelector().GetMatchLabels()))
+	}},
+}
+
+// Metadata implements Analyzer
+func (a *MeshWideAnalyzer) Metadata() analysis.Metadata {
+	inputs := collection.Names{collections.IstioMeshV1Alpha1MeshConfig.Name()}
+	for _, s := range scopes {
+		inputs = append(inputs, s.col)
+	}
+
+	return analysis.Metadata{
+		Name:        "rootnamespace.MeshWideAnalyzer",
+		Description: "Checks for resources in the root namespace that apply to the whole mesh",
+		Inputs:      inputs,
+	}
+}
+
+// Analyze implements Analyzer
+func (a *MeshWideAnalyzer) Analyze(c analysis.Context) {
+	rootNamespace := resource.Namespace(analysis.MeshConfig(c).GetRootNamespace())
+	if rootNamespace == "" {
+		return
+	}
+
+	for _, s := range scopes {
+		s := s
+		c.ForEach(s.col, func(r *resource.Instance) bool {
+			if r.Metadata.FullName.Namespace != rootNamespace {
+				return true
+			}
+			if field := s.scope(r); field != "" {
+				c.Report(s.col, msg.NewMeshWideResource(r, string(rootNamespace), field))
+			}
+			return true
+		})
+	}
+}
+
+// missingExportTo returns "exportTo" unless the resource sets it. Exporting to all namespaces explicitly confirms that
+// the resource is meant to be mesh-wide.
+func missingExportTo(exportTo []string) string {
+	if len(exportTo) == 0 {
+		return "exportTo"
+	}
+	return ""
+}
+
+func missingSelector(labels int) string {
+	if labels == 0 {
+		return "selector"
+	}
+	return ""
+}
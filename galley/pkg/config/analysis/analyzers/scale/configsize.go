@@ -0,0 +1,308 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scale
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+
+	"istio.io/api/annotation"
+	"istio.io/api/networking/v1alpha3"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/util"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+// ConfigSizeAnalyzer warns about configuration that makes the configuration pushed to each proxy grow with the size
+// of the mesh: namespaces whose proxies import all service ports of a large mesh because no Sidecar resource limits
+// their egress hosts, Sidecar resources that import all namespaces of a large mesh, virtual services with hundreds of
+// routes, and envoy filters that patch every proxy of the mesh. The reported sizes are rough estimates, meant to tell
+// which configuration dominates the size of the configuration of proxies.
+type ConfigSizeAnalyzer struct {
+	// MaxServicePorts is the number of service ports above which proxies should not import all of them. Defaults to
+	// 1000.
+	MaxServicePorts int
+
+	// MaxRoutes is the number of routes above which virtual services are reported. Defaults to 100.
+	MaxRoutes int
+}
+
+var _ analysis.Analyzer = &ConfigSizeAnalyzer{}
+var _ analysis.Configurable = &ConfigSizeAnalyzer{}
+
+const (
+	defaultMaxServicePorts = 1000
+	defaultMaxRoutes       = 100
+
+	istioProxyName = "istio-proxy"
+
+	// The egress host of Sidecar resources that imports all hosts of all namespaces.
+	allHosts = "*/*"
+)
+
+// Rough estimates of the size of the Envoy configuration generated for each service port a proxy imports, i.e. its
+// cluster, listener and route configuration, and for each route of a virtual service.
+const (
+	bytesPerServicePort = 4 * 1024
+	bytesPerRoute       = 512
+)
+
+// Metadata implements Analyzer
+func (a *ConfigSizeAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:        "scale.ConfigSizeAnalyzer",
+		Description: "Checks for configuration that makes the configuration of proxies grow with the size of the mesh",
+		Inputs: collection.Names{
+			collections.IstioMeshV1Alpha1MeshConfig.Name(),
+			collections.IstioNetworkingV1Alpha3Envoyfilters.Name(),
+			collections.IstioNetworkingV1Alpha3Serviceentries.Name(),
+			collections.IstioNetworkingV1Alpha3Sidecars.Name(),
+			collections.IstioNetworkingV1Alpha3Virtualservices.Name(),
+			collections.K8SCoreV1Namespaces.Name(),
+			collections.K8SCoreV1Pods.Name(),
+			collections.K8SCoreV1Services.Name(),
+		},
+	}
+}
+
+// Configure implements Configurable. The maxServicePorts and maxRoutes parameters set MaxServicePorts and MaxRoutes.
+func (a *ConfigSizeAnalyzer) Configure(params map[string]string) error {
+	for k, v := range params {
+		var target *int
+		switch k {
+		case "maxServicePorts":
+			target = &a.MaxServicePorts
+		case "maxRoutes":
+			target = &a.MaxRoutes
+		default:
+			return fmt.Errorf("unknown parameter %q", k)
+		}
+
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return fmt.Errorf("invalid %s %q: must be a positive integer", k, v)
+		}
+		*target = n
+	}
+	return nil
+}
+
+// Analyze implements Analyzer
+func (a *ConfigSizeAnalyzer) Analyze(c analysis.Context) {
+	rootNamespace := resource.Namespace(analysis.MeshConfig(c).GetRootNamespace())
+	ports := countServicePorts(c)
+
+	a.analyzeSidecars(c, rootNamespace, ports)
+	a.analyzeVirtualServices(c)
+	a.analyzeEnvoyFilters(c, rootNamespace)
+}
+
+// servicePorts is the number of service ports visible in each namespace.
+type servicePorts struct {
+	exported int
+	local    map[resource.Namespace]int
+}
+
+func (p servicePorts) visibleIn(ns resource.Namespace) int {
+	return p.exported + p.local[ns]
+}
+
+// countServicePorts counts the ports of the hosts of services and service entries, which proxies import unless a
+// Sidecar resource limits their egress hosts.
+func countServicePorts(c analysis.Context) servicePorts {
+	result := servicePorts{local: make(map[resource.Namespace]int)}
+	add := func(ns resource.Namespace, exportedToAll bool, n int) {
+		if exportedToAll {
+			result.exported += n
+		} else {
+			result.local[ns] += n
+		}
+	}
+
+	c.ForEach(collections.K8SCoreV1Services.Name(), func(r *resource.Instance) bool {
+		all := true
+		if exportTo, ok := r.Metadata.Annotations[annotation.NetworkingExportTo.Name]; ok {
+			all = util.IsExportToAllNamespaces(strings.Split(exportTo, ","))
+		}
+		add(r.Metadata.FullName.Namespace, all, atLeastOne(len(r.Message.(*v1.ServiceSpec).Ports)))
+		return true
+	})
+
+	c.ForEach(collections.IstioNetworkingV1Alpha3Serviceentries.Name(), func(r *resource.Instance) bool {
+		se := r.Message.(*v1alpha3.ServiceEntry)
+		add(r.Metadata.FullName.Namespace, util.IsExportToAllNamespaces(se.GetExportTo()),
+			len(se.GetHosts())*atLeastOne(len(se.GetPorts())))
+		return true
+	})
+
+	return result
+}
+
+// analyzeSidecars reports Sidecar resources that import all namespaces, and namespaces whose proxies import all
+// service ports because there is no default Sidecar resource, i.e. one without a workload selector, in the namespace
+// or in the root namespace.
+func (a *ConfigSizeAnalyzer) analyzeSidecars(c analysis.Context, rootNamespace resource.Namespace, ports servicePorts) {
+	scoped := make(map[resource.Namespace]bool)
+	c.ForEach(collections.IstioNetworkingV1Alpha3Sidecars.Name(), func(r *resource.Instance) bool {
+		sc := r.Message.(*v1alpha3.Sidecar)
+		ns := r.Metadata.FullName.Namespace
+
+		isDefault := len(sc.GetWorkloadSelector().GetLabels()) == 0
+
+		// The namespaces of default Sidecar resources that import all hosts are not reported as well, as the Sidecar
+		// resource is reported instead.
+		if isDefault && len(sc.GetEgress()) > 0 {
+			scoped[ns] = true
+		}
+
+		if importsAllHosts(sc) {
+			// A default Sidecar resource in the root namespace applies to proxies in all namespaces.
+			n := ports.visibleIn(ns)
+			if ns == rootNamespace && isDefault {
+				n = ports.exported
+			}
+			if n > a.maxServicePorts() {
+				c.Report(collections.IstioNetworkingV1Alpha3Sidecars.Name(),
+					msg.NewWildcardSidecarEgress(r, n, formatSize(n*bytesPerServicePort)))
+			}
+		}
+		return true
+	})
+	if scoped[rootNamespace] {
+		return
+	}
+
+	proxies := make(map[resource.Namespace]int)
+	c.ForEach(collections.K8SCoreV1Pods.Name(), func(r *resource.Instance) bool {
+		for _, container := range r.Message.(*v1.Pod).Spec.Containers {
+			if container.Name == istioProxyName {
+				proxies[r.Metadata.FullName.Namespace]++
+				break
+			}
+		}
+		return true
+	})
+
+	namespaces := make([]string, 0, len(proxies))
+	for ns := range proxies {
+		namespaces = append(namespaces, ns.String())
+	}
+	sort.Strings(namespaces)
+
+	for _, name := range namespaces {
+		ns := resource.Namespace(name)
+		n := ports.visibleIn(ns)
+		if scoped[ns] || n <= a.maxServicePorts() {
+			continue
+		}
+		r := c.Find(collections.K8SCoreV1Namespaces.Name(), resource.NewFullName("", resource.LocalName(name)))
+		if r == nil {
+			continue
+		}
+		c.Report(collections.K8SCoreV1Namespaces.Name(),
+			msg.NewUnscopedProxyConfig(r, proxies[ns], name, n, formatSize(n*bytesPerServicePort)))
+	}
+}
+
+// importsAllHosts returns whether any egress listener of the Sidecar resource imports all hosts of all namespaces.
+func importsAllHosts(sc *v1alpha3.Sidecar) bool {
+	for _, e := range sc.GetEgress() {
+		for _, h := range e.GetHosts() {
+			if h == allHosts {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (a *ConfigSizeAnalyzer) analyzeVirtualServices(c analysis.Context) {
+	c.ForEach(collections.IstioNetworkingV1Alpha3Virtualservices.Name(), func(r *resource.Instance) bool {
+		vs := r.Message.(*v1alpha3.VirtualService)
+
+		// Each match of a route is a separate Envoy route.
+		routes := 0
+		for _, h := range vs.GetHttp() {
+			routes += atLeastOne(len(h.GetMatch()))
+		}
+		for _, t := range vs.GetTls() {
+			routes += atLeastOne(len(t.GetMatch()))
+		}
+		for _, t := range vs.GetTcp() {
+			routes += atLeastOne(len(t.GetMatch()))
+		}
+
+		if routes > a.maxRoutes() {
+			c.Report(collections.IstioNetworkingV1Alpha3Virtualservices.Name(),
+				msg.NewLargeVirtualService(r, routes, a.maxRoutes(), formatSize(routes*bytesPerRoute)))
+		}
+		return true
+	})
+}
+
+// analyzeEnvoyFilters reports envoy filters without a workload selector in the root namespace, which apply to all
+// proxies.
+func (a *ConfigSizeAnalyzer) analyzeEnvoyFilters(c analysis.Context, rootNamespace resource.Namespace) {
+	c.ForEach(collections.IstioNetworkingV1Alpha3Envoyfilters.Name(), func(r *resource.Instance) bool {
+		ef := r.Message.(*v1alpha3.EnvoyFilter)
+		if r.Metadata.FullName.Namespace == rootNamespace && len(ef.GetWorkloadSelector().GetLabels()) == 0 &&
+			len(ef.GetConfigPatches()) > 0 {
+			c.Report(collections.IstioNetworkingV1Alpha3Envoyfilters.Name(),
+				msg.NewMeshWideEnvoyFilter(r, len(ef.GetConfigPatches())))
+		}
+		return true
+	})
+}
+
+func (a *ConfigSizeAnalyzer) maxServicePorts() int {
+	if a.MaxServicePorts > 0 {
+		return a.MaxServicePorts
+	}
+	return defaultMaxServicePorts
+}
+
+func (a *ConfigSizeAnalyzer) maxRoutes() int {
+	if a.MaxRoutes > 0 {
+		return a.MaxRoutes
+	}
+	return defaultMaxRoutes
+}
+
+func atLeastOne(n int) int {
+	if n == 0 {
+		return 1
+	}
+	return n
+}
+
+// formatSize formats a number of bytes for messages, e.g. 4 KiB or 1.5 MiB.
+func formatSize(bytes int) string {
+	switch {
+	case bytes >= 1<<20:
+		return fmt.Sprintf("%.1f MiB", float64(bytes)/(1<<20))
+	case bytes >= 1<<10:
+		return fmt.Sprintf("%d KiB", bytes>>10)
+	default:
+		return fmt.Sprintf("%d B", bytes)
+	}
+}
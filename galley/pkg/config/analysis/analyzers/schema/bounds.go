@@ -0,0 +1,215 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schema
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"istio.io/api/networking/v1alpha3"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+// BoundsAnalyzer checks that numeric fields of networking resources, such as percentages, weights and port numbers,
+// are within their valid range. The OpenAPI schemas of the CRDs only check the types of these fields, so files and
+// clusters without the validation webhook can contain out of range values. Each value is reported with the path of its
+// field.
+type BoundsAnalyzer struct{}
+
+var _ analysis.Analyzer = &BoundsAnalyzer{}
+
+// bound is the valid range of a numeric field
+type bound struct {
+	min, max float64
+}
+
+var (
+	percentBound = bound{0, 100}
+	portBound    = bound{1, 65535}
+	optPortBound = bound{0, 65535} // for ports where 0 means unset
+	nonNegative  = bound{0, math.Inf(1)}
+)
+
+func (b bound) String() string {
+	if math.IsInf(b.max, 1) {
+		return formatNumber(b.min) + " or more"
+	}
+	return fmt.Sprintf("%s to %s", formatNumber(b.min), formatNumber(b.max))
+}
+
+// Metadata implements Analyzer
+func (a *BoundsAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:        "schema.BoundsAnalyzer",
+		Description: "Checks that numeric fields of networking resources are within their valid range",
+		Inputs: collection.Names{
+			collections.IstioNetworkingV1Alpha3Destinationrules.Name(),
+			collections.IstioNetworkingV1Alpha3Gateways.Name(),
+			collections.IstioNetworkingV1Alpha3Serviceentries.Name(),
+			collections.IstioNetworkingV1Alpha3Sidecars.Name(),
+			collections.IstioNetworkingV1Alpha3Virtualservices.Name(),
+		},
+	}
+}
+
+// boundsChecker reports out of range values of a single resource
+type boundsChecker struct {
+	c   analysis.Context
+	col collection.Name
+	r   *resource.Instance
+}
+
+func (b *boundsChecker) check(field string, value float64, valid bound) {
+	if value < valid.min || value > valid.max {
+		b.c.Report(b.col, msg.NewFieldValueOutOfRange(b.r, field, formatNumber(value), valid.String()))
+	}
+}
+
+// Analyze implements Analyzer
+func (a *BoundsAnalyzer) Analyze(c analysis.Context) {
+	forEach := func(col collection.Name, fn func(b *boundsChecker)) {
+		c.ForEach(col, func(r *resource.Instance) bool {
+			fn(&boundsChecker{c: c, col: col, r: r})
+			return true
+		})
+	}
+
+	forEach(collections.IstioNetworkingV1Alpha3Virtualservices.Name(), func(b *boundsChecker) {
+		checkVirtualService(b, b.r.Message.(*v1alpha3.VirtualService))
+	})
+	forEach(collections.IstioNetworkingV1Alpha3Destinationrules.Name(), func(b *boundsChecker) {
+		dr := b.r.Message.(*v1alpha3.DestinationRule)
+		checkTrafficPolicy(b, "trafficPolicy", dr.GetTrafficPolicy())
+		for i, s := range dr.GetSubsets() {
+			checkTrafficPolicy(b, fmt.Sprintf("subsets[%d].trafficPolicy", i), s.GetTrafficPolicy())
+		}
+	})
+	forEach(collections.IstioNetworkingV1Alpha3Serviceentries.Name(), func(b *boundsChecker) {
+		se := b.r.Message.(*v1alpha3.ServiceEntry)
+		for i, p := range se.GetPorts() {
+			b.check(fmt.Sprintf("ports[%d].number", i), float64(p.GetNumber()), portBound)
+		}
+		for i, e := range se.GetEndpoints() {
+			for name, number := range e.GetPorts() {
+				b.check(fmt.Sprintf("endpoints[%d].ports.%s", i, name), float64(number), portBound)
+			}
+		}
+	})
+	forEach(collections.IstioNetworkingV1Alpha3Gateways.Name(), func(b *boundsChecker) {
+		for i, s := range b.r.Message.(*v1alpha3.Gateway).GetServers() {
+			b.check(fmt.Sprintf("servers[%d].port.number", i), float64(s.GetPort().GetNumber()), portBound)
+		}
+	})
+	forEach(collections.IstioNetworkingV1Alpha3Sidecars.Name(), func(b *boundsChecker) {
+		sc := b.r.Message.(*v1alpha3.Sidecar)
+		for i, l := range sc.GetIngress() {
+			b.check(fmt.Sprintf("ingress[%d].port.number", i), float64(l.GetPort().GetNumber()), portBound)
+		}
+		for i, l := range sc.GetEgress() {
+			if l.GetPort() != nil {
+				b.check(fmt.Sprintf("egress[%d].port.number", i), float64(l.GetPort().GetNumber()), optPortBound)
+			}
+		}
+	})
+}
+
+func checkVirtualService(b *boundsChecker, vs *v1alpha3.VirtualService) {
+	for i, h := range vs.GetHttp() {
+		field := fmt.Sprintf("http[%d]", i)
+		for j, m := range h.GetMatch() {
+			b.check(fmt.Sprintf("%s.match[%d].port", field, j), float64(m.GetPort()), optPortBound)
+		}
+		for j, rd := range h.GetRoute() {
+			checkRouteDestination(b, fmt.Sprintf("%s.route[%d]", field, j), rd.GetDestination(), rd.GetWeight())
+		}
+		if m := h.GetMirror(); m != nil {
+			b.check(field+".mirror.port.number", float64(m.GetPort().GetNumber()), optPortBound)
+		}
+		if p := h.GetMirrorPercent(); p != nil {
+			b.check(field+".mirrorPercent", float64(p.GetValue()), percentBound)
+		}
+		if p := h.GetMirrorPercentage(); p != nil {
+			b.check(field+".mirrorPercentage.value", p.GetValue(), percentBound)
+		}
+		if p := h.GetFault().GetAbort().GetPercentage(); p != nil {
+			b.check(field+".fault.abort.percentage.value", p.GetValue(), percentBound)
+		}
+		if p := h.GetFault().GetDelay().GetPercentage(); p != nil {
+			b.check(field+".fault.delay.percentage.value", p.GetValue(), percentBound)
+		}
+		if r := h.GetRetries(); r != nil {
+			b.check(field+".retries.attempts", float64(r.GetAttempts()), nonNegative)
+		}
+	}
+	for i, t := range vs.GetTls() {
+		for j, m := range t.GetMatch() {
+			b.check(fmt.Sprintf("tls[%d].match[%d].port", i, j), float64(m.GetPort()), optPortBound)
+		}
+		for j, rd := range t.GetRoute() {
+			checkRouteDestination(b, fmt.Sprintf("tls[%d].route[%d]", i, j), rd.GetDestination(), rd.GetWeight())
+		}
+	}
+	for i, t := range vs.GetTcp() {
+		for j, m := range t.GetMatch() {
+			b.check(fmt.Sprintf("tcp[%d].match[%d].port", i, j), float64(m.GetPort()), optPortBound)
+		}
+		for j, rd := range t.GetRoute() {
+			checkRouteDestination(b, fmt.Sprintf("tcp[%d].route[%d]", i, j), rd.GetDestination(), rd.GetWeight())
+		}
+	}
+}
+
+func checkRouteDestination(b *boundsChecker, field string, d *v1alpha3.Destination, weight int32) {
+	b.check(field+".weight", float64(weight), percentBound)
+	b.check(field+".destination.port.number", float64(d.GetPort().GetNumber()), optPortBound)
+}
+
+func checkTrafficPolicy(b *boundsChecker, field string, p *v1alpha3.TrafficPolicy) {
+	if p == nil {
+		return
+	}
+	checkPortTrafficPolicy(b, field, p.GetConnectionPool(), p.GetOutlierDetection())
+	for i, pls := range p.GetPortLevelSettings() {
+		plsField := fmt.Sprintf("%s.portLevelSettings[%d]", field, i)
+		b.check(plsField+".port.number", float64(pls.GetPort().GetNumber()), portBound)
+		checkPortTrafficPolicy(b, plsField, pls.GetConnectionPool(), pls.GetOutlierDetection())
+	}
+}
+
+func checkPortTrafficPolicy(b *boundsChecker, field string, cp *v1alpha3.ConnectionPoolSettings, od *v1alpha3.OutlierDetection) {
+	if tcp := cp.GetTcp(); tcp != nil {
+		b.check(field+".connectionPool.tcp.maxConnections", float64(tcp.GetMaxConnections()), nonNegative)
+	}
+	if http := cp.GetHttp(); http != nil {
+		b.check(field+".connectionPool.http.http1MaxPendingRequests", float64(http.GetHttp1MaxPendingRequests()), nonNegative)
+		b.check(field+".connectionPool.http.http2MaxRequests", float64(http.GetHttp2MaxRequests()), nonNegative)
+		b.check(field+".connectionPool.http.maxRequestsPerConnection", float64(http.GetMaxRequestsPerConnection()), nonNegative)
+		b.check(field+".connectionPool.http.maxRetries", float64(http.GetMaxRetries()), nonNegative)
+	}
+	if od != nil {
+		b.check(field+".outlierDetection.maxEjectionPercent", float64(od.GetMaxEjectionPercent()), percentBound)
+		b.check(field+".outlierDetection.minHealthPercent", float64(od.GetMinHealthPercent()), percentBound)
+	}
+}
+
+func formatNumber(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
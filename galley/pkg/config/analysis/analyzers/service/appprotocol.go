@@ -0,0 +1,106 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/util"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	configKube "istio.io/istio/pkg/config/kube"
+	"istio.io/istio/pkg/config/protocol"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+// AppProtocolAnalyzer checks for service ports whose appProtocol and port name prefix imply different protocols.
+// Which one is used depends on the Istio version.
+type AppProtocolAnalyzer struct{}
+
+var _ analysis.Analyzer = &AppProtocolAnalyzer{}
+
+// The version in which appProtocol started to take precedence over the port name.
+const appProtocolVersion = "1.6"
+
+// Metadata implements Analyzer
+func (s *AppProtocolAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:        "service.AppProtocolAnalyzer",
+		Description: "Checks for service ports whose appProtocol and name imply different protocols",
+		Inputs: collection.Names{
+			collections.K8SCoreV1Services.Name(),
+		},
+	}
+}
+
+// Analyze implements Analyzer
+func (s *AppProtocolAnalyzer) Analyze(c analysis.Context) {
+	version := analysis.IstioVersion(c)
+
+	c.ForEach(collections.K8SCoreV1Services.Name(), func(r *resource.Instance) bool {
+		if util.IsSystemNamespace(r.Metadata.FullName.Namespace) || util.IsIstioControlPlane(r) {
+			return true
+		}
+
+		svc := r.Message.(*v1.ServiceSpec)
+		for _, port := range svc.Ports {
+			if port.AppProtocol == nil || port.Protocol == v1.ProtocolUDP {
+				continue
+			}
+
+			byName := configKube.ConvertProtocol(port.Port, port.Name, port.Protocol, nil)
+			byAppProtocol := configKube.ConvertProtocol(port.Port, port.Name, port.Protocol, port.AppProtocol)
+			// A port name without protocol prefix does not conflict with anything.
+			if byName.IsUnsupported() || byName == byAppProtocol {
+				continue
+			}
+
+			c.Report(collections.K8SCoreV1Services.Name(), msg.NewPortProtocolConflict(r, port.Name, int(port.Port),
+				*port.AppProtocol, protocolName(byAppProtocol), string(byName),
+				honoredProtocol(version, byName, byAppProtocol)))
+		}
+		return true
+	})
+}
+
+// honoredProtocol explains which of the conflicting protocols is used in the given Istio version.
+func honoredProtocol(version string, byName, byAppProtocol protocol.Instance) string {
+	usesAppProtocol := fmt.Sprintf("uses appProtocol (%s) and ignores the port name", protocolName(byAppProtocol))
+	if byAppProtocol.IsUnsupported() {
+		usesAppProtocol = "uses appProtocol, which it does not recognize, and falls back to protocol detection"
+	}
+
+	if version != "" {
+		if c, err := analysis.CompareVersion(version, appProtocolVersion); err == nil {
+			if c < 0 {
+				return fmt.Sprintf("Istio %s ignores appProtocol and uses the port name (%s).", version, byName)
+			}
+			return fmt.Sprintf("Istio %s %s.", version, usesAppProtocol)
+		}
+	}
+	return fmt.Sprintf("Since Istio %s, Istio %s; before, it ignored appProtocol and used the port name (%s).",
+		appProtocolVersion, usesAppProtocol, byName)
+}
+
+func protocolName(p protocol.Instance) string {
+	if p.IsUnsupported() {
+		return "none"
+	}
+	return string(p)
+}
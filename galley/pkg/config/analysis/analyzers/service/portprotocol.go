@@ -0,0 +1,119 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/util"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	configKube "istio.io/istio/pkg/config/kube"
+	"istio.io/istio/pkg/config/protocol"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+// PortProtocolAnalyzer checks for service ports whose protocol Istio can't route: ports that target the same
+// container port with different protocols, and ports of headless services that don't declare a protocol.
+type PortProtocolAnalyzer struct{}
+
+var _ analysis.Analyzer = &PortProtocolAnalyzer{}
+
+// Metadata implements Analyzer
+func (s *PortProtocolAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:        "service.PortProtocolAnalyzer",
+		Description: "Checks for service ports whose protocol Istio can't route",
+		Inputs: collection.Names{
+			collections.K8SCoreV1Services.Name(),
+		},
+	}
+}
+
+// Analyze implements Analyzer
+func (s *PortProtocolAnalyzer) Analyze(c analysis.Context) {
+	c.ForEach(collections.K8SCoreV1Services.Name(), func(r *resource.Instance) bool {
+		if util.IsSystemNamespace(r.Metadata.FullName.Namespace) || util.IsIstioControlPlane(r) {
+			return true
+		}
+
+		svc := r.Message.(*v1.ServiceSpec)
+		s.analyzeTargetPorts(r, c, svc)
+		if svc.ClusterIP == v1.ClusterIPNone {
+			s.analyzeHeadlessService(r, c, svc)
+		}
+		return true
+	})
+}
+
+// analyzeTargetPorts reports service ports that target the same container port as an earlier port of the service,
+// with a different protocol. The sidecar accepts a single protocol on each port of the workload.
+func (s *PortProtocolAnalyzer) analyzeTargetPorts(r *resource.Instance, c analysis.Context, svc *v1.ServiceSpec) {
+	byTarget := make(map[string]v1.ServicePort)
+	for _, port := range svc.Ports {
+		// UDP isn't intercepted by the sidecar, so serving TCP and UDP on the same port (e.g. DNS) is fine.
+		if port.Protocol == v1.ProtocolUDP {
+			continue
+		}
+		p := configKube.ConvertProtocol(port.Port, port.Name, port.Protocol, port.AppProtocol)
+		if p.IsUnsupported() {
+			continue
+		}
+
+		target := targetPort(port)
+		other, ok := byTarget[target]
+		if !ok {
+			byTarget[target] = port
+			continue
+		}
+		otherProtocol := configKube.ConvertProtocol(other.Port, other.Name, other.Protocol, other.AppProtocol)
+		if p != otherProtocol {
+			c.Report(collections.K8SCoreV1Services.Name(), msg.NewTargetPortProtocolConflict(r,
+				portDescription(other), portDescription(port), target, string(otherProtocol), string(p)))
+		}
+	}
+}
+
+// analyzeHeadlessService reports ports of a headless service that rely on protocol detection.
+func (s *PortProtocolAnalyzer) analyzeHeadlessService(r *resource.Instance, c analysis.Context, svc *v1.ServiceSpec) {
+	for _, port := range svc.Ports {
+		if port.Protocol == v1.ProtocolUDP {
+			continue
+		}
+		if configKube.ConvertProtocol(port.Port, port.Name, port.Protocol, port.AppProtocol) == protocol.Unsupported {
+			c.Report(collections.K8SCoreV1Services.Name(),
+				msg.NewHeadlessServicePortProtocolNotDeclared(r, port.Name, int(port.Port)))
+		}
+	}
+}
+
+// targetPort returns the container port that the service port targets, which defaults to the service port.
+func targetPort(port v1.ServicePort) string {
+	if port.TargetPort.String() == "" || port.TargetPort.String() == "0" {
+		return fmt.Sprintf("%d", port.Port)
+	}
+	return port.TargetPort.String()
+}
+
+func portDescription(port v1.ServicePort) string {
+	if port.Name == "" {
+		return fmt.Sprintf("%d", port.Port)
+	}
+	return fmt.Sprintf("%s (%d)", port.Name, port.Port)
+}
@@ -0,0 +1,147 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package serviceentry
+
+import (
+	"sort"
+	"strings"
+
+	"istio.io/api/networking/v1alpha3"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+// EndpointAddressAnalyzer checks that an endpoint address, as used by the endpoints of service entries and by
+// workload entries, is not used with conflicting labels or ports within a network. Such endpoints are repeatedly
+// reassigned as the registry is updated, which makes VM and hybrid workloads flap.
+type EndpointAddressAnalyzer struct{}
+
+var _ analysis.Analyzer = &EndpointAddressAnalyzer{}
+
+// endpoint is a workload entry, either inline in a service entry or standalone
+type endpoint struct {
+	col   collection.Name
+	r     *resource.Instance
+	entry *v1alpha3.WorkloadEntry
+}
+
+type networkAddress struct {
+	network string
+	address string
+}
+
+// Metadata implements Analyzer
+func (a *EndpointAddressAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:        "serviceentry.EndpointAddressAnalyzer",
+		Description: "Checks that endpoint addresses are not used with conflicting labels or ports",
+		Inputs: collection.Names{
+			collections.IstioNetworkingV1Alpha3Serviceentries.Name(),
+			collections.IstioNetworkingV1Alpha3Workloadentries.Name(),
+		},
+	}
+}
+
+// Analyze implements Analyzer
+func (a *EndpointAddressAnalyzer) Analyze(c analysis.Context) {
+	endpoints := make(map[networkAddress][]endpoint)
+	add := func(col collection.Name, r *resource.Instance, entry *v1alpha3.WorkloadEntry) {
+		if entry.GetAddress() == "" {
+			return
+		}
+		key := networkAddress{network: entry.GetNetwork(), address: entry.GetAddress()}
+		endpoints[key] = append(endpoints[key], endpoint{col: col, r: r, entry: entry})
+	}
+
+	c.ForEach(collections.IstioNetworkingV1Alpha3Serviceentries.Name(), func(r *resource.Instance) bool {
+		for _, e := range r.Message.(*v1alpha3.ServiceEntry).GetEndpoints() {
+			add(collections.IstioNetworkingV1Alpha3Serviceentries.Name(), r, e)
+		}
+		return true
+	})
+	c.ForEach(collections.IstioNetworkingV1Alpha3Workloadentries.Name(), func(r *resource.Instance) bool {
+		add(collections.IstioNetworkingV1Alpha3Workloadentries.Name(), r, r.Message.(*v1alpha3.WorkloadEntry))
+		return true
+	})
+
+	for key, eps := range endpoints {
+		// Resources listing the address more than once are reported once
+		reported := make(map[*resource.Instance]bool)
+		for i, ep := range eps {
+			var others []string
+			var labels, ports bool
+			for j, other := range eps {
+				if i == j {
+					continue
+				}
+				l := conflicting(ep.entry.GetLabels(), other.entry.GetLabels())
+				p := conflictingPorts(ep.entry.GetPorts(), other.entry.GetPorts())
+				if !l && !p {
+					continue
+				}
+				labels = labels || l
+				ports = ports || p
+				others = appendUnique(others, other.r.Origin.FriendlyName())
+			}
+			if len(others) == 0 || reported[ep.r] {
+				continue
+			}
+			reported[ep.r] = true
+
+			var conflict []string
+			if labels {
+				conflict = append(conflict, "labels")
+			}
+			if ports {
+				conflict = append(conflict, "ports")
+			}
+			sort.Strings(others)
+			c.Report(ep.col, msg.NewConflictingEndpointAddress(ep.r, key.address, others, strings.Join(conflict, " and ")))
+		}
+	}
+}
+
+// conflicting returns whether both label sets have a label with different values.
+func conflicting(a, b map[string]string) bool {
+	for k, v := range a {
+		if other, ok := b[k]; ok && other != v {
+			return true
+		}
+	}
+	return false
+}
+
+// conflictingPorts returns whether both endpoints map a port name to different port numbers.
+func conflictingPorts(a, b map[string]uint32) bool {
+	for name, number := range a {
+		if other, ok := b[name]; ok && other != number {
+			return true
+		}
+	}
+	return false
+}
+
+func appendUnique(names []string, name string) []string {
+	for _, n := range names {
+		if n == name {
+			return names
+		}
+	}
+	return append(names, name)
+}
@@ -0,0 +1,207 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package serviceentry
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	"istio.io/api/annotation"
+	"istio.io/api/networking/v1alpha3"
+	v1 "k8s.io/api/core/v1"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/util"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+// InterceptionAnalyzer checks that the addresses of service entries are intercepted by the sidecars of the pods they
+// are visible to. Traffic to addresses that are excluded from interception, or not included in it, by the
+// traffic.sidecar.istio.io/includeOutboundIPRanges and excludeOutboundIPRanges annotations bypasses the sidecar, and
+// with it all routing, security and telemetry configured for the service entry. The sidecar injector records the
+// effective ranges, including the defaults from its values, in these annotations of each injected pod.
+type InterceptionAnalyzer struct{}
+
+var _ analysis.Analyzer = &InterceptionAnalyzer{}
+
+const (
+	istioProxyName = "istio-proxy"
+
+	// The maximum number of pods named in a message
+	maxPodsInMessage = 3
+)
+
+// interception holds the outbound IP ranges intercepted by the sidecar of a pod
+type interception struct {
+	includeAll bool
+	include    []*net.IPNet
+	exclude    []*net.IPNet
+}
+
+// Metadata implements Analyzer
+func (a *InterceptionAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:        "serviceentry.InterceptionAnalyzer",
+		Description: "Checks that service entry addresses are intercepted by the sidecars",
+		Inputs: collection.Names{
+			collections.IstioNetworkingV1Alpha3Serviceentries.Name(),
+			collections.K8SCoreV1Pods.Name(),
+		},
+	}
+}
+
+// Analyze implements Analyzer
+func (a *InterceptionAnalyzer) Analyze(c analysis.Context) {
+	pods := make(map[resource.FullName]interception)
+	c.ForEach(collections.K8SCoreV1Pods.Name(), func(r *resource.Instance) bool {
+		if i, ok := podInterception(r.Message.(*v1.Pod)); ok {
+			pods[r.Metadata.FullName] = i
+		}
+		return true
+	})
+	if len(pods) == 0 {
+		return
+	}
+
+	c.ForEach(collections.IstioNetworkingV1Alpha3Serviceentries.Name(), func(r *resource.Instance) bool {
+		se := r.Message.(*v1alpha3.ServiceEntry)
+		exportAll := util.IsExportToAllNamespaces(se.GetExportTo())
+
+		for _, address := range se.GetAddresses() {
+			network := parseRange(address)
+			if network == nil {
+				continue
+			}
+
+			// The names of the affected pods, by the reason that the address is not intercepted
+			affected := make(map[string][]string)
+			for name, i := range pods {
+				if !exportAll && name.Namespace != r.Metadata.FullName.Namespace {
+					continue
+				}
+				if reason := i.check(network); reason != "" {
+					affected[reason] = append(affected[reason], name.String())
+				}
+			}
+
+			for reason, names := range affected {
+				c.Report(collections.IstioNetworkingV1Alpha3Serviceentries.Name(),
+					msg.NewServiceEntryAddressNotIntercepted(r, address, reason, describePods(names)))
+			}
+		}
+		return true
+	})
+}
+
+// podInterception returns the outbound interception of an injected pod. Pods without a sidecar are ignored.
+func podInterception(pod *v1.Pod) (interception, bool) {
+	var i interception
+
+	injected := false
+	for _, container := range pod.Spec.Containers {
+		if container.Name == istioProxyName {
+			injected = true
+		}
+	}
+	if !injected {
+		return i, false
+	}
+
+	include, ok := pod.GetAnnotations()[annotation.SidecarTrafficIncludeOutboundIPRanges.Name]
+	if !ok || strings.TrimSpace(include) == "*" {
+		i.includeAll = true
+	} else {
+		i.include = parseRanges(include)
+	}
+	i.exclude = parseRanges(pod.GetAnnotations()[annotation.SidecarTrafficExcludeOutboundIPRanges.Name])
+	return i, true
+}
+
+// check returns why traffic to the given network is not intercepted, or the empty string if it is.
+func (i interception) check(network *net.IPNet) string {
+	for _, e := range i.exclude {
+		if overlaps(e, network) {
+			return fmt.Sprintf("excluded from interception by excludeOutboundIPRanges %s", e)
+		}
+	}
+	if i.includeAll {
+		return ""
+	}
+	for _, in := range i.include {
+		if contains(in, network) {
+			return ""
+		}
+	}
+	return "not included in includeOutboundIPRanges"
+}
+
+// parseRange parses an IP address or CIDR range. Invalid ranges are ignored, as they are reported by validation.
+func parseRange(s string) *net.IPNet {
+	s = strings.TrimSpace(s)
+	if !strings.Contains(s, "/") {
+		ip := net.ParseIP(s)
+		if ip == nil {
+			return nil
+		}
+		bits := 8 * net.IPv4len
+		if ip.To4() == nil {
+			bits = 8 * net.IPv6len
+		}
+		s = fmt.Sprintf("%s/%d", s, bits)
+	}
+	_, network, err := net.ParseCIDR(s)
+	if err != nil {
+		return nil
+	}
+	return network
+}
+
+func parseRanges(s string) []*net.IPNet {
+	var result []*net.IPNet
+	for _, r := range strings.Split(s, ",") {
+		if network := parseRange(r); network != nil {
+			result = append(result, network)
+		}
+	}
+	return result
+}
+
+// contains returns whether the network a contains all of network b.
+func contains(a, b *net.IPNet) bool {
+	aOnes, aBits := a.Mask.Size()
+	bOnes, bBits := b.Mask.Size()
+	return aBits == bBits && aOnes <= bOnes && a.Contains(b.IP)
+}
+
+// overlaps returns whether the networks a and b have any address in common.
+func overlaps(a, b *net.IPNet) bool {
+	return a.Contains(b.IP) || b.Contains(a.IP)
+}
+
+func describePods(names []string) string {
+	sort.Strings(names)
+	if len(names) == 1 {
+		return "pod " + names[0]
+	}
+	if len(names) <= maxPodsInMessage {
+		return "pods " + strings.Join(names, ", ")
+	}
+	return fmt.Sprintf("pods %s and %d more", strings.Join(names[:maxPodsInMessage], ", "), len(names)-maxPodsInMessage)
+}
@@ -0,0 +1,235 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package serviceentry
+
+import (
+	"net"
+	"sort"
+	"strings"
+
+	"istio.io/api/networking/v1alpha3"
+	v1 "k8s.io/api/core/v1"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/util"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+// OverlapAnalyzer checks that the hosts and addresses of service entries do not overlap with Kubernetes services or
+// with other service entries visible in the same namespace. Which of the overlapping definitions is used for the
+// traffic depends on the order the registries are merged in, so routing is nondeterministic, especially if the
+// service entries use different resolutions or locations.
+//
+// Wildcard hosts are not considered to overlap with the hosts they match, as the most specific host is used.
+type OverlapAnalyzer struct{}
+
+var _ analysis.Analyzer = &OverlapAnalyzer{}
+
+type serviceEntry struct {
+	r         *resource.Instance
+	se        *v1alpha3.ServiceEntry
+	addresses []*net.IPNet
+
+	// Namespaces the service entry is visible in, unless it is exported to all namespaces
+	all        bool
+	namespaces map[string]bool
+}
+
+type clusterIP struct {
+	r       *resource.Instance
+	address net.IP
+}
+
+// Metadata implements Analyzer
+func (a *OverlapAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:        "serviceentry.OverlapAnalyzer",
+		Description: "Checks that service entries do not overlap with services or other service entries",
+		Inputs: collection.Names{
+			collections.IstioNetworkingV1Alpha3Serviceentries.Name(),
+			collections.K8SCoreV1Services.Name(),
+		},
+	}
+}
+
+// Analyze implements Analyzer
+func (a *OverlapAnalyzer) Analyze(c analysis.Context) {
+	var clusterIPs []clusterIP
+	c.ForEach(collections.K8SCoreV1Services.Name(), func(r *resource.Instance) bool {
+		if ip := net.ParseIP(r.Message.(*v1.ServiceSpec).ClusterIP); ip != nil {
+			clusterIPs = append(clusterIPs, clusterIP{r: r, address: ip})
+		}
+		return true
+	})
+
+	var entries []*serviceEntry
+	c.ForEach(collections.IstioNetworkingV1Alpha3Serviceentries.Name(), func(r *resource.Instance) bool {
+		entries = append(entries, newServiceEntry(r))
+		return true
+	})
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].r.Metadata.FullName.String() < entries[j].r.Metadata.FullName.String()
+	})
+
+	for _, e := range entries {
+		a.analyzeServices(c, e, clusterIPs)
+	}
+
+	for i, e := range entries {
+		for _, other := range entries[i+1:] {
+			if !e.visibleWith(other) {
+				continue
+			}
+			if h, ok := overlappingHost(e.se, other.se); ok {
+				report(c, e, "host", h, other)
+				report(c, other, "host", h, e)
+				continue
+			}
+			if address, otherAddress, ok := overlappingAddress(e.addresses, other.addresses); ok {
+				report(c, e, "address", address, other)
+				report(c, other, "address", otherAddress, e)
+			}
+		}
+	}
+}
+
+func (a *OverlapAnalyzer) analyzeServices(c analysis.Context, e *serviceEntry, clusterIPs []clusterIP) {
+	for _, h := range e.se.GetHosts() {
+		svc := util.GetFullNameFromFQDN(h)
+		if svc.Name == "" {
+			continue
+		}
+		if r := c.Find(collections.K8SCoreV1Services.Name(), svc); r != nil {
+			c.Report(collections.IstioNetworkingV1Alpha3Serviceentries.Name(),
+				msg.NewOverlappingServiceEntry(e.r, "host", h, r.Origin.FriendlyName(), ""))
+		}
+	}
+
+	for _, address := range e.addresses {
+		for _, ip := range clusterIPs {
+			if address.Contains(ip.address) {
+				c.Report(collections.IstioNetworkingV1Alpha3Serviceentries.Name(),
+					msg.NewOverlappingServiceEntry(e.r, "address", address.String(), ip.r.Origin.FriendlyName(), ""))
+			}
+		}
+	}
+}
+
+func newServiceEntry(r *resource.Instance) *serviceEntry {
+	se := r.Message.(*v1alpha3.ServiceEntry)
+	e := &serviceEntry{
+		r:   r,
+		se:  se,
+		all: util.IsExportToAllNamespaces(se.GetExportTo()),
+	}
+
+	if !e.all {
+		e.namespaces = make(map[string]bool)
+		for _, ns := range se.GetExportTo() {
+			if ns == util.ExportToNamespaceLocal {
+				ns = r.Metadata.FullName.Namespace.String()
+			}
+			e.namespaces[ns] = true
+		}
+	}
+
+	for _, address := range se.GetAddresses() {
+		if n := parseAddress(address); n != nil {
+			e.addresses = append(e.addresses, n)
+		}
+	}
+
+	return e
+}
+
+// visibleWith returns whether both service entries are visible in a common namespace.
+func (e *serviceEntry) visibleWith(other *serviceEntry) bool {
+	if e.all || other.all {
+		return true
+	}
+	for ns := range e.namespaces {
+		if other.namespaces[ns] {
+			return true
+		}
+	}
+	return false
+}
+
+func report(c analysis.Context, e *serviceEntry, field, value string, other *serviceEntry) {
+	c.Report(collections.IstioNetworkingV1Alpha3Serviceentries.Name(),
+		msg.NewOverlappingServiceEntry(e.r, field, value, other.r.Origin.FriendlyName(), difference(e.se, other.se)))
+}
+
+// difference describes the settings of the other service entry that differ from those of the service entry.
+func difference(se, other *v1alpha3.ServiceEntry) string {
+	var settings []string
+	if se.GetResolution() != other.GetResolution() {
+		settings = append(settings, "resolution "+other.GetResolution().String())
+	}
+	if se.GetLocation() != other.GetLocation() {
+		settings = append(settings, "location "+other.GetLocation().String())
+	}
+	if len(settings) == 0 {
+		return ""
+	}
+	return ", which uses " + strings.Join(settings, " and ")
+}
+
+func overlappingHost(a, b *v1alpha3.ServiceEntry) (string, bool) {
+	for _, h := range a.GetHosts() {
+		for _, other := range b.GetHosts() {
+			if h == other {
+				return h, true
+			}
+		}
+	}
+	return "", false
+}
+
+// overlappingAddress returns the first pair of overlapping addresses, one of each list.
+func overlappingAddress(a, b []*net.IPNet) (string, string, bool) {
+	for _, n := range a {
+		for _, other := range b {
+			if n.Contains(other.IP) || other.Contains(n.IP) {
+				return n.String(), other.String(), true
+			}
+		}
+	}
+	return "", "", false
+}
+
+// parseAddress parses an IP address or CIDR block of a service entry, and returns nil if it is invalid.
+func parseAddress(address string) *net.IPNet {
+	if strings.Contains(address, "/") {
+		_, n, err := net.ParseCIDR(address)
+		if err != nil {
+			return nil
+		}
+		return n
+	}
+
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return nil
+	}
+	bits := 128
+	if ip4 := ip.To4(); ip4 != nil {
+		ip, bits = ip4, 32
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}
+}
@@ -0,0 +1,99 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sidecar
+
+import (
+	"strings"
+
+	"istio.io/api/networking/v1alpha3"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/util"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+// EgressHostAnalyzer checks that the egress hosts of Sidecars, of the form namespace/host, refer to namespaces and
+// Kubernetes services that exist. A Sidecar with egress hosts limits the services its workloads can reach to those
+// hosts, so a misspelled host silently cuts off traffic.
+//
+// Namespaces are only checked if the analyzed configuration contains any Namespace resources, so that analyzing
+// files without a cluster does not report every namespace. Hosts other than Kubernetes services are not checked here.
+type EgressHostAnalyzer struct{}
+
+var _ analysis.Analyzer = &EgressHostAnalyzer{}
+
+// Metadata implements Analyzer
+func (a *EgressHostAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:        "sidecar.EgressHostAnalyzer",
+		Description: "Checks that the egress hosts of sidecars refer to existing namespaces and services",
+		Inputs: collection.Names{
+			collections.IstioNetworkingV1Alpha3Serviceentries.Name(),
+			collections.IstioNetworkingV1Alpha3Sidecars.Name(),
+			collections.K8SCoreV1Namespaces.Name(),
+			collections.K8SCoreV1Services.Name(),
+		},
+	}
+}
+
+// Analyze implements Analyzer
+func (a *EgressHostAnalyzer) Analyze(c analysis.Context) {
+	namespaces := make(map[resource.Namespace]bool)
+	c.ForEach(collections.K8SCoreV1Namespaces.Name(), func(r *resource.Instance) bool {
+		namespaces[resource.Namespace(r.Metadata.FullName.Name)] = true
+		return true
+	})
+
+	serviceEntryHosts := make(map[string]bool)
+	c.ForEach(collections.IstioNetworkingV1Alpha3Serviceentries.Name(), func(r *resource.Instance) bool {
+		for _, h := range r.Message.(*v1alpha3.ServiceEntry).GetHosts() {
+			serviceEntryHosts[h] = true
+		}
+		return true
+	})
+
+	c.ForEach(collections.IstioNetworkingV1Alpha3Sidecars.Name(), func(r *resource.Instance) bool {
+		s := r.Message.(*v1alpha3.Sidecar)
+		for _, e := range s.GetEgress() {
+			for _, h := range e.GetHosts() {
+				parts := strings.SplitN(h, "/", 2)
+				if len(parts) != 2 {
+					// Rejected by validation
+					continue
+				}
+				ns, host := parts[0], parts[1]
+
+				if len(namespaces) > 0 && ns != "*" && ns != "~" && ns != "." && !namespaces[resource.Namespace(ns)] {
+					c.Report(collections.IstioNetworkingV1Alpha3Sidecars.Name(),
+						msg.NewReferencedResourceNotFound(r, "egress namespace", ns))
+					continue
+				}
+
+				if strings.HasPrefix(host, "*") || serviceEntryHosts[host] {
+					continue
+				}
+				svc := util.GetFullNameFromFQDN(host)
+				if svc.Name != "" && !c.Exists(collections.K8SCoreV1Services.Name(), svc) {
+					c.Report(collections.IstioNetworkingV1Alpha3Sidecars.Name(),
+						msg.NewReferencedResourceNotFound(r, "egress host", h))
+				}
+			}
+		}
+		return true
+	})
+}
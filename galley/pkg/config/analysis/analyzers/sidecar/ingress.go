@@ -0,0 +1,174 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sidecar
+
+import (
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+
+	"istio.io/api/networking/v1alpha3"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/util"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	configKube "istio.io/istio/pkg/config/kube"
+	"istio.io/istio/pkg/config/protocol"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+// IngressPortAnalyzer checks the ingress listeners of sidecars against the services of the workloads they select:
+// every port should be targeted by a service with a compatible protocol, and no port may be listened on twice.
+type IngressPortAnalyzer struct{}
+
+var _ analysis.Analyzer = &IngressPortAnalyzer{}
+
+// servicePort is a port of a service, targeting a port of a workload.
+type servicePort struct {
+	service  *resource.Instance
+	port     v1.ServicePort
+	protocol protocol.Instance
+}
+
+// Metadata implements Analyzer
+func (a *IngressPortAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:        "sidecar.IngressPortAnalyzer",
+		Description: "Checks that the ingress listeners of sidecars match the ports and protocols of services",
+		Inputs: collection.Names{
+			collections.IstioNetworkingV1Alpha3Sidecars.Name(),
+			collections.K8SCoreV1Pods.Name(),
+			collections.K8SCoreV1Services.Name(),
+		},
+	}
+}
+
+// Analyze implements Analyzer
+func (a *IngressPortAnalyzer) Analyze(c analysis.Context) {
+	services := util.BuildServiceSelectorIndex(c)
+
+	c.ForEach(collections.IstioNetworkingV1Alpha3Sidecars.Name(), func(r *resource.Instance) bool {
+		s := r.Message.(*v1alpha3.Sidecar)
+		if len(s.Ingress) == 0 {
+			return true
+		}
+
+		// Without a workload selector, the sidecar applies to the workloads of its namespace
+		matched := util.SelectPods(c, r.Metadata.FullName.Namespace, s.GetWorkloadSelector().GetLabels())
+		if len(matched) == 0 {
+			// Reported by SelectorAnalyzer
+			return true
+		}
+		targeted := targetedPorts(matched, services)
+
+		seen := make(map[uint32]bool)
+		for _, l := range s.Ingress {
+			number := l.GetPort().GetNumber()
+			if number == 0 {
+				continue
+			}
+			if seen[number] {
+				c.Report(collections.IstioNetworkingV1Alpha3Sidecars.Name(), msg.NewSidecarIngressDuplicatePort(r, int(number)))
+				continue
+			}
+			seen[number] = true
+
+			sps, ok := targeted[number]
+			if !ok {
+				c.Report(collections.IstioNetworkingV1Alpha3Sidecars.Name(), msg.NewSidecarIngressPortNotExposed(r, int(number)))
+				continue
+			}
+			p := protocol.Parse(l.Port.Protocol)
+			for _, sp := range sps {
+				if !compatible(p, sp.protocol) {
+					c.Report(collections.IstioNetworkingV1Alpha3Sidecars.Name(), msg.NewSidecarIngressProtocolMismatch(r,
+						int(number), l.Port.Protocol, portName(sp.port), sp.service.Metadata.FullName.String(),
+						string(sp.protocol)))
+				}
+			}
+		}
+		return true
+	})
+}
+
+// targetedPorts returns the ports of services that target the given pods, by the number of the pod port. Named target
+// ports are resolved against the container ports of each pod.
+func targetedPorts(pods []*resource.Instance, services *util.SelectorIndex) map[uint32][]servicePort {
+	result := make(map[uint32][]servicePort)
+	seen := make(map[string]bool)
+	for _, r := range pods {
+		pod := r.Message.(*v1.Pod)
+		for _, svc := range services.Matches(r.Metadata.Labels) {
+			if svc.Metadata.FullName.Namespace != r.Metadata.FullName.Namespace {
+				continue
+			}
+			for _, port := range svc.Message.(*v1.ServiceSpec).Ports {
+				// UDP isn't intercepted by the sidecar
+				if port.Protocol == v1.ProtocolUDP {
+					continue
+				}
+				number := podPort(pod, port)
+				if number == 0 {
+					continue
+				}
+				key := fmt.Sprintf("%s/%d/%d", svc.Metadata.FullName, port.Port, number)
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+				result[number] = append(result[number], servicePort{
+					service:  svc,
+					port:     port,
+					protocol: configKube.ConvertProtocol(port.Port, port.Name, port.Protocol, port.AppProtocol),
+				})
+			}
+		}
+	}
+	return result
+}
+
+// podPort returns the number of the pod port that a service port targets, or 0 if the pod has no port of the
+// targeted name. The target port defaults to the service port.
+func podPort(pod *v1.Pod, port v1.ServicePort) uint32 {
+	if port.TargetPort.StrVal == "" {
+		if port.TargetPort.IntVal != 0 {
+			return uint32(port.TargetPort.IntVal)
+		}
+		return uint32(port.Port)
+	}
+	for _, c := range pod.Spec.Containers {
+		for _, cp := range c.Ports {
+			if cp.Name == port.TargetPort.StrVal {
+				return uint32(cp.ContainerPort)
+			}
+		}
+	}
+	return 0
+}
+
+// compatible returns true if the protocol of an ingress listener can serve a service port. Ports of services that
+// don't declare a protocol are detected by the sidecar, and the HTTP protocols can serve each other.
+func compatible(listener, service protocol.Instance) bool {
+	return service.IsUnsupported() || listener == service || (listener.IsHTTP() && service.IsHTTP())
+}
+
+func portName(port v1.ServicePort) string {
+	if port.Name == "" {
+		return fmt.Sprintf("%d", port.Port)
+	}
+	return fmt.Sprintf("%s (%d)", port.Name, port.Port)
+}
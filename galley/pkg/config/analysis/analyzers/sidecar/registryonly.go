@@ -0,0 +1,183 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sidecar
+
+import (
+	"strings"
+
+	"istio.io/api/mesh/v1alpha1"
+	"istio.io/api/networking/v1alpha3"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/util"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+// RegistryOnlyAnalyzer checks, for workloads whose outbound traffic policy is REGISTRY_ONLY, that the external hosts
+// referenced in their configuration are in the service registry. Traffic to hosts outside of the registry is
+// blackholed under REGISTRY_ONLY, so these references predict broken traffic. The referencing configuration is:
+// * the hosts of VirtualServices applying to sidecars, for workloads in the VirtualService's namespace
+// * the egress hosts of Sidecars
+//
+// Kubernetes service hosts are not checked here, as VirtualService destinations and missing services are covered by
+// other analyzers. JWKS URIs are fetched by the control plane rather than the workloads, so they are not affected.
+type RegistryOnlyAnalyzer struct{}
+
+var _ analysis.Analyzer = &RegistryOnlyAnalyzer{}
+
+// Metadata implements Analyzer
+func (a *RegistryOnlyAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:        "sidecar.RegistryOnlyAnalyzer",
+		Description: "Checks that external hosts referenced by workloads with a REGISTRY_ONLY outbound traffic policy are in the registry",
+		Inputs: collection.Names{
+			collections.IstioMeshV1Alpha1MeshConfig.Name(),
+			collections.IstioNetworkingV1Alpha3Serviceentries.Name(),
+			collections.IstioNetworkingV1Alpha3Sidecars.Name(),
+			collections.IstioNetworkingV1Alpha3Virtualservices.Name(),
+		},
+	}
+}
+
+// Analyze implements Analyzer
+func (a *RegistryOnlyAnalyzer) Analyze(c analysis.Context) {
+	mc := analysis.MeshConfig(c)
+	meshRegistryOnly := mc.GetOutboundTrafficPolicy().GetMode() == v1alpha1.MeshConfig_OutboundTrafficPolicy_REGISTRY_ONLY
+	rootNamespace := resource.Namespace(mc.GetRootNamespace())
+
+	// Namespace wide Sidecars determine the policy of the workloads without a more specific Sidecar
+	defaultSidecars := make(map[resource.Namespace]*v1alpha3.Sidecar)
+	c.ForEach(collections.IstioNetworkingV1Alpha3Sidecars.Name(), func(r *resource.Instance) bool {
+		s := r.Message.(*v1alpha3.Sidecar)
+		if s.WorkloadSelector == nil {
+			defaultSidecars[r.Metadata.FullName.Namespace] = s
+		}
+		return true
+	})
+	registryOnly := func(s *v1alpha3.Sidecar) bool {
+		if p := s.GetOutboundTrafficPolicy(); p != nil {
+			return p.GetMode() == v1alpha3.OutboundTrafficPolicy_REGISTRY_ONLY
+		}
+		return meshRegistryOnly
+	}
+	namespaceRegistryOnly := func(ns resource.Namespace) bool {
+		if s, ok := defaultSidecars[ns]; ok {
+			return registryOnly(s)
+		}
+		if s, ok := defaultSidecars[rootNamespace]; ok {
+			return registryOnly(s)
+		}
+		return meshRegistryOnly
+	}
+
+	registry := newExternalRegistry(c)
+
+	c.ForEach(collections.IstioNetworkingV1Alpha3Sidecars.Name(), func(r *resource.Instance) bool {
+		s := r.Message.(*v1alpha3.Sidecar)
+		ns := r.Metadata.FullName.Namespace
+		if !registryOnly(s) {
+			return true
+		}
+		for _, e := range s.GetEgress() {
+			for _, h := range e.GetHosts() {
+				parts := strings.SplitN(h, "/", 2)
+				if len(parts) != 2 || parts[0] == "~" {
+					continue
+				}
+				if !registry.covers(ns, parts[1]) {
+					c.Report(collections.IstioNetworkingV1Alpha3Sidecars.Name(),
+						msg.NewUnregisteredExternalHost(r, parts[1], ns.String()))
+				}
+			}
+		}
+		return true
+	})
+
+	c.ForEach(collections.IstioNetworkingV1Alpha3Virtualservices.Name(), func(r *resource.Instance) bool {
+		vs := r.Message.(*v1alpha3.VirtualService)
+		ns := r.Metadata.FullName.Namespace
+		if !appliesToSidecars(vs) || !namespaceRegistryOnly(ns) {
+			return true
+		}
+		for _, h := range vs.GetHosts() {
+			if !registry.covers(ns, h) {
+				c.Report(collections.IstioNetworkingV1Alpha3Virtualservices.Name(),
+					msg.NewUnregisteredExternalHost(r, h, ns.String()))
+			}
+		}
+		return true
+	})
+}
+
+func appliesToSidecars(vs *v1alpha3.VirtualService) bool {
+	if len(vs.GetGateways()) == 0 {
+		return true
+	}
+	for _, g := range vs.GetGateways() {
+		if g == util.MeshGateway {
+			return true
+		}
+	}
+	return false
+}
+
+// externalRegistry holds the hosts of the ServiceEntries by the namespace they are visible in, or "*" for hosts
+// visible in all namespaces.
+type externalRegistry map[string][]string
+
+func newExternalRegistry(c analysis.Context) externalRegistry {
+	reg := make(externalRegistry)
+	c.ForEach(collections.IstioNetworkingV1Alpha3Serviceentries.Name(), func(r *resource.Instance) bool {
+		se := r.Message.(*v1alpha3.ServiceEntry)
+		scope := r.Metadata.FullName.Namespace.String()
+		if util.IsExportToAllNamespaces(se.GetExportTo()) {
+			scope = util.ExportToAllNamespaces
+		}
+		reg[scope] = append(reg[scope], se.GetHosts()...)
+		return true
+	})
+	return reg
+}
+
+// covers returns whether the given host is either not external, i.e. a Kubernetes service host or a wildcard
+// selecting hosts of any kind, or matched by a ServiceEntry visible in the given namespace.
+func (reg externalRegistry) covers(ns resource.Namespace, host string) bool {
+	if host == util.Wildcard || !strings.Contains(host, ".") {
+		return true
+	}
+	fqdn := util.ConvertHostToFQDN(ns, host)
+	if strings.HasSuffix(fqdn, "."+util.DefaultKubernetesDomain) {
+		return true
+	}
+
+	for _, scope := range []string{ns.String(), util.ExportToAllNamespaces} {
+		for _, seHost := range reg[scope] {
+			if matchesRegistryHost(seHost, fqdn) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func matchesRegistryHost(seHost, host string) bool {
+	if strings.HasPrefix(seHost, util.Wildcard) {
+		return strings.HasSuffix(strings.TrimPrefix(host, util.Wildcard), strings.TrimPrefix(seHost, util.Wildcard))
+	}
+	return seHost == host
+}
@@ -14,12 +14,12 @@
 package sidecar
 
 import (
-	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/labels"
 
 	"istio.io/api/networking/v1alpha3"
 
 	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/util"
 	"istio.io/istio/galley/pkg/config/analysis/msg"
 	"istio.io/istio/pkg/config/resource"
 	"istio.io/istio/pkg/config/schema/collection"
@@ -50,9 +50,6 @@ func (a *SelectorAnalyzer) Metadata() analysis.Metadata {
 func (a *SelectorAnalyzer) Analyze(c analysis.Context) {
 	podsToSidecars := make(map[resource.FullName][]*resource.Instance)
 
-	// This is using an unindexed approach for matching selectors.
-	// Using an index for selectoes is problematic because selector != label
-	// We can match a label to a selector, but we can't generate a selector from a label.
 	c.ForEach(collections.IstioNetworkingV1Alpha3Sidecars.Name(), func(rs *resource.Instance) bool {
 		s := rs.Message.(*v1alpha3.Sidecar)
 
@@ -61,29 +58,14 @@ func (a *SelectorAnalyzer) Analyze(c analysis.Context) {
 			return true
 		}
 
-		sNs := rs.Metadata.FullName.Namespace
-		sel := labels.SelectorFromSet(s.WorkloadSelector.Labels)
-
-		foundPod := false
-		c.ForEach(collections.K8SCoreV1Pods.Name(), func(rp *resource.Instance) bool {
-			pod := rp.Message.(*v1.Pod)
-			pNs := rp.Metadata.FullName.Namespace
-			podLabels := labels.Set(pod.ObjectMeta.Labels)
-
-			// Only attempt to match in the same namespace
-			if pNs != sNs {
-				return true
-			}
-
-			if sel.Matches(podLabels) {
-				foundPod = true
-				podsToSidecars[rp.Metadata.FullName] = append(podsToSidecars[rp.Metadata.FullName], rs)
-			}
-
-			return true
-		})
+		// Only attempt to match in the same namespace
+		matched := util.SelectPods(c, rs.Metadata.FullName.Namespace, s.WorkloadSelector.Labels)
+		for _, rp := range matched {
+			podsToSidecars[rp.Metadata.FullName] = append(podsToSidecars[rp.Metadata.FullName], rs)
+		}
 
-		if !foundPod {
+		if len(matched) == 0 {
+			sel := labels.SelectorFromSet(s.WorkloadSelector.Labels)
 			c.Report(collections.IstioNetworkingV1Alpha3Sidecars.Name(), msg.NewReferencedResourceNotFound(rs, "selector", sel.String()))
 		}
 
@@ -13,12 +13,18 @@
 // limitations under the License.
 package sidecar
 
-import "istio.io/istio/pkg/config/resource"
+import (
+	"sort"
 
+	"istio.io/istio/pkg/config/resource"
+)
+
+// getNames returns the sorted names of the entries, so that messages do not depend on collection iteration order.
 func getNames(entries []*resource.Instance) []string {
 	names := make([]string, 0, len(entries))
 	for _, rs := range entries {
 		names = append(names, string(rs.Metadata.FullName.Name))
 	}
+	sort.Strings(names)
 	return names
 }
@@ -0,0 +1,176 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package unreferenced
+
+import (
+	"strings"
+
+	"istio.io/api/networking/v1alpha3"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/util"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+// Analyzer reports dangling configuration that has no effect, as a hint to clean it up:
+// * gateways that no virtual service is bound to
+// * destination rule subsets that no virtual service routes to
+// * destination rules for hosts that are neither services, nor declared by service entries, nor routed to
+// Virtual services bound to gateways that do not exist are reported by virtualservice.GatewayAnalyzer.
+type Analyzer struct{}
+
+var _ analysis.Analyzer = &Analyzer{}
+
+// Metadata implements Analyzer
+func (a *Analyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:        "unreferenced.Analyzer",
+		Description: "Checks for gateways, destination rules and subsets that are not referenced by anything",
+		Inputs: collection.Names{
+			collections.IstioNetworkingV1Alpha3Destinationrules.Name(),
+			collections.IstioNetworkingV1Alpha3Gateways.Name(),
+			collections.IstioNetworkingV1Alpha3Serviceentries.Name(),
+			collections.IstioNetworkingV1Alpha3Virtualservices.Name(),
+			collections.K8SCoreV1Services.Name(),
+		},
+	}
+}
+
+// Analyze implements Analyzer
+func (a *Analyzer) Analyze(c analysis.Context) {
+	gateways := make(map[resource.FullName]bool)
+	routedHosts := make(map[string]bool)
+	routedSubsets := make(map[string]bool)
+
+	c.ForEach(collections.IstioNetworkingV1Alpha3Virtualservices.Name(), func(r *resource.Instance) bool {
+		vs := r.Message.(*v1alpha3.VirtualService)
+		ns := r.Metadata.FullName.Namespace
+
+		for _, gw := range boundGateways(vs) {
+			if gw != util.MeshGateway {
+				gateways[resource.NewShortOrFullName(ns, gw)] = true
+			}
+		}
+		for _, d := range routeDestinations(vs) {
+			host := util.ConvertHostToFQDN(ns, d.GetHost())
+			routedHosts[host] = true
+			if d.GetSubset() != "" {
+				routedSubsets[subsetKey(host, d.GetSubset())] = true
+			}
+		}
+		return true
+	})
+
+	c.ForEach(collections.IstioNetworkingV1Alpha3Gateways.Name(), func(r *resource.Instance) bool {
+		if !gateways[r.Metadata.FullName] && !autoPassthrough(r.Message.(*v1alpha3.Gateway)) {
+			c.Report(collections.IstioNetworkingV1Alpha3Gateways.Name(), msg.NewUnreferencedGateway(r))
+		}
+		return true
+	})
+
+	declaredHosts := make(map[string]bool)
+	c.ForEach(collections.IstioNetworkingV1Alpha3Serviceentries.Name(), func(r *resource.Instance) bool {
+		for _, h := range r.Message.(*v1alpha3.ServiceEntry).GetHosts() {
+			declaredHosts[util.ConvertHostToFQDN(r.Metadata.FullName.Namespace, h)] = true
+		}
+		return true
+	})
+
+	c.ForEach(collections.IstioNetworkingV1Alpha3Destinationrules.Name(), func(r *resource.Instance) bool {
+		dr := r.Message.(*v1alpha3.DestinationRule)
+		// Wildcard hosts apply to whatever hosts match them
+		if strings.Contains(dr.GetHost(), "*") {
+			return true
+		}
+		host := util.ConvertHostToFQDN(r.Metadata.FullName.Namespace, dr.GetHost())
+
+		if !routedHosts[host] && !declaredHosts[host] &&
+			!c.Exists(collections.K8SCoreV1Services.Name(), util.GetResourceNameFromHost(r.Metadata.FullName.Namespace, dr.GetHost())) {
+			c.Report(collections.IstioNetworkingV1Alpha3Destinationrules.Name(), msg.NewUnreferencedDestinationRule(r, dr.GetHost()))
+			// Its subsets are unused as well, so do not report them separately
+			return true
+		}
+
+		for _, s := range dr.GetSubsets() {
+			if !routedSubsets[subsetKey(host, s.GetName())] {
+				c.Report(collections.IstioNetworkingV1Alpha3Destinationrules.Name(), msg.NewUnusedSubset(r, s.GetName()))
+			}
+		}
+		return true
+	})
+}
+
+// boundGateways returns the gateways a virtual service and its routes are bound to.
+func boundGateways(vs *v1alpha3.VirtualService) []string {
+	gateways := append([]string(nil), vs.GetGateways()...)
+	for _, h := range vs.GetHttp() {
+		for _, m := range h.GetMatch() {
+			gateways = append(gateways, m.GetGateways()...)
+		}
+	}
+	for _, t := range vs.GetTls() {
+		for _, m := range t.GetMatch() {
+			gateways = append(gateways, m.GetGateways()...)
+		}
+	}
+	for _, t := range vs.GetTcp() {
+		for _, m := range t.GetMatch() {
+			gateways = append(gateways, m.GetGateways()...)
+		}
+	}
+	return gateways
+}
+
+// routeDestinations returns the destinations that a virtual service routes or mirrors traffic to.
+func routeDestinations(vs *v1alpha3.VirtualService) []*v1alpha3.Destination {
+	var destinations []*v1alpha3.Destination
+	for _, h := range vs.GetHttp() {
+		for _, d := range h.GetRoute() {
+			destinations = append(destinations, d.GetDestination())
+		}
+		if m := h.GetMirror(); m != nil {
+			destinations = append(destinations, m)
+		}
+	}
+	for _, t := range vs.GetTls() {
+		for _, d := range t.GetRoute() {
+			destinations = append(destinations, d.GetDestination())
+		}
+	}
+	for _, t := range vs.GetTcp() {
+		for _, d := range t.GetRoute() {
+			destinations = append(destinations, d.GetDestination())
+		}
+	}
+	return destinations
+}
+
+// autoPassthrough returns whether a gateway has an AUTO_PASSTHROUGH server, which routes by SNI without virtual
+// services.
+func autoPassthrough(gw *v1alpha3.Gateway) bool {
+	for _, s := range gw.GetServers() {
+		if s.GetTls().GetMode() == v1alpha3.ServerTLSSettings_AUTO_PASSTHROUGH {
+			return true
+		}
+	}
+	return false
+}
+
+func subsetKey(host, subset string) string {
+	return host + "/" + subset
+}
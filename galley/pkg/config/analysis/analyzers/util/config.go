@@ -15,27 +15,11 @@
 package util
 
 import (
-	"istio.io/api/mesh/v1alpha1"
+	"strings"
 
-	"istio.io/istio/galley/pkg/config/analysis"
 	"istio.io/istio/pkg/config/resource"
-	"istio.io/istio/pkg/config/schema/collections"
 )
 
-// MeshConfig returns the mesh configuration object associated with the context
-// Analyzers that call this should include metadata.IstioMeshV1Alpha1MeshConfig as an input in their Metadata
-func MeshConfig(ctx analysis.Context) *v1alpha1.MeshConfig {
-	// Only one MeshConfig should exist in practice, but getting it this way avoids needing
-	// to plumb through the name or enforce/expose a constant.
-	var mc *v1alpha1.MeshConfig
-	ctx.ForEach(collections.IstioMeshV1Alpha1MeshConfig.Name(), func(r *resource.Instance) bool {
-		mc = r.Message.(*v1alpha1.MeshConfig)
-		return true
-	})
-
-	return mc
-}
-
 // IsSystemNamespace returns true for system namespaces
 func IsSystemNamespace(ns resource.Namespace) bool {
 	return ns == "kube-system" || ns == "kube-public"
@@ -51,3 +35,14 @@ func IsIstioControlPlane(r *resource.Instance) bool {
 	}
 	return false
 }
+
+// ImageTag returns the tag of a container image reference, or the empty string if it has none.
+func ImageTag(image string) string {
+	if i := strings.Index(image, "@"); i >= 0 {
+		image = image[:i]
+	}
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		return image[i+1:]
+	}
+	return ""
+}
@@ -0,0 +1,33 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestImageTag(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	g.Expect(ImageTag("docker.io/istio/proxyv2:1.6.0")).To(Equal("1.6.0"))
+	g.Expect(ImageTag("localhost:5000/istio/proxyv2:1.6.0")).To(Equal("1.6.0"))
+	g.Expect(ImageTag("docker.io/istio/proxyv2:1.6.0@sha256:abcd")).To(Equal("1.6.0"))
+
+	// No tag
+	g.Expect(ImageTag("localhost:5000/istio/proxyv2")).To(Equal(""))
+	g.Expect(ImageTag("docker.io/istio/proxyv2@sha256:abcd")).To(Equal(""))
+}
@@ -0,0 +1,67 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"fmt"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/gogo/protobuf/proto"
+
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+	"istio.io/istio/pkg/util/gogoprotomarshal"
+)
+
+// WithSpecFix returns the message with a fix that changes the spec of its Istio resource as done by modify, which is
+// called on a copy of the spec. If the patch can't be computed, the message is returned without a fix.
+func WithSpecFix(m diag.Message, description string, modify func(spec proto.Message)) diag.Message {
+	if m.Resource == nil || m.Resource.Message == nil {
+		return m
+	}
+	patch, err := SpecPatch(m.Resource.Message, modify)
+	if err != nil {
+		return m
+	}
+	return m.WithFix(description, patch)
+}
+
+// SpecPatch returns the JSON merge patch of a resource that changes its spec as done by modify, which is called on a
+// copy of the spec. Fields are named as in the YAML of Istio resources.
+func SpecPatch(spec proto.Message, modify func(spec proto.Message)) (string, error) {
+	modified := proto.Clone(spec)
+	modify(modified)
+
+	original, err := specJSON(spec)
+	if err != nil {
+		return "", err
+	}
+	changed, err := specJSON(modified)
+	if err != nil {
+		return "", err
+	}
+	patch, err := jsonpatch.CreateMergePatch(original, changed)
+	if err != nil {
+		return "", err
+	}
+	return string(patch), nil
+}
+
+func specJSON(spec proto.Message) ([]byte, error) {
+	js, err := gogoprotomarshal.ToJSON(spec)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(fmt.Sprintf(`{"spec":%s}`, js)), nil
+}
@@ -0,0 +1,70 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"testing"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/types"
+	. "github.com/onsi/gomega"
+
+	"istio.io/api/networking/v1alpha3"
+
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+	"istio.io/istio/pkg/config/resource"
+)
+
+func TestSpecPatch(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	dr := &v1alpha3.DestinationRule{
+		Host: "reviews",
+		TrafficPolicy: &v1alpha3.TrafficPolicy{
+			OutlierDetection: &v1alpha3.OutlierDetection{ConsecutiveErrors: 5},
+		},
+	}
+
+	patch, err := SpecPatch(dr, func(spec proto.Message) {
+		od := spec.(*v1alpha3.DestinationRule).TrafficPolicy.OutlierDetection
+		od.ConsecutiveGatewayErrors = &types.UInt32Value{Value: od.ConsecutiveErrors}
+		od.ConsecutiveErrors = 0
+	})
+	g.Expect(err).To(BeNil())
+	g.Expect(patch).To(Equal(
+		`{"spec":{"trafficPolicy":{"outlierDetection":{"consecutiveErrors":null,"consecutiveGatewayErrors":5}}}}`))
+
+	// The original spec is not changed
+	g.Expect(dr.TrafficPolicy.OutlierDetection.ConsecutiveErrors).To(Equal(int32(5)))
+}
+
+func TestWithSpecFix(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	mt := diag.NewMessageType(diag.Warning, "IST-0042", "Short host: %s")
+	rename := func(spec proto.Message) {
+		spec.(*v1alpha3.DestinationRule).Host = "reviews.default.svc.cluster.local"
+	}
+
+	m := WithSpecFix(diag.NewMessage(mt, &resource.Instance{Message: &v1alpha3.DestinationRule{Host: "reviews"}}, "reviews"),
+		"Use the FQDN", rename)
+	g.Expect(m.Fix).To(Equal(&diag.Fix{
+		Description: "Use the FQDN",
+		Patch:       `{"spec":{"host":"reviews.default.svc.cluster.local"}}`,
+	}))
+
+	// Messages without a resource have no fix
+	g.Expect(WithSpecFix(diag.NewMessage(mt, nil, "reviews"), "Use the FQDN", rename).Fix).To(BeNil())
+}
@@ -0,0 +1,75 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"sort"
+
+	"k8s.io/apimachinery/pkg/labels"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+// SelectPods returns the pods in the given namespace whose labels match the selector, as WorkloadIndex.Select does
+// for the index of all pods. The result is memoized in the context, so analyzers selecting pods with the same selector,
+// e.g. the ones checking the same gateway, share it. The result must not be modified.
+// Analyzers that call this should include collections.K8SCoreV1Pods as an input in their Metadata.
+func SelectPods(ctx analysis.Context, ns resource.Namespace, selector map[string]string) []*resource.Instance {
+	key := "util.SelectPods/" + ns.String() + "/" + labels.SelectorFromSet(selector).String()
+	return analysis.Index(ctx, key, func() interface{} {
+		return BuildWorkloadIndex(ctx, collections.K8SCoreV1Pods.Name()).Select(ns, selector)
+	}).([]*resource.Instance)
+}
+
+// SecretIndex indexes secrets by name, to find the namespaces that have a secret of a given name without scanning
+// every secret.
+type SecretIndex struct {
+	byName map[resource.LocalName][]resource.Namespace
+}
+
+// BuildSecretIndex returns a SecretIndex of all secrets. The index is shared with other analyzers using the same
+// context, and must not be modified.
+// Analyzers that call this should include collections.K8SCoreV1Secrets as an input in their Metadata.
+func BuildSecretIndex(ctx analysis.Context) *SecretIndex {
+	return analysis.Index(ctx, "util.SecretIndex", func() interface{} {
+		idx := &SecretIndex{byName: make(map[resource.LocalName][]resource.Namespace)}
+		ctx.ForEach(collections.K8SCoreV1Secrets.Name(), func(r *resource.Instance) bool {
+			name := r.Metadata.FullName
+			idx.byName[name.Name] = append(idx.byName[name.Name], name.Namespace)
+			return true
+		})
+		for _, namespaces := range idx.byName {
+			sort.Slice(namespaces, func(i, j int) bool { return namespaces[i] < namespaces[j] })
+		}
+		return idx
+	}).(*SecretIndex)
+}
+
+// Namespaces returns the sorted namespaces that have a secret with the given name.
+func (s *SecretIndex) Namespaces(name resource.LocalName) []resource.Namespace {
+	return s.byName[name]
+}
+
+// Exists returns whether the secret with the given name exists.
+func (s *SecretIndex) Exists(name resource.FullName) bool {
+	for _, ns := range s.byName[name.Name] {
+		if ns == name.Namespace {
+			return true
+		}
+	}
+	return false
+}
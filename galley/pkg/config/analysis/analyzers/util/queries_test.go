@@ -0,0 +1,96 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/testing/fixtures"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+)
+
+// countingContext counts the iterations over the collections of the snapshot.
+type countingContext struct {
+	indexingContext
+	iterations int
+}
+
+// ForEach implements analysis.Context
+func (c *countingContext) ForEach(col collection.Name, fn analysis.IteratorFn) {
+	c.iterations++
+	c.indexingContext.ForEach(col, fn)
+}
+
+func newCountingContext(resources ...*resource.Instance) *countingContext {
+	return &countingContext{
+		indexingContext: indexingContext{
+			Context: fixtures.Context{Resources: resources},
+			indexes: make(map[string]interface{}),
+		},
+	}
+}
+
+func TestSelectPods(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	ctx := newCountingContext(
+		newWorkload("ns1", "a", map[string]string{"app": "a"}),
+		newWorkload("ns1", "b", map[string]string{"app": "b"}),
+		newWorkload("ns2", "a", map[string]string{"app": "a"}))
+
+	g.Expect(names(SelectPods(ctx, "", map[string]string{"app": "a"}))).To(Equal([]string{"ns1/a", "ns2/a"}))
+	g.Expect(names(SelectPods(ctx, "ns1", map[string]string{"app": "a"}))).To(Equal([]string{"ns1/a"}))
+	g.Expect(names(SelectPods(ctx, "ns1", nil))).To(Equal([]string{"ns1/a", "ns1/b"}))
+	g.Expect(SelectPods(ctx, "ns1", map[string]string{"app": "c"})).To(BeEmpty())
+
+	// The pods are iterated once, and selections are memoized
+	g.Expect(ctx.iterations).To(Equal(1))
+	g.Expect(ctx.indexes).To(HaveKey("util.SelectPods/ns1/app=a"))
+	g.Expect(names(SelectPods(ctx, "ns1", map[string]string{"app": "a"}))).To(Equal([]string{"ns1/a"}))
+	g.Expect(ctx.iterations).To(Equal(1))
+}
+
+func TestSelectPodsWithoutIndexes(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	// Without support for shared indexes, the pods are iterated on every selection
+	ctx := &fixtures.Context{Resources: []*resource.Instance{newWorkload("ns1", "a", map[string]string{"app": "a"})}}
+	g.Expect(names(SelectPods(ctx, "", map[string]string{"app": "a"}))).To(Equal([]string{"ns1/a"}))
+	g.Expect(names(SelectPods(ctx, "", map[string]string{"app": "a"}))).To(Equal([]string{"ns1/a"}))
+}
+
+func TestSecretIndex(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	ctx := newCountingContext(
+		newWorkload("ns2", "cert", nil),
+		newWorkload("ns1", "cert", nil),
+		newWorkload("ns1", "key", nil))
+
+	secrets := BuildSecretIndex(ctx)
+	g.Expect(secrets.Namespaces("cert")).To(Equal([]resource.Namespace{"ns1", "ns2"}))
+	g.Expect(secrets.Namespaces("key")).To(Equal([]resource.Namespace{"ns1"}))
+	g.Expect(secrets.Namespaces("missing")).To(BeEmpty())
+	g.Expect(secrets.Exists(resource.NewFullName("ns2", "cert"))).To(BeTrue())
+	g.Expect(secrets.Exists(resource.NewFullName("ns2", "key"))).To(BeFalse())
+
+	// The secrets are iterated once, and the index is shared
+	g.Expect(BuildSecretIndex(ctx)).To(BeIdenticalTo(secrets))
+	g.Expect(ctx.iterations).To(Equal(1))
+}
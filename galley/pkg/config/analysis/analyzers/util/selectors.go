@@ -0,0 +1,101 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+// SelectorIndex indexes resources that select workloads by label (e.g. services), so that the resources selecting a
+// set of workload labels can be found without checking every selector. It is the inverse of WorkloadIndex.
+type SelectorIndex struct {
+	selectors map[resource.FullName]map[string]string
+	resources map[resource.FullName]*resource.Instance
+	// Each non-empty selector is indexed by one of its labels, which every matching workload must have
+	byLabel map[string]nameSet
+	// Resources with empty selectors, which match every workload
+	everything nameSet
+}
+
+// NewSelectorIndex returns a new, empty SelectorIndex.
+func NewSelectorIndex() *SelectorIndex {
+	return &SelectorIndex{
+		selectors:  make(map[resource.FullName]map[string]string),
+		resources:  make(map[resource.FullName]*resource.Instance),
+		byLabel:    make(map[string]nameSet),
+		everything: make(nameSet),
+	}
+}
+
+// BuildServiceSelectorIndex returns a SelectorIndex of the selectors of all services. Analyzers that call this should
+// include the services collection as an input in their Metadata. The index is shared with other analyzers using the
+// same context, and must not be modified.
+func BuildServiceSelectorIndex(ctx analysis.Context) *SelectorIndex {
+	return analysis.Index(ctx, "util.ServiceSelectorIndex", func() interface{} {
+		idx := NewSelectorIndex()
+		ctx.ForEach(collections.K8SCoreV1Services.Name(), func(r *resource.Instance) bool {
+			idx.Add(r, r.Message.(*v1.ServiceSpec).Selector)
+			return true
+		})
+		return idx
+	}).(*SelectorIndex)
+}
+
+// Add adds a resource with the given selector to the index.
+func (s *SelectorIndex) Add(r *resource.Instance, selector map[string]string) {
+	name := r.Metadata.FullName
+	s.selectors[name] = selector
+	s.resources[name] = r
+
+	if len(selector) == 0 {
+		s.everything[name] = struct{}{}
+		return
+	}
+	// Any label will do, the smallest key keeps the index deterministic
+	var key string
+	for k := range selector {
+		if key == "" || k < key {
+			key = k
+		}
+	}
+	addToSet(s.byLabel, labelKey(key, selector[key]), name)
+}
+
+// Matches returns the resources whose selectors match the given workload labels, sorted by name. As with Kubernetes
+// label selectors, an empty selector matches every workload.
+func (s *SelectorIndex) Matches(labels map[string]string) []*resource.Instance {
+	var result []*resource.Instance
+	for name := range s.everything {
+		result = append(result, s.resources[name])
+	}
+	for k, v := range labels {
+		for name := range s.byLabel[labelKey(k, v)] {
+			if matchesAll(labels, s.selectors[name]) {
+				result = append(result, s.resources[name])
+			}
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Metadata.FullName.String() < result[j].Metadata.FullName.String()
+	})
+	return result
+}
@@ -0,0 +1,37 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestSelectorIndex(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	idx := NewSelectorIndex()
+	idx.Add(newWorkload("ns1", "a", nil), map[string]string{"app": "a"})
+	idx.Add(newWorkload("ns1", "a-v1", nil), map[string]string{"app": "a", "version": "v1"})
+	idx.Add(newWorkload("ns1", "b", nil), map[string]string{"app": "b"})
+	idx.Add(newWorkload("ns2", "all", nil), nil)
+
+	g.Expect(names(idx.Matches(map[string]string{"app": "a", "version": "v1"}))).To(
+		Equal([]string{"ns1/a", "ns1/a-v1", "ns2/all"}))
+	g.Expect(names(idx.Matches(map[string]string{"app": "a", "version": "v2"}))).To(Equal([]string{"ns1/a", "ns2/all"}))
+	g.Expect(names(idx.Matches(map[string]string{"version": "v1"}))).To(Equal([]string{"ns2/all"}))
+	g.Expect(names(idx.Matches(nil))).To(Equal([]string{"ns2/all"}))
+}
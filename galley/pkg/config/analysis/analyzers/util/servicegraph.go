@@ -0,0 +1,403 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"sort"
+	"strings"
+
+	"istio.io/api/networking/v1alpha3"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+// ServiceGraphInputs are the collections the service graph is derived from. Analyzers that call BuildServiceGraph
+// should include them as inputs in their Metadata.
+var ServiceGraphInputs = collection.Names{
+	collections.IstioNetworkingV1Alpha3Destinationrules.Name(),
+	collections.IstioNetworkingV1Alpha3Serviceentries.Name(),
+	collections.IstioNetworkingV1Alpha3Sidecars.Name(),
+	collections.IstioNetworkingV1Alpha3Virtualservices.Name(),
+}
+
+// NodeKind is the kind of a node in the service graph.
+type NodeKind int
+
+const (
+	// HostNode is a service host, named by its FQDN.
+	HostNode NodeKind = iota
+
+	// VirtualServiceNode is a VirtualService, named by its full name.
+	VirtualServiceNode
+)
+
+// Node is a node in the service graph.
+type Node struct {
+	Kind NodeKind
+	Name string
+}
+
+// String implements fmt.Stringer
+func (n Node) String() string {
+	if n.Kind == VirtualServiceNode {
+		return "VirtualService " + n.Name
+	}
+	return n.Name
+}
+
+// EdgeKind is the kind of an edge in the service graph.
+type EdgeKind int
+
+const (
+	// BindEdge connects a host to a VirtualService that configures the routing of the host's traffic.
+	BindEdge EdgeKind = iota
+
+	// RouteEdge connects a VirtualService to a host it routes traffic to.
+	RouteEdge
+
+	// MirrorEdge connects a VirtualService to a host it mirrors traffic to.
+	MirrorEdge
+
+	// DelegateEdge connects a VirtualService to a VirtualService it delegates routes to.
+	DelegateEdge
+)
+
+// Edge is a directed edge in the service graph.
+type Edge struct {
+	From Node
+	To   Node
+	Kind EdgeKind
+}
+
+// ServiceGraph is the service-to-service dependency graph derived from the VirtualServices, DestinationRules,
+// Sidecars and ServiceEntries of a snapshot. Traffic for a host flows along BindEdges to the VirtualServices routing
+// it, and from there along RouteEdges and MirrorEdges to the destination hosts, or along DelegateEdges to delegate
+// VirtualServices. The graph is read-only once built.
+type ServiceGraph struct {
+	edges            map[Node][]Edge
+	incoming         map[Node][]Edge
+	virtualServices  map[Node]*resource.Instance
+	serviceEntries   map[string][]*resource.Instance
+	destinationRules map[string][]*resource.Instance
+
+	// Egress hosts of the namespace wide Sidecar of each namespace that has one
+	sidecarEgress map[resource.Namespace][]string
+}
+
+// BuildServiceGraph returns the service graph of the resources in the context.
+// Analyzers that call this should include ServiceGraphInputs as inputs in their Metadata.
+// The graph is shared with other analyzers using the same context.
+func BuildServiceGraph(ctx analysis.Context) *ServiceGraph {
+	return analysis.Index(ctx, "util.ServiceGraph", func() interface{} {
+		return buildServiceGraph(ctx)
+	}).(*ServiceGraph)
+}
+
+func buildServiceGraph(ctx analysis.Context) *ServiceGraph {
+	g := &ServiceGraph{
+		edges:            make(map[Node][]Edge),
+		incoming:         make(map[Node][]Edge),
+		virtualServices:  make(map[Node]*resource.Instance),
+		serviceEntries:   make(map[string][]*resource.Instance),
+		destinationRules: make(map[string][]*resource.Instance),
+		sidecarEgress:    make(map[resource.Namespace][]string),
+	}
+
+	ctx.ForEach(collections.IstioNetworkingV1Alpha3Virtualservices.Name(), func(r *resource.Instance) bool {
+		g.addVirtualService(r)
+		return true
+	})
+
+	ctx.ForEach(collections.IstioNetworkingV1Alpha3Serviceentries.Name(), func(r *resource.Instance) bool {
+		ns := r.Metadata.FullName.Namespace
+		for _, h := range r.Message.(*v1alpha3.ServiceEntry).GetHosts() {
+			fqdn := ConvertHostToFQDN(ns, h)
+			g.serviceEntries[fqdn] = append(g.serviceEntries[fqdn], r)
+		}
+		return true
+	})
+
+	ctx.ForEach(collections.IstioNetworkingV1Alpha3Destinationrules.Name(), func(r *resource.Instance) bool {
+		fqdn := ConvertHostToFQDN(r.Metadata.FullName.Namespace, r.Message.(*v1alpha3.DestinationRule).GetHost())
+		g.destinationRules[fqdn] = append(g.destinationRules[fqdn], r)
+		return true
+	})
+
+	ctx.ForEach(collections.IstioNetworkingV1Alpha3Sidecars.Name(), func(r *resource.Instance) bool {
+		sc := r.Message.(*v1alpha3.Sidecar)
+		if sc.GetWorkloadSelector() != nil {
+			return true
+		}
+		ns := r.Metadata.FullName.Namespace
+		for _, e := range sc.GetEgress() {
+			g.sidecarEgress[ns] = append(g.sidecarEgress[ns], e.GetHosts()...)
+		}
+		return true
+	})
+
+	return g
+}
+
+func (g *ServiceGraph) addVirtualService(r *resource.Instance) {
+	vs := r.Message.(*v1alpha3.VirtualService)
+	ns := r.Metadata.FullName.Namespace
+	vsNode := Node{Kind: VirtualServiceNode, Name: r.Metadata.FullName.String()}
+	g.virtualServices[vsNode] = r
+
+	for _, h := range vs.GetHosts() {
+		g.addEdge(Node{Kind: HostNode, Name: ConvertHostToFQDN(ns, h)}, vsNode, BindEdge)
+	}
+
+	for _, route := range vs.GetHttp() {
+		for _, d := range route.GetRoute() {
+			g.addDestination(vsNode, ns, d.GetDestination(), RouteEdge)
+		}
+		g.addDestination(vsNode, ns, route.GetMirror(), MirrorEdge)
+		if d := route.GetDelegate(); d != nil {
+			delegateNs := resource.Namespace(d.GetNamespace())
+			if delegateNs == "" {
+				delegateNs = ns
+			}
+			delegate := resource.NewFullName(delegateNs, resource.LocalName(d.GetName()))
+			g.addEdge(vsNode, Node{Kind: VirtualServiceNode, Name: delegate.String()}, DelegateEdge)
+		}
+	}
+	for _, route := range vs.GetTcp() {
+		for _, d := range route.GetRoute() {
+			g.addDestination(vsNode, ns, d.GetDestination(), RouteEdge)
+		}
+	}
+	for _, route := range vs.GetTls() {
+		for _, d := range route.GetRoute() {
+			g.addDestination(vsNode, ns, d.GetDestination(), RouteEdge)
+		}
+	}
+}
+
+func (g *ServiceGraph) addDestination(from Node, ns resource.Namespace, d *v1alpha3.Destination, kind EdgeKind) {
+	if d == nil || d.GetHost() == "" {
+		return
+	}
+	g.addEdge(from, Node{Kind: HostNode, Name: ConvertHostToFQDN(ns, d.GetHost())}, kind)
+}
+
+func (g *ServiceGraph) addEdge(from, to Node, kind EdgeKind) {
+	e := Edge{From: from, To: to, Kind: kind}
+	for _, existing := range g.edges[from] {
+		if existing == e {
+			return
+		}
+	}
+	g.edges[from] = append(g.edges[from], e)
+	g.incoming[to] = append(g.incoming[to], e)
+}
+
+// Nodes returns all nodes of the graph, sorted by name.
+func (g *ServiceGraph) Nodes() []Node {
+	seen := make(map[Node]bool)
+	for n, edges := range g.edges {
+		seen[n] = true
+		for _, e := range edges {
+			seen[e.To] = true
+		}
+	}
+
+	result := make([]Node, 0, len(seen))
+	for n := range seen {
+		result = append(result, n)
+	}
+	sortNodes(result)
+	return result
+}
+
+// Edges returns the edges leaving the given node.
+func (g *ServiceGraph) Edges(n Node) []Edge {
+	return g.edges[n]
+}
+
+// Incoming returns the edges entering the given node.
+func (g *ServiceGraph) Incoming(n Node) []Edge {
+	return g.incoming[n]
+}
+
+// VirtualService returns the resource of a VirtualServiceNode, or nil if the VirtualService does not exist, e.g. for
+// a missing delegate.
+func (g *ServiceGraph) VirtualService(n Node) *resource.Instance {
+	return g.virtualServices[n]
+}
+
+// ServiceEntries returns the ServiceEntries declaring the given host.
+func (g *ServiceGraph) ServiceEntries(host string) []*resource.Instance {
+	return g.serviceEntries[host]
+}
+
+// ServiceEntryHosts returns the hosts declared by ServiceEntries, sorted.
+func (g *ServiceGraph) ServiceEntryHosts() []string {
+	result := make([]string, 0, len(g.serviceEntries))
+	for h := range g.serviceEntries {
+		result = append(result, h)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// DestinationRules returns the DestinationRules for the given host.
+func (g *ServiceGraph) DestinationRules(host string) []*resource.Instance {
+	return g.destinationRules[host]
+}
+
+// Visible returns whether workloads in the given namespace can reach the given host, as scoped by the egress hosts
+// of the namespace wide Sidecar in that namespace. Hosts are visible from namespaces without such a Sidecar.
+func (g *ServiceGraph) Visible(from resource.Namespace, host string) bool {
+	egress, ok := g.sidecarEgress[from]
+	if !ok {
+		return true
+	}
+
+	namespaces := make(map[resource.Namespace]bool)
+	for _, se := range g.serviceEntries[host] {
+		namespaces[se.Metadata.FullName.Namespace] = true
+	}
+	if ns := GetFullNameFromFQDN(host).Namespace; ns != "" {
+		namespaces[ns] = true
+	}
+
+	for _, e := range egress {
+		parts := strings.SplitN(e, "/", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		if !matchesEgressNamespace(parts[0], from, namespaces) {
+			continue
+		}
+		if matchesHost(parts[1], host) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesEgressNamespace(pattern string, from resource.Namespace, namespaces map[resource.Namespace]bool) bool {
+	switch pattern {
+	case "*":
+		return true
+	case "~":
+		return false
+	case ".":
+		return namespaces[from]
+	default:
+		return namespaces[resource.Namespace(pattern)]
+	}
+}
+
+func matchesHost(pattern, host string) bool {
+	if pattern == "*" {
+		return true
+	}
+	if strings.HasPrefix(pattern, "*") {
+		return strings.HasSuffix(host, pattern[1:])
+	}
+	return pattern == host
+}
+
+// Cycles returns the sets of nodes that lie on a common cycle when only following edges of the given kinds. Each set
+// is sorted, and the sets are sorted by their first node.
+func (g *ServiceGraph) Cycles(kinds ...EdgeKind) [][]Node {
+	follow := make(map[EdgeKind]bool)
+	for _, k := range kinds {
+		follow[k] = true
+	}
+
+	// Tarjan's strongly connected components algorithm
+	var (
+		index   = make(map[Node]int)
+		lowlink = make(map[Node]int)
+		onStack = make(map[Node]bool)
+		stack   []Node
+		next    int
+		result  [][]Node
+	)
+	var visit func(n Node)
+	visit = func(n Node) {
+		index[n] = next
+		lowlink[n] = next
+		next++
+		stack = append(stack, n)
+		onStack[n] = true
+
+		selfLoop := false
+		for _, e := range g.edges[n] {
+			if !follow[e.Kind] {
+				continue
+			}
+			if e.To == n {
+				selfLoop = true
+			}
+			if _, ok := index[e.To]; !ok {
+				visit(e.To)
+				if lowlink[e.To] < lowlink[n] {
+					lowlink[n] = lowlink[e.To]
+				}
+			} else if onStack[e.To] && index[e.To] < lowlink[n] {
+				lowlink[n] = index[e.To]
+			}
+		}
+
+		if lowlink[n] != index[n] {
+			return
+		}
+		var component []Node
+		for {
+			m := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[m] = false
+			component = append(component, m)
+			if m == n {
+				break
+			}
+		}
+		if len(component) > 1 || selfLoop {
+			sortNodes(component)
+			result = append(result, component)
+		}
+	}
+
+	for _, n := range g.Nodes() {
+		if _, ok := index[n]; !ok {
+			visit(n)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return nodeLess(result[i][0], result[j][0])
+	})
+	return result
+}
+
+func sortNodes(nodes []Node) {
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodeLess(nodes[i], nodes[j])
+	})
+}
+
+func nodeLess(a, b Node) bool {
+	if a.Name != b.Name {
+		return a.Name < b.Name
+	}
+	return a.Kind < b.Kind
+}
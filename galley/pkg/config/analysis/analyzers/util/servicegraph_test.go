@@ -0,0 +1,188 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"testing"
+
+	"github.com/gogo/protobuf/proto"
+	. "github.com/onsi/gomega"
+
+	"istio.io/api/networking/v1alpha3"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+type collectionContext struct {
+	resources map[collection.Name][]*resource.Instance
+}
+
+var _ analysis.Context = &collectionContext{}
+
+func (ctx *collectionContext) Report(collection.Name, diag.Message)                       {}
+func (ctx *collectionContext) Find(collection.Name, resource.FullName) *resource.Instance { return nil }
+func (ctx *collectionContext) Exists(collection.Name, resource.FullName) bool             { return false }
+func (ctx *collectionContext) Canceled() bool                                             { return false }
+
+func (ctx *collectionContext) ForEach(col collection.Name, fn analysis.IteratorFn) {
+	for _, r := range ctx.resources[col] {
+		if !fn(r) {
+			return
+		}
+	}
+}
+
+func newConfig(ns, name string, m proto.Message) *resource.Instance {
+	return &resource.Instance{
+		Metadata: resource.Metadata{FullName: resource.NewFullName(resource.Namespace(ns), resource.LocalName(name))},
+		Message:  m,
+	}
+}
+
+func host(name string) Node {
+	return Node{Kind: HostNode, Name: name}
+}
+
+func virtualService(name string) Node {
+	return Node{Kind: VirtualServiceNode, Name: name}
+}
+
+func route(hosts ...string) []*v1alpha3.HTTPRouteDestination {
+	var result []*v1alpha3.HTTPRouteDestination
+	for _, h := range hosts {
+		result = append(result, &v1alpha3.HTTPRouteDestination{Destination: &v1alpha3.Destination{Host: h}})
+	}
+	return result
+}
+
+func testServiceGraph() *ServiceGraph {
+	return buildServiceGraph(&collectionContext{resources: map[collection.Name][]*resource.Instance{
+		collections.IstioNetworkingV1Alpha3Virtualservices.Name(): {
+			// a routes to b and mirrors to c, while c mirrors back to a
+			newConfig("ns1", "a", &v1alpha3.VirtualService{
+				Hosts: []string{"a"},
+				Http:  []*v1alpha3.HTTPRoute{{Route: route("b"), Mirror: &v1alpha3.Destination{Host: "c"}}},
+			}),
+			newConfig("ns1", "c", &v1alpha3.VirtualService{
+				Hosts: []string{"c"},
+				Http:  []*v1alpha3.HTTPRoute{{Route: route("c"), Mirror: &v1alpha3.Destination{Host: "a"}}},
+			}),
+			// root and delegate delegate to each other
+			newConfig("ns2", "root", &v1alpha3.VirtualService{
+				Hosts: []string{"d.example.com"},
+				Http:  []*v1alpha3.HTTPRoute{{Delegate: &v1alpha3.Delegate{Name: "delegate"}}},
+			}),
+			newConfig("ns2", "delegate", &v1alpha3.VirtualService{
+				Http: []*v1alpha3.HTTPRoute{{Delegate: &v1alpha3.Delegate{Name: "root", Namespace: "ns2"}}},
+				Tcp:  []*v1alpha3.TCPRoute{{Route: []*v1alpha3.RouteDestination{{Destination: &v1alpha3.Destination{Host: "e.example.com"}}}}},
+			}),
+		},
+		collections.IstioNetworkingV1Alpha3Serviceentries.Name(): {
+			newConfig("ns2", "external", &v1alpha3.ServiceEntry{Hosts: []string{"e.example.com", "f.example.com"}}),
+		},
+		collections.IstioNetworkingV1Alpha3Destinationrules.Name(): {
+			newConfig("ns1", "b", &v1alpha3.DestinationRule{Host: "b"}),
+		},
+		collections.IstioNetworkingV1Alpha3Sidecars.Name(): {
+			newConfig("ns1", "default", &v1alpha3.Sidecar{
+				Egress: []*v1alpha3.IstioEgressListener{{Hosts: []string{"./*", "ns2/e.example.com"}}},
+			}),
+			newConfig("ns2", "selected", &v1alpha3.Sidecar{
+				WorkloadSelector: &v1alpha3.WorkloadSelector{Labels: map[string]string{"app": "x"}},
+				Egress:           []*v1alpha3.IstioEgressListener{{Hosts: []string{"~/*"}}},
+			}),
+		},
+	}})
+}
+
+func TestServiceGraphEdges(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	sg := testServiceGraph()
+	a := host("a.ns1.svc.cluster.local")
+	b := host("b.ns1.svc.cluster.local")
+	c := host("c.ns1.svc.cluster.local")
+	vsA := virtualService("ns1/a")
+
+	g.Expect(sg.Edges(a)).To(ConsistOf(Edge{From: a, To: vsA, Kind: BindEdge}))
+	g.Expect(sg.Edges(vsA)).To(ConsistOf(
+		Edge{From: vsA, To: b, Kind: RouteEdge},
+		Edge{From: vsA, To: c, Kind: MirrorEdge},
+	))
+	g.Expect(sg.Incoming(b)).To(ConsistOf(Edge{From: vsA, To: b, Kind: RouteEdge}))
+	g.Expect(sg.Edges(virtualService("ns2/delegate"))).To(ConsistOf(
+		Edge{From: virtualService("ns2/delegate"), To: virtualService("ns2/root"), Kind: DelegateEdge},
+		Edge{From: virtualService("ns2/delegate"), To: host("e.example.com"), Kind: RouteEdge},
+	))
+	g.Expect(sg.VirtualService(vsA).Metadata.FullName.String()).To(Equal("ns1/a"))
+	g.Expect(sg.VirtualService(virtualService("ns3/missing"))).To(BeNil())
+	g.Expect(sg.Nodes()).To(ContainElement(host("e.example.com")))
+
+	g.Expect(sg.DestinationRules(b.Name)).To(HaveLen(1))
+	g.Expect(sg.ServiceEntries("f.example.com")).To(HaveLen(1))
+	g.Expect(sg.ServiceEntryHosts()).To(Equal([]string{"e.example.com", "f.example.com"}))
+}
+
+func TestServiceGraphCycles(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	sg := testServiceGraph()
+
+	g.Expect(sg.Cycles(BindEdge, MirrorEdge)).To(Equal([][]Node{{
+		host("a.ns1.svc.cluster.local"),
+		host("c.ns1.svc.cluster.local"),
+		virtualService("ns1/a"),
+		virtualService("ns1/c"),
+	}}))
+	g.Expect(sg.Cycles(DelegateEdge)).To(Equal([][]Node{{
+		virtualService("ns2/delegate"),
+		virtualService("ns2/root"),
+	}}))
+
+	// c routes to itself
+	g.Expect(sg.Cycles(BindEdge, RouteEdge)).To(Equal([][]Node{{
+		host("c.ns1.svc.cluster.local"),
+		virtualService("ns1/c"),
+	}}))
+	g.Expect(sg.Cycles()).To(BeEmpty())
+}
+
+func TestServiceGraphVisible(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	sg := testServiceGraph()
+
+	g.Expect(sg.Visible("ns1", "b.ns1.svc.cluster.local")).To(BeTrue())
+	g.Expect(sg.Visible("ns1", "e.example.com")).To(BeTrue())
+	g.Expect(sg.Visible("ns1", "f.example.com")).To(BeFalse())
+	g.Expect(sg.Visible("ns1", "x.ns3.svc.cluster.local")).To(BeFalse())
+
+	// Sidecars with workload selectors don't scope the whole namespace
+	g.Expect(sg.Visible("ns2", "b.ns1.svc.cluster.local")).To(BeTrue())
+	g.Expect(sg.Visible("ns3", "f.example.com")).To(BeTrue())
+}
+
+func TestBuildServiceGraph(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	ictx := &indexingContext{indexes: make(map[string]interface{})}
+	sg := BuildServiceGraph(ictx)
+	g.Expect(sg.Nodes()).To(BeEmpty())
+	g.Expect(BuildServiceGraph(ictx)).To(BeIdenticalTo(sg))
+}
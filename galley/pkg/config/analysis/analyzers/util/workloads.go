@@ -0,0 +1,174 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"sort"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+)
+
+type nameSet map[resource.FullName]struct{}
+
+// WorkloadIndex indexes workload resources (e.g. pods) by namespace and label, so that the workloads matching a
+// label selector can be found without scanning every workload. The index is built once and can be kept up to date
+// with Add and Remove.
+type WorkloadIndex struct {
+	workloads   map[resource.FullName]*resource.Instance
+	byNamespace map[string]nameSet
+	byLabel     map[string]nameSet
+}
+
+// NewWorkloadIndex returns a new, empty WorkloadIndex.
+func NewWorkloadIndex() *WorkloadIndex {
+	return &WorkloadIndex{
+		workloads:   make(map[resource.FullName]*resource.Instance),
+		byNamespace: make(map[string]nameSet),
+		byLabel:     make(map[string]nameSet),
+	}
+}
+
+// BuildWorkloadIndex returns a WorkloadIndex of all the resources in the given collection.
+// Analyzers that call this should include the collection as an input in their Metadata.
+// The index is shared with other analyzers using the same context, and must not be modified. Use Clone to get a copy
+// that can be modified.
+func BuildWorkloadIndex(ctx analysis.Context, col collection.Name) *WorkloadIndex {
+	return analysis.Index(ctx, "util.WorkloadIndex/"+col.String(), func() interface{} {
+		idx := NewWorkloadIndex()
+		ctx.ForEach(col, func(r *resource.Instance) bool {
+			idx.Add(r)
+			return true
+		})
+		return idx
+	}).(*WorkloadIndex)
+}
+
+// Clone returns a copy of the index, which can be modified independently.
+func (w *WorkloadIndex) Clone() *WorkloadIndex {
+	c := NewWorkloadIndex()
+	for _, r := range w.workloads {
+		c.Add(r)
+	}
+	return c
+}
+
+// Add a workload to the index, replacing any existing workload of the same name.
+func (w *WorkloadIndex) Add(r *resource.Instance) {
+	name := r.Metadata.FullName
+	w.Remove(name)
+
+	w.workloads[name] = r
+	addToSet(w.byNamespace, name.Namespace.String(), name)
+	for k, v := range r.Metadata.Labels {
+		addToSet(w.byLabel, labelKey(k, v), name)
+	}
+}
+
+// Remove a workload from the index.
+func (w *WorkloadIndex) Remove(name resource.FullName) {
+	r, ok := w.workloads[name]
+	if !ok {
+		return
+	}
+
+	delete(w.workloads, name)
+	removeFromSet(w.byNamespace, name.Namespace.String(), name)
+	for k, v := range r.Metadata.Labels {
+		removeFromSet(w.byLabel, labelKey(k, v), name)
+	}
+}
+
+// Len returns the number of workloads in the index.
+func (w *WorkloadIndex) Len() int {
+	return len(w.workloads)
+}
+
+// Select returns the workloads in the given namespace whose labels match all of the given selector labels, sorted by
+// name. If the namespace is empty, workloads in all namespaces are considered. As with Kubernetes label selectors, an
+// empty selector matches every workload.
+func (w *WorkloadIndex) Select(ns resource.Namespace, selector map[string]string) []*resource.Instance {
+	// Start from the smallest candidate set, then verify each candidate against the full selector.
+	var candidates nameSet
+	if ns != "" {
+		candidates = w.byNamespace[ns.String()]
+		if len(candidates) == 0 {
+			return nil
+		}
+	}
+	for k, v := range selector {
+		s := w.byLabel[labelKey(k, v)]
+		if len(s) == 0 {
+			return nil
+		}
+		if candidates == nil || len(s) < len(candidates) {
+			candidates = s
+		}
+	}
+
+	var result []*resource.Instance
+	if candidates == nil {
+		// Empty selector and no namespace restriction
+		for _, r := range w.workloads {
+			result = append(result, r)
+		}
+	} else {
+		for name := range candidates {
+			if ns != "" && name.Namespace != ns {
+				continue
+			}
+			r := w.workloads[name]
+			if matchesAll(r.Metadata.Labels, selector) {
+				result = append(result, r)
+			}
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Metadata.FullName.String() < result[j].Metadata.FullName.String()
+	})
+	return result
+}
+
+func matchesAll(labels, selector map[string]string) bool {
+	for k, v := range selector {
+		if lv, ok := labels[k]; !ok || lv != v {
+			return false
+		}
+	}
+	return true
+}
+
+func labelKey(k, v string) string {
+	return k + "=" + v
+}
+
+func addToSet(m map[string]nameSet, key string, name resource.FullName) {
+	s, ok := m[key]
+	if !ok {
+		s = make(nameSet)
+		m[key] = s
+	}
+	s[name] = struct{}{}
+}
+
+func removeFromSet(m map[string]nameSet, key string, name resource.FullName) {
+	s := m[key]
+	delete(s, name)
+	if len(s) == 0 {
+		delete(m, key)
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"istio.io/istio/galley/pkg/config/analysis/testing/fixtures"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+func newWorkload(ns, name string, labels map[string]string) *resource.Instance {
+	return &resource.Instance{
+		Metadata: resource.Metadata{
+			FullName: resource.NewFullName(resource.Namespace(ns), resource.LocalName(name)),
+			Labels:   labels,
+		},
+	}
+}
+
+func names(rs []*resource.Instance) []string {
+	var result []string
+	for _, r := range rs {
+		result = append(result, r.Metadata.FullName.String())
+	}
+	return result
+}
+
+func TestWorkloadIndex(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	idx := NewWorkloadIndex()
+	idx.Add(newWorkload("ns1", "a", map[string]string{"app": "a", "version": "v1"}))
+	idx.Add(newWorkload("ns1", "b", map[string]string{"app": "b", "version": "v1"}))
+	idx.Add(newWorkload("ns2", "a", map[string]string{"app": "a", "version": "v2"}))
+	g.Expect(idx.Len()).To(Equal(3))
+
+	g.Expect(names(idx.Select("ns1", map[string]string{"app": "a"}))).To(Equal([]string{"ns1/a"}))
+	g.Expect(names(idx.Select("", map[string]string{"app": "a"}))).To(Equal([]string{"ns1/a", "ns2/a"}))
+	g.Expect(names(idx.Select("ns1", map[string]string{"version": "v1"}))).To(Equal([]string{"ns1/a", "ns1/b"}))
+	g.Expect(names(idx.Select("ns1", map[string]string{"app": "a", "version": "v2"}))).To(BeEmpty())
+	g.Expect(names(idx.Select("ns1", map[string]string{"app": "c"}))).To(BeEmpty())
+	g.Expect(names(idx.Select("ns3", nil))).To(BeEmpty())
+
+	// Empty selectors match everything in scope
+	g.Expect(names(idx.Select("ns1", nil))).To(Equal([]string{"ns1/a", "ns1/b"}))
+	g.Expect(idx.Select("", nil)).To(HaveLen(3))
+
+	// Updates replace the previous labels
+	idx.Add(newWorkload("ns1", "a", map[string]string{"app": "c"}))
+	g.Expect(idx.Len()).To(Equal(3))
+	g.Expect(names(idx.Select("ns1", map[string]string{"app": "a"}))).To(BeEmpty())
+	g.Expect(names(idx.Select("ns1", map[string]string{"app": "c"}))).To(Equal([]string{"ns1/a"}))
+
+	idx.Remove(resource.NewFullName("ns1", "a"))
+	g.Expect(idx.Len()).To(Equal(2))
+	g.Expect(names(idx.Select("ns1", nil))).To(Equal([]string{"ns1/b"}))
+}
+
+func TestWorkloadIndexClone(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	idx := NewWorkloadIndex()
+	idx.Add(newWorkload("ns1", "a", map[string]string{"app": "a"}))
+
+	c := idx.Clone()
+	c.Add(newWorkload("ns1", "b", map[string]string{"app": "a"}))
+	c.Remove(resource.NewFullName("ns1", "a"))
+
+	g.Expect(names(idx.Select("", map[string]string{"app": "a"}))).To(Equal([]string{"ns1/a"}))
+	g.Expect(names(c.Select("", map[string]string{"app": "a"}))).To(Equal([]string{"ns1/b"}))
+}
+
+type indexingContext struct {
+	fixtures.Context
+	indexes map[string]interface{}
+}
+
+// Index implements analysis.IndexProvider
+func (c *indexingContext) Index(key string, build func() interface{}) interface{} {
+	if _, ok := c.indexes[key]; !ok {
+		c.indexes[key] = build()
+	}
+	return c.indexes[key]
+}
+
+func TestBuildWorkloadIndex(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	col := collections.K8SCoreV1Pods.Name()
+	workloads := []*resource.Instance{newWorkload("ns1", "a", map[string]string{"app": "a"})}
+
+	// Without support for shared indexes, a new index is built every time
+	ctx := &fixtures.Context{Resources: workloads}
+	idx := BuildWorkloadIndex(ctx, col)
+	g.Expect(idx.Len()).To(Equal(1))
+	g.Expect(BuildWorkloadIndex(ctx, col)).NotTo(BeIdenticalTo(idx))
+
+	ictx := &indexingContext{Context: fixtures.Context{Resources: workloads}, indexes: make(map[string]interface{})}
+	idx = BuildWorkloadIndex(ictx, col)
+	g.Expect(idx.Len()).To(Equal(1))
+	g.Expect(BuildWorkloadIndex(ictx, col)).To(BeIdenticalTo(idx))
+}
@@ -0,0 +1,118 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"fmt"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"istio.io/api/networking/v1alpha3"
+	"istio.io/api/security/v1beta1"
+
+	"istio.io/istio/pkg/config/resource"
+)
+
+// WorkloadSelector returns the labels of the workload selector of a Gateway, Sidecar, EnvoyFilter,
+// AuthorizationPolicy, PeerAuthentication or RequestAuthentication, or nil if the resource has none.
+func WorkloadSelector(r *resource.Instance) map[string]string {
+	switch m := r.Message.(type) {
+	case *v1alpha3.Gateway:
+		return m.GetSelector()
+	case *v1alpha3.Sidecar:
+		return m.GetWorkloadSelector().GetLabels()
+	case *v1alpha3.EnvoyFilter:
+		return m.GetWorkloadSelector().GetLabels()
+	case *v1beta1.AuthorizationPolicy:
+		return m.GetSelector().GetMatchLabels()
+	case *v1beta1.PeerAuthentication:
+		return m.GetSelector().GetMatchLabels()
+	case *v1beta1.RequestAuthentication:
+		return m.GetSelector().GetMatchLabels()
+	}
+	return nil
+}
+
+// WorkloadSelectorNamespace returns the namespace of the workloads that the workload selector of a resource applies
+// to, or "" if it applies to workloads in all namespaces. Gateways select workloads in all namespaces, Sidecars and
+// PeerAuthentications in their own namespace, and the other resources in their own namespace unless they are in the
+// root namespace.
+func WorkloadSelectorNamespace(r *resource.Instance, rootNamespace resource.Namespace) resource.Namespace {
+	ns := r.Metadata.FullName.Namespace
+	switch r.Message.(type) {
+	case *v1alpha3.Gateway:
+		return ""
+	case *v1alpha3.Sidecar, *v1beta1.PeerAuthentication:
+		return ns
+	}
+	if ns == rootNamespace {
+		return ""
+	}
+	return ns
+}
+
+// SelectWorkloads returns the workloads that the workload selector of a resource matches, sorted by name. A resource
+// without a selector matches every workload in scope.
+func SelectWorkloads(workloads *WorkloadIndex, r *resource.Instance, rootNamespace resource.Namespace) []*resource.Instance {
+	return workloads.Select(WorkloadSelectorNamespace(r, rootNamespace), WorkloadSelector(r))
+}
+
+// ClosestMatch describes the workload whose labels match most of the selector, or returns "none" if no workload
+// matches any of it. Ties are broken by name, as workloads are sorted by name.
+func ClosestMatch(workloads []*resource.Instance, selector map[string]string) string {
+	var best *resource.Instance
+	bestScore := 0
+	for _, w := range workloads {
+		score := 0
+		for k, v := range selector {
+			if w.Metadata.Labels[k] == v {
+				score++
+			}
+		}
+		if score > bestScore {
+			best = w
+			bestScore = score
+		}
+	}
+
+	if best == nil {
+		return "none"
+	}
+	return fmt.Sprintf("pod %s with labels %s", best.Metadata.FullName, labels.SelectorFromSet(best.Metadata.Labels))
+}
+
+// AppName returns the name of the application a pod belongs to: the value of its app label, or else the name of the
+// workload that owns it, e.g. the Deployment of its ReplicaSet, or else the name of the pod itself.
+func AppName(pod *resource.Instance) string {
+	for _, l := range []string{"app", "app.kubernetes.io/name"} {
+		if app := pod.Metadata.Labels[l]; app != "" {
+			return app
+		}
+	}
+
+	if p, ok := pod.Message.(*v1.Pod); ok {
+		for _, owner := range p.OwnerReferences {
+			if owner.Kind == "ReplicaSet" {
+				if hash := pod.Metadata.Labels["pod-template-hash"]; hash != "" {
+					return strings.TrimSuffix(owner.Name, "-"+hash)
+				}
+			}
+			return owner.Name
+		}
+	}
+	return pod.Metadata.FullName.Name.String()
+}
@@ -0,0 +1,99 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"istio.io/api/networking/v1alpha3"
+	"istio.io/api/security/v1beta1"
+	selectorpb "istio.io/api/type/v1beta1"
+
+	"istio.io/istio/pkg/config/resource"
+)
+
+func newSelectorResource(ns, name string, m resource.Message) *resource.Instance {
+	return &resource.Instance{
+		Metadata: resource.Metadata{FullName: resource.NewFullName(resource.Namespace(ns), resource.LocalName(name))},
+		Message:  m,
+	}
+}
+
+func TestWorkloadSelector(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	selector := map[string]string{"app": "a"}
+	gw := newSelectorResource("ns1", "gw", &v1alpha3.Gateway{Selector: selector})
+	sc := newSelectorResource("istio-system", "sc", &v1alpha3.Sidecar{
+		WorkloadSelector: &v1alpha3.WorkloadSelector{Labels: selector}})
+	pa := newSelectorResource("istio-system", "pa", &v1beta1.PeerAuthentication{
+		Selector: &selectorpb.WorkloadSelector{MatchLabels: selector}})
+	ap := newSelectorResource("ns1", "ap", &v1beta1.AuthorizationPolicy{})
+
+	g.Expect(WorkloadSelector(gw)).To(Equal(selector))
+	g.Expect(WorkloadSelector(sc)).To(Equal(selector))
+	g.Expect(WorkloadSelector(pa)).To(Equal(selector))
+	g.Expect(WorkloadSelector(ap)).To(BeEmpty())
+
+	g.Expect(WorkloadSelectorNamespace(gw, "istio-system")).To(Equal(resource.Namespace("")))
+	g.Expect(WorkloadSelectorNamespace(sc, "istio-system")).To(Equal(resource.Namespace("istio-system")))
+	g.Expect(WorkloadSelectorNamespace(pa, "istio-system")).To(Equal(resource.Namespace("istio-system")))
+	g.Expect(WorkloadSelectorNamespace(ap, "istio-system")).To(Equal(resource.Namespace("ns1")))
+
+	idx := NewWorkloadIndex()
+	idx.Add(newWorkload("ns1", "a-1", selector))
+	idx.Add(newWorkload("ns2", "a-2", selector))
+	idx.Add(newWorkload("ns1", "b-1", map[string]string{"app": "b"}))
+	g.Expect(names(SelectWorkloads(idx, gw, "istio-system"))).To(Equal([]string{"ns1/a-1", "ns2/a-2"}))
+	g.Expect(names(SelectWorkloads(idx, ap, "istio-system"))).To(Equal([]string{"ns1/a-1", "ns1/b-1"}))
+}
+
+func TestClosestMatch(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	workloads := []*resource.Instance{
+		newWorkload("ns1", "a", map[string]string{"app": "a", "version": "v1"}),
+		newWorkload("ns1", "b", map[string]string{"app": "b", "version": "v2"}),
+	}
+	g.Expect(ClosestMatch(workloads, map[string]string{"app": "a", "version": "v2"})).To(
+		Equal("pod ns1/a with labels app=a,version=v1"))
+	g.Expect(ClosestMatch(workloads, map[string]string{"app": "c"})).To(Equal("none"))
+}
+
+func TestAppName(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	g.Expect(AppName(newWorkload("ns1", "a-1", map[string]string{"app": "a"}))).To(Equal("a"))
+	g.Expect(AppName(newWorkload("ns1", "a-1", map[string]string{"app.kubernetes.io/name": "a"}))).To(Equal("a"))
+
+	pod := newWorkload("ns1", "reviews-v1-5d8c9b-x7k2p", map[string]string{"pod-template-hash": "5d8c9b"})
+	pod.Message = &v1.Pod{ObjectMeta: metav1.ObjectMeta{
+		OwnerReferences: []metav1.OwnerReference{{Kind: "ReplicaSet", Name: "reviews-v1-5d8c9b"}},
+	}}
+	g.Expect(AppName(pod)).To(Equal("reviews-v1"))
+
+	pod = newWorkload("ns1", "db-0", nil)
+	pod.Message = &v1.Pod{ObjectMeta: metav1.ObjectMeta{
+		OwnerReferences: []metav1.OwnerReference{{Kind: "StatefulSet", Name: "db"}},
+	}}
+	g.Expect(AppName(pod)).To(Equal("db"))
+
+	g.Expect(AppName(newWorkload("ns1", "standalone", nil))).To(Equal("standalone"))
+}
@@ -15,6 +15,7 @@
 package virtualservice
 
 import (
+	"sort"
 	"strings"
 
 	"istio.io/api/networking/v1alpha3"
@@ -64,6 +65,8 @@ func combineResourceEntryNames(rList []*resource.Instance) string {
 	for _, r := range rList {
 		names = append(names, r.Metadata.FullName.String())
 	}
+	// Collections are iterated in no particular order, so sort to keep the message stable.
+	sort.Strings(names)
 	return strings.Join(names, ",")
 }
 
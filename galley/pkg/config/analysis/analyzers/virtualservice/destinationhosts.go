@@ -34,7 +34,7 @@ import (
 // DestinationHostAnalyzer checks the destination hosts associated with each virtual service
 type DestinationHostAnalyzer struct{}
 
-var _ analysis.Analyzer = &DestinationHostAnalyzer{}
+var _ analysis.IncrementalAnalyzer = &DestinationHostAnalyzer{}
 
 type hostAndSubset struct {
 	host   resource.FullName
@@ -65,6 +65,21 @@ func (a *DestinationHostAnalyzer) Analyze(ctx analysis.Context) {
 	})
 }
 
+// IncrementalInput implements analysis.IncrementalAnalyzer
+func (a *DestinationHostAnalyzer) IncrementalInput() collection.Name {
+	return collections.IstioNetworkingV1Alpha3Virtualservices.Name()
+}
+
+// AnalyzeResource implements analysis.IncrementalAnalyzer
+func (a *DestinationHostAnalyzer) AnalyzeResource(ctx analysis.Context, r *resource.Instance) {
+	// The service entry hosts are shared by the virtual services of a snapshot
+	serviceEntryHosts := analysis.Index(ctx, "virtualservice.ServiceEntryHosts", func() interface{} {
+		return initServiceEntryHostMap(ctx)
+	}).(map[util.ScopedFqdn]*v1alpha3.ServiceEntry)
+
+	a.analyzeVirtualService(r, ctx, serviceEntryHosts)
+}
+
 func (a *DestinationHostAnalyzer) analyzeVirtualService(r *resource.Instance, ctx analysis.Context,
 	serviceEntryHosts map[util.ScopedFqdn]*v1alpha3.ServiceEntry) {
 
@@ -74,7 +89,7 @@ func (a *DestinationHostAnalyzer) analyzeVirtualService(r *resource.Instance, ct
 		s := getDestinationHost(r.Metadata.FullName.Namespace, d.GetHost(), serviceEntryHosts)
 		if s == nil {
 			ctx.Report(collections.IstioNetworkingV1Alpha3Virtualservices.Name(),
-				msg.NewReferencedResourceNotFound(r, "host", d.GetHost()))
+				msg.NewReferencedResourceNotFound(r, "host", d.GetHost()).WithFieldPath(d.path+".host"))
 			continue
 		}
 		checkServiceEntryPorts(ctx, r, d, s)
@@ -168,7 +183,7 @@ func initServiceEntryHostMap(ctx analysis.Context) map[util.ScopedFqdn]*v1alpha3
 	return result
 }
 
-func checkServiceEntryPorts(ctx analysis.Context, r *resource.Instance, d *v1alpha3.Destination, s *v1alpha3.ServiceEntry) {
+func checkServiceEntryPorts(ctx analysis.Context, r *resource.Instance, d routeDestination, s *v1alpha3.ServiceEntry) {
 	if d.GetPort() == nil {
 		// If destination port isn't specified, it's only a problem if the service being referenced exposes multiple ports.
 		if len(s.GetPorts()) > 1 {
@@ -177,7 +192,7 @@ func checkServiceEntryPorts(ctx analysis.Context, r *resource.Instance, d *v1alp
 				portNumbers = append(portNumbers, int(p.GetNumber()))
 			}
 			ctx.Report(collections.IstioNetworkingV1Alpha3Virtualservices.Name(),
-				msg.NewVirtualServiceDestinationPortSelectorRequired(r, d.GetHost(), portNumbers))
+				msg.NewVirtualServiceDestinationPortSelectorRequired(r, d.GetHost(), portNumbers).WithFieldPath(d.path))
 			return
 		}
 
@@ -194,6 +209,7 @@ func checkServiceEntryPorts(ctx analysis.Context, r *resource.Instance, d *v1alp
 	}
 	if !foundPort {
 		ctx.Report(collections.IstioNetworkingV1Alpha3Virtualservices.Name(),
-			msg.NewReferencedResourceNotFound(r, "host:port", fmt.Sprintf("%s:%d", d.GetHost(), d.GetPort().GetNumber())))
+			msg.NewReferencedResourceNotFound(r, "host:port", fmt.Sprintf("%s:%d", d.GetHost(), d.GetPort().GetNumber())).
+				WithFieldPath(d.path+".port.number"))
 	}
 }
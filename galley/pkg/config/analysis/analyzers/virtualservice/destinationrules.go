@@ -30,7 +30,7 @@ import (
 // DestinationRuleAnalyzer checks the destination rules associated with each virtual service
 type DestinationRuleAnalyzer struct{}
 
-var _ analysis.Analyzer = &DestinationRuleAnalyzer{}
+var _ analysis.IncrementalAnalyzer = &DestinationRuleAnalyzer{}
 
 // Metadata implements Analyzer
 func (d *DestinationRuleAnalyzer) Metadata() analysis.Metadata {
@@ -55,6 +55,21 @@ func (d *DestinationRuleAnalyzer) Analyze(ctx analysis.Context) {
 	})
 }
 
+// IncrementalInput implements analysis.IncrementalAnalyzer
+func (d *DestinationRuleAnalyzer) IncrementalInput() collection.Name {
+	return collections.IstioNetworkingV1Alpha3Virtualservices.Name()
+}
+
+// AnalyzeResource implements analysis.IncrementalAnalyzer
+func (d *DestinationRuleAnalyzer) AnalyzeResource(ctx analysis.Context, r *resource.Instance) {
+	// The destination host+subset combinations are shared by the virtual services of a snapshot
+	destHostsAndSubsets := analysis.Index(ctx, "virtualservice.DestHostsAndSubsets", func() interface{} {
+		return initDestHostsAndSubsets(ctx)
+	}).(map[hostAndSubset]bool)
+
+	d.analyzeVirtualService(r, ctx, destHostsAndSubsets)
+}
+
 func (d *DestinationRuleAnalyzer) analyzeVirtualService(r *resource.Instance, ctx analysis.Context,
 	destHostsAndSubsets map[hostAndSubset]bool) {
 
@@ -64,9 +79,10 @@ func (d *DestinationRuleAnalyzer) analyzeVirtualService(r *resource.Instance, ct
 	destinations := getRouteDestinations(vs)
 
 	for _, destination := range destinations {
-		if !d.checkDestinationSubset(ns, destination, destHostsAndSubsets) {
+		if !d.checkDestinationSubset(ns, destination.Destination, destHostsAndSubsets) {
 			ctx.Report(collections.IstioNetworkingV1Alpha3Virtualservices.Name(),
-				msg.NewReferencedResourceNotFound(r, "host+subset in destinationrule", fmt.Sprintf("%s+%s", destination.GetHost(), destination.GetSubset())))
+				msg.NewReferencedResourceNotFound(r, "host+subset in destinationrule", fmt.Sprintf("%s+%s", destination.GetHost(), destination.GetSubset())).
+					WithFieldPath(destination.path+".subset"))
 		}
 	}
 }
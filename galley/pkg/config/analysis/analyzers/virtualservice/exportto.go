@@ -0,0 +1,207 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package virtualservice
+
+import (
+	"sort"
+	"strings"
+
+	"istio.io/api/annotation"
+	"istio.io/api/networking/v1alpha3"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/util"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+// ExportToAnalyzer checks that the destinations of each virtual service are visible in the namespaces
+// the virtual service is exported to
+type ExportToAnalyzer struct{}
+
+var _ analysis.Analyzer = &ExportToAnalyzer{}
+
+// visibility is the set of namespaces a resource, or a group of resources, is visible in
+type visibility struct {
+	all        bool
+	namespaces map[resource.Namespace]bool
+}
+
+// Metadata implements Analyzer
+func (a *ExportToAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:        "virtualservice.ExportToAnalyzer",
+		Description: "Checks that the destinations of each virtual service are visible where the virtual service is exported",
+		Inputs: collection.Names{
+			collections.IstioNetworkingV1Alpha3Destinationrules.Name(),
+			collections.IstioNetworkingV1Alpha3Serviceentries.Name(),
+			collections.IstioNetworkingV1Alpha3Virtualservices.Name(),
+			collections.K8SCoreV1Services.Name(),
+		},
+	}
+}
+
+// Analyze implements Analyzer
+func (a *ExportToAnalyzer) Analyze(ctx analysis.Context) {
+	hosts := initHostVisibility(ctx)
+	destinationRules := initDestinationRuleVisibility(ctx)
+
+	ctx.ForEach(collections.IstioNetworkingV1Alpha3Virtualservices.Name(), func(r *resource.Instance) bool {
+		a.analyzeVirtualService(r, ctx, hosts, destinationRules)
+		return true
+	})
+}
+
+func (a *ExportToAnalyzer) analyzeVirtualService(r *resource.Instance, ctx analysis.Context,
+	hosts map[string]*visibility, destinationRules map[string]*visibility) {
+
+	vs := r.Message.(*v1alpha3.VirtualService)
+	ns := r.Metadata.FullName.Namespace
+	exported := newVisibility(ns, util.IsExportToAllNamespaces(vs.GetExportTo()))
+
+	seen := make(map[string]bool)
+	for _, d := range getRouteDestinations(vs) {
+		host := util.ConvertHostToFQDN(ns, d.GetHost())
+		if seen[host] || strings.Contains(host, util.Wildcard) {
+			continue
+		}
+		seen[host] = true
+
+		// A destination that isn't visible in the namespace of the virtual service itself is reported by the
+		// DestinationHostAnalyzer, so only the namespaces beyond it are checked here.
+		if v := lookupHost(hosts, host); v != nil && exported.all && !v.all {
+			ctx.Report(collections.IstioNetworkingV1Alpha3Virtualservices.Name(),
+				msg.NewDestinationHostNotExported(r, d.GetHost(), v.String()).WithFieldPath(d.path+".host"))
+		}
+
+		if v, ok := destinationRules[host]; ok && !v.covers(exported) {
+			ctx.Report(collections.IstioNetworkingV1Alpha3Virtualservices.Name(),
+				msg.NewDestinationRuleNotExported(r, d.GetHost(), v.String(), exported.String()).WithFieldPath(d.path+".host"))
+		}
+	}
+}
+
+// lookupHost returns the combined visibility of the services and service entries providing the host, or nil if
+// there are none.
+func lookupHost(hosts map[string]*visibility, host string) *visibility {
+	if v, ok := hosts[host]; ok {
+		return v
+	}
+
+	var result *visibility
+	for h, v := range hosts {
+		if !strings.HasPrefix(h, util.Wildcard) || !strings.HasSuffix(host, strings.TrimPrefix(h, util.Wildcard)) {
+			continue
+		}
+		if result == nil {
+			result = &visibility{namespaces: make(map[resource.Namespace]bool)}
+		}
+		result.add(v)
+	}
+	return result
+}
+
+func initHostVisibility(ctx analysis.Context) map[string]*visibility {
+	result := make(map[string]*visibility)
+
+	ctx.ForEach(collections.IstioNetworkingV1Alpha3Serviceentries.Name(), func(r *resource.Instance) bool {
+		se := r.Message.(*v1alpha3.ServiceEntry)
+		all := util.IsExportToAllNamespaces(se.GetExportTo())
+		for _, h := range se.GetHosts() {
+			addVisibility(result, util.ConvertHostToFQDN(r.Metadata.FullName.Namespace, h), newVisibility(r.Metadata.FullName.Namespace, all))
+		}
+		return true
+	})
+
+	ctx.ForEach(collections.K8SCoreV1Services.Name(), func(r *resource.Instance) bool {
+		all := true
+		if exportTo, ok := r.Metadata.Annotations[annotation.NetworkingExportTo.Name]; ok {
+			all = util.IsExportToAllNamespaces(strings.Split(exportTo, ","))
+		}
+		host := util.ConvertHostToFQDN(r.Metadata.FullName.Namespace, r.Metadata.FullName.Name.String())
+		addVisibility(result, host, newVisibility(r.Metadata.FullName.Namespace, all))
+		return true
+	})
+
+	return result
+}
+
+func initDestinationRuleVisibility(ctx analysis.Context) map[string]*visibility {
+	result := make(map[string]*visibility)
+
+	ctx.ForEach(collections.IstioNetworkingV1Alpha3Destinationrules.Name(), func(r *resource.Instance) bool {
+		dr := r.Message.(*v1alpha3.DestinationRule)
+		host := util.ConvertHostToFQDN(r.Metadata.FullName.Namespace, dr.GetHost())
+		addVisibility(result, host, newVisibility(r.Metadata.FullName.Namespace, util.IsExportToAllNamespaces(dr.GetExportTo())))
+		return true
+	})
+
+	return result
+}
+
+func addVisibility(m map[string]*visibility, host string, v *visibility) {
+	if existing, ok := m[host]; ok {
+		existing.add(v)
+		return
+	}
+	m[host] = v
+}
+
+func newVisibility(ns resource.Namespace, all bool) *visibility {
+	return &visibility{
+		all:        all,
+		namespaces: map[resource.Namespace]bool{ns: true},
+	}
+}
+
+func (v *visibility) add(other *visibility) {
+	v.all = v.all || other.all
+	for ns := range other.namespaces {
+		v.namespaces[ns] = true
+	}
+}
+
+// covers returns whether v includes every namespace of other.
+func (v *visibility) covers(other *visibility) bool {
+	if v.all {
+		return true
+	}
+	if other.all {
+		return false
+	}
+	for ns := range other.namespaces {
+		if !v.namespaces[ns] {
+			return false
+		}
+	}
+	return true
+}
+
+func (v *visibility) String() string {
+	if v.all {
+		return "all namespaces"
+	}
+	var names []string
+	for ns := range v.namespaces {
+		names = append(names, ns.String())
+	}
+	sort.Strings(names)
+	if len(names) == 1 {
+		return "namespace " + names[0]
+	}
+	return "namespaces " + strings.Join(names, ", ")
+}
@@ -0,0 +1,185 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package virtualservice
+
+import (
+	"sort"
+	"strings"
+
+	"istio.io/api/networking/v1alpha3"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/util"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+// GatewayRouteConflictAnalyzer checks for virtual services that route the same host on the same gateway with
+// overlapping HTTP match conditions. Such virtual services are merged in an undefined order, so the routes of one can
+// shadow the routes of the other.
+type GatewayRouteConflictAnalyzer struct{}
+
+var _ analysis.Analyzer = &GatewayRouteConflictAnalyzer{}
+
+// Metadata implements Analyzer
+func (a *GatewayRouteConflictAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:        "virtualservice.GatewayRouteConflictAnalyzer",
+		Description: "Checks for virtual services whose routes for the same host and gateway shadow each other",
+		Inputs: collection.Names{
+			collections.IstioNetworkingV1Alpha3Virtualservices.Name(),
+		},
+	}
+}
+
+// Analyze implements Analyzer
+func (a *GatewayRouteConflictAnalyzer) Analyze(ctx analysis.Context) {
+	byGateway := make(map[resource.FullName][]*resource.Instance)
+	ctx.ForEach(collections.IstioNetworkingV1Alpha3Virtualservices.Name(), func(r *resource.Instance) bool {
+		vs := r.Message.(*v1alpha3.VirtualService)
+		if len(vs.GetHttp()) == 0 {
+			return true
+		}
+		for _, gw := range vs.GetGateways() {
+			if gw == util.MeshGateway {
+				continue
+			}
+			name := resource.NewShortOrFullName(r.Metadata.FullName.Namespace, gw)
+			byGateway[name] = append(byGateway[name], r)
+		}
+		return true
+	})
+
+	for gw, resources := range byGateway {
+		// Collections are iterated in no particular order, so sort to keep the messages stable.
+		sort.Slice(resources, func(i, j int) bool {
+			return resources[i].Metadata.FullName.String() < resources[j].Metadata.FullName.String()
+		})
+		for i, r1 := range resources {
+			for _, r2 := range resources[i+1:] {
+				host := conflictingHost(r1.Message.(*v1alpha3.VirtualService), r2.Message.(*v1alpha3.VirtualService))
+				if host == "" {
+					continue
+				}
+				ctx.Report(collections.IstioNetworkingV1Alpha3Virtualservices.Name(),
+					msg.NewGatewayRoutesShadowed(r1, r2.Metadata.FullName.String(), host, gw.String()))
+				ctx.Report(collections.IstioNetworkingV1Alpha3Virtualservices.Name(),
+					msg.NewGatewayRoutesShadowed(r2, r1.Metadata.FullName.String(), host, gw.String()))
+			}
+		}
+	}
+}
+
+// conflictingHost returns a host that both virtual services route with overlapping match conditions, or the empty
+// string if there is none.
+func conflictingHost(vs1, vs2 *v1alpha3.VirtualService) string {
+	for _, h1 := range vs1.GetHosts() {
+		for _, h2 := range vs2.GetHosts() {
+			if hostsOverlap(h1, h2) && routesOverlap(vs1.GetHttp(), vs2.GetHttp()) {
+				return h1
+			}
+		}
+	}
+	return ""
+}
+
+func hostsOverlap(h1, h2 string) bool {
+	h1, h2 = strings.ToLower(h1), strings.ToLower(h2)
+	if h1 == h2 || h1 == util.Wildcard || h2 == util.Wildcard {
+		return true
+	}
+	if strings.HasPrefix(h1, util.Wildcard) && strings.HasSuffix(h2, h1[1:]) {
+		return true
+	}
+	return strings.HasPrefix(h2, util.Wildcard) && strings.HasSuffix(h1, h2[1:])
+}
+
+func routesOverlap(routes1, routes2 []*v1alpha3.HTTPRoute) bool {
+	for _, r1 := range routes1 {
+		for _, r2 := range routes2 {
+			if matchesOverlap(r1.GetMatch(), r2.GetMatch()) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchesOverlap returns whether a request can match both lists of match conditions. A route without match
+// conditions matches every request.
+func matchesOverlap(m1, m2 []*v1alpha3.HTTPMatchRequest) bool {
+	if len(m1) == 0 || len(m2) == 0 {
+		return true
+	}
+	for _, a := range m1 {
+		for _, b := range m2 {
+			if requestsOverlap(a, b) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// requestsOverlap returns whether a request can match both match conditions. Conditions that cannot be compared
+// statically, e.g. regular expressions, are assumed to overlap.
+func requestsOverlap(a, b *v1alpha3.HTTPMatchRequest) bool {
+	if !stringMatchesOverlap(a.GetUri(), b.GetUri()) ||
+		!stringMatchesOverlap(a.GetScheme(), b.GetScheme()) ||
+		!stringMatchesOverlap(a.GetMethod(), b.GetMethod()) ||
+		!stringMatchesOverlap(a.GetAuthority(), b.GetAuthority()) {
+		return false
+	}
+	if a.GetPort() != 0 && b.GetPort() != 0 && a.GetPort() != b.GetPort() {
+		return false
+	}
+	return keyedMatchesOverlap(a.GetHeaders(), b.GetHeaders()) &&
+		keyedMatchesOverlap(a.GetQueryParams(), b.GetQueryParams())
+}
+
+// keyedMatchesOverlap returns whether a request can match both sets of header or query parameter conditions.
+func keyedMatchesOverlap(a, b map[string]*v1alpha3.StringMatch) bool {
+	for k, va := range a {
+		if vb, ok := b[k]; ok && !stringMatchesOverlap(va, vb) {
+			return false
+		}
+	}
+	return true
+}
+
+func stringMatchesOverlap(a, b *v1alpha3.StringMatch) bool {
+	if a == nil || b == nil {
+		return true
+	}
+	switch ma := a.GetMatchType().(type) {
+	case *v1alpha3.StringMatch_Exact:
+		switch mb := b.GetMatchType().(type) {
+		case *v1alpha3.StringMatch_Exact:
+			return ma.Exact == mb.Exact
+		case *v1alpha3.StringMatch_Prefix:
+			return strings.HasPrefix(ma.Exact, mb.Prefix)
+		}
+	case *v1alpha3.StringMatch_Prefix:
+		switch mb := b.GetMatchType().(type) {
+		case *v1alpha3.StringMatch_Exact:
+			return strings.HasPrefix(mb.Exact, ma.Prefix)
+		case *v1alpha3.StringMatch_Prefix:
+			return strings.HasPrefix(ma.Prefix, mb.Prefix) || strings.HasPrefix(mb.Prefix, ma.Prefix)
+		}
+	}
+	return true
+}
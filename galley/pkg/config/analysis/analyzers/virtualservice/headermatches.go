@@ -0,0 +1,103 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package virtualservice
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"istio.io/api/networking/v1alpha3"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+// HeaderMatchAnalyzer checks the names of the headers matched by virtual services. Envoy rejects the routes of a
+// virtual service matching a header whose name is not a valid HTTP header name.
+type HeaderMatchAnalyzer struct{}
+
+var _ analysis.Analyzer = &HeaderMatchAnalyzer{}
+
+// The characters allowed in header names besides letters and digits, see the token rule of RFC 7230.
+const headerNameSymbols = "!#$%&'*+-.^_`|~"
+
+// Metadata implements Analyzer
+func (a *HeaderMatchAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:        "virtualservice.HeaderMatchAnalyzer",
+		Description: "Checks the names of matched headers",
+		Inputs: collection.Names{
+			collections.IstioNetworkingV1Alpha3Virtualservices.Name(),
+		},
+	}
+}
+
+// Analyze implements Analyzer
+func (a *HeaderMatchAnalyzer) Analyze(ctx analysis.Context) {
+	ctx.ForEach(collections.IstioNetworkingV1Alpha3Virtualservices.Name(), func(r *resource.Instance) bool {
+		vs := r.Message.(*v1alpha3.VirtualService)
+		for _, route := range vs.GetHttp() {
+			for _, m := range route.GetMatch() {
+				analyzeHeaderNames(ctx, r, m.GetHeaders(), "headers")
+				analyzeHeaderNames(ctx, r, m.GetWithoutHeaders(), "withoutHeaders")
+			}
+		}
+		return true
+	})
+}
+
+func analyzeHeaderNames(ctx analysis.Context, r *resource.Instance, headers map[string]*v1alpha3.StringMatch,
+	where string) {
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if problem := headerNameProblem(name); problem != "" {
+			ctx.Report(collections.IstioNetworkingV1Alpha3Virtualservices.Name(),
+				msg.NewInvalidHeaderName(r, where, name, problem))
+		}
+	}
+}
+
+// headerNameProblem describes why name is not a valid header name, or returns "" if it is. Pseudo-headers like
+// :authority are valid names too.
+func headerNameProblem(name string) string {
+	token := strings.TrimPrefix(name, ":")
+	if token == "" {
+		return "the name is empty"
+	}
+	for _, c := range token {
+		if !isHeaderNameChar(c) {
+			return fmt.Sprintf("%q is not allowed in header names", c)
+		}
+	}
+	return ""
+}
+
+func isHeaderNameChar(c rune) bool {
+	switch {
+	case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		return true
+	}
+	return strings.ContainsRune(headerNameSymbols, c)
+}
@@ -0,0 +1,113 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package virtualservice
+
+import (
+	"strings"
+
+	"istio.io/api/networking/v1alpha3"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/util"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/galley/pkg/config/analysis/pushed"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+// PushedRoutesAnalyzer checks virtual services against the configuration pushed to proxies, when Envoy config dumps
+// of proxies are part of the analysis. A virtual service with HTTP routes that produced no routes in any of the
+// proxies it applies to has no effect, e.g. because its hosts match no service known to the proxies, or because
+// another virtual service for the same hosts takes precedence.
+//
+// Only the proxies that config dumps were given for are considered, and only those whose type is known from the node
+// ID in their dump. TCP and TLS routes are not checked, as pilot does not record the virtual service they were
+// generated from.
+type PushedRoutesAnalyzer struct{}
+
+var _ analysis.Analyzer = &PushedRoutesAnalyzer{}
+
+// Metadata implements Analyzer
+func (a *PushedRoutesAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:        "virtualservice.PushedRoutesAnalyzer",
+		Description: "Checks that virtual services produced routes in the configuration pushed to proxies",
+		Inputs: collection.Names{
+			collections.IstioNetworkingV1Alpha3Virtualservices.Name(),
+		},
+	}
+}
+
+// Analyze implements Analyzer
+func (a *PushedRoutesAnalyzer) Analyze(ctx analysis.Context) {
+	cfg := analysis.PushedConfig(ctx)
+	if cfg == nil {
+		return
+	}
+	proxies := cfg.Proxies()
+
+	ctx.ForEach(collections.IstioNetworkingV1Alpha3Virtualservices.Name(), func(r *resource.Instance) bool {
+		vs := r.Message.(*v1alpha3.VirtualService)
+		if len(vs.GetHttp()) == 0 {
+			return true
+		}
+
+		var applies []string
+		for _, p := range proxies {
+			if appliesToProxy(r.Metadata.FullName.Namespace, vs, p) {
+				applies = append(applies, p.ID)
+			}
+		}
+		if len(applies) == 0 {
+			return true
+		}
+
+		if len(cfg.ProxiesOf(collections.IstioNetworkingV1Alpha3Virtualservices.Name(), r.Metadata.FullName)) == 0 {
+			ctx.Report(collections.IstioNetworkingV1Alpha3Virtualservices.Name(),
+				msg.NewVirtualServiceNotPushed(r, strings.Join(applies, ", ")))
+		}
+		return true
+	})
+}
+
+// appliesToProxy returns whether the virtual service in the given namespace applies to the proxy: to sidecars if it is
+// bound to the mesh gateway, to gateways if it is bound to any other gateway, and only to proxies in its own namespace
+// if it is not exported to all namespaces.
+func appliesToProxy(ns resource.Namespace, vs *v1alpha3.VirtualService, p *pushed.Proxy) bool {
+	if !util.IsExportToAllNamespaces(vs.GetExportTo()) && p.Namespace() != ns.String() {
+		return false
+	}
+
+	gateways := vs.GetGateways()
+	switch p.Type() {
+	case pushed.SidecarProxy:
+		if len(gateways) == 0 {
+			return true
+		}
+		for _, g := range gateways {
+			if g == util.MeshGateway {
+				return true
+			}
+		}
+	case pushed.RouterProxy:
+		for _, g := range gateways {
+			if g != util.MeshGateway {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -15,7 +15,10 @@
 package virtualservice
 
 import (
+	"fmt"
 	"regexp"
+	"regexp/syntax"
+	"strconv"
 
 	"istio.io/api/networking/v1alpha3"
 
@@ -27,9 +30,17 @@ import (
 )
 
 // RegexAnalyzer checks all regexes in a virtual service
-type RegexAnalyzer struct{}
+type RegexAnalyzer struct {
+	// MaxProgramSize is the largest program size of a regex that Envoy accepts. Defaults to 100, Envoy's default.
+	MaxProgramSize int
+}
 
 var _ analysis.Analyzer = &RegexAnalyzer{}
+var _ analysis.Configurable = &RegexAnalyzer{}
+
+// Envoy rejects regexes whose compiled RE2 program is larger than this, see the re2.max_program_size.error_level
+// runtime key.
+const defaultMaxProgramSize = 100
 
 // Metadata implements Analyzer
 func (a *RegexAnalyzer) Metadata() analysis.Metadata {
@@ -42,6 +53,23 @@ func (a *RegexAnalyzer) Metadata() analysis.Metadata {
 	}
 }
 
+// Configure implements analysis.Configurable. The maxProgramSize parameter sets MaxProgramSize.
+func (a *RegexAnalyzer) Configure(params map[string]string) error {
+	for k, v := range params {
+		switch k {
+		case "maxProgramSize":
+			n, err := strconv.Atoi(v)
+			if err != nil || n <= 0 {
+				return fmt.Errorf("invalid maxProgramSize %q: must be a positive integer", v)
+			}
+			a.MaxProgramSize = n
+		default:
+			return fmt.Errorf("unknown parameter %q", k)
+		}
+	}
+	return nil
+}
+
 // Analyze implements Analyzer
 func (a *RegexAnalyzer) Analyze(ctx analysis.Context) {
 	ctx.ForEach(collections.IstioNetworkingV1Alpha3Virtualservices.Name(), func(r *resource.Instance) bool {
@@ -56,35 +84,59 @@ func (a *RegexAnalyzer) analyzeVirtualService(r *resource.Instance, ctx analysis
 
 	for _, route := range vs.GetHttp() {
 		for _, m := range route.GetMatch() {
-			analyzeStringMatch(r, m.GetUri(), ctx, "uri")
-			analyzeStringMatch(r, m.GetScheme(), ctx, "scheme")
-			analyzeStringMatch(r, m.GetMethod(), ctx, "method")
-			analyzeStringMatch(r, m.GetAuthority(), ctx, "authority")
+			a.analyzeStringMatch(r, m.GetUri(), ctx, "uri")
+			a.analyzeStringMatch(r, m.GetScheme(), ctx, "scheme")
+			a.analyzeStringMatch(r, m.GetMethod(), ctx, "method")
+			a.analyzeStringMatch(r, m.GetAuthority(), ctx, "authority")
 			for _, h := range m.GetHeaders() {
-				analyzeStringMatch(r, h, ctx, "headers")
+				a.analyzeStringMatch(r, h, ctx, "headers")
 			}
 			for _, qp := range m.GetQueryParams() {
-				analyzeStringMatch(r, qp, ctx, "queryParams")
+				a.analyzeStringMatch(r, qp, ctx, "queryParams")
 			}
 			// We don't validate withoutHeaders, because they are undocumented
 		}
 		for _, origin := range route.GetCorsPolicy().GetAllowOrigins() {
-			analyzeStringMatch(r, origin, ctx, "corsPolicy.allowOrigins")
+			a.analyzeStringMatch(r, origin, ctx, "corsPolicy.allowOrigins")
 		}
 	}
 }
 
-func analyzeStringMatch(r *resource.Instance, sm *v1alpha3.StringMatch, ctx analysis.Context, where string) {
+func (a *RegexAnalyzer) analyzeStringMatch(r *resource.Instance, sm *v1alpha3.StringMatch, ctx analysis.Context,
+	where string) {
+
 	re := sm.GetRegex()
 	if re == "" {
 		return
 	}
 
 	_, err := regexp.Compile(re)
-	if err == nil {
+	if err != nil {
+		ctx.Report(collections.IstioNetworkingV1Alpha3Virtualservices.Name(),
+			msg.NewInvalidRegexp(r, where, re, err.Error()))
 		return
 	}
 
-	ctx.Report(collections.IstioNetworkingV1Alpha3Virtualservices.Name(),
-		msg.NewInvalidRegexp(r, where, re, err.Error()))
+	maxSize := a.MaxProgramSize
+	if maxSize == 0 {
+		maxSize = defaultMaxProgramSize
+	}
+	if size := programSize(re); size > maxSize {
+		ctx.Report(collections.IstioNetworkingV1Alpha3Virtualservices.Name(),
+			msg.NewRegexTooComplex(r, where, re, size, maxSize))
+	}
+}
+
+// programSize returns the number of instructions of the compiled regex, which approximates the program size RE2
+// computes for it. Counted repetitions like a{1,64} are expanded, so they make programs grow quickly.
+func programSize(re string) int {
+	parsed, err := syntax.Parse(re, syntax.Perl)
+	if err != nil {
+		return 0
+	}
+	prog, err := syntax.Compile(parsed.Simplify())
+	if err != nil {
+		return 0
+	}
+	return len(prog.Inst)
 }
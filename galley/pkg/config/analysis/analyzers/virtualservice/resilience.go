@@ -0,0 +1,221 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package virtualservice
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"istio.io/api/networking/v1alpha3"
+	v1 "k8s.io/api/core/v1"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/util"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	configKube "istio.io/istio/pkg/config/kube"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+// ResilienceAnalyzer checks combinations of the timeouts, retries and fault injection of virtual service routes that
+// are valid, but break traffic: per-try timeouts that are not shorter than the route timeout, retries of
+// non-idempotent requests after they may have reached the destination, gRPC retry conditions for destinations that
+// do not serve gRPC, and faults injected into all traffic of the mesh to wildcard hosts.
+//
+// Fault percentages out of bounds are checked by the schema.BoundsAnalyzer, and mirror destinations that do not exist
+// by the DestinationHostAnalyzer.
+type ResilienceAnalyzer struct{}
+
+var _ analysis.Analyzer = &ResilienceAnalyzer{}
+
+// Methods that are not idempotent, so that processing a request more than once has a different effect than
+// processing it once.
+var nonIdempotentMethods = map[string]bool{
+	http.MethodPost:    true,
+	http.MethodPatch:   true,
+	http.MethodConnect: true,
+}
+
+// Retry conditions that only apply if the destination has not received the request, so that retrying is safe for all
+// methods.
+var safeRetryConditions = map[string]bool{
+	"connect-failure": true,
+	"refused-stream":  true,
+}
+
+// Retry conditions that check the gRPC status of responses.
+var grpcRetryConditions = map[string]bool{
+	"cancelled":          true,
+	"deadline-exceeded":  true,
+	"internal":           true,
+	"resource-exhausted": true,
+	"unavailable":        true,
+}
+
+// Metadata implements Analyzer
+func (a *ResilienceAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:        "virtualservice.ResilienceAnalyzer",
+		Description: "Checks combinations of route timeouts, retries and fault injection that break traffic",
+		Inputs: collection.Names{
+			collections.IstioNetworkingV1Alpha3Virtualservices.Name(),
+			collections.K8SCoreV1Services.Name(),
+		},
+	}
+}
+
+// Analyze implements Analyzer
+func (a *ResilienceAnalyzer) Analyze(ctx analysis.Context) {
+	ctx.ForEach(collections.IstioNetworkingV1Alpha3Virtualservices.Name(), func(r *resource.Instance) bool {
+		vs := r.Message.(*v1alpha3.VirtualService)
+		for i, route := range vs.GetHttp() {
+			name := routeName(route, i)
+			path := fmt.Sprintf("spec.http[%d]", i)
+			a.analyzeTimeouts(ctx, r, name, path, route)
+			a.analyzeRetryConditions(ctx, r, name, path, route)
+			a.analyzeFault(ctx, r, name, path, vs, route)
+		}
+		return true
+	})
+}
+
+func (a *ResilienceAnalyzer) analyzeTimeouts(ctx analysis.Context, r *resource.Instance, name, path string,
+	route *v1alpha3.HTTPRoute) {
+
+	timeout := route.GetTimeout()
+	perTry := route.GetRetries().GetPerTryTimeout()
+	// A timeout of 0s disables the route timeout.
+	if timeout == nil || (timeout.Seconds == 0 && timeout.Nanos == 0) || perTry == nil ||
+		route.GetRetries().GetAttempts() == 0 {
+		return
+	}
+	if !longer(timeout, perTry) {
+		ctx.Report(collections.IstioNetworkingV1Alpha3Virtualservices.Name(),
+			msg.NewPerTryTimeoutNotShorterThanTimeout(r, name, duration(perTry).String(), duration(timeout).String()).
+				WithFieldPath(path+".retries.perTryTimeout"))
+	}
+}
+
+func (a *ResilienceAnalyzer) analyzeRetryConditions(ctx analysis.Context, r *resource.Instance, name, path string,
+	route *v1alpha3.HTTPRoute) {
+
+	retries := route.GetRetries()
+	if retries.GetAttempts() == 0 || retries.GetRetryOn() == "" {
+		return
+	}
+
+	var unsafe, grpc []string
+	for _, c := range strings.Split(retries.GetRetryOn(), ",") {
+		c = strings.TrimSpace(c)
+		if !safeRetryConditions[c] {
+			unsafe = append(unsafe, c)
+		}
+		if grpcRetryConditions[c] {
+			grpc = append(grpc, c)
+		}
+	}
+
+	if methods := matchedNonIdempotentMethods(route); len(methods) > 0 && len(unsafe) > 0 {
+		ctx.Report(collections.IstioNetworkingV1Alpha3Virtualservices.Name(),
+			msg.NewNonIdempotentRetry(r, name, strings.Join(methods, ", "), strings.Join(unsafe, ", ")).
+				WithFieldPath(path+".retries.retryOn"))
+	}
+
+	if len(grpc) == 0 {
+		return
+	}
+	for _, rd := range route.GetRoute() {
+		dest := rd.GetDestination()
+		if servesHTTP2(ctx, r.Metadata.FullName.Namespace, dest) {
+			continue
+		}
+		ctx.Report(collections.IstioNetworkingV1Alpha3Virtualservices.Name(),
+			msg.NewGrpcRetryOnNonGrpcDestination(r, name, strings.Join(grpc, ", "), dest.GetHost()).
+				WithFieldPath(path+".retries.retryOn"))
+	}
+}
+
+func (a *ResilienceAnalyzer) analyzeFault(ctx analysis.Context, r *resource.Instance, name, path string,
+	vs *v1alpha3.VirtualService, route *v1alpha3.HTTPRoute) {
+
+	if route.GetFault() == nil || len(route.GetMatch()) > 0 || !appliesToMesh(vs) {
+		return
+	}
+
+	var wildcards []string
+	for _, h := range vs.GetHosts() {
+		if strings.Contains(h, util.Wildcard) {
+			wildcards = append(wildcards, h)
+		}
+	}
+	if len(wildcards) > 0 {
+		ctx.Report(collections.IstioNetworkingV1Alpha3Virtualservices.Name(),
+			msg.NewMeshWideFaultInjection(r, name, strings.Join(wildcards, ", ")).WithFieldPath(path+".fault"))
+	}
+}
+
+// matchedNonIdempotentMethods returns the non-idempotent methods that the route matches exactly.
+func matchedNonIdempotentMethods(route *v1alpha3.HTTPRoute) []string {
+	found := make(map[string]bool)
+	for _, m := range route.GetMatch() {
+		if method := strings.ToUpper(m.GetMethod().GetExact()); nonIdempotentMethods[method] {
+			found[method] = true
+		}
+	}
+
+	result := make([]string, 0, len(found))
+	for method := range found {
+		result = append(result, method)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// servesHTTP2 returns whether the destination may serve gRPC, which requires HTTP/2. It returns true unless the
+// destination is a Kubernetes service whose protocol on the destination port is known not to be HTTP/2.
+func servesHTTP2(ctx analysis.Context, ns resource.Namespace, dest *v1alpha3.Destination) bool {
+	svc := ctx.Find(collections.K8SCoreV1Services.Name(), util.GetResourceNameFromHost(ns, dest.GetHost()))
+	if svc == nil {
+		return true
+	}
+	matched := false
+	for _, port := range svc.Message.(*v1.ServiceSpec).Ports {
+		if dest.GetPort() != nil && uint32(port.Port) != dest.GetPort().GetNumber() {
+			continue
+		}
+		matched = true
+		p := configKube.ConvertProtocol(port.Port, port.Name, port.Protocol, port.AppProtocol)
+		if p.IsHTTP2() || p.IsUnsupported() {
+			return true
+		}
+	}
+	return !matched
+}
+
+// appliesToMesh returns whether the virtual service applies to the sidecars of the mesh.
+func appliesToMesh(vs *v1alpha3.VirtualService) bool {
+	if len(vs.GetGateways()) == 0 {
+		return true
+	}
+	for _, gw := range vs.GetGateways() {
+		if gw == util.MeshGateway {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,145 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package virtualservice
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gogo/protobuf/types"
+
+	"istio.io/api/networking/v1alpha3"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/util"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+// TimeoutAnalyzer checks the timeouts of virtual service routes, for a timeout of 0s, whose relation to the default
+// timeout depends on the Istio version, and for timeouts that do not leave time for the connect timeout of the
+// destination to expire.
+type TimeoutAnalyzer struct{}
+
+var _ analysis.Analyzer = &TimeoutAnalyzer{}
+
+// The version in which routes stopped having a default timeout of 15s.
+const noDefaultTimeoutVersion = "1.6"
+
+// Metadata implements Analyzer
+func (t *TimeoutAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:        "virtualservice.TimeoutAnalyzer",
+		Description: "Checks the timeouts of virtual service routes",
+		Inputs: collection.Names{
+			collections.IstioNetworkingV1Alpha3Virtualservices.Name(),
+			collections.IstioNetworkingV1Alpha3Destinationrules.Name(),
+		},
+	}
+}
+
+// Analyze implements Analyzer
+func (t *TimeoutAnalyzer) Analyze(ctx analysis.Context) {
+	zeroBehavior := zeroTimeoutBehavior(analysis.IstioVersion(ctx))
+
+	destinationRules := make(map[resource.FullName]*resource.Instance)
+	ctx.ForEach(collections.IstioNetworkingV1Alpha3Destinationrules.Name(), func(r *resource.Instance) bool {
+		dr := r.Message.(*v1alpha3.DestinationRule)
+		destinationRules[util.GetResourceNameFromHost(r.Metadata.FullName.Namespace, dr.GetHost())] = r
+		return true
+	})
+
+	ctx.ForEach(collections.IstioNetworkingV1Alpha3Virtualservices.Name(), func(r *resource.Instance) bool {
+		vs := r.Message.(*v1alpha3.VirtualService)
+		ns := r.Metadata.FullName.Namespace
+
+		for i, route := range vs.GetHttp() {
+			timeout := route.GetTimeout()
+			if timeout == nil {
+				continue
+			}
+			name := routeName(route, i)
+
+			if timeout.Seconds == 0 && timeout.Nanos == 0 {
+				ctx.Report(collections.IstioNetworkingV1Alpha3Virtualservices.Name(),
+					msg.NewZeroRouteTimeout(r, name, zeroBehavior))
+				continue
+			}
+
+			for _, rd := range route.GetRoute() {
+				dest := rd.GetDestination()
+				drResource, ok := destinationRules[util.GetResourceNameFromHost(ns, dest.GetHost())]
+				if !ok {
+					continue
+				}
+				t.checkConnectionPool(ctx, r, name, timeout, drResource, dest.GetSubset())
+			}
+		}
+		return true
+	})
+}
+
+func (t *TimeoutAnalyzer) checkConnectionPool(ctx analysis.Context, r *resource.Instance, route string, timeout *types.Duration,
+	drResource *resource.Instance, subset string) {
+
+	dr := drResource.Message.(*v1alpha3.DestinationRule)
+	pool := dr.GetTrafficPolicy().GetConnectionPool()
+	for _, s := range dr.GetSubsets() {
+		if s.GetName() == subset && s.GetTrafficPolicy().GetConnectionPool() != nil {
+			pool = s.GetTrafficPolicy().GetConnectionPool()
+		}
+	}
+
+	// A route timeout longer than the connect timeout is the normal case, it leaves time to retry a failed connection.
+	if connect := pool.GetTcp().GetConnectTimeout(); connect != nil && !longer(timeout, connect) {
+		ctx.Report(collections.IstioNetworkingV1Alpha3Virtualservices.Name(),
+			msg.NewRouteTimeoutNotLongerThanConnectTimeout(r, route, duration(timeout).String(),
+				duration(connect).String(), drResource.Metadata.FullName.String()))
+	}
+}
+
+// zeroTimeoutBehavior explains the effect of a 0s route timeout compared to leaving the timeout unset, in the given
+// Istio version.
+func zeroTimeoutBehavior(version string) string {
+	if version != "" {
+		if c, err := analysis.CompareVersion(version, noDefaultTimeoutVersion); err == nil {
+			if c < 0 {
+				return fmt.Sprintf("In Istio %s, routes without a timeout use a default of 15s instead.", version)
+			}
+			return fmt.Sprintf("In Istio %s, this is the same as leaving the timeout unset, as routes have no default timeout.", version)
+		}
+	}
+	return fmt.Sprintf("Before Istio %s, routes without a timeout used a default of 15s; since then they have no default "+
+		"timeout, and 0s is the same as leaving the timeout unset.", noDefaultTimeoutVersion)
+}
+
+func routeName(route *v1alpha3.HTTPRoute, index int) string {
+	if route.GetName() != "" {
+		return fmt.Sprintf("%q", route.GetName())
+	}
+	return fmt.Sprintf("http[%d]", index)
+}
+
+func longer(a, b *types.Duration) bool {
+	return duration(a) > duration(b)
+}
+
+// duration converts a validated duration, so that conversion errors can be ignored.
+func duration(d *types.Duration) time.Duration {
+	result, _ := types.DurationFromProto(d)
+	return result
+}
@@ -0,0 +1,138 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package virtualservice
+
+import (
+	"fmt"
+	"strings"
+
+	"istio.io/api/networking/v1alpha3"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/util"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/gateway"
+	"istio.io/istio/pkg/config/host"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+// TLSRouteAnalyzer checks the matches of virtual service TLS routes. Routes whose matches are missing SNI hosts, and
+// routes bound to gateways that have no TLS passthrough server for them, are silently dropped by the proxies.
+type TLSRouteAnalyzer struct{}
+
+var _ analysis.Analyzer = &TLSRouteAnalyzer{}
+
+// Metadata implements Analyzer
+func (t *TLSRouteAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:        "virtualservice.TLSRouteAnalyzer",
+		Description: "Checks the matches of virtual service TLS routes",
+		Inputs: collection.Names{
+			collections.IstioNetworkingV1Alpha3Gateways.Name(),
+			collections.IstioNetworkingV1Alpha3Virtualservices.Name(),
+		},
+	}
+}
+
+// Analyze implements Analyzer
+func (t *TLSRouteAnalyzer) Analyze(c analysis.Context) {
+	c.ForEach(collections.IstioNetworkingV1Alpha3Virtualservices.Name(), func(r *resource.Instance) bool {
+		t.analyzeVirtualService(r, c)
+		return true
+	})
+}
+
+func (t *TLSRouteAnalyzer) analyzeVirtualService(r *resource.Instance, c analysis.Context) {
+	vs := r.Message.(*v1alpha3.VirtualService)
+	vsNs := r.Metadata.FullName.Namespace
+
+	for i, route := range vs.GetTls() {
+		name := fmt.Sprintf("tls[%d]", i)
+
+		for _, match := range route.GetMatch() {
+			if len(match.GetSniHosts()) == 0 {
+				c.Report(collections.IstioNetworkingV1Alpha3Virtualservices.Name(), msg.NewTLSRouteMissingSNIHosts(r, name))
+				continue
+			}
+
+			gateways := match.GetGateways()
+			if len(gateways) == 0 {
+				gateways = vs.GetGateways()
+			}
+			for _, gwName := range gateways {
+				// Sidecars accept TLS routes on any port
+				if gwName == util.MeshGateway {
+					continue
+				}
+				gwResource := c.Find(collections.IstioNetworkingV1Alpha3Gateways.Name(), resource.NewShortOrFullName(vsNs, gwName))
+				if gwResource == nil {
+					// Reported by the GatewayAnalyzer
+					continue
+				}
+				t.checkGatewayServers(c, r, name, match, gwResource)
+			}
+		}
+	}
+}
+
+func (t *TLSRouteAnalyzer) checkGatewayServers(c analysis.Context, r *resource.Instance, route string,
+	match *v1alpha3.TLSMatchAttributes, gwResource *resource.Instance) {
+
+	gw := gwResource.Message.(*v1alpha3.Gateway)
+	gwName := gwResource.Metadata.FullName.String()
+
+	var servers []*v1alpha3.Server
+	for _, s := range gw.GetServers() {
+		if match.GetPort() != 0 && s.GetPort().GetNumber() != match.GetPort() {
+			continue
+		}
+		if gateway.IsPassThroughServer(s) {
+			servers = append(servers, s)
+		}
+	}
+	if len(servers) == 0 {
+		port := ""
+		if match.GetPort() != 0 {
+			port = fmt.Sprintf(" on port %d", match.GetPort())
+		}
+		c.Report(collections.IstioNetworkingV1Alpha3Virtualservices.Name(),
+			msg.NewTLSRouteNotOnPassthroughServer(r, route, gwName, port))
+		return
+	}
+
+	for _, sni := range match.GetSniHosts() {
+		if !serversAcceptHost(servers, sni) {
+			c.Report(collections.IstioNetworkingV1Alpha3Virtualservices.Name(),
+				msg.NewSNIHostNotInGatewayServer(r, route, sni, gwName))
+		}
+	}
+}
+
+func serversAcceptHost(servers []*v1alpha3.Server, sni string) bool {
+	for _, s := range servers {
+		for _, h := range s.GetHosts() {
+			// Server hosts may be qualified with the namespace of the virtual services they accept
+			if i := strings.Index(h, "/"); i >= 0 {
+				h = h[i+1:]
+			}
+			if host.Name(sni).Matches(host.Name(h)) {
+				return true
+			}
+		}
+	}
+	return false
+}
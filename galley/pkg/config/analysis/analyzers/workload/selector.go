@@ -0,0 +1,163 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workload
+
+import (
+	"fmt"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/labels"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/util"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+// SelectorAnalyzer checks the workload selectors of Gateways, Sidecars, EnvoyFilters and security policies for
+// consistency with the pods they select. Selectors scoped to a namespace, and those of Gateways, should match the pods
+// of a single application, and at most one workload-level PeerAuthentication should select each pod, as only one of
+// them applies. PeerAuthentication selectors that match no pod are reported too; the analyzers of the other kinds
+// already cover theirs.
+type SelectorAnalyzer struct{}
+
+var _ analysis.Analyzer = &SelectorAnalyzer{}
+
+// selectorCollections are the collections of the resources that select workloads.
+var selectorCollections = []collection.Name{
+	collections.IstioNetworkingV1Alpha3Gateways.Name(),
+	collections.IstioNetworkingV1Alpha3Sidecars.Name(),
+	collections.IstioNetworkingV1Alpha3Envoyfilters.Name(),
+	collections.IstioSecurityV1Beta1Authorizationpolicies.Name(),
+	collections.IstioSecurityV1Beta1Peerauthentications.Name(),
+	collections.IstioSecurityV1Beta1Requestauthentications.Name(),
+}
+
+// Metadata implements Analyzer
+func (a *SelectorAnalyzer) Metadata() analysis.Metadata {
+	inputs := collection.Names{
+		collections.IstioMeshV1Alpha1MeshConfig.Name(),
+		collections.K8SCoreV1Pods.Name(),
+	}
+	inputs = append(inputs, selectorCollections...)
+
+	return analysis.Metadata{
+		Name: "workload.SelectorAnalyzer",
+		Description: "Checks that workload selectors match the pods of a single application, and that no pod is " +
+			"selected by conflicting peer authentications",
+		Inputs: inputs,
+	}
+}
+
+// Analyze implements Analyzer
+func (a *SelectorAnalyzer) Analyze(c analysis.Context) {
+	rootNamespace := resource.Namespace(analysis.MeshConfig(c).GetRootNamespace())
+
+	for _, col := range selectorCollections {
+		c.ForEach(col, func(r *resource.Instance) bool {
+			selector := util.WorkloadSelector(r)
+			if len(selector) == 0 {
+				return true
+			}
+
+			ns := util.WorkloadSelectorNamespace(r, rootNamespace)
+			selected := util.SelectPods(c, ns, selector)
+			sel := labels.SelectorFromSet(selector).String()
+			if len(selected) == 0 {
+				if col == collections.IstioSecurityV1Beta1Peerauthentications.Name() {
+					c.Report(col, msg.NewUnmatchedWorkloadSelector(r, sel, util.ClosestMatch(util.SelectPods(c, ns, nil), selector)))
+				}
+				return true
+			}
+
+			// Policies in the root namespace are meant to apply across applications.
+			if ns == "" && col != collections.IstioNetworkingV1Alpha3Gateways.Name() {
+				return true
+			}
+			if apps := appNames(selected); len(apps) > 1 {
+				c.Report(col, msg.NewWorkloadSelectorSpansApplications(r, sel, apps))
+			}
+			return true
+		})
+	}
+
+	a.analyzePeerAuthentications(c)
+}
+
+// analyzePeerAuthentications reports workload-level peer authentications that select the same pod. Each set of
+// conflicting policies is reported once, for the first pod they select.
+func (a *SelectorAnalyzer) analyzePeerAuthentications(c analysis.Context) {
+	podsToPolicies := make(map[resource.FullName][]*resource.Instance)
+	c.ForEach(collections.IstioSecurityV1Beta1Peerauthentications.Name(), func(r *resource.Instance) bool {
+		selector := util.WorkloadSelector(r)
+		if len(selector) == 0 {
+			return true
+		}
+		for _, p := range util.SelectPods(c, r.Metadata.FullName.Namespace, selector) {
+			podsToPolicies[p.Metadata.FullName] = append(podsToPolicies[p.Metadata.FullName], r)
+		}
+		return true
+	})
+
+	podNames := make([]resource.FullName, 0, len(podsToPolicies))
+	for p := range podsToPolicies {
+		podNames = append(podNames, p)
+	}
+	sort.Slice(podNames, func(i, j int) bool {
+		return podNames[i].String() < podNames[j].String()
+	})
+
+	reported := make(map[string]bool)
+	for _, p := range podNames {
+		policies := podsToPolicies[p]
+		if len(policies) < 2 {
+			continue
+		}
+
+		names := make([]string, 0, len(policies))
+		for _, r := range policies {
+			names = append(names, r.Metadata.FullName.Name.String())
+		}
+		sort.Strings(names)
+
+		key := p.Namespace.String() + "/" + fmt.Sprint(names)
+		if reported[key] {
+			continue
+		}
+		reported[key] = true
+
+		for _, r := range policies {
+			c.Report(collections.IstioSecurityV1Beta1Peerauthentications.Name(),
+				msg.NewConflictingPeerAuthentications(r, names, p.String()))
+		}
+	}
+}
+
+// appNames returns the sorted, distinct application names of the given pods.
+func appNames(pods []*resource.Instance) []string {
+	seen := make(map[string]bool)
+	var apps []string
+	for _, p := range pods {
+		app := util.AppName(p)
+		if !seen[app] {
+			seen[app] = true
+			apps = append(apps, app)
+		}
+	}
+	sort.Strings(apps)
+	return apps
+}
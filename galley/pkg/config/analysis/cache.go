@@ -0,0 +1,306 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package analysis
+
+import (
+	"context"
+	"encoding/binary"
+	"hash/fnv"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/gogo/protobuf/proto"
+
+	"istio.io/api/mesh/v1alpha1"
+
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+	"istio.io/istio/galley/pkg/config/analysis/pushed"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+)
+
+// ResultCache memoizes the messages reported by each analyzer, keyed by a hash of the resource versions in the
+// analyzer's input collections. When used across repeated analysis runs (e.g. in continuous analysis), analyzers
+// whose inputs did not change return their previous results without being executed again. IncrementalAnalyzers whose
+// other inputs did not change only re-evaluate the changed resources of their incremental input.
+//
+// The digests of the collections are computed once per snapshot and shared by all analyzers, if the Context is an
+// IndexProvider. Analyzers whose Metadata is TimeDependent are always executed.
+type ResultCache struct {
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+
+	hits   int64
+	misses int64
+}
+
+type cacheEntry struct {
+	key     uint64
+	reports []report
+
+	// The results of an IncrementalAnalyzer, by resource of its incremental input
+	resources map[resource.FullName]resourceEntry
+}
+
+type resourceEntry struct {
+	hash    uint64
+	reports []report
+}
+
+type report struct {
+	col collection.Name
+	m   diag.Message
+}
+
+// NewResultCache returns a new, empty ResultCache.
+func NewResultCache() *ResultCache {
+	return &ResultCache{
+		entries: make(map[string]cacheEntry),
+	}
+}
+
+// Stats returns the number of cache hits and misses so far.
+func (rc *ResultCache) Stats() (hits, misses int64) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return rc.hits, rc.misses
+}
+
+// analyze runs the given analyzer, or replays its cached results if its inputs are unchanged. The analyzer is given
+// the context returned by wrap, so that whatever the wrapping context reports is cached as well.
+func (rc *ResultCache) analyze(a Analyzer, ctx Context, wrap func(Context) Context) {
+	if a.Metadata().TimeDependent {
+		a.Analyze(wrap(ctx))
+		return
+	}
+	if ia, ok := a.(IncrementalAnalyzer); ok {
+		rc.analyzeIncrementally(ia, ctx, wrap)
+		return
+	}
+
+	name := a.Metadata().Name
+	key, ok := inputKey(ctx, a.Metadata().Inputs)
+	if !ok {
+		a.Analyze(wrap(ctx))
+		return
+	}
+
+	rc.mu.Lock()
+	e, found := rc.entries[name]
+	if found && e.key == key {
+		rc.hits++
+		rc.mu.Unlock()
+		for _, r := range e.reports {
+			ctx.Report(r.col, r.m)
+		}
+		return
+	}
+	rc.misses++
+	rc.mu.Unlock()
+
+	rctx := &recordingContext{Context: ctx}
+	a.Analyze(wrap(rctx))
+
+	// Partial results of a canceled run must not be cached.
+	if ctx.Canceled() {
+		return
+	}
+
+	rc.mu.Lock()
+	rc.entries[name] = cacheEntry{key: key, reports: rctx.reports}
+	rc.mu.Unlock()
+}
+
+// analyzeIncrementally runs the given incremental analyzer on the resources of its incremental input that changed
+// since the previous run, and replays the cached results of the others. All resources are analyzed if the other
+// inputs changed.
+func (rc *ResultCache) analyzeIncrementally(a IncrementalAnalyzer, ctx Context, wrap func(Context) Context) {
+	name := a.Metadata().Name
+	incremental := a.IncrementalInput()
+	var others collection.Names
+	for _, in := range a.Metadata().Inputs {
+		if in != incremental {
+			others = append(others, in)
+		}
+	}
+
+	key, ok := inputKey(ctx, others)
+	d := digest(ctx, incremental)
+	if !ok || d == nil {
+		a.Analyze(wrap(ctx))
+		return
+	}
+
+	rc.mu.Lock()
+	previous := rc.entries[name]
+	rc.mu.Unlock()
+	if previous.key != key {
+		previous.resources = nil
+	}
+
+	resources := make(map[resource.FullName]resourceEntry, len(d.resources))
+	rctx := &recordingContext{Context: ctx}
+	wrapped := wrap(rctx)
+	changed := 0
+	wrap(ctx).ForEach(incremental, func(r *resource.Instance) bool {
+		hash := d.resources[r.Metadata.FullName]
+		if e, found := previous.resources[r.Metadata.FullName]; found && e.hash == hash {
+			for _, rep := range e.reports {
+				ctx.Report(rep.col, rep.m)
+			}
+			resources[r.Metadata.FullName] = e
+			return true
+		}
+
+		rctx.reports = nil
+		a.AnalyzeResource(wrapped, r)
+		resources[r.Metadata.FullName] = resourceEntry{hash: hash, reports: rctx.reports}
+		changed++
+		return !ctx.Canceled()
+	})
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if changed == 0 && len(resources) == len(previous.resources) {
+		rc.hits++
+	} else {
+		rc.misses++
+	}
+
+	// Partial results of a canceled run must not be cached.
+	if !ctx.Canceled() {
+		rc.entries[name] = cacheEntry{key: key, resources: resources}
+	}
+}
+
+// inputKey computes an order-independent hash of the resources in the given collections. Resources without a version
+// are hashed by content. The second return value is false if the key could not be computed.
+func inputKey(ctx Context, inputs collection.Names) (uint64, bool) {
+	names := make([]string, 0, len(inputs))
+	for _, in := range inputs {
+		names = append(names, in.String())
+	}
+	sort.Strings(names)
+
+	h := fnv.New64a()
+	for _, n := range names {
+		d := digest(ctx, collection.NewName(n))
+		if d == nil {
+			return 0, false
+		}
+		_, _ = h.Write([]byte(n))
+		writeUint64(h, d.sum)
+		writeUint64(h, uint64(len(d.resources)))
+	}
+	return h.Sum64(), true
+}
+
+// collectionDigest holds the hashes of the resources of a collection.
+type collectionDigest struct {
+	sum       uint64
+	resources map[resource.FullName]uint64
+}
+
+// digest returns the digest of the collection, which is shared by all analyzers using the same Context if it is an
+// IndexProvider. Nil is returned if a resource can't be hashed.
+func digest(ctx Context, col collection.Name) *collectionDigest {
+	return Index(ctx, "analysis.ResultCache/"+col.String(), func() interface{} {
+		d := &collectionDigest{resources: make(map[resource.FullName]uint64)}
+		ok := true
+		ctx.ForEach(col, func(r *resource.Instance) bool {
+			rh := fnv.New64a()
+			_, _ = rh.Write([]byte(r.Metadata.FullName.String()))
+			if r.Metadata.Version != "" {
+				_, _ = rh.Write([]byte(r.Metadata.Version))
+			} else {
+				b, err := proto.Marshal(r.Message)
+				if err != nil {
+					ok = false
+					return false
+				}
+				_, _ = rh.Write(b)
+			}
+			d.sum += rh.Sum64()
+			d.resources[r.Metadata.FullName] = rh.Sum64()
+			return true
+		})
+		if !ok {
+			return (*collectionDigest)(nil)
+		}
+		return d
+	}).(*collectionDigest)
+}
+
+func writeUint64(w io.Writer, v uint64) {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], v)
+	_, _ = w.Write(b[:])
+}
+
+// recordingContext is a Context that records all the messages reported through it.
+type recordingContext struct {
+	Context
+	reports []report
+}
+
+// Report implements Context
+func (c *recordingContext) Report(col collection.Name, m diag.Message) {
+	c.reports = append(c.reports, report{col: col, m: m})
+	c.Context.Report(col, m)
+}
+
+// Index implements IndexProvider
+func (c *recordingContext) Index(key string, build func() interface{}) interface{} {
+	return Index(c.Context, key, build)
+}
+
+// GoContext implements GoContextProvider
+func (c *recordingContext) GoContext() context.Context {
+	return GoContext(c.Context)
+}
+
+// IstioVersion implements VersionProvider
+func (c *recordingContext) IstioVersion() string {
+	return IstioVersion(c.Context)
+}
+
+// Clusters implements ClusterProvider
+func (c *recordingContext) Clusters() []string {
+	return Clusters(c.Context)
+}
+
+// ResourceClusters implements ClusterProvider
+func (c *recordingContext) ResourceClusters(col collection.Name, name resource.FullName) []string {
+	return ResourceClusters(c.Context, col, name)
+}
+
+// ClusterMeshNetworks implements ClusterProvider
+func (c *recordingContext) ClusterMeshNetworks(cluster string) *v1alpha1.MeshNetworks {
+	return ClusterMeshNetworks(c.Context, cluster)
+}
+
+// PushedConfig implements PushedConfigProvider
+func (c *recordingContext) PushedConfig() *pushed.Config {
+	return PushedConfig(c.Context)
+}
+
+func (c *recordingContext) messages() diag.Messages {
+	result := make(diag.Messages, 0, len(c.reports))
+	for _, r := range c.reports {
+		result = append(result, r.m)
+	}
+	return result
+}
@@ -0,0 +1,295 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package analysis
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gogo/protobuf/types"
+	. "github.com/onsi/gomega"
+
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+)
+
+var testMessageType = diag.NewMessageType(diag.Warning, "TEST0001", "test message")
+
+type resourceContext struct {
+	resources map[collection.Name][]*resource.Instance
+	reports   []diag.Message
+}
+
+// Report implements Context
+func (ctx *resourceContext) Report(_ collection.Name, m diag.Message) {
+	ctx.reports = append(ctx.reports, m)
+}
+
+// Find implements Context
+func (ctx *resourceContext) Find(collection.Name, resource.FullName) *resource.Instance { return nil }
+
+// Exists implements Context
+func (ctx *resourceContext) Exists(collection.Name, resource.FullName) bool { return false }
+
+// ForEach implements Context
+func (ctx *resourceContext) ForEach(c collection.Name, fn IteratorFn) {
+	for _, r := range ctx.resources[c] {
+		if !fn(r) {
+			return
+		}
+	}
+}
+
+// Canceled implements Context
+func (ctx *resourceContext) Canceled() bool { return false }
+
+type reportingAnalyzer struct {
+	name   string
+	inputs collection.Names
+	runs   int
+}
+
+// Metadata implements Analyzer
+func (a *reportingAnalyzer) Metadata() Metadata {
+	return Metadata{
+		Name:   a.name,
+		Inputs: a.inputs,
+	}
+}
+
+// Analyze implements Analyzer
+func (a *reportingAnalyzer) Analyze(ctx Context) {
+	a.runs++
+	for _, in := range a.inputs {
+		ctx.ForEach(in, func(r *resource.Instance) bool {
+			ctx.Report(in, diag.NewMessage(testMessageType, r))
+			return true
+		})
+	}
+}
+
+// incrementalAnalyzer reports a message on each resource of its first input.
+type incrementalAnalyzer struct {
+	reportingAnalyzer
+	analyzed []string
+}
+
+var _ IncrementalAnalyzer = &incrementalAnalyzer{}
+
+// Analyze implements Analyzer
+func (a *incrementalAnalyzer) Analyze(ctx Context) {
+	ctx.ForEach(a.IncrementalInput(), func(r *resource.Instance) bool {
+		a.AnalyzeResource(ctx, r)
+		return true
+	})
+}
+
+// IncrementalInput implements IncrementalAnalyzer
+func (a *incrementalAnalyzer) IncrementalInput() collection.Name {
+	return a.inputs[0]
+}
+
+// AnalyzeResource implements IncrementalAnalyzer
+func (a *incrementalAnalyzer) AnalyzeResource(ctx Context, r *resource.Instance) {
+	a.analyzed = append(a.analyzed, string(r.Metadata.FullName.Name))
+	ctx.Report(a.inputs[0], diag.NewMessage(testMessageType, r))
+}
+
+// expiryAnalyzer reports a message on each resource of its input once the clock passes notAfter, like the analyzers
+// of certificate expiry do.
+type expiryAnalyzer struct {
+	reportingAnalyzer
+	notAfter time.Time
+	now      time.Time
+}
+
+// Metadata implements Analyzer
+func (a *expiryAnalyzer) Metadata() Metadata {
+	m := a.reportingAnalyzer.Metadata()
+	m.TimeDependent = true
+	return m
+}
+
+// Analyze implements Analyzer
+func (a *expiryAnalyzer) Analyze(ctx Context) {
+	a.runs++
+	if !a.now.After(a.notAfter) {
+		return
+	}
+	ctx.ForEach(a.inputs[0], func(r *resource.Instance) bool {
+		ctx.Report(a.inputs[0], diag.NewMessage(testMessageType, r))
+		return true
+	})
+}
+
+func newInstance(name string, version resource.Version) *resource.Instance {
+	return &resource.Instance{
+		Metadata: resource.Metadata{
+			FullName: resource.NewFullName("ns", resource.LocalName(name)),
+			Version:  version,
+		},
+		Message: &types.Empty{},
+	}
+}
+
+func TestResultCache(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	col1 := newSchema("col1")
+	col2 := newSchema("col2")
+
+	a1 := &reportingAnalyzer{name: "a1", inputs: collection.Names{col1.Name()}}
+	a2 := &reportingAnalyzer{name: "a2", inputs: collection.Names{col2.Name()}}
+
+	cache := NewResultCache()
+	a := Combine("combined", a1, a2)
+	a.SetResultCache(cache)
+
+	ctx := &resourceContext{
+		resources: map[collection.Name][]*resource.Instance{
+			col1.Name(): {newInstance("r1", "v1")},
+			col2.Name(): {newInstance("r2", "v1")},
+		},
+	}
+	a.Analyze(ctx)
+	g.Expect(ctx.reports).To(HaveLen(2))
+	g.Expect(a1.runs).To(Equal(1))
+	g.Expect(a2.runs).To(Equal(1))
+
+	// Only col2 changes, so a1 should be served from the cache.
+	ctx.resources[col2.Name()] = []*resource.Instance{newInstance("r2", "v2")}
+	ctx.reports = nil
+	a.Analyze(ctx)
+	g.Expect(ctx.reports).To(HaveLen(2))
+	g.Expect(a1.runs).To(Equal(1))
+	g.Expect(a2.runs).To(Equal(2))
+
+	hits, misses := cache.Stats()
+	g.Expect(hits).To(Equal(int64(1)))
+	g.Expect(misses).To(Equal(int64(3)))
+}
+
+func TestResultCacheUsesContentForUnversionedResources(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	col1 := newSchema("col1")
+	a1 := &reportingAnalyzer{name: "a1", inputs: collection.Names{col1.Name()}}
+
+	a := Combine("combined", a1)
+	a.SetResultCache(NewResultCache())
+
+	r := newInstance("r1", "")
+	ctx := &resourceContext{
+		resources: map[collection.Name][]*resource.Instance{
+			col1.Name(): {r},
+		},
+	}
+	a.Analyze(ctx)
+	a.Analyze(ctx)
+	g.Expect(a1.runs).To(Equal(1))
+
+	r2 := newInstance("r1", "")
+	r2.Message = &types.StringValue{Value: "changed"}
+	ctx.resources[col1.Name()] = []*resource.Instance{r2}
+	a.Analyze(ctx)
+	g.Expect(a1.runs).To(Equal(2))
+}
+
+func TestResultCacheIncremental(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	col1 := newSchema("col1")
+	col2 := newSchema("col2")
+	a1 := &incrementalAnalyzer{reportingAnalyzer: reportingAnalyzer{
+		name:   "a1",
+		inputs: collection.Names{col1.Name(), col2.Name()},
+	}}
+
+	cache := NewResultCache()
+	a := Combine("combined", a1)
+	a.SetResultCache(cache)
+
+	ctx := &resourceContext{
+		resources: map[collection.Name][]*resource.Instance{
+			col1.Name(): {newInstance("r1", "v1"), newInstance("r2", "v1")},
+			col2.Name(): {newInstance("r3", "v1")},
+		},
+	}
+	run := func() []string {
+		a1.analyzed = nil
+		ctx.reports = nil
+		a.Analyze(ctx)
+		return a1.analyzed
+	}
+
+	g.Expect(run()).To(Equal([]string{"r1", "r2"}))
+	g.Expect(ctx.reports).To(HaveLen(2))
+
+	// Only the changed resource is re-evaluated, the results of the other one are replayed.
+	ctx.resources[col1.Name()] = []*resource.Instance{newInstance("r1", "v1"), newInstance("r2", "v2")}
+	g.Expect(run()).To(Equal([]string{"r2"}))
+	g.Expect(ctx.reports).To(HaveLen(2))
+
+	g.Expect(run()).To(BeEmpty())
+	g.Expect(ctx.reports).To(HaveLen(2))
+
+	// Removed resources are no longer reported on.
+	ctx.resources[col1.Name()] = []*resource.Instance{newInstance("r2", "v2")}
+	g.Expect(run()).To(BeEmpty())
+	g.Expect(ctx.reports).To(HaveLen(1))
+
+	// All resources are re-evaluated when the other inputs change.
+	ctx.resources[col2.Name()] = []*resource.Instance{newInstance("r3", "v2")}
+	g.Expect(run()).To(Equal([]string{"r2"}))
+
+	hits, misses := cache.Stats()
+	g.Expect(hits).To(Equal(int64(1)))
+	g.Expect(misses).To(Equal(int64(4)))
+}
+
+func TestResultCacheTimeDependent(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	col1 := newSchema("col1")
+	notAfter := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+	a1 := &expiryAnalyzer{
+		reportingAnalyzer: reportingAnalyzer{name: "a1", inputs: collection.Names{col1.Name()}},
+		notAfter:          notAfter,
+		now:               notAfter.Add(-time.Hour),
+	}
+
+	cache := NewResultCache()
+	a := Combine("combined", a1)
+	a.SetResultCache(cache)
+
+	ctx := &resourceContext{
+		resources: map[collection.Name][]*resource.Instance{
+			col1.Name(): {newInstance("r1", "v1")},
+		},
+	}
+	a.Analyze(ctx)
+	g.Expect(ctx.reports).To(BeEmpty())
+
+	// The inputs are unchanged, but the certificate expired in the meantime.
+	a1.now = notAfter.Add(time.Hour)
+	a.Analyze(ctx)
+	g.Expect(ctx.reports).To(HaveLen(1))
+	g.Expect(a1.runs).To(Equal(2))
+
+	hits, misses := cache.Stats()
+	g.Expect(hits).To(Equal(int64(0)))
+	g.Expect(misses).To(Equal(int64(0)))
+}
@@ -0,0 +1,185 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package celcheck
+
+import (
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/galley/pkg/config/scope"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+// ChecksLabel marks the ConfigMaps holding checks. Each data entry of a ConfigMap with this label set to "true" is a
+// checks document.
+const ChecksLabel = "analysis.istio.io/checks"
+
+// CheckAnalyzer evaluates user defined checks, read from files or from labeled ConfigMaps, and reports their
+// violations with their own message codes.
+type CheckAnalyzer struct {
+	inputs collection.Names
+	checks []*Check
+
+	// Checks compiled from ConfigMaps, which are reused across runs as long as the ConfigMaps do not change.
+	configMaps map[resource.FullName]*configMapChecks
+}
+
+var _ analysis.Analyzer = &CheckAnalyzer{}
+
+type configMapChecks struct {
+	version resource.Version
+	checks  []*Check
+	// Compilation errors, by data key
+	errors map[string]string
+}
+
+// NewCheckAnalyzer returns an analyzer evaluating the given checks, and the checks in labeled ConfigMaps, against the
+// given input collections. The checks must have been parsed with the same inputs.
+func NewCheckAnalyzer(inputs collection.Names, checks ...*Check) *CheckAnalyzer {
+	if !contains(inputs, collections.K8SCoreV1Configmaps.Name()) {
+		inputs = append(append(collection.Names{}, inputs...), collections.K8SCoreV1Configmaps.Name())
+	}
+	return &CheckAnalyzer{
+		inputs:     inputs,
+		checks:     checks,
+		configMaps: make(map[resource.FullName]*configMapChecks),
+	}
+}
+
+// LoadCheckAnalyzer reads checks from the given files and returns an analyzer evaluating them.
+func LoadCheckAnalyzer(inputs collection.Names, files ...string) (*CheckAnalyzer, error) {
+	checks, err := Load(inputs, files...)
+	if err != nil {
+		return nil, err
+	}
+	return NewCheckAnalyzer(inputs, checks...), nil
+}
+
+// Metadata implements analysis.Analyzer
+func (a *CheckAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:        "celcheck.CheckAnalyzer",
+		Description: "Evaluates user defined CEL checks against the configuration",
+		Inputs:      a.inputs,
+		Fields:      []string{analysis.AllFields},
+	}
+}
+
+// Analyze implements analysis.Analyzer
+func (a *CheckAnalyzer) Analyze(c analysis.Context) {
+	checks := append([]*Check{}, a.checks...)
+
+	seen := make(map[resource.FullName]bool)
+	c.ForEach(collections.K8SCoreV1Configmaps.Name(), func(r *resource.Instance) bool {
+		if r.Metadata.Labels[ChecksLabel] != "true" {
+			return true
+		}
+		seen[r.Metadata.FullName] = true
+
+		cm := a.configMapChecks(r)
+		keys := make([]string, 0, len(cm.errors))
+		for key := range cm.errors {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			c.Report(collections.K8SCoreV1Configmaps.Name(), msg.NewInvalidAnalysisChecks(r, key, cm.errors[key]))
+		}
+
+		checks = append(checks, cm.checks...)
+		return true
+	})
+	for name := range a.configMaps {
+		if !seen[name] {
+			delete(a.configMaps, name)
+		}
+	}
+
+	// Resources are rendered once, however many checks they are evaluated against.
+	docs := make(map[collection.Name]map[resource.FullName]map[string]interface{})
+	for _, check := range checks {
+		if c.Canceled() {
+			return
+		}
+		if docs[check.col] == nil {
+			docs[check.col] = make(map[resource.FullName]map[string]interface{})
+		}
+		a.evaluate(c, check, docs[check.col])
+	}
+}
+
+func (a *CheckAnalyzer) evaluate(c analysis.Context, check *Check, docs map[resource.FullName]map[string]interface{}) {
+	c.ForEach(check.col, func(r *resource.Instance) bool {
+		doc, ok := docs[r.Metadata.FullName]
+		if !ok {
+			var err error
+			if doc, err = analysis.ResourceDocument(r); err != nil {
+				scope.Analysis.Errorf("celcheck: error rendering %s %v: %v", check.col, r.Metadata.FullName, err)
+			}
+			docs[r.Metadata.FullName] = doc
+		}
+		if doc == nil {
+			return true
+		}
+
+		out, _, err := check.program.Eval(map[string]interface{}{ResourceVariable: doc})
+		if err != nil {
+			// Typically a field that is not set, which conditions can test for with has()
+			scope.Analysis.Debugf("celcheck: error evaluating check %s against %s %v: %v",
+				check.Code, check.col, r.Metadata.FullName, err)
+			return true
+		}
+		if violated, ok := out.Value().(bool); ok && violated {
+			c.Report(check.col, diag.NewMessage(check.mt, r, check.Message))
+		}
+		return true
+	})
+}
+
+// configMapChecks returns the checks compiled from a ConfigMap, compiling them if the ConfigMap changed.
+func (a *CheckAnalyzer) configMapChecks(r *resource.Instance) *configMapChecks {
+	if cm, ok := a.configMaps[r.Metadata.FullName]; ok && cm.version == r.Metadata.Version && r.Metadata.Version != "" {
+		return cm
+	}
+
+	cm := &configMapChecks{
+		version: r.Metadata.Version,
+		errors:  make(map[string]string),
+	}
+	data := r.Message.(*v1.ConfigMap).Data
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	// Compile in a stable order, so that the checks are reported in a stable order
+	sort.Strings(keys)
+	for _, key := range keys {
+		checks, err := Parse([]byte(data[key]), a.inputs)
+		if err != nil {
+			cm.errors[key] = err.Error()
+			continue
+		}
+		cm.checks = append(cm.checks, checks...)
+	}
+
+	a.configMaps[r.Metadata.FullName] = cm
+	return cm
+}
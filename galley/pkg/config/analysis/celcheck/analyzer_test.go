@@ -0,0 +1,160 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package celcheck
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+
+	"istio.io/api/networking/v1alpha3"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+type testContext struct {
+	resources map[collection.Name][]*resource.Instance
+	reports   []diag.Message
+}
+
+var _ analysis.Context = &testContext{}
+
+// Report implements analysis.Context
+func (ctx *testContext) Report(_ collection.Name, m diag.Message) {
+	ctx.reports = append(ctx.reports, m)
+}
+
+// Find implements analysis.Context
+func (ctx *testContext) Find(collection.Name, resource.FullName) *resource.Instance {
+	return nil
+}
+
+// Exists implements analysis.Context
+func (ctx *testContext) Exists(collection.Name, resource.FullName) bool {
+	return false
+}
+
+// ForEach implements analysis.Context
+func (ctx *testContext) ForEach(col collection.Name, fn analysis.IteratorFn) {
+	for _, r := range ctx.resources[col] {
+		if !fn(r) {
+			return
+		}
+	}
+}
+
+// Canceled implements analysis.Context
+func (ctx *testContext) Canceled() bool {
+	return false
+}
+
+func newInstance(col collection.Schema, name, version string, labels map[string]string, m interface{}) *resource.Instance {
+	return &resource.Instance{
+		Metadata: resource.Metadata{
+			FullName: resource.NewFullName("ns", resource.LocalName(name)),
+			Version:  resource.Version(version),
+			Schema:   col.Resource(),
+			Labels:   labels,
+		},
+		Message: m,
+	}
+}
+
+func newGateway(name string, labels map[string]string, port uint32) *resource.Instance {
+	return newInstance(collections.IstioNetworkingV1Alpha3Gateways, name, "v1", labels, &v1alpha3.Gateway{
+		Servers: []*v1alpha3.Server{{
+			Port:  &v1alpha3.Port{Number: port, Name: "http", Protocol: "HTTP"},
+			Hosts: []string{"*"},
+		}},
+	})
+}
+
+func newChecksConfigMap(version string, data map[string]string) *resource.Instance {
+	return newInstance(collections.K8SCoreV1Configmaps, "checks", version, map[string]string{ChecksLabel: "true"},
+		&v1.ConfigMap{Data: data})
+}
+
+func TestCheckAnalyzer(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	checks, err := Parse([]byte(teamLabelChecks), inputs)
+	g.Expect(err).To(BeNil())
+	a := NewCheckAnalyzer(inputs, checks...)
+
+	labeled := newGateway("labeled", map[string]string{"team": "a"}, 443)
+	unlabeled := newGateway("unlabeled", nil, 80)
+	ctx := &testContext{
+		resources: map[collection.Name][]*resource.Instance{
+			collections.IstioNetworkingV1Alpha3Gateways.Name(): {labeled, unlabeled},
+		},
+	}
+	a.Analyze(ctx)
+
+	g.Expect(ctx.reports).To(HaveLen(2))
+	g.Expect(ctx.reports[0].Type.Code()).To(Equal("ORG0101"))
+	g.Expect(ctx.reports[0].Resource).To(BeIdenticalTo(unlabeled))
+	g.Expect(ctx.reports[0].Parameters).To(Equal([]interface{}{"Gateways must have a team label"}))
+	g.Expect(ctx.reports[1].Type.Code()).To(Equal("ORG0102"))
+	g.Expect(ctx.reports[1].Resource).To(BeIdenticalTo(unlabeled))
+}
+
+func TestCheckAnalyzerConfigMaps(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	a := NewCheckAnalyzer(collection.Names{collections.IstioNetworkingV1Alpha3Gateways.Name()})
+	g.Expect(a.Metadata().Inputs).To(ContainElement(collections.K8SCoreV1Configmaps.Name()))
+
+	cm := newChecksConfigMap("v1", map[string]string{
+		"team.yaml":    teamLabelChecks,
+		"invalid.yaml": "checks:\n- code: A",
+	})
+	unlabeled := newInstance(collections.K8SCoreV1Configmaps, "other", "v1", nil,
+		&v1.ConfigMap{Data: map[string]string{"invalid.yaml": "checks: ["}})
+	gateway := newGateway("unlabeled", nil, 443)
+	ctx := &testContext{
+		resources: map[collection.Name][]*resource.Instance{
+			collections.IstioNetworkingV1Alpha3Gateways.Name(): {gateway},
+			collections.K8SCoreV1Configmaps.Name():             {cm, unlabeled},
+		},
+	}
+	a.Analyze(ctx)
+
+	g.Expect(ctx.reports).To(HaveLen(2))
+	g.Expect(ctx.reports[0].Type).To(Equal(msg.InvalidAnalysisChecks))
+	g.Expect(ctx.reports[0].Resource).To(BeIdenticalTo(cm))
+	g.Expect(ctx.reports[0].Parameters[0]).To(Equal("invalid.yaml"))
+	g.Expect(ctx.reports[1].Type.Code()).To(Equal("ORG0101"))
+
+	// Checks are compiled again only when the ConfigMap changes
+	compiled := a.configMaps[cm.Metadata.FullName]
+	a.Analyze(ctx)
+	g.Expect(a.configMaps[cm.Metadata.FullName]).To(BeIdenticalTo(compiled))
+
+	ctx.resources[collections.K8SCoreV1Configmaps.Name()] = []*resource.Instance{newChecksConfigMap("v2", nil)}
+	ctx.reports = nil
+	a.Analyze(ctx)
+	g.Expect(ctx.reports).To(BeEmpty())
+
+	// Removed ConfigMaps are forgotten
+	delete(ctx.resources, collections.K8SCoreV1Configmaps.Name())
+	a.Analyze(ctx)
+	g.Expect(a.configMaps).To(BeEmpty())
+}
@@ -0,0 +1,173 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package celcheck evaluates user defined checks, written as CEL expressions over the resources of a collection, as
+// part of an analysis run.
+package celcheck
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/ghodss/yaml"
+	celgo "github.com/google/cel-go/cel"
+	"github.com/google/cel-go/checker/decls"
+	exprpb "google.golang.org/genproto/googleapis/api/expr/v1alpha1"
+
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+	"istio.io/istio/pkg/config/schema/collection"
+)
+
+// ResourceVariable is the name of the CEL variable holding the resource a check is evaluated against. The resource
+// is rendered in its JSON form, as {"apiVersion": ..., "kind": ..., "metadata": {...}, "spec": {...}}. As in JSON,
+// numbers are doubles.
+const ResourceVariable = "resource"
+
+// Checks is a document of checks, as read from a file or a ConfigMap, e.g.:
+//
+//   checks:
+//   - code: ORG0101
+//     level: Error
+//     collection: istio/networking/v1alpha3/gateways
+//     condition: '!("team" in resource.metadata.labels)'
+//     message: Gateways must have a team label
+//
+// A check reports its message for each resource of its collection for which its condition is true.
+type Checks struct {
+	Checks []*Check `json:"checks"`
+}
+
+// Check is a single user defined check.
+type Check struct {
+	// Code is the code of the messages reported by the check.
+	Code string `json:"code"`
+	// Level is one of Info, Warn (or Warning) and Error, and defaults to Warn.
+	Level string `json:"level,omitempty"`
+	// Collection is the collection whose resources are checked.
+	Collection string `json:"collection"`
+	// Condition is a CEL expression of type bool, which is true for resources violating the check.
+	Condition string `json:"condition"`
+	// Message is the text of the messages reported by the check.
+	Message string `json:"message"`
+
+	col     collection.Name
+	mt      *diag.MessageType
+	program celgo.Program
+}
+
+var env = newEnv()
+
+func newEnv() *celgo.Env {
+	env, err := celgo.NewEnv(celgo.Declarations(
+		decls.NewIdent(ResourceVariable, decls.NewMapType(decls.String, decls.Dyn), nil)))
+	if err != nil {
+		panic(fmt.Sprintf("error creating CEL environment: %v", err))
+	}
+	return env
+}
+
+// Parse parses checks in YAML or JSON format, and compiles them. The collections of the checks must be among the
+// given inputs. Unknown fields are rejected, so that misspelled fields are not silently ignored.
+func Parse(b []byte, inputs collection.Names) ([]*Check, error) {
+	js, err := yaml.YAMLToJSON(b)
+	if err != nil {
+		return nil, err
+	}
+
+	var checks Checks
+	d := json.NewDecoder(bytes.NewReader(js))
+	d.DisallowUnknownFields()
+	if err := d.Decode(&checks); err != nil {
+		return nil, err
+	}
+
+	for i, c := range checks.Checks {
+		if c == nil {
+			return nil, fmt.Errorf("check %d is empty", i)
+		}
+		if err := c.compile(inputs); err != nil {
+			return nil, fmt.Errorf("check %d (%s): %v", i, c.Code, err)
+		}
+	}
+	return checks.Checks, nil
+}
+
+// Load reads checks from the given files, and compiles them with Parse.
+func Load(inputs collection.Names, files ...string) ([]*Check, error) {
+	var result []*Check
+	for _, f := range files {
+		b, err := ioutil.ReadFile(f)
+		if err != nil {
+			return nil, fmt.Errorf("error reading checks file %q: %v", f, err)
+		}
+		checks, err := Parse(b, inputs)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing checks file %q: %v", f, err)
+		}
+		result = append(result, checks...)
+	}
+	return result, nil
+}
+
+func (c *Check) compile(inputs collection.Names) error {
+	if c.Code == "" {
+		return fmt.Errorf("no code")
+	}
+	if c.Condition == "" {
+		return fmt.Errorf("no condition")
+	}
+	if c.Message == "" {
+		return fmt.Errorf("no message")
+	}
+	level, ok := diag.ParseLevel(c.Level)
+	if !ok {
+		return fmt.Errorf("unknown level %q", c.Level)
+	}
+	c.mt = diag.NewMessageType(level, c.Code, "%s")
+
+	c.col = collection.NewName(c.Collection)
+	if !contains(inputs, c.col) {
+		return fmt.Errorf("unknown collection %q", c.Collection)
+	}
+
+	parsed, iss := env.Parse(c.Condition)
+	if iss != nil && iss.Err() != nil {
+		return fmt.Errorf("invalid condition: %v", iss.Err())
+	}
+	checked, iss := env.Check(parsed)
+	if iss != nil && iss.Err() != nil {
+		return fmt.Errorf("invalid condition: %v", iss.Err())
+	}
+	if checked.ResultType().GetPrimitive() != exprpb.Type_BOOL {
+		return fmt.Errorf("condition is not of type bool")
+	}
+
+	program, err := env.Program(checked)
+	if err != nil {
+		return fmt.Errorf("invalid condition: %v", err)
+	}
+	c.program = program
+	return nil
+}
+
+func contains(names collection.Names, name collection.Name) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
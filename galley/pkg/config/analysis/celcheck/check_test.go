@@ -0,0 +1,83 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package celcheck
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+var inputs = collection.Names{
+	collections.IstioNetworkingV1Alpha3Gateways.Name(),
+	collections.K8SCoreV1Configmaps.Name(),
+}
+
+const teamLabelChecks = `
+checks:
+- code: ORG0101
+  level: Error
+  collection: istio/networking/v1alpha3/gateways
+  condition: '!("team" in resource.metadata.labels)'
+  message: Gateways must have a team label
+- code: ORG0102
+  collection: istio/networking/v1alpha3/gateways
+  condition: 'resource.spec.servers.exists(s, s.port.number == 80.0)'
+  message: Gateways must not serve port 80
+`
+
+func TestParse(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	checks, err := Parse([]byte(teamLabelChecks), inputs)
+	g.Expect(err).To(BeNil())
+	g.Expect(checks).To(HaveLen(2))
+
+	g.Expect(checks[0].col).To(Equal(collections.IstioNetworkingV1Alpha3Gateways.Name()))
+	g.Expect(checks[0].mt.Code()).To(Equal("ORG0101"))
+	g.Expect(checks[0].mt.Level()).To(Equal(diag.Error))
+	g.Expect(checks[0].program).NotTo(BeNil())
+	g.Expect(checks[1].mt.Level()).To(Equal(diag.Warning))
+}
+
+func TestParseErrors(t *testing.T) {
+	check := func(fields string) string {
+		return "checks:\n- " + fields
+	}
+	cases := map[string]string{
+		"syntax":             "checks: [",
+		"unknown field":      check("code: A\n  collection: istio/networking/v1alpha3/gateways\n  condition: 'true'\n  message: m\n  severity: Error"),
+		"no code":            check("collection: istio/networking/v1alpha3/gateways\n  condition: 'true'\n  message: m"),
+		"no condition":       check("code: A\n  collection: istio/networking/v1alpha3/gateways\n  message: m"),
+		"no message":         check("code: A\n  collection: istio/networking/v1alpha3/gateways\n  condition: 'true'"),
+		"unknown level":      check("code: A\n  level: Fatal\n  collection: istio/networking/v1alpha3/gateways\n  condition: 'true'\n  message: m"),
+		"unknown collection": check("code: A\n  collection: istio/networking/v1alpha3/sidecars\n  condition: 'true'\n  message: m"),
+		"invalid condition":  check("code: A\n  collection: istio/networking/v1alpha3/gateways\n  condition: 'resource.'\n  message: m"),
+		"unknown variable":   check("code: A\n  collection: istio/networking/v1alpha3/gateways\n  condition: 'gateway.spec'\n  message: m"),
+		"not bool":           check("code: A\n  collection: istio/networking/v1alpha3/gateways\n  condition: 'size(resource)'\n  message: m"),
+	}
+	for name, checks := range cases {
+		checks := checks
+		t.Run(name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			_, err := Parse([]byte(checks), inputs)
+			g.Expect(err).NotTo(BeNil())
+		})
+	}
+}
@@ -15,9 +15,16 @@
 package analysis
 
 import (
+	"context"
+
+	"istio.io/api/mesh/v1alpha1"
+
 	"istio.io/istio/galley/pkg/config/analysis/diag"
+	"istio.io/istio/galley/pkg/config/analysis/pushed"
+	"istio.io/istio/galley/pkg/config/mesh"
 	"istio.io/istio/pkg/config/resource"
 	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
 )
 
 // IteratorFn is used to iterate over a set of collection entries. It must return true to keep iterating.
@@ -40,3 +47,144 @@ type Context interface {
 	// Canceled indicates that the context has been canceled. The analyzer should stop executing as soon as possible.
 	Canceled() bool
 }
+
+// IndexProvider is implemented by contexts that can share derived indexes (e.g. a lookup table built from one or more
+// collections) across analyzers. Contexts are backed by immutable snapshots, so an index built once remains valid for
+// the lifetime of the context and can be used from multiple goroutines.
+type IndexProvider interface {
+	// Index returns the index with the given key, calling build to create it on first use.
+	Index(key string, build func() interface{}) interface{}
+}
+
+// Index returns the derived index with the given key. If ctx is an IndexProvider the index is shared with all other
+// analyzers using the same context, otherwise build is called on every invocation. Shared indexes must not be modified;
+// analyzers that need to make changes should work on a copy.
+func Index(ctx Context, key string, build func() interface{}) interface{} {
+	if p, ok := ctx.(IndexProvider); ok {
+		return p.Index(key, build)
+	}
+	return build()
+}
+
+// GoContextProvider is implemented by contexts that carry a context.Context, which is canceled along with the
+// analysis.
+type GoContextProvider interface {
+	// GoContext returns the context.Context of the analysis.
+	GoContext() context.Context
+}
+
+// GoContext returns the context.Context of the given analysis context, for analyzers that perform blocking operations
+// such as network calls, so that they stop as soon as the analysis is canceled. If ctx does not carry a
+// context.Context, context.Background() is returned.
+func GoContext(ctx Context) context.Context {
+	if p, ok := ctx.(GoContextProvider); ok {
+		return p.GoContext()
+	}
+	return context.Background()
+}
+
+// MeshConfig returns the mesh configuration of the given analysis context, for analyzers whose findings depend on mesh
+// settings such as the root namespace, the outbound traffic policy or auto mTLS. If the context has no mesh
+// configuration, the default one is returned, so the result is never nil. Analyzers that call this should include
+// collections.IstioMeshV1Alpha1MeshConfig as an input in their Metadata.
+func MeshConfig(ctx Context) *v1alpha1.MeshConfig {
+	// Only one MeshConfig should exist in practice, but getting it this way avoids needing
+	// to plumb through the name or enforce/expose a constant.
+	var mc *v1alpha1.MeshConfig
+	ctx.ForEach(collections.IstioMeshV1Alpha1MeshConfig.Name(), func(r *resource.Instance) bool {
+		mc = r.Message.(*v1alpha1.MeshConfig)
+		return false
+	})
+	if mc == nil {
+		return mesh.DefaultMeshConfig()
+	}
+	return mc
+}
+
+// MeshNetworks returns the mesh networks configuration of the given analysis context. If the context has none, the
+// default, empty, configuration is returned, so the result is never nil. Analyzers that call this should include
+// collections.IstioMeshV1Alpha1MeshNetworks as an input in their Metadata.
+func MeshNetworks(ctx Context) *v1alpha1.MeshNetworks {
+	var mn *v1alpha1.MeshNetworks
+	ctx.ForEach(collections.IstioMeshV1Alpha1MeshNetworks.Name(), func(r *resource.Instance) bool {
+		mn = r.Message.(*v1alpha1.MeshNetworks)
+		return false
+	})
+	if mn == nil {
+		return mesh.DefaultMeshNetworks()
+	}
+	return mn
+}
+
+// VersionProvider is implemented by contexts that know the Istio control plane version the configuration is checked
+// against.
+type VersionProvider interface {
+	// IstioVersion returns the Istio version of the control plane, or the empty string if it is unknown.
+	IstioVersion() string
+}
+
+// IstioVersion returns the Istio control plane version of the given analysis context, for analyzers whose findings
+// depend on version specific behavior. The empty string is returned if the version is unknown.
+func IstioVersion(ctx Context) string {
+	if p, ok := ctx.(VersionProvider); ok {
+		return p.IstioVersion()
+	}
+	return ""
+}
+
+// ClusterProvider is implemented by contexts of analyses that span multiple clusters. The resources of all clusters
+// are analyzed as one snapshot, in which a resource that exists in several clusters appears once.
+type ClusterProvider interface {
+	// Clusters returns the sorted names of the analyzed clusters, or nil if the analysis is not multi-cluster.
+	Clusters() []string
+
+	// ResourceClusters returns the sorted names of the clusters that contain the resource with the given name.
+	ResourceClusters(c collection.Name, name resource.FullName) []string
+
+	// ClusterMeshNetworks returns the mesh networks configuration of the given cluster, or nil if it is unknown.
+	ClusterMeshNetworks(cluster string) *v1alpha1.MeshNetworks
+}
+
+// Clusters returns the sorted names of the clusters analyzed by the given context, or nil if the analysis is not
+// multi-cluster. Cross-cluster analyzers should do nothing unless there are at least two clusters.
+func Clusters(ctx Context) []string {
+	if p, ok := ctx.(ClusterProvider); ok {
+		return p.Clusters()
+	}
+	return nil
+}
+
+// ResourceClusters returns the sorted names of the clusters that contain the resource with the given name, or nil if
+// the analysis is not multi-cluster.
+func ResourceClusters(ctx Context, c collection.Name, name resource.FullName) []string {
+	if p, ok := ctx.(ClusterProvider); ok {
+		return p.ResourceClusters(c, name)
+	}
+	return nil
+}
+
+// ClusterMeshNetworks returns the mesh networks configuration of the given cluster, or nil if it is unknown or the
+// analysis is not multi-cluster.
+func ClusterMeshNetworks(ctx Context, cluster string) *v1alpha1.MeshNetworks {
+	if p, ok := ctx.(ClusterProvider); ok {
+		return p.ClusterMeshNetworks(cluster)
+	}
+	return nil
+}
+
+// PushedConfigProvider is implemented by contexts of analyses that include the configuration the control plane pushed
+// to proxies, e.g. as read from Envoy config dumps.
+type PushedConfigProvider interface {
+	// PushedConfig returns the configuration pushed to proxies, or nil if it is not part of the analysis.
+	PushedConfig() *pushed.Config
+}
+
+// PushedConfig returns the configuration pushed to proxies of the given analysis context, for analyzers that compare
+// the intended configuration with what actually got programmed. Nil is returned if the pushed configuration is not
+// part of the analysis, in which case such analyzers should do nothing.
+func PushedConfig(ctx Context) *pushed.Config {
+	if p, ok := ctx.(PushedConfigProvider); ok {
+		return p.PushedConfig()
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package analysis
+
+import (
+	"reflect"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"istio.io/api/mesh/v1alpha1"
+
+	"istio.io/istio/galley/pkg/config/analysis/pushed"
+	"istio.io/istio/galley/pkg/config/mesh"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+// meshContext is a context with the resources of a few collections
+type meshContext struct {
+	context
+	resources map[collection.Name][]*resource.Instance
+}
+
+func (ctx *meshContext) ForEach(c collection.Name, fn IteratorFn) {
+	for _, r := range ctx.resources[c] {
+		if !fn(r) {
+			return
+		}
+	}
+}
+
+func TestMeshConfig(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	g.Expect(MeshConfig(&meshContext{})).To(Equal(mesh.DefaultMeshConfig()))
+
+	mc := &v1alpha1.MeshConfig{RootNamespace: "mesh-root"}
+	ctx := &meshContext{resources: map[collection.Name][]*resource.Instance{
+		collections.IstioMeshV1Alpha1MeshConfig.Name(): {{Message: mc}},
+	}}
+	g.Expect(MeshConfig(ctx)).To(BeIdenticalTo(mc))
+}
+
+func TestMeshNetworks(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	g.Expect(MeshNetworks(&meshContext{})).To(Equal(mesh.DefaultMeshNetworks()))
+
+	mn := &v1alpha1.MeshNetworks{Networks: map[string]*v1alpha1.Network{"network1": {}}}
+	ctx := &meshContext{resources: map[collection.Name][]*resource.Instance{
+		collections.IstioMeshV1Alpha1MeshNetworks.Name(): {{Message: mn}},
+	}}
+	g.Expect(MeshNetworks(ctx)).To(BeIdenticalTo(mn))
+}
+
+// clusterContext is a context of a multi-cluster analysis
+type clusterContext struct {
+	context
+}
+
+func (ctx *clusterContext) Clusters() []string {
+	return []string{"east", "west"}
+}
+
+func (ctx *clusterContext) ResourceClusters(collection.Name, resource.FullName) []string {
+	return []string{"west"}
+}
+
+func (ctx *clusterContext) ClusterMeshNetworks(cluster string) *v1alpha1.MeshNetworks {
+	return &v1alpha1.MeshNetworks{Networks: map[string]*v1alpha1.Network{cluster: {}}}
+}
+
+// wrappers returns the contexts that wrap the context of a run before it is given to analyzers. Every context that
+// wraps another one must be listed, so that it is checked to forward all the optional providers.
+func wrappers(ctx Context) []Context {
+	l := newLimiter(ctx, DefaultLimits)
+	return []Context{
+		&countingContext{Context: ctx},
+		&recordingContext{Context: ctx},
+		l,
+		&limitingContext{Context: ctx, l: l},
+	}
+}
+
+func TestWrappersForwardClusters(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	name := resource.NewFullName("ns", "svc")
+	for _, ctx := range wrappers(&clusterContext{}) {
+		g.Expect(Clusters(ctx)).To(Equal([]string{"east", "west"}))
+		g.Expect(ResourceClusters(ctx, collections.K8SCoreV1Services.Name(), name)).To(Equal([]string{"west"}))
+		g.Expect(ClusterMeshNetworks(ctx, "east").Networks).To(HaveKey("east"))
+	}
+}
+
+// pushedContext is a context of an analysis that includes the configuration pushed to proxies
+type pushedContext struct {
+	context
+	cfg *pushed.Config
+}
+
+func (ctx *pushedContext) PushedConfig() *pushed.Config {
+	return ctx.cfg
+}
+
+func TestWrappersForwardPushedConfig(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	cfg := pushed.NewConfig()
+	for _, ctx := range wrappers(&pushedContext{cfg: cfg}) {
+		g.Expect(PushedConfig(ctx)).To(BeIdenticalTo(cfg))
+	}
+}
+
+// providers are the optional interfaces of contexts, which wrappers must forward to the contexts they wrap.
+var providers = []reflect.Type{
+	reflect.TypeOf((*IndexProvider)(nil)).Elem(),
+	reflect.TypeOf((*GoContextProvider)(nil)).Elem(),
+	reflect.TypeOf((*VersionProvider)(nil)).Elem(),
+	reflect.TypeOf((*ClusterProvider)(nil)).Elem(),
+	reflect.TypeOf((*PushedConfigProvider)(nil)).Elem(),
+}
+
+func TestWrappersImplementProviders(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	for _, ctx := range wrappers(&context{}) {
+		for _, p := range providers {
+			g.Expect(reflect.TypeOf(ctx).Implements(p)).To(BeTrue(), "%T does not implement %v", ctx, p)
+		}
+	}
+}
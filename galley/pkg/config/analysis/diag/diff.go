@@ -0,0 +1,67 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+import "fmt"
+
+// Difference is the change in the findings of analysis between two snapshots, e.g. between a cluster and the cluster
+// with proposed changes applied.
+type Difference struct {
+	// New are the findings of the second snapshot that the first one does not have.
+	New Messages `json:"new"`
+
+	// Resolved are the findings of the first snapshot that the second one does not have.
+	Resolved Messages `json:"resolved"`
+
+	// Persisting are the findings of the second snapshot that the first one has as well.
+	Persisting Messages `json:"persisting"`
+}
+
+// Diff compares the findings of two snapshots. Findings are the same if they have the same code, resource name and
+// text, regardless of where the resource was read from. Each finding of one snapshot matches at most one of the other.
+func Diff(before, after Messages) Difference {
+	unmatched := make(map[string]Messages)
+	for _, m := range before {
+		k := diffKey(&m)
+		unmatched[k] = append(unmatched[k], m)
+	}
+
+	var d Difference
+	for _, m := range after {
+		k := diffKey(&m)
+		if len(unmatched[k]) == 0 {
+			d.New = append(d.New, m)
+			continue
+		}
+		unmatched[k] = unmatched[k][1:]
+		d.Persisting = append(d.Persisting, m)
+	}
+	for _, m := range before {
+		k := diffKey(&m)
+		if ms := unmatched[k]; len(ms) > 0 {
+			d.Resolved = append(d.Resolved, ms[0])
+			unmatched[k] = ms[1:]
+		}
+	}
+	return d
+}
+
+func diffKey(m *Message) string {
+	origin := ""
+	if m.Resource != nil {
+		origin = m.Resource.Origin.FriendlyName()
+	}
+	return m.Type.Code() + "/" + origin + "/" + fmt.Sprintf(m.Type.Template(), m.Parameters...)
+}
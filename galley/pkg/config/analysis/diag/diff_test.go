@@ -0,0 +1,55 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestDiff(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	mt := NewMessageType(Error, "A1", "Template: %q")
+	fixed := NewMessage(mt, testResource("fixed"), "x")
+	kept := NewMessage(mt, testResource("kept"), "x")
+	changed := NewMessage(mt, testResource("kept"), "y")
+	added := NewMessage(mt, testResource("added"), "x")
+
+	// The same finding from a different resource instance, e.g. read from a file rather than the cluster
+	keptAgain := NewMessage(mt, testResource("kept"), "x")
+
+	d := Diff(Messages{fixed, kept, changed}, Messages{added, keptAgain})
+	g.Expect(d.New).To(Equal(Messages{added}))
+	g.Expect(d.Resolved).To(Equal(Messages{fixed, changed}))
+	g.Expect(d.Persisting).To(Equal(Messages{keptAgain}))
+}
+
+func TestDiffDuplicates(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	m := NewMessage(NewMessageType(Warning, "A1", "Template"), nil)
+
+	d := Diff(Messages{m}, Messages{m, m})
+	g.Expect(d.New).To(Equal(Messages{m}))
+	g.Expect(d.Resolved).To(BeEmpty())
+	g.Expect(d.Persisting).To(Equal(Messages{m}))
+
+	d = Diff(Messages{m, m}, Messages{m})
+	g.Expect(d.New).To(BeEmpty())
+	g.Expect(d.Resolved).To(Equal(Messages{m}))
+	g.Expect(d.Persisting).To(Equal(Messages{m}))
+}
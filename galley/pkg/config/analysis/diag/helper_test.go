@@ -20,6 +20,7 @@ import (
 
 var _ resource.Origin = &testOrigin{}
 var _ resource.Reference = &testReference{}
+var _ resource.ClusterOrigin = &testClusterOrigin{}
 
 type testOrigin struct {
 	name string
@@ -38,6 +39,20 @@ func (o testOrigin) Reference() resource.Reference {
 	return o.ref
 }
 
+type testClusterOrigin struct {
+	testOrigin
+	cluster string
+	version resource.Version
+}
+
+func (o testClusterOrigin) ClusterName() string {
+	return o.cluster
+}
+
+func (o testClusterOrigin) ResourceVersion() resource.Version {
+	return o.version
+}
+
 type testReference struct {
 	name string
 }
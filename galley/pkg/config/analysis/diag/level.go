@@ -68,3 +68,14 @@ func GetUppercaseStringToLevelMap() map[string]Level {
 	}
 	return m
 }
+
+// ParseLevel returns the Level with the given name, ignoring case. As user supplied names, "Warning" is accepted for
+// Warning, and the empty string defaults to Warning.
+func ParseLevel(s string) (Level, bool) {
+	s = strings.ToUpper(s)
+	if s == "" || s == "WARNING" {
+		return Warning, true
+	}
+	l, ok := GetUppercaseStringToLevelMap()[s]
+	return l, ok
+}
@@ -0,0 +1,40 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestParseLevel(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	for in, want := range map[string]Level{
+		"":        Warning,
+		"info":    Info,
+		"Warn":    Warning,
+		"WARNING": Warning,
+		"error":   Error,
+	} {
+		l, ok := ParseLevel(in)
+		g.Expect(ok).To(BeTrue())
+		g.Expect(l).To(Equal(want))
+	}
+
+	_, ok := ParseLevel("fatal")
+	g.Expect(ok).To(BeFalse())
+}
@@ -45,6 +45,10 @@ func (m *MessageType) Code() string { return m.code }
 // Template returns the message template used by the MessageType
 func (m *MessageType) Template() string { return m.template }
 
+// DocumentationURL returns the URL of the documentation of the MessageType. It only depends on the code, so it is stable
+// across releases.
+func (m *MessageType) DocumentationURL() string { return fmt.Sprintf("%s/%s", DocPrefix, m.code) }
+
 // Message is a specific diagnostic message
 type Message struct {
 	Type *MessageType
@@ -58,6 +62,32 @@ type Message struct {
 
 	// DocRef is an optional reference tracker for the documentation URL
 	DocRef string
+
+	// FieldPath optionally locates the message within the resource, as the path of the field it is about, e.g.
+	// spec.servers[2].tls.credentialName. It is omitted from String.
+	FieldPath string
+
+	// Notes is optional context about the message that is not part of its identity, e.g. the distribution status of
+	// the resource to proxies. It is omitted from String.
+	Notes []string
+
+	// Fix is an optional remediation of the message that can be applied by machine. It is omitted from String.
+	Fix *Fix
+
+	// Aggregated holds the further messages of the same type that a Reporter collapsed into this one. It is omitted
+	// from String.
+	Aggregated Messages
+}
+
+// Fix is a machine-applyable remediation of a message: a JSON merge patch (RFC 7386) of the resource the message is
+// about, as applied by `kubectl patch --type merge`. A merge patch can also replace a field, or the whole spec, with
+// new content.
+type Fix struct {
+	// Description tells users what the fix changes.
+	Description string
+
+	// Patch is the JSON merge patch of the resource.
+	Patch string
 }
 
 // Unstructured returns this message as a JSON-style unstructured map
@@ -71,6 +101,17 @@ func (m *Message) Unstructured(includeOrigin bool) map[string]interface{} {
 		if m.Resource.Origin.Reference() != nil {
 			result["reference"] = m.Resource.Origin.Reference().String()
 		}
+		if o, ok := m.Resource.Origin.(resource.ClusterOrigin); ok {
+			if o.ClusterName() != "" {
+				result["cluster"] = o.ClusterName()
+			}
+			if o.ResourceVersion() != "" {
+				result["resource_version"] = string(o.ResourceVersion())
+			}
+		}
+	}
+	if m.FieldPath != "" {
+		result["field_path"] = m.FieldPath
 	}
 	result["message"] = fmt.Sprintf(m.Type.Template(), m.Parameters...)
 
@@ -78,7 +119,36 @@ func (m *Message) Unstructured(includeOrigin bool) map[string]interface{} {
 	if m.DocRef != "" {
 		docQueryString = fmt.Sprintf("?ref=%s", m.DocRef)
 	}
-	result["documentation_url"] = fmt.Sprintf("%s/%s%s", DocPrefix, m.Type.Code(), docQueryString)
+	result["documentation_url"] = m.Type.DocumentationURL() + docQueryString
+
+	if len(m.Notes) > 0 {
+		result["notes"] = m.Notes
+	}
+
+	if m.Fix != nil {
+		result["fix"] = map[string]interface{}{
+			"description": m.Fix.Description,
+			"patch":       m.Fix.Patch,
+		}
+	}
+
+	if len(m.Aggregated) > 0 {
+		aggregated := make([]map[string]interface{}, 0, len(m.Aggregated))
+		for _, a := range m.Aggregated {
+			u := map[string]interface{}{"message": fmt.Sprintf(a.Type.Template(), a.Parameters...)}
+			if includeOrigin && a.Resource != nil {
+				u["origin"] = a.Resource.Origin.FriendlyName()
+				if a.Resource.Origin.Reference() != nil {
+					u["reference"] = a.Resource.Origin.Reference().String()
+				}
+			}
+			if a.FieldPath != "" {
+				u["field_path"] = a.FieldPath
+			}
+			aggregated = append(aggregated, u)
+		}
+		result["aggregated"] = aggregated
+	}
 
 	return result
 }
@@ -97,6 +167,27 @@ func (m *Message) String() string {
 		"%v [%v]%s %s", m.Type.Level(), m.Type.Code(), origin, fmt.Sprintf(m.Type.Template(), m.Parameters...))
 }
 
+// WithLevel returns a copy of the message at the given level, instead of the default level of its type. Analyzers can
+// use it to report a finding at a lower level when they are not certain that it is a problem.
+func (m Message) WithLevel(level Level) Message {
+	if m.Type.Level() != level {
+		m.Type = NewMessageType(level, m.Type.Code(), m.Type.Template())
+	}
+	return m
+}
+
+// WithFieldPath returns a copy of the message located at the given field path of its resource.
+func (m Message) WithFieldPath(path string) Message {
+	m.FieldPath = path
+	return m
+}
+
+// WithFix returns a copy of the message with the given fix attached.
+func (m Message) WithFix(description, patch string) Message {
+	m.Fix = &Fix{Description: description, Patch: patch}
+	return m
+}
+
 // MarshalJSON satisfies the Marshaler interface
 func (m *Message) MarshalJSON() ([]byte, error) {
 	return json.Marshal(m.Unstructured(true))
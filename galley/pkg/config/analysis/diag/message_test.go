@@ -61,6 +61,96 @@ func TestMessageWithDocRef(t *testing.T) {
 	g.Expect(m.Unstructured(false)["documentation_url"]).To(Equal("https://istio.io/docs/reference/config/analysis/IST-0042?ref=test-ref"))
 }
 
+func TestMessageType_DocumentationURL(t *testing.T) {
+	g := NewGomegaWithT(t)
+	mt := NewMessageType(Error, "IST-0042", "Cheese type not found: %q")
+	g.Expect(mt.DocumentationURL()).To(Equal("https://istio.io/docs/reference/config/analysis/IST-0042"))
+}
+
+func TestMessageWithNotes(t *testing.T) {
+	g := NewGomegaWithT(t)
+	mt := NewMessageType(Error, "IST-0042", "Cheese type not found: %q")
+	m := NewMessage(mt, nil, "Feta")
+	g.Expect(m.Unstructured(false)).To(Not(HaveKey("notes")))
+
+	m.Notes = []string{"out of stock"}
+	g.Expect(m.Unstructured(false)).To(HaveKeyWithValue("notes", []string{"out of stock"}))
+	g.Expect(m.String()).To(Not(ContainSubstring("out of stock")))
+}
+
+func TestMessageWithFieldPath(t *testing.T) {
+	g := NewGomegaWithT(t)
+	mt := NewMessageType(Error, "IST-0042", "Cheese type not found: %q")
+	m := NewMessage(mt, nil, "Feta")
+	g.Expect(m.Unstructured(false)).To(Not(HaveKey("field_path")))
+
+	located := m.WithFieldPath("spec.toppings[1].cheese")
+	g.Expect(m.FieldPath).To(BeEmpty())
+	g.Expect(located.Unstructured(false)).To(HaveKeyWithValue("field_path", "spec.toppings[1].cheese"))
+	g.Expect(located.String()).To(Equal(m.String()))
+}
+
+func TestMessageWithClusterOrigin(t *testing.T) {
+	g := NewGomegaWithT(t)
+	mt := NewMessageType(Error, "IST-0042", "Cheese type not found: %q")
+	o := testClusterOrigin{testOrigin: testOrigin{name: "toppings/cheese"}, cluster: "kitchen", version: "42"}
+	m := NewMessage(mt, &resource.Instance{Origin: o}, "Feta")
+
+	g.Expect(m.Unstructured(true)).To(HaveKeyWithValue("cluster", "kitchen"))
+	g.Expect(m.Unstructured(true)).To(HaveKeyWithValue("resource_version", "42"))
+	g.Expect(m.Unstructured(false)).To(Not(HaveKey("cluster")))
+	g.Expect(m.Unstructured(false)).To(Not(HaveKey("resource_version")))
+
+	o.cluster = ""
+	o.version = ""
+	m = NewMessage(mt, &resource.Instance{Origin: o}, "Feta")
+	g.Expect(m.Unstructured(true)).To(Not(HaveKey("cluster")))
+	g.Expect(m.Unstructured(true)).To(Not(HaveKey("resource_version")))
+}
+
+func TestMessageWithLevel(t *testing.T) {
+	g := NewGomegaWithT(t)
+	mt := NewMessageType(Error, "IST-0042", "Cheese type not found: %q")
+	m := NewMessage(mt, nil, "Feta")
+
+	info := m.WithLevel(Info)
+	g.Expect(info.String()).To(Equal(`Info [IST-0042] Cheese type not found: "Feta"`))
+	g.Expect(m.Type.Level()).To(Equal(Error))
+	g.Expect(m.WithLevel(Error).Type).To(BeIdenticalTo(mt))
+}
+
+func TestMessageWithFix(t *testing.T) {
+	g := NewGomegaWithT(t)
+	mt := NewMessageType(Error, "IST-0042", "Cheese type not found: %q")
+	m := NewMessage(mt, nil, "Feta")
+	g.Expect(m.Unstructured(false)).To(Not(HaveKey("fix")))
+
+	fixed := m.WithFix("Use Halloumi", `{"spec":{"cheese":"Halloumi"}}`)
+	g.Expect(m.Fix).To(BeNil())
+	g.Expect(fixed.Unstructured(false)).To(HaveKeyWithValue("fix", map[string]interface{}{
+		"description": "Use Halloumi",
+		"patch":       `{"spec":{"cheese":"Halloumi"}}`,
+	}))
+	g.Expect(fixed.String()).To(Equal(m.String()))
+}
+
+func TestMessageWithAggregated(t *testing.T) {
+	g := NewGomegaWithT(t)
+	mt := NewMessageType(Error, "IST-0042", "Cheese type not found: %q")
+	m := NewMessage(mt, testResource("A"), "Feta")
+	g.Expect(m.Unstructured(true)).To(Not(HaveKey("aggregated")))
+
+	s := m.String()
+	m.Aggregated = Messages{NewMessage(mt, testResource("B"), "Gouda")}
+	g.Expect(m.Unstructured(true)).To(HaveKeyWithValue("aggregated", []map[string]interface{}{
+		{"message": `Cheese type not found: "Gouda"`, "origin": "B"},
+	}))
+	g.Expect(m.Unstructured(false)).To(HaveKeyWithValue("aggregated", []map[string]interface{}{
+		{"message": `Cheese type not found: "Gouda"`},
+	}))
+	g.Expect(m.String()).To(Equal(s))
+}
+
 func TestMessage_JSON(t *testing.T) {
 	g := NewGomegaWithT(t)
 	mt := NewMessageType(Error, "IST-0042", "Cheese type not found: %q")
@@ -0,0 +1,71 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+// Reporter collects the messages of an analysis run and prepares them for presentation, so that the output stays
+// readable on large clusters: identical messages are reported once, the messages of a type that is reported many times
+// are collapsed into a single message, and the total number of messages can be capped.
+type Reporter struct {
+	// AggregateThreshold is the number of messages of a type from which they are collapsed into the first of them,
+	// which holds the others in Aggregated. Zero disables aggregation.
+	AggregateThreshold int
+
+	// MaxMessages is the maximum number of messages to report. The most severe messages are kept. Zero means no limit.
+	MaxMessages int
+
+	messages Messages
+}
+
+// Add a message to the reporter.
+func (r *Reporter) Add(m Message) {
+	r.messages.Add(m)
+}
+
+// Report returns the sorted, deduplicated and aggregated messages added to the reporter, and the number of messages
+// that were omitted because they exceed MaxMessages.
+func (r *Reporter) Report() (Messages, int) {
+	ms := r.messages.SortedDedupedCopy()
+	if r.AggregateThreshold > 0 {
+		ms = aggregate(ms, r.AggregateThreshold)
+	}
+
+	omitted := 0
+	if r.MaxMessages > 0 && len(ms) > r.MaxMessages {
+		omitted = len(ms) - r.MaxMessages
+		ms = ms[:r.MaxMessages]
+	}
+	return ms, omitted
+}
+
+// aggregate collapses runs of at least threshold messages with the same level and code in the sorted messages.
+func aggregate(ms Messages, threshold int) Messages {
+	var result Messages
+	for start := 0; start < len(ms); {
+		end := start + 1
+		for end < len(ms) && ms[end].Type.Level() == ms[start].Type.Level() && ms[end].Type.Code() == ms[start].Type.Code() {
+			end++
+		}
+
+		if end-start >= threshold {
+			m := ms[start]
+			m.Aggregated = append(Messages(nil), ms[start+1:end]...)
+			result = append(result, m)
+		} else {
+			result = append(result, ms[start:end]...)
+		}
+		start = end
+	}
+	return result
+}
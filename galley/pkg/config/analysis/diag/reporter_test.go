@@ -0,0 +1,83 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestReporter_Dedupe(t *testing.T) {
+	g := NewGomegaWithT(t)
+	mt := NewMessageType(Error, "B1", "Template: %q")
+
+	r := Reporter{}
+	r.Add(NewMessage(mt, testResource("B"), "B"))
+	r.Add(NewMessage(mt, testResource("A"), "A"))
+	r.Add(NewMessage(mt, testResource("B"), "B"))
+
+	ms, omitted := r.Report()
+	g.Expect(ms).To(Equal(Messages{
+		NewMessage(mt, testResource("A"), "A"),
+		NewMessage(mt, testResource("B"), "B"),
+	}))
+	g.Expect(omitted).To(Equal(0))
+}
+
+func TestReporter_Aggregate(t *testing.T) {
+	g := NewGomegaWithT(t)
+	errorType := NewMessageType(Error, "B1", "Template: %q")
+	warningType := NewMessageType(Warning, "A1", "Template: %q")
+
+	r := Reporter{AggregateThreshold: 3}
+	for _, name := range []string{"C", "B", "A"} {
+		r.Add(NewMessage(warningType, testResource(name), name))
+	}
+	for _, name := range []string{"B", "A"} {
+		r.Add(NewMessage(errorType, testResource(name), name))
+	}
+
+	ms, _ := r.Report()
+	g.Expect(ms).To(HaveLen(3))
+	g.Expect(ms[0]).To(Equal(NewMessage(errorType, testResource("A"), "A")))
+	g.Expect(ms[1]).To(Equal(NewMessage(errorType, testResource("B"), "B")))
+
+	aggregated := NewMessage(warningType, testResource("A"), "A")
+	aggregated.Aggregated = Messages{
+		NewMessage(warningType, testResource("B"), "B"),
+		NewMessage(warningType, testResource("C"), "C"),
+	}
+	g.Expect(ms[2]).To(Equal(aggregated))
+}
+
+func TestReporter_MaxMessages(t *testing.T) {
+	g := NewGomegaWithT(t)
+	errorType := NewMessageType(Error, "B1", "Template: %q")
+	infoType := NewMessageType(Info, "A1", "Template: %q")
+
+	r := Reporter{MaxMessages: 2}
+	r.Add(NewMessage(infoType, testResource("A"), "A"))
+	r.Add(NewMessage(errorType, testResource("B"), "B"))
+	r.Add(NewMessage(errorType, testResource("C"), "C"))
+	r.Add(NewMessage(infoType, testResource("D"), "D"))
+
+	ms, omitted := r.Report()
+	g.Expect(ms).To(Equal(Messages{
+		NewMessage(errorType, testResource("B"), "B"),
+		NewMessage(errorType, testResource("C"), "C"),
+	}))
+	g.Expect(omitted).To(Equal(2))
+}
@@ -0,0 +1,61 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package analysis
+
+import (
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/util/gogoprotomarshal"
+)
+
+// ResourceDocument renders a resource as an unstructured document of the form
+// {"apiVersion": ..., "kind": ..., "metadata": {...}, "spec": {...}}, with the spec in its JSON representation. This is
+// the form in which user supplied policies and checks see resources.
+func ResourceDocument(r *resource.Instance) (map[string]interface{}, error) {
+	metadata := map[string]interface{}{
+		"name":        r.Metadata.FullName.Name.String(),
+		"namespace":   r.Metadata.FullName.Namespace.String(),
+		"labels":      toInterfaceMap(r.Metadata.Labels),
+		"annotations": toInterfaceMap(r.Metadata.Annotations),
+	}
+	if r.Metadata.Version != "" {
+		metadata["resourceVersion"] = string(r.Metadata.Version)
+	}
+
+	doc := map[string]interface{}{
+		"metadata": metadata,
+	}
+	if r.Metadata.Schema != nil {
+		doc["apiVersion"] = r.Metadata.Schema.APIVersion()
+		doc["kind"] = r.Metadata.Schema.Kind()
+	}
+
+	if r.Message != nil {
+		spec, err := gogoprotomarshal.ToJSONMap(r.Message)
+		if err != nil {
+			return nil, err
+		}
+		doc["spec"] = spec
+	}
+
+	return doc, nil
+}
+
+func toInterfaceMap(m map[string]string) map[string]interface{} {
+	result := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		result[k] = v
+	}
+	return result
+}
@@ -0,0 +1,195 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package gatekeeper generates Gatekeeper ConstraintTemplates and Constraints that enforce the checks of selected
+// analyzers at admission time. Only analyzers that look at a single resource in isolation can be expressed this way;
+// analyzers that correlate several resources need the full analysis snapshot.
+package gatekeeper
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+)
+
+const (
+	templateAPIVersion   = "templates.gatekeeper.sh/v1beta1"
+	constraintAPIVersion = "constraints.gatekeeper.sh/v1beta1"
+	admissionTarget      = "admission.k8s.gatekeeper.sh"
+)
+
+// matchKind selects the resources a constraint applies to.
+type matchKind struct {
+	apiGroups []string
+	kinds     []string
+}
+
+// template describes how the checks of a single analyzer are enforced with Gatekeeper.
+type template struct {
+	// analyzer is the name of the analyzer, as returned by its Metadata.
+	analyzer string
+
+	// kind of the generated Constraint. The ConstraintTemplate is named after the lowercased kind.
+	kind string
+
+	// constraintName is the name of the generated Constraint.
+	constraintName string
+
+	match              []matchKind
+	excludedNamespaces []string
+
+	// rego is the policy, in a package named after the lowercased kind.
+	rego string
+
+	// parameters returns the parameters of the Constraint, or nil if there are none.
+	parameters func() map[string]interface{}
+}
+
+var templates = []*template{
+	annotationsTemplate,
+	deprecatedFieldsTemplate,
+	namespaceInjectionTemplate,
+	portNameTemplate,
+}
+
+// Supported returns the names of the analyzers that can be converted to Gatekeeper constraints, sorted by name.
+func Supported() []string {
+	var names []string
+	for _, t := range templates {
+		names = append(names, t.analyzer)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Generate returns a ConstraintTemplate and a Constraint for each of the named analyzers, in that order. If no
+// analyzers are named, all supported analyzers are converted.
+func Generate(analyzers ...string) ([]map[string]interface{}, error) {
+	if len(analyzers) == 0 {
+		analyzers = Supported()
+	}
+
+	var result []map[string]interface{}
+	for _, name := range analyzers {
+		t := lookup(name)
+		if t == nil {
+			return nil, fmt.Errorf("analyzer %q cannot be converted to a Gatekeeper constraint (supported: %s)",
+				name, strings.Join(Supported(), ", "))
+		}
+		result = append(result, t.constraintTemplate(), t.constraint())
+	}
+	return result, nil
+}
+
+// GenerateYAML is like Generate, but renders the resources as a multi-document YAML stream.
+func GenerateYAML(analyzers ...string) ([]byte, error) {
+	resources, err := Generate(analyzers...)
+	if err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	for i, r := range resources {
+		if i > 0 {
+			b.WriteString("---\n")
+		}
+		y, err := yaml.Marshal(r)
+		if err != nil {
+			return nil, err
+		}
+		b.Write(y)
+	}
+	return b.Bytes(), nil
+}
+
+func lookup(analyzer string) *template {
+	for _, t := range templates {
+		if t.analyzer == analyzer {
+			return t
+		}
+	}
+	return nil
+}
+
+func (t *template) constraintTemplate() map[string]interface{} {
+	name := strings.ToLower(t.kind)
+	return map[string]interface{}{
+		"apiVersion": templateAPIVersion,
+		"kind":       "ConstraintTemplate",
+		"metadata": map[string]interface{}{
+			"name": name,
+			"annotations": map[string]interface{}{
+				"analysis.istio.io/analyzer": t.analyzer,
+			},
+		},
+		"spec": map[string]interface{}{
+			"crd": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"names": map[string]interface{}{
+						"kind": t.kind,
+					},
+				},
+			},
+			"targets": []interface{}{
+				map[string]interface{}{
+					"target": admissionTarget,
+					"rego":   "package " + name + "\n" + t.rego,
+				},
+			},
+		},
+	}
+}
+
+func (t *template) constraint() map[string]interface{} {
+	var kinds []interface{}
+	for _, m := range t.match {
+		kinds = append(kinds, map[string]interface{}{
+			"apiGroups": toInterfaceSlice(m.apiGroups),
+			"kinds":     toInterfaceSlice(m.kinds),
+		})
+	}
+	match := map[string]interface{}{
+		"kinds": kinds,
+	}
+	if len(t.excludedNamespaces) > 0 {
+		match["excludedNamespaces"] = toInterfaceSlice(t.excludedNamespaces)
+	}
+
+	spec := map[string]interface{}{
+		"match": match,
+	}
+	if t.parameters != nil {
+		spec["parameters"] = t.parameters()
+	}
+
+	return map[string]interface{}{
+		"apiVersion": constraintAPIVersion,
+		"kind":       t.kind,
+		"metadata": map[string]interface{}{
+			"name": t.constraintName,
+		},
+		"spec": spec,
+	}
+}
+
+func toInterfaceSlice(s []string) []interface{} {
+	result := make([]interface{}, 0, len(s))
+	for _, v := range s {
+		result = append(result, v)
+	}
+	return result
+}
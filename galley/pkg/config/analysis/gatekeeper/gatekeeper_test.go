@@ -0,0 +1,233 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gatekeeper
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/open-policy-agent/opa/rego"
+)
+
+func TestGenerate(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	resources, err := Generate()
+	g.Expect(err).To(BeNil())
+	g.Expect(resources).To(HaveLen(2 * len(Supported())))
+
+	for i := 0; i < len(resources); i += 2 {
+		ct, c := resources[i], resources[i+1]
+		g.Expect(ct["kind"]).To(Equal("ConstraintTemplate"))
+		g.Expect(ct["apiVersion"]).To(Equal(templateAPIVersion))
+		g.Expect(c["apiVersion"]).To(Equal(constraintAPIVersion))
+
+		kind := ct["spec"].(map[string]interface{})["crd"].(map[string]interface{})["spec"].(map[string]interface{})["names"].(map[string]interface{})["kind"]
+		g.Expect(c["kind"]).To(Equal(kind))
+		g.Expect(ct["metadata"].(map[string]interface{})["name"]).To(Equal(strings.ToLower(kind.(string))))
+	}
+}
+
+func TestGenerateSelected(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	resources, err := Generate("service.PortNameAnalyzer")
+	g.Expect(err).To(BeNil())
+	g.Expect(resources).To(HaveLen(2))
+	g.Expect(resources[1]["kind"]).To(Equal("IstioPortNameConvention"))
+
+	_, err = Generate("virtualservice.GatewayAnalyzer")
+	g.Expect(err).NotTo(BeNil())
+}
+
+func TestGenerateYAML(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	y, err := GenerateYAML("service.PortNameAnalyzer", "injection.Analyzer")
+	g.Expect(err).To(BeNil())
+	g.Expect(strings.Split(string(y), "---\n")).To(HaveLen(4))
+	g.Expect(string(y)).To(ContainSubstring("kind: IstioNamespaceInjection"))
+}
+
+func TestPolicies(t *testing.T) {
+	cases := []struct {
+		analyzer string
+		kind     string
+		object   map[string]interface{}
+		want     []string
+	}{
+		{
+			analyzer: "service.PortNameAnalyzer",
+			kind:     "Service",
+			object: map[string]interface{}{
+				"metadata": map[string]interface{}{"name": "svc"},
+				"spec": map[string]interface{}{
+					"ports": []interface{}{
+						map[string]interface{}{"name": "http-web", "port": 80},
+						map[string]interface{}{"name": "grpc-web-ui", "port": 8080},
+						map[string]interface{}{"name": "dns", "port": 53},
+						map[string]interface{}{"name": "syslog", "port": 514, "protocol": "UDP"},
+						map[string]interface{}{"name": "foo", "port": 8081, "appProtocol": "http"},
+						map[string]interface{}{"name": "bad", "port": 9090, "targetPort": 9091},
+					},
+				},
+			},
+			want: []string{"[IST0118] Port name bad (port: 9090, targetPort: 9091)"},
+		},
+		{
+			analyzer: "service.PortNameAnalyzer",
+			kind:     "Service",
+			object: map[string]interface{}{
+				"metadata": map[string]interface{}{"name": "istiod", "labels": map[string]interface{}{"istio": "pilot"}},
+				"spec": map[string]interface{}{
+					"ports": []interface{}{
+						map[string]interface{}{"name": "bad", "port": 9090},
+					},
+				},
+			},
+		},
+		{
+			analyzer: "injection.Analyzer",
+			kind:     "Namespace",
+			object: map[string]interface{}{
+				"metadata": map[string]interface{}{"name": "ns"},
+			},
+			want: []string{"[IST0102]"},
+		},
+		{
+			analyzer: "injection.Analyzer",
+			kind:     "Namespace",
+			object: map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"name":   "ns",
+					"labels": map[string]interface{}{"istio-injection": "enabled", "istio.io/rev": "canary"},
+				},
+			},
+			want: []string{"[IST0123]"},
+		},
+		{
+			analyzer: "injection.Analyzer",
+			kind:     "Namespace",
+			object: map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"name":   "ns",
+					"labels": map[string]interface{}{"istio.io/rev": "canary"},
+				},
+			},
+		},
+		{
+			analyzer: "annotations.K8sAnalyzer",
+			kind:     "Pod",
+			object: map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"name": "pod",
+					"annotations": map[string]interface{}{
+						"sidecar.istio.io/inject": "false",
+						"example.com/foo":         "bar",
+						"sidecar.istio.io/fake":   "true",
+					},
+				},
+			},
+			want: []string{"[IST0108] Unknown annotation: sidecar.istio.io/fake"},
+		},
+		{
+			analyzer: "deprecation.DeprecationAnalyzer",
+			kind:     "VirtualService",
+			object: map[string]interface{}{
+				"metadata": map[string]interface{}{"name": "vs"},
+				"spec": map[string]interface{}{
+					"http": []interface{}{
+						map[string]interface{}{"fault": map[string]interface{}{"delay": map[string]interface{}{"percent": 50}}},
+					},
+				},
+			},
+			want: []string{"[IST0002]"},
+		},
+		{
+			analyzer: "deprecation.DeprecationAnalyzer",
+			kind:     "DestinationRule",
+			object: map[string]interface{}{
+				"metadata": map[string]interface{}{"name": "dr"},
+				"spec": map[string]interface{}{
+					"subsets": []interface{}{
+						map[string]interface{}{
+							"name": "v1",
+							"trafficPolicy": map[string]interface{}{
+								"outlierDetection": map[string]interface{}{"consecutiveErrors": 5},
+							},
+						},
+					},
+				},
+			},
+			want: []string{"[IST0002] Deprecated: TrafficPolicy.outlierDetection.consecutiveErrors"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.analyzer+"/"+c.kind, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+
+			tmpl := lookup(c.analyzer)
+			g.Expect(tmpl).NotTo(BeNil())
+			g.Expect(evaluate(t, tmpl, c.kind, c.object)).To(ConsistOf(prefixMatchers(c.want)...))
+		})
+	}
+}
+
+func prefixMatchers(prefixes []string) []interface{} {
+	var result []interface{}
+	for _, p := range prefixes {
+		result = append(result, HavePrefix(p))
+	}
+	return result
+}
+
+// evaluate runs the template's policy against the given object, the way Gatekeeper does, and returns the violation
+// messages.
+func evaluate(t *testing.T, tmpl *template, kind string, object map[string]interface{}) []string {
+	t.Helper()
+
+	pkg := strings.ToLower(tmpl.kind)
+	input := map[string]interface{}{
+		"review": map[string]interface{}{
+			"kind":   map[string]interface{}{"kind": kind},
+			"object": object,
+		},
+	}
+	if tmpl.parameters != nil {
+		input["parameters"] = tmpl.parameters()
+	}
+
+	rs, err := rego.New(
+		rego.Module(pkg+".rego", "package "+pkg+"\n"+tmpl.rego),
+		rego.Query("data."+pkg+".violation"),
+		rego.Input(input),
+	).Eval(context.Background())
+	if err != nil {
+		t.Fatalf("error evaluating policy: %v", err)
+	}
+
+	var msgs []string
+	for _, r := range rs {
+		for _, e := range r.Expressions {
+			for _, v := range e.Value.([]interface{}) {
+				msgs = append(msgs, v.(map[string]interface{})["msg"].(string))
+			}
+		}
+	}
+	return msgs
+}
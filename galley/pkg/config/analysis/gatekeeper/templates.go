@@ -0,0 +1,251 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gatekeeper
+
+import (
+	"istio.io/api/annotation"
+
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/annotations"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/deprecation"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/injection"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/service"
+	configKube "istio.io/istio/pkg/config/kube"
+)
+
+// systemNamespaces are skipped by the analyzers, see util.IsSystemNamespace.
+var systemNamespaces = []string{"kube-system", "kube-public"}
+
+// portNameTemplate enforces service.PortNameAnalyzer. As in the analyzer, services that are part of the Istio control
+// plane are skipped.
+var portNameTemplate = &template{
+	analyzer:           (&service.PortNameAnalyzer{}).Metadata().Name,
+	kind:               "IstioPortNameConvention",
+	constraintName:     "istio-port-name-convention",
+	match:              []matchKind{{apiGroups: []string{""}, kinds: []string{"Service"}}},
+	excludedNamespaces: systemNamespaces,
+	rego: `
+violation[{"msg": msg}] {
+	not control_plane
+	port := input.review.object.spec.ports[_]
+	not port.protocol == "UDP"
+	not supported(port)
+	msg := sprintf("[IST0118] Port name %v (port: %v, targetPort: %v) doesn't follow the naming convention of Istio port.",
+		[object_get(port, "name", ""), port.port, object_get(port, "targetPort", "")])
+}
+
+control_plane {
+	input.review.object.metadata.labels.istio
+}
+
+control_plane {
+	input.review.object.metadata.labels.release == "istio"
+}
+
+port_name(port) = name {
+	name := port.appProtocol
+} else = name {
+	name := port.name
+} else = "" {
+	true
+}
+
+supported(port) {
+	startswith(lower(port_name(port)), "grpc-web")
+}
+
+supported(port) {
+	prefix := split(port_name(port), "-")[0]
+	input.parameters.protocols[_] == lower(prefix)
+}
+
+supported(port) {
+	input.parameters.wellKnownPorts[_] == port.port
+}
+
+object_get(obj, key, def) = value {
+	value := obj[key]
+} else = def {
+	true
+}
+`,
+	parameters: func() map[string]interface{} {
+		return map[string]interface{}{
+			// The port name prefixes accepted by protocol.Parse
+			"protocols": toInterfaceSlice([]string{
+				"grpc", "grpc-web", "http", "http2", "http_proxy", "https", "mongo", "mysql", "redis", "tcp", "thrift",
+				"tls", "udp",
+			}),
+			// Ports that default to TCP without a protocol prefix, see kube.ConvertProtocol
+			"wellKnownPorts": []interface{}{configKube.SMTP, configKube.DNS, configKube.MySQL, configKube.MongoDB},
+		}
+	},
+}
+
+// annotationsTemplate enforces annotations.K8sAnalyzer. Value validation of individual annotations is not converted.
+// In addition to unknown and misplaced annotations, annotations marked as deprecated in the annotation table are
+// reported.
+var annotationsTemplate = &template{
+	analyzer:       (&annotations.K8sAnalyzer{}).Metadata().Name,
+	kind:           "IstioAnnotations",
+	constraintName: "istio-annotations",
+	match: []matchKind{
+		{apiGroups: []string{""}, kinds: []string{"Namespace", "Pod", "Service"}},
+		{apiGroups: []string{"apps"}, kinds: []string{"Deployment"}},
+	},
+	rego: `
+violation[{"msg": msg}] {
+	input.review.object.metadata.annotations[ann] = _
+	istio_annotation(ann)
+	not known[ann]
+	msg := sprintf("[IST0108] Unknown annotation: %v", [ann])
+}
+
+violation[{"msg": msg}] {
+	input.review.object.metadata.annotations[ann] = _
+	a := known[ann]
+	not attaches(a, input.review.kind.kind)
+	msg := sprintf("[IST0107] Misplaced annotation: %v can only be applied to %v", [ann, concat(", ", a.resources)])
+}
+
+violation[{"msg": msg}] {
+	input.review.object.metadata.annotations[ann] = _
+	a := known[ann]
+	a.deprecated
+	msg := sprintf("[IST0002] Deprecated: annotation %v is deprecated", [ann])
+}
+
+istio_annotation(ann) {
+	ann == "kubernetes.io/ingress.class"
+}
+
+istio_annotation(ann) {
+	endswith(split(ann, "/")[0], "istio.io")
+}
+
+known[name] = a {
+	a := input.parameters.annotations[_]
+	name := a.name
+}
+
+attaches(a, kind) {
+	a.resources[_] == "Any"
+}
+
+attaches(a, kind) {
+	a.resources[_] == kind
+}
+`,
+	parameters: func() map[string]interface{} {
+		var anns []interface{}
+		for _, a := range annotation.AllResourceAnnotations() {
+			var resources []interface{}
+			for _, rt := range a.Resources {
+				if rt == annotation.Any {
+					resources = []interface{}{"Any"}
+					break
+				}
+				if s := rt.String(); s != "Unknown" {
+					resources = append(resources, s)
+				}
+			}
+			anns = append(anns, map[string]interface{}{
+				"name":       a.Name,
+				"resources":  resources,
+				"deprecated": a.Deprecated,
+			})
+		}
+		return map[string]interface{}{
+			"annotations": anns,
+		}
+	},
+}
+
+// namespaceInjectionTemplate enforces the namespace label checks of injection.Analyzer. The pod checks need the
+// injection configuration and are not converted.
+var namespaceInjectionTemplate = &template{
+	analyzer:           (&injection.Analyzer{}).Metadata().Name,
+	kind:               "IstioNamespaceInjection",
+	constraintName:     "istio-namespace-injection",
+	match:              []matchKind{{apiGroups: []string{""}, kinds: []string{"Namespace"}}},
+	excludedNamespaces: systemNamespaces,
+	rego: `
+violation[{"msg": msg}] {
+	not labels[input.parameters.injectionLabel] != ""
+	not has_label(input.parameters.revisionLabel)
+	ns := input.review.object.metadata.name
+	msg := sprintf("[IST0102] The namespace is not enabled for Istio injection. Run 'kubectl label namespace %v istio-injection=enabled' to enable it, or 'kubectl label namespace %v istio-injection=disabled' to explicitly mark it as not needing injection", [ns, ns])
+}
+
+violation[{"msg": msg}] {
+	labels[input.parameters.injectionLabel] != ""
+	has_label(input.parameters.revisionLabel)
+	ns := input.review.object.metadata.name
+	msg := sprintf("[IST0123] The namespace has both new and legacy injection labels. Run 'kubectl label namespace %v istio.io/rev-' or 'kubectl label namespace %v istio-injection-'", [ns, ns])
+}
+
+labels = l {
+	l := input.review.object.metadata.labels
+} else = {} {
+	true
+}
+
+has_label(name) {
+	labels[name] = _
+}
+`,
+	parameters: func() map[string]interface{} {
+		return map[string]interface{}{
+			"injectionLabel": injection.InjectionLabelName,
+			"revisionLabel":  injection.RevisionInjectionLabelName,
+		}
+	},
+}
+
+// deprecatedFieldsTemplate enforces deprecation.FieldAnalyzer.
+var deprecatedFieldsTemplate = &template{
+	analyzer:       (&deprecation.FieldAnalyzer{}).Metadata().Name,
+	kind:           "IstioDeprecatedFields",
+	constraintName: "istio-deprecated-fields",
+	match:          []matchKind{{apiGroups: []string{"networking.istio.io"}, kinds: []string{"DestinationRule", "VirtualService"}}},
+	rego: `
+violation[{"msg": msg}] {
+	route := input.review.object.spec.http[_]
+	route.fault.delay.percent > 0
+	msg := "[IST0002] Deprecated: HTTPRoute.fault.delay.percent is deprecated; use HTTPRoute.fault.delay.percentage"
+}
+
+violation[{"msg": msg}] {
+	traffic_policies[policy]
+	policy.outlierDetection.consecutiveErrors > 0
+	msg := "[IST0002] Deprecated: TrafficPolicy.outlierDetection.consecutiveErrors is deprecated; use TrafficPolicy.outlierDetection.consecutive5xxErrors or consecutiveGatewayErrors"
+}
+
+traffic_policies[policy] {
+	policy := input.review.object.spec.trafficPolicy
+}
+
+traffic_policies[policy] {
+	policy := input.review.object.spec.subsets[_].trafficPolicy
+}
+
+traffic_policies[policy] {
+	policy := input.review.object.spec.trafficPolicy.portLevelSettings[_]
+}
+
+traffic_policies[policy] {
+	policy := input.review.object.spec.subsets[_].trafficPolicy.portLevelSettings[_]
+}
+`,
+}
@@ -0,0 +1,227 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package history records when config analysis findings first appeared, were last seen and were resolved, and
+// reports on the trend of the findings over time.
+package history
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+	"istio.io/istio/galley/pkg/config/monitoring"
+	"istio.io/istio/galley/pkg/config/processing/snapshotter"
+	"istio.io/istio/galley/pkg/config/scope"
+)
+
+// DefaultRetention is the default time resolved findings are kept for.
+const DefaultRetention = 7 * 24 * time.Hour
+
+// Finding is the recorded history of a single finding.
+type Finding struct {
+	Code       string     `json:"code"`
+	Level      string     `json:"level"`
+	Origin     string     `json:"origin,omitempty"`
+	Message    string     `json:"message"`
+	FirstSeen  time.Time  `json:"firstSeen"`
+	ResolvedAt *time.Time `json:"resolvedAt,omitempty"`
+
+	// LastSeen is the time of the last analysis run that reported the finding. To limit the writes to the store, it
+	// is only saved along with other changes of the history.
+	LastSeen time.Time `json:"lastSeen"`
+}
+
+// Resolved returns true if the finding has been resolved.
+func (f *Finding) Resolved() bool {
+	return f.ResolvedAt != nil
+}
+
+func (f *Finding) key() string {
+	return f.Code + "/" + f.Origin + "/" + f.Message
+}
+
+func newFinding(m *diag.Message, now time.Time) Finding {
+	f := Finding{
+		Code:      m.Type.Code(),
+		Level:     m.Type.Level().String(),
+		Message:   fmt.Sprintf(m.Type.Template(), m.Parameters...),
+		FirstSeen: now,
+		LastSeen:  now,
+	}
+	if m.Resource != nil {
+		f.Origin = m.Resource.Origin.FriendlyName()
+	}
+	return f
+}
+
+// Options for a Recorder.
+type Options struct {
+	// Store that the history is persisted to.
+	Store Store
+
+	// Retention is the time resolved findings are kept for. Defaults to DefaultRetention.
+	Retention time.Duration
+}
+
+// Recorder is a snapshotter.StatusUpdater that records the history of the findings of continuous analysis. The
+// history is loaded from the store on the first update, and saved to it whenever it changes.
+type Recorder struct {
+	o   Options
+	now func() time.Time
+
+	mu       sync.RWMutex
+	loaded   bool
+	findings []Finding
+}
+
+var _ snapshotter.StatusUpdater = &Recorder{}
+
+// NewRecorder returns a new Recorder.
+func NewRecorder(o Options) *Recorder {
+	if o.Retention == 0 {
+		o.Retention = DefaultRetention
+	}
+	return &Recorder{
+		o:   o,
+		now: time.Now,
+	}
+}
+
+// Update implements snapshotter.StatusUpdater
+func (r *Recorder) Update(messages diag.Messages) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.loaded {
+		findings, err := r.o.Store.Load()
+		if err != nil {
+			scope.Analysis.Errorf("Error loading analysis history, starting afresh: %v", err)
+		}
+		r.findings = findings
+		r.loaded = true
+	}
+
+	now := r.now()
+	current := make(map[string]Finding, len(messages))
+	for i := range messages {
+		f := newFinding(&messages[i], now)
+		current[f.key()] = f
+	}
+
+	changed := false
+	introduced := make(map[string]int)
+	resolved := make(map[string]int)
+	var findings []Finding
+	for _, f := range r.findings {
+		if f.Resolved() {
+			if now.Sub(*f.ResolvedAt) > r.o.Retention {
+				changed = true
+				continue
+			}
+		} else if _, ok := current[f.key()]; ok {
+			f.LastSeen = now
+			delete(current, f.key())
+		} else {
+			resolvedAt := now
+			f.ResolvedAt = &resolvedAt
+			resolved[f.Code]++
+			changed = true
+		}
+		findings = append(findings, f)
+	}
+
+	// Add the remaining findings in the order they were reported.
+	for i := range messages {
+		f := newFinding(&messages[i], now)
+		if _, ok := current[f.key()]; ok {
+			findings = append(findings, f)
+			introduced[f.Code]++
+			delete(current, f.key())
+			changed = true
+		}
+	}
+
+	r.findings = findings
+	monitoring.RecordFindingsHistory(introduced, resolved, oldestOpen(findings, now))
+	if !changed {
+		return
+	}
+	if err := r.o.Store.Save(findings); err != nil {
+		scope.Analysis.Errorf("Error saving analysis history: %v", err)
+	}
+}
+
+// Findings returns the recorded findings, both open and resolved, in the order they first appeared.
+func (r *Recorder) Findings() []Finding {
+	return r.filter(func(*Finding) bool { return true })
+}
+
+// NewSince returns the findings that first appeared at or after the given time.
+func (r *Recorder) NewSince(t time.Time) []Finding {
+	return r.filter(func(f *Finding) bool { return !f.FirstSeen.Before(t) })
+}
+
+// ResolvedSince returns the findings that were resolved at or after the given time.
+func (r *Recorder) ResolvedSince(t time.Time) []Finding {
+	return r.filter(func(f *Finding) bool { return f.Resolved() && !f.ResolvedAt.Before(t) })
+}
+
+// MeanTimeToResolution returns the mean time between the first appearance and the resolution of the resolved findings
+// among the given ones, and the number of resolved findings. Combine with ResolvedSince to report over a time window.
+func MeanTimeToResolution(findings []Finding) (time.Duration, int) {
+	var total time.Duration
+	count := 0
+	for i := range findings {
+		f := &findings[i]
+		if !f.Resolved() {
+			continue
+		}
+		total += f.ResolvedAt.Sub(f.FirstSeen)
+		count++
+	}
+	if count == 0 {
+		return 0, 0
+	}
+	return total / time.Duration(count), count
+}
+
+// oldestOpen returns the age of the oldest open finding per message code.
+func oldestOpen(findings []Finding, now time.Time) map[string]time.Duration {
+	result := make(map[string]time.Duration)
+	for i := range findings {
+		f := &findings[i]
+		if f.Resolved() {
+			continue
+		}
+		if age, ok := result[f.Code]; !ok || now.Sub(f.FirstSeen) > age {
+			result[f.Code] = now.Sub(f.FirstSeen)
+		}
+	}
+	return result
+}
+
+func (r *Recorder) filter(fn func(*Finding) bool) []Finding {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var result []Finding
+	for i := range r.findings {
+		if fn(&r.findings[i]) {
+			result = append(result, r.findings[i])
+		}
+	}
+	return result
+}
@@ -0,0 +1,275 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package history
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+	"istio.io/istio/galley/pkg/config/source/kube/rt"
+	"istio.io/istio/galley/pkg/config/testing/basicmeta"
+	"istio.io/istio/pkg/config/resource"
+)
+
+var testType = diag.NewMessageType(diag.Error, "TEST0001", "broken %s")
+var otherType = diag.NewMessageType(diag.Warning, "TEST0002", "odd %s")
+
+func newMessage(name string) diag.Message {
+	return newMessageOfType(testType, name)
+}
+
+func newMessageOfType(t *diag.MessageType, name string) diag.Message {
+	r := &resource.Instance{
+		Origin: &rt.Origin{
+			Collection: basicmeta.K8SCollection1.Name(),
+			Kind:       "Kind1",
+			FullName:   resource.NewFullName("ns", resource.LocalName(name)),
+		},
+	}
+	return diag.NewMessage(t, r, name)
+}
+
+type fakeClock struct {
+	t time.Time
+}
+
+func (c *fakeClock) now() time.Time {
+	return c.t
+}
+
+func (c *fakeClock) step(d time.Duration) {
+	c.t = c.t.Add(d)
+}
+
+func newTestRecorder(s Store, retention time.Duration) (*Recorder, *fakeClock) {
+	c := &fakeClock{t: time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)}
+	r := NewRecorder(Options{Store: s, Retention: retention})
+	r.now = c.now
+	return r, c
+}
+
+func TestRecorder(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	s := &MemoryStore{}
+	r, c := newTestRecorder(s, 0)
+	start := c.t
+
+	a := newMessage("a")
+	b := newMessage("b")
+
+	r.Update(diag.Messages{a})
+	g.Expect(r.Findings()).To(HaveLen(1))
+	f := r.Findings()[0]
+	g.Expect(f.Code).To(Equal("TEST0001"))
+	g.Expect(f.Level).To(Equal("Error"))
+	g.Expect(f.Origin).To(Equal("Kind1 a.ns"))
+	g.Expect(f.Message).To(Equal("broken a"))
+	g.Expect(f.FirstSeen).To(Equal(start))
+	g.Expect(f.LastSeen).To(Equal(start))
+	g.Expect(f.Resolved()).To(BeFalse())
+
+	c.step(time.Hour)
+	r.Update(diag.Messages{a, b})
+	g.Expect(r.Findings()).To(HaveLen(2))
+	g.Expect(r.Findings()[0].FirstSeen).To(Equal(start))
+	g.Expect(r.Findings()[0].LastSeen).To(Equal(start.Add(time.Hour)))
+	g.Expect(r.NewSince(start.Add(time.Minute))).To(HaveLen(1))
+	g.Expect(r.NewSince(start.Add(time.Minute))[0].Origin).To(Equal("Kind1 b.ns"))
+
+	c.step(time.Hour)
+	r.Update(diag.Messages{b})
+	resolved := r.ResolvedSince(start)
+	g.Expect(resolved).To(HaveLen(1))
+	g.Expect(resolved[0].Origin).To(Equal("Kind1 a.ns"))
+	g.Expect(*resolved[0].ResolvedAt).To(Equal(start.Add(2 * time.Hour)))
+
+	// A resolved finding that reappears starts a new record.
+	c.step(time.Hour)
+	r.Update(diag.Messages{a, b})
+	g.Expect(r.Findings()).To(HaveLen(3))
+	g.Expect(r.NewSince(c.t)).To(HaveLen(1))
+
+	// The history was saved, and a new recorder picks it up.
+	saved, err := s.Load()
+	g.Expect(err).To(BeNil())
+	g.Expect(saved).To(Equal(r.Findings()))
+
+	r2, c2 := newTestRecorder(s, 0)
+	c2.t = c.t
+	r2.Update(diag.Messages{a, b})
+	g.Expect(r2.Findings()).To(Equal(r.Findings()))
+}
+
+func TestRecorderRetention(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	r, c := newTestRecorder(&MemoryStore{}, time.Hour)
+
+	r.Update(diag.Messages{newMessage("a")})
+	c.step(time.Minute)
+	r.Update(nil)
+	g.Expect(r.Findings()).To(HaveLen(1))
+
+	c.step(2 * time.Hour)
+	r.Update(nil)
+	g.Expect(r.Findings()).To(BeEmpty())
+}
+
+type errorStore struct {
+	saves int
+}
+
+func (s *errorStore) Load() ([]Finding, error) { return nil, errors.New("load failed") }
+
+func (s *errorStore) Save([]Finding) error {
+	s.saves++
+	return errors.New("save failed")
+}
+
+func TestRecorderStoreErrors(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	s := &errorStore{}
+	r, _ := newTestRecorder(s, 0)
+
+	r.Update(diag.Messages{newMessage("a")})
+	g.Expect(r.Findings()).To(HaveLen(1))
+	g.Expect(s.saves).To(Equal(1))
+
+	// Unchanged findings are not saved again.
+	r.Update(diag.Messages{newMessage("a")})
+	g.Expect(s.saves).To(Equal(1))
+}
+
+func TestMeanTimeToResolution(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	start := time.Now()
+	r1 := start.Add(time.Hour)
+	r2 := start.Add(3 * time.Hour)
+	findings := []Finding{
+		{FirstSeen: start, ResolvedAt: &r1},
+		{FirstSeen: start, ResolvedAt: &r2},
+		{FirstSeen: start},
+	}
+
+	mttr, n := MeanTimeToResolution(findings)
+	g.Expect(mttr).To(Equal(2 * time.Hour))
+	g.Expect(n).To(Equal(2))
+
+	mttr, n = MeanTimeToResolution(nil)
+	g.Expect(mttr).To(Equal(time.Duration(0)))
+	g.Expect(n).To(Equal(0))
+}
+
+func TestHTTPHandler(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	r, c := newTestRecorder(&MemoryStore{}, 0)
+	r.Update(diag.Messages{newMessage("a"), newMessage("b")})
+	c.step(48 * time.Hour)
+	r.Update(diag.Messages{newMessage("b"), newMessage("c")})
+	c.step(time.Hour)
+
+	w := httptest.NewRecorder()
+	NewHTTPHandler(r).ServeHTTP(w, httptest.NewRequest("GET", "/debug/analysis_history", nil))
+	g.Expect(w.Code).To(Equal(http.StatusOK))
+
+	var rep Report
+	g.Expect(json.Unmarshal(w.Body.Bytes(), &rep)).To(Succeed())
+	g.Expect(rep.Open).To(HaveLen(2))
+	g.Expect(rep.New).To(HaveLen(1))
+	g.Expect(rep.New[0].Origin).To(Equal("Kind1 c.ns"))
+	g.Expect(rep.Resolved).To(HaveLen(1))
+	g.Expect(rep.MeanTimeToResolution).To(Equal((48 * time.Hour).String()))
+
+	w = httptest.NewRecorder()
+	NewHTTPHandler(r).ServeHTTP(w, httptest.NewRequest("GET", "/debug/analysis_history?since=72h", nil))
+	g.Expect(json.Unmarshal(w.Body.Bytes(), &rep)).To(Succeed())
+	g.Expect(rep.New).To(HaveLen(3))
+
+	w = httptest.NewRecorder()
+	NewHTTPHandler(r).ServeHTTP(w, httptest.NewRequest("GET", "/debug/analysis_history?since=yesterday", nil))
+	g.Expect(w.Code).To(Equal(http.StatusBadRequest))
+
+	w = httptest.NewRecorder()
+	NewHTTPHandler(r).ServeHTTP(w, httptest.NewRequest("GET",
+		"/debug/analysis_history?since=72h&step=6h&code=TEST0001,TEST0002&code=TEST0003", nil))
+	g.Expect(w.Code).To(Equal(http.StatusOK))
+	rep = Report{}
+	g.Expect(json.Unmarshal(w.Body.Bytes(), &rep)).To(Succeed())
+	g.Expect(rep.Codes).To(HaveLen(1))
+	g.Expect(rep.Trend).To(HaveLen(13))
+
+	w = httptest.NewRecorder()
+	NewHTTPHandler(r).ServeHTTP(w, httptest.NewRequest("GET", "/debug/analysis_history?code=TEST0002", nil))
+	g.Expect(w.Code).To(Equal(http.StatusOK))
+	rep = Report{}
+	g.Expect(json.Unmarshal(w.Body.Bytes(), &rep)).To(Succeed())
+	g.Expect(rep.Open).To(BeEmpty())
+	g.Expect(rep.Codes).To(BeEmpty())
+
+	w = httptest.NewRecorder()
+	NewHTTPHandler(r).ServeHTTP(w, httptest.NewRequest("GET", "/debug/analysis_history?step=1s", nil))
+	g.Expect(w.Code).To(Equal(http.StatusBadRequest))
+}
+
+func TestReport(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	r, c := newTestRecorder(&MemoryStore{}, 0)
+	start := c.t
+	r.Update(diag.Messages{newMessage("a"), newMessage("b"), newMessageOfType(otherType, "c")})
+	c.step(2 * time.Hour)
+	r.Update(diag.Messages{newMessage("b"), newMessageOfType(otherType, "c")})
+	c.step(2 * time.Hour)
+	r.Update(diag.Messages{newMessage("b"), newMessage("d")})
+
+	rep := r.NewReportWithOptions(ReportOptions{Since: start.Add(time.Hour), Step: time.Hour})
+	g.Expect(rep.Open).To(HaveLen(2))
+	g.Expect(rep.New).To(HaveLen(1))
+	g.Expect(rep.Resolved).To(HaveLen(2))
+
+	oldest := start
+	g.Expect(rep.Codes).To(Equal([]CodeSummary{
+		{Code: "TEST0001", Open: 2, New: 1, Resolved: 1, OldestOpen: &oldest},
+		{Code: "TEST0002", Resolved: 1},
+	}))
+
+	// One point per hour: a was resolved after 2h, c after 4h, and d appeared after 4h.
+	g.Expect(rep.Trend).To(HaveLen(4))
+	g.Expect(rep.Trend[0]).To(Equal(TrendPoint{Time: start.Add(time.Hour), Open: 3,
+		OpenByCode: map[string]int{"TEST0001": 2, "TEST0002": 1}}))
+	g.Expect(rep.Trend[1].Open).To(Equal(2))
+	g.Expect(rep.Trend[2].Open).To(Equal(2))
+	g.Expect(rep.Trend[3]).To(Equal(TrendPoint{Time: start.Add(4 * time.Hour), Open: 2,
+		OpenByCode: map[string]int{"TEST0001": 2}}))
+
+	rep = r.NewReportWithOptions(ReportOptions{Since: start, Codes: []string{"TEST0002"}})
+	g.Expect(rep.Open).To(BeEmpty())
+	g.Expect(rep.New).To(HaveLen(1))
+	g.Expect(rep.Codes).To(Equal([]CodeSummary{{Code: "TEST0002", New: 1, Resolved: 1}}))
+	g.Expect(rep.Trend).To(HaveLen(DefaultTrendPoints + 1))
+	g.Expect(rep.Trend[0].Open).To(Equal(1))
+	g.Expect(rep.Trend[DefaultTrendPoints].Open).To(Equal(0))
+}
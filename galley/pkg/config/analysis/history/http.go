@@ -0,0 +1,212 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultReportWindow is the default window of a Report.
+	DefaultReportWindow = 24 * time.Hour
+
+	// DefaultTrendPoints is the default number of points of the trend of a Report.
+	DefaultTrendPoints = 24
+
+	// maxTrendPoints limits the size of the trend served over HTTP.
+	maxTrendPoints = 1000
+)
+
+// Report summarizes the findings history over a time window.
+type Report struct {
+	// Since is the start of the window.
+	Since time.Time `json:"since"`
+
+	// Open findings, regardless of when they first appeared.
+	Open []Finding `json:"open"`
+
+	// New findings that first appeared within the window.
+	New []Finding `json:"new"`
+
+	// Resolved findings that were resolved within the window.
+	Resolved []Finding `json:"resolved"`
+
+	// MeanTimeToResolution of the findings resolved within the window.
+	MeanTimeToResolution string `json:"meanTimeToResolution"`
+
+	// Codes summarizes the findings per message code, sorted by code.
+	Codes []CodeSummary `json:"codes"`
+
+	// Trend is the number of open findings over the window. As resolved findings are only kept for the retention of
+	// the Recorder, it undercounts before that.
+	Trend []TrendPoint `json:"trend"`
+}
+
+// CodeSummary summarizes the findings of a message code in a Report.
+type CodeSummary struct {
+	Code     string `json:"code"`
+	Open     int    `json:"open"`
+	New      int    `json:"new"`
+	Resolved int    `json:"resolved"`
+
+	// OldestOpen is the time the oldest open finding of the code first appeared, if there is one.
+	OldestOpen *time.Time `json:"oldestOpen,omitempty"`
+}
+
+// TrendPoint is the number of findings that were open at a point in time.
+type TrendPoint struct {
+	Time       time.Time      `json:"time"`
+	Open       int            `json:"open"`
+	OpenByCode map[string]int `json:"openByCode,omitempty"`
+}
+
+// ReportOptions select the findings of a Report and the resolution of its trend.
+type ReportOptions struct {
+	// Since is the start of the window.
+	Since time.Time
+
+	// Codes restricts the report to the findings with these message codes. All findings are reported if empty.
+	Codes []string
+
+	// Step is the time between the points of the trend. Defaults to the window divided by DefaultTrendPoints.
+	Step time.Duration
+}
+
+// NewReport returns a Report of the findings history since the given time.
+func (r *Recorder) NewReport(since time.Time) Report {
+	return r.NewReportWithOptions(ReportOptions{Since: since})
+}
+
+// NewReportWithOptions returns a Report of the findings history selected by the options.
+func (r *Recorder) NewReportWithOptions(o ReportOptions) Report {
+	codes := make(map[string]bool, len(o.Codes))
+	for _, c := range o.Codes {
+		codes[c] = true
+	}
+	findings := r.filter(func(f *Finding) bool { return len(codes) == 0 || codes[f.Code] })
+
+	rep := Report{Since: o.Since}
+	summaries := make(map[string]*CodeSummary)
+	for i := range findings {
+		f := &findings[i]
+		s, ok := summaries[f.Code]
+		if !ok {
+			s = &CodeSummary{Code: f.Code}
+			summaries[f.Code] = s
+		}
+
+		if !f.Resolved() {
+			rep.Open = append(rep.Open, *f)
+			s.Open++
+			if s.OldestOpen == nil || f.FirstSeen.Before(*s.OldestOpen) {
+				firstSeen := f.FirstSeen
+				s.OldestOpen = &firstSeen
+			}
+		}
+		if !f.FirstSeen.Before(o.Since) {
+			rep.New = append(rep.New, *f)
+			s.New++
+		}
+		if f.Resolved() && !f.ResolvedAt.Before(o.Since) {
+			rep.Resolved = append(rep.Resolved, *f)
+			s.Resolved++
+		}
+	}
+
+	mttr, _ := MeanTimeToResolution(rep.Resolved)
+	rep.MeanTimeToResolution = mttr.String()
+
+	for _, s := range summaries {
+		rep.Codes = append(rep.Codes, *s)
+	}
+	sort.Slice(rep.Codes, func(i, j int) bool { return rep.Codes[i].Code < rep.Codes[j].Code })
+
+	now := r.now()
+	step := o.Step
+	if step <= 0 {
+		step = now.Sub(o.Since) / DefaultTrendPoints
+	}
+	if step > 0 {
+		rep.Trend = trend(findings, o.Since, now, step)
+	}
+	return rep
+}
+
+// trend returns the number of the findings that were open at each step from since until now.
+func trend(findings []Finding, since, now time.Time, step time.Duration) []TrendPoint {
+	var result []TrendPoint
+	for t := since; !t.After(now); t = t.Add(step) {
+		p := TrendPoint{Time: t}
+		for i := range findings {
+			f := &findings[i]
+			if f.FirstSeen.After(t) || f.Resolved() && !f.ResolvedAt.After(t) {
+				continue
+			}
+			if p.OpenByCode == nil {
+				p.OpenByCode = make(map[string]int)
+			}
+			p.Open++
+			p.OpenByCode[f.Code]++
+		}
+		result = append(result, p)
+	}
+	return result
+}
+
+// NewHTTPHandler returns an http.Handler that serves a Report as JSON. The window is given by the "since" query
+// parameter as a duration (e.g. "24h") and defaults to DefaultReportWindow. The "code" query parameter restricts the
+// report to findings with the given message codes, and can be repeated or hold a comma-separated list. The "step"
+// query parameter sets the time between the points of the trend.
+func NewHTTPHandler(r *Recorder) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		query := req.URL.Query()
+		window := DefaultReportWindow
+		if s := query.Get("since"); s != "" {
+			d, err := time.ParseDuration(s)
+			if err != nil || d <= 0 {
+				http.Error(w, fmt.Sprintf("invalid since duration %q", s), http.StatusBadRequest)
+				return
+			}
+			window = d
+		}
+
+		o := ReportOptions{Since: r.now().Add(-window)}
+		if s := query.Get("step"); s != "" {
+			d, err := time.ParseDuration(s)
+			if err != nil || d <= 0 || window/d > maxTrendPoints {
+				http.Error(w, fmt.Sprintf("invalid step duration %q: must be positive, with at most %d steps in the "+
+					"window", s, maxTrendPoints), http.StatusBadRequest)
+				return
+			}
+			o.Step = d
+		}
+		for _, c := range query["code"] {
+			o.Codes = append(o.Codes, strings.Split(c, ",")...)
+		}
+
+		b, err := json.MarshalIndent(r.NewReportWithOptions(o), "", "  ")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(b)
+	})
+}
@@ -0,0 +1,133 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package history
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	v1 "k8s.io/client-go/kubernetes/typed/core/v1"
+)
+
+// Store persists the findings history.
+type Store interface {
+	// Load the findings. Returns no findings and no error if nothing was saved yet.
+	Load() ([]Finding, error)
+
+	// Save the findings, replacing any previously saved ones.
+	Save(findings []Finding) error
+}
+
+// MemoryStore is an in-memory Store, which does not persist the history across restarts.
+type MemoryStore struct {
+	mu       sync.Mutex
+	findings []Finding
+}
+
+var _ Store = &MemoryStore{}
+
+// Load implements Store
+func (s *MemoryStore) Load() ([]Finding, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Finding(nil), s.findings...), nil
+}
+
+// Save implements Store
+func (s *MemoryStore) Save(findings []Finding) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.findings = append([]Finding(nil), findings...)
+	return nil
+}
+
+const (
+	configMapDataKey = "findings"
+	configMapLabel   = "internal.istio.io/analysis-history"
+)
+
+// ConfigMapStore is a Store that keeps the findings as JSON in a ConfigMap. As ConfigMaps are limited in size, the
+// Recorder's retention should be chosen to keep the number of resolved findings bounded.
+type ConfigMapStore struct {
+	client v1.ConfigMapInterface
+	name   string
+}
+
+var _ Store = &ConfigMapStore{}
+
+// NewConfigMapStore returns a new Store backed by the named ConfigMap. The ConfigMap is created if necessary.
+func NewConfigMapStore(client v1.ConfigMapInterface, name string) *ConfigMapStore {
+	return &ConfigMapStore{
+		client: client,
+		name:   name,
+	}
+}
+
+// Load implements Store
+func (s *ConfigMapStore) Load() ([]Finding, error) {
+	cm, err := s.client.Get(context.TODO(), s.name, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	data := cm.Data[configMapDataKey]
+	if data == "" {
+		return nil, nil
+	}
+
+	var findings []Finding
+	if err := json.Unmarshal([]byte(data), &findings); err != nil {
+		return nil, err
+	}
+	return findings, nil
+}
+
+// Save implements Store
+func (s *ConfigMapStore) Save(findings []Finding) error {
+	b, err := json.Marshal(findings)
+	if err != nil {
+		return err
+	}
+
+	cm, err := s.client.Get(context.TODO(), s.name, metav1.GetOptions{})
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return err
+		}
+		cm = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   s.name,
+				Labels: map[string]string{configMapLabel: "true"},
+			},
+			Data: map[string]string{configMapDataKey: string(b)},
+		}
+		_, err = s.client.Create(context.TODO(), cm, metav1.CreateOptions{})
+		return err
+	}
+
+	if cm.Data == nil {
+		cm.Data = make(map[string]string)
+	}
+	cm.Data[configMapDataKey] = string(b)
+	_, err = s.client.Update(context.TODO(), cm, metav1.UpdateOptions{})
+	return err
+}
@@ -0,0 +1,69 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package history
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestConfigMapStore(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	client := fake.NewSimpleClientset().CoreV1().ConfigMaps("istio-system")
+	s := NewConfigMapStore(client, "analysis-history")
+
+	findings, err := s.Load()
+	g.Expect(err).To(BeNil())
+	g.Expect(findings).To(BeEmpty())
+
+	resolved := time.Date(2020, 5, 2, 0, 0, 0, 0, time.UTC)
+	want := []Finding{
+		{Code: "IST0101", Level: "Error", Origin: "Gateway gw.default", Message: "broken",
+			FirstSeen: time.Date(2020, 5, 1, 0, 0, 0, 0, time.UTC), ResolvedAt: &resolved},
+		{Code: "IST0102", Level: "Info", Message: "namespace not injected",
+			FirstSeen: time.Date(2020, 5, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	// Created on first save, updated afterwards.
+	g.Expect(s.Save(want[:1])).To(Succeed())
+	g.Expect(s.Save(want)).To(Succeed())
+
+	findings, err = s.Load()
+	g.Expect(err).To(BeNil())
+	g.Expect(findings).To(Equal(want))
+
+	cm, err := client.Get(context.TODO(), "analysis-history", metav1.GetOptions{})
+	g.Expect(err).To(BeNil())
+	g.Expect(cm.Labels).To(HaveKeyWithValue(configMapLabel, "true"))
+}
+
+func TestConfigMapStoreInvalidData(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	client := fake.NewSimpleClientset(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "analysis-history", Namespace: "istio-system"},
+		Data:       map[string]string{configMapDataKey: "not json"},
+	}).CoreV1().ConfigMaps("istio-system")
+
+	_, err := NewConfigMapStore(client, "analysis-history").Load()
+	g.Expect(err).NotTo(BeNil())
+}
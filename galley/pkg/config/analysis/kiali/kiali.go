@@ -0,0 +1,218 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package kiali maps config analysis messages to the equivalent Kiali validations, so that findings of both systems
+// can be cross-referenced, and Kiali can delegate the checks it duplicates to config analysis.
+package kiali
+
+import (
+	"sort"
+
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/galley/pkg/config/source/kube/rt"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+// Category is the kind of problem a check detects, as shared by config analysis and Kiali.
+type Category string
+
+const (
+	// MissingReference is a reference to a resource that does not exist.
+	MissingReference Category = "MissingReference"
+
+	// NoMatchingWorkload is a workload selector that selects no workloads.
+	NoMatchingWorkload Category = "NoMatchingWorkload"
+
+	// Conflict is a set of resources whose configuration conflicts.
+	Conflict Category = "Conflict"
+
+	// Naming is a name that does not follow Istio's conventions.
+	Naming Category = "Naming"
+)
+
+// Mapping relates an analysis message to a Kiali validation.
+type Mapping struct {
+	// Code of the analysis message, e.g. IST0101.
+	Code string
+
+	// KialiID of the Kiali validation, e.g. KIA1102.
+	KialiID string
+
+	// Collection of the resources the message is reported on.
+	Collection collection.Name
+
+	// RefType further qualifies ReferencedResourceNotFound messages by the type of the missing reference (the
+	// message's first parameter). Empty for other messages.
+	RefType string
+
+	// Category of the problem.
+	Category Category
+
+	// Exact is true if the analysis message detects exactly the problems the Kiali validation does, so that Kiali can
+	// delegate the check to config analysis. Otherwise the checks only partially overlap.
+	Exact bool
+}
+
+var mappings = []Mapping{
+	{
+		Code:       msg.ReferencedResourceNotFound.Code(),
+		KialiID:    "KIA1101",
+		Collection: collections.IstioNetworkingV1Alpha3Virtualservices.Name(),
+		RefType:    "host",
+		Category:   MissingReference,
+		Exact:      true,
+	},
+	{
+		Code:       msg.ReferencedResourceNotFound.Code(),
+		KialiID:    "KIA1102",
+		Collection: collections.IstioNetworkingV1Alpha3Virtualservices.Name(),
+		RefType:    "gateway",
+		Category:   MissingReference,
+		Exact:      true,
+	},
+	{
+		Code:       msg.ReferencedResourceNotFound.Code(),
+		KialiID:    "KIA1107",
+		Collection: collections.IstioNetworkingV1Alpha3Virtualservices.Name(),
+		RefType:    "host+subset in destinationrule",
+		Category:   MissingReference,
+		Exact:      true,
+	},
+	{
+		Code:       msg.ReferencedResourceNotFound.Code(),
+		KialiID:    "KIA0302",
+		Collection: collections.IstioNetworkingV1Alpha3Gateways.Name(),
+		RefType:    "selector",
+		Category:   NoMatchingWorkload,
+		Exact:      true,
+	},
+	{
+		Code:       msg.ReferencedResourceNotFound.Code(),
+		KialiID:    "KIA0004",
+		Collection: collections.IstioNetworkingV1Alpha3Sidecars.Name(),
+		RefType:    "selector",
+		Category:   NoMatchingWorkload,
+		Exact:      true,
+	},
+	{
+		Code:       msg.MultipleSidecarsWithoutWorkloadSelectors.Code(),
+		KialiID:    "KIA0002",
+		Collection: collections.IstioNetworkingV1Alpha3Sidecars.Name(),
+		Category:   Conflict,
+		Exact:      true,
+	},
+	{
+		Code:       msg.ConflictingSidecarWorkloadSelectors.Code(),
+		KialiID:    "KIA0003",
+		Collection: collections.IstioNetworkingV1Alpha3Sidecars.Name(),
+		Category:   Conflict,
+		Exact:      true,
+	},
+	{
+		// Kiali reports any VirtualServices sharing a host, analysis only those bound to the mesh gateway.
+		Code:       msg.ConflictingMeshGatewayVirtualServiceHosts.Code(),
+		KialiID:    "KIA1106",
+		Collection: collections.IstioNetworkingV1Alpha3Virtualservices.Name(),
+		Category:   Conflict,
+	},
+	{
+		Code:       msg.PortNameIsNotUnderNamingConvention.Code(),
+		KialiID:    "KIA0601",
+		Collection: collections.K8SCoreV1Services.Name(),
+		Category:   Naming,
+		Exact:      true,
+	},
+}
+
+// Mappings returns all known mappings between analysis messages and Kiali validations.
+func Mappings() []Mapping {
+	return append([]Mapping(nil), mappings...)
+}
+
+// KialiIDs returns the IDs of the Kiali validations that correspond to the given analysis message code, sorted.
+func KialiIDs(code string) []string {
+	var ids []string
+	for _, m := range mappings {
+		if m.Code == code {
+			ids = appendUnique(ids, m.KialiID)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Codes returns the analysis message codes that correspond to the given Kiali validation ID, sorted.
+func Codes(kialiID string) []string {
+	var codes []string
+	for _, m := range mappings {
+		if m.KialiID == kialiID {
+			codes = appendUnique(codes, m.Code)
+		}
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// Delegable returns the IDs of the Kiali validations that config analysis covers exactly, sorted.
+func Delegable() []string {
+	var ids []string
+	for _, m := range mappings {
+		if m.Exact {
+			ids = appendUnique(ids, m.KialiID)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Lookup returns the mapping of the given message to a Kiali validation, if there is exactly one. The message's
+// resource and parameters are used to disambiguate between mappings of the same code.
+func Lookup(m diag.Message) (Mapping, bool) {
+	var col collection.Name
+	if m.Resource != nil {
+		if o, ok := m.Resource.Origin.(*rt.Origin); ok {
+			col = o.Collection
+		}
+	}
+	var refType string
+	if m.Type == msg.ReferencedResourceNotFound && len(m.Parameters) > 0 {
+		refType, _ = m.Parameters[0].(string)
+	}
+
+	var found []Mapping
+	for _, mp := range mappings {
+		if mp.Code != m.Type.Code() || mp.RefType != refType {
+			continue
+		}
+		if col != "" && mp.Collection != col {
+			continue
+		}
+		found = append(found, mp)
+	}
+	if len(found) != 1 {
+		return Mapping{}, false
+	}
+	return found[0], true
+}
+
+func appendUnique(list []string, s string) []string {
+	for _, l := range list {
+		if l == s {
+			return list
+		}
+	}
+	return append(list, s)
+}
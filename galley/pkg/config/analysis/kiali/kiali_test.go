@@ -0,0 +1,109 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kiali
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/galley/pkg/config/source/kube/rt"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+func newResource(col collection.Name) *resource.Instance {
+	return &resource.Instance{
+		Origin: &rt.Origin{
+			Collection: col,
+			FullName:   resource.NewFullName("ns", "name"),
+		},
+	}
+}
+
+func TestMappingsAreValid(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	codes := make(map[string]bool)
+	for _, mt := range msg.All() {
+		codes[mt.Code()] = true
+	}
+
+	seen := make(map[Mapping]bool)
+	for _, m := range Mappings() {
+		g.Expect(codes).To(HaveKey(m.Code))
+		g.Expect(m.KialiID).To(MatchRegexp(`^KIA\d{4}$`))
+		_, found := collections.All.Find(m.Collection.String())
+		g.Expect(found).To(BeTrue(), m.Collection.String())
+		g.Expect(m.Category).NotTo(BeEmpty())
+		if m.RefType != "" {
+			g.Expect(m.Code).To(Equal(msg.ReferencedResourceNotFound.Code()))
+		}
+		g.Expect(seen).NotTo(HaveKey(m))
+		seen[m] = true
+	}
+}
+
+func TestKialiIDsAndCodes(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	g.Expect(KialiIDs(msg.ReferencedResourceNotFound.Code())).To(Equal(
+		[]string{"KIA0004", "KIA0302", "KIA1101", "KIA1102", "KIA1107"}))
+	g.Expect(KialiIDs(msg.PortNameIsNotUnderNamingConvention.Code())).To(Equal([]string{"KIA0601"}))
+	g.Expect(KialiIDs(msg.InternalError.Code())).To(BeEmpty())
+
+	g.Expect(Codes("KIA1102")).To(Equal([]string{msg.ReferencedResourceNotFound.Code()}))
+	g.Expect(Codes("KIA9999")).To(BeEmpty())
+
+	g.Expect(Delegable()).To(ContainElement("KIA1102"))
+	g.Expect(Delegable()).NotTo(ContainElement("KIA1106"))
+}
+
+func TestLookup(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	vs := newResource(collections.IstioNetworkingV1Alpha3Virtualservices.Name())
+	gw := newResource(collections.IstioNetworkingV1Alpha3Gateways.Name())
+	sc := newResource(collections.IstioNetworkingV1Alpha3Sidecars.Name())
+
+	m, ok := Lookup(msg.NewReferencedResourceNotFound(vs, "gateway", "gw"))
+	g.Expect(ok).To(BeTrue())
+	g.Expect(m.KialiID).To(Equal("KIA1102"))
+	g.Expect(m.Category).To(Equal(MissingReference))
+
+	m, ok = Lookup(msg.NewReferencedResourceNotFound(gw, "selector", "app=gw"))
+	g.Expect(ok).To(BeTrue())
+	g.Expect(m.KialiID).To(Equal("KIA0302"))
+
+	m, ok = Lookup(msg.NewReferencedResourceNotFound(sc, "selector", "app=foo"))
+	g.Expect(ok).To(BeTrue())
+	g.Expect(m.KialiID).To(Equal("KIA0004"))
+
+	m, ok = Lookup(msg.NewMultipleSidecarsWithoutWorkloadSelectors(sc, []string{"a", "b"}, "ns"))
+	g.Expect(ok).To(BeTrue())
+	g.Expect(m.KialiID).To(Equal("KIA0002"))
+
+	// No equivalent Kiali validation.
+	_, ok = Lookup(msg.NewReferencedResourceNotFound(gw, "credentialName", "secret"))
+	g.Expect(ok).To(BeFalse())
+	_, ok = Lookup(msg.NewInternalError(vs, "oops"))
+	g.Expect(ok).To(BeFalse())
+
+	// Ambiguous without a resource.
+	_, ok = Lookup(msg.NewReferencedResourceNotFound(nil, "selector", "app=foo"))
+	g.Expect(ok).To(BeFalse())
+}
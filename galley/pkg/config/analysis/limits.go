@@ -0,0 +1,266 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package analysis
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/gogo/protobuf/proto"
+
+	"istio.io/api/mesh/v1alpha1"
+
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/galley/pkg/config/analysis/pushed"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+)
+
+// DefaultLimits are limits that comfortably fit regular configuration, while protecting analysis against
+// pathological resources (e.g. generated EnvoyFilters or giant ConfigMaps).
+var DefaultLimits = Limits{
+	MaxResourceBytes: 512 * 1024,
+	MaxListLength:    5000,
+}
+
+// Limits bound the size of the resources that analyzers iterate over. Resources exceeding any of the limits are
+// skipped when iterating over a collection, and a ResourceTooLarge message is reported for them instead. Lookups by
+// name are not affected, so that references to such resources still resolve. A zero value disables the limit.
+type Limits struct {
+	// MaxResourceBytes is the maximum serialized size of a resource.
+	MaxResourceBytes int
+
+	// MaxListLength is the maximum number of entries in any list or map within a resource.
+	MaxListLength int
+}
+
+func (l Limits) enabled() bool {
+	return l.MaxResourceBytes > 0 || l.MaxListLength > 0
+}
+
+// Check returns a description of the limit exceeded by the resource, or the empty string if the resource is within
+// the limits.
+func (l Limits) Check(r *resource.Instance) string {
+	if r.Message == nil {
+		return ""
+	}
+
+	if l.MaxResourceBytes > 0 {
+		if size := proto.Size(r.Message); size > l.MaxResourceBytes {
+			return fmt.Sprintf("size of %d bytes exceeds the limit of %d bytes", size, l.MaxResourceBytes)
+		}
+	}
+
+	if l.MaxListLength > 0 {
+		if n := maxListLength(reflect.ValueOf(r.Message), l.MaxListLength); n > l.MaxListLength {
+			return fmt.Sprintf("list of %d entries exceeds the limit of %d entries", n, l.MaxListLength)
+		}
+	}
+
+	return ""
+}
+
+// maxListLength returns the length of the longest list or map within v. It stops searching as soon as a list longer
+// than limit is found.
+func maxListLength(v reflect.Value, limit int) int {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return 0
+		}
+		return maxListLength(v.Elem(), limit)
+
+	case reflect.Struct:
+		longest := 0
+		for i := 0; i < v.NumField() && longest <= limit; i++ {
+			// Skip unexported fields, e.g. protobuf bookkeeping
+			if v.Type().Field(i).PkgPath != "" {
+				continue
+			}
+			if n := maxListLength(v.Field(i), limit); n > longest {
+				longest = n
+			}
+		}
+		return longest
+
+	case reflect.Slice:
+		// Byte slices are opaque data rather than lists, and are covered by the size limit
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return 0
+		}
+		longest := v.Len()
+		for i := 0; i < v.Len() && longest <= limit; i++ {
+			if n := maxListLength(v.Index(i), limit); n > longest {
+				longest = n
+			}
+		}
+		return longest
+
+	case reflect.Map:
+		longest := v.Len()
+		iter := v.MapRange()
+		for longest <= limit && iter.Next() {
+			if n := maxListLength(iter.Value(), limit); n > longest {
+				longest = n
+			}
+		}
+		return longest
+
+	default:
+		return 0
+	}
+}
+
+// limiter checks the resources of a single run against the limits, and is shared by all analyzers of the run. As the
+// base Context of the run, it passes on each ResourceTooLarge message once, no matter how many analyzers reported it
+// or replayed it from a ResultCache.
+type limiter struct {
+	Context
+	limits Limits
+
+	mu       sync.Mutex
+	checked  map[*resource.Instance]string
+	reported map[string]bool
+}
+
+func newLimiter(ctx Context, limits Limits) *limiter {
+	return &limiter{
+		Context:  ctx,
+		limits:   limits,
+		checked:  make(map[*resource.Instance]string),
+		reported: make(map[string]bool),
+	}
+}
+
+// Report implements Context
+func (l *limiter) Report(col collection.Name, m diag.Message) {
+	if m.Type == msg.ResourceTooLarge && m.Resource != nil {
+		key := col.String() + "/" + m.Resource.Metadata.FullName.String()
+		l.mu.Lock()
+		reported := l.reported[key]
+		l.reported[key] = true
+		l.mu.Unlock()
+		if reported {
+			return
+		}
+	}
+	l.Context.Report(col, m)
+}
+
+// Index implements IndexProvider
+func (l *limiter) Index(key string, build func() interface{}) interface{} {
+	return Index(l.Context, key, build)
+}
+
+// GoContext implements GoContextProvider
+func (l *limiter) GoContext() context.Context {
+	return GoContext(l.Context)
+}
+
+// IstioVersion implements VersionProvider
+func (l *limiter) IstioVersion() string {
+	return IstioVersion(l.Context)
+}
+
+// Clusters implements ClusterProvider
+func (l *limiter) Clusters() []string {
+	return Clusters(l.Context)
+}
+
+// ResourceClusters implements ClusterProvider
+func (l *limiter) ResourceClusters(col collection.Name, name resource.FullName) []string {
+	return ResourceClusters(l.Context, col, name)
+}
+
+// ClusterMeshNetworks implements ClusterProvider
+func (l *limiter) ClusterMeshNetworks(cluster string) *v1alpha1.MeshNetworks {
+	return ClusterMeshNetworks(l.Context, cluster)
+}
+
+// PushedConfig implements PushedConfigProvider
+func (l *limiter) PushedConfig() *pushed.Config {
+	return PushedConfig(l.Context)
+}
+
+// check returns a description of the limit exceeded by the resource, or the empty string if the resource is within
+// the limits. Each resource is only checked once per run.
+func (l *limiter) check(r *resource.Instance) string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	reason, found := l.checked[r]
+	if !found {
+		reason = l.limits.Check(r)
+		l.checked[r] = reason
+	}
+	return reason
+}
+
+// limitingContext is the Context of a single analyzer that hides resources exceeding the limits from iteration, and
+// reports them through the Context it wraps. So a ResultCache records the reports along with the other results of
+// the analyzer, and replays them while the resources are unchanged.
+type limitingContext struct {
+	Context
+	l *limiter
+}
+
+// ForEach implements Context
+func (c *limitingContext) ForEach(col collection.Name, fn IteratorFn) {
+	c.Context.ForEach(col, func(r *resource.Instance) bool {
+		if reason := c.l.check(r); reason != "" {
+			c.Context.Report(col, msg.NewResourceTooLarge(r, reason))
+			return true
+		}
+		return fn(r)
+	})
+}
+
+// Index implements IndexProvider
+func (c *limitingContext) Index(key string, build func() interface{}) interface{} {
+	return Index(c.Context, key, build)
+}
+
+// GoContext implements GoContextProvider
+func (c *limitingContext) GoContext() context.Context {
+	return GoContext(c.Context)
+}
+
+// IstioVersion implements VersionProvider
+func (c *limitingContext) IstioVersion() string {
+	return IstioVersion(c.Context)
+}
+
+// Clusters implements ClusterProvider
+func (c *limitingContext) Clusters() []string {
+	return Clusters(c.Context)
+}
+
+// ResourceClusters implements ClusterProvider
+func (c *limitingContext) ResourceClusters(col collection.Name, name resource.FullName) []string {
+	return ResourceClusters(c.Context, col, name)
+}
+
+// ClusterMeshNetworks implements ClusterProvider
+func (c *limitingContext) ClusterMeshNetworks(cluster string) *v1alpha1.MeshNetworks {
+	return ClusterMeshNetworks(c.Context, cluster)
+}
+
+// PushedConfig implements PushedConfigProvider
+func (c *limitingContext) PushedConfig() *pushed.Config {
+	return PushedConfig(c.Context)
+}
@@ -0,0 +1,172 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package analysis
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"istio.io/api/networking/v1alpha3"
+
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+)
+
+func virtualService(name string, hosts int, routes int) *resource.Instance {
+	vs := &v1alpha3.VirtualService{}
+	for i := 0; i < hosts; i++ {
+		vs.Hosts = append(vs.Hosts, fmt.Sprintf("host-%d.example.com", i))
+	}
+	route := &v1alpha3.HTTPRoute{}
+	for i := 0; i < routes; i++ {
+		route.Route = append(route.Route, &v1alpha3.HTTPRouteDestination{
+			Destination: &v1alpha3.Destination{Host: fmt.Sprintf("dest-%d", i)},
+		})
+	}
+	vs.Http = []*v1alpha3.HTTPRoute{route}
+
+	return &resource.Instance{
+		Metadata: resource.Metadata{
+			FullName: resource.NewFullName("ns", resource.LocalName(name)),
+		},
+		Message: vs,
+	}
+}
+
+func TestLimitsCheck(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	small := virtualService("small", 2, 2)
+	manyHosts := virtualService("hosts", 20, 2)
+	manyRoutes := virtualService("routes", 2, 20)
+
+	g.Expect(Limits{}.Check(manyHosts)).To(BeEmpty())
+	g.Expect(Limits{}.enabled()).To(BeFalse())
+
+	l := Limits{MaxListLength: 10}
+	g.Expect(l.Check(small)).To(BeEmpty())
+	g.Expect(l.Check(manyHosts)).To(Equal("list of 20 entries exceeds the limit of 10 entries"))
+	g.Expect(l.Check(manyRoutes)).To(Equal("list of 20 entries exceeds the limit of 10 entries"))
+
+	l = Limits{MaxResourceBytes: 100}
+	g.Expect(l.Check(small)).To(BeEmpty())
+	g.Expect(l.Check(manyHosts)).To(MatchRegexp(`^size of \d+ bytes exceeds the limit of 100 bytes$`))
+
+	g.Expect(DefaultLimits.Check(&resource.Instance{})).To(BeEmpty())
+}
+
+func TestMaxListLength(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	type nested struct {
+		Data    map[string]string
+		Raw     []byte
+		Entries []interface{}
+		hidden  []int
+	}
+
+	v := &nested{
+		Data:   map[string]string{"a": "b", "c": "d"},
+		Raw:    []byte(strings.Repeat("x", 100)),
+		hidden: make([]int, 100),
+	}
+	g.Expect(maxListLength(reflect.ValueOf(v), 50)).To(Equal(2))
+
+	v.Entries = []interface{}{1, []int{1, 2, 3}, map[string][]int{"x": make([]int, 7)}}
+	g.Expect(maxListLength(reflect.ValueOf(v), 50)).To(Equal(7))
+
+	// Searching stops once the limit is exceeded
+	g.Expect(maxListLength(reflect.ValueOf(v), 2)).To(BeNumerically(">", 2))
+
+	g.Expect(maxListLength(reflect.ValueOf((*nested)(nil)), 50)).To(Equal(0))
+}
+
+func TestCombinedAnalyzerWithLimits(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	col1 := newSchema("col1")
+	small := virtualService("small", 2, 2)
+	large := virtualService("large", 20, 2)
+
+	ctx := &resourceContext{resources: map[collection.Name][]*resource.Instance{
+		col1.Name(): {small, large},
+	}}
+
+	a1 := &reportingAnalyzer{name: "a1", inputs: collection.Names{col1.Name()}}
+	a2 := &reportingAnalyzer{name: "a2", inputs: collection.Names{col1.Name()}}
+	a := Combine("combined", a1, a2)
+	a.SetLimits(Limits{MaxListLength: 10})
+
+	sub, err := a.Subset("a1")
+	g.Expect(err).To(BeNil())
+	g.Expect(sub.limits).To(Equal(a.limits))
+
+	a.Analyze(ctx)
+
+	var tooLarge, reported []string
+	for _, m := range ctx.reports {
+		if m.Type == msg.ResourceTooLarge {
+			tooLarge = append(tooLarge, m.Resource.Metadata.FullName.String())
+		} else {
+			reported = append(reported, m.Resource.Metadata.FullName.String())
+		}
+	}
+
+	// The large resource is reported once, and hidden from both analyzers
+	g.Expect(tooLarge).To(Equal([]string{"ns/large"}))
+	g.Expect(reported).To(Equal([]string{"ns/small", "ns/small"}))
+}
+
+func TestCombinedAnalyzerWithLimitsAndCache(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	col1 := newSchema("col1")
+	ctx := &resourceContext{resources: map[collection.Name][]*resource.Instance{
+		col1.Name(): {virtualService("small", 2, 2), virtualService("large", 20, 2)},
+	}}
+
+	a1 := &reportingAnalyzer{name: "a1", inputs: collection.Names{col1.Name()}}
+	a2 := &reportingAnalyzer{name: "a2", inputs: collection.Names{col1.Name()}}
+	a := Combine("combined", a1, a2)
+	a.SetLimits(Limits{MaxListLength: 10})
+	a.SetResultCache(NewResultCache())
+
+	tooLarge := func() []string {
+		var result []string
+		for _, m := range ctx.reports {
+			if m.Type == msg.ResourceTooLarge {
+				result = append(result, m.Resource.Metadata.FullName.String())
+			}
+		}
+		return result
+	}
+
+	a.Analyze(ctx)
+	g.Expect(tooLarge()).To(Equal([]string{"ns/large"}))
+	g.Expect(ctx.reports).To(HaveLen(3))
+
+	// The results of both analyzers are replayed from the cache, including the large resource.
+	ctx.reports = nil
+	a.Analyze(ctx)
+	g.Expect(a1.runs).To(Equal(1))
+	g.Expect(a2.runs).To(Equal(1))
+	g.Expect(tooLarge()).To(Equal([]string{"ns/large"}))
+	g.Expect(ctx.reports).To(HaveLen(3))
+}
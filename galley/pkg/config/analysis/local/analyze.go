@@ -19,6 +19,8 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -32,6 +34,7 @@ import (
 
 	"istio.io/istio/galley/pkg/config/analysis"
 	"istio.io/istio/galley/pkg/config/analysis/diag"
+	"istio.io/istio/galley/pkg/config/analysis/pushed"
 	galley_mesh "istio.io/istio/galley/pkg/config/mesh"
 	"istio.io/istio/galley/pkg/config/processing/snapshotter"
 	"istio.io/istio/galley/pkg/config/processing/transformer"
@@ -41,8 +44,10 @@ import (
 	"istio.io/istio/galley/pkg/config/source/kube"
 	"istio.io/istio/galley/pkg/config/source/kube/apiserver"
 	"istio.io/istio/galley/pkg/config/source/kube/inmemory"
+	"istio.io/istio/galley/pkg/config/source/kube/rt"
 	"istio.io/istio/galley/pkg/config/util/kuberesource"
 	"istio.io/istio/pkg/config/constants"
+	"istio.io/istio/pkg/config/event"
 	"istio.io/istio/pkg/config/mesh"
 	"istio.io/istio/pkg/config/resource"
 	"istio.io/istio/pkg/config/schema"
@@ -94,6 +99,26 @@ type SourceAnalyzer struct {
 
 	// How long to wait for snapshot + analysis to complete before aborting
 	timeout time.Duration
+
+	// Whether to record a resource accounting profile of the analysis run
+	profile bool
+
+	// Hook function called with the messages of each analyzer as soon as it completes
+	onAnalyzerDone analysis.AnalyzerDoneFn
+
+	// Istio control plane version that the configuration is checked against. Analyzers that don't apply to it are
+	// skipped. If empty, all analyzers run.
+	istioVersion string
+
+	// The clusters of a multi-cluster analysis, or nil if no cluster source has been added.
+	clusters *clusterInventory
+
+	// The configuration pushed to proxies, or nil if no config dump has been added.
+	pushedConfig *pushed.Config
+
+	// The part of the configuration that messages are reported about. If it has no namespaces, the namespace of the
+	// analyzer is used.
+	analysisScope snapshotter.AnalysisScope
 }
 
 // AnalysisResult represents the returnable results of an analysis execution
@@ -101,6 +126,13 @@ type AnalysisResult struct {
 	Messages          diag.Messages
 	SkippedAnalyzers  []string
 	ExecutedAnalyzers []string
+
+	// Profile is the resource accounting profile of the run, if profiling was enabled.
+	Profile *analysis.Profile
+
+	// SnapshotDuration is the time spent loading the sources and building the snapshot before the analyzers started
+	// running. It is only set if profiling was enabled.
+	SnapshotDuration time.Duration
 }
 
 // ReaderSource is a tuple of a io.Reader and filepath.
@@ -148,9 +180,11 @@ func NewSourceAnalyzer(m *schema.Metadata, analyzer *analysis.CombinedAnalyzer,
 	return sa
 }
 
-// Analyze loads the sources and executes the analysis
-func (sa *SourceAnalyzer) Analyze(cancel chan struct{}) (AnalysisResult, error) {
+// Analyze loads the sources and executes the analysis. Canceling ctx stops building snapshots and analyzing them, and
+// releases the sources, e.g. the informers of a running Kubernetes source.
+func (sa *SourceAnalyzer) Analyze(ctx context.Context) (AnalysisResult, error) {
 	var result AnalysisResult
+	start := time.Now()
 
 	// We need at least one non-meshcfg source
 	if len(sa.sources) == 0 {
@@ -177,18 +211,14 @@ func (sa *SourceAnalyzer) Analyze(cancel chan struct{}) (AnalysisResult, error)
 		},
 	})
 
-	var namespaces []resource.Namespace
-	if sa.namespace != "" {
+	namespaces := sa.analysisScope.Namespaces
+	if len(namespaces) == 0 && sa.namespace != "" {
 		namespaces = []resource.Namespace{sa.namespace}
 	}
 
-	var colsInSnapshots collection.Names
-	for _, c := range sa.m.AllCollectionsInSnapshots(analysisSnapshots) {
-		colsInSnapshots = append(colsInSnapshots, collection.NewName(c))
-	}
-
-	result.SkippedAnalyzers = sa.analyzer.RemoveSkipped(colsInSnapshots, sa.kubeResources.DisabledCollectionNames(),
+	result.SkippedAnalyzers = sa.analyzer.RemoveSkipped(AnalysisCollections(sa.m), sa.kubeResources.DisabledCollectionNames(),
 		sa.transformerProviders)
+	result.SkippedAnalyzers = append(result.SkippedAnalyzers, sa.analyzer.RemoveInapplicable(sa.istioVersion)...)
 	result.ExecutedAnalyzers = sa.analyzer.AnalyzerNames()
 
 	updater := &snapshotter.InMemoryStatusUpdater{
@@ -203,7 +233,17 @@ func (sa *SourceAnalyzer) Analyze(cancel chan struct{}) (AnalysisResult, error)
 		TriggerSnapshot:    snapshots.LocalAnalysis,
 		CollectionReporter: sa.collectionReporter,
 		AnalysisNamespaces: namespaces,
+		AnalysisSelector:   sa.analysisScope.Selector,
+		IncludeOutOfScope:  sa.analysisScope.IncludeOutOfScope,
 		Suppressions:       sa.suppressions,
+		Profile:            sa.profile,
+		OnAnalyzerDone:     sa.onAnalyzerDone,
+		Context:            ctx,
+		IstioVersion:       sa.istioVersion,
+		PushedConfig:       sa.pushedConfig,
+	}
+	if sa.clusters != nil {
+		distributorSettings.Clusters = sa.clusters
 	}
 	distributor := snapshotter.NewAnalyzingDistributor(distributorSettings)
 
@@ -221,19 +261,31 @@ func (sa *SourceAnalyzer) Analyze(cancel chan struct{}) (AnalysisResult, error)
 	}
 
 	rt.Start()
+	defer rt.Stop()
 
 	scope.Analysis.Debugf("Waiting for analysis messages to be available...")
-	if err := updater.WaitForReport(cancel); err != nil {
+	if err := updater.WaitForReport(ctx.Done()); err != nil {
 		return result, fmt.Errorf("failed to get analysis result: %v", err)
 	}
 
 	result.Messages = updater.Get()
-
-	rt.Stop()
+	result.Profile = distributor.LastProfile()
+	if result.Profile != nil {
+		result.SnapshotDuration = result.Profile.Started.Sub(start)
+	}
 
 	return result, nil
 }
 
+// AnalysisCollections returns the collections that are available to analyzers during local analysis.
+func AnalysisCollections(m *schema.Metadata) collection.Names {
+	var cols collection.Names
+	for _, c := range m.AllCollectionsInSnapshots(analysisSnapshots) {
+		cols = append(cols, collection.NewName(c))
+	}
+	return cols
+}
+
 // SetSuppressions will set the list of suppressions for the analyzer. Any
 // resource that matches the provided suppression will not be included in the
 // final message output.
@@ -241,8 +293,60 @@ func (sa *SourceAnalyzer) SetSuppressions(suppressions []snapshotter.AnalysisSup
 	sa.suppressions = suppressions
 }
 
+// SetScope restricts the messages to the given part of the configuration. All resources are still analyzed, so
+// that findings take the resources outside of the scope into account. The namespaces of the scope take precedence
+// over the namespace of the analyzer, which remains the default namespace of resources read from files.
+func (sa *SourceAnalyzer) SetScope(s snapshotter.AnalysisScope) {
+	sa.analysisScope = s
+}
+
+// SetProfiling enables or disables the recording of per-collection entry counts, per-analyzer allocations and peak
+// memory during analysis. The recorded profile is returned as part of the AnalysisResult.
+func (sa *SourceAnalyzer) SetProfiling(enabled bool) {
+	sa.profile = enabled
+}
+
+// SetStreamHandler sets a function that will be called with the (filtered) messages of each analyzer as soon as
+// that analyzer completes, allowing results to be rendered while slower analyzers are still running. The full result
+// set is still returned from Analyze.
+func (sa *SourceAnalyzer) SetStreamHandler(fn analysis.AnalyzerDoneFn) {
+	sa.onAnalyzerDone = fn
+}
+
+// SetIstioVersion sets the Istio control plane version that the configuration is checked against, overriding the
+// version detected from a running cluster. Analyzers that don't apply to this version are skipped.
+func (sa *SourceAnalyzer) SetIstioVersion(version string) {
+	sa.istioVersion = version
+}
+
+// IstioVersion returns the Istio control plane version that the configuration is checked against, or the empty
+// string if it is unknown.
+func (sa *SourceAnalyzer) IstioVersion() string {
+	return sa.istioVersion
+}
+
 // AddReaderKubeSource adds a source based on the specified k8s yaml files to the current SourceAnalyzer
 func (sa *SourceAnalyzer) AddReaderKubeSource(readers []ReaderSource) error {
+	src, errs := sa.readerKubeSource(readers)
+	sa.sources = append(sa.sources, precedenceSourceInput{src: src, cols: sa.kubeResources.CollectionNames()})
+	return errs
+}
+
+// AddReaderKubeClusterSource adds a source based on the specified k8s yaml files, e.g. exported from one of the
+// clusters of a multi-cluster mesh, under the given cluster name. See AddRunningKubeClusterSource.
+func (sa *SourceAnalyzer) AddReaderKubeClusterSource(cluster string, readers []ReaderSource) error {
+	if sa.clusters == nil {
+		sa.clusters = newClusterInventory()
+	}
+	src, errs := sa.readerKubeSource(readers)
+	sa.sources = append(sa.sources, precedenceSourceInput{
+		src:  newClusterSource(src, cluster, sa.clusters),
+		cols: sa.kubeResources.CollectionNames(),
+	})
+	return errs
+}
+
+func (sa *SourceAnalyzer) readerKubeSource(readers []ReaderSource) (event.Source, error) {
 	src := inmemory.NewKubeSource(sa.kubeResources)
 	src.SetDefaultNamespace(sa.namespace)
 
@@ -261,22 +365,49 @@ func (sa *SourceAnalyzer) AddReaderKubeSource(readers []ReaderSource) error {
 		}
 	}
 
-	sa.sources = append(sa.sources, precedenceSourceInput{src: src, cols: sa.kubeResources.CollectionNames()})
-
-	return errs
+	return src, errs
 }
 
 // AddRunningKubeSource adds a source based on a running k8s cluster to the current SourceAnalyzer
 // Also tries to get mesh config from the running cluster, if it can
 func (sa *SourceAnalyzer) AddRunningKubeSource(k kube.Interfaces) {
+	if src := sa.runningKubeSource(k, ""); src != nil {
+		sa.sources = append(sa.sources, precedenceSourceInput{src: src, cols: sa.kubeResources.CollectionNames()})
+	}
+}
+
+// AddRunningKubeClusterSource adds a source based on one of the running k8s clusters of a multi-cluster mesh, under
+// the given cluster name. The resources of all clusters are analyzed together in one snapshot, and are tagged with
+// the cluster they were read from, so that cross-cluster analyzers can compare the clusters (see analysis.Clusters).
+// Mesh config is read from the running cluster as with AddRunningKubeSource, and the clusters added last take
+// precedence.
+func (sa *SourceAnalyzer) AddRunningKubeClusterSource(cluster string, k kube.Interfaces) {
+	if sa.clusters == nil {
+		sa.clusters = newClusterInventory()
+	}
+	if src := sa.runningKubeSource(k, cluster); src != nil {
+		sa.sources = append(sa.sources, precedenceSourceInput{
+			src:  newClusterSource(src, cluster, sa.clusters),
+			cols: sa.kubeResources.CollectionNames(),
+		})
+	}
+}
+
+// runningKubeSource returns a source based on a running k8s cluster, and reads the mesh config and the Istio version
+// from it. The cluster name is only set for multi-cluster analysis. Nil is returned if the cluster can't be accessed.
+func (sa *SourceAnalyzer) runningKubeSource(k kube.Interfaces, cluster string) event.Source {
 	client, err := k.KubeClient()
 	if err != nil {
 		scope.Analysis.Errorf("error getting KubeClient: %v", err)
-		return
+		return nil
 	}
 
 	// Since we're using a running k8s source, try to get meshconfig and meshnetworks from the configmap.
-	if err := sa.addRunningKubeIstioConfigMapSource(client); err != nil {
+	if err := sa.addRunningKubeIstioConfigMapSource(client); err == nil {
+		if cluster != "" {
+			sa.clusters.setMeshNetworks(cluster, sa.meshNetworks)
+		}
+	} else {
 		_, err := client.CoreV1().Namespaces().Get(context.TODO(), sa.istioNamespace.String(), metav1.GetOptions{})
 		if kerrors.IsNotFound(err) {
 			scope.Analysis.Warnf("%v namespace not found. Istio may not be installed in the target cluster. "+
@@ -286,11 +417,38 @@ func (sa *SourceAnalyzer) AddRunningKubeSource(k kube.Interfaces) {
 		}
 	}
 
-	src := apiserverNew(apiserver.Options{
-		Client:  k,
-		Schemas: sa.kubeResources,
+	if sa.istioVersion == "" {
+		if sa.istioVersion, err = detectIstioVersion(client, sa.istioNamespace.String()); err != nil {
+			scope.Analysis.Warnf("Unable to detect the Istio control plane version, running all analyzers: %v", err)
+		}
+	}
+
+	// The informers of the source are only used for analysis, so the watched objects are compacted to keep the
+	// memory used for very large clusters in check.
+	return apiserverNew(apiserver.Options{
+		Client:    k,
+		Schemas:   sa.kubeResources,
+		Compactor: rt.NewCompactor(sa.unreadFields()...),
 	})
-	sa.sources = append(sa.sources, precedenceSourceInput{src: src, cols: sa.kubeResources.CollectionNames()})
+}
+
+// unreadFields returns the droppable fields of resources that none of the analyzers reads.
+func (sa *SourceAnalyzer) unreadFields() []string {
+	read := make(map[string]bool)
+	for _, f := range sa.analyzer.Metadata().Fields {
+		if f == analysis.AllFields {
+			return nil
+		}
+		read[f] = true
+	}
+
+	var result []string
+	for _, f := range rt.DroppableFields {
+		if !read[f] {
+			result = append(result, f)
+		}
+	}
+	return result
 }
 
 // AddFileKubeMeshConfig gets mesh config from the specified yaml file
@@ -309,6 +467,29 @@ func (sa *SourceAnalyzer) AddFileKubeMeshConfig(file string) error {
 	return nil
 }
 
+// AddFileConfigDump adds the Envoy config dump of a proxy in the given file, as returned by the /config_dump admin
+// endpoint of Envoy or the /debug/config_dump endpoint of istiod, to the configuration pushed to proxies that is
+// analyzed together with the resources (see analysis.PushedConfig). The proxy is identified by the node ID in the
+// dump, or by the file name if the dump does not include the bootstrap configuration.
+func (sa *SourceAnalyzer) AddFileConfigDump(file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	p, err := pushed.ReadConfigDump(strings.TrimSuffix(filepath.Base(file), filepath.Ext(file)), f)
+	if err != nil {
+		return err
+	}
+
+	if sa.pushedConfig == nil {
+		sa.pushedConfig = pushed.NewConfig()
+	}
+	sa.pushedConfig.Add(p)
+	return nil
+}
+
 // AddFileKubeMeshNetworks gets a file meshnetworks and add it to the analyzer.
 func (sa *SourceAnalyzer) AddFileKubeMeshNetworks(file string) error {
 	mn, err := mesh.ReadMeshNetworks(file)
@@ -320,6 +501,21 @@ func (sa *SourceAnalyzer) AddFileKubeMeshNetworks(file string) error {
 	return nil
 }
 
+// AddFileKubeClusterMeshNetworks sets the mesh networks configuration of one of the clusters of a multi-cluster
+// analysis from the specified file, for clusters added from files rather than running clusters.
+func (sa *SourceAnalyzer) AddFileKubeClusterMeshNetworks(cluster, file string) error {
+	mn, err := mesh.ReadMeshNetworks(file)
+	if err != nil {
+		return err
+	}
+
+	if sa.clusters == nil {
+		sa.clusters = newClusterInventory()
+	}
+	sa.clusters.setMeshNetworks(cluster, mn)
+	return nil
+}
+
 // AddDefaultResources adds some basic dummy Istio resources, based on mesh configuration.
 // This is useful for files-only analysis cases where we don't expect the user to be including istio system resources
 // and don't want to generate false positives because they aren't there.
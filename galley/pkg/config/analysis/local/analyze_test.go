@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -27,10 +28,13 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/diag"
 	"istio.io/istio/galley/pkg/config/analysis/msg"
 	"istio.io/istio/galley/pkg/config/mesh"
+	"istio.io/istio/galley/pkg/config/processing/snapshotter"
 	"istio.io/istio/galley/pkg/config/source/kube/apiserver"
 	"istio.io/istio/galley/pkg/config/source/kube/inmemory"
+	"istio.io/istio/galley/pkg/config/source/kube/rt"
 	"istio.io/istio/galley/pkg/config/testing/basicmeta"
 	"istio.io/istio/galley/pkg/config/testing/data"
 	"istio.io/istio/galley/pkg/config/testing/k8smeta"
@@ -39,11 +43,14 @@ import (
 	"istio.io/istio/pkg/config/resource"
 	"istio.io/istio/pkg/config/schema"
 	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
 )
 
 type testAnalyzer struct {
-	fn     func(analysis.Context)
-	inputs collection.Names
+	fn         func(analysis.Context)
+	inputs     collection.Names
+	minVersion string
+	fields     []string
 }
 
 var blankTestAnalyzer = &testAnalyzer{
@@ -59,8 +66,10 @@ var (
 // Metadata implements Analyzer
 func (a *testAnalyzer) Metadata() analysis.Metadata {
 	return analysis.Metadata{
-		Name:   "testAnalyzer",
-		Inputs: a.inputs,
+		Name:       "testAnalyzer",
+		Inputs:     a.inputs,
+		MinVersion: a.minVersion,
+		Fields:     a.fields,
 	}
 }
 
@@ -72,18 +81,14 @@ func (a *testAnalyzer) Analyze(ctx analysis.Context) {
 func TestAbortWithNoSources(t *testing.T) {
 	g := NewGomegaWithT(t)
 
-	cancel := make(chan struct{})
-
 	sa := NewSourceAnalyzer(k8smeta.MustGet(), blankCombinedAnalyzer, "", "", nil, false, timeout)
-	_, err := sa.Analyze(cancel)
+	_, err := sa.Analyze(context.Background())
 	g.Expect(err).To(Not(BeNil()))
 }
 
 func TestAnalyzersRun(t *testing.T) {
 	g := NewGomegaWithT(t)
 
-	cancel := make(chan struct{})
-
 	r := createTestResource(t, "ns", "resource", "v1")
 	m := msg.NewInternalError(r, "msg")
 	a := &testAnalyzer{
@@ -102,17 +107,165 @@ func TestAnalyzersRun(t *testing.T) {
 	err := sa.AddReaderKubeSource(nil)
 	g.Expect(err).To(BeNil())
 
-	result, err := sa.Analyze(cancel)
+	result, err := sa.Analyze(context.Background())
 	g.Expect(err).To(BeNil())
 	g.Expect(result.Messages).To(ConsistOf(m))
 	g.Expect(collectionAccessed).To(Equal(basicmeta.K8SCollection1.Name()))
 	g.Expect(result.ExecutedAnalyzers).To(ConsistOf(a.Metadata().Name))
 }
 
-func TestFilterOutputByNamespace(t *testing.T) {
+func TestAnalyzeSkipsInapplicableAnalyzers(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	ran := false
+	a := &testAnalyzer{
+		fn:         func(analysis.Context) { ran = true },
+		minVersion: "1.6",
+	}
+
+	sa := NewSourceAnalyzer(schema.MustGet(), analysis.Combine("a", a), "", "", nil, false, timeout)
+	sa.SetIstioVersion("1.5.2")
+	err := sa.AddReaderKubeSource(nil)
+	g.Expect(err).To(BeNil())
+
+	result, err := sa.Analyze(context.Background())
+	g.Expect(err).To(BeNil())
+	g.Expect(result.SkippedAnalyzers).To(ConsistOf(a.Metadata().Name))
+	g.Expect(result.ExecutedAnalyzers).To(BeEmpty())
+	g.Expect(ran).To(BeFalse())
+}
+
+func TestAnalyzeWithConfigDump(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tmpfile, err := ioutil.TempFile("", "ingressgateway-*.json")
+	g.Expect(err).To(BeNil())
+	defer func() { _ = os.Remove(tmpfile.Name()) }()
+	_, err = tmpfile.WriteString(`{"configs": [{"dynamic_route_configs": [{"route_config": {"name": "http.80",
+		"virtual_hosts": [{"name": "*:80", "routes": [{"metadata": {"filter_metadata": {"istio": {
+		"config": "/apis/networking.istio.io/v1alpha3/namespaces/default/virtual-service/reviews"}}}}]}]}}]}]}`)
+	g.Expect(err).To(BeNil())
+	g.Expect(tmpfile.Close()).To(BeNil())
+
+	var proxies []string
+	a := &testAnalyzer{
+		fn: func(ctx analysis.Context) {
+			proxies = analysis.PushedConfig(ctx).ProxiesOf(collections.IstioNetworkingV1Alpha3Virtualservices.Name(),
+				resource.NewFullName("default", "reviews"))
+		},
+		inputs: collection.Names{collections.IstioNetworkingV1Alpha3Virtualservices.Name()},
+	}
+
+	// The analyzer is given the context through all the wrappers of the analysis run.
+	combined := analysis.Combine("a", a)
+	combined.SetResultCache(analysis.NewResultCache())
+	combined.SetLimits(analysis.DefaultLimits)
+
+	sa := NewSourceAnalyzer(schema.MustGet(), combined, "", "", nil, false, timeout)
+	sa.SetProfiling(true)
+	g.Expect(sa.AddFileConfigDump(tmpfile.Name())).To(BeNil())
+	err = sa.AddReaderKubeSource(nil)
+	g.Expect(err).To(BeNil())
+
+	_, err = sa.Analyze(context.Background())
+	g.Expect(err).To(BeNil())
+	g.Expect(proxies).To(HaveLen(1))
+	g.Expect(proxies[0]).To(HavePrefix("ingressgateway-"))
+	g.Expect(proxies[0]).NotTo(HaveSuffix(".json"))
+
+	g.Expect(sa.AddFileConfigDump("nonexistent.json")).NotTo(BeNil())
+}
+
+func TestAnalyzeMultiCluster(t *testing.T) {
 	g := NewGomegaWithT(t)
 
-	cancel := make(chan struct{})
+	service := func(name string) string {
+		return fmt.Sprintf(`
+apiVersion: v1
+kind: Service
+metadata:
+  name: %s
+  namespace: default
+spec:
+  ports:
+  - port: 9080
+`, name)
+	}
+
+	var clusters, resourceClusters []string
+	a := &testAnalyzer{
+		fn: func(ctx analysis.Context) {
+			clusters = analysis.Clusters(ctx)
+			resourceClusters = analysis.ResourceClusters(ctx, collections.K8SCoreV1Services.Name(),
+				resource.NewFullName("default", "reviews"))
+		},
+		inputs: collection.Names{collections.K8SCoreV1Services.Name()},
+	}
+
+	// The analyzer is given the context through all the wrappers of the analysis run.
+	combined := analysis.Combine("a", a)
+	combined.SetResultCache(analysis.NewResultCache())
+	combined.SetLimits(analysis.DefaultLimits)
+
+	sa := NewSourceAnalyzer(schema.MustGet(), combined, "", "", nil, false, timeout)
+	sa.SetProfiling(true)
+	g.Expect(sa.AddReaderKubeClusterSource("west",
+		[]ReaderSource{{Name: "west.yaml", Reader: strings.NewReader(service("reviews"))}})).To(BeNil())
+	g.Expect(sa.AddReaderKubeClusterSource("east",
+		[]ReaderSource{{Name: "east.yaml", Reader: strings.NewReader(service("ratings"))}})).To(BeNil())
+
+	_, err := sa.Analyze(context.Background())
+	g.Expect(err).To(BeNil())
+	g.Expect(clusters).To(Equal([]string{"east", "west"}))
+	g.Expect(resourceClusters).To(Equal([]string{"west"}))
+}
+
+func TestAnalyzeCanceled(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	started := make(chan struct{})
+	stopped := make(chan struct{})
+	a := &testAnalyzer{
+		fn: func(actx analysis.Context) {
+			close(started)
+			<-analysis.GoContext(actx).Done()
+			close(stopped)
+		},
+	}
+
+	sa := NewSourceAnalyzer(schema.MustGet(), analysis.Combine("a", a), "", "", nil, false, time.Minute)
+	err := sa.AddReaderKubeSource(nil)
+	g.Expect(err).To(BeNil())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-started
+		cancel()
+	}()
+
+	_, err = sa.Analyze(ctx)
+	g.Expect(err).To(MatchError(ContainSubstring("cancelled")))
+	g.Eventually(stopped).Should(BeClosed())
+}
+
+func TestAnalyzeWithProfiling(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	sa := NewSourceAnalyzer(schema.MustGet(), analysis.Combine("a", blankTestAnalyzer), "", "", nil, false, timeout)
+	sa.SetProfiling(true)
+	err := sa.AddReaderKubeSource(nil)
+	g.Expect(err).To(BeNil())
+
+	result, err := sa.Analyze(context.Background())
+	g.Expect(err).To(BeNil())
+	g.Expect(result.Profile).NotTo(BeNil())
+	g.Expect(result.Profile.Analyzers).To(HaveLen(1))
+	g.Expect(result.Profile.CollectionCounts).NotTo(BeEmpty())
+	g.Expect(result.SnapshotDuration).To(BeNumerically(">", 0))
+}
+
+func TestAnalyzeStreamsResults(t *testing.T) {
+	g := NewGomegaWithT(t)
 
 	r1 := createTestResource(t, "ns1", "resource", "v1")
 	r2 := createTestResource(t, "ns2", "resource", "v1")
@@ -125,15 +278,87 @@ func TestFilterOutputByNamespace(t *testing.T) {
 		},
 	}
 
+	var streamed diag.Messages
 	sa := NewSourceAnalyzer(schema.MustGet(), analysis.Combine("a", a), "ns1", "", nil, false, timeout)
+	sa.SetStreamHandler(func(analyzer string, messages diag.Messages) {
+		g.Expect(analyzer).To(Equal(a.Metadata().Name))
+		streamed = append(streamed, messages...)
+	})
 	err := sa.AddReaderKubeSource(nil)
 	g.Expect(err).To(BeNil())
 
-	result, err := sa.Analyze(cancel)
+	result, err := sa.Analyze(context.Background())
 	g.Expect(err).To(BeNil())
+	g.Expect(streamed).To(ConsistOf(msg1))
 	g.Expect(result.Messages).To(ConsistOf(msg1))
 }
 
+func TestFilterOutputByNamespace(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	r1 := createTestResource(t, "ns1", "resource", "v1")
+	r2 := createTestResource(t, "ns2", "resource", "v1")
+	msg1 := msg.NewInternalError(r1, "msg")
+	msg2 := msg.NewInternalError(r2, "msg")
+	a := &testAnalyzer{
+		fn: func(ctx analysis.Context) {
+			ctx.Report(basicmeta.K8SCollection1.Name(), msg1)
+			ctx.Report(basicmeta.K8SCollection1.Name(), msg2)
+		},
+	}
+
+	sa := NewSourceAnalyzer(schema.MustGet(), analysis.Combine("a", a), "ns1", "", nil, false, timeout)
+	err := sa.AddReaderKubeSource(nil)
+	g.Expect(err).To(BeNil())
+
+	result, err := sa.Analyze(context.Background())
+	g.Expect(err).To(BeNil())
+	g.Expect(result.Messages).To(ConsistOf(msg1))
+}
+
+func TestFilterOutputByScope(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	r1 := createTestResource(t, "ns1", "resource", "v1")
+	r2 := createTestResource(t, "ns2", "resource", "v1")
+	r3 := createTestResource(t, "ns3", "resource", "v1")
+	msg1 := msg.NewInternalError(r1, "msg")
+	msg2 := msg.NewInternalError(r2, "msg")
+	msg3 := msg.NewInternalError(r3, "msg")
+	a := &testAnalyzer{
+		fn: func(ctx analysis.Context) {
+			ctx.Report(basicmeta.K8SCollection1.Name(), msg1)
+			ctx.Report(basicmeta.K8SCollection1.Name(), msg2)
+			ctx.Report(basicmeta.K8SCollection1.Name(), msg3)
+		},
+	}
+
+	// The namespaces of the scope take precedence over the namespace of the analyzer
+	sa := NewSourceAnalyzer(schema.MustGet(), analysis.Combine("a", a), "ns1", "", nil, false, timeout)
+	sa.SetScope(snapshotter.AnalysisScope{Namespaces: []resource.Namespace{"ns2", "ns3"}})
+	g.Expect(sa.AddReaderKubeSource(nil)).To(Succeed())
+
+	result, err := sa.Analyze(context.Background())
+	g.Expect(err).To(BeNil())
+	g.Expect(result.Messages).To(ConsistOf(msg2, msg3))
+
+	// Messages outside of the scope are kept with a note
+	sa = NewSourceAnalyzer(schema.MustGet(), analysis.Combine("a", a), "ns1", "", nil, false, timeout)
+	sa.SetScope(snapshotter.AnalysisScope{IncludeOutOfScope: true})
+	g.Expect(sa.AddReaderKubeSource(nil)).To(Succeed())
+
+	result, err = sa.Analyze(context.Background())
+	g.Expect(err).To(BeNil())
+	g.Expect(result.Messages).To(HaveLen(3))
+	for _, m := range result.Messages {
+		if m.Resource == r1 {
+			g.Expect(m.Notes).To(BeEmpty())
+		} else {
+			g.Expect(m.Notes).To(ConsistOf(snapshotter.OutOfScopeNote))
+		}
+	}
+}
+
 func TestAddRunningKubeSource(t *testing.T) {
 	g := NewGomegaWithT(t)
 
@@ -183,6 +408,43 @@ func TestAddRunningKubeSourceWithIstioMeshConfigMap(t *testing.T) {
 	g.Expect(sa.sources[0].src).To(BeAssignableToTypeOf(&apiserver.Source{})) // Resources via api server
 }
 
+func TestAddRunningKubeClusterSource(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	istioNamespace := resource.Namespace("istio-system")
+
+	cfg := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: meshConfigMapName,
+		},
+		Data: map[string]string{
+			meshConfigMapKey:   "",
+			meshNetworksMapKey: `networks: {"n1": {}, "n2": {}}`,
+		},
+	}
+
+	east := mock.NewKube()
+	west := mock.NewKube()
+	client, err := west.KubeClient()
+	if err != nil {
+		t.Fatalf("Error getting client for mock kube: %v", err)
+	}
+	if _, err := client.CoreV1().ConfigMaps(istioNamespace.String()).Create(context.TODO(), cfg, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("Error creating mesh config configmap: %v", err)
+	}
+
+	sa := NewSourceAnalyzer(k8smeta.MustGet(), blankCombinedAnalyzer, "", istioNamespace, nil, false, timeout)
+
+	sa.AddRunningKubeClusterSource("west", west)
+	sa.AddRunningKubeClusterSource("east", east)
+	g.Expect(sa.sources).To(HaveLen(2))
+	g.Expect(sa.sources[0].src).To(BeAssignableToTypeOf(&clusterSource{}))
+	g.Expect(sa.sources[1].src).To(BeAssignableToTypeOf(&clusterSource{}))
+	g.Expect(sa.clusters.Clusters()).To(Equal([]string{"east", "west"}))
+	g.Expect(sa.clusters.ClusterMeshNetworks("west").Networks).To(HaveLen(2))
+	g.Expect(sa.clusters.ClusterMeshNetworks("east")).To(BeNil())
+}
+
 func TestAddReaderKubeSource(t *testing.T) {
 	g := NewGomegaWithT(t)
 
@@ -274,6 +536,23 @@ func TestResourceFiltering(t *testing.T) {
 			g.Expect(r.IsDisabled()).To(BeTrue(), fmt.Sprintf("%s should be disabled", r.Name()))
 		}
 	}
+	g.Expect(recordedOptions.Compactor).NotTo(BeNil())
+}
+
+func TestUnreadFields(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	sa := NewSourceAnalyzer(schema.MustGet(), analysis.Combine("a", &testAnalyzer{}), "", "", nil, true, timeout)
+	g.Expect(sa.unreadFields()).To(Equal(rt.DroppableFields))
+
+	sa = NewSourceAnalyzer(schema.MustGet(), analysis.Combine("a", &testAnalyzer{fields: []string{rt.PodStatusField}}),
+		"", "", nil, true, timeout)
+	g.Expect(sa.unreadFields()).NotTo(ContainElement(rt.PodStatusField))
+	g.Expect(sa.unreadFields()).To(HaveLen(len(rt.DroppableFields) - 1))
+
+	sa = NewSourceAnalyzer(schema.MustGet(), analysis.Combine("a", &testAnalyzer{},
+		&testAnalyzer{fields: []string{analysis.AllFields}}), "", "", nil, true, timeout)
+	g.Expect(sa.unreadFields()).To(BeEmpty())
 }
 
 func tempFileFromString(t *testing.T, content string) *os.File {
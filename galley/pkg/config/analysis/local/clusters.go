@@ -0,0 +1,162 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package local
+
+import (
+	"sort"
+	"sync"
+
+	"istio.io/api/mesh/v1alpha1"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/source/kube/rt"
+	"istio.io/istio/pkg/config/event"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+)
+
+// clusterInventory records which clusters each resource of a multi-cluster analysis was read from, along with the
+// mesh networks configuration of each cluster. It is safe for concurrent use.
+type clusterInventory struct {
+	mu           sync.RWMutex
+	clusters     map[string]bool
+	resources    map[collection.Name]map[resource.FullName]map[string]bool
+	meshNetworks map[string]*v1alpha1.MeshNetworks
+}
+
+var _ analysis.ClusterProvider = &clusterInventory{}
+
+func newClusterInventory() *clusterInventory {
+	return &clusterInventory{
+		clusters:     make(map[string]bool),
+		resources:    make(map[collection.Name]map[resource.FullName]map[string]bool),
+		meshNetworks: make(map[string]*v1alpha1.MeshNetworks),
+	}
+}
+
+func (ci *clusterInventory) addCluster(cluster string) {
+	ci.mu.Lock()
+	defer ci.mu.Unlock()
+	ci.clusters[cluster] = true
+}
+
+func (ci *clusterInventory) setMeshNetworks(cluster string, mn *v1alpha1.MeshNetworks) {
+	ci.mu.Lock()
+	defer ci.mu.Unlock()
+	ci.meshNetworks[cluster] = mn
+}
+
+func (ci *clusterInventory) add(col collection.Name, name resource.FullName, cluster string) {
+	ci.mu.Lock()
+	defer ci.mu.Unlock()
+	names := ci.resources[col]
+	if names == nil {
+		names = make(map[resource.FullName]map[string]bool)
+		ci.resources[col] = names
+	}
+	if names[name] == nil {
+		names[name] = make(map[string]bool)
+	}
+	names[name][cluster] = true
+}
+
+func (ci *clusterInventory) remove(col collection.Name, name resource.FullName, cluster string) {
+	ci.mu.Lock()
+	defer ci.mu.Unlock()
+	clusters := ci.resources[col][name]
+	delete(clusters, cluster)
+	if len(clusters) == 0 {
+		delete(ci.resources[col], name)
+	}
+}
+
+// Clusters implements analysis.ClusterProvider
+func (ci *clusterInventory) Clusters() []string {
+	ci.mu.RLock()
+	defer ci.mu.RUnlock()
+	return sortedKeys(ci.clusters)
+}
+
+// ResourceClusters implements analysis.ClusterProvider
+func (ci *clusterInventory) ResourceClusters(col collection.Name, name resource.FullName) []string {
+	ci.mu.RLock()
+	defer ci.mu.RUnlock()
+	return sortedKeys(ci.resources[col][name])
+}
+
+// ClusterMeshNetworks implements analysis.ClusterProvider
+func (ci *clusterInventory) ClusterMeshNetworks(cluster string) *v1alpha1.MeshNetworks {
+	ci.mu.RLock()
+	defer ci.mu.RUnlock()
+	return ci.meshNetworks[cluster]
+}
+
+func sortedKeys(m map[string]bool) []string {
+	if len(m) == 0 {
+		return nil
+	}
+	result := make([]string, 0, len(m))
+	for k := range m {
+		result = append(result, k)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// clusterSource is an event.Source that tags the resources of a cluster's source with the name of the cluster, and
+// records them in the inventory.
+type clusterSource struct {
+	event.Source
+	cluster   string
+	inventory *clusterInventory
+}
+
+var _ event.Source = &clusterSource{}
+
+func newClusterSource(src event.Source, cluster string, inventory *clusterInventory) *clusterSource {
+	inventory.addCluster(cluster)
+	return &clusterSource{
+		Source:    src,
+		cluster:   cluster,
+		inventory: inventory,
+	}
+}
+
+// Dispatch implements event.Dispatcher
+func (s *clusterSource) Dispatch(h event.Handler) {
+	s.Source.Dispatch(event.HandlerFromFn(func(e event.Event) {
+		switch e.Kind {
+		case event.Added, event.Updated:
+			e.Resource = s.tag(e.Resource)
+			s.inventory.add(e.Source.Name(), e.Resource.Metadata.FullName, s.cluster)
+		case event.Deleted:
+			s.inventory.remove(e.Source.Name(), e.Resource.Metadata.FullName, s.cluster)
+		}
+		h.Handle(e)
+	}))
+}
+
+// tag returns a shallow copy of the resource, whose origin names the cluster.
+func (s *clusterSource) tag(r *resource.Instance) *resource.Instance {
+	o, ok := r.Origin.(*rt.Origin)
+	if !ok {
+		return r
+	}
+	origin := *o
+	origin.Cluster = s.cluster
+	tagged := *r
+	tagged.Origin = &origin
+	return &tagged
+}
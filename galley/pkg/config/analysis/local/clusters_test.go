@@ -0,0 +1,62 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package local
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"istio.io/istio/galley/pkg/config/source/kube/rt"
+	"istio.io/istio/galley/pkg/config/testing/basicmeta"
+	"istio.io/istio/galley/pkg/config/testing/fixtures"
+	"istio.io/istio/pkg/config/event"
+	"istio.io/istio/pkg/config/resource"
+)
+
+func TestClusterSource(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	inventory := newClusterInventory()
+	east := &fixtures.Source{}
+	west := &fixtures.Source{}
+	newClusterSource(east, "east", inventory).Dispatch(&fixtures.Accumulator{})
+	h := &fixtures.Accumulator{}
+	newClusterSource(west, "west", inventory).Dispatch(h)
+
+	col := basicmeta.K8SCollection1.Name()
+	r := createTestResource(t, "ns", "resource1", "v1")
+	name := r.Metadata.FullName
+	east.Handle(createTestEvent(t, event.Added, r))
+	west.Handle(createTestEvent(t, event.Added, r))
+
+	// Resources are tagged with their cluster, without modifying the original
+	g.Expect(h.Events()).To(HaveLen(1))
+	g.Expect(h.Events()[0].Resource.Origin.(*rt.Origin).Cluster).To(Equal("west"))
+	g.Expect(r.Origin.(*rt.Origin).Cluster).To(BeEmpty())
+
+	g.Expect(inventory.Clusters()).To(Equal([]string{"east", "west"}))
+	g.Expect(inventory.ResourceClusters(col, name)).To(Equal([]string{"east", "west"}))
+	g.Expect(inventory.ResourceClusters(col, resource.NewFullName("ns", "other"))).To(BeNil())
+
+	west.Handle(createTestEvent(t, event.Deleted, r))
+	g.Expect(inventory.ResourceClusters(col, name)).To(Equal([]string{"east"}))
+	east.Handle(createTestEvent(t, event.Deleted, r))
+	g.Expect(inventory.ResourceClusters(col, name)).To(BeNil())
+
+	// Other events are passed through
+	west.Handle(createTestEvent(t, event.FullSync, nil))
+	g.Expect(h.Events()).To(HaveLen(3))
+}
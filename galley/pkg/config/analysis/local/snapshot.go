@@ -0,0 +1,432 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package local
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/hashicorp/go-multierror"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubeSchema "k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/yaml"
+
+	"istio.io/istio/galley/pkg/config/source/kube/rt"
+	"istio.io/istio/galley/pkg/config/util/kubeyaml"
+	"istio.io/istio/pkg/config/event"
+	"istio.io/istio/pkg/config/mesh"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/util/gogoprotomarshal"
+)
+
+const (
+	snapshotFormatVersion    = 1
+	snapshotManifestFile     = "manifest.yaml"
+	snapshotMeshConfigFile   = "meshconfig.yaml"
+	snapshotMeshNetworksFile = "meshnetworks.yaml"
+)
+
+// snapshotManifest describes the contents of a snapshot archive.
+type snapshotManifest struct {
+	Version      int              `json:"version"`
+	IstioVersion string           `json:"istioVersion,omitempty"`
+	Sources      []snapshotSource `json:"sources"`
+}
+
+// snapshotSource is a file of resources in a snapshot archive, in ascending precedence order.
+type snapshotSource struct {
+	File string `json:"file"`
+	// Cluster is the name of the cluster the resources were read from, for multi-cluster analysis.
+	Cluster string `json:"cluster,omitempty"`
+	// MeshNetworksFile holds the mesh networks configuration of the cluster, if it is known.
+	MeshNetworksFile string `json:"meshNetworksFile,omitempty"`
+}
+
+// ExportSnapshot loads the sources of the analyzer and writes everything the analysis would see, i.e. the resources
+// of all collections, the mesh configuration and the Istio version, to w as a snapshot archive (a gzipped tarball).
+// The archive can be analyzed later, e.g. offline, with AddSnapshotArchive. The data of secrets is redacted.
+// The sources are stopped afterwards, so ExportSnapshot is used instead of Analyze. Canceling ctx aborts the export.
+func (sa *SourceAnalyzer) ExportSnapshot(ctx context.Context, w io.Writer) error {
+	if len(sa.sources) == 0 {
+		return fmt.Errorf("at least one file and/or Kubernetes source must be provided")
+	}
+
+	manifest := snapshotManifest{
+		Version:      snapshotFormatVersion,
+		IstioVersion: sa.istioVersion,
+	}
+	files := make(map[string][]byte)
+
+	for i, input := range sa.sources {
+		recorder, err := sa.recordSource(ctx, input)
+		if err != nil {
+			return err
+		}
+		by, err := recorder.toYAML(sa.kubeResources)
+		if err != nil {
+			return err
+		}
+
+		s := snapshotSource{File: fmt.Sprintf("resources/%d.yaml", i)}
+		files[s.File] = by
+		if cs, ok := input.src.(*clusterSource); ok {
+			s.Cluster = cs.cluster
+			if mn := sa.clusters.ClusterMeshNetworks(cs.cluster); mn != nil {
+				s.MeshNetworksFile = fmt.Sprintf("meshnetworks/%d.yaml", i)
+				if files[s.MeshNetworksFile], err = protoToYAML(mn); err != nil {
+					return err
+				}
+			}
+		}
+		manifest.Sources = append(manifest.Sources, s)
+	}
+
+	var err error
+	if files[snapshotMeshConfigFile], err = protoToYAML(sa.meshCfg); err != nil {
+		return err
+	}
+	if files[snapshotMeshNetworksFile], err = protoToYAML(sa.meshNetworks); err != nil {
+		return err
+	}
+	if files[snapshotManifestFile], err = yaml.Marshal(manifest); err != nil {
+		return err
+	}
+
+	return writeSnapshotArchive(w, files)
+}
+
+// AddSnapshotArchive adds the resources of a snapshot archive written by ExportSnapshot as sources, in the same
+// precedence order as when it was exported. The mesh configuration is read from the archive as well, as is the Istio
+// version unless one was set already.
+func (sa *SourceAnalyzer) AddSnapshotArchive(r io.Reader) error {
+	files, err := readSnapshotArchive(r)
+	if err != nil {
+		return err
+	}
+
+	var manifest snapshotManifest
+	by, ok := files[snapshotManifestFile]
+	if !ok {
+		return fmt.Errorf("not a snapshot archive: missing %s", snapshotManifestFile)
+	}
+	if err := yaml.Unmarshal(by, &manifest); err != nil {
+		return fmt.Errorf("error parsing %s: %v", snapshotManifestFile, err)
+	}
+	if manifest.Version != snapshotFormatVersion {
+		return fmt.Errorf("unsupported snapshot archive version %d", manifest.Version)
+	}
+
+	if by, ok := files[snapshotMeshConfigFile]; ok {
+		if sa.meshCfg, err = mesh.ApplyMeshConfigDefaults(string(by)); err != nil {
+			return fmt.Errorf("error parsing mesh config: %v", err)
+		}
+	}
+	if by, ok := files[snapshotMeshNetworksFile]; ok {
+		if sa.meshNetworks, err = mesh.ParseMeshNetworks(string(by)); err != nil {
+			return fmt.Errorf("error parsing mesh networks: %v", err)
+		}
+	}
+	if sa.istioVersion == "" {
+		sa.istioVersion = manifest.IstioVersion
+	}
+
+	// If we encounter any errors reading the resources, track them but attempt to continue
+	var errs error
+	for _, s := range manifest.Sources {
+		by, ok := files[s.File]
+		if !ok {
+			errs = multierror.Append(errs, fmt.Errorf("missing %s", s.File))
+			continue
+		}
+		readers := []ReaderSource{{Name: s.File, Reader: bytes.NewReader(by)}}
+
+		if s.Cluster == "" {
+			if err := sa.AddReaderKubeSource(readers); err != nil {
+				errs = multierror.Append(errs, err)
+			}
+			continue
+		}
+
+		if err := sa.AddReaderKubeClusterSource(s.Cluster, readers); err != nil {
+			errs = multierror.Append(errs, err)
+		}
+		if by, ok := files[s.MeshNetworksFile]; ok {
+			mn, err := mesh.ParseMeshNetworks(string(by))
+			if err != nil {
+				errs = multierror.Append(errs, fmt.Errorf("error parsing mesh networks of cluster %s: %v", s.Cluster, err))
+				continue
+			}
+			sa.clusters.setMeshNetworks(s.Cluster, mn)
+		}
+	}
+
+	return errs
+}
+
+// recordSource starts the source and records the resources it provides, until all of its collections are synced.
+func (sa *SourceAnalyzer) recordSource(ctx context.Context, input precedenceSourceInput) (*snapshotRecorder, error) {
+	recorder := newSnapshotRecorder(input.cols)
+	input.src.Dispatch(recorder)
+	input.src.Start()
+	defer input.src.Stop()
+
+	var timeout <-chan time.Time
+	if sa.timeout > 0 {
+		timer := time.NewTimer(sa.timeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case <-recorder.synced:
+		return recorder, nil
+	case <-timeout:
+		return nil, fmt.Errorf("timed out waiting for the resources of the snapshot")
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// snapshotRecorder is an event.Handler that records the current resources of a source.
+type snapshotRecorder struct {
+	mu        sync.Mutex
+	resources map[collection.Name]map[resource.FullName]*resource.Instance
+	pending   map[collection.Name]bool
+	synced    chan struct{}
+}
+
+var _ event.Handler = &snapshotRecorder{}
+
+func newSnapshotRecorder(cols collection.Names) *snapshotRecorder {
+	r := &snapshotRecorder{
+		resources: make(map[collection.Name]map[resource.FullName]*resource.Instance),
+		pending:   make(map[collection.Name]bool),
+		synced:    make(chan struct{}),
+	}
+	for _, c := range cols {
+		r.pending[c] = true
+	}
+	if len(r.pending) == 0 {
+		close(r.synced)
+	}
+	return r
+}
+
+// Handle implements event.Handler
+func (r *snapshotRecorder) Handle(e event.Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	switch e.Kind {
+	case event.Added, event.Updated:
+		col := e.Source.Name()
+		if r.resources[col] == nil {
+			r.resources[col] = make(map[resource.FullName]*resource.Instance)
+		}
+		r.resources[col][e.Resource.Metadata.FullName] = e.Resource
+	case event.Deleted:
+		delete(r.resources[e.Source.Name()], e.Resource.Metadata.FullName)
+	case event.FullSync:
+		if r.pending[e.Source.Name()] {
+			delete(r.pending, e.Source.Name())
+			if len(r.pending) == 0 {
+				close(r.synced)
+			}
+		}
+	}
+}
+
+// toYAML returns the recorded resources as a multi-document yaml file, ordered by collection and name.
+func (r *snapshotRecorder) toYAML(schemas collection.Schemas) ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var cols []string
+	for c := range r.resources {
+		cols = append(cols, c.String())
+	}
+	sort.Strings(cols)
+
+	var parts [][]byte
+	for _, c := range cols {
+		s, ok := schemas.Find(c)
+		if !ok {
+			continue
+		}
+		instances := r.resources[collection.NewName(c)]
+		var names []resource.FullName
+		for n := range instances {
+			names = append(names, n)
+		}
+		sort.Slice(names, func(i, j int) bool { return names[i].String() < names[j].String() })
+
+		for _, n := range names {
+			by, err := snapshotResourceYAML(s, instances[n])
+			if err != nil {
+				return nil, fmt.Errorf("error exporting %s %s: %v", s.Resource().Kind(), n, err)
+			}
+			parts = append(parts, by)
+		}
+	}
+
+	return kubeyaml.Join(parts...), nil
+}
+
+// snapshotResourceYAML converts a resource back to the yaml form it is read from.
+func snapshotResourceYAML(s collection.Schema, r *resource.Instance) ([]byte, error) {
+	var obj map[string]interface{}
+	var err error
+
+	switch m := r.Message.(type) {
+	case *corev1.Secret:
+		// Analyzers only look at which keys a secret has, and the data doesn't belong into an archive that is
+		// passed around.
+		secret := m.DeepCopy()
+		for k := range secret.Data {
+			secret.Data[k] = []byte{}
+		}
+		for k := range secret.StringData {
+			secret.StringData[k] = ""
+		}
+		obj, err = toJSONMap(secret)
+	case metav1.Object:
+		// Some built-in resources, e.g. pods, are analyzed as a whole rather than just their spec.
+		obj, err = toJSONMap(m)
+	default:
+		var spec map[string]interface{}
+		if rt.DefaultProvider().GetAdapter(s.Resource()).IsBuiltIn() {
+			spec, err = toJSONMap(m)
+		} else {
+			spec, err = gogoprotomarshal.ToJSONMap(m)
+		}
+		obj = map[string]interface{}{"spec": spec}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	obj["apiVersion"] = kubeSchema.GroupVersion{Group: s.Resource().Group(), Version: s.Resource().Version()}.String()
+	obj["kind"] = s.Resource().Kind()
+	if md, ok := obj["metadata"].(map[string]interface{}); ok {
+		delete(md, "managedFields")
+	} else {
+		obj["metadata"] = snapshotMetadata(r)
+	}
+
+	return yaml.Marshal(obj)
+}
+
+func snapshotMetadata(r *resource.Instance) map[string]interface{} {
+	md := map[string]interface{}{
+		"name": r.Metadata.FullName.Name.String(),
+	}
+	if r.Metadata.FullName.Namespace != "" {
+		md["namespace"] = r.Metadata.FullName.Namespace.String()
+	}
+	if len(r.Metadata.Labels) > 0 {
+		md["labels"] = r.Metadata.Labels
+	}
+	if len(r.Metadata.Annotations) > 0 {
+		md["annotations"] = r.Metadata.Annotations
+	}
+	return md
+}
+
+func toJSONMap(v interface{}) (map[string]interface{}, error) {
+	by, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var result map[string]interface{}
+	if err := json.Unmarshal(by, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func protoToYAML(m proto.Message) ([]byte, error) {
+	s, err := gogoprotomarshal.ToYAML(m)
+	return []byte(s), err
+}
+
+// writeSnapshotArchive writes the files, sorted by name, as a gzipped tarball.
+func writeSnapshotArchive(w io.Writer, files map[string][]byte) error {
+	var names []string
+	for n := range files {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+	for _, n := range names {
+		hdr := &tar.Header{
+			Name:     n,
+			Typeflag: tar.TypeReg,
+			Mode:     0644,
+			Size:     int64(len(files[n])),
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if _, err := tw.Write(files[n]); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gw.Close()
+}
+
+// readSnapshotArchive returns the files of a snapshot archive, by name.
+func readSnapshotArchive(r io.Reader) (map[string][]byte, error) {
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("not a snapshot archive: %v", err)
+	}
+	defer func() { _ = gr.Close() }()
+
+	files := make(map[string][]byte)
+	tr := tar.NewReader(gr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return files, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading snapshot archive: %v", err)
+		}
+		if hdr.FileInfo().IsDir() {
+			continue
+		}
+		if files[hdr.Name], err = ioutil.ReadAll(tr); err != nil {
+			return nil, fmt.Errorf("error reading %s from snapshot archive: %v", hdr.Name, err)
+		}
+	}
+}
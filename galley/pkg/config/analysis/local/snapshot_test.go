@@ -0,0 +1,103 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package local
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"istio.io/api/mesh/v1alpha1"
+
+	"istio.io/istio/galley/pkg/config/testing/basicmeta"
+	"istio.io/istio/galley/pkg/config/testing/data"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	sa := NewSourceAnalyzer(basicmeta.MustGet(), blankCombinedAnalyzer, "", "", nil, false, timeout)
+	g.Expect(sa.AddReaderKubeSource([]ReaderSource{{Reader: strings.NewReader(data.YamlN1I1V1)}})).To(BeNil())
+	g.Expect(sa.AddReaderKubeClusterSource("east", []ReaderSource{{Reader: strings.NewReader(data.YamlN2I2V1)}})).To(BeNil())
+	sa.clusters.setMeshNetworks("east", &v1alpha1.MeshNetworks{Networks: map[string]*v1alpha1.Network{"n1": {}}})
+	sa.meshCfg.RootNamespace = "root"
+	sa.SetIstioVersion("1.6.0")
+
+	var archive bytes.Buffer
+	g.Expect(sa.ExportSnapshot(context.Background(), &archive)).To(BeNil())
+
+	files, err := readSnapshotArchive(bytes.NewReader(archive.Bytes()))
+	g.Expect(err).To(BeNil())
+	g.Expect(files).To(HaveKey(snapshotManifestFile))
+	g.Expect(string(files["resources/0.yaml"])).To(ContainSubstring("n1_i1: v1"))
+	g.Expect(string(files["resources/1.yaml"])).To(ContainSubstring("n2_i2: v1"))
+	g.Expect(files).To(HaveKey("meshnetworks/1.yaml"))
+
+	replay := NewSourceAnalyzer(basicmeta.MustGet(), blankCombinedAnalyzer, "", "", nil, false, timeout)
+	g.Expect(replay.AddSnapshotArchive(bytes.NewReader(archive.Bytes()))).To(BeNil())
+	g.Expect(replay.meshCfg.RootNamespace).To(Equal("root"))
+	g.Expect(replay.IstioVersion()).To(Equal("1.6.0"))
+	g.Expect(replay.sources).To(HaveLen(2))
+	g.Expect(replay.clusters.Clusters()).To(Equal([]string{"east"}))
+	g.Expect(replay.clusters.ClusterMeshNetworks("east").Networks).To(HaveKey("n1"))
+
+	// Exporting the replayed snapshot again results in the same archive contents
+	var again bytes.Buffer
+	g.Expect(replay.ExportSnapshot(context.Background(), &again)).To(BeNil())
+	replayed, err := readSnapshotArchive(&again)
+	g.Expect(err).To(BeNil())
+	g.Expect(replayed).To(Equal(files))
+}
+
+func TestSnapshotRedactsSecrets(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	r := &resource.Instance{
+		Metadata: resource.Metadata{
+			FullName: resource.NewFullName("ns", "credential"),
+		},
+		Message: &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "credential", Namespace: "ns"},
+			Data:       map[string][]byte{"tls.crt": []byte("certificate")},
+		},
+	}
+
+	by, err := snapshotResourceYAML(collections.K8SCoreV1Secrets, r)
+	g.Expect(err).To(BeNil())
+	g.Expect(string(by)).To(ContainSubstring("apiVersion: v1\n"))
+	g.Expect(string(by)).To(ContainSubstring("kind: Secret\n"))
+	g.Expect(string(by)).To(ContainSubstring("tls.crt"))
+	g.Expect(string(by)).NotTo(ContainSubstring("Y2VydGlmaWNhdGU=")) // base64 of the certificate data
+}
+
+func TestAddSnapshotArchiveErrors(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	sa := NewSourceAnalyzer(basicmeta.MustGet(), blankCombinedAnalyzer, "", "", nil, false, timeout)
+	g.Expect(sa.AddSnapshotArchive(strings.NewReader("bogus"))).NotTo(BeNil())
+
+	var archive bytes.Buffer
+	g.Expect(writeSnapshotArchive(&archive, map[string][]byte{snapshotManifestFile: []byte("version: 2")})).To(BeNil())
+	g.Expect(sa.AddSnapshotArchive(&archive)).NotTo(BeNil())
+	g.Expect(sa.sources).To(BeEmpty())
+}
@@ -0,0 +1,68 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package local
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	goversion "github.com/hashicorp/go-version"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Label selectors of the control plane deployments whose image tag identifies the running Istio version, in order
+// of preference.
+var controlPlaneSelectors = []string{"app=istiod", "istio=pilot"}
+
+// detectIstioVersion returns the Istio version of the control plane running in the given namespace, as derived from
+// the image tag of its deployment.
+func detectIstioVersion(client kubernetes.Interface, istioNamespace string) (string, error) {
+	for _, selector := range controlPlaneSelectors {
+		deployments, err := client.AppsV1().Deployments(istioNamespace).List(context.TODO(), metav1.ListOptions{
+			LabelSelector: selector,
+		})
+		if err != nil {
+			return "", err
+		}
+
+		for _, d := range deployments.Items {
+			for _, c := range d.Spec.Template.Spec.Containers {
+				if version := versionFromImage(c.Image); version != "" {
+					return version, nil
+				}
+			}
+		}
+	}
+
+	return "", fmt.Errorf("no control plane deployment with a versioned image found in namespace %q", istioNamespace)
+}
+
+// versionFromImage returns the version in the tag of a container image, e.g. 1.5.2 for docker.io/istio/pilot:1.5.2,
+// or the empty string if the image is not tagged with a version.
+func versionFromImage(image string) string {
+	image = strings.SplitN(image, "@", 2)[0]
+	i := strings.LastIndex(image, ":")
+	if i < 0 || strings.Contains(image[i:], "/") {
+		return ""
+	}
+
+	tag := strings.TrimPrefix(image[i+1:], "release-")
+	if _, err := goversion.NewVersion(tag); err != nil {
+		return ""
+	}
+	return tag
+}
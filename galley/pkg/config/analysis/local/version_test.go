@@ -0,0 +1,80 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package local
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func deployment(name string, labels map[string]string, image string) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "istio-system", Labels: labels},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "discovery", Image: image}}},
+			},
+		},
+	}
+}
+
+func TestVersionFromImage(t *testing.T) {
+	cases := map[string]string{
+		"docker.io/istio/pilot:1.5.2":                   "1.5.2",
+		"gcr.io/istio-release/pilot:release-1.6":        "1.6",
+		"istio/pilot:1.6.0-beta.1":                      "1.6.0-beta.1",
+		"istio/pilot:1.5.2@sha256:0123456789abcdef":     "1.5.2",
+		"localhost:5000/istio/pilot":                    "",
+		"istio/pilot:latest":                            "",
+		"istio/pilot@sha256:0123456789abcdef0123456789": "",
+	}
+	for image, expected := range cases {
+		t.Run(image, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			g.Expect(versionFromImage(image)).To(Equal(expected))
+		})
+	}
+}
+
+func TestDetectIstioVersion(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	client := fake.NewSimpleClientset(
+		deployment("istio-pilot", map[string]string{"istio": "pilot"}, "docker.io/istio/pilot:1.4.6"),
+		deployment("istiod", map[string]string{"app": "istiod"}, "docker.io/istio/pilot:1.5.2"),
+	)
+	version, err := detectIstioVersion(client, "istio-system")
+	g.Expect(err).To(BeNil())
+	g.Expect(version).To(Equal("1.5.2"))
+
+	// Pre-istiod control planes
+	client = fake.NewSimpleClientset(
+		deployment("istio-pilot", map[string]string{"istio": "pilot"}, "docker.io/istio/pilot:1.4.6"),
+	)
+	version, err = detectIstioVersion(client, "istio-system")
+	g.Expect(err).To(BeNil())
+	g.Expect(version).To(Equal("1.4.6"))
+
+	client = fake.NewSimpleClientset(
+		deployment("istiod", map[string]string{"app": "istiod"}, "docker.io/istio/pilot:latest"),
+	)
+	_, err = detectIstioVersion(client, "istio-system")
+	g.Expect(err).NotTo(BeNil())
+}
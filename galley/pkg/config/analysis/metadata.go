@@ -25,4 +25,22 @@ type Metadata struct {
 	// field is displayed to users when --list-analyzers is called.
 	Description string
 	Inputs      collection.Names
+
+	// MinVersion and MaxVersion optionally bound the Istio control plane versions the analyzer applies to, e.g.
+	// "1.5" or "1.5.2". Both bounds are inclusive; a bound without a patch number covers all patch releases of
+	// that minor version. Analyzers are skipped for control plane versions outside of the bounds.
+	MinVersion string
+	MaxVersion string
+
+	// Fields are the fields of the input resources that the analyzer reads, out of the ones that sources may drop to
+	// save memory (see rt.DroppableFields). Analyzers that may read any field, e.g. because they evaluate user
+	// supplied checks, declare AllFields.
+	Fields []string
+
+	// TimeDependent marks analyzers whose findings change over time with unchanged inputs, e.g. because they report
+	// certificates that are about to expire. Their results are not memoized by a ResultCache.
+	TimeDependent bool
 }
+
+// AllFields is declared in Metadata.Fields by analyzers that may read any field of their input resources.
+const AllFields = "*"
@@ -0,0 +1,72 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msg
+
+import (
+	"sort"
+
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+)
+
+// detail is the part of the definition of a message type that is only needed for its documentation.
+type detail struct {
+	name        string
+	description string
+}
+
+// Entry is the description of a message type in the message catalog, for tools that present analysis findings.
+type Entry struct {
+	Code             string `json:"code"`
+	Name             string `json:"name"`
+	Level            string `json:"level"`
+	Template         string `json:"template"`
+	Description      string `json:"description"`
+	DocumentationURL string `json:"documentation_url"`
+}
+
+// Catalog returns the entries of all known message types, sorted by code.
+func Catalog() []Entry {
+	all := All()
+	entries := make([]Entry, 0, len(all))
+	for _, t := range all {
+		entries = append(entries, entry(t))
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Code < entries[j].Code
+	})
+	return entries
+}
+
+// Explain returns the catalog entry of the message type with the given code, and whether the code is known.
+func Explain(code string) (Entry, bool) {
+	for _, t := range All() {
+		if t.Code() == code {
+			return entry(t), true
+		}
+	}
+	return Entry{}, false
+}
+
+func entry(t *diag.MessageType) Entry {
+	d := details[t.Code()]
+	return Entry{
+		Code:             t.Code(),
+		Name:             d.name,
+		Level:            t.Level().String(),
+		Template:         t.Template(),
+		Description:      d.description,
+		DocumentationURL: t.DocumentationURL(),
+	}
+}
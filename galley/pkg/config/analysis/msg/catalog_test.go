@@ -0,0 +1,53 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msg
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestCatalog(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	entries := Catalog()
+	g.Expect(entries).To(HaveLen(len(All())))
+	for i, e := range entries {
+		g.Expect(e.Name).NotTo(BeEmpty(), "name of %s", e.Code)
+		g.Expect(e.Description).NotTo(BeEmpty(), "description of %s", e.Code)
+		if i > 0 {
+			g.Expect(e.Code > entries[i-1].Code).To(BeTrue(), "order of %s", e.Code)
+		}
+	}
+}
+
+func TestExplain(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	e, ok := Explain("IST0101")
+	g.Expect(ok).To(BeTrue())
+	g.Expect(e).To(Equal(Entry{
+		Code:             "IST0101",
+		Name:             "ReferencedResourceNotFound",
+		Level:            "Error",
+		Template:         "Referenced %s not found: %q",
+		Description:      "A resource being referenced does not exist.",
+		DocumentationURL: "https://istio.io/docs/reference/config/analysis/IST0101",
+	}))
+
+	_, ok = Explain("IST9999")
+	g.Expect(ok).To(BeFalse())
+}
@@ -142,6 +142,13 @@ func All() []*diag.MessageType {
 	}
 }
 
+// details holds the name and description of all known message types, by code.
+var details = map[string]detail{
+	{{- range .Messages}}
+	"{{.Code}}": {name: "{{.Name}}", description: "{{.Description}}"},
+	{{- end}}
+}
+
 {{range .Messages}}
 // New{{.Name}} returns a new diag.Message based on {{.Name}}.
 func New{{.Name}}(r *resource.Instance{{range .Args}}, {{.Name}} {{.Type}}{{end}}) diag.Message {
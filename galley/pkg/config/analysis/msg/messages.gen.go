@@ -116,6 +116,374 @@ var (
 	// UnknownMeshNetworksServiceRegistry defines a diag.MessageType for message "UnknownMeshNetworksServiceRegistry".
 	// Description: A service registry in Mesh Networks is unknown
 	UnknownMeshNetworksServiceRegistry = diag.NewMessageType(diag.Error, "IST0126", "Unknown service registry %s in network %s")
+
+	// ResourceRejected defines a diag.MessageType for message "ResourceRejected".
+	// Description: A resource was rejected by all proxies it was pushed to
+	ResourceRejected = diag.NewMessageType(diag.Error, "IST0127", "The resource was rejected by proxies: %s. Fix the rejection first; other messages about this resource have been downgraded.")
+
+	// ResourceTooLarge defines a diag.MessageType for message "ResourceTooLarge".
+	// Description: A resource exceeds the analysis limits and was skipped by the analyzers
+	ResourceTooLarge = diag.NewMessageType(diag.Info, "IST0128", "The resource is too large to analyze fully: %s. It was skipped by the analyzers.")
+
+	// UpgradeRemovedAPI defines a diag.MessageType for message "UpgradeRemovedAPI".
+	// Description: A resource uses an API that is removed in the target Istio version
+	UpgradeRemovedAPI = diag.NewMessageType(diag.Error, "IST0129", "The %s API is removed in Istio %s: %s")
+
+	// UpgradeRenamedField defines a diag.MessageType for message "UpgradeRenamedField".
+	// Description: A resource sets a field that is replaced in the target Istio version
+	UpgradeRenamedField = diag.NewMessageType(diag.Warning, "IST0130", "Field %s is replaced by %s in Istio %s")
+
+	// UpgradeChangedDefault defines a diag.MessageType for message "UpgradeChangedDefault".
+	// Description: A resource relies on a default value that changes in the target Istio version
+	UpgradeChangedDefault = diag.NewMessageType(diag.Info, "IST0131", "The default of field %s changes from %s to %s in Istio %s. Set it explicitly to keep the current behavior.")
+
+	// ConflictingEnvoyFilterPatches defines a diag.MessageType for message "ConflictingEnvoyFilterPatches".
+	// Description: Multiple EnvoyFilters insert at the same location, so their order depends on creation time
+	ConflictingEnvoyFilterPatches = diag.NewMessageType(diag.Warning, "IST0132", "EnvoyFilters %s all insert at %s for the same workloads; their relative order depends on the creation time of the resources")
+
+	// UnmatchedWorkloadSelector defines a diag.MessageType for message "UnmatchedWorkloadSelector".
+	// Description: A workload selector does not match any pod, so the resource has no effect
+	UnmatchedWorkloadSelector = diag.NewMessageType(diag.Warning, "IST0133", "The workload selector %s does not match any pod in scope, so the resource has no effect. Closest match: %s")
+
+	// UnregisteredExternalHost defines a diag.MessageType for message "UnregisteredExternalHost".
+	// Description: A host is not in the service registry, so traffic to it is blocked by the REGISTRY_ONLY outbound traffic policy
+	UnregisteredExternalHost = diag.NewMessageType(diag.Warning, "IST0134", "Host %s is not in the service registry, so traffic from namespace %s to it is blocked by the REGISTRY_ONLY outbound traffic policy. Add a ServiceEntry for it.")
+
+	// ZeroRouteTimeout defines a diag.MessageType for message "ZeroRouteTimeout".
+	// Description: A route sets a timeout of 0s, whose effect differs between Istio versions
+	ZeroRouteTimeout = diag.NewMessageType(diag.Warning, "IST0135", "Route %s sets a timeout of 0s, which disables the timeout. %s")
+
+	// RouteTimeoutNotLongerThanConnectTimeout defines a diag.MessageType for message "RouteTimeoutNotLongerThanConnectTimeout".
+	// Description: A route timeout is not longer than the connect timeout of the destination
+	RouteTimeoutNotLongerThanConnectTimeout = diag.NewMessageType(diag.Info, "IST0136", "Route %s has a timeout of %s, which is not longer than the connectTimeout of %s set by DestinationRule %s. Requests to an unreachable endpoint time out before the connection attempt does, so they are never retried on another endpoint.")
+
+	// TLSRouteMissingSNIHosts defines a diag.MessageType for message "TLSRouteMissingSNIHosts".
+	// Description: A TLS route match has no SNI hosts, so the route is dropped
+	TLSRouteMissingSNIHosts = diag.NewMessageType(diag.Error, "IST0137", "TLS route %s has a match without SNI hosts, so the route is dropped")
+
+	// TLSRouteNotOnPassthroughServer defines a diag.MessageType for message "TLSRouteNotOnPassthroughServer".
+	// Description: A TLS route is bound to a gateway without a matching TLS passthrough server, so the route is dropped
+	TLSRouteNotOnPassthroughServer = diag.NewMessageType(diag.Warning, "IST0138", "TLS route %s is bound to gateway %s, which has no TLS passthrough server%s, so the route is dropped")
+
+	// SNIHostNotInGatewayServer defines a diag.MessageType for message "SNIHostNotInGatewayServer".
+	// Description: A TLS route matches an SNI host that no TLS passthrough server of its gateway accepts
+	SNIHostNotInGatewayServer = diag.NewMessageType(diag.Warning, "IST0139", "TLS route %s matches SNI host %s, which is not in the hosts of any TLS passthrough server of gateway %s, so the match is dropped")
+
+	// HostNotCanonical defines a diag.MessageType for message "HostNotCanonical".
+	// Description: A host is not written in its canonical form
+	HostNotCanonical = diag.NewMessageType(diag.Warning, "IST0140", "Host %q has %s, so it may not match the same host written in canonical form. Use %q instead.")
+
+	// InvalidHostCharacters defines a diag.MessageType for message "InvalidHostCharacters".
+	// Description: A host contains characters that are not valid in DNS names
+	InvalidHostCharacters = diag.NewMessageType(diag.Error, "IST0141", "Host %q contains characters that are not valid in DNS names: %s")
+
+	// InconsistentHostForm defines a diag.MessageType for message "InconsistentHostForm".
+	// Description: A service is referred to by its short name in some resources and by its FQDN in others
+	InconsistentHostForm = diag.NewMessageType(diag.Info, "IST0142", "Host %q refers to %s, which is referred to by its FQDN in %s. Use %q consistently.")
+
+	// ConflictingEndpointAddress defines a diag.MessageType for message "ConflictingEndpointAddress".
+	// Description: The same endpoint address is used with conflicting labels or ports, so the endpoint's assignment flaps
+	ConflictingEndpointAddress = diag.NewMessageType(diag.Warning, "IST0143", "Endpoint address %s is also used by %s with conflicting %s, so which workload it is assigned to may change between updates")
+
+	// ConnectionPoolRisk defines a diag.MessageType for message "ConnectionPoolRisk".
+	// Description: DestinationRule connection pool settings are likely to cause bursts of 503 errors
+	ConnectionPoolRisk = diag.NewMessageType(diag.Warning, "IST0144", "The connection pool settings of %s are likely to cause 503 errors: %s")
+
+	// AggressiveOutlierDetection defines a diag.MessageType for message "AggressiveOutlierDetection".
+	// Description: DestinationRule outlier detection settings can eject healthy hosts and cause self-inflicted outages
+	AggressiveOutlierDetection = diag.NewMessageType(diag.Warning, "IST0145", "The outlier detection of %s %s")
+
+	// FieldValueOutOfRange defines a diag.MessageType for message "FieldValueOutOfRange".
+	// Description: A numeric field has a value outside of its valid range
+	FieldValueOutOfRange = diag.NewMessageType(diag.Error, "IST0146", "Field %s is %s, which is outside of its valid range %s")
+
+	// ServiceEntryAddressNotIntercepted defines a diag.MessageType for message "ServiceEntryAddressNotIntercepted".
+	// Description: Traffic to a ServiceEntry address is not intercepted by the sidecars, so it bypasses egress policy
+	ServiceEntryAddressNotIntercepted = diag.NewMessageType(diag.Warning, "IST0147", "Address %s is %s for %s, so their traffic to it bypasses the sidecar and any policy declared for the ServiceEntry")
+
+	// InvalidHostLength defines a diag.MessageType for message "InvalidHostLength".
+	// Description: A host or one of its labels is too long to be a DNS name
+	InvalidHostLength = diag.NewMessageType(diag.Error, "IST0148", "Host %q is not a valid DNS name, as %s. Envoy virtual host matching and TLS SNI do not work for it.")
+
+	// MeshWideResource defines a diag.MessageType for message "MeshWideResource".
+	// Description: A resource in the root namespace applies to the whole mesh
+	MeshWideResource = diag.NewMessageType(diag.Info, "IST0149", "This resource is in the root namespace %s and has no %s, so it applies to the whole mesh. Confirm that it is meant to be mesh-wide.")
+
+	// UnprotectedGatewayExposure defines a diag.MessageType for message "UnprotectedGatewayExposure".
+	// Description: A gateway exposed to the internet has no authorization policy or request authentication
+	UnprotectedGatewayExposure = diag.NewMessageType(diag.Info, "IST0150", "The gateway exposes hosts %v to the internet through the LoadBalancer service %s, but its workload %s is not covered by any AuthorizationPolicy or RequestAuthentication. Review whether the exposed services need access control.")
+
+	// InvalidAnalysisChecks defines a diag.MessageType for message "InvalidAnalysisChecks".
+	// Description: A ConfigMap holds analysis checks that cannot be compiled
+	InvalidAnalysisChecks = diag.NewMessageType(diag.Error, "IST0151", "The analysis checks in key %q cannot be used: %s")
+
+	// PortProtocolConflict defines a diag.MessageType for message "PortProtocolConflict".
+	// Description: The appProtocol and the name of a service port imply different protocols
+	PortProtocolConflict = diag.NewMessageType(diag.Warning, "IST0152", "Port %s (%d) of the service has appProtocol %q, which implies protocol %s, while its name implies protocol %s. %s")
+
+	// IstioConfigInNonMeshNamespace defines a diag.MessageType for message "IstioConfigInNonMeshNamespace".
+	// Description: A namespace without mesh workloads contains Istio configuration
+	IstioConfigInNonMeshNamespace = diag.NewMessageType(diag.Info, "IST0153", "Namespace %s is not enabled for injection and has no workloads with a sidecar, but contains %v. These resources likely have no effect here and may have been applied to the wrong namespace.")
+
+	// InvalidGatewayCredential defines a diag.MessageType for message "InvalidGatewayCredential".
+	// Description: The secret referenced by a gateway does not hold a usable certificate and key
+	InvalidGatewayCredential = diag.NewMessageType(diag.Error, "IST0154", "The credential %s of the gateway cannot be used: %s")
+
+	// GatewayCertificateExpiring defines a diag.MessageType for message "GatewayCertificateExpiring".
+	// Description: The certificate of a gateway credential expires soon
+	GatewayCertificateExpiring = diag.NewMessageType(diag.Warning, "IST0155", "The certificate in credential %s expires on %s, within %s. Renew it before the gateway serves an expired certificate.")
+
+	// GatewayRoutesShadowed defines a diag.MessageType for message "GatewayRoutesShadowed".
+	// Description: Virtual services route the same host on the same gateway with overlapping match conditions
+	GatewayRoutesShadowed = diag.NewMessageType(diag.Warning, "IST0156", "VirtualService %s also routes host %s on gateway %s, with overlapping match conditions. The routes of both virtual services are merged in an undefined order, so the routes of one may shadow the other.")
+
+	// MTLSModeMismatch defines a diag.MessageType for message "MTLSModeMismatch".
+	// Description: The TLS mode of a DestinationRule does not match the mTLS mode of the destination workloads
+	MTLSModeMismatch = diag.NewMessageType(diag.Error, "IST0157", "The DestinationRule sets TLS mode %s for host %s, but workload %s has mTLS mode %s, as set by PeerAuthentication %s. %s")
+
+	// ConflictingGatewayServers defines a diag.MessageType for message "ConflictingGatewayServers".
+	// Description: Gateways selecting the same workload define conflicting servers on the same port
+	ConflictingGatewayServers = diag.NewMessageType(diag.Error, "IST0158", "The server on port %d conflicts with a server of gateway %s, which selects the same workload %s: %s. The proxy silently drops one of the servers.")
+
+	// OverlappingServiceEntry defines a diag.MessageType for message "OverlappingServiceEntry".
+	// Description: A ServiceEntry overlaps with a Kubernetes service or another ServiceEntry
+	OverlappingServiceEntry = diag.NewMessageType(diag.Warning, "IST0159", "The %s %s overlaps with %s%s. Which of them is used for the traffic is nondeterministic.")
+
+	// PodProxyInNonInjectedNamespace defines a diag.MessageType for message "PodProxyInNonInjectedNamespace".
+	// Description: A pod has an injected sidecar, but its namespace is not enabled for injection
+	PodProxyInNonInjectedNamespace = diag.NewMessageType(diag.Info, "IST0160", "The pod has an injected sidecar, but its namespace %s is not enabled for injection. Unless the sidecar was injected manually, pods recreated from the same spec will not get one.")
+
+	// ProxyVersionSkew defines a diag.MessageType for message "ProxyVersionSkew".
+	// Description: The proxy of a pod is more than one minor version behind the control plane
+	ProxyVersionSkew = diag.NewMessageType(diag.Warning, "IST0161", "The proxy of this pod runs version %s, which is %d minor versions behind the control plane version %s. Proxies more than one minor version behind the control plane are not supported; restart the pod to update its proxy.")
+
+	// AuthorizationPolicyDeniesAll defines a diag.MessageType for message "AuthorizationPolicyDeniesAll".
+	// Description: An authorization policy allows requests, but has no rules, so it denies all requests
+	AuthorizationPolicyDeniesAll = diag.NewMessageType(diag.Info, "IST0162", "This authorization policy has no rules, so it denies all requests to %s. Add a rule to allow requests, or ignore this message if denying all requests is intended.")
+
+	// DuplicateJwtIssuer defines a diag.MessageType for message "DuplicateJwtIssuer".
+	// Description: Multiple JWT rules for the same issuer apply to a workload
+	DuplicateJwtIssuer = diag.NewMessageType(diag.Warning, "IST0163", "The JWT issuer %s is configured more than once for workload %s, by %s. Which of the rules validates a token of the issuer is undefined.")
+
+	// InvalidJwksURI defines a diag.MessageType for message "InvalidJwksURI".
+	// Description: The JWKS URI of a JWT rule is invalid
+	InvalidJwksURI = diag.NewMessageType(diag.Error, "IST0164", "The jwksUri %s of issuer %s is invalid: %s.")
+
+	// InsecureJwksURI defines a diag.MessageType for message "InsecureJwksURI".
+	// Description: The JWKS URI of a JWT rule uses plaintext HTTP
+	InsecureJwksURI = diag.NewMessageType(diag.Warning, "IST0165", "The jwksUri %s of issuer %s uses plaintext HTTP, so the keys that validate tokens can be tampered with in transit. Use HTTPS instead.")
+
+	// EnvoyFilterRenamedMatch defines a diag.MessageType for message "EnvoyFilterRenamedMatch".
+	// Description: An EnvoyFilter patch matches an Envoy object by a name that has changed between versions
+	EnvoyFilterRenamedMatch = diag.NewMessageType(diag.Warning, "IST0166", "The patch for %s matches the %s %s, which is named %s in newer versions. Names are matched exactly, so the patch silently stops applying once proxies use the new name.")
+
+	// EnvoyFilterRelativePatchWithoutMatch defines a diag.MessageType for message "EnvoyFilterRelativePatchWithoutMatch".
+	// Description: An EnvoyFilter patch inserts relative to an object, but does not match the object
+	EnvoyFilterRelativePatchWithoutMatch = diag.NewMessageType(diag.Warning, "IST0167", "The %s patch for %s does not match a %s to insert relative to, so it is applied as %s.")
+
+	// UnreferencedGateway defines a diag.MessageType for message "UnreferencedGateway".
+	// Description: A gateway is not referenced by any virtual service
+	UnreferencedGateway = diag.NewMessageType(diag.Info, "IST0168", "The gateway is not referenced by any virtual service, so it does not route any traffic. Remove it if it is no longer needed.")
+
+	// UnusedSubset defines a diag.MessageType for message "UnusedSubset".
+	// Description: A destination rule subset is not the destination of any virtual service route
+	UnusedSubset = diag.NewMessageType(diag.Info, "IST0169", "The subset %s is not the destination of any virtual service route. Remove it if it is no longer needed.")
+
+	// UnreferencedDestinationRule defines a diag.MessageType for message "UnreferencedDestinationRule".
+	// Description: The host of a destination rule is unknown, and not the destination of any virtual service route
+	UnreferencedDestinationRule = diag.NewMessageType(diag.Info, "IST0170", "The host %s is neither a service nor declared by a service entry, and is not the destination of any virtual service route, so the destination rule does not apply to any traffic. Remove it if it is no longer needed.")
+
+	// RemoteServiceNotFound defines a diag.MessageType for message "RemoteServiceNotFound".
+	// Description: A service entry refers to the service of a remote cluster that does not exist
+	RemoteServiceNotFound = diag.NewMessageType(diag.Warning, "IST0171", "The host %s refers to the service %s of a remote cluster, but no cluster other than %s has that service. Requests to the host fail.")
+
+	// MeshNetworksMismatch defines a diag.MessageType for message "MeshNetworksMismatch".
+	// Description: The clusters of a mesh have different mesh networks configuration
+	MeshNetworksMismatch = diag.NewMessageType(diag.Warning, "IST0172", "The mesh networks configuration of cluster %s differs from that of cluster %s. Cross-network traffic is only routed consistently if all clusters of the mesh have the same mesh networks configuration.")
+
+	// ZeroTrafficPolicyDuration defines a diag.MessageType for message "ZeroTrafficPolicyDuration".
+	// Description: A traffic policy sets a duration to zero, which proxies reject
+	ZeroTrafficPolicyDuration = diag.NewMessageType(diag.Error, "IST0173", "The %s sets %s to zero. Proxies reject the cluster configuration of the destination, so requests to it fail. Set a positive duration or remove the field to use the default.")
+
+	// IstioMutualWithCertificates defines a diag.MessageType for message "IstioMutualWithCertificates".
+	// Description: A traffic policy uses ISTIO_MUTUAL TLS together with explicit certificates, which are ignored
+	IstioMutualWithCertificates = diag.NewMessageType(diag.Warning, "IST0174", "The %s uses TLS mode ISTIO_MUTUAL together with %s. ISTIO_MUTUAL always presents the certificate of the proxy, so these settings are ignored. Use mode MUTUAL to present custom certificates.")
+
+	// SubsetTLSModeContradiction defines a diag.MessageType for message "SubsetTLSModeContradiction".
+	// Description: The TLS mode of a subset contradicts the TLS mode of the destination rule
+	SubsetTLSModeContradiction = diag.NewMessageType(diag.Warning, "IST0175", "Subset %s sets TLS mode %s, while the traffic policy of the destination rule sets %s. Requests routed to the subset use a different TLS mode than other requests to the host, which fails against workloads that only accept one of them.")
+
+	// DestinationHostNotExported defines a diag.MessageType for message "DestinationHostNotExported".
+	// Description: A virtual service is exported to namespaces its destination host is not visible in
+	DestinationHostNotExported = diag.NewMessageType(diag.Warning, "IST0176", "The virtual service is exported to all namespaces, but its destination host %s is only visible in %s. Proxies in other namespaces receive the route but have no endpoints for it, so their requests fail.")
+
+	// DestinationRuleNotExported defines a diag.MessageType for message "DestinationRuleNotExported".
+	// Description: A virtual service is exported to namespaces the destination rules of its destination host are not visible in
+	DestinationRuleNotExported = diag.NewMessageType(diag.Warning, "IST0177", "The destination rules for host %s are only visible in %s, while the virtual service is exported to %s. Proxies that receive the route do not know the subsets and traffic policy of the host, so requests routed to a subset fail.")
+
+	// TargetPortProtocolConflict defines a diag.MessageType for message "TargetPortProtocolConflict".
+	// Description: Two ports of a service target the same container port with different protocols
+	TargetPortProtocolConflict = diag.NewMessageType(diag.Warning, "IST0178", "Service ports %s and %s both target port %s, with protocols %s and %s. The sidecar accepts a single protocol on each port of the workload, so the traffic of one of the service ports fails.")
+
+	// HeadlessServicePortProtocolNotDeclared defines a diag.MessageType for message "HeadlessServicePortProtocolNotDeclared".
+	// Description: A port of a headless service does not declare its protocol
+	HeadlessServicePortProtocolNotDeclared = diag.NewMessageType(diag.Warning, "IST0179", "Port %s (port: %d) of the headless service does not declare its protocol. Clients of a headless service connect to the pods directly, and protocol detection fails for server-first protocols such as MySQL. Name the port with a protocol prefix, e.g. tcp-, or set its appProtocol.")
+
+	// WorkloadSelectorSpansApplications defines a diag.MessageType for message "WorkloadSelectorSpansApplications".
+	// Description: A workload selector matches the pods of more than one application
+	WorkloadSelectorSpansApplications = diag.NewMessageType(diag.Info, "IST0180", "The workload selector %s matches the pods of the applications %v. If the resource is meant for a single application, add a label such as app to the selector.")
+
+	// ConflictingPeerAuthentications defines a diag.MessageType for message "ConflictingPeerAuthentications".
+	// Description: Several workload-level peer authentications select the same pod
+	ConflictingPeerAuthentications = diag.NewMessageType(diag.Warning, "IST0181", "The peer authentications %v select the same workload pod %q. Only one of them applies to the pod, which one is undefined.")
+
+	// RouteParentRefNotAccepted defines a diag.MessageType for message "RouteParentRefNotAccepted".
+	// Description: A Gateway API route references a gateway that does not accept it
+	RouteParentRefNotAccepted = diag.NewMessageType(diag.Error, "IST0182", "The parent reference %s of the route is not accepted: %s. The route is not attached to the gateway.")
+
+	// ListenerRouteKindIncompatible defines a diag.MessageType for message "ListenerRouteKindIncompatible".
+	// Description: A Gateway API listener allows a route kind that is incompatible with its protocol
+	ListenerRouteKindIncompatible = diag.NewMessageType(diag.Error, "IST0183", "Listener %s allows routes of kind %s, which cannot attach to listeners with protocol %s.")
+
+	// ListenerCertificateMissing defines a diag.MessageType for message "ListenerCertificateMissing".
+	// Description: A Gateway API listener terminates TLS without referencing a certificate
+	ListenerCertificateMissing = diag.NewMessageType(diag.Error, "IST0184", "Listener %s uses protocol %s and terminates TLS, but does not reference a certificate.")
+
+	// InconsistentWebhookFailurePolicy defines a diag.MessageType for message "InconsistentWebhookFailurePolicy".
+	// Description: The Istio validating webhooks of the cluster have different failure policies
+	InconsistentWebhookFailurePolicy = diag.NewMessageType(diag.Warning, "IST0185", "Webhook %s has failurePolicy %s, but webhook %s has failurePolicy %s. Whether invalid configuration is rejected while the control plane is unavailable depends on the webhook that handles it.")
+
+	// RootCASecretMissing defines a diag.MessageType for message "RootCASecretMissing".
+	// Description: Istiod runs without a root CA secret
+	RootCASecretMissing = diag.NewMessageType(diag.Warning, "IST0186", "Istiod runs in namespace %s, but neither of the root CA secrets istio-ca-secret and cacerts exists there. Workload certificates can't be signed unless an external CA is used.")
+
+	// RootCertificateExpiring defines a diag.MessageType for message "RootCertificateExpiring".
+	// Description: The root certificate of the mesh expires soon
+	RootCertificateExpiring = diag.NewMessageType(diag.Warning, "IST0187", "The root certificate in secret %s expires on %s, within %s. Rotate it before workloads can no longer validate each other's certificates.")
+
+	// RootCertificateExpired defines a diag.MessageType for message "RootCertificateExpired".
+	// Description: The root certificate of the mesh has expired
+	RootCertificateExpired = diag.NewMessageType(diag.Error, "IST0188", "The root certificate in secret %s expired on %s. Workloads can no longer validate each other's certificates.")
+
+	// OverlappingInjectionRevisions defines a diag.MessageType for message "OverlappingInjectionRevisions".
+	// Description: A namespace is selected by the sidecar injectors of multiple control plane revisions
+	OverlappingInjectionRevisions = diag.NewMessageType(diag.Warning, "IST0189", "The namespace is selected by the sidecar injectors of revisions %v. Its pods may be injected by an unexpected revision.")
+
+	// GatewaySecretNotFound defines a diag.MessageType for message "GatewaySecretNotFound".
+	// Description: The secret referenced by a gateway server exists in some, but not all namespaces of the gateway workloads
+	GatewaySecretNotFound = diag.NewMessageType(diag.Error, "IST0190", "The credential %s of the server on port %d with hosts %v is not found in namespace %s of the gateway workloads")
+
+	// RegexTooComplex defines a diag.MessageType for message "RegexTooComplex".
+	// Description: A regular expression is too complex for Envoy
+	RegexTooComplex = diag.NewMessageType(diag.Error, "IST0191", "Field %q regular expression %q is too complex: its program size %d exceeds %d, so Envoy rejects it")
+
+	// InvalidHeaderName defines a diag.MessageType for message "InvalidHeaderName".
+	// Description: A matched header name is not a valid HTTP header name
+	InvalidHeaderName = diag.NewMessageType(diag.Error, "IST0192", "Field %q header name %q is invalid: %s. Envoy rejects the routes of the virtual service.")
+
+	// ProxyRevisionVersionSkew defines a diag.MessageType for message "ProxyRevisionVersionSkew".
+	// Description: The proxy of a pod is too many minor versions behind the control plane revision it belongs to
+	ProxyRevisionVersionSkew = diag.NewMessageType(diag.Warning, "IST0193", "The proxy of this pod runs version %s, which is %d minor versions behind version %s of control plane revision %s. At most %d minor versions are allowed; restart the pod to update its proxy.")
+
+	// ProxyRevisionNotInstalled defines a diag.MessageType for message "ProxyRevisionNotInstalled".
+	// Description: The proxy of a pod belongs to a control plane revision that is not installed
+	ProxyRevisionNotInstalled = diag.NewMessageType(diag.Error, "IST0194", "The proxy of this pod belongs to control plane revision %s, which is not installed. The installed revisions are %v; restart the pod in a namespace injected by one of them.")
+
+	// MixedProxyRevisions defines a diag.MessageType for message "MixedProxyRevisions".
+	// Description: The pods of a namespace belong to different control plane revisions
+	MixedProxyRevisions = diag.NewMessageType(diag.Info, "IST0195", "The pods of this namespace belong to control plane revisions %v. Restart the pods still on an old revision to complete the upgrade.")
+
+	// SidecarIngressPortNotExposed defines a diag.MessageType for message "SidecarIngressPortNotExposed".
+	// Description: An ingress listener of a sidecar is on a port that no service of the selected workloads targets
+	SidecarIngressPortNotExposed = diag.NewMessageType(diag.Warning, "IST0196", "The ingress listener on port %d captures a port of the selected workloads that no service targets. Traffic to the port bypasses service routing, or the port is a typo.")
+
+	// SidecarIngressDuplicatePort defines a diag.MessageType for message "SidecarIngressDuplicatePort".
+	// Description: Multiple ingress listeners of a sidecar are on the same port
+	SidecarIngressDuplicatePort = diag.NewMessageType(diag.Error, "IST0197", "Multiple ingress listeners are on port %d. The proxies of the selected workloads reject the conflicting inbound listeners.")
+
+	// SidecarIngressProtocolMismatch defines a diag.MessageType for message "SidecarIngressProtocolMismatch".
+	// Description: The protocol of an ingress listener of a sidecar differs from the protocol of a service port targeting it
+	SidecarIngressProtocolMismatch = diag.NewMessageType(diag.Warning, "IST0198", "The ingress listener on port %d uses protocol %s, but port %s of service %s targets it with protocol %s")
+
+	// LocalityWithoutEndpoints defines a diag.MessageType for message "LocalityWithoutEndpoints".
+	// Description: A locality load balancing setting of a destination rule sends traffic to a locality without endpoints
+	LocalityWithoutEndpoints = diag.NewMessageType(diag.Warning, "IST0199", "The locality load balancing setting of the %s %s to %s, but no endpoint of host %s is in that locality. Traffic sent there has no endpoint to go to.")
+
+	// LocalityWeightsNotSumTo100 defines a diag.MessageType for message "LocalityWeightsNotSumTo100".
+	// Description: The weights of a locality load balancing distribution of a destination rule do not sum to 100
+	LocalityWeightsNotSumTo100 = diag.NewMessageType(diag.Error, "IST0200", "The locality load balancing setting of the %s distributes traffic from %s with weights summing to %d instead of 100")
+
+	// PerTryTimeoutNotShorterThanTimeout defines a diag.MessageType for message "PerTryTimeoutNotShorterThanTimeout".
+	// Description: The per-try timeout of the retries of a virtual service route is not shorter than the route timeout
+	PerTryTimeoutNotShorterThanTimeout = diag.NewMessageType(diag.Warning, "IST0201", "The per-try timeout %s of route %s is not shorter than its timeout %s. The route times out before a try does, so requests are never retried after a try times out.")
+
+	// NonIdempotentRetry defines a diag.MessageType for message "NonIdempotentRetry".
+	// Description: A virtual service route retries non-idempotent requests after they may have reached the destination
+	NonIdempotentRetry = diag.NewMessageType(diag.Warning, "IST0202", "Route %s matches %s requests and retries them on %s. These conditions also apply after the destination received the request, so it may process the request more than once. Retry these methods only on connect-failure or refused-stream.")
+
+	// GrpcRetryOnNonGrpcDestination defines a diag.MessageType for message "GrpcRetryOnNonGrpcDestination".
+	// Description: A virtual service route retries on gRPC conditions for a destination that does not serve gRPC
+	GrpcRetryOnNonGrpcDestination = diag.NewMessageType(diag.Warning, "IST0203", "Route %s retries on the gRPC conditions %s, but destination %s does not serve gRPC. These conditions check the gRPC status of responses and never apply to it.")
+
+	// MeshWideFaultInjection defines a diag.MessageType for message "MeshWideFaultInjection".
+	// Description: A virtual service route injects faults into all traffic of the mesh to a wildcard host
+	MeshWideFaultInjection = diag.NewMessageType(diag.Warning, "IST0204", "Route %s injects faults into all requests to %s from every workload in the mesh, as it has no match conditions and the virtual service applies to the mesh gateway with a wildcard host. Add match conditions or narrow the hosts to limit the faults to the traffic under test.")
+
+	// IdentityTrustDomainMismatch defines a diag.MessageType for message "IdentityTrustDomainMismatch".
+	// Description: An identity in a resource has a trust domain that is not the one of the mesh, nor one of its aliases
+	IdentityTrustDomainMismatch = diag.NewMessageType(diag.Warning, "IST0205", "The %s %q has trust domain %s, which is neither the trust domain %s of the mesh nor one of its aliases %v. It can never match the identity of a workload of the mesh.")
+
+	// MalformedPrincipal defines a diag.MessageType for message "MalformedPrincipal".
+	// Description: A principal of an authorization policy does not have the form of the identities of workloads
+	MalformedPrincipal = diag.NewMessageType(diag.Warning, "IST0206", "Principal %q can never match the identity of a workload, as %s.")
+
+	// GatewayServerHostsOverlap defines a diag.MessageType for message "GatewayServerHostsOverlap".
+	// Description: Servers of a gateway on the same port have overlapping hosts but different TLS modes
+	GatewayServerHostsOverlap = diag.NewMessageType(diag.Warning, "IST0207", "The servers on port %d for the overlapping hosts %s (TLS mode %s) and %s (TLS mode %s) are chosen by the SNI of connections, preferring exact hosts over wildcards. Connections to the hosts both servers accept get only one of the TLS modes.")
+
+	// PassthroughHostCollision defines a diag.MessageType for message "PassthroughHostCollision".
+	// Description: A TLS passthrough server of a gateway has hosts that collide with a server terminating TLS on the same port
+	PassthroughHostCollision = diag.NewMessageType(diag.Warning, "IST0208", "The %s server on port %d for host %s collides with host %s of a server terminating TLS with mode %s on the same port. Connections to the hosts both servers accept are either terminated at the gateway or passed through to the destination, depending on which host is more specific.")
+
+	// VirtualServiceNotPushed defines a diag.MessageType for message "VirtualServiceNotPushed".
+	// Description: A virtual service produced no routes in the configuration pushed to the proxies it applies to
+	VirtualServiceNotPushed = diag.NewMessageType(diag.Warning, "IST0209", "The virtual service produced no routes in the configuration pushed to the proxies it applies to (%s). Check that its hosts match services known to these proxies, and that no other virtual service for the same hosts takes precedence.")
+
+	// UnscopedProxyConfig defines a diag.MessageType for message "UnscopedProxyConfig".
+	// Description: Proxies of a large mesh import all service ports because no Sidecar resource limits their egress hosts
+	UnscopedProxyConfig = diag.NewMessageType(diag.Warning, "IST0210", "The %d proxies in namespace %s import all %d service ports visible to them, an estimated %s of configuration per proxy, because no Sidecar resource in the namespace or the root namespace limits their egress hosts. Add a Sidecar resource that only imports the hosts the workloads call.")
+
+	// WildcardSidecarEgress defines a diag.MessageType for message "WildcardSidecarEgress".
+	// Description: A Sidecar resource of a large mesh imports the hosts of all namespaces
+	WildcardSidecarEgress = diag.NewMessageType(diag.Warning, "IST0211", "The sidecar imports the hosts of all namespaces (*/*), i.e. all %d service ports visible to its proxies, an estimated %s of configuration per proxy. Only import the namespaces and hosts the workloads call.")
+
+	// LargeVirtualService defines a diag.MessageType for message "LargeVirtualService".
+	// Description: A virtual service has so many routes that it slows down request matching and grows the configuration of proxies
+	LargeVirtualService = diag.NewMessageType(diag.Info, "IST0212", "The virtual service has %d routes, more than %d. Routes are matched in order for each request, and add an estimated %s of configuration to every proxy the virtual service is exported to. Consider splitting it into virtual services for fewer hosts.")
+
+	// MeshWideEnvoyFilter defines a diag.MessageType for message "MeshWideEnvoyFilter".
+	// Description: An envoy filter patches the configuration of every proxy of the mesh
+	MeshWideEnvoyFilter = diag.NewMessageType(diag.Info, "IST0213", "The envoy filter applies %d patches to the configuration of every proxy of the mesh, which are re-applied on each push to all of them. Set a workloadSelector, or move the envoy filter out of the root namespace, to only patch the proxies that need it.")
+
+	// IngressNotHandled defines a diag.MessageType for message "IngressNotHandled".
+	// Description: A Kubernetes Ingress that looks meant for Istio is not handled by Istio
+	IngressNotHandled = diag.NewMessageType(diag.Info, "IST0214", "The Ingress is not handled by Istio: %s.")
+
+	// IngressBackendPortNotFound defines a diag.MessageType for message "IngressBackendPortNotFound".
+	// Description: The backend of a Kubernetes Ingress references a port its service does not expose
+	IngressBackendPortNotFound = diag.NewMessageType(diag.Error, "IST0215", "The Ingress backend references port %s of service %s, which the service does not expose.")
+
+	// IngressSecretNotInGatewayNamespace defines a diag.MessageType for message "IngressSecretNotInGatewayNamespace".
+	// Description: The TLS secret of a Kubernetes Ingress is not in the namespace of the Istio ingress gateway
+	IngressSecretNotInGatewayNamespace = diag.NewMessageType(diag.Error, "IST0216", "The TLS secret %s of the Ingress is not in namespace %s of the Istio ingress gateway, which reads the secrets of Ingresses from its own namespace. It only exists in namespaces %v.")
+
+	// IngressHostConflict defines a diag.MessageType for message "IngressHostConflict".
+	// Description: A host of a Kubernetes Ingress is also routed by a virtual service on the Istio ingress gateway
+	IngressHostConflict = diag.NewMessageType(diag.Warning, "IST0217", "Host %s of the Ingress is also routed by virtual service %s on gateway %s. Both configure the Istio ingress gateway, so requests are routed by whichever configuration takes precedence. Remove the host from the Ingress after migrating it to the Gateway.")
+
+	// GatewaySecretNotLoaded defines a diag.MessageType for message "GatewaySecretNotLoaded".
+	// Description: Gateway proxies requested the secret of a gateway server, but did not load it
+	GatewaySecretNotLoaded = diag.NewMessageType(diag.Error, "IST0218", "The credential %s was requested by gateway proxies %s, but they never received it or rejected it. The server does not accept TLS connections on these proxies. Check the secret and the logs of the proxies and of istiod.")
 )
 
 // All returns a list of all known message types.
@@ -148,9 +516,224 @@ func All() []*diag.MessageType {
 		NamespaceMultipleInjectionLabels,
 		InvalidAnnotation,
 		UnknownMeshNetworksServiceRegistry,
+		ResourceRejected,
+		ResourceTooLarge,
+		UpgradeRemovedAPI,
+		UpgradeRenamedField,
+		UpgradeChangedDefault,
+		ConflictingEnvoyFilterPatches,
+		UnmatchedWorkloadSelector,
+		UnregisteredExternalHost,
+		ZeroRouteTimeout,
+		RouteTimeoutNotLongerThanConnectTimeout,
+		TLSRouteMissingSNIHosts,
+		TLSRouteNotOnPassthroughServer,
+		SNIHostNotInGatewayServer,
+		HostNotCanonical,
+		InvalidHostCharacters,
+		InconsistentHostForm,
+		ConflictingEndpointAddress,
+		ConnectionPoolRisk,
+		AggressiveOutlierDetection,
+		FieldValueOutOfRange,
+		ServiceEntryAddressNotIntercepted,
+		InvalidHostLength,
+		MeshWideResource,
+		UnprotectedGatewayExposure,
+		InvalidAnalysisChecks,
+		PortProtocolConflict,
+		IstioConfigInNonMeshNamespace,
+		InvalidGatewayCredential,
+		GatewayCertificateExpiring,
+		GatewayRoutesShadowed,
+		MTLSModeMismatch,
+		ConflictingGatewayServers,
+		OverlappingServiceEntry,
+		PodProxyInNonInjectedNamespace,
+		ProxyVersionSkew,
+		AuthorizationPolicyDeniesAll,
+		DuplicateJwtIssuer,
+		InvalidJwksURI,
+		InsecureJwksURI,
+		EnvoyFilterRenamedMatch,
+		EnvoyFilterRelativePatchWithoutMatch,
+		UnreferencedGateway,
+		UnusedSubset,
+		UnreferencedDestinationRule,
+		RemoteServiceNotFound,
+		MeshNetworksMismatch,
+		ZeroTrafficPolicyDuration,
+		IstioMutualWithCertificates,
+		SubsetTLSModeContradiction,
+		DestinationHostNotExported,
+		DestinationRuleNotExported,
+		TargetPortProtocolConflict,
+		HeadlessServicePortProtocolNotDeclared,
+		WorkloadSelectorSpansApplications,
+		ConflictingPeerAuthentications,
+		RouteParentRefNotAccepted,
+		ListenerRouteKindIncompatible,
+		ListenerCertificateMissing,
+		InconsistentWebhookFailurePolicy,
+		RootCASecretMissing,
+		RootCertificateExpiring,
+		RootCertificateExpired,
+		OverlappingInjectionRevisions,
+		GatewaySecretNotFound,
+		RegexTooComplex,
+		InvalidHeaderName,
+		ProxyRevisionVersionSkew,
+		ProxyRevisionNotInstalled,
+		MixedProxyRevisions,
+		SidecarIngressPortNotExposed,
+		SidecarIngressDuplicatePort,
+		SidecarIngressProtocolMismatch,
+		LocalityWithoutEndpoints,
+		LocalityWeightsNotSumTo100,
+		PerTryTimeoutNotShorterThanTimeout,
+		NonIdempotentRetry,
+		GrpcRetryOnNonGrpcDestination,
+		MeshWideFaultInjection,
+		IdentityTrustDomainMismatch,
+		MalformedPrincipal,
+		GatewayServerHostsOverlap,
+		PassthroughHostCollision,
+		VirtualServiceNotPushed,
+		UnscopedProxyConfig,
+		WildcardSidecarEgress,
+		LargeVirtualService,
+		MeshWideEnvoyFilter,
+		IngressNotHandled,
+		IngressBackendPortNotFound,
+		IngressSecretNotInGatewayNamespace,
+		IngressHostConflict,
+		GatewaySecretNotLoaded,
 	}
 }
 
+// details holds the name and description of all known message types, by code.
+var details = map[string]detail{
+	"IST0001": {name: "InternalError", description: "There was an internal error in the toolchain. This is almost always a bug in the implementation."},
+	"IST0002": {name: "Deprecated", description: "A feature that the configuration is depending on is now deprecated."},
+	"IST0101": {name: "ReferencedResourceNotFound", description: "A resource being referenced does not exist."},
+	"IST0102": {name: "NamespaceNotInjected", description: "A namespace is not enabled for Istio injection."},
+	"IST0103": {name: "PodMissingProxy", description: "A pod is missing the Istio proxy."},
+	"IST0104": {name: "GatewayPortNotOnWorkload", description: "Unhandled gateway port"},
+	"IST0105": {name: "IstioProxyImageMismatch", description: "The image of the Istio proxy running on the pod does not match the image defined in the injection configuration."},
+	"IST0106": {name: "SchemaValidationError", description: "The resource has a schema validation error."},
+	"IST0107": {name: "MisplacedAnnotation", description: "An Istio annotation is applied to the wrong kind of resource."},
+	"IST0108": {name: "UnknownAnnotation", description: "An Istio annotation is not recognized for any kind of resource"},
+	"IST0109": {name: "ConflictingMeshGatewayVirtualServiceHosts", description: "Conflicting hosts on VirtualServices associated with mesh gateway"},
+	"IST0110": {name: "ConflictingSidecarWorkloadSelectors", description: "A Sidecar resource selects the same workloads as another Sidecar resource"},
+	"IST0111": {name: "MultipleSidecarsWithoutWorkloadSelectors", description: "More than one sidecar resource in a namespace has no workload selector"},
+	"IST0112": {name: "VirtualServiceDestinationPortSelectorRequired", description: "A VirtualService routes to a service with more than one port exposed, but does not specify which to use."},
+	"IST0113": {name: "MTLSPolicyConflict", description: "A DestinationRule and Policy are in conflict with regards to mTLS."},
+	"IST0114": {name: "PolicySpecifiesPortNameThatDoesntExist", description: "A Policy targets a port name that cannot be found."},
+	"IST0115": {name: "DestinationRuleUsesMTLSForWorkloadWithoutSidecar", description: "A DestinationRule uses mTLS for a workload that has no sidecar."},
+	"IST0116": {name: "DeploymentAssociatedToMultipleServices", description: "The resulting pods of a service mesh deployment can't be associated with multiple services using the same port but different protocols."},
+	"IST0117": {name: "DeploymentRequiresServiceAssociated", description: "The resulting pods of a service mesh deployment must be associated with at least one service."},
+	"IST0118": {name: "PortNameIsNotUnderNamingConvention", description: "Port name is not under naming convention. Protocol detection is applied to the port."},
+	"IST0119": {name: "JwtFailureDueToInvalidServicePortPrefix", description: "Authentication policy with JWT targets Service with invalid port specification."},
+	"IST0120": {name: "PolicyResourceIsDeprecated", description: "The Policy resource is deprecated and will be removed in a future Istio release. Migrate to the PeerAuthentication resource."},
+	"IST0121": {name: "MeshPolicyResourceIsDeprecated", description: "The MeshPolicy resource is deprecated and will be removed in a future Istio release. Migrate to the PeerAuthentication resource."},
+	"IST0122": {name: "InvalidRegexp", description: "Invalid Regex"},
+	"IST0123": {name: "NamespaceMultipleInjectionLabels", description: "A namespace has both new and legacy injection labels"},
+	"IST0125": {name: "InvalidAnnotation", description: "An Istio annotation that is not valid"},
+	"IST0126": {name: "UnknownMeshNetworksServiceRegistry", description: "A service registry in Mesh Networks is unknown"},
+	"IST0127": {name: "ResourceRejected", description: "A resource was rejected by all proxies it was pushed to"},
+	"IST0128": {name: "ResourceTooLarge", description: "A resource exceeds the analysis limits and was skipped by the analyzers"},
+	"IST0129": {name: "UpgradeRemovedAPI", description: "A resource uses an API that is removed in the target Istio version"},
+	"IST0130": {name: "UpgradeRenamedField", description: "A resource sets a field that is replaced in the target Istio version"},
+	"IST0131": {name: "UpgradeChangedDefault", description: "A resource relies on a default value that changes in the target Istio version"},
+	"IST0132": {name: "ConflictingEnvoyFilterPatches", description: "Multiple EnvoyFilters insert at the same location, so their order depends on creation time"},
+	"IST0133": {name: "UnmatchedWorkloadSelector", description: "A workload selector does not match any pod, so the resource has no effect"},
+	"IST0134": {name: "UnregisteredExternalHost", description: "A host is not in the service registry, so traffic to it is blocked by the REGISTRY_ONLY outbound traffic policy"},
+	"IST0135": {name: "ZeroRouteTimeout", description: "A route sets a timeout of 0s, whose effect differs between Istio versions"},
+	"IST0136": {name: "RouteTimeoutNotLongerThanConnectTimeout", description: "A route timeout is not longer than the connect timeout of the destination"},
+	"IST0137": {name: "TLSRouteMissingSNIHosts", description: "A TLS route match has no SNI hosts, so the route is dropped"},
+	"IST0138": {name: "TLSRouteNotOnPassthroughServer", description: "A TLS route is bound to a gateway without a matching TLS passthrough server, so the route is dropped"},
+	"IST0139": {name: "SNIHostNotInGatewayServer", description: "A TLS route matches an SNI host that no TLS passthrough server of its gateway accepts"},
+	"IST0140": {name: "HostNotCanonical", description: "A host is not written in its canonical form"},
+	"IST0141": {name: "InvalidHostCharacters", description: "A host contains characters that are not valid in DNS names"},
+	"IST0142": {name: "InconsistentHostForm", description: "A service is referred to by its short name in some resources and by its FQDN in others"},
+	"IST0143": {name: "ConflictingEndpointAddress", description: "The same endpoint address is used with conflicting labels or ports, so the endpoint's assignment flaps"},
+	"IST0144": {name: "ConnectionPoolRisk", description: "DestinationRule connection pool settings are likely to cause bursts of 503 errors"},
+	"IST0145": {name: "AggressiveOutlierDetection", description: "DestinationRule outlier detection settings can eject healthy hosts and cause self-inflicted outages"},
+	"IST0146": {name: "FieldValueOutOfRange", description: "A numeric field has a value outside of its valid range"},
+	"IST0147": {name: "ServiceEntryAddressNotIntercepted", description: "Traffic to a ServiceEntry address is not intercepted by the sidecars, so it bypasses egress policy"},
+	"IST0148": {name: "InvalidHostLength", description: "A host or one of its labels is too long to be a DNS name"},
+	"IST0149": {name: "MeshWideResource", description: "A resource in the root namespace applies to the whole mesh"},
+	"IST0150": {name: "UnprotectedGatewayExposure", description: "A gateway exposed to the internet has no authorization policy or request authentication"},
+	"IST0151": {name: "InvalidAnalysisChecks", description: "A ConfigMap holds analysis checks that cannot be compiled"},
+	"IST0152": {name: "PortProtocolConflict", description: "The appProtocol and the name of a service port imply different protocols"},
+	"IST0153": {name: "IstioConfigInNonMeshNamespace", description: "A namespace without mesh workloads contains Istio configuration"},
+	"IST0154": {name: "InvalidGatewayCredential", description: "The secret referenced by a gateway does not hold a usable certificate and key"},
+	"IST0155": {name: "GatewayCertificateExpiring", description: "The certificate of a gateway credential expires soon"},
+	"IST0156": {name: "GatewayRoutesShadowed", description: "Virtual services route the same host on the same gateway with overlapping match conditions"},
+	"IST0157": {name: "MTLSModeMismatch", description: "The TLS mode of a DestinationRule does not match the mTLS mode of the destination workloads"},
+	"IST0158": {name: "ConflictingGatewayServers", description: "Gateways selecting the same workload define conflicting servers on the same port"},
+	"IST0159": {name: "OverlappingServiceEntry", description: "A ServiceEntry overlaps with a Kubernetes service or another ServiceEntry"},
+	"IST0160": {name: "PodProxyInNonInjectedNamespace", description: "A pod has an injected sidecar, but its namespace is not enabled for injection"},
+	"IST0161": {name: "ProxyVersionSkew", description: "The proxy of a pod is more than one minor version behind the control plane"},
+	"IST0162": {name: "AuthorizationPolicyDeniesAll", description: "An authorization policy allows requests, but has no rules, so it denies all requests"},
+	"IST0163": {name: "DuplicateJwtIssuer", description: "Multiple JWT rules for the same issuer apply to a workload"},
+	"IST0164": {name: "InvalidJwksURI", description: "The JWKS URI of a JWT rule is invalid"},
+	"IST0165": {name: "InsecureJwksURI", description: "The JWKS URI of a JWT rule uses plaintext HTTP"},
+	"IST0166": {name: "EnvoyFilterRenamedMatch", description: "An EnvoyFilter patch matches an Envoy object by a name that has changed between versions"},
+	"IST0167": {name: "EnvoyFilterRelativePatchWithoutMatch", description: "An EnvoyFilter patch inserts relative to an object, but does not match the object"},
+	"IST0168": {name: "UnreferencedGateway", description: "A gateway is not referenced by any virtual service"},
+	"IST0169": {name: "UnusedSubset", description: "A destination rule subset is not the destination of any virtual service route"},
+	"IST0170": {name: "UnreferencedDestinationRule", description: "The host of a destination rule is unknown, and not the destination of any virtual service route"},
+	"IST0171": {name: "RemoteServiceNotFound", description: "A service entry refers to the service of a remote cluster that does not exist"},
+	"IST0172": {name: "MeshNetworksMismatch", description: "The clusters of a mesh have different mesh networks configuration"},
+	"IST0173": {name: "ZeroTrafficPolicyDuration", description: "A traffic policy sets a duration to zero, which proxies reject"},
+	"IST0174": {name: "IstioMutualWithCertificates", description: "A traffic policy uses ISTIO_MUTUAL TLS together with explicit certificates, which are ignored"},
+	"IST0175": {name: "SubsetTLSModeContradiction", description: "The TLS mode of a subset contradicts the TLS mode of the destination rule"},
+	"IST0176": {name: "DestinationHostNotExported", description: "A virtual service is exported to namespaces its destination host is not visible in"},
+	"IST0177": {name: "DestinationRuleNotExported", description: "A virtual service is exported to namespaces the destination rules of its destination host are not visible in"},
+	"IST0178": {name: "TargetPortProtocolConflict", description: "Two ports of a service target the same container port with different protocols"},
+	"IST0179": {name: "HeadlessServicePortProtocolNotDeclared", description: "A port of a headless service does not declare its protocol"},
+	"IST0180": {name: "WorkloadSelectorSpansApplications", description: "A workload selector matches the pods of more than one application"},
+	"IST0181": {name: "ConflictingPeerAuthentications", description: "Several workload-level peer authentications select the same pod"},
+	"IST0182": {name: "RouteParentRefNotAccepted", description: "A Gateway API route references a gateway that does not accept it"},
+	"IST0183": {name: "ListenerRouteKindIncompatible", description: "A Gateway API listener allows a route kind that is incompatible with its protocol"},
+	"IST0184": {name: "ListenerCertificateMissing", description: "A Gateway API listener terminates TLS without referencing a certificate"},
+	"IST0185": {name: "InconsistentWebhookFailurePolicy", description: "The Istio validating webhooks of the cluster have different failure policies"},
+	"IST0186": {name: "RootCASecretMissing", description: "Istiod runs without a root CA secret"},
+	"IST0187": {name: "RootCertificateExpiring", description: "The root certificate of the mesh expires soon"},
+	"IST0188": {name: "RootCertificateExpired", description: "The root certificate of the mesh has expired"},
+	"IST0189": {name: "OverlappingInjectionRevisions", description: "A namespace is selected by the sidecar injectors of multiple control plane revisions"},
+	"IST0190": {name: "GatewaySecretNotFound", description: "The secret referenced by a gateway server exists in some, but not all namespaces of the gateway workloads"},
+	"IST0191": {name: "RegexTooComplex", description: "A regular expression is too complex for Envoy"},
+	"IST0192": {name: "InvalidHeaderName", description: "A matched header name is not a valid HTTP header name"},
+	"IST0193": {name: "ProxyRevisionVersionSkew", description: "The proxy of a pod is too many minor versions behind the control plane revision it belongs to"},
+	"IST0194": {name: "ProxyRevisionNotInstalled", description: "The proxy of a pod belongs to a control plane revision that is not installed"},
+	"IST0195": {name: "MixedProxyRevisions", description: "The pods of a namespace belong to different control plane revisions"},
+	"IST0196": {name: "SidecarIngressPortNotExposed", description: "An ingress listener of a sidecar is on a port that no service of the selected workloads targets"},
+	"IST0197": {name: "SidecarIngressDuplicatePort", description: "Multiple ingress listeners of a sidecar are on the same port"},
+	"IST0198": {name: "SidecarIngressProtocolMismatch", description: "The protocol of an ingress listener of a sidecar differs from the protocol of a service port targeting it"},
+	"IST0199": {name: "LocalityWithoutEndpoints", description: "A locality load balancing setting of a destination rule sends traffic to a locality without endpoints"},
+	"IST0200": {name: "LocalityWeightsNotSumTo100", description: "The weights of a locality load balancing distribution of a destination rule do not sum to 100"},
+	"IST0201": {name: "PerTryTimeoutNotShorterThanTimeout", description: "The per-try timeout of the retries of a virtual service route is not shorter than the route timeout"},
+	"IST0202": {name: "NonIdempotentRetry", description: "A virtual service route retries non-idempotent requests after they may have reached the destination"},
+	"IST0203": {name: "GrpcRetryOnNonGrpcDestination", description: "A virtual service route retries on gRPC conditions for a destination that does not serve gRPC"},
+	"IST0204": {name: "MeshWideFaultInjection", description: "A virtual service route injects faults into all traffic of the mesh to a wildcard host"},
+	"IST0205": {name: "IdentityTrustDomainMismatch", description: "An identity in a resource has a trust domain that is not the one of the mesh, nor one of its aliases"},
+	"IST0206": {name: "MalformedPrincipal", description: "A principal of an authorization policy does not have the form of the identities of workloads"},
+	"IST0207": {name: "GatewayServerHostsOverlap", description: "Servers of a gateway on the same port have overlapping hosts but different TLS modes"},
+	"IST0208": {name: "PassthroughHostCollision", description: "A TLS passthrough server of a gateway has hosts that collide with a server terminating TLS on the same port"},
+	"IST0209": {name: "VirtualServiceNotPushed", description: "A virtual service produced no routes in the configuration pushed to the proxies it applies to"},
+	"IST0210": {name: "UnscopedProxyConfig", description: "Proxies of a large mesh import all service ports because no Sidecar resource limits their egress hosts"},
+	"IST0211": {name: "WildcardSidecarEgress", description: "A Sidecar resource of a large mesh imports the hosts of all namespaces"},
+	"IST0212": {name: "LargeVirtualService", description: "A virtual service has so many routes that it slows down request matching and grows the configuration of proxies"},
+	"IST0213": {name: "MeshWideEnvoyFilter", description: "An envoy filter patches the configuration of every proxy of the mesh"},
+	"IST0214": {name: "IngressNotHandled", description: "A Kubernetes Ingress that looks meant for Istio is not handled by Istio"},
+	"IST0215": {name: "IngressBackendPortNotFound", description: "The backend of a Kubernetes Ingress references a port its service does not expose"},
+	"IST0216": {name: "IngressSecretNotInGatewayNamespace", description: "The TLS secret of a Kubernetes Ingress is not in the namespace of the Istio ingress gateway"},
+	"IST0217": {name: "IngressHostConflict", description: "A host of a Kubernetes Ingress is also routed by a virtual service on the Istio ingress gateway"},
+	"IST0218": {name: "GatewaySecretNotLoaded", description: "Gateway proxies requested the secret of a gateway server, but did not load it"},
+}
+
 // NewInternalError returns a new diag.Message based on InternalError.
 func NewInternalError(r *resource.Instance, detail string) diag.Message {
 	return diag.NewMessage(
@@ -417,3 +1000,983 @@ func NewUnknownMeshNetworksServiceRegistry(r *resource.Instance, serviceregistry
 		network,
 	)
 }
+
+// NewResourceRejected returns a new diag.Message based on ResourceRejected.
+func NewResourceRejected(r *resource.Instance, proxies string) diag.Message {
+	return diag.NewMessage(
+		ResourceRejected,
+		r,
+		proxies,
+	)
+}
+
+// NewResourceTooLarge returns a new diag.Message based on ResourceTooLarge.
+func NewResourceTooLarge(r *resource.Instance, reason string) diag.Message {
+	return diag.NewMessage(
+		ResourceTooLarge,
+		r,
+		reason,
+	)
+}
+
+// NewUpgradeRemovedAPI returns a new diag.Message based on UpgradeRemovedAPI.
+func NewUpgradeRemovedAPI(r *resource.Instance, api string, version string, hint string) diag.Message {
+	return diag.NewMessage(
+		UpgradeRemovedAPI,
+		r,
+		api,
+		version,
+		hint,
+	)
+}
+
+// NewUpgradeRenamedField returns a new diag.Message based on UpgradeRenamedField.
+func NewUpgradeRenamedField(r *resource.Instance, field string, replacement string, version string) diag.Message {
+	return diag.NewMessage(
+		UpgradeRenamedField,
+		r,
+		field,
+		replacement,
+		version,
+	)
+}
+
+// NewUpgradeChangedDefault returns a new diag.Message based on UpgradeChangedDefault.
+func NewUpgradeChangedDefault(r *resource.Instance, field string, oldDefault string, newDefault string, version string) diag.Message {
+	return diag.NewMessage(
+		UpgradeChangedDefault,
+		r,
+		field,
+		oldDefault,
+		newDefault,
+		version,
+	)
+}
+
+// NewConflictingEnvoyFilterPatches returns a new diag.Message based on ConflictingEnvoyFilterPatches.
+func NewConflictingEnvoyFilterPatches(r *resource.Instance, envoyFilters []string, location string) diag.Message {
+	return diag.NewMessage(
+		ConflictingEnvoyFilterPatches,
+		r,
+		envoyFilters,
+		location,
+	)
+}
+
+// NewUnmatchedWorkloadSelector returns a new diag.Message based on UnmatchedWorkloadSelector.
+func NewUnmatchedWorkloadSelector(r *resource.Instance, selector string, closest string) diag.Message {
+	return diag.NewMessage(
+		UnmatchedWorkloadSelector,
+		r,
+		selector,
+		closest,
+	)
+}
+
+// NewUnregisteredExternalHost returns a new diag.Message based on UnregisteredExternalHost.
+func NewUnregisteredExternalHost(r *resource.Instance, host string, namespace string) diag.Message {
+	return diag.NewMessage(
+		UnregisteredExternalHost,
+		r,
+		host,
+		namespace,
+	)
+}
+
+// NewZeroRouteTimeout returns a new diag.Message based on ZeroRouteTimeout.
+func NewZeroRouteTimeout(r *resource.Instance, route string, behavior string) diag.Message {
+	return diag.NewMessage(
+		ZeroRouteTimeout,
+		r,
+		route,
+		behavior,
+	)
+}
+
+// NewRouteTimeoutNotLongerThanConnectTimeout returns a new diag.Message based on RouteTimeoutNotLongerThanConnectTimeout.
+func NewRouteTimeoutNotLongerThanConnectTimeout(r *resource.Instance, route string, timeout string, connectTimeout string, destinationRule string) diag.Message {
+	return diag.NewMessage(
+		RouteTimeoutNotLongerThanConnectTimeout,
+		r,
+		route,
+		timeout,
+		connectTimeout,
+		destinationRule,
+	)
+}
+
+// NewTLSRouteMissingSNIHosts returns a new diag.Message based on TLSRouteMissingSNIHosts.
+func NewTLSRouteMissingSNIHosts(r *resource.Instance, route string) diag.Message {
+	return diag.NewMessage(
+		TLSRouteMissingSNIHosts,
+		r,
+		route,
+	)
+}
+
+// NewTLSRouteNotOnPassthroughServer returns a new diag.Message based on TLSRouteNotOnPassthroughServer.
+func NewTLSRouteNotOnPassthroughServer(r *resource.Instance, route string, gateway string, port string) diag.Message {
+	return diag.NewMessage(
+		TLSRouteNotOnPassthroughServer,
+		r,
+		route,
+		gateway,
+		port,
+	)
+}
+
+// NewSNIHostNotInGatewayServer returns a new diag.Message based on SNIHostNotInGatewayServer.
+func NewSNIHostNotInGatewayServer(r *resource.Instance, route string, sniHost string, gateway string) diag.Message {
+	return diag.NewMessage(
+		SNIHostNotInGatewayServer,
+		r,
+		route,
+		sniHost,
+		gateway,
+	)
+}
+
+// NewHostNotCanonical returns a new diag.Message based on HostNotCanonical.
+func NewHostNotCanonical(r *resource.Instance, host string, problem string, canonical string) diag.Message {
+	return diag.NewMessage(
+		HostNotCanonical,
+		r,
+		host,
+		problem,
+		canonical,
+	)
+}
+
+// NewInvalidHostCharacters returns a new diag.Message based on InvalidHostCharacters.
+func NewInvalidHostCharacters(r *resource.Instance, host string, characters string) diag.Message {
+	return diag.NewMessage(
+		InvalidHostCharacters,
+		r,
+		host,
+		characters,
+	)
+}
+
+// NewInconsistentHostForm returns a new diag.Message based on InconsistentHostForm.
+func NewInconsistentHostForm(r *resource.Instance, host string, fqdn string, resources []string, canonical string) diag.Message {
+	return diag.NewMessage(
+		InconsistentHostForm,
+		r,
+		host,
+		fqdn,
+		resources,
+		canonical,
+	)
+}
+
+// NewConflictingEndpointAddress returns a new diag.Message based on ConflictingEndpointAddress.
+func NewConflictingEndpointAddress(r *resource.Instance, address string, others []string, conflict string) diag.Message {
+	return diag.NewMessage(
+		ConflictingEndpointAddress,
+		r,
+		address,
+		others,
+		conflict,
+	)
+}
+
+// NewConnectionPoolRisk returns a new diag.Message based on ConnectionPoolRisk.
+func NewConnectionPoolRisk(r *resource.Instance, policy string, explanation string) diag.Message {
+	return diag.NewMessage(
+		ConnectionPoolRisk,
+		r,
+		policy,
+		explanation,
+	)
+}
+
+// NewAggressiveOutlierDetection returns a new diag.Message based on AggressiveOutlierDetection.
+func NewAggressiveOutlierDetection(r *resource.Instance, policy string, explanation string) diag.Message {
+	return diag.NewMessage(
+		AggressiveOutlierDetection,
+		r,
+		policy,
+		explanation,
+	)
+}
+
+// NewFieldValueOutOfRange returns a new diag.Message based on FieldValueOutOfRange.
+func NewFieldValueOutOfRange(r *resource.Instance, field string, value string, validRange string) diag.Message {
+	return diag.NewMessage(
+		FieldValueOutOfRange,
+		r,
+		field,
+		value,
+		validRange,
+	)
+}
+
+// NewServiceEntryAddressNotIntercepted returns a new diag.Message based on ServiceEntryAddressNotIntercepted.
+func NewServiceEntryAddressNotIntercepted(r *resource.Instance, address string, reason string, pods string) diag.Message {
+	return diag.NewMessage(
+		ServiceEntryAddressNotIntercepted,
+		r,
+		address,
+		reason,
+		pods,
+	)
+}
+
+// NewInvalidHostLength returns a new diag.Message based on InvalidHostLength.
+func NewInvalidHostLength(r *resource.Instance, host string, problem string) diag.Message {
+	return diag.NewMessage(
+		InvalidHostLength,
+		r,
+		host,
+		problem,
+	)
+}
+
+// NewMeshWideResource returns a new diag.Message based on MeshWideResource.
+func NewMeshWideResource(r *resource.Instance, rootNamespace string, field string) diag.Message {
+	return diag.NewMessage(
+		MeshWideResource,
+		r,
+		rootNamespace,
+		field,
+	)
+}
+
+// NewUnprotectedGatewayExposure returns a new diag.Message based on UnprotectedGatewayExposure.
+func NewUnprotectedGatewayExposure(r *resource.Instance, hosts []string, service string, workload string) diag.Message {
+	return diag.NewMessage(
+		UnprotectedGatewayExposure,
+		r,
+		hosts,
+		service,
+		workload,
+	)
+}
+
+// NewInvalidAnalysisChecks returns a new diag.Message based on InvalidAnalysisChecks.
+func NewInvalidAnalysisChecks(r *resource.Instance, key string, reason string) diag.Message {
+	return diag.NewMessage(
+		InvalidAnalysisChecks,
+		r,
+		key,
+		reason,
+	)
+}
+
+// NewPortProtocolConflict returns a new diag.Message based on PortProtocolConflict.
+func NewPortProtocolConflict(r *resource.Instance, portName string, port int, appProtocol string, appProtocolProtocol string, nameProtocol string, honored string) diag.Message {
+	return diag.NewMessage(
+		PortProtocolConflict,
+		r,
+		portName,
+		port,
+		appProtocol,
+		appProtocolProtocol,
+		nameProtocol,
+		honored,
+	)
+}
+
+// NewIstioConfigInNonMeshNamespace returns a new diag.Message based on IstioConfigInNonMeshNamespace.
+func NewIstioConfigInNonMeshNamespace(r *resource.Instance, namespace string, resources []string) diag.Message {
+	return diag.NewMessage(
+		IstioConfigInNonMeshNamespace,
+		r,
+		namespace,
+		resources,
+	)
+}
+
+// NewInvalidGatewayCredential returns a new diag.Message based on InvalidGatewayCredential.
+func NewInvalidGatewayCredential(r *resource.Instance, credentialName string, reason string) diag.Message {
+	return diag.NewMessage(
+		InvalidGatewayCredential,
+		r,
+		credentialName,
+		reason,
+	)
+}
+
+// NewGatewayCertificateExpiring returns a new diag.Message based on GatewayCertificateExpiring.
+func NewGatewayCertificateExpiring(r *resource.Instance, credentialName string, expiry string, window string) diag.Message {
+	return diag.NewMessage(
+		GatewayCertificateExpiring,
+		r,
+		credentialName,
+		expiry,
+		window,
+	)
+}
+
+// NewGatewayRoutesShadowed returns a new diag.Message based on GatewayRoutesShadowed.
+func NewGatewayRoutesShadowed(r *resource.Instance, virtualService string, host string, gateway string) diag.Message {
+	return diag.NewMessage(
+		GatewayRoutesShadowed,
+		r,
+		virtualService,
+		host,
+		gateway,
+	)
+}
+
+// NewMTLSModeMismatch returns a new diag.Message based on MTLSModeMismatch.
+func NewMTLSModeMismatch(r *resource.Instance, tlsMode string, host string, workload string, mtlsMode string, peerAuthentication string, consequence string) diag.Message {
+	return diag.NewMessage(
+		MTLSModeMismatch,
+		r,
+		tlsMode,
+		host,
+		workload,
+		mtlsMode,
+		peerAuthentication,
+		consequence,
+	)
+}
+
+// NewConflictingGatewayServers returns a new diag.Message based on ConflictingGatewayServers.
+func NewConflictingGatewayServers(r *resource.Instance, port int, gateway string, workload string, reason string) diag.Message {
+	return diag.NewMessage(
+		ConflictingGatewayServers,
+		r,
+		port,
+		gateway,
+		workload,
+		reason,
+	)
+}
+
+// NewOverlappingServiceEntry returns a new diag.Message based on OverlappingServiceEntry.
+func NewOverlappingServiceEntry(r *resource.Instance, field string, value string, other string, difference string) diag.Message {
+	return diag.NewMessage(
+		OverlappingServiceEntry,
+		r,
+		field,
+		value,
+		other,
+		difference,
+	)
+}
+
+// NewPodProxyInNonInjectedNamespace returns a new diag.Message based on PodProxyInNonInjectedNamespace.
+func NewPodProxyInNonInjectedNamespace(r *resource.Instance, namespace string) diag.Message {
+	return diag.NewMessage(
+		PodProxyInNonInjectedNamespace,
+		r,
+		namespace,
+	)
+}
+
+// NewProxyVersionSkew returns a new diag.Message based on ProxyVersionSkew.
+func NewProxyVersionSkew(r *resource.Instance, proxyVersion string, behind int, controlPlaneVersion string) diag.Message {
+	return diag.NewMessage(
+		ProxyVersionSkew,
+		r,
+		proxyVersion,
+		behind,
+		controlPlaneVersion,
+	)
+}
+
+// NewAuthorizationPolicyDeniesAll returns a new diag.Message based on AuthorizationPolicyDeniesAll.
+func NewAuthorizationPolicyDeniesAll(r *resource.Instance, workloads string) diag.Message {
+	return diag.NewMessage(
+		AuthorizationPolicyDeniesAll,
+		r,
+		workloads,
+	)
+}
+
+// NewDuplicateJwtIssuer returns a new diag.Message based on DuplicateJwtIssuer.
+func NewDuplicateJwtIssuer(r *resource.Instance, issuer string, workload string, policies string) diag.Message {
+	return diag.NewMessage(
+		DuplicateJwtIssuer,
+		r,
+		issuer,
+		workload,
+		policies,
+	)
+}
+
+// NewInvalidJwksURI returns a new diag.Message based on InvalidJwksURI.
+func NewInvalidJwksURI(r *resource.Instance, jwksURI string, issuer string, problem string) diag.Message {
+	return diag.NewMessage(
+		InvalidJwksURI,
+		r,
+		jwksURI,
+		issuer,
+		problem,
+	)
+}
+
+// NewInsecureJwksURI returns a new diag.Message based on InsecureJwksURI.
+func NewInsecureJwksURI(r *resource.Instance, jwksURI string, issuer string) diag.Message {
+	return diag.NewMessage(
+		InsecureJwksURI,
+		r,
+		jwksURI,
+		issuer,
+	)
+}
+
+// NewEnvoyFilterRenamedMatch returns a new diag.Message based on EnvoyFilterRenamedMatch.
+func NewEnvoyFilterRenamedMatch(r *resource.Instance, applyTo string, field string, name string, newName string) diag.Message {
+	return diag.NewMessage(
+		EnvoyFilterRenamedMatch,
+		r,
+		applyTo,
+		field,
+		name,
+		newName,
+	)
+}
+
+// NewEnvoyFilterRelativePatchWithoutMatch returns a new diag.Message based on EnvoyFilterRelativePatchWithoutMatch.
+func NewEnvoyFilterRelativePatchWithoutMatch(r *resource.Instance, operation string, applyTo string, field string, equivalent string) diag.Message {
+	return diag.NewMessage(
+		EnvoyFilterRelativePatchWithoutMatch,
+		r,
+		operation,
+		applyTo,
+		field,
+		equivalent,
+	)
+}
+
+// NewUnreferencedGateway returns a new diag.Message based on UnreferencedGateway.
+func NewUnreferencedGateway(r *resource.Instance) diag.Message {
+	return diag.NewMessage(
+		UnreferencedGateway,
+		r,
+	)
+}
+
+// NewUnusedSubset returns a new diag.Message based on UnusedSubset.
+func NewUnusedSubset(r *resource.Instance, subset string) diag.Message {
+	return diag.NewMessage(
+		UnusedSubset,
+		r,
+		subset,
+	)
+}
+
+// NewUnreferencedDestinationRule returns a new diag.Message based on UnreferencedDestinationRule.
+func NewUnreferencedDestinationRule(r *resource.Instance, host string) diag.Message {
+	return diag.NewMessage(
+		UnreferencedDestinationRule,
+		r,
+		host,
+	)
+}
+
+// NewRemoteServiceNotFound returns a new diag.Message based on RemoteServiceNotFound.
+func NewRemoteServiceNotFound(r *resource.Instance, host string, service string, cluster string) diag.Message {
+	return diag.NewMessage(
+		RemoteServiceNotFound,
+		r,
+		host,
+		service,
+		cluster,
+	)
+}
+
+// NewMeshNetworksMismatch returns a new diag.Message based on MeshNetworksMismatch.
+func NewMeshNetworksMismatch(r *resource.Instance, cluster string, other string) diag.Message {
+	return diag.NewMessage(
+		MeshNetworksMismatch,
+		r,
+		cluster,
+		other,
+	)
+}
+
+// NewZeroTrafficPolicyDuration returns a new diag.Message based on ZeroTrafficPolicyDuration.
+func NewZeroTrafficPolicyDuration(r *resource.Instance, policy string, field string) diag.Message {
+	return diag.NewMessage(
+		ZeroTrafficPolicyDuration,
+		r,
+		policy,
+		field,
+	)
+}
+
+// NewIstioMutualWithCertificates returns a new diag.Message based on IstioMutualWithCertificates.
+func NewIstioMutualWithCertificates(r *resource.Instance, policy string, fields string) diag.Message {
+	return diag.NewMessage(
+		IstioMutualWithCertificates,
+		r,
+		policy,
+		fields,
+	)
+}
+
+// NewSubsetTLSModeContradiction returns a new diag.Message based on SubsetTLSModeContradiction.
+func NewSubsetTLSModeContradiction(r *resource.Instance, subset string, mode string, destinationRuleMode string) diag.Message {
+	return diag.NewMessage(
+		SubsetTLSModeContradiction,
+		r,
+		subset,
+		mode,
+		destinationRuleMode,
+	)
+}
+
+// NewDestinationHostNotExported returns a new diag.Message based on DestinationHostNotExported.
+func NewDestinationHostNotExported(r *resource.Instance, host string, visibleIn string) diag.Message {
+	return diag.NewMessage(
+		DestinationHostNotExported,
+		r,
+		host,
+		visibleIn,
+	)
+}
+
+// NewDestinationRuleNotExported returns a new diag.Message based on DestinationRuleNotExported.
+func NewDestinationRuleNotExported(r *resource.Instance, host string, visibleIn string, exportedTo string) diag.Message {
+	return diag.NewMessage(
+		DestinationRuleNotExported,
+		r,
+		host,
+		visibleIn,
+		exportedTo,
+	)
+}
+
+// NewTargetPortProtocolConflict returns a new diag.Message based on TargetPortProtocolConflict.
+func NewTargetPortProtocolConflict(r *resource.Instance, port string, otherPort string, targetPort string, protocol string, otherProtocol string) diag.Message {
+	return diag.NewMessage(
+		TargetPortProtocolConflict,
+		r,
+		port,
+		otherPort,
+		targetPort,
+		protocol,
+		otherProtocol,
+	)
+}
+
+// NewHeadlessServicePortProtocolNotDeclared returns a new diag.Message based on HeadlessServicePortProtocolNotDeclared.
+func NewHeadlessServicePortProtocolNotDeclared(r *resource.Instance, portName string, port int) diag.Message {
+	return diag.NewMessage(
+		HeadlessServicePortProtocolNotDeclared,
+		r,
+		portName,
+		port,
+	)
+}
+
+// NewWorkloadSelectorSpansApplications returns a new diag.Message based on WorkloadSelectorSpansApplications.
+func NewWorkloadSelectorSpansApplications(r *resource.Instance, selector string, applications []string) diag.Message {
+	return diag.NewMessage(
+		WorkloadSelectorSpansApplications,
+		r,
+		selector,
+		applications,
+	)
+}
+
+// NewConflictingPeerAuthentications returns a new diag.Message based on ConflictingPeerAuthentications.
+func NewConflictingPeerAuthentications(r *resource.Instance, conflictingPolicies []string, workloadPod string) diag.Message {
+	return diag.NewMessage(
+		ConflictingPeerAuthentications,
+		r,
+		conflictingPolicies,
+		workloadPod,
+	)
+}
+
+// NewRouteParentRefNotAccepted returns a new diag.Message based on RouteParentRefNotAccepted.
+func NewRouteParentRefNotAccepted(r *resource.Instance, parentRef string, reason string) diag.Message {
+	return diag.NewMessage(
+		RouteParentRefNotAccepted,
+		r,
+		parentRef,
+		reason,
+	)
+}
+
+// NewListenerRouteKindIncompatible returns a new diag.Message based on ListenerRouteKindIncompatible.
+func NewListenerRouteKindIncompatible(r *resource.Instance, listener string, kind string, protocol string) diag.Message {
+	return diag.NewMessage(
+		ListenerRouteKindIncompatible,
+		r,
+		listener,
+		kind,
+		protocol,
+	)
+}
+
+// NewListenerCertificateMissing returns a new diag.Message based on ListenerCertificateMissing.
+func NewListenerCertificateMissing(r *resource.Instance, listener string, protocol string) diag.Message {
+	return diag.NewMessage(
+		ListenerCertificateMissing,
+		r,
+		listener,
+		protocol,
+	)
+}
+
+// NewInconsistentWebhookFailurePolicy returns a new diag.Message based on InconsistentWebhookFailurePolicy.
+func NewInconsistentWebhookFailurePolicy(r *resource.Instance, webhook string, failurePolicy string, otherWebhook string, otherFailurePolicy string) diag.Message {
+	return diag.NewMessage(
+		InconsistentWebhookFailurePolicy,
+		r,
+		webhook,
+		failurePolicy,
+		otherWebhook,
+		otherFailurePolicy,
+	)
+}
+
+// NewRootCASecretMissing returns a new diag.Message based on RootCASecretMissing.
+func NewRootCASecretMissing(r *resource.Instance, namespace string) diag.Message {
+	return diag.NewMessage(
+		RootCASecretMissing,
+		r,
+		namespace,
+	)
+}
+
+// NewRootCertificateExpiring returns a new diag.Message based on RootCertificateExpiring.
+func NewRootCertificateExpiring(r *resource.Instance, secret string, expiry string, window string) diag.Message {
+	return diag.NewMessage(
+		RootCertificateExpiring,
+		r,
+		secret,
+		expiry,
+		window,
+	)
+}
+
+// NewRootCertificateExpired returns a new diag.Message based on RootCertificateExpired.
+func NewRootCertificateExpired(r *resource.Instance, secret string, expiry string) diag.Message {
+	return diag.NewMessage(
+		RootCertificateExpired,
+		r,
+		secret,
+		expiry,
+	)
+}
+
+// NewOverlappingInjectionRevisions returns a new diag.Message based on OverlappingInjectionRevisions.
+func NewOverlappingInjectionRevisions(r *resource.Instance, revisions []string) diag.Message {
+	return diag.NewMessage(
+		OverlappingInjectionRevisions,
+		r,
+		revisions,
+	)
+}
+
+// NewGatewaySecretNotFound returns a new diag.Message based on GatewaySecretNotFound.
+func NewGatewaySecretNotFound(r *resource.Instance, credentialName string, port uint32, hosts []string, namespace string) diag.Message {
+	return diag.NewMessage(
+		GatewaySecretNotFound,
+		r,
+		credentialName,
+		port,
+		hosts,
+		namespace,
+	)
+}
+
+// NewRegexTooComplex returns a new diag.Message based on RegexTooComplex.
+func NewRegexTooComplex(r *resource.Instance, where string, re string, size int, limit int) diag.Message {
+	return diag.NewMessage(
+		RegexTooComplex,
+		r,
+		where,
+		re,
+		size,
+		limit,
+	)
+}
+
+// NewInvalidHeaderName returns a new diag.Message based on InvalidHeaderName.
+func NewInvalidHeaderName(r *resource.Instance, where string, name string, problem string) diag.Message {
+	return diag.NewMessage(
+		InvalidHeaderName,
+		r,
+		where,
+		name,
+		problem,
+	)
+}
+
+// NewProxyRevisionVersionSkew returns a new diag.Message based on ProxyRevisionVersionSkew.
+func NewProxyRevisionVersionSkew(r *resource.Instance, proxyVersion string, behind int, controlPlaneVersion string, revision string, maxBehind int) diag.Message {
+	return diag.NewMessage(
+		ProxyRevisionVersionSkew,
+		r,
+		proxyVersion,
+		behind,
+		controlPlaneVersion,
+		revision,
+		maxBehind,
+	)
+}
+
+// NewProxyRevisionNotInstalled returns a new diag.Message based on ProxyRevisionNotInstalled.
+func NewProxyRevisionNotInstalled(r *resource.Instance, revision string, installed []string) diag.Message {
+	return diag.NewMessage(
+		ProxyRevisionNotInstalled,
+		r,
+		revision,
+		installed,
+	)
+}
+
+// NewMixedProxyRevisions returns a new diag.Message based on MixedProxyRevisions.
+func NewMixedProxyRevisions(r *resource.Instance, revisions []string) diag.Message {
+	return diag.NewMessage(
+		MixedProxyRevisions,
+		r,
+		revisions,
+	)
+}
+
+// NewSidecarIngressPortNotExposed returns a new diag.Message based on SidecarIngressPortNotExposed.
+func NewSidecarIngressPortNotExposed(r *resource.Instance, port int) diag.Message {
+	return diag.NewMessage(
+		SidecarIngressPortNotExposed,
+		r,
+		port,
+	)
+}
+
+// NewSidecarIngressDuplicatePort returns a new diag.Message based on SidecarIngressDuplicatePort.
+func NewSidecarIngressDuplicatePort(r *resource.Instance, port int) diag.Message {
+	return diag.NewMessage(
+		SidecarIngressDuplicatePort,
+		r,
+		port,
+	)
+}
+
+// NewSidecarIngressProtocolMismatch returns a new diag.Message based on SidecarIngressProtocolMismatch.
+func NewSidecarIngressProtocolMismatch(r *resource.Instance, port int, protocol string, servicePort string, service string, serviceProtocol string) diag.Message {
+	return diag.NewMessage(
+		SidecarIngressProtocolMismatch,
+		r,
+		port,
+		protocol,
+		servicePort,
+		service,
+		serviceProtocol,
+	)
+}
+
+// NewLocalityWithoutEndpoints returns a new diag.Message based on LocalityWithoutEndpoints.
+func NewLocalityWithoutEndpoints(r *resource.Instance, policy string, setting string, locality string, host string) diag.Message {
+	return diag.NewMessage(
+		LocalityWithoutEndpoints,
+		r,
+		policy,
+		setting,
+		locality,
+		host,
+	)
+}
+
+// NewLocalityWeightsNotSumTo100 returns a new diag.Message based on LocalityWeightsNotSumTo100.
+func NewLocalityWeightsNotSumTo100(r *resource.Instance, policy string, from string, total int) diag.Message {
+	return diag.NewMessage(
+		LocalityWeightsNotSumTo100,
+		r,
+		policy,
+		from,
+		total,
+	)
+}
+
+// NewPerTryTimeoutNotShorterThanTimeout returns a new diag.Message based on PerTryTimeoutNotShorterThanTimeout.
+func NewPerTryTimeoutNotShorterThanTimeout(r *resource.Instance, route string, perTryTimeout string, timeout string) diag.Message {
+	return diag.NewMessage(
+		PerTryTimeoutNotShorterThanTimeout,
+		r,
+		route,
+		perTryTimeout,
+		timeout,
+	)
+}
+
+// NewNonIdempotentRetry returns a new diag.Message based on NonIdempotentRetry.
+func NewNonIdempotentRetry(r *resource.Instance, route string, methods string, conditions string) diag.Message {
+	return diag.NewMessage(
+		NonIdempotentRetry,
+		r,
+		route,
+		methods,
+		conditions,
+	)
+}
+
+// NewGrpcRetryOnNonGrpcDestination returns a new diag.Message based on GrpcRetryOnNonGrpcDestination.
+func NewGrpcRetryOnNonGrpcDestination(r *resource.Instance, route string, conditions string, host string) diag.Message {
+	return diag.NewMessage(
+		GrpcRetryOnNonGrpcDestination,
+		r,
+		route,
+		conditions,
+		host,
+	)
+}
+
+// NewMeshWideFaultInjection returns a new diag.Message based on MeshWideFaultInjection.
+func NewMeshWideFaultInjection(r *resource.Instance, route string, hosts string) diag.Message {
+	return diag.NewMessage(
+		MeshWideFaultInjection,
+		r,
+		route,
+		hosts,
+	)
+}
+
+// NewIdentityTrustDomainMismatch returns a new diag.Message based on IdentityTrustDomainMismatch.
+func NewIdentityTrustDomainMismatch(r *resource.Instance, kind string, identity string, trustDomain string, meshTrustDomain string, aliases []string) diag.Message {
+	return diag.NewMessage(
+		IdentityTrustDomainMismatch,
+		r,
+		kind,
+		identity,
+		trustDomain,
+		meshTrustDomain,
+		aliases,
+	)
+}
+
+// NewMalformedPrincipal returns a new diag.Message based on MalformedPrincipal.
+func NewMalformedPrincipal(r *resource.Instance, principal string, problem string) diag.Message {
+	return diag.NewMessage(
+		MalformedPrincipal,
+		r,
+		principal,
+		problem,
+	)
+}
+
+// NewGatewayServerHostsOverlap returns a new diag.Message based on GatewayServerHostsOverlap.
+func NewGatewayServerHostsOverlap(r *resource.Instance, port int, host string, mode string, otherHost string, otherMode string) diag.Message {
+	return diag.NewMessage(
+		GatewayServerHostsOverlap,
+		r,
+		port,
+		host,
+		mode,
+		otherHost,
+		otherMode,
+	)
+}
+
+// NewPassthroughHostCollision returns a new diag.Message based on PassthroughHostCollision.
+func NewPassthroughHostCollision(r *resource.Instance, passthroughMode string, port int, passthroughHost string, host string, mode string) diag.Message {
+	return diag.NewMessage(
+		PassthroughHostCollision,
+		r,
+		passthroughMode,
+		port,
+		passthroughHost,
+		host,
+		mode,
+	)
+}
+
+// NewVirtualServiceNotPushed returns a new diag.Message based on VirtualServiceNotPushed.
+func NewVirtualServiceNotPushed(r *resource.Instance, proxies string) diag.Message {
+	return diag.NewMessage(
+		VirtualServiceNotPushed,
+		r,
+		proxies,
+	)
+}
+
+// NewUnscopedProxyConfig returns a new diag.Message based on UnscopedProxyConfig.
+func NewUnscopedProxyConfig(r *resource.Instance, proxies int, namespace string, servicePorts int, size string) diag.Message {
+	return diag.NewMessage(
+		UnscopedProxyConfig,
+		r,
+		proxies,
+		namespace,
+		servicePorts,
+		size,
+	)
+}
+
+// NewWildcardSidecarEgress returns a new diag.Message based on WildcardSidecarEgress.
+func NewWildcardSidecarEgress(r *resource.Instance, servicePorts int, size string) diag.Message {
+	return diag.NewMessage(
+		WildcardSidecarEgress,
+		r,
+		servicePorts,
+		size,
+	)
+}
+
+// NewLargeVirtualService returns a new diag.Message based on LargeVirtualService.
+func NewLargeVirtualService(r *resource.Instance, routes int, maxRoutes int, size string) diag.Message {
+	return diag.NewMessage(
+		LargeVirtualService,
+		r,
+		routes,
+		maxRoutes,
+		size,
+	)
+}
+
+// NewMeshWideEnvoyFilter returns a new diag.Message based on MeshWideEnvoyFilter.
+func NewMeshWideEnvoyFilter(r *resource.Instance, patches int) diag.Message {
+	return diag.NewMessage(
+		MeshWideEnvoyFilter,
+		r,
+		patches,
+	)
+}
+
+// NewIngressNotHandled returns a new diag.Message based on IngressNotHandled.
+func NewIngressNotHandled(r *resource.Instance, reason string) diag.Message {
+	return diag.NewMessage(
+		IngressNotHandled,
+		r,
+		reason,
+	)
+}
+
+// NewIngressBackendPortNotFound returns a new diag.Message based on IngressBackendPortNotFound.
+func NewIngressBackendPortNotFound(r *resource.Instance, port string, service string) diag.Message {
+	return diag.NewMessage(
+		IngressBackendPortNotFound,
+		r,
+		port,
+		service,
+	)
+}
+
+// NewIngressSecretNotInGatewayNamespace returns a new diag.Message based on IngressSecretNotInGatewayNamespace.
+func NewIngressSecretNotInGatewayNamespace(r *resource.Instance, secret string, gatewayNamespace string, namespaces []string) diag.Message {
+	return diag.NewMessage(
+		IngressSecretNotInGatewayNamespace,
+		r,
+		secret,
+		gatewayNamespace,
+		namespaces,
+	)
+}
+
+// NewIngressHostConflict returns a new diag.Message based on IngressHostConflict.
+func NewIngressHostConflict(r *resource.Instance, host string, virtualService string, gateway string) diag.Message {
+	return diag.NewMessage(
+		IngressHostConflict,
+		r,
+		host,
+		virtualService,
+		gateway,
+	)
+}
+
+// NewGatewaySecretNotLoaded returns a new diag.Message based on GatewaySecretNotLoaded.
+func NewGatewaySecretNotLoaded(r *resource.Instance, credentialName string, proxies string) diag.Message {
+	return diag.NewMessage(
+		GatewaySecretNotLoaded,
+		r,
+		credentialName,
+		proxies,
+	)
+}
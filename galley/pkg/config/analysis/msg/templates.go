@@ -0,0 +1,105 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msg
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// CheckTemplate checks that a template can replace the template of the message type with the given code, e.g. to
+// translate the message or to add a link to an internal runbook. The code must be known, and the template must format
+// the same arguments with the same verbs as the original, since analyzers keep passing the arguments of the original.
+// Arguments can be reordered with explicit argument indexes, e.g. "%[2]s: %[1]s".
+func CheckTemplate(code, template string) error {
+	e, ok := Explain(code)
+	if !ok {
+		return fmt.Errorf("unknown message code %q", code)
+	}
+
+	want, err := templateArguments(e.Template)
+	if err != nil {
+		return fmt.Errorf("invalid template of %s: %v", code, err)
+	}
+	got, err := templateArguments(template)
+	if err != nil {
+		return err
+	}
+
+	for i, verb := range want {
+		v, ok := got[i]
+		if !ok {
+			return fmt.Errorf("argument %d (%%%c) is not formatted", i+1, verb)
+		}
+		if v != verb {
+			return fmt.Errorf("argument %d is formatted with %%%c instead of %%%c", i+1, v, verb)
+		}
+	}
+	for i := range got {
+		if _, ok := want[i]; !ok {
+			return fmt.Errorf("argument %d does not exist, %s has %d arguments", i+1, code, len(want))
+		}
+	}
+	return nil
+}
+
+// templateArguments returns the verbs a template formats its arguments with, by argument index. Flags, width and
+// precision are ignored, as they do not change the type of argument a verb accepts.
+func templateArguments(template string) (map[int]rune, error) {
+	result := make(map[int]rune)
+	arg := 0
+	for i := 0; i < len(template); i++ {
+		if template[i] != '%' {
+			continue
+		}
+		i++
+		for i < len(template) && strings.IndexByte("+-# 0123456789.", template[i]) >= 0 {
+			i++
+		}
+		if i < len(template) && template[i] == '[' {
+			end := strings.IndexByte(template[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated argument index at %d", i)
+			}
+			n, err := strconv.Atoi(template[i+1 : i+end])
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("invalid argument index %q", template[i:i+end+1])
+			}
+			arg = n - 1
+			i += end + 1
+			for i < len(template) && strings.IndexByte("0123456789.", template[i]) >= 0 {
+				i++
+			}
+		}
+		if i >= len(template) {
+			return nil, fmt.Errorf("missing verb at the end")
+		}
+
+		verb := rune(template[i])
+		switch {
+		case verb == '%':
+			continue
+		case verb == '*':
+			return nil, fmt.Errorf("width and precision arguments are not supported")
+		}
+		if v, ok := result[arg]; ok && v != verb {
+			return nil, fmt.Errorf("argument %d is formatted with both %%%c and %%%c", arg+1, v, verb)
+		}
+		result[arg] = verb
+		arg++
+	}
+	return result, nil
+}
@@ -0,0 +1,64 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msg
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestCheckTemplate(t *testing.T) {
+	// IST0101 is "Referenced %s not found: %q"
+	cases := []struct {
+		code     string
+		template string
+		err      string
+	}{
+		{"IST0101", "Referenced %s not found: %q. See https://wiki.example.com/runbooks/IST0101", ""},
+		{"IST0101", "%[2]q: %[1]s nicht gefunden (100%% sicher)", ""},
+		{"IST0101", "Referenced %-10s not found: %+q", ""},
+		{"IST9999", "%s", "unknown message code"},
+		{"IST0101", "Referenced %s not found", "argument 2 (%q) is not formatted"},
+		{"IST0101", "Referenced %s not found: %d", "argument 2 is formatted with %d instead of %q"},
+		{"IST0101", "Referenced %s not found: %q %s", "argument 3 does not exist"},
+		{"IST0101", "Referenced %s not found: %[2", "unterminated argument index"},
+		{"IST0101", "Referenced %s not found: %[0]q", "invalid argument index"},
+		{"IST0101", "Referenced %s not found: %*q", "not supported"},
+		{"IST0101", "Referenced %s not found: %q %", "missing verb"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.template, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+
+			err := CheckTemplate(c.code, c.template)
+			if c.err == "" {
+				g.Expect(err).NotTo(HaveOccurred())
+			} else {
+				g.Expect(err).To(MatchError(ContainSubstring(c.err)))
+			}
+		})
+	}
+}
+
+func TestCheckTemplateOfAllMessages(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	// Every message type accepts its own template
+	for _, e := range Catalog() {
+		g.Expect(CheckTemplate(e.Code, e.Template)).To(Succeed(), e.Code)
+	}
+}
@@ -0,0 +1,214 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package notify sends notifications about new config analysis findings to external systems.
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+	"istio.io/istio/galley/pkg/config/processing/snapshotter"
+	"istio.io/istio/galley/pkg/config/scope"
+)
+
+const (
+	// DefaultInterval is the default minimum time between two notifications.
+	DefaultInterval = time.Minute
+
+	// DefaultMaxFindings is the default maximum number of findings listed in a single notification.
+	DefaultMaxFindings = 20
+
+	// DefaultTimeout is the default timeout of a webhook request.
+	DefaultTimeout = 10 * time.Second
+)
+
+// Options for a Webhook.
+type Options struct {
+	// URL that notifications are POSTed to, e.g. a Slack incoming webhook.
+	URL string
+
+	// MinLevel is the least severe level of findings that are notified. Defaults to diag.Warning.
+	MinLevel diag.Level
+
+	// Interval is the minimum time between two notifications. Findings that appear in the meantime are batched into
+	// the next notification. Defaults to DefaultInterval.
+	Interval time.Duration
+
+	// MaxFindings is the maximum number of findings listed in a single notification. Defaults to DefaultMaxFindings.
+	MaxFindings int
+
+	// Client used to send the requests. Defaults to a client with DefaultTimeout.
+	Client *http.Client
+}
+
+// Payload is the JSON body POSTed to the webhook. It is compatible with Slack incoming webhooks.
+type Payload struct {
+	Text string `json:"text"`
+}
+
+// Webhook is a snapshotter.StatusUpdater that POSTs newly appeared findings to a webhook. The findings of the first
+// update are taken as the baseline and are not notified, so that restarts of the control plane do not repeat
+// notifications. A finding that is resolved and then reappears is notified again.
+type Webhook struct {
+	o Options
+
+	mu       sync.Mutex
+	seen     map[string]struct{}
+	pending  []pendingFinding
+	lastSent time.Time
+	timer    *time.Timer
+}
+
+type pendingFinding struct {
+	key string
+	m   diag.Message
+}
+
+var _ snapshotter.StatusUpdater = &Webhook{}
+
+// NewWebhook returns a new Webhook.
+func NewWebhook(o Options) *Webhook {
+	if o.MinLevel.String() == "" {
+		o.MinLevel = diag.Warning
+	}
+	if o.Interval == 0 {
+		o.Interval = DefaultInterval
+	}
+	if o.MaxFindings == 0 {
+		o.MaxFindings = DefaultMaxFindings
+	}
+	if o.Client == nil {
+		o.Client = &http.Client{Timeout: DefaultTimeout}
+	}
+	return &Webhook{o: o}
+}
+
+// Update implements snapshotter.StatusUpdater
+func (w *Webhook) Update(messages diag.Messages) {
+	current := make(map[string]diag.Message)
+	for _, m := range messages {
+		if m.Type.Level().IsWorseThanOrEqualTo(w.o.MinLevel) {
+			current[m.String()] = m
+		}
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.seen != nil {
+		// Drop pending findings that were resolved before they could be sent.
+		var pending []pendingFinding
+		queued := make(map[string]struct{})
+		for _, p := range w.pending {
+			if _, ok := current[p.key]; ok {
+				pending = append(pending, p)
+				queued[p.key] = struct{}{}
+			}
+		}
+		for _, m := range messages {
+			k := m.String()
+			if _, ok := current[k]; !ok {
+				continue
+			}
+			if _, ok := w.seen[k]; ok {
+				continue
+			}
+			if _, ok := queued[k]; ok {
+				continue
+			}
+			pending = append(pending, pendingFinding{key: k, m: m})
+			queued[k] = struct{}{}
+		}
+		w.pending = pending
+	}
+
+	w.seen = make(map[string]struct{}, len(current))
+	for k := range current {
+		w.seen[k] = struct{}{}
+	}
+
+	if len(w.pending) > 0 && w.timer == nil {
+		delay := time.Until(w.lastSent.Add(w.o.Interval))
+		if delay < 0 {
+			delay = 0
+		}
+		w.timer = time.AfterFunc(delay, w.flush)
+	}
+}
+
+func (w *Webhook) flush() {
+	if err := w.Flush(); err != nil {
+		scope.Analysis.Warnf("Failed to notify new analysis findings: %v", err)
+	}
+}
+
+// Flush sends the pending findings right away, regardless of the interval.
+func (w *Webhook) Flush() error {
+	w.mu.Lock()
+	pending := w.pending
+	w.pending = nil
+	if w.timer != nil {
+		w.timer.Stop()
+		w.timer = nil
+	}
+	if len(pending) > 0 {
+		w.lastSent = time.Now()
+	}
+	w.mu.Unlock()
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	if err := w.send(pending); err != nil {
+		return fmt.Errorf("failed to send %d findings: %v", len(pending), err)
+	}
+	return nil
+}
+
+func (w *Webhook) send(findings []pendingFinding) error {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Istio config analysis found %d new issue(s):", len(findings))
+	for i, f := range findings {
+		if i == w.o.MaxFindings {
+			fmt.Fprintf(&sb, "\n... and %d more", len(findings)-i)
+			break
+		}
+		sb.WriteString("\n• ")
+		sb.WriteString(f.m.String())
+	}
+
+	b, err := json.Marshal(Payload{Text: sb.String()})
+	if err != nil {
+		return err
+	}
+
+	resp, err := w.o.Client.Post(w.o.URL, "application/json", bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+)
+
+var (
+	errorType = diag.NewMessageType(diag.Error, "TEST0001", "error %s")
+	warnType  = diag.NewMessageType(diag.Warning, "TEST0002", "warning %s")
+	infoType  = diag.NewMessageType(diag.Info, "TEST0003", "info %s")
+)
+
+func newServer(t *testing.T) (*httptest.Server, chan Payload) {
+	t.Helper()
+	ch := make(chan Payload, 10)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var p Payload
+		if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+			t.Errorf("invalid payload: %v", err)
+		}
+		ch <- p
+	}))
+	return s, ch
+}
+
+func TestWebhookNotifiesNewFindings(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	s, ch := newServer(t)
+	defer s.Close()
+	w := NewWebhook(Options{URL: s.URL, Interval: time.Millisecond})
+
+	existing := diag.NewMessage(errorType, nil, "existing")
+	w.Update(diag.Messages{existing})
+	g.Consistently(ch, 100*time.Millisecond).ShouldNot(Receive())
+
+	added := diag.NewMessage(warnType, nil, "added")
+	info := diag.NewMessage(infoType, nil, "info")
+	w.Update(diag.Messages{existing, added, added, info})
+
+	var p Payload
+	g.Eventually(ch).Should(Receive(&p))
+	g.Expect(p.Text).To(ContainSubstring("1 new issue(s)"))
+	g.Expect(p.Text).To(ContainSubstring("warning added"))
+	g.Expect(p.Text).NotTo(ContainSubstring("existing"))
+	g.Expect(p.Text).NotTo(ContainSubstring("info"))
+
+	// Unchanged findings are not notified again.
+	w.Update(diag.Messages{existing, added})
+	g.Consistently(ch, 100*time.Millisecond).ShouldNot(Receive())
+
+	// Resolved findings that reappear are.
+	w.Update(diag.Messages{added})
+	w.Update(diag.Messages{existing, added})
+	g.Eventually(ch).Should(Receive(&p))
+	g.Expect(p.Text).To(ContainSubstring("error existing"))
+}
+
+func TestWebhookRateLimits(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	s, ch := newServer(t)
+	defer s.Close()
+	w := NewWebhook(Options{URL: s.URL, Interval: 500 * time.Millisecond})
+	w.Update(nil)
+
+	m1 := diag.NewMessage(errorType, nil, "one")
+	m2 := diag.NewMessage(errorType, nil, "two")
+	m3 := diag.NewMessage(errorType, nil, "three")
+
+	w.Update(diag.Messages{m1})
+	var p Payload
+	g.Eventually(ch).Should(Receive(&p))
+	g.Expect(p.Text).To(ContainSubstring("error one"))
+
+	// Findings that appear within the interval are batched, and those resolved in the meantime are dropped.
+	w.Update(diag.Messages{m1, m2})
+	w.Update(diag.Messages{m1, m3})
+	w.Update(diag.Messages{m1, m2, m3})
+	g.Consistently(ch, 200*time.Millisecond).ShouldNot(Receive())
+
+	g.Eventually(ch, time.Second).Should(Receive(&p))
+	g.Expect(p.Text).To(ContainSubstring("2 new issue(s)"))
+	g.Expect(p.Text).To(ContainSubstring("error two"))
+	g.Expect(p.Text).To(ContainSubstring("error three"))
+}
+
+func TestWebhookTruncatesFindings(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	s, ch := newServer(t)
+	defer s.Close()
+	w := NewWebhook(Options{URL: s.URL, Interval: time.Millisecond, MaxFindings: 2, MinLevel: diag.Error})
+	w.Update(nil)
+
+	w.Update(diag.Messages{
+		diag.NewMessage(errorType, nil, "one"),
+		diag.NewMessage(errorType, nil, "two"),
+		diag.NewMessage(errorType, nil, "three"),
+		diag.NewMessage(warnType, nil, "four"),
+	})
+
+	var p Payload
+	g.Eventually(ch).Should(Receive(&p))
+	g.Expect(p.Text).To(ContainSubstring("3 new issue(s)"))
+	g.Expect(strings.Count(p.Text, "\n• ")).To(Equal(2))
+	g.Expect(p.Text).To(HaveSuffix("... and 1 more"))
+}
+
+func TestWebhookFlush(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	s, ch := newServer(t)
+	defer s.Close()
+	w := NewWebhook(Options{URL: s.URL, Interval: time.Hour})
+	w.Update(nil)
+
+	g.Expect(w.Flush()).To(Succeed())
+	g.Consistently(ch, 100*time.Millisecond).ShouldNot(Receive())
+
+	w.Update(diag.Messages{diag.NewMessage(errorType, nil, "one")})
+	g.Expect(w.Flush()).To(Succeed())
+
+	// The first notification is not delayed, so the timer might have sent it before Flush.
+	var p Payload
+	g.Eventually(ch).Should(Receive(&p))
+	g.Expect(p.Text).To(ContainSubstring("error one"))
+
+	w.Update(diag.Messages{diag.NewMessage(errorType, nil, "one"), diag.NewMessage(errorType, nil, "two")})
+	g.Consistently(ch, 100*time.Millisecond).ShouldNot(Receive())
+	g.Expect(w.Flush()).To(Succeed())
+	g.Expect(ch).To(Receive(&p))
+	g.Expect(p.Text).To(ContainSubstring("error two"))
+}
@@ -0,0 +1,63 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package opa exports analysis snapshots as OPA input documents, and evaluates user supplied Rego policies against
+// them as part of an analysis run.
+package opa
+
+import (
+	"fmt"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+)
+
+// Export renders the resources in the given collections as an OPA input document of the form:
+//
+//   {
+//     "resources": {
+//       "<collection>": [
+//         {"apiVersion": ..., "kind": ..., "metadata": {...}, "spec": {...}},
+//         ...
+//       ],
+//       ...
+//     }
+//   }
+//
+// Collections without resources are rendered as empty lists, so that policies can iterate over them unconditionally.
+func Export(ctx analysis.Context, cols collection.Names) (map[string]interface{}, error) {
+	resources := make(map[string]interface{}, len(cols))
+	for _, col := range cols {
+		docs := make([]interface{}, 0)
+		var err error
+		ctx.ForEach(col, func(r *resource.Instance) bool {
+			var doc map[string]interface{}
+			if doc, err = analysis.ResourceDocument(r); err != nil {
+				err = fmt.Errorf("error exporting %s %v: %v", col, r.Metadata.FullName, err)
+				return false
+			}
+			docs = append(docs, doc)
+			return true
+		})
+		if err != nil {
+			return nil, err
+		}
+		resources[col.String()] = docs
+	}
+
+	return map[string]interface{}{
+		"resources": resources,
+	}, nil
+}
@@ -0,0 +1,119 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package opa
+
+import (
+	"testing"
+
+	"github.com/gogo/protobuf/types"
+	. "github.com/onsi/gomega"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+	"istio.io/istio/galley/pkg/config/testing/basicmeta"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+)
+
+type testContext struct {
+	resources map[collection.Name][]*resource.Instance
+	reports   []diag.Message
+}
+
+var _ analysis.Context = &testContext{}
+
+// Report implements analysis.Context
+func (ctx *testContext) Report(_ collection.Name, m diag.Message) {
+	ctx.reports = append(ctx.reports, m)
+}
+
+// Find implements analysis.Context
+func (ctx *testContext) Find(col collection.Name, name resource.FullName) *resource.Instance {
+	for _, r := range ctx.resources[col] {
+		if r.Metadata.FullName == name {
+			return r
+		}
+	}
+	return nil
+}
+
+// Exists implements analysis.Context
+func (ctx *testContext) Exists(col collection.Name, name resource.FullName) bool {
+	return ctx.Find(col, name) != nil
+}
+
+// ForEach implements analysis.Context
+func (ctx *testContext) ForEach(col collection.Name, fn analysis.IteratorFn) {
+	for _, r := range ctx.resources[col] {
+		if !fn(r) {
+			return
+		}
+	}
+}
+
+// Canceled implements analysis.Context
+func (ctx *testContext) Canceled() bool {
+	return false
+}
+
+func newInstance(ns, name string, labels map[string]string, fields map[string]string) *resource.Instance {
+	s := &types.Struct{Fields: make(map[string]*types.Value)}
+	for k, v := range fields {
+		s.Fields[k] = &types.Value{Kind: &types.Value_StringValue{StringValue: v}}
+	}
+	return &resource.Instance{
+		Metadata: resource.Metadata{
+			FullName: resource.NewFullName(resource.Namespace(ns), resource.LocalName(name)),
+			Version:  "v1",
+			Schema:   basicmeta.K8SCollection1.Resource(),
+			Labels:   labels,
+		},
+		Message: s,
+	}
+}
+
+func TestExport(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	col1 := basicmeta.K8SCollection1.Name()
+	col2 := basicmeta.Collection2.Name()
+	ctx := &testContext{
+		resources: map[collection.Name][]*resource.Instance{
+			col1: {newInstance("ns", "r1", map[string]string{"app": "a"}, map[string]string{"foo": "bar"})},
+		},
+	}
+
+	input, err := Export(ctx, collection.Names{col1, col2})
+	g.Expect(err).To(BeNil())
+	g.Expect(input).To(Equal(map[string]interface{}{
+		"resources": map[string]interface{}{
+			col1.String(): []interface{}{
+				map[string]interface{}{
+					"apiVersion": basicmeta.K8SCollection1.Resource().APIVersion(),
+					"kind":       basicmeta.K8SCollection1.Resource().Kind(),
+					"metadata": map[string]interface{}{
+						"name":            "r1",
+						"namespace":       "ns",
+						"resourceVersion": "v1",
+						"labels":          map[string]interface{}{"app": "a"},
+						"annotations":     map[string]interface{}{},
+					},
+					"spec": map[string]interface{}{"foo": "bar"},
+				},
+			},
+			col2.String(): []interface{}{},
+		},
+	}))
+}
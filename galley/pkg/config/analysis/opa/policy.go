@@ -0,0 +1,222 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package opa
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/open-policy-agent/opa/ast"
+	"github.com/open-policy-agent/opa/rego"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+	"istio.io/istio/galley/pkg/config/scope"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+)
+
+// ViolationQuery is the Rego query evaluated to find policy violations. Policies contribute to it by defining rules
+// in the istio.analysis package, e.g.:
+//
+//   package istio.analysis
+//
+//   violation[{"code": "ORG0001", "level": "Error", "message": msg,
+//               "collection": col, "namespace": gw.metadata.namespace, "name": gw.metadata.name}] {
+//     col := "istio/networking/v1alpha3/gateways"
+//     gw := input.resources[col][_]
+//     not gw.metadata.labels.team
+//     msg := "Gateways must have a team label"
+//   }
+//
+// The level is one of Info, Warn (or Warning) and Error, and defaults to Warn. The collection, namespace and name
+// identify the resource the violation is reported against, and can be omitted for violations that do not concern a
+// single resource.
+const ViolationQuery = "data.istio.analysis.violation"
+
+// PolicyAnalyzer evaluates Rego policies against the analysis snapshot, and reports their violations as messages.
+type PolicyAnalyzer struct {
+	name     string
+	inputs   collection.Names
+	compiler *ast.Compiler
+
+	// Message types are created on demand from the user defined codes, and reused across runs.
+	types map[string]*diag.MessageType
+}
+
+var _ analysis.Analyzer = &PolicyAnalyzer{}
+
+// NewPolicyAnalyzer compiles the given Rego policies, keyed by file name, into an analyzer that evaluates them
+// against the given input collections.
+func NewPolicyAnalyzer(name string, policies map[string]string, inputs collection.Names) (*PolicyAnalyzer, error) {
+	if len(policies) == 0 {
+		return nil, fmt.Errorf("no policies specified")
+	}
+
+	modules := make(map[string]*ast.Module, len(policies))
+	for filename, policy := range policies {
+		parsed, err := ast.ParseModule(filename, policy)
+		if err != nil {
+			return nil, err
+		}
+		modules[filename] = parsed
+	}
+
+	compiler := ast.NewCompiler()
+	compiler.Compile(modules)
+	if compiler.Failed() {
+		return nil, compiler.Errors
+	}
+
+	return &PolicyAnalyzer{
+		name:     name,
+		inputs:   inputs,
+		compiler: compiler,
+		types:    make(map[string]*diag.MessageType),
+	}, nil
+}
+
+// LoadPolicyAnalyzer reads Rego policies from the given files and compiles them with NewPolicyAnalyzer.
+func LoadPolicyAnalyzer(inputs collection.Names, files ...string) (*PolicyAnalyzer, error) {
+	policies := make(map[string]string, len(files))
+	for _, f := range files {
+		b, err := ioutil.ReadFile(f)
+		if err != nil {
+			return nil, fmt.Errorf("error reading policy file %q: %v", f, err)
+		}
+		policies[f] = string(b)
+	}
+	return NewPolicyAnalyzer("opa.PolicyAnalyzer", policies, inputs)
+}
+
+// Metadata implements analysis.Analyzer
+func (a *PolicyAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:        a.name,
+		Description: "Evaluates user supplied Rego policies against the configuration",
+		Inputs:      a.inputs,
+		Fields:      []string{analysis.AllFields},
+	}
+}
+
+// Analyze implements analysis.Analyzer
+func (a *PolicyAnalyzer) Analyze(ctx analysis.Context) {
+	input, err := Export(ctx, a.inputs)
+	if err != nil {
+		scope.Analysis.Errorf("%s: %v", a.name, err)
+		return
+	}
+
+	rs, err := rego.New(
+		rego.Compiler(a.compiler),
+		rego.Query(ViolationQuery),
+		rego.Input(input),
+	).Eval(analysis.GoContext(ctx))
+	if err != nil {
+		scope.Analysis.Errorf("%s: error evaluating policies: %v", a.name, err)
+		return
+	}
+
+	for _, result := range rs {
+		for _, expr := range result.Expressions {
+			violations, ok := expr.Value.([]interface{})
+			if !ok {
+				scope.Analysis.Errorf("%s: %s is not a set of violations: %v", a.name, ViolationQuery, expr.Value)
+				continue
+			}
+			a.report(ctx, violations)
+		}
+	}
+}
+
+func (a *PolicyAnalyzer) report(ctx analysis.Context, violations []interface{}) {
+	type report struct {
+		key string
+		col collection.Name
+		m   diag.Message
+	}
+
+	var reports []report
+	for _, v := range violations {
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			scope.Analysis.Errorf("%s: violation is not an object: %v", a.name, v)
+			continue
+		}
+
+		code := stringField(obj, "code")
+		if code == "" {
+			scope.Analysis.Errorf("%s: violation has no code: %v", a.name, v)
+			continue
+		}
+		level, ok := parseLevel(stringField(obj, "level"))
+		if !ok {
+			scope.Analysis.Errorf("%s: violation has an unknown level: %v", a.name, v)
+			continue
+		}
+
+		col := collection.NewName(stringField(obj, "collection"))
+		fullName := resource.NewFullName(
+			resource.Namespace(stringField(obj, "namespace")), resource.LocalName(stringField(obj, "name")))
+		var r *resource.Instance
+		if col.String() != "" && fullName.Name != "" {
+			r = ctx.Find(col, fullName)
+		}
+
+		message := stringField(obj, "message")
+		reports = append(reports, report{
+			key: strings.Join([]string{code, col.String(), fullName.String(), message}, "/"),
+			col: col,
+			m:   diag.NewMessage(a.messageType(level, code), r, message),
+		})
+	}
+
+	// Rego sets are unordered, so report in a stable order.
+	sort.Slice(reports, func(i, j int) bool {
+		return reports[i].key < reports[j].key
+	})
+	for _, r := range reports {
+		ctx.Report(r.col, r.m)
+	}
+}
+
+func (a *PolicyAnalyzer) messageType(level diag.Level, code string) *diag.MessageType {
+	key := level.String() + "/" + code
+	mt, ok := a.types[key]
+	if !ok {
+		mt = diag.NewMessageType(level, code, "%s")
+		a.types[key] = mt
+	}
+	return mt
+}
+
+func parseLevel(s string) (diag.Level, bool) {
+	if s == "" {
+		return diag.Warning, true
+	}
+	s = strings.ToUpper(s)
+	if s == "WARNING" {
+		return diag.Warning, true
+	}
+	l, ok := diag.GetUppercaseStringToLevelMap()[s]
+	return l, ok
+}
+
+func stringField(obj map[string]interface{}, key string) string {
+	s, _ := obj[key].(string)
+	return s
+}
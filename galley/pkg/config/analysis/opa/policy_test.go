@@ -0,0 +1,111 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package opa
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+	"istio.io/istio/galley/pkg/config/testing/basicmeta"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+)
+
+const teamLabelPolicy = `
+package istio.analysis
+
+violation[{"code": "ORG0001", "level": "Error", "message": msg,
+           "collection": col, "namespace": r.metadata.namespace, "name": r.metadata.name}] {
+	col := "k8s/collection1"
+	r := input.resources[col][_]
+	not r.metadata.labels.team
+	msg := sprintf("%s has no team label", [r.metadata.name])
+}
+
+violation[{"code": "ORG0002", "message": "at most one resource is allowed"}] {
+	count(input.resources["k8s/collection1"]) > 1
+}
+`
+
+func TestPolicyAnalyzer(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	col := basicmeta.K8SCollection1.Name()
+	a, err := NewPolicyAnalyzer("test", map[string]string{"team.rego": teamLabelPolicy}, collection.Names{col})
+	g.Expect(err).To(BeNil())
+	g.Expect(a.Metadata().Inputs).To(ConsistOf(col))
+
+	labeled := newInstance("ns", "labeled", map[string]string{"team": "a"}, nil)
+	unlabeled := newInstance("ns", "unlabeled", nil, nil)
+	ctx := &testContext{
+		resources: map[collection.Name][]*resource.Instance{
+			col: {labeled, unlabeled},
+		},
+	}
+	a.Analyze(ctx)
+
+	g.Expect(ctx.reports).To(HaveLen(2))
+
+	g.Expect(ctx.reports[0].Type.Code()).To(Equal("ORG0001"))
+	g.Expect(ctx.reports[0].Type.Level()).To(Equal(diag.Error))
+	g.Expect(ctx.reports[0].Resource).To(BeIdenticalTo(unlabeled))
+	g.Expect(ctx.reports[0].Parameters).To(Equal([]interface{}{"unlabeled has no team label"}))
+
+	g.Expect(ctx.reports[1].Type.Code()).To(Equal("ORG0002"))
+	g.Expect(ctx.reports[1].Type.Level()).To(Equal(diag.Warning))
+	g.Expect(ctx.reports[1].Resource).To(BeNil())
+
+	// Message types are reused across runs
+	types := []*diag.MessageType{ctx.reports[0].Type, ctx.reports[1].Type}
+	ctx.reports = nil
+	a.Analyze(ctx)
+	g.Expect([]*diag.MessageType{ctx.reports[0].Type, ctx.reports[1].Type}).To(Equal(types))
+}
+
+func TestNewPolicyAnalyzerErrors(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	col := basicmeta.K8SCollection1.Name()
+
+	_, err := NewPolicyAnalyzer("test", nil, collection.Names{col})
+	g.Expect(err).NotTo(BeNil())
+
+	_, err = NewPolicyAnalyzer("test", map[string]string{"bad.rego": "package"}, collection.Names{col})
+	g.Expect(err).NotTo(BeNil())
+
+	_, err = NewPolicyAnalyzer("test", map[string]string{"bad.rego": "package x\nviolation[v] { v := y }"}, collection.Names{col})
+	g.Expect(err).NotTo(BeNil())
+}
+
+func TestParseLevel(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	for in, want := range map[string]diag.Level{
+		"":        diag.Warning,
+		"info":    diag.Info,
+		"Warn":    diag.Warning,
+		"WARNING": diag.Warning,
+		"error":   diag.Error,
+	} {
+		l, ok := parseLevel(in)
+		g.Expect(ok).To(BeTrue())
+		g.Expect(l).To(Equal(want))
+	}
+
+	_, ok := parseLevel("fatal")
+	g.Expect(ok).To(BeFalse())
+}
@@ -0,0 +1,155 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orgpolicy
+
+import (
+	"fmt"
+
+	k8s_labels "k8s.io/apimachinery/pkg/labels"
+
+	"istio.io/api/networking/v1alpha3"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+// RuleAnalyzer evaluates organization policy rules against the configuration, and reports their violations with the
+// rule IDs as message codes.
+type RuleAnalyzer struct {
+	rules *Rules
+
+	// Message types are created from the rules, and reused across runs.
+	types map[string]*diag.MessageType
+}
+
+var _ analysis.Analyzer = &RuleAnalyzer{}
+
+// NewRuleAnalyzer returns an analyzer evaluating the given rules.
+func NewRuleAnalyzer(rules *Rules) *RuleAnalyzer {
+	types := make(map[string]*diag.MessageType, len(rules.Rules))
+	for _, r := range rules.Rules {
+		types[r.ID] = diag.NewMessageType(r.level, r.ID, "%s: %s")
+	}
+	return &RuleAnalyzer{
+		rules: rules,
+		types: types,
+	}
+}
+
+// LoadRuleAnalyzer reads rules from the given files and returns an analyzer evaluating them.
+func LoadRuleAnalyzer(files ...string) (*RuleAnalyzer, error) {
+	rules, err := Load(files...)
+	if err != nil {
+		return nil, err
+	}
+	return NewRuleAnalyzer(rules), nil
+}
+
+// Metadata implements analysis.Analyzer
+func (a *RuleAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:        "orgpolicy.RuleAnalyzer",
+		Description: "Evaluates user supplied organization policy rules against the configuration",
+		Inputs: collection.Names{
+			collections.IstioNetworkingV1Alpha3Gateways.Name(),
+		},
+	}
+}
+
+// Analyze implements analysis.Analyzer
+func (a *RuleAnalyzer) Analyze(c analysis.Context) {
+	for _, rule := range a.rules.Rules {
+		if rule.GatewayServers != nil {
+			a.analyzeGatewayServers(c, rule)
+		}
+	}
+}
+
+func (a *RuleAnalyzer) analyzeGatewayServers(c analysis.Context, rule *Rule) {
+	gr := rule.GatewayServers
+	selector := k8s_labels.SelectorFromSet(gr.GatewaySelector)
+
+	c.ForEach(collections.IstioNetworkingV1Alpha3Gateways.Name(), func(r *resource.Instance) bool {
+		gw := r.Message.(*v1alpha3.Gateway)
+		if !inNamespaces(r.Metadata.FullName.Namespace, gr.Namespaces) ||
+			!selector.Matches(k8s_labels.Set(gw.GetSelector())) {
+			return true
+		}
+
+		for _, server := range gw.GetServers() {
+			for _, violation := range gr.check(server) {
+				c.Report(collections.IstioNetworkingV1Alpha3Gateways.Name(),
+					diag.NewMessage(a.types[rule.ID], r, rule.describe(), violation))
+			}
+		}
+		return true
+	})
+}
+
+// check returns the violations of the rule by a gateway server.
+func (r *GatewayServerRule) check(server *v1alpha3.Server) []string {
+	var violations []string
+	port := fmt.Sprintf("server on port %d", server.GetPort().GetNumber())
+	tls := server.GetTls()
+
+	if r.RequireTLS && tls == nil {
+		violations = append(violations, fmt.Sprintf("%s does not use TLS", port))
+	}
+	if tls == nil || tls.GetHttpsRedirect() {
+		return violations
+	}
+
+	// Passthrough servers do not terminate TLS, so the TLS settings of the gateway do not apply.
+	mode := tls.GetMode()
+	terminates := mode != v1alpha3.ServerTLSSettings_PASSTHROUGH && mode != v1alpha3.ServerTLSSettings_AUTO_PASSTHROUGH
+	if terminates && r.minProtocolVersion != v1alpha3.ServerTLSSettings_TLS_AUTO {
+		v := tls.GetMinProtocolVersion()
+		if v == v1alpha3.ServerTLSSettings_TLS_AUTO {
+			// Envoy accepts TLS 1.0 by default
+			v = v1alpha3.ServerTLSSettings_TLSV1_0
+		}
+		if v < r.minProtocolVersion {
+			violations = append(violations, fmt.Sprintf("%s accepts %s, below the minimum of %s", port, v, r.minProtocolVersion))
+		}
+	}
+	if r.credentialNamePattern != nil && tls.GetCredentialName() != "" &&
+		!r.credentialNamePattern.MatchString(tls.GetCredentialName()) {
+		violations = append(violations, fmt.Sprintf("%s uses credential %q, which does not match %q",
+			port, tls.GetCredentialName(), r.CredentialNamePattern))
+	}
+	return violations
+}
+
+func (r *Rule) describe() string {
+	if r.Description != "" {
+		return r.Description
+	}
+	return "Violates organization policy rule " + r.ID
+}
+
+func inNamespaces(ns resource.Namespace, namespaces []string) bool {
+	if len(namespaces) == 0 {
+		return true
+	}
+	for _, n := range namespaces {
+		if resource.Namespace(n) == ns {
+			return true
+		}
+	}
+	return false
+}
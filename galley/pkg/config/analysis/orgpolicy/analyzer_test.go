@@ -0,0 +1,152 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orgpolicy
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"istio.io/api/networking/v1alpha3"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+type testContext struct {
+	resources map[collection.Name][]*resource.Instance
+	reports   []diag.Message
+}
+
+var _ analysis.Context = &testContext{}
+
+// Report implements analysis.Context
+func (ctx *testContext) Report(_ collection.Name, m diag.Message) {
+	ctx.reports = append(ctx.reports, m)
+}
+
+// Find implements analysis.Context
+func (ctx *testContext) Find(collection.Name, resource.FullName) *resource.Instance {
+	return nil
+}
+
+// Exists implements analysis.Context
+func (ctx *testContext) Exists(collection.Name, resource.FullName) bool {
+	return false
+}
+
+// ForEach implements analysis.Context
+func (ctx *testContext) ForEach(col collection.Name, fn analysis.IteratorFn) {
+	for _, r := range ctx.resources[col] {
+		if !fn(r) {
+			return
+		}
+	}
+}
+
+// Canceled implements analysis.Context
+func (ctx *testContext) Canceled() bool {
+	return false
+}
+
+func newGateway(ns, name string, gw *v1alpha3.Gateway) *resource.Instance {
+	return &resource.Instance{
+		Metadata: resource.Metadata{
+			FullName: resource.NewFullName(resource.Namespace(ns), resource.LocalName(name)),
+			Schema:   collections.IstioNetworkingV1Alpha3Gateways.Resource(),
+		},
+		Message: gw,
+	}
+}
+
+func server(number uint32, tls *v1alpha3.ServerTLSSettings) *v1alpha3.Server {
+	return &v1alpha3.Server{
+		Port:  &v1alpha3.Port{Number: number, Name: "port", Protocol: "HTTPS"},
+		Hosts: []string{"*"},
+		Tls:   tls,
+	}
+}
+
+func TestRuleAnalyzer(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	rules, err := Parse([]byte(tlsRules))
+	g.Expect(err).To(BeNil())
+	a := NewRuleAnalyzer(rules)
+
+	ingress := map[string]string{"istio": "ingressgateway"}
+	ctx := &testContext{
+		resources: map[collection.Name][]*resource.Instance{
+			collections.IstioNetworkingV1Alpha3Gateways.Name(): {
+				newGateway("istio-system", "compliant", &v1alpha3.Gateway{
+					Selector: ingress,
+					Servers: []*v1alpha3.Server{
+						server(443, &v1alpha3.ServerTLSSettings{
+							Mode:               v1alpha3.ServerTLSSettings_SIMPLE,
+							CredentialName:     "shop-cert",
+							MinProtocolVersion: v1alpha3.ServerTLSSettings_TLSV1_3,
+						}),
+						server(80, &v1alpha3.ServerTLSSettings{HttpsRedirect: true}),
+						server(8443, &v1alpha3.ServerTLSSettings{Mode: v1alpha3.ServerTLSSettings_PASSTHROUGH}),
+					},
+				}),
+				newGateway("istio-system", "violating", &v1alpha3.Gateway{
+					Selector: ingress,
+					Servers: []*v1alpha3.Server{
+						server(80, nil),
+						server(443, &v1alpha3.ServerTLSSettings{
+							Mode:           v1alpha3.ServerTLSSettings_SIMPLE,
+							CredentialName: "shop",
+						}),
+					},
+				}),
+				// Not selected by the first rule, and not in the namespace of the second
+				newGateway("default", "internal", &v1alpha3.Gateway{
+					Selector: map[string]string{"istio": "internalgateway"},
+					Servers: []*v1alpha3.Server{
+						server(80, nil),
+						server(443, &v1alpha3.ServerTLSSettings{CredentialName: "internal"}),
+					},
+				}),
+			},
+		},
+	}
+	a.Analyze(ctx)
+
+	text := func(m diag.Message) string {
+		return m.Unstructured(false)["message"].(string)
+	}
+
+	g.Expect(ctx.reports).To(HaveLen(3))
+	for _, m := range ctx.reports {
+		g.Expect(m.Resource.Metadata.FullName.Name).To(Equal(resource.LocalName("violating")))
+	}
+
+	g.Expect(ctx.reports[0].Type.Code()).To(Equal("ORG-TLS-001"))
+	g.Expect(ctx.reports[0].Type.Level()).To(Equal(diag.Error))
+	g.Expect(text(ctx.reports[0])).To(Equal(
+		"Exposed server ports must use TLS 1.2 or later: server on port 80 does not use TLS"))
+	g.Expect(text(ctx.reports[1])).To(Equal(
+		"Exposed server ports must use TLS 1.2 or later: server on port 443 accepts TLSV1_0, below the minimum of TLSV1_2"))
+
+	g.Expect(ctx.reports[2].Type.Code()).To(Equal("ORG-TLS-002"))
+	g.Expect(ctx.reports[2].Type.Level()).To(Equal(diag.Warning))
+	g.Expect(text(ctx.reports[2])).To(Equal(
+		`Violates organization policy rule ORG-TLS-002: server on port 443 uses credential "shop", ` +
+			`which does not match "[a-z0-9-]+-cert"`))
+}
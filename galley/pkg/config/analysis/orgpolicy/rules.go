@@ -0,0 +1,181 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orgpolicy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+
+	"github.com/ghodss/yaml"
+
+	"istio.io/api/networking/v1alpha3"
+
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+)
+
+// Rules is a set of organization policy rules, as declared by platform teams in a rules file, e.g.:
+//
+//   rules:
+//   - id: ORG-TLS-001
+//     level: Error
+//     description: Exposed server ports must use TLS 1.2 or later
+//     gatewayServers:
+//       gatewaySelector:
+//         istio: ingressgateway
+//       requireTLS: true
+//       minProtocolVersion: TLSV1_2
+//   - id: ORG-TLS-002
+//     description: Credentials must follow the naming convention
+//     gatewayServers:
+//       credentialNamePattern: "[a-z0-9-]+-cert"
+//
+// Violations are reported with the rule ID as message code.
+type Rules struct {
+	Rules []*Rule `json:"rules"`
+}
+
+// Rule is a single organization policy rule. Each rule constrains exactly one kind of configuration.
+type Rule struct {
+	// ID identifies the rule, and is used as the code of the messages reporting its violations.
+	ID string `json:"id"`
+	// Level is one of Info, Warn (or Warning) and Error, and defaults to Warn.
+	Level string `json:"level,omitempty"`
+	// Description explains the rule, and prefixes the messages reporting its violations.
+	Description string `json:"description,omitempty"`
+
+	GatewayServers *GatewayServerRule `json:"gatewayServers,omitempty"`
+
+	level diag.Level
+}
+
+// GatewayServerRule constrains the servers of gateways.
+type GatewayServerRule struct {
+	// GatewaySelector restricts the rule to gateways whose workload selector includes these labels. The rule applies
+	// to all gateways if it is empty.
+	GatewaySelector map[string]string `json:"gatewaySelector,omitempty"`
+	// Namespaces restricts the rule to gateways in these namespaces. The rule applies to all namespaces if it is empty.
+	Namespaces []string `json:"namespaces,omitempty"`
+
+	// RequireTLS requires servers to use TLS, or to redirect to HTTPS.
+	RequireTLS bool `json:"requireTLS,omitempty"`
+	// MinProtocolVersion is the minimum TLS version of servers that terminate TLS, e.g. TLSV1_2. Servers that do not
+	// set a minimum version accept TLS 1.0.
+	MinProtocolVersion string `json:"minProtocolVersion,omitempty"`
+	// CredentialNamePattern is a regular expression that the credential names of servers must match completely.
+	CredentialNamePattern string `json:"credentialNamePattern,omitempty"`
+
+	minProtocolVersion    v1alpha3.ServerTLSSettings_TLSProtocol
+	credentialNamePattern *regexp.Regexp
+}
+
+// Parse parses and validates rules in YAML or JSON format. Unknown fields are rejected, so that misspelled
+// constraints are not silently ignored.
+func Parse(b []byte) (*Rules, error) {
+	js, err := yaml.YAMLToJSON(b)
+	if err != nil {
+		return nil, err
+	}
+
+	var rules Rules
+	d := json.NewDecoder(bytes.NewReader(js))
+	d.DisallowUnknownFields()
+	if err := d.Decode(&rules); err != nil {
+		return nil, err
+	}
+	if err := rules.validate(); err != nil {
+		return nil, err
+	}
+	return &rules, nil
+}
+
+// Load reads and parses the rules in the given files.
+func Load(files ...string) (*Rules, error) {
+	result := &Rules{}
+	for _, f := range files {
+		b, err := ioutil.ReadFile(f)
+		if err != nil {
+			return nil, fmt.Errorf("error reading rules file %q: %v", f, err)
+		}
+		rules, err := Parse(b)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing rules file %q: %v", f, err)
+		}
+		result.Rules = append(result.Rules, rules.Rules...)
+	}
+	if err := result.validate(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (r *Rules) validate() error {
+	ids := make(map[string]bool)
+	for i, rule := range r.Rules {
+		if rule == nil {
+			return fmt.Errorf("rule %d is empty", i)
+		}
+		if rule.ID == "" {
+			return fmt.Errorf("rule %d has no id", i)
+		}
+		if ids[rule.ID] {
+			return fmt.Errorf("rule %s is defined more than once", rule.ID)
+		}
+		ids[rule.ID] = true
+
+		if err := rule.validate(); err != nil {
+			return fmt.Errorf("rule %s: %v", rule.ID, err)
+		}
+	}
+	return nil
+}
+
+func (r *Rule) validate() error {
+	level, ok := diag.ParseLevel(r.Level)
+	if !ok {
+		return fmt.Errorf("unknown level %q", r.Level)
+	}
+	r.level = level
+
+	if r.GatewayServers == nil {
+		return fmt.Errorf("no constraints")
+	}
+	return r.GatewayServers.validate()
+}
+
+func (r *GatewayServerRule) validate() error {
+	if !r.RequireTLS && r.MinProtocolVersion == "" && r.CredentialNamePattern == "" {
+		return fmt.Errorf("gatewayServers has no constraints")
+	}
+
+	if r.MinProtocolVersion != "" {
+		v, ok := v1alpha3.ServerTLSSettings_TLSProtocol_value[r.MinProtocolVersion]
+		if !ok || v1alpha3.ServerTLSSettings_TLSProtocol(v) == v1alpha3.ServerTLSSettings_TLS_AUTO {
+			return fmt.Errorf("unknown minProtocolVersion %q", r.MinProtocolVersion)
+		}
+		r.minProtocolVersion = v1alpha3.ServerTLSSettings_TLSProtocol(v)
+	}
+
+	if r.CredentialNamePattern != "" {
+		p, err := regexp.Compile("^(?:" + r.CredentialNamePattern + ")$")
+		if err != nil {
+			return fmt.Errorf("invalid credentialNamePattern: %v", err)
+		}
+		r.credentialNamePattern = p
+	}
+	return nil
+}
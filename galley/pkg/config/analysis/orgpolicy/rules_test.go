@@ -0,0 +1,117 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orgpolicy
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"istio.io/api/networking/v1alpha3"
+
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+)
+
+const tlsRules = `
+rules:
+- id: ORG-TLS-001
+  level: Error
+  description: Exposed server ports must use TLS 1.2 or later
+  gatewayServers:
+    gatewaySelector:
+      istio: ingressgateway
+    requireTLS: true
+    minProtocolVersion: TLSV1_2
+- id: ORG-TLS-002
+  gatewayServers:
+    namespaces: [istio-system]
+    credentialNamePattern: "[a-z0-9-]+-cert"
+`
+
+func TestParse(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	rules, err := Parse([]byte(tlsRules))
+	g.Expect(err).To(BeNil())
+	g.Expect(rules.Rules).To(HaveLen(2))
+
+	r := rules.Rules[0]
+	g.Expect(r.ID).To(Equal("ORG-TLS-001"))
+	g.Expect(r.level).To(Equal(diag.Error))
+	g.Expect(r.GatewayServers.GatewaySelector).To(Equal(map[string]string{"istio": "ingressgateway"}))
+	g.Expect(r.GatewayServers.RequireTLS).To(BeTrue())
+	g.Expect(r.GatewayServers.minProtocolVersion).To(Equal(v1alpha3.ServerTLSSettings_TLSV1_2))
+
+	r = rules.Rules[1]
+	g.Expect(r.level).To(Equal(diag.Warning))
+	g.Expect(r.GatewayServers.Namespaces).To(Equal([]string{"istio-system"}))
+	g.Expect(r.GatewayServers.credentialNamePattern.MatchString("ingress-cert")).To(BeTrue())
+	// Patterns must match completely
+	g.Expect(r.GatewayServers.credentialNamePattern.MatchString("ingress-cert-old")).To(BeFalse())
+}
+
+func TestParseErrors(t *testing.T) {
+	cases := map[string]string{
+		"syntax":             "rules: [",
+		"unknown field":      "rules:\n- id: A\n  gatewayServers:\n    requireTls: true",
+		"no id":              "rules:\n- gatewayServers:\n    requireTLS: true",
+		"duplicate id":       "rules:\n- id: A\n  gatewayServers: {requireTLS: true}\n- id: A\n  gatewayServers: {requireTLS: true}",
+		"unknown level":      "rules:\n- id: A\n  level: Fatal\n  gatewayServers: {requireTLS: true}",
+		"no target":          "rules:\n- id: A",
+		"no constraints":     "rules:\n- id: A\n  gatewayServers: {namespaces: [default]}",
+		"unknown version":    "rules:\n- id: A\n  gatewayServers: {minProtocolVersion: TLSV1_4}",
+		"auto version":       "rules:\n- id: A\n  gatewayServers: {minProtocolVersion: TLS_AUTO}",
+		"invalid expression": "rules:\n- id: A\n  gatewayServers: {credentialNamePattern: \"(\"}",
+	}
+	for name, rules := range cases {
+		rules := rules
+		t.Run(name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			_, err := Parse([]byte(rules))
+			g.Expect(err).NotTo(BeNil())
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	dir, err := ioutil.TempDir("", "orgpolicy")
+	g.Expect(err).To(BeNil())
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		g.Expect(ioutil.WriteFile(path, []byte(content), 0644)).To(Succeed())
+		return path
+	}
+	tls := write("tls.yaml", tlsRules)
+	other := write("other.yaml", "rules:\n- id: ORG-OTHER\n  gatewayServers: {requireTLS: true}")
+	duplicate := write("duplicate.yaml", "rules:\n- id: ORG-TLS-001\n  gatewayServers: {requireTLS: true}")
+
+	rules, err := Load(tls, other)
+	g.Expect(err).To(BeNil())
+	g.Expect(rules.Rules).To(HaveLen(3))
+
+	// IDs must be unique across files
+	_, err = Load(tls, duplicate)
+	g.Expect(err).To(MatchError(ContainSubstring("ORG-TLS-001")))
+
+	_, err = Load(filepath.Join(dir, "missing.yaml"))
+	g.Expect(err).NotTo(BeNil())
+}
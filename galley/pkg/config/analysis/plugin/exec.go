@@ -0,0 +1,181 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package plugin runs analyzers that are shipped as separate executables, so that custom analyzers can be added
+// without rebuilding Istio.
+//
+// An external analyzer is run once per analysis run. It receives the resources of the analyzed collections as JSON
+// on stdin, in the same document format as the input of OPA policies (see opa.Export):
+//
+//   {"resources": {"<collection>": [{"apiVersion": ..., "kind": ..., "metadata": {...}, "spec": {...}}, ...], ...}}
+//
+// and writes the violations it found as JSON to stdout:
+//
+//   {"violations": [{"code": "ORG0001", "level": "Error", "message": "...",
+//                    "collection": "istio/networking/v1alpha3/gateways", "namespace": "default", "name": "gw"}]}
+//
+// The level is one of Info, Warn (or Warning) and Error, and defaults to Warn. The collection, namespace and name
+// identify the resource the violation is reported against, and can be omitted for violations that do not concern a
+// single resource. An analyzer exiting with a non-zero status fails, and its output is ignored.
+package plugin
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+	"istio.io/istio/galley/pkg/config/analysis/opa"
+	"istio.io/istio/galley/pkg/config/scope"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+)
+
+// ExecAnalyzer runs an external analyzer executable, and reports its violations as messages.
+type ExecAnalyzer struct {
+	name    string
+	command string
+	args    []string
+	inputs  collection.Names
+
+	// Message types are created on demand from the codes of the external analyzer, and reused across runs.
+	types map[string]*diag.MessageType
+}
+
+var _ analysis.Analyzer = &ExecAnalyzer{}
+
+// Violation is a finding of an external analyzer.
+type Violation struct {
+	Code       string `json:"code"`
+	Level      string `json:"level,omitempty"`
+	Message    string `json:"message"`
+	Collection string `json:"collection,omitempty"`
+	Namespace  string `json:"namespace,omitempty"`
+	Name       string `json:"name,omitempty"`
+}
+
+// Output is the document external analyzers write to stdout.
+type Output struct {
+	Violations []Violation `json:"violations"`
+}
+
+// NewExecAnalyzer returns an analyzer that runs the given command, passing it the resources of the given input
+// collections. The analyzer is named after the executable, e.g. plugin.team-policies for /usr/bin/team-policies.
+func NewExecAnalyzer(inputs collection.Names, command string, args ...string) *ExecAnalyzer {
+	return &ExecAnalyzer{
+		name:    "plugin." + filepath.Base(command),
+		command: command,
+		args:    args,
+		inputs:  inputs,
+		types:   make(map[string]*diag.MessageType),
+	}
+}
+
+// Metadata implements analysis.Analyzer
+func (a *ExecAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:        a.name,
+		Description: fmt.Sprintf("Runs the external analyzer %s", a.command),
+		Inputs:      a.inputs,
+		Fields:      []string{analysis.AllFields},
+	}
+}
+
+// Analyze implements analysis.Analyzer
+func (a *ExecAnalyzer) Analyze(ctx analysis.Context) {
+	input, err := opa.Export(ctx, a.inputs)
+	if err != nil {
+		scope.Analysis.Errorf("%s: %v", a.name, err)
+		return
+	}
+	in, err := json.Marshal(input)
+	if err != nil {
+		scope.Analysis.Errorf("%s: error encoding input: %v", a.name, err)
+		return
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(analysis.GoContext(ctx), a.command, a.args...)
+	cmd.Stdin = bytes.NewReader(in)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		scope.Analysis.Errorf("%s: error running %s: %v: %s", a.name, a.command, err, strings.TrimSpace(stderr.String()))
+		return
+	}
+
+	var out Output
+	if err := json.Unmarshal(stdout.Bytes(), &out); err != nil {
+		scope.Analysis.Errorf("%s: error decoding output: %v", a.name, err)
+		return
+	}
+	a.report(ctx, out.Violations)
+}
+
+func (a *ExecAnalyzer) report(ctx analysis.Context, violations []Violation) {
+	// Report in a stable order, regardless of the order the external analyzer wrote the violations in.
+	sort.SliceStable(violations, func(i, j int) bool {
+		return violationKey(violations[i]) < violationKey(violations[j])
+	})
+
+	for _, v := range violations {
+		if v.Code == "" {
+			scope.Analysis.Errorf("%s: violation has no code: %+v", a.name, v)
+			continue
+		}
+		level, ok := parseLevel(v.Level)
+		if !ok {
+			scope.Analysis.Errorf("%s: violation has an unknown level: %+v", a.name, v)
+			continue
+		}
+
+		col := collection.NewName(v.Collection)
+		var r *resource.Instance
+		if v.Collection != "" && v.Name != "" {
+			r = ctx.Find(col, resource.NewFullName(resource.Namespace(v.Namespace), resource.LocalName(v.Name)))
+		}
+		ctx.Report(col, diag.NewMessage(a.messageType(level, v.Code), r, v.Message))
+	}
+}
+
+func (a *ExecAnalyzer) messageType(level diag.Level, code string) *diag.MessageType {
+	key := level.String() + "/" + code
+	mt, ok := a.types[key]
+	if !ok {
+		mt = diag.NewMessageType(level, code, "%s")
+		a.types[key] = mt
+	}
+	return mt
+}
+
+func violationKey(v Violation) string {
+	return strings.Join([]string{v.Code, v.Collection, v.Namespace, v.Name, v.Message}, "/")
+}
+
+func parseLevel(s string) (diag.Level, bool) {
+	if s == "" {
+		return diag.Warning, true
+	}
+	s = strings.ToUpper(s)
+	if s == "WARNING" {
+		return diag.Warning, true
+	}
+	l, ok := diag.GetUppercaseStringToLevelMap()[s]
+	return l, ok
+}
@@ -0,0 +1,162 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gogo/protobuf/types"
+	. "github.com/onsi/gomega"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+	"istio.io/istio/galley/pkg/config/testing/basicmeta"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+)
+
+type testContext struct {
+	resources map[collection.Name][]*resource.Instance
+	reports   []diag.Message
+}
+
+var _ analysis.Context = &testContext{}
+
+// Report implements analysis.Context
+func (ctx *testContext) Report(_ collection.Name, m diag.Message) {
+	ctx.reports = append(ctx.reports, m)
+}
+
+// Find implements analysis.Context
+func (ctx *testContext) Find(col collection.Name, name resource.FullName) *resource.Instance {
+	for _, r := range ctx.resources[col] {
+		if r.Metadata.FullName == name {
+			return r
+		}
+	}
+	return nil
+}
+
+// Exists implements analysis.Context
+func (ctx *testContext) Exists(col collection.Name, name resource.FullName) bool {
+	return ctx.Find(col, name) != nil
+}
+
+// ForEach implements analysis.Context
+func (ctx *testContext) ForEach(col collection.Name, fn analysis.IteratorFn) {
+	for _, r := range ctx.resources[col] {
+		if !fn(r) {
+			return
+		}
+	}
+}
+
+// Canceled implements analysis.Context
+func (ctx *testContext) Canceled() bool {
+	return false
+}
+
+func newInstance(ns, name string) *resource.Instance {
+	return &resource.Instance{
+		Metadata: resource.Metadata{
+			FullName: resource.NewFullName(resource.Namespace(ns), resource.LocalName(name)),
+			Version:  "v1",
+			Schema:   basicmeta.K8SCollection1.Resource(),
+		},
+		Message: &types.Struct{Fields: make(map[string]*types.Value)},
+	}
+}
+
+// writeScript writes an executable shell script to a temporary directory, and returns its path.
+func writeScript(g *GomegaWithT, dir, name, script string) string {
+	path := filepath.Join(dir, name)
+	g.Expect(ioutil.WriteFile(path, []byte("#!/bin/sh\n"+script), 0755)).To(Succeed())
+	return path
+}
+
+func TestExecAnalyzer(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	dir, err := ioutil.TempDir("", "plugin")
+	g.Expect(err).To(BeNil())
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	// The plugin saves its input, and reports a resource violation and a violation without a resource.
+	input := filepath.Join(dir, "input.json")
+	script := writeScript(g, dir, "team-policies", `cat > `+input+`
+cat <<'EOF'
+{"violations": [
+  {"code": "ORG0002", "message": "too many resources"},
+  {"code": "ORG0001", "level": "Error", "message": "r1 has no team", "collection": "k8s/collection1", "namespace": "ns", "name": "r1"},
+  {"code": "ORG0003", "level": "fatal", "message": "dropped"}
+]}
+EOF
+`)
+
+	col := basicmeta.K8SCollection1.Name()
+	a := NewExecAnalyzer(collection.Names{col}, script)
+	g.Expect(a.Metadata().Name).To(Equal("plugin.team-policies"))
+	g.Expect(a.Metadata().Inputs).To(ConsistOf(col))
+
+	r1 := newInstance("ns", "r1")
+	ctx := &testContext{
+		resources: map[collection.Name][]*resource.Instance{
+			col: {r1},
+		},
+	}
+	a.Analyze(ctx)
+
+	b, err := ioutil.ReadFile(input)
+	g.Expect(err).To(BeNil())
+	g.Expect(string(b)).To(ContainSubstring(`"k8s/collection1":[{`))
+	g.Expect(string(b)).To(ContainSubstring(`"name":"r1"`))
+
+	g.Expect(ctx.reports).To(HaveLen(2))
+	g.Expect(ctx.reports[0].Type.Code()).To(Equal("ORG0001"))
+	g.Expect(ctx.reports[0].Type.Level()).To(Equal(diag.Error))
+	g.Expect(ctx.reports[0].Resource).To(BeIdenticalTo(r1))
+	g.Expect(ctx.reports[0].Parameters).To(Equal([]interface{}{"r1 has no team"}))
+	g.Expect(ctx.reports[1].Type.Code()).To(Equal("ORG0002"))
+	g.Expect(ctx.reports[1].Type.Level()).To(Equal(diag.Warning))
+	g.Expect(ctx.reports[1].Resource).To(BeNil())
+
+	// Message types are reused across runs
+	types := []*diag.MessageType{ctx.reports[0].Type, ctx.reports[1].Type}
+	ctx.reports = nil
+	a.Analyze(ctx)
+	g.Expect([]*diag.MessageType{ctx.reports[0].Type, ctx.reports[1].Type}).To(Equal(types))
+}
+
+func TestExecAnalyzerFailures(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	dir, err := ioutil.TempDir("", "plugin")
+	g.Expect(err).To(BeNil())
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	col := basicmeta.K8SCollection1.Name()
+	for _, command := range []string{
+		writeScript(g, dir, "failing", `echo '{"violations": [{"code": "ORG0001", "message": "ignored"}]}'; exit 1`),
+		writeScript(g, dir, "garbage", `echo 'not json'`),
+		filepath.Join(dir, "missing"),
+	} {
+		ctx := &testContext{}
+		NewExecAnalyzer(collection.Names{col}, command).Analyze(ctx)
+		g.Expect(ctx.reports).To(BeEmpty())
+	}
+}
@@ -0,0 +1,277 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package analysis
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"istio.io/api/mesh/v1alpha1"
+
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+	"istio.io/istio/galley/pkg/config/analysis/pushed"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+)
+
+// Profile holds resource accounting information collected during a single analysis run. It is used to attribute
+// the memory and time consumed by analysis to specific collections and analyzers.
+type Profile struct {
+	// CollectionCounts is the number of entries in each collection of the analyzed snapshot.
+	CollectionCounts map[collection.Name]int
+
+	// Analyzers contains the measurements for each analyzer, in order of completion.
+	Analyzers []AnalyzerProfile
+
+	// PeakHeapBytes is the highest heap allocation observed during the run.
+	PeakHeapBytes uint64
+
+	// Started is the time at which the analyzers started running.
+	Started time.Time
+
+	// Duration is the total time spent running analyzers.
+	Duration time.Duration
+}
+
+// AnalyzerProfile holds the measurements for a single analyzer.
+type AnalyzerProfile struct {
+	// Name of the analyzer.
+	Name string
+
+	// Duration is the time spent in the analyzer.
+	Duration time.Duration
+
+	// AllocBytes is the number of heap bytes allocated while the analyzer was running.
+	AllocBytes uint64
+
+	// Mallocs is the number of heap objects allocated while the analyzer was running.
+	Mallocs uint64
+
+	// HeapBytes is the heap allocation at the time the analyzer completed.
+	HeapBytes uint64
+
+	// Resources is the number of resources the analyzer iterated over or looked up.
+	Resources int
+
+	// Messages is the number of messages the analyzer reported.
+	Messages int
+}
+
+// NewProfile returns a new, empty Profile.
+func NewProfile() *Profile {
+	return &Profile{
+		CollectionCounts: make(map[collection.Name]int),
+	}
+}
+
+// SetCollectionCount records the number of entries in the given collection.
+func (p *Profile) SetCollectionCount(col collection.Name, count int) {
+	p.CollectionCounts[col] = count
+}
+
+// TotalEntries returns the total number of entries across all collections.
+func (p *Profile) TotalEntries() int {
+	total := 0
+	for _, c := range p.CollectionCounts {
+		total += c
+	}
+	return total
+}
+
+// String implements io.Stringer
+func (p *Profile) String() string {
+	var b strings.Builder
+
+	_, _ = fmt.Fprintf(&b, "Collections (%d entries total):\n", p.TotalEntries())
+	cols := make([]collection.Name, 0, len(p.CollectionCounts))
+	for c := range p.CollectionCounts {
+		cols = append(cols, c)
+	}
+	sort.Slice(cols, func(i, j int) bool {
+		return cols[i].String() < cols[j].String()
+	})
+	for _, c := range cols {
+		_, _ = fmt.Fprintf(&b, "  %-60s %8d\n", c, p.CollectionCounts[c])
+	}
+
+	_, _ = fmt.Fprintf(&b, "Analyzers (%v total):\n", p.Duration)
+	for _, a := range p.Analyzers {
+		_, _ = fmt.Fprintf(&b, "  %-60s %12v %12d bytes %10d allocs\n", a.Name, a.Duration, a.AllocBytes, a.Mallocs)
+	}
+
+	_, _ = fmt.Fprintf(&b, "Peak heap: %d bytes\n", p.PeakHeapBytes)
+	return b.String()
+}
+
+// Timings returns a summary of the time spent in each analyzer, along with the number of resources it scanned and the
+// number of messages it reported.
+func (p *Profile) Timings() string {
+	var b strings.Builder
+
+	_, _ = fmt.Fprintf(&b, "Analyzer timings (%v total):\n", p.Duration)
+	for _, a := range p.Analyzers {
+		_, _ = fmt.Fprintf(&b, "  %-60s %12v %8d resources %6d messages\n", a.Name, a.Duration, a.Resources, a.Messages)
+	}
+	return b.String()
+}
+
+// profiler measures the resources consumed by the analyzers of a run. Analyzers may be measured concurrently, in
+// which case their allocation measurements include the allocations of the analyzers running at the same time.
+type profiler struct {
+	p     *Profile
+	start time.Time
+
+	mu sync.Mutex
+}
+
+// measurement is the measurement of a single analyzer that is in progress.
+type measurement struct {
+	profile AnalyzerProfile
+	start   time.Time
+	before  runtime.MemStats
+}
+
+func newProfiler(p *Profile) *profiler {
+	pr := &profiler{
+		p:     p,
+		start: time.Now(),
+	}
+	p.Started = pr.start
+	pr.sample()
+	return pr
+}
+
+func (pr *profiler) begin(name string) *measurement {
+	m := &measurement{profile: AnalyzerProfile{Name: name}}
+	runtime.ReadMemStats(&m.before)
+	pr.recordHeap(m.before.HeapAlloc)
+	m.start = time.Now()
+	return m
+}
+
+func (pr *profiler) end(m *measurement, ctx *countingContext) {
+	m.profile.Duration = time.Since(m.start)
+	m.profile.Resources = ctx.resources
+	m.profile.Messages = ctx.messages
+
+	var after runtime.MemStats
+	runtime.ReadMemStats(&after)
+	m.profile.AllocBytes = after.TotalAlloc - m.before.TotalAlloc
+	m.profile.Mallocs = after.Mallocs - m.before.Mallocs
+	m.profile.HeapBytes = after.HeapAlloc
+	pr.recordHeap(after.HeapAlloc)
+
+	pr.mu.Lock()
+	pr.p.Analyzers = append(pr.p.Analyzers, m.profile)
+	pr.mu.Unlock()
+}
+
+func (pr *profiler) done() {
+	pr.sample()
+	pr.p.Duration = time.Since(pr.start)
+}
+
+func (pr *profiler) sample() {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	pr.recordHeap(ms.HeapAlloc)
+}
+
+func (pr *profiler) recordHeap(heap uint64) {
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+	if heap > pr.p.PeakHeapBytes {
+		pr.p.PeakHeapBytes = heap
+	}
+}
+
+// countingContext is a Context that counts the resources accessed and the messages reported through it.
+type countingContext struct {
+	Context
+	resources int
+	messages  int
+}
+
+// Report implements Context
+func (c *countingContext) Report(col collection.Name, m diag.Message) {
+	c.messages++
+	c.Context.Report(col, m)
+}
+
+// Find implements Context
+func (c *countingContext) Find(col collection.Name, name resource.FullName) *resource.Instance {
+	r := c.Context.Find(col, name)
+	if r != nil {
+		c.resources++
+	}
+	return r
+}
+
+// Exists implements Context
+func (c *countingContext) Exists(col collection.Name, name resource.FullName) bool {
+	ok := c.Context.Exists(col, name)
+	if ok {
+		c.resources++
+	}
+	return ok
+}
+
+// ForEach implements Context
+func (c *countingContext) ForEach(col collection.Name, fn IteratorFn) {
+	c.Context.ForEach(col, func(r *resource.Instance) bool {
+		c.resources++
+		return fn(r)
+	})
+}
+
+// Index implements IndexProvider
+func (c *countingContext) Index(key string, build func() interface{}) interface{} {
+	return Index(c.Context, key, build)
+}
+
+// GoContext implements GoContextProvider
+func (c *countingContext) GoContext() context.Context {
+	return GoContext(c.Context)
+}
+
+// IstioVersion implements VersionProvider
+func (c *countingContext) IstioVersion() string {
+	return IstioVersion(c.Context)
+}
+
+// Clusters implements ClusterProvider
+func (c *countingContext) Clusters() []string {
+	return Clusters(c.Context)
+}
+
+// ResourceClusters implements ClusterProvider
+func (c *countingContext) ResourceClusters(col collection.Name, name resource.FullName) []string {
+	return ResourceClusters(c.Context, col, name)
+}
+
+// ClusterMeshNetworks implements ClusterProvider
+func (c *countingContext) ClusterMeshNetworks(cluster string) *v1alpha1.MeshNetworks {
+	return ClusterMeshNetworks(c.Context, cluster)
+}
+
+// PushedConfig implements PushedConfigProvider
+func (c *countingContext) PushedConfig() *pushed.Config {
+	return PushedConfig(c.Context)
+}
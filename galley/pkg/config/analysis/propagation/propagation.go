@@ -0,0 +1,131 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package propagation annotates analysis messages with the distribution status of the resources they are reported on,
+// as tracked by the control plane, so that misconfiguration can be told apart from configuration that failed to
+// propagate to proxies.
+package propagation
+
+import (
+	"fmt"
+	"strings"
+
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/resource"
+)
+
+// Status is the distribution status of a resource version to proxies.
+type Status struct {
+	// Acked is the number of proxies that accepted the resource version.
+	Acked int
+
+	// Total is the number of proxies the resource version is distributed to.
+	Total int
+
+	// NackedBy lists the proxies that rejected the resource version.
+	NackedBy []string
+}
+
+// Complete returns true if all proxies accepted the resource version.
+func (s Status) Complete() bool {
+	return s.Acked >= s.Total && len(s.NackedBy) == 0
+}
+
+// Rejected returns true if the resource version was rejected by proxies, and accepted by none.
+func (s Status) Rejected() bool {
+	return len(s.NackedBy) > 0 && s.Acked == 0
+}
+
+// Notes returns human-readable notes describing an incomplete distribution.
+func (s Status) Notes() []string {
+	var notes []string
+	if len(s.NackedBy) > 0 {
+		notes = append(notes, fmt.Sprintf("NACKed by %s %s", proxies(len(s.NackedBy)), strings.Join(s.NackedBy, ", ")))
+	}
+	if pending := s.Total - s.Acked - len(s.NackedBy); pending > 0 {
+		notes = append(notes, fmt.Sprintf("not yet propagated to %d %s", pending, proxies(pending)))
+	}
+	return notes
+}
+
+func proxies(n int) string {
+	if n == 1 {
+		return "proxy"
+	}
+	return "proxies"
+}
+
+// Tracker returns the distribution status of resources.
+type Tracker interface {
+	// Propagation returns the distribution status of the given resource version, or false if it is not known.
+	Propagation(r *resource.Instance) (Status, bool)
+}
+
+// Annotate returns a copy of msgs in which the messages about resources that have not fully propagated carry notes
+// with their distribution status.
+func Annotate(t Tracker, msgs diag.Messages) diag.Messages {
+	result := make(diag.Messages, 0, len(msgs))
+	for _, m := range msgs {
+		// ResourceRejected already names the proxies.
+		if m.Resource != nil && m.Type != msg.ResourceRejected {
+			if s, ok := t.Propagation(m.Resource); ok && !s.Complete() {
+				m.Notes = append(append([]string(nil), m.Notes...), s.Notes()...)
+			}
+		}
+		result = append(result, m)
+	}
+	return result
+}
+
+// Correlate reduces the noise of messages about resources that were rejected outright. For each such resource, a
+// single ResourceRejected message is reported, and the other messages about it are downgraded to Info, so that users
+// fix the rejection first. The returned messages are sorted.
+func Correlate(t Tracker, msgs diag.Messages) diag.Messages {
+	result := make(diag.Messages, 0, len(msgs))
+	rejected := make(map[string]bool)
+	downgraded := make(map[*diag.MessageType]*diag.MessageType)
+	for _, m := range msgs {
+		if m.Resource == nil {
+			result = append(result, m)
+			continue
+		}
+		s, ok := t.Propagation(m.Resource)
+		if !ok || !s.Rejected() {
+			result = append(result, m)
+			continue
+		}
+
+		name := m.Resource.Origin.FriendlyName()
+		if !rejected[name] {
+			rejected[name] = true
+			result = append(result, msg.NewResourceRejected(m.Resource, strings.Join(s.NackedBy, ", ")))
+		}
+		if m.Type == msg.ResourceRejected || m.Type.Level() == diag.Info {
+			result = append(result, m)
+			continue
+		}
+
+		mt, ok := downgraded[m.Type]
+		if !ok {
+			mt = diag.NewMessageType(diag.Info, m.Type.Code(), m.Type.Template())
+			downgraded[m.Type] = mt
+		}
+		m.Notes = append(append([]string(nil), m.Notes...),
+			fmt.Sprintf("downgraded from %s, as the resource was rejected by proxies", m.Type.Level()))
+		m.Type = mt
+		result = append(result, m)
+	}
+	return result.SortedDedupedCopy()
+}
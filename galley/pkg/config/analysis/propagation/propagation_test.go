@@ -0,0 +1,141 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package propagation
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/galley/pkg/config/source/kube/rt"
+	"istio.io/istio/galley/pkg/config/testing/basicmeta"
+	"istio.io/istio/pkg/config/resource"
+)
+
+var testType = diag.NewMessageType(diag.Error, "TEST0001", "broken %s")
+
+func newResource(name string) *resource.Instance {
+	return &resource.Instance{
+		Metadata: resource.Metadata{
+			FullName: resource.NewFullName("ns", resource.LocalName(name)),
+		},
+		Origin: &rt.Origin{
+			Collection: basicmeta.K8SCollection1.Name(),
+			Kind:       "Kind1",
+			FullName:   resource.NewFullName("ns", resource.LocalName(name)),
+		},
+	}
+}
+
+type fakeTracker map[resource.FullName]Status
+
+func (t fakeTracker) Propagation(r *resource.Instance) (Status, bool) {
+	s, ok := t[r.Metadata.FullName]
+	return s, ok
+}
+
+func TestStatusNotes(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	g.Expect(Status{Acked: 3, Total: 3}.Complete()).To(BeTrue())
+	g.Expect(Status{Acked: 3, Total: 3}.Notes()).To(BeEmpty())
+
+	s := Status{Acked: 1, Total: 3}
+	g.Expect(s.Complete()).To(BeFalse())
+	g.Expect(s.Notes()).To(Equal([]string{"not yet propagated to 2 proxies"}))
+
+	s = Status{Acked: 1, Total: 3, NackedBy: []string{"sidecar~10.0.0.1~a.ns~ns.svc.cluster.local"}}
+	g.Expect(s.Complete()).To(BeFalse())
+	g.Expect(s.Notes()).To(Equal([]string{
+		"NACKed by proxy sidecar~10.0.0.1~a.ns~ns.svc.cluster.local",
+		"not yet propagated to 1 proxy",
+	}))
+}
+
+func TestAnnotate(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tracker := fakeTracker{
+		resource.NewFullName("ns", "pending"):  {Acked: 1, Total: 2},
+		resource.NewFullName("ns", "complete"): {Acked: 2, Total: 2},
+	}
+	msgs := diag.Messages{
+		diag.NewMessage(testType, newResource("pending"), "pending"),
+		diag.NewMessage(testType, newResource("complete"), "complete"),
+		diag.NewMessage(testType, newResource("unknown"), "unknown"),
+		diag.NewMessage(testType, nil, "global"),
+	}
+
+	annotated := Annotate(tracker, msgs)
+	g.Expect(annotated).To(HaveLen(4))
+	g.Expect(annotated[0].Notes).To(Equal([]string{"not yet propagated to 1 proxy"}))
+	g.Expect(annotated[1].Notes).To(BeEmpty())
+	g.Expect(annotated[2].Notes).To(BeEmpty())
+	g.Expect(annotated[3].Notes).To(BeEmpty())
+
+	// The input is left untouched.
+	g.Expect(msgs[0].Notes).To(BeEmpty())
+}
+
+func TestCorrelate(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	rejected := newResource("rejected")
+	tracker := fakeTracker{
+		resource.NewFullName("ns", "rejected"): {Total: 2, NackedBy: []string{"proxy-a", "proxy-b"}},
+		resource.NewFullName("ns", "partial"):  {Acked: 1, Total: 2, NackedBy: []string{"proxy-a"}},
+	}
+	infoType := diag.NewMessageType(diag.Info, "TEST0002", "fyi %s")
+	msgs := diag.Messages{
+		diag.NewMessage(testType, rejected, "ref1"),
+		diag.NewMessage(testType, rejected, "ref2"),
+		diag.NewMessage(infoType, rejected, "info"),
+		diag.NewMessage(testType, newResource("partial"), "partial"),
+		diag.NewMessage(testType, nil, "global"),
+	}
+
+	correlated := Correlate(tracker, msgs)
+	g.Expect(correlated).To(HaveLen(6))
+
+	// A single rejection error is reported, the other messages about the resource are downgraded.
+	var rejections, downgraded int
+	for _, m := range correlated {
+		switch {
+		case m.Type == msg.ResourceRejected:
+			rejections++
+			g.Expect(m.Resource).To(Equal(rejected))
+			g.Expect(m.Parameters).To(Equal([]interface{}{"proxy-a, proxy-b"}))
+		case m.Resource == rejected && m.Type.Code() == testType.Code():
+			downgraded++
+			g.Expect(m.Type.Level()).To(Equal(diag.Info))
+			g.Expect(m.Notes).To(Equal([]string{"downgraded from Error, as the resource was rejected by proxies"}))
+		case m.Resource == rejected:
+			g.Expect(m.Type).To(Equal(infoType))
+			g.Expect(m.Notes).To(BeEmpty())
+		default:
+			g.Expect(m.Type).To(Equal(testType))
+		}
+	}
+	g.Expect(rejections).To(Equal(1))
+	g.Expect(downgraded).To(Equal(2))
+
+	// The messages are sorted, with the rejection first.
+	g.Expect(correlated[0].Type).To(Equal(msg.ResourceRejected))
+
+	// ResourceRejected is not annotated again.
+	g.Expect(Annotate(tracker, correlated)[0].Notes).To(BeEmpty())
+}
@@ -0,0 +1,352 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package pushed models the configuration that the control plane actually pushed to proxies, as read from Envoy
+// config dumps, and maps it back to the Istio resources it was generated from. This allows analyzers to compare the
+// intended configuration with what got programmed, e.g. to find a virtual service that produced no routes.
+package pushed
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
+	"istio.io/istio/pkg/util/strcase"
+)
+
+// Proxy types, as in the node IDs of proxies.
+const (
+	SidecarProxy = "sidecar"
+	RouterProxy  = "router"
+)
+
+// Source identifies the Istio resource that a piece of Envoy configuration was generated from.
+type Source struct {
+	Collection collection.Name
+	Name       resource.FullName
+}
+
+// Route is a route of a virtual host of a route configuration pushed to a proxy.
+type Route struct {
+	RouteConfig string
+	VirtualHost string
+	Name        string
+
+	// Source is the resource the route was generated from, or nil if it was not generated from a resource.
+	Source *Source
+}
+
+// Cluster is a cluster pushed to a proxy.
+type Cluster struct {
+	Name string
+
+	// Source is the resource the cluster was generated from, or nil if it was not generated from a resource.
+	Source *Source
+}
+
+// Secret is a secret that a proxy requested through SDS.
+type Secret struct {
+	Name string
+
+	// Warming is whether the proxy still waits for the secret: it was requested, but never delivered or rejected.
+	Warming bool
+}
+
+// Proxy is the configuration pushed to one proxy.
+type Proxy struct {
+	// ID is the node ID of the proxy, e.g. sidecar~10.1.1.1~reviews-v1-abc.default~default.svc.cluster.local, or the
+	// name its config dump was read under if the dump does not include the bootstrap configuration.
+	ID string
+
+	Routes   []Route
+	Clusters []Cluster
+	Secrets  []Secret
+}
+
+// Type returns the type of the proxy, e.g. SidecarProxy or RouterProxy, or the empty string if it is unknown.
+func (p *Proxy) Type() string {
+	parts := strings.Split(p.ID, "~")
+	if len(parts) != 4 {
+		return ""
+	}
+	return parts[0]
+}
+
+// Namespace returns the namespace of the proxy, or the empty string if it is unknown.
+func (p *Proxy) Namespace() string {
+	_, ns := p.pod()
+	return ns
+}
+
+// PodName returns the name of the pod of the proxy, or the empty string if it is unknown.
+func (p *Proxy) PodName() string {
+	name, _ := p.pod()
+	return name
+}
+
+// pod returns the pod name and namespace from the <pod>.<namespace> part of the node ID of the proxy.
+func (p *Proxy) pod() (string, string) {
+	parts := strings.Split(p.ID, "~")
+	if len(parts) != 4 {
+		return "", ""
+	}
+	i := strings.LastIndex(parts[2], ".")
+	if i < 0 {
+		return "", ""
+	}
+	return parts[2][:i], parts[2][i+1:]
+}
+
+// Config is the configuration pushed to a set of proxies. It is safe for concurrent use. A nil Config has no proxies.
+type Config struct {
+	mu      sync.RWMutex
+	proxies map[string]*Proxy
+	sources map[Source]map[string]bool
+	warming map[string]map[string]bool
+}
+
+// NewConfig returns a new, empty, Config.
+func NewConfig() *Config {
+	return &Config{
+		proxies: make(map[string]*Proxy),
+		sources: make(map[Source]map[string]bool),
+		warming: make(map[string]map[string]bool),
+	}
+}
+
+// Add adds the configuration of a proxy, replacing any previously added configuration of the same proxy.
+func (c *Config) Add(p *Proxy) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.proxies[p.ID]; ok {
+		for _, proxies := range c.sources {
+			delete(proxies, p.ID)
+		}
+		for _, proxies := range c.warming {
+			delete(proxies, p.ID)
+		}
+	}
+	c.proxies[p.ID] = p
+
+	add := func(s *Source) {
+		if s == nil {
+			return
+		}
+		if c.sources[*s] == nil {
+			c.sources[*s] = make(map[string]bool)
+		}
+		c.sources[*s][p.ID] = true
+	}
+	for _, r := range p.Routes {
+		add(r.Source)
+	}
+	for _, cl := range p.Clusters {
+		add(cl.Source)
+	}
+	for _, s := range p.Secrets {
+		if !s.Warming {
+			continue
+		}
+		if c.warming[s.Name] == nil {
+			c.warming[s.Name] = make(map[string]bool)
+		}
+		c.warming[s.Name][p.ID] = true
+	}
+}
+
+// Proxies returns the proxies the configuration was pushed to, sorted by ID.
+func (c *Config) Proxies() []*Proxy {
+	if c == nil {
+		return nil
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	result := make([]*Proxy, 0, len(c.proxies))
+	for _, p := range c.proxies {
+		result = append(result, p)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ID < result[j].ID
+	})
+	return result
+}
+
+// ProxiesOf returns the sorted IDs of the proxies with routes or clusters generated from the given resource.
+func (c *Config) ProxiesOf(col collection.Name, name resource.FullName) []string {
+	if c == nil {
+		return nil
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return sortedIDs(c.sources[Source{Collection: col, Name: name}])
+}
+
+// ProxiesWaitingFor returns the sorted IDs of the proxies that requested the given SDS secret, but did not load it.
+func (c *Config) ProxiesWaitingFor(secret string) []string {
+	if c == nil {
+		return nil
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return sortedIDs(c.warming[secret])
+}
+
+func sortedIDs(proxies map[string]bool) []string {
+	result := make([]string, 0, len(proxies))
+	for id := range proxies {
+		result = append(result, id)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// configDump is the part of an Envoy admin config dump that is read. The config dumps of Envoy and of the istiod
+// debug endpoint list the dumps of bootstrap, clusters, listeners, routes and secrets, which have distinct fields, so
+// they are all decoded into the same struct regardless of their type.
+type configDump struct {
+	Configs []struct {
+		Bootstrap *struct {
+			Node struct {
+				ID string `json:"id"`
+			} `json:"node"`
+		} `json:"bootstrap"`
+
+		StaticRouteConfigs  []routeConfigDump `json:"static_route_configs"`
+		DynamicRouteConfigs []routeConfigDump `json:"dynamic_route_configs"`
+
+		StaticClusters        []clusterDump `json:"static_clusters"`
+		DynamicActiveClusters []clusterDump `json:"dynamic_active_clusters"`
+
+		StaticSecrets         []secretDump `json:"static_secrets"`
+		DynamicActiveSecrets  []secretDump `json:"dynamic_active_secrets"`
+		DynamicWarmingSecrets []secretDump `json:"dynamic_warming_secrets"`
+	} `json:"configs"`
+}
+
+type routeConfigDump struct {
+	RouteConfig struct {
+		Name         string `json:"name"`
+		VirtualHosts []struct {
+			Name   string `json:"name"`
+			Routes []struct {
+				Name     string   `json:"name"`
+				Metadata metadata `json:"metadata"`
+			} `json:"routes"`
+		} `json:"virtual_hosts"`
+	} `json:"route_config"`
+}
+
+type clusterDump struct {
+	Cluster struct {
+		Name     string   `json:"name"`
+		Metadata metadata `json:"metadata"`
+	} `json:"cluster"`
+}
+
+type secretDump struct {
+	Name string `json:"name"`
+}
+
+type metadata struct {
+	FilterMetadata map[string]map[string]interface{} `json:"filter_metadata"`
+}
+
+// ReadConfigDump reads the Envoy config dump of a proxy, as returned by the /config_dump admin endpoint of Envoy or
+// the /debug/config_dump endpoint of istiod. The proxy is identified by the node ID in the bootstrap configuration of
+// the dump, or by the given name if the dump has none.
+func ReadConfigDump(name string, r io.Reader) (*Proxy, error) {
+	var dump configDump
+	if err := json.NewDecoder(r).Decode(&dump); err != nil {
+		return nil, fmt.Errorf("error reading config dump %s: %v", name, err)
+	}
+
+	p := &Proxy{ID: name}
+	for _, cfg := range dump.Configs {
+		if cfg.Bootstrap != nil && cfg.Bootstrap.Node.ID != "" {
+			p.ID = cfg.Bootstrap.Node.ID
+		}
+
+		for _, rc := range append(cfg.StaticRouteConfigs, cfg.DynamicRouteConfigs...) {
+			for _, vh := range rc.RouteConfig.VirtualHosts {
+				for _, route := range vh.Routes {
+					p.Routes = append(p.Routes, Route{
+						RouteConfig: rc.RouteConfig.Name,
+						VirtualHost: vh.Name,
+						Name:        route.Name,
+						Source:      route.Metadata.source(),
+					})
+				}
+			}
+		}
+
+		for _, cl := range append(cfg.StaticClusters, cfg.DynamicActiveClusters...) {
+			p.Clusters = append(p.Clusters, Cluster{
+				Name:   cl.Cluster.Name,
+				Source: cl.Cluster.Metadata.source(),
+			})
+		}
+
+		for _, s := range append(cfg.StaticSecrets, cfg.DynamicActiveSecrets...) {
+			p.Secrets = append(p.Secrets, Secret{Name: s.Name})
+		}
+		for _, s := range cfg.DynamicWarmingSecrets {
+			p.Secrets = append(p.Secrets, Secret{Name: s.Name, Warming: true})
+		}
+	}
+	return p, nil
+}
+
+// source returns the resource named by the config metadata that pilot adds to the Envoy configuration it generates,
+// or nil if there is none.
+func (m metadata) source() *Source {
+	path, _ := m.FilterMetadata["istio"]["config"].(string)
+	if path == "" {
+		return nil
+	}
+	return ParseSource(path)
+}
+
+// ParseSource returns the resource named by a config metadata path as used by pilot for routes and clusters, of the
+// form /apis/<group>/<version>/namespaces/<namespace>/<type>/<name>. Nil is returned if the path is malformed or names
+// a resource of an unknown type.
+func ParseSource(path string) *Source {
+	parts := strings.Split(path, "/")
+	if len(parts) != 8 || parts[0] != "" || parts[1] != "apis" || parts[4] != "namespaces" {
+		return nil
+	}
+	group, ns, typ, name := parts[2], parts[5], parts[6], parts[7]
+
+	var result *Source
+	collections.Pilot.ForEach(func(s collection.Schema) bool {
+		if s.Resource().Group() != group || strcase.CamelCaseToKebabCase(s.Resource().Kind()) != typ {
+			return false
+		}
+		result = &Source{
+			Collection: s.Name(),
+			Name:       resource.NewFullName(resource.Namespace(ns), resource.LocalName(name)),
+		}
+		return true
+	})
+	return result
+}
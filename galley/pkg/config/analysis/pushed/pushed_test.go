@@ -0,0 +1,201 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pushed
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+const sidecarDump = `{
+  "configs": [
+    {
+      "@type": "type.googleapis.com/envoy.admin.v3.BootstrapConfigDump",
+      "bootstrap": {"node": {"id": "sidecar~10.1.1.1~productpage-v1-abc.default~default.svc.cluster.local"}}
+    },
+    {
+      "@type": "type.googleapis.com/envoy.admin.v3.ClustersConfigDump",
+      "dynamic_active_clusters": [
+        {"cluster": {
+          "name": "outbound|9080|v1|reviews.default.svc.cluster.local",
+          "metadata": {"filter_metadata": {"istio": {
+            "config": "/apis/networking.istio.io/v1alpha3/namespaces/default/destination-rule/reviews"}}}
+        }},
+        {"cluster": {"name": "outbound|9080||ratings.default.svc.cluster.local"}}
+      ]
+    },
+    {
+      "@type": "type.googleapis.com/envoy.admin.v3.RouteConfigurationDump",
+      "dynamic_route_configs": [
+        {"route_config": {
+          "name": "9080",
+          "virtual_hosts": [
+            {"name": "reviews.default.svc.cluster.local:9080", "routes": [{
+              "name": "default",
+              "metadata": {"filter_metadata": {"istio": {
+                "config": "/apis/networking.istio.io/v1alpha3/namespaces/default/virtual-service/reviews"}}}
+            }]},
+            {"name": "ratings.default.svc.cluster.local:9080", "routes": [{"name": "default"}]}
+          ]
+        }}
+      ]
+    }
+  ]
+}`
+
+func TestReadConfigDump(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	p, err := ReadConfigDump("productpage.json", strings.NewReader(sidecarDump))
+	g.Expect(err).To(BeNil())
+
+	g.Expect(p.ID).To(Equal("sidecar~10.1.1.1~productpage-v1-abc.default~default.svc.cluster.local"))
+	g.Expect(p.Type()).To(Equal(SidecarProxy))
+	g.Expect(p.Namespace()).To(Equal("default"))
+
+	g.Expect(p.Routes).To(HaveLen(2))
+	g.Expect(p.Routes[0].RouteConfig).To(Equal("9080"))
+	g.Expect(p.Routes[0].VirtualHost).To(Equal("reviews.default.svc.cluster.local:9080"))
+	g.Expect(p.Routes[0].Source).To(Equal(&Source{
+		Collection: collections.IstioNetworkingV1Alpha3Virtualservices.Name(),
+		Name:       resource.NewFullName("default", "reviews"),
+	}))
+	g.Expect(p.Routes[1].Source).To(BeNil())
+
+	g.Expect(p.Clusters).To(HaveLen(2))
+	g.Expect(p.Clusters[0].Source).To(Equal(&Source{
+		Collection: collections.IstioNetworkingV1Alpha3Destinationrules.Name(),
+		Name:       resource.NewFullName("default", "reviews"),
+	}))
+	g.Expect(p.Clusters[1].Source).To(BeNil())
+	g.Expect(p.Secrets).To(BeEmpty())
+}
+
+const gatewayDump = `{
+  "configs": [
+    {
+      "@type": "type.googleapis.com/envoy.admin.v3.BootstrapConfigDump",
+      "bootstrap": {"node": {"id": "router~10.1.1.2~istio-ingressgateway-abc.istio-system~istio-system.svc.cluster.local"}}
+    },
+    {
+      "@type": "type.googleapis.com/envoy.admin.v3.SecretsConfigDump",
+      "dynamic_active_secrets": [{"name": "bookinfo-credential", "version_info": "2020-04-01T10:00:00Z"}],
+      "dynamic_warming_secrets": [{"name": "httpbin-credential"}]
+    }
+  ]
+}`
+
+func TestReadConfigDumpSecrets(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	p, err := ReadConfigDump("istio-ingressgateway.json", strings.NewReader(gatewayDump))
+	g.Expect(err).To(BeNil())
+
+	g.Expect(p.Type()).To(Equal(RouterProxy))
+	g.Expect(p.PodName()).To(Equal("istio-ingressgateway-abc"))
+	g.Expect(p.Secrets).To(Equal([]Secret{
+		{Name: "bookinfo-credential"},
+		{Name: "httpbin-credential", Warming: true},
+	}))
+}
+
+func TestReadConfigDumpWithoutBootstrap(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	p, err := ReadConfigDump("istio-ingressgateway", strings.NewReader(`{"configs": []}`))
+	g.Expect(err).To(BeNil())
+	g.Expect(p.ID).To(Equal("istio-ingressgateway"))
+	g.Expect(p.Type()).To(Equal(""))
+	g.Expect(p.Namespace()).To(Equal(""))
+	g.Expect(p.PodName()).To(Equal(""))
+}
+
+func TestReadConfigDumpError(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	_, err := ReadConfigDump("broken.json", strings.NewReader(`{"configs": [`))
+	g.Expect(err).NotTo(BeNil())
+	g.Expect(err.Error()).To(ContainSubstring("broken.json"))
+}
+
+func TestParseSource(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	g.Expect(ParseSource("/apis/networking.istio.io/v1alpha3/namespaces/istio-system/gateway/ingress")).To(Equal(&Source{
+		Collection: collections.IstioNetworkingV1Alpha3Gateways.Name(),
+		Name:       resource.NewFullName("istio-system", "ingress"),
+	}))
+	g.Expect(ParseSource("/apis/networking.istio.io/v1alpha3/namespaces/default/unknown-type/foo")).To(BeNil())
+	g.Expect(ParseSource("/apis/networking.istio.io/v1alpha3/default/virtual-service/foo")).To(BeNil())
+	g.Expect(ParseSource("")).To(BeNil())
+}
+
+func TestConfigProxiesOf(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	vs := Source{
+		Collection: collections.IstioNetworkingV1Alpha3Virtualservices.Name(),
+		Name:       resource.NewFullName("default", "reviews"),
+	}
+
+	c := NewConfig()
+	c.Add(&Proxy{ID: "b", Routes: []Route{{Name: "default", Source: &vs}}})
+	c.Add(&Proxy{ID: "a", Routes: []Route{{Name: "default", Source: &vs}}})
+	c.Add(&Proxy{ID: "c", Routes: []Route{{Name: "default"}}})
+
+	g.Expect(c.ProxiesOf(vs.Collection, vs.Name)).To(Equal([]string{"a", "b"}))
+	g.Expect(c.ProxiesOf(vs.Collection, resource.NewFullName("default", "ratings"))).To(BeEmpty())
+
+	var ids []string
+	for _, p := range c.Proxies() {
+		ids = append(ids, p.ID)
+	}
+	g.Expect(ids).To(Equal([]string{"a", "b", "c"}))
+
+	// Adding a proxy again replaces its configuration.
+	c.Add(&Proxy{ID: "b"})
+	g.Expect(c.ProxiesOf(vs.Collection, vs.Name)).To(Equal([]string{"a"}))
+}
+
+func TestConfigProxiesWaitingFor(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	c := NewConfig()
+	c.Add(&Proxy{ID: "b", Secrets: []Secret{{Name: "httpbin-credential", Warming: true}}})
+	c.Add(&Proxy{ID: "a", Secrets: []Secret{{Name: "httpbin-credential", Warming: true}}})
+	c.Add(&Proxy{ID: "c", Secrets: []Secret{{Name: "httpbin-credential"}}})
+
+	g.Expect(c.ProxiesWaitingFor("httpbin-credential")).To(Equal([]string{"a", "b"}))
+	g.Expect(c.ProxiesWaitingFor("bookinfo-credential")).To(BeEmpty())
+
+	// Adding a proxy again replaces its configuration.
+	c.Add(&Proxy{ID: "b", Secrets: []Secret{{Name: "httpbin-credential"}}})
+	g.Expect(c.ProxiesWaitingFor("httpbin-credential")).To(Equal([]string{"a"}))
+}
+
+func TestNilConfig(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	var c *Config
+	g.Expect(c.Proxies()).To(BeEmpty())
+	g.Expect(c.ProxiesOf(collections.IstioNetworkingV1Alpha3Virtualservices.Name(),
+		resource.NewFullName("default", "reviews"))).To(BeEmpty())
+	g.Expect(c.ProxiesWaitingFor("httpbin-credential")).To(BeEmpty())
+}
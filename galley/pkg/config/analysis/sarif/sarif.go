@@ -0,0 +1,206 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sarif converts analysis findings to the Static Analysis Results Interchange Format (SARIF), so that they
+// can be consumed by code scanning tools, e.g. GitHub code scanning.
+package sarif
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+	"istio.io/istio/galley/pkg/config/source/kube/rt"
+)
+
+const (
+	// Version is the version of the SARIF format that is produced.
+	Version = "2.1.0"
+	// Schema is the JSON schema of the SARIF format that is produced.
+	Schema = "https://schemastore.azurewebsites.net/schemas/json/sarif-2.1.0-rtm.5.json"
+
+	informationURI = "https://istio.io"
+)
+
+// The subset of the SARIF object model that is needed for analysis findings.
+type (
+	log struct {
+		Version string `json:"version"`
+		Schema  string `json:"$schema"`
+		Runs    []run  `json:"runs"`
+	}
+
+	run struct {
+		Tool    tool     `json:"tool"`
+		Results []result `json:"results"`
+	}
+
+	tool struct {
+		Driver driver `json:"driver"`
+	}
+
+	driver struct {
+		Name           string `json:"name"`
+		Version        string `json:"version,omitempty"`
+		InformationURI string `json:"informationUri"`
+		Rules          []rule `json:"rules"`
+	}
+
+	rule struct {
+		ID                   string        `json:"id"`
+		HelpURI              string        `json:"helpUri"`
+		DefaultConfiguration configuration `json:"defaultConfiguration"`
+	}
+
+	configuration struct {
+		Level string `json:"level"`
+	}
+
+	result struct {
+		RuleID    string     `json:"ruleId"`
+		RuleIndex int        `json:"ruleIndex"`
+		Level     string     `json:"level"`
+		Message   message    `json:"message"`
+		Locations []location `json:"locations,omitempty"`
+	}
+
+	message struct {
+		Text string `json:"text"`
+	}
+
+	location struct {
+		PhysicalLocation *physicalLocation `json:"physicalLocation,omitempty"`
+		LogicalLocations []logicalLocation `json:"logicalLocations,omitempty"`
+	}
+
+	physicalLocation struct {
+		ArtifactLocation artifactLocation `json:"artifactLocation"`
+		Region           *region          `json:"region,omitempty"`
+	}
+
+	artifactLocation struct {
+		URI string `json:"uri"`
+	}
+
+	region struct {
+		StartLine int `json:"startLine"`
+	}
+
+	logicalLocation struct {
+		FullyQualifiedName string `json:"fullyQualifiedName"`
+		Kind               string `json:"kind"`
+	}
+)
+
+// Marshal returns the messages as a SARIF log of a single run of the named tool. Each message code is a rule of the
+// run. Messages on resources read from files are located by file and line, messages on other resources by their
+// friendly name. Messages with a field path are also located at the field. Messages that aggregate others are located at the resources of all of them.
+func Marshal(msgs diag.Messages, toolName, toolVersion string) ([]byte, error) {
+	levels := make(map[string]diag.Level)
+	for _, m := range msgs {
+		levels[m.Type.Code()] = m.Type.Level()
+	}
+	codes := make([]string, 0, len(levels))
+	for code := range levels {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	rules := make([]rule, 0, len(codes))
+	ruleIndex := make(map[string]int, len(codes))
+	for i, code := range codes {
+		rules = append(rules, rule{
+			ID:                   code,
+			HelpURI:              fmt.Sprintf("%s/%s", diag.DocPrefix, code),
+			DefaultConfiguration: configuration{Level: level(levels[code])},
+		})
+		ruleIndex[code] = i
+	}
+
+	results := make([]result, 0, len(msgs))
+	for _, m := range msgs {
+		results = append(results, result{
+			RuleID:    m.Type.Code(),
+			RuleIndex: ruleIndex[m.Type.Code()],
+			Level:     level(m.Type.Level()),
+			Message:   message{Text: fmt.Sprintf(m.Type.Template(), m.Parameters...)},
+			Locations: locations(m),
+		})
+	}
+
+	return json.MarshalIndent(log{
+		Version: Version,
+		Schema:  Schema,
+		Runs: []run{{
+			Tool: tool{Driver: driver{
+				Name:           toolName,
+				Version:        toolVersion,
+				InformationURI: informationURI,
+				Rules:          rules,
+			}},
+			Results: results,
+		}},
+	}, "", "  ")
+}
+
+func level(l diag.Level) string {
+	switch l {
+	case diag.Error:
+		return "error"
+	case diag.Warning:
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+func locations(m diag.Message) []location {
+	var locs []location
+	for _, a := range append(diag.Messages{m}, m.Aggregated...) {
+		if loc, ok := resourceLocation(a); ok {
+			locs = append(locs, loc)
+		}
+	}
+	return locs
+}
+
+func resourceLocation(m diag.Message) (location, bool) {
+	if m.Resource == nil || m.Resource.Origin == nil {
+		return location{}, false
+	}
+
+	loc := location{
+		LogicalLocations: []logicalLocation{{
+			FullyQualifiedName: m.Resource.Origin.FriendlyName(),
+			Kind:               "resource",
+		}},
+	}
+	if m.FieldPath != "" {
+		loc.LogicalLocations = append(loc.LogicalLocations, logicalLocation{
+			FullyQualifiedName: m.Resource.Origin.FriendlyName() + "/" + m.FieldPath,
+			Kind:               "property",
+		})
+	}
+	if p, ok := m.Resource.Origin.Reference().(*rt.Position); ok && p.Filename != "" {
+		loc.PhysicalLocation = &physicalLocation{
+			ArtifactLocation: artifactLocation{URI: filepath.ToSlash(p.Filename)},
+		}
+		if p.Line > 0 {
+			loc.PhysicalLocation.Region = &region{StartLine: p.Line}
+		}
+	}
+	return loc, true
+}
@@ -0,0 +1,151 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sarif
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+	"istio.io/istio/galley/pkg/config/source/kube/rt"
+	"istio.io/istio/pkg/config/resource"
+)
+
+func TestMarshal(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	errorType := diag.NewMessageType(diag.Error, "TEST0001", "broken %s")
+	infoType := diag.NewMessageType(diag.Info, "TEST0002", "note %s")
+	r := &resource.Instance{
+		Origin: &rt.Origin{
+			Kind:     "VirtualService",
+			FullName: resource.NewFullName("ns", "a"),
+			Ref:      &rt.Position{Filename: "config/a.yaml", Line: 12},
+		},
+	}
+	msgs := diag.Messages{
+		diag.NewMessage(infoType, nil, "b"),
+		diag.NewMessage(errorType, r, "a"),
+	}
+
+	b, err := Marshal(msgs, "istioctl", "1.6.0")
+	g.Expect(err).To(BeNil())
+
+	var l log
+	g.Expect(json.Unmarshal(b, &l)).To(Succeed())
+	g.Expect(l.Version).To(Equal(Version))
+	g.Expect(l.Schema).To(Equal(Schema))
+	g.Expect(l.Runs).To(HaveLen(1))
+
+	run := l.Runs[0]
+	g.Expect(run.Tool.Driver.Name).To(Equal("istioctl"))
+	g.Expect(run.Tool.Driver.Version).To(Equal("1.6.0"))
+	g.Expect(run.Tool.Driver.Rules).To(Equal([]rule{
+		{
+			ID:                   "TEST0001",
+			HelpURI:              "https://istio.io/docs/reference/config/analysis/TEST0001",
+			DefaultConfiguration: configuration{Level: "error"},
+		},
+		{
+			ID:                   "TEST0002",
+			HelpURI:              "https://istio.io/docs/reference/config/analysis/TEST0002",
+			DefaultConfiguration: configuration{Level: "note"},
+		},
+	}))
+
+	g.Expect(run.Results).To(Equal([]result{
+		{
+			RuleID:    "TEST0002",
+			RuleIndex: 1,
+			Level:     "note",
+			Message:   message{Text: "note b"},
+		},
+		{
+			RuleID:    "TEST0001",
+			RuleIndex: 0,
+			Level:     "error",
+			Message:   message{Text: "broken a"},
+			Locations: []location{{
+				PhysicalLocation: &physicalLocation{
+					ArtifactLocation: artifactLocation{URI: "config/a.yaml"},
+					Region:           &region{StartLine: 12},
+				},
+				LogicalLocations: []logicalLocation{{FullyQualifiedName: "VirtualService a.ns", Kind: "resource"}},
+			}},
+		},
+	}))
+}
+
+func TestMarshalAggregated(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	errorType := diag.NewMessageType(diag.Error, "TEST0001", "broken %s")
+	resourceNamed := func(name string) *resource.Instance {
+		return &resource.Instance{
+			Origin: &rt.Origin{Kind: "VirtualService", FullName: resource.NewFullName("ns", resource.LocalName(name))},
+		}
+	}
+	m := diag.NewMessage(errorType, resourceNamed("a"), "a")
+	m.Aggregated = diag.Messages{diag.NewMessage(errorType, resourceNamed("b"), "b")}
+
+	b, err := Marshal(diag.Messages{m}, "istioctl", "")
+	g.Expect(err).To(BeNil())
+
+	var l log
+	g.Expect(json.Unmarshal(b, &l)).To(Succeed())
+	g.Expect(l.Runs[0].Results).To(HaveLen(1))
+	g.Expect(l.Runs[0].Results[0].Locations).To(Equal([]location{
+		{LogicalLocations: []logicalLocation{{FullyQualifiedName: "VirtualService a.ns", Kind: "resource"}}},
+		{LogicalLocations: []logicalLocation{{FullyQualifiedName: "VirtualService b.ns", Kind: "resource"}}},
+	}))
+}
+
+func TestMarshalFieldPath(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	errorType := diag.NewMessageType(diag.Error, "TEST0001", "broken %s")
+	r := &resource.Instance{
+		Origin: &rt.Origin{Kind: "Gateway", FullName: resource.NewFullName("ns", "a")},
+	}
+	m := diag.NewMessage(errorType, r, "a").WithFieldPath("spec.servers[2].tls.credentialName")
+
+	b, err := Marshal(diag.Messages{m}, "istioctl", "")
+	g.Expect(err).To(BeNil())
+
+	var l log
+	g.Expect(json.Unmarshal(b, &l)).To(Succeed())
+	g.Expect(l.Runs[0].Results[0].Locations).To(Equal([]location{
+		{LogicalLocations: []logicalLocation{
+			{FullyQualifiedName: "Gateway a.ns", Kind: "resource"},
+			{FullyQualifiedName: "Gateway a.ns/spec.servers[2].tls.credentialName", Kind: "property"},
+		}},
+	}))
+}
+
+func TestMarshalEmpty(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	b, err := Marshal(nil, "istioctl", "")
+	g.Expect(err).To(BeNil())
+
+	// Code scanning tools expect empty lists rather than nulls
+	var l map[string]interface{}
+	g.Expect(json.Unmarshal(b, &l)).To(Succeed())
+	run := l["runs"].([]interface{})[0].(map[string]interface{})
+	g.Expect(run["results"]).To(Equal([]interface{}{}))
+	g.Expect(run["tool"].(map[string]interface{})["driver"]).To(HaveKeyWithValue("rules", []interface{}{}))
+}
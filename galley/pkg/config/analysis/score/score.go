@@ -0,0 +1,156 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package score computes configuration health scores from analysis findings, so that the configuration hygiene of a
+// namespace or the whole mesh can be tracked as a single number over time.
+package score
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+)
+
+// Max is the score of configuration without findings.
+const Max = 100.0
+
+// Weights are the penalties of findings. The weight of a message code takes precedence over the weight of its level.
+type Weights struct {
+	Levels map[diag.Level]float64
+	Codes  map[string]float64
+}
+
+// DefaultWeights are the weights used if no others are configured.
+var DefaultWeights = Weights{
+	Levels: map[diag.Level]float64{
+		diag.Error:   10,
+		diag.Warning: 3,
+		diag.Info:    1,
+	},
+}
+
+// ParseWeights parses weights of the form <level>=<weight> or <code>=<weight>, e.g. "Error=20" or "IST0101=5". Levels
+// that are not given keep their default weight.
+func ParseWeights(specs []string) (Weights, error) {
+	w := Weights{
+		Levels: make(map[diag.Level]float64, len(DefaultWeights.Levels)),
+		Codes:  make(map[string]float64),
+	}
+	for l, v := range DefaultWeights.Levels {
+		w.Levels[l] = v
+	}
+
+	for _, spec := range specs {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+		parts := strings.SplitN(spec, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return Weights{}, fmt.Errorf("invalid score weight %q: expected <level or code>=<weight>", spec)
+		}
+		v, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil || v < 0 {
+			return Weights{}, fmt.Errorf("invalid score weight %q: the weight must be a non-negative number", spec)
+		}
+		if l, ok := diag.ParseLevel(parts[0]); ok {
+			w.Levels[l] = v
+		} else {
+			w.Codes[parts[0]] = v
+		}
+	}
+	return w, nil
+}
+
+// Weight returns the penalty of the given message.
+func (w Weights) Weight(m diag.Message) float64 {
+	if v, ok := w.Codes[m.Type.Code()]; ok {
+		return v
+	}
+	if v, ok := w.Levels[m.Type.Level()]; ok {
+		return v
+	}
+	return DefaultWeights.Levels[m.Type.Level()]
+}
+
+// Score is the health score of the configuration of a namespace, or of the whole mesh.
+type Score struct {
+	// Namespace is empty for the mesh-wide score.
+	Namespace string `json:"namespace,omitempty"`
+
+	// Value is the score, from Max without findings down towards 0. A penalty of Max halves the score.
+	Value float64 `json:"score"`
+
+	// Penalty is the sum of the weights of the findings.
+	Penalty float64 `json:"penalty"`
+
+	// Findings is the number of findings, by level.
+	Findings map[string]int `json:"findings"`
+}
+
+func (s *Score) add(m diag.Message, weight float64) {
+	s.Penalty += weight
+	s.Findings[m.Type.Level().String()]++
+	s.Value = Max * Max / (Max + s.Penalty)
+}
+
+// Summary holds the health scores of a set of findings.
+type Summary struct {
+	// Mesh is the mesh-wide score, including findings on cluster-scoped resources.
+	Mesh Score `json:"mesh"`
+
+	// Namespaces holds the scores of the namespaces with findings, sorted by namespace. Namespaces without findings
+	// have a score of Max.
+	Namespaces []Score `json:"namespaces"`
+}
+
+// Compute returns the health scores of the given findings.
+func Compute(msgs diag.Messages, w Weights) Summary {
+	mesh := newScore("")
+	namespaces := make(map[string]*Score)
+	for _, m := range msgs {
+		weight := w.Weight(m)
+		mesh.add(m, weight)
+
+		if m.Resource == nil {
+			continue
+		}
+		ns := m.Resource.Metadata.FullName.Namespace.String()
+		if ns == "" {
+			continue
+		}
+		s, ok := namespaces[ns]
+		if !ok {
+			s = newScore(ns)
+			namespaces[ns] = s
+		}
+		s.add(m, weight)
+	}
+
+	result := Summary{Mesh: *mesh, Namespaces: make([]Score, 0, len(namespaces))}
+	for _, s := range namespaces {
+		result.Namespaces = append(result.Namespaces, *s)
+	}
+	sort.Slice(result.Namespaces, func(i, j int) bool {
+		return result.Namespaces[i].Namespace < result.Namespaces[j].Namespace
+	})
+	return result
+}
+
+func newScore(ns string) *Score {
+	return &Score{Namespace: ns, Value: Max, Findings: make(map[string]int)}
+}
@@ -0,0 +1,93 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package score
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+	"istio.io/istio/pkg/config/resource"
+)
+
+var (
+	errorType   = diag.NewMessageType(diag.Error, "TEST0001", "broken %s")
+	warningType = diag.NewMessageType(diag.Warning, "TEST0002", "odd %s")
+	infoType    = diag.NewMessageType(diag.Info, "TEST0003", "note %s")
+)
+
+func newMessage(mt *diag.MessageType, ns string) diag.Message {
+	r := &resource.Instance{
+		Metadata: resource.Metadata{FullName: resource.NewFullName(resource.Namespace(ns), "a")},
+	}
+	return diag.NewMessage(mt, r, "a")
+}
+
+func TestParseWeights(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	w, err := ParseWeights([]string{"error=20", " IST0101=0.5 ", "", "Warning=4"})
+	g.Expect(err).To(BeNil())
+	g.Expect(w.Levels).To(Equal(map[diag.Level]float64{diag.Error: 20, diag.Warning: 4, diag.Info: 1}))
+	g.Expect(w.Codes).To(Equal(map[string]float64{"IST0101": 0.5}))
+
+	for _, spec := range []string{"Error", "=5", "Error=high", "IST0101=-1"} {
+		_, err := ParseWeights([]string{spec})
+		g.Expect(err).To(MatchError(ContainSubstring("invalid score weight")), spec)
+	}
+
+	// The defaults are left alone
+	g.Expect(DefaultWeights.Levels[diag.Error]).To(Equal(10.0))
+}
+
+func TestWeight(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	w := Weights{Codes: map[string]float64{"TEST0002": 7}}
+	g.Expect(w.Weight(newMessage(errorType, "ns"))).To(Equal(10.0))
+	g.Expect(w.Weight(newMessage(warningType, "ns"))).To(Equal(7.0))
+	g.Expect(w.Weight(newMessage(infoType, "ns"))).To(Equal(1.0))
+}
+
+func TestCompute(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	s := Compute(diag.Messages{
+		newMessage(errorType, "b"),
+		newMessage(warningType, "a"),
+		newMessage(errorType, "b"),
+		newMessage(infoType, ""),
+		diag.NewMessage(infoType, nil, "x"),
+	}, DefaultWeights)
+
+	g.Expect(s.Mesh).To(Equal(Score{
+		Value:    Max * Max / (Max + 25),
+		Penalty:  25,
+		Findings: map[string]int{"Error": 2, "Warn": 1, "Info": 2},
+	}))
+	g.Expect(s.Namespaces).To(Equal([]Score{
+		{Namespace: "a", Value: Max * Max / (Max + 3), Penalty: 3, Findings: map[string]int{"Warn": 1}},
+		{Namespace: "b", Value: Max * Max / (Max + 20), Penalty: 20, Findings: map[string]int{"Error": 2}},
+	}))
+
+	// A penalty of Max halves the score
+	g.Expect(Compute(diag.Messages{newMessage(errorType, "a")}, Weights{Codes: map[string]float64{"TEST0001": Max}}).
+		Mesh.Value).To(Equal(Max / 2))
+
+	empty := Compute(nil, DefaultWeights)
+	g.Expect(empty.Mesh.Value).To(Equal(Max))
+	g.Expect(empty.Namespaces).To(BeEmpty())
+}
@@ -0,0 +1,196 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+	"istio.io/istio/galley/pkg/config/analysis/local"
+	"istio.io/istio/galley/pkg/config/processing/snapshotter"
+)
+
+// Results provides both on-demand and cached analysis results.
+type Results interface {
+	Engine
+
+	// LastMessages returns the messages of the last completed continuous analysis run and the time it completed.
+	LastMessages() (diag.Messages, time.Time)
+}
+
+// HTTPResponse is the JSON body served by the HTTP handler.
+type HTTPResponse struct {
+	// Cached is true if the messages are the results of the last continuous analysis run, rather than of an on-demand
+	// run.
+	Cached bool `json:"cached"`
+
+	// AnalyzedAt is the time the analysis completed.
+	AnalyzedAt time.Time `json:"analyzedAt"`
+
+	Messages diag.Messages `json:"messages"`
+}
+
+type httpHandler struct {
+	results Results
+}
+
+// NewHTTPHandler returns an http.Handler that serves analysis results as JSON. The results of the last continuous
+// analysis run are served if available, unless the "refresh" query parameter is set to true or the "analyzer" query
+// parameter selects analyzers to run, in which case analysis is run on demand. The "namespace" and "code" query
+// parameters restrict the results to the given namespace and comma-separated message codes.
+func NewHTTPHandler(results Results) http.Handler {
+	return &httpHandler{results: results}
+}
+
+// ServeHTTP implements http.Handler
+func (h *httpHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	q := req.URL.Query()
+	analyzers := splitList(q["analyzer"])
+
+	var resp HTTPResponse
+	if q.Get("refresh") != "true" && len(analyzers) == 0 {
+		resp.Messages, resp.AnalyzedAt = h.results.LastMessages()
+		resp.Cached = !resp.AnalyzedAt.IsZero()
+	}
+
+	if !resp.Cached {
+		msgs, err := h.results.AnalyzeNow(snapshotter.AnalysisRequest{
+			Analyzers: analyzers,
+			Context:   req.Context(),
+		})
+		if err != nil {
+			code := http.StatusBadRequest
+			if err == snapshotter.ErrNoSnapshot {
+				code = http.StatusServiceUnavailable
+			}
+			http.Error(w, err.Error(), code)
+			return
+		}
+		resp.Messages = msgs
+		resp.AnalyzedAt = time.Now()
+	}
+
+	resp.Messages = filter(resp.Messages, q.Get("namespace"), splitList(q["code"]))
+	if resp.Messages == nil {
+		resp.Messages = diag.Messages{}
+	}
+
+	writeJSON(w, resp)
+}
+
+func writeJSON(w http.ResponseWriter, resp HTTPResponse) {
+	b, err := json.MarshalIndent(resp, "", "  ")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(b)
+}
+
+// MaxResourcesSize is the largest request body accepted by the handler returned by NewResourcesHTTPHandler.
+const MaxResourcesSize = 10 << 20
+
+type resourcesHTTPHandler struct {
+	analyzer *ResourceAnalyzer
+}
+
+// NewResourcesHTTPHandler returns an http.Handler that analyzes the Kubernetes resources POSTed to it in YAML or JSON
+// format, and serves the results as JSON. The "analyzer" query parameter selects the analyzers to run, and the
+// "namespace" query parameter sets the namespace of resources that do not specify one, and restricts the results to
+// it. The "code" query parameter restricts the results to the given comma-separated message codes.
+func NewResourcesHTTPHandler(analyzer *ResourceAnalyzer) http.Handler {
+	return &resourcesHTTPHandler{analyzer: analyzer}
+}
+
+// ServeHTTP implements http.Handler
+func (h *resourcesHTTPHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, fmt.Sprintf("method %s is not allowed, POST the resources to analyze", req.Method),
+			http.StatusMethodNotAllowed)
+		return
+	}
+
+	q := req.URL.Query()
+	files := []local.ReaderSource{{
+		Name:   "request",
+		Reader: http.MaxBytesReader(w, req.Body, MaxResourcesSize),
+	}}
+	result, err := h.analyzer.Analyze(req.Context(), files, splitList(q["analyzer"]), q.Get("namespace"))
+	if err != nil {
+		code := http.StatusInternalServerError
+		if _, ok := err.(invalidRequestError); ok {
+			code = http.StatusBadRequest
+		}
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	resp := HTTPResponse{
+		AnalyzedAt: time.Now(),
+		Messages:   filter(result.Messages.SortedDedupedCopy(), "", splitList(q["code"])),
+	}
+	if resp.Messages == nil {
+		resp.Messages = diag.Messages{}
+	}
+	writeJSON(w, resp)
+}
+
+// filter returns the messages about resources in the given namespace with one of the given codes. An empty namespace
+// or code list matches all messages.
+func filter(msgs diag.Messages, namespace string, codes []string) diag.Messages {
+	if namespace == "" && len(codes) == 0 {
+		return msgs
+	}
+
+	var result diag.Messages
+	for _, m := range msgs {
+		if namespace != "" && (m.Resource == nil || m.Resource.Origin.Namespace().String() != namespace) {
+			continue
+		}
+		if len(codes) > 0 && !contains(codes, m.Type.Code()) {
+			continue
+		}
+		result = append(result, m)
+	}
+	return result
+}
+
+// splitList flattens repeated and comma-separated query parameter values.
+func splitList(values []string) []string {
+	var result []string
+	for _, v := range values {
+		for _, s := range strings.Split(v, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				result = append(result, s)
+			}
+		}
+	}
+	return result
+}
+
+func contains(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,120 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/galley/pkg/config/processing/snapshotter"
+)
+
+type jsonResponse struct {
+	Cached   bool                     `json:"cached"`
+	Messages []map[string]interface{} `json:"messages"`
+}
+
+func serve(g *GomegaWithT, r Results, url string) (int, jsonResponse) {
+	w := httptest.NewRecorder()
+	NewHTTPHandler(r).ServeHTTP(w, httptest.NewRequest("GET", url, nil))
+
+	var resp jsonResponse
+	if w.Code == http.StatusOK {
+		g.Expect(w.Header().Get("Content-Type")).To(Equal("application/json"))
+		g.Expect(json.Unmarshal(w.Body.Bytes(), &resp)).To(Succeed())
+	}
+	return w.Code, resp
+}
+
+func TestHTTPHandler(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	cached := diag.Messages{
+		msg.NewInternalError(newResource("ns1", "r1"), "one"),
+		msg.NewReferencedResourceNotFound(newResource("ns2", "r2"), "gateway", "foo"),
+		msg.NewInternalError(newResource("ns2", "r3"), "three"),
+	}
+	fresh := diag.Messages{
+		msg.NewInternalError(newResource("ns1", "r4"), "four"),
+	}
+	e := &fakeEngine{
+		order:    []string{"a1"},
+		results:  map[string]diag.Messages{"a1": fresh},
+		last:     cached,
+		lastTime: time.Now(),
+	}
+
+	code, resp := serve(g, e, "/debug/analysis")
+	g.Expect(code).To(Equal(http.StatusOK))
+	g.Expect(resp.Cached).To(BeTrue())
+	g.Expect(resp.Messages).To(HaveLen(3))
+
+	_, resp = serve(g, e, "/debug/analysis?namespace=ns2")
+	g.Expect(resp.Messages).To(HaveLen(2))
+
+	_, resp = serve(g, e, "/debug/analysis?namespace=ns2&code="+msg.InternalError.Code())
+	g.Expect(resp.Messages).To(HaveLen(1))
+	g.Expect(resp.Messages[0]["origin"]).To(Equal("Kind1 r3.ns2"))
+
+	_, resp = serve(g, e, "/debug/analysis?code="+msg.InternalError.Code()+","+msg.ReferencedResourceNotFound.Code())
+	g.Expect(resp.Messages).To(HaveLen(3))
+
+	_, resp = serve(g, e, "/debug/analysis?namespace=none")
+	g.Expect(resp.Messages).To(BeEmpty())
+
+	_, resp = serve(g, e, "/debug/analysis?refresh=true")
+	g.Expect(resp.Cached).To(BeFalse())
+	g.Expect(resp.Messages).To(HaveLen(1))
+	g.Expect(resp.Messages[0]["origin"]).To(Equal("Kind1 r4.ns1"))
+
+	_, resp = serve(g, e, "/debug/analysis?analyzer=a1")
+	g.Expect(resp.Cached).To(BeFalse())
+	g.Expect(e.req.Analyzers).To(Equal([]string{"a1"}))
+}
+
+func TestHTTPHandlerAnalyzesWhenNothingCached(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	e := &fakeEngine{
+		order:   []string{"a1"},
+		results: map[string]diag.Messages{"a1": {msg.NewInternalError(newResource("ns1", "r1"), "one")}},
+	}
+
+	code, resp := serve(g, e, "/debug/analysis")
+	g.Expect(code).To(Equal(http.StatusOK))
+	g.Expect(resp.Cached).To(BeFalse())
+	g.Expect(resp.Messages).To(HaveLen(1))
+}
+
+func TestHTTPHandlerErrors(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	code, _ := serve(g, &fakeEngine{err: snapshotter.ErrNoSnapshot}, "/debug/analysis")
+	g.Expect(code).To(Equal(http.StatusServiceUnavailable))
+
+	code, _ = serve(g, &fakeEngine{err: snapshotter.ErrNoSnapshot}, "/debug/analysis?analyzer=foo")
+	g.Expect(code).To(Equal(http.StatusServiceUnavailable))
+
+	code, _ = serve(g, &fakeEngine{err: errors.New("unknown analyzers: foo")}, "/debug/analysis?analyzer=foo")
+	g.Expect(code).To(Equal(http.StatusBadRequest))
+}
@@ -0,0 +1,86 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"time"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/local"
+	"istio.io/istio/pkg/config/constants"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema"
+)
+
+// DefaultResourceTimeout is the default time allowed for the analysis of submitted resources.
+const DefaultResourceTimeout = 30 * time.Second
+
+// ResourceAnalyzer analyzes submitted resources on their own, like istioctl analyze analyzes files: the resources
+// that an Istio installation provides, like the ingress gateway, are assumed to exist, and nothing else.
+type ResourceAnalyzer struct {
+	analyzer       *analysis.CombinedAnalyzer
+	istioNamespace resource.Namespace
+	timeout        time.Duration
+}
+
+// invalidRequestError is returned for requests naming unknown analyzers, or submitting resources that cannot be parsed.
+type invalidRequestError struct {
+	err error
+}
+
+func (e invalidRequestError) Error() string {
+	return e.err.Error()
+}
+
+// NewResourceAnalyzer returns a ResourceAnalyzer running the given analyzers, which assumes that Istio is installed in
+// the given namespace.
+func NewResourceAnalyzer(analyzer *analysis.CombinedAnalyzer, istioNamespace resource.Namespace,
+	timeout time.Duration) *ResourceAnalyzer {
+
+	if istioNamespace == "" {
+		istioNamespace = constants.IstioSystemNamespace
+	}
+	if timeout == 0 {
+		timeout = DefaultResourceTimeout
+	}
+	return &ResourceAnalyzer{
+		analyzer:       analyzer,
+		istioNamespace: istioNamespace,
+		timeout:        timeout,
+	}
+}
+
+// Analyze runs the named analyzers, or all analyzers if none are named, against the resources in the given files.
+// Resources that do not specify a namespace are put in the given namespace, and if it is set, only the messages for
+// that namespace are returned.
+func (a *ResourceAnalyzer) Analyze(ctx context.Context, files []local.ReaderSource, analyzers []string,
+	namespace string) (local.AnalysisResult, error) {
+
+	combined, err := a.analyzer.Subset(analyzers...)
+	if err != nil {
+		return local.AnalysisResult{}, invalidRequestError{err}
+	}
+
+	sa := local.NewSourceAnalyzer(schema.MustGet(), combined, resource.Namespace(namespace), a.istioNamespace, nil,
+		true, a.timeout)
+	if err := sa.AddDefaultResources(); err != nil {
+		return local.AnalysisResult{}, err
+	}
+	if err := sa.AddReaderKubeSource(files); err != nil {
+		return local.AnalysisResult{}, invalidRequestError{err}
+	}
+	return sa.Analyze(ctx)
+}
@@ -0,0 +1,166 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/local"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	v1alpha1 "istio.io/istio/galley/pkg/config/analysis/service/v1alpha1"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+const services = `
+apiVersion: v1
+kind: Service
+metadata:
+  name: reviews
+  namespace: bookinfo
+spec:
+  ports:
+  - name: http
+    port: 9080
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: ratings
+spec:
+  ports:
+  - name: http
+    port: 9080
+`
+
+// serviceAnalyzer reports every service.
+type serviceAnalyzer struct{}
+
+// Metadata implements analysis.Analyzer
+func (a *serviceAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:   "test.ServiceAnalyzer",
+		Inputs: collection.Names{collections.K8SCoreV1Services.Name()},
+	}
+}
+
+// Analyze implements analysis.Analyzer
+func (a *serviceAnalyzer) Analyze(ctx analysis.Context) {
+	ctx.ForEach(collections.K8SCoreV1Services.Name(), func(r *resource.Instance) bool {
+		ctx.Report(collections.K8SCoreV1Services.Name(), msg.NewInternalError(r, "found"))
+		return true
+	})
+}
+
+func newTestResourceAnalyzer() *ResourceAnalyzer {
+	return NewResourceAnalyzer(analysis.Combine("test", &serviceAnalyzer{}), "", 0)
+}
+
+func TestResourceAnalyzer(t *testing.T) {
+	g := NewGomegaWithT(t)
+	a := newTestResourceAnalyzer()
+
+	files := func() []local.ReaderSource {
+		return []local.ReaderSource{{Name: "services.yaml", Reader: strings.NewReader(services)}}
+	}
+
+	result, err := a.Analyze(context.Background(), files(), nil, "")
+	g.Expect(err).To(BeNil())
+	g.Expect(result.ExecutedAnalyzers).To(Equal([]string{"test.ServiceAnalyzer"}))
+	// The ingress gateway that Istio installs is assumed to exist
+	g.Expect(result.Messages).To(HaveLen(3))
+
+	// Services without namespace are put in the requested one
+	result, err = a.Analyze(context.Background(), files(), []string{"test.ServiceAnalyzer"}, "reviews")
+	g.Expect(err).To(BeNil())
+	g.Expect(result.Messages).To(HaveLen(1))
+	g.Expect(result.Messages[0].Resource.Origin.FriendlyName()).To(Equal("Service ratings.reviews"))
+}
+
+func TestResourceAnalyzerErrors(t *testing.T) {
+	g := NewGomegaWithT(t)
+	a := newTestResourceAnalyzer()
+
+	_, err := a.Analyze(context.Background(), nil, []string{"unknown"}, "")
+	g.Expect(err).To(BeAssignableToTypeOf(invalidRequestError{}))
+
+	files := []local.ReaderSource{{Name: "broken.yaml", Reader: strings.NewReader("kind: [")}}
+	_, err = a.Analyze(context.Background(), files, nil, "")
+	g.Expect(err).To(BeAssignableToTypeOf(invalidRequestError{}))
+}
+
+func TestAnalyzeResources(t *testing.T) {
+	g := NewGomegaWithT(t)
+	s := New(nil).WithResources(newTestResourceAnalyzer())
+
+	resp, err := s.AnalyzeResources(context.Background(), &v1alpha1.AnalyzeResourcesRequest{
+		Files:     []*v1alpha1.ResourceFile{{Name: "services.yaml", Content: services}},
+		Namespace: "bookinfo",
+	})
+	g.Expect(err).To(BeNil())
+	g.Expect(resp.Findings).To(HaveLen(1))
+	g.Expect(resp.Findings[0].Origin).To(Equal("Service reviews.bookinfo"))
+	g.Expect(resp.Summary.Findings).To(Equal(int32(1)))
+	g.Expect(resp.Summary.Analyzers).To(Equal([]string{"test.ServiceAnalyzer"}))
+
+	_, err = s.AnalyzeResources(context.Background(), &v1alpha1.AnalyzeResourcesRequest{Analyzers: []string{"unknown"}})
+	g.Expect(status.Code(err)).To(Equal(codes.InvalidArgument))
+
+	// A standalone server does not analyze the configuration of a control plane, and a control plane's server does
+	// not have to analyze submitted resources.
+	g.Expect(status.Code(s.Analyze(&v1alpha1.AnalyzeRequest{}, &fakeStream{ctx: context.Background()}))).
+		To(Equal(codes.Unimplemented))
+	_, err = New(&fakeEngine{}).AnalyzeResources(context.Background(), &v1alpha1.AnalyzeResourcesRequest{})
+	g.Expect(status.Code(err)).To(Equal(codes.Unimplemented))
+}
+
+func TestResourcesHTTPHandler(t *testing.T) {
+	g := NewGomegaWithT(t)
+	h := NewResourcesHTTPHandler(newTestResourceAnalyzer())
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("POST", "/analyze?namespace=bookinfo", strings.NewReader(services)))
+	g.Expect(w.Code).To(Equal(http.StatusOK))
+	var resp jsonResponse
+	g.Expect(json.Unmarshal(w.Body.Bytes(), &resp)).To(Succeed())
+	g.Expect(resp.Cached).To(BeFalse())
+	g.Expect(resp.Messages).To(HaveLen(1))
+	g.Expect(resp.Messages[0]["origin"]).To(Equal("Service reviews.bookinfo"))
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("POST", "/analyze?code=IST0000", strings.NewReader(services)))
+	g.Expect(json.Unmarshal(w.Body.Bytes(), &resp)).To(Succeed())
+	g.Expect(resp.Messages).To(BeEmpty())
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("POST", "/analyze?analyzer=unknown", strings.NewReader(services)))
+	g.Expect(w.Code).To(Equal(http.StatusBadRequest))
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/analyze", nil))
+	g.Expect(w.Code).To(Equal(http.StatusMethodNotAllowed))
+	g.Expect(w.Header().Get("Allow")).To(Equal("POST"))
+}
@@ -0,0 +1,184 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package service exposes config analysis over gRPC and HTTP, so that tools can request fresh analysis results from
+// the control plane, or have submitted resources analyzed without a control plane.
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+	"istio.io/istio/galley/pkg/config/analysis/kiali"
+	"istio.io/istio/galley/pkg/config/analysis/local"
+	v1alpha1 "istio.io/istio/galley/pkg/config/analysis/service/v1alpha1"
+	"istio.io/istio/galley/pkg/config/processing/snapshotter"
+	"istio.io/istio/galley/pkg/config/scope"
+	"istio.io/istio/pkg/config/resource"
+)
+
+// Engine runs analysis on demand.
+type Engine interface {
+	AnalyzeNow(r snapshotter.AnalysisRequest) (diag.Messages, error)
+}
+
+// Server implements the AnalysisService gRPC service.
+type Server struct {
+	engine    Engine
+	resources *ResourceAnalyzer
+}
+
+var _ v1alpha1.AnalysisServiceServer = &Server{}
+
+// New returns a new Server that runs analysis using the given engine. A server without engine, e.g. one that runs
+// outside of the control plane, rejects Analyze requests as unimplemented.
+func New(engine Engine) *Server {
+	return &Server{engine: engine}
+}
+
+// WithResources makes the server analyze submitted resources with the given analyzer. A server without one rejects
+// AnalyzeResources requests as unimplemented.
+func (s *Server) WithResources(a *ResourceAnalyzer) *Server {
+	s.resources = a
+	return s
+}
+
+// Register the server with the given gRPC server.
+func (s *Server) Register(gs *grpc.Server) {
+	v1alpha1.RegisterAnalysisServiceServer(gs, s)
+}
+
+// Analyze implements v1alpha1.AnalysisServiceServer
+func (s *Server) Analyze(req *v1alpha1.AnalyzeRequest, stream v1alpha1.AnalysisService_AnalyzeServer) error {
+	if s.engine == nil {
+		return status.Error(codes.Unimplemented, "the server does not analyze the configuration of a control plane")
+	}
+
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+
+	r := snapshotter.AnalysisRequest{
+		Analyzers: req.Analyzers,
+		Context:   ctx,
+	}
+	if req.Namespace != "" {
+		r.Namespaces = []resource.Namespace{resource.Namespace(req.Namespace)}
+	}
+
+	var completed []string
+	var sendErr error
+	r.OnAnalyzerDone = func(analyzer string, messages diag.Messages) {
+		if sendErr != nil {
+			return
+		}
+		completed = append(completed, analyzer)
+		if len(messages) == 0 {
+			return
+		}
+		res := &v1alpha1.AnalyzeResponse{
+			Analyzer: analyzer,
+			Findings: toFindings(messages.SortedDedupedCopy()),
+		}
+		if sendErr = stream.Send(res); sendErr != nil {
+			cancel()
+		}
+	}
+
+	start := time.Now()
+	msgs, err := s.engine.AnalyzeNow(r)
+	if err != nil {
+		if err == snapshotter.ErrNoSnapshot {
+			return status.Error(codes.Unavailable, err.Error())
+		}
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	if sendErr != nil {
+		scope.Analysis.Debugf("Analysis stream closed: %v", sendErr)
+		return sendErr
+	}
+
+	return stream.Send(&v1alpha1.AnalyzeResponse{
+		Summary: &v1alpha1.AnalysisSummary{
+			Findings:       int32(len(msgs)),
+			Analyzers:      completed,
+			Canceled:       stream.Context().Err() != nil,
+			DurationMillis: time.Since(start).Milliseconds(),
+		},
+	})
+}
+
+// AnalyzeResources implements v1alpha1.AnalysisServiceServer
+func (s *Server) AnalyzeResources(ctx context.Context,
+	req *v1alpha1.AnalyzeResourcesRequest) (*v1alpha1.AnalyzeResourcesResponse, error) {
+
+	if s.resources == nil {
+		return nil, status.Error(codes.Unimplemented, "the server does not analyze submitted resources")
+	}
+
+	files := make([]local.ReaderSource, 0, len(req.Files))
+	for _, f := range req.Files {
+		files = append(files, local.ReaderSource{Name: f.Name, Reader: strings.NewReader(f.Content)})
+	}
+
+	start := time.Now()
+	result, err := s.resources.Analyze(ctx, files, req.Analyzers, req.Namespace)
+	if err != nil {
+		if _, ok := err.(invalidRequestError); ok {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	msgs := result.Messages.SortedDedupedCopy()
+	return &v1alpha1.AnalyzeResourcesResponse{
+		Findings: toFindings(msgs),
+		Summary: &v1alpha1.AnalysisSummary{
+			Findings:       int32(len(msgs)),
+			Analyzers:      result.ExecutedAnalyzers,
+			Canceled:       ctx.Err() != nil,
+			DurationMillis: time.Since(start).Milliseconds(),
+		},
+	}, nil
+}
+
+func toFindings(msgs diag.Messages) []*v1alpha1.Finding {
+	result := make([]*v1alpha1.Finding, 0, len(msgs))
+	for i := range msgs {
+		m := msgs[i].Unstructured(true)
+		f := &v1alpha1.Finding{
+			Code:             m["code"].(string),
+			Level:            m["level"].(string),
+			Message:          m["message"].(string),
+			DocumentationUrl: m["documentation_url"].(string),
+			Notes:            msgs[i].Notes,
+		}
+		if origin, ok := m["origin"].(string); ok {
+			f.Origin = origin
+		}
+		if ref, ok := m["reference"].(string); ok {
+			f.Reference = ref
+		}
+		if mp, ok := kiali.Lookup(msgs[i]); ok {
+			f.KialiId = mp.KialiID
+		}
+		result = append(result, f)
+	}
+	return result
+}
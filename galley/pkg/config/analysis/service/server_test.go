@@ -0,0 +1,202 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	v1alpha1 "istio.io/istio/galley/pkg/config/analysis/service/v1alpha1"
+	"istio.io/istio/galley/pkg/config/processing/snapshotter"
+	"istio.io/istio/galley/pkg/config/source/kube/rt"
+	"istio.io/istio/galley/pkg/config/testing/basicmeta"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+type fakeEngine struct {
+	results map[string]diag.Messages
+	order   []string
+	err     error
+
+	last     diag.Messages
+	lastTime time.Time
+
+	req snapshotter.AnalysisRequest
+}
+
+// LastMessages implements Results
+func (e *fakeEngine) LastMessages() (diag.Messages, time.Time) {
+	return e.last, e.lastTime
+}
+
+// AnalyzeNow implements Engine
+func (e *fakeEngine) AnalyzeNow(r snapshotter.AnalysisRequest) (diag.Messages, error) {
+	e.req = r
+	if e.err != nil {
+		return nil, e.err
+	}
+
+	var all diag.Messages
+	for _, name := range e.order {
+		select {
+		case <-r.Context.Done():
+			return all, nil
+		default:
+		}
+		if r.OnAnalyzerDone != nil {
+			r.OnAnalyzerDone(name, e.results[name])
+		}
+		all = append(all, e.results[name]...)
+	}
+	return all, nil
+}
+
+type fakeStream struct {
+	grpc.ServerStream
+	ctx     context.Context
+	sent    []*v1alpha1.AnalyzeResponse
+	sendErr error
+}
+
+// Context implements grpc.ServerStream
+func (s *fakeStream) Context() context.Context {
+	return s.ctx
+}
+
+// Send implements v1alpha1.AnalysisService_AnalyzeServer
+func (s *fakeStream) Send(r *v1alpha1.AnalyzeResponse) error {
+	if s.sendErr != nil {
+		return s.sendErr
+	}
+	s.sent = append(s.sent, r)
+	return nil
+}
+
+func newResource(ns, name string) *resource.Instance {
+	return &resource.Instance{
+		Origin: &rt.Origin{
+			Collection: basicmeta.K8SCollection1.Name(),
+			Kind:       "Kind1",
+			FullName:   resource.NewFullName(resource.Namespace(ns), resource.LocalName(name)),
+		},
+	}
+}
+
+func newVirtualService(ns, name string) *resource.Instance {
+	r := newResource(ns, name)
+	r.Origin.(*rt.Origin).Collection = collections.IstioNetworkingV1Alpha3Virtualservices.Name()
+	return r
+}
+
+func TestAnalyze(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	e := &fakeEngine{
+		order: []string{"a1", "a2", "a3"},
+		results: map[string]diag.Messages{
+			"a1": {msg.NewInternalError(newResource("ns1", "r1"), "one")},
+			"a3": {
+				msg.NewInternalError(nil, "two"),
+				msg.NewReferencedResourceNotFound(newVirtualService("ns1", "r2"), "gateway", "gw"),
+			},
+		},
+	}
+	st := &fakeStream{ctx: context.Background()}
+
+	err := New(e).Analyze(&v1alpha1.AnalyzeRequest{Namespace: "ns1", Analyzers: []string{"a1", "a3"}}, st)
+	g.Expect(err).To(BeNil())
+	g.Expect(e.req.Analyzers).To(Equal([]string{"a1", "a3"}))
+	g.Expect(e.req.Namespaces).To(Equal([]resource.Namespace{"ns1"}))
+
+	// One response per analyzer with findings, then the summary.
+	g.Expect(st.sent).To(HaveLen(3))
+	g.Expect(st.sent[0].Analyzer).To(Equal("a1"))
+	g.Expect(st.sent[0].Findings).To(HaveLen(1))
+	f := st.sent[0].Findings[0]
+	g.Expect(f.Code).To(Equal(msg.InternalError.Code()))
+	g.Expect(f.Level).To(Equal(diag.Error.String()))
+	g.Expect(f.Message).To(ContainSubstring("one"))
+	g.Expect(f.Origin).To(Equal("Kind1 r1.ns1"))
+	g.Expect(f.DocumentationUrl).To(HavePrefix(diag.DocPrefix))
+	g.Expect(f.KialiId).To(BeEmpty())
+
+	g.Expect(st.sent[1].Analyzer).To(Equal("a3"))
+	g.Expect(st.sent[1].Findings).To(HaveLen(2))
+	g.Expect(st.sent[1].Findings[1].KialiId).To(Equal("KIA1102"))
+
+	summary := st.sent[2].Summary
+	g.Expect(summary).NotTo(BeNil())
+	g.Expect(summary.Findings).To(Equal(int32(3)))
+	g.Expect(summary.Analyzers).To(Equal([]string{"a1", "a2", "a3"}))
+	g.Expect(summary.Canceled).To(BeFalse())
+}
+
+func TestAnalyzeAllNamespaces(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	e := &fakeEngine{}
+	st := &fakeStream{ctx: context.Background()}
+
+	g.Expect(New(e).Analyze(&v1alpha1.AnalyzeRequest{}, st)).To(BeNil())
+	g.Expect(e.req.Namespaces).To(BeEmpty())
+	g.Expect(st.sent).To(HaveLen(1))
+	g.Expect(st.sent[0].Summary.Findings).To(Equal(int32(0)))
+}
+
+func TestAnalyzeErrors(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	st := &fakeStream{ctx: context.Background()}
+	err := New(&fakeEngine{err: snapshotter.ErrNoSnapshot}).Analyze(&v1alpha1.AnalyzeRequest{}, st)
+	g.Expect(status.Code(err)).To(Equal(codes.Unavailable))
+
+	err = New(&fakeEngine{err: errors.New("unknown analyzers: foo")}).Analyze(&v1alpha1.AnalyzeRequest{}, st)
+	g.Expect(status.Code(err)).To(Equal(codes.InvalidArgument))
+	g.Expect(st.sent).To(BeEmpty())
+}
+
+func TestAnalyzeStopsOnSendError(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	e := &fakeEngine{
+		order: []string{"a1", "a2"},
+		results: map[string]diag.Messages{
+			"a1": {msg.NewInternalError(nil, "one")},
+			"a2": {msg.NewInternalError(nil, "two")},
+		},
+	}
+	sendErr := errors.New("stream closed")
+	st := &fakeStream{ctx: context.Background(), sendErr: sendErr}
+
+	err := New(e).Analyze(&v1alpha1.AnalyzeRequest{}, st)
+	g.Expect(err).To(Equal(sendErr))
+
+	// The analysis is canceled once the stream is broken.
+	select {
+	case <-e.req.Context.Done():
+	default:
+		t.Fatal("expected analysis to be canceled")
+	}
+}
@@ -0,0 +1,137 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sink
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	"istio.io/istio/galley/pkg/config/analysis/notify"
+	"istio.io/istio/galley/pkg/config/processing/snapshotter"
+)
+
+// The kinds of sinks that can be configured with a spec.
+const (
+	// KindStdout writes the findings to standard output.
+	KindStdout = "stdout"
+
+	// KindFile keeps a file up to date with the findings. Requires the path as argument, e.g. "file:/tmp/findings.json".
+	KindFile = "file"
+
+	// KindCRD writes the findings to the status of the resources they are reported on.
+	KindCRD = "crd"
+
+	// KindEvents records the findings as Kubernetes Events.
+	KindEvents = "events"
+
+	// KindWebhook notifies new findings to a webhook. Requires the URL as argument, e.g. "webhook:https://example.com".
+	KindWebhook = "webhook"
+)
+
+// Kinds lists all kinds of sinks.
+var Kinds = []string{KindStdout, KindFile, KindCRD, KindEvents, KindWebhook}
+
+// Dependencies that sinks built from specs are created with. Specs of sinks whose dependencies are missing are
+// rejected.
+type Dependencies struct {
+	// Stdout is written to by stdout sinks.
+	Stdout io.Writer
+
+	// StatusUpdater writes messages to resource statuses, as used by crd sinks.
+	StatusUpdater snapshotter.StatusUpdater
+
+	// Events is the client used by events sinks.
+	Events corev1client.EventsGetter
+
+	// Webhook holds the options of webhook sinks. The URL is taken from the spec.
+	Webhook notify.Options
+}
+
+// New returns a MessageSink that combines the sinks of the given specs. A spec has the form <kind>[:<argument>], with
+// the kind being one of Kinds. Empty specs are ignored.
+func New(specs []string, deps Dependencies) (MessageSink, error) {
+	var sinks []MessageSink
+	for _, spec := range specs {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+		s, err := newSink(spec, deps)
+		if err != nil {
+			return nil, fmt.Errorf("invalid analysis sink %q: %v", spec, err)
+		}
+		sinks = append(sinks, s)
+	}
+
+	if len(sinks) == 1 {
+		return sinks[0], nil
+	}
+	return Combine(sinks...), nil
+}
+
+func newSink(spec string, deps Dependencies) (MessageSink, error) {
+	kind := spec
+	arg := ""
+	if i := strings.Index(spec, ":"); i >= 0 {
+		kind, arg = spec[:i], spec[i+1:]
+	}
+
+	switch kind {
+	case KindStdout, KindCRD, KindEvents:
+		if arg != "" {
+			return nil, fmt.Errorf("unexpected argument %q", arg)
+		}
+	}
+
+	switch kind {
+	case KindStdout:
+		if deps.Stdout == nil {
+			return nil, fmt.Errorf("stdout is not available")
+		}
+		return NewWriter(deps.Stdout), nil
+
+	case KindFile:
+		if arg == "" {
+			return nil, fmt.Errorf("missing file path")
+		}
+		return NewFile(arg), nil
+
+	case KindCRD:
+		if deps.StatusUpdater == nil {
+			return nil, fmt.Errorf("resource statuses are not available")
+		}
+		return FromStatusUpdater(deps.StatusUpdater), nil
+
+	case KindEvents:
+		if deps.Events == nil {
+			return nil, fmt.Errorf("kubernetes events are not available")
+		}
+		return NewEvents(deps.Events), nil
+
+	case KindWebhook:
+		if arg == "" {
+			return nil, fmt.Errorf("missing webhook URL")
+		}
+		o := deps.Webhook
+		o.URL = arg
+		return FromStatusUpdater(notify.NewWebhook(o)), nil
+
+	default:
+		return nil, fmt.Errorf("unknown kind %q, expected one of %s", kind, strings.Join(Kinds, ", "))
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sink
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+	"istio.io/istio/galley/pkg/config/processing/snapshotter"
+)
+
+func TestNew(t *testing.T) {
+	deps := Dependencies{
+		Stdout:        &bytes.Buffer{},
+		StatusUpdater: &snapshotter.InMemoryStatusUpdater{},
+		Events:        fake.NewSimpleClientset().CoreV1(),
+	}
+
+	cases := []struct {
+		specs []string
+		deps  Dependencies
+		err   string
+	}{
+		{specs: []string{"stdout"}, deps: deps},
+		{specs: []string{"file:/tmp/findings.json"}, deps: deps},
+		{specs: []string{"crd"}, deps: deps},
+		{specs: []string{"events"}, deps: deps},
+		{specs: []string{"webhook:http://example.com:8080/hook"}, deps: deps},
+		{specs: []string{"crd", "events", "stdout"}, deps: deps},
+		{specs: nil, deps: deps},
+		{specs: []string{"", " crd "}, deps: deps},
+		{specs: []string{"crd"}, err: `invalid analysis sink "crd": resource statuses are not available`},
+		{specs: []string{"events"}, err: "kubernetes events are not available"},
+		{specs: []string{"stdout"}, err: "stdout is not available"},
+		{specs: []string{"file"}, deps: deps, err: "missing file path"},
+		{specs: []string{"webhook:"}, deps: deps, err: "missing webhook URL"},
+		{specs: []string{"crd:foo"}, deps: deps, err: `unexpected argument "foo"`},
+		{specs: []string{"stdout", "syslog"}, deps: deps, err: `unknown kind "syslog"`},
+	}
+	for _, c := range cases {
+		c := c
+		t.Run(strings.Join(c.specs, ","), func(t *testing.T) {
+			g := NewGomegaWithT(t)
+
+			s, err := New(c.specs, c.deps)
+			if c.err != "" {
+				g.Expect(err).To(MatchError(ContainSubstring(c.err)))
+				return
+			}
+			g.Expect(err).To(BeNil())
+			g.Expect(s).NotTo(BeNil())
+		})
+	}
+}
+
+func TestNewWritesToAll(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	var stdout bytes.Buffer
+	updater := &snapshotter.InMemoryStatusUpdater{}
+	s, err := New([]string{"stdout", "crd"}, Dependencies{Stdout: &stdout, StatusUpdater: updater})
+	g.Expect(err).To(BeNil())
+
+	batch := diag.Messages{newMessage(errorType, "ns", "a")}
+	g.Expect(s.Write(batch)).To(Succeed())
+	g.Expect(s.Flush()).To(Succeed())
+	g.Expect(stdout.String()).To(ContainSubstring("broken a"))
+	g.Expect(updater.Get()).To(Equal(batch))
+}
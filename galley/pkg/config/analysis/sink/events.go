@@ -0,0 +1,140 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sink
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+	"istio.io/istio/galley/pkg/config/source/kube/rt"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+// EventSource is the component that Events are attributed to.
+const EventSource = "istio-config-analysis"
+
+// Events is a MessageSink that records findings as Kubernetes Events on the resources they are reported on. An Event
+// is recorded when a finding first appears; a finding that is resolved and then reappears is recorded again.
+// Findings on resources that do not originate from Kubernetes are skipped.
+type Events struct {
+	client corev1client.EventsGetter
+	now    func() time.Time
+
+	mu   sync.Mutex
+	seen map[string]struct{}
+}
+
+var _ MessageSink = &Events{}
+
+// NewEvents returns a new Events.
+func NewEvents(client corev1client.EventsGetter) *Events {
+	return &Events{
+		client: client,
+		now:    time.Now,
+	}
+}
+
+// Write implements MessageSink
+func (e *Events) Write(batch diag.Messages) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var errs error
+	seen := make(map[string]struct{}, len(batch))
+	for i := range batch {
+		m := &batch[i]
+		k := m.String()
+		if _, ok := e.seen[k]; !ok {
+			ev := e.event(m)
+			if ev == nil {
+				continue
+			}
+			if _, err := e.client.Events(ev.Namespace).Create(context.TODO(), ev, metav1.CreateOptions{}); err != nil {
+				// Leave the finding unseen, so that it is retried with the next batch.
+				errs = multierror.Append(errs, err)
+				continue
+			}
+		}
+		seen[k] = struct{}{}
+	}
+	e.seen = seen
+
+	return errs
+}
+
+// Flush implements MessageSink
+func (e *Events) Flush() error {
+	return nil
+}
+
+func (e *Events) event(m *diag.Message) *corev1.Event {
+	if m.Resource == nil {
+		return nil
+	}
+	o, ok := m.Resource.Origin.(*rt.Origin)
+	if !ok {
+		return nil
+	}
+	s, ok := collections.All.Find(o.Collection.String())
+	if !ok {
+		return nil
+	}
+
+	apiVersion := s.Resource().Version()
+	if s.Resource().Group() != "" {
+		apiVersion = s.Resource().Group() + "/" + apiVersion
+	}
+
+	// Events on cluster scoped resources go to the default namespace, as with other Kubernetes components.
+	ns := o.FullName.Namespace.String()
+	if ns == "" {
+		ns = metav1.NamespaceDefault
+	}
+
+	eventType := corev1.EventTypeWarning
+	if !m.Type.Level().IsWorseThanOrEqualTo(diag.Warning) {
+		eventType = corev1.EventTypeNormal
+	}
+
+	now := metav1.NewTime(e.now())
+	return &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s.%s.%x", o.FullName.Name, strings.ToLower(m.Type.Code()), now.UnixNano()),
+			Namespace: ns,
+		},
+		InvolvedObject: corev1.ObjectReference{
+			APIVersion: apiVersion,
+			Kind:       o.Kind,
+			Namespace:  o.FullName.Namespace.String(),
+			Name:       o.FullName.Name.String(),
+		},
+		Reason:         m.Type.Code(),
+		Message:        fmt.Sprintf(m.Type.Template(), m.Parameters...),
+		Type:           eventType,
+		Source:         corev1.EventSource{Component: EventSource},
+		FirstTimestamp: now,
+		LastTimestamp:  now,
+		Count:          1,
+	}
+}
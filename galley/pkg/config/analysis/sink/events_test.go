@@ -0,0 +1,106 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sink
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+	"istio.io/istio/pkg/config/resource"
+)
+
+func listEvents(g *GomegaWithT, client *fake.Clientset) []corev1.Event {
+	l, err := client.CoreV1().Events(metav1.NamespaceAll).List(context.TODO(), metav1.ListOptions{})
+	g.Expect(err).To(BeNil())
+	return l.Items
+}
+
+func TestEvents(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	client := fake.NewSimpleClientset()
+	e := NewEvents(client.CoreV1())
+	now := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+	e.now = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+
+	a := newMessage(errorType, "ns", "a")
+	b := newMessage(infoType, "", "b")
+	unknown := diag.NewMessage(errorType, &resource.Instance{}, "unknown")
+
+	g.Expect(e.Write(diag.Messages{a, b, unknown})).To(Succeed())
+	events := listEvents(g, client)
+	g.Expect(events).To(HaveLen(2))
+
+	byName := make(map[string]corev1.Event)
+	for _, ev := range events {
+		byName[ev.InvolvedObject.Name] = ev
+	}
+	g.Expect(byName["a"].Namespace).To(Equal("ns"))
+	g.Expect(byName["a"].InvolvedObject).To(Equal(corev1.ObjectReference{
+		APIVersion: "networking.istio.io/v1alpha3",
+		Kind:       "VirtualService",
+		Namespace:  "ns",
+		Name:       "a",
+	}))
+	g.Expect(byName["a"].Type).To(Equal(corev1.EventTypeWarning))
+	g.Expect(byName["a"].Reason).To(Equal("TEST0001"))
+	g.Expect(byName["a"].Message).To(Equal("broken a"))
+	g.Expect(byName["a"].Source.Component).To(Equal(EventSource))
+	g.Expect(byName["b"].Namespace).To(Equal(metav1.NamespaceDefault))
+	g.Expect(byName["b"].Type).To(Equal(corev1.EventTypeNormal))
+
+	// Findings are recorded once, until they are resolved and reappear
+	g.Expect(e.Write(diag.Messages{a, b})).To(Succeed())
+	g.Expect(listEvents(g, client)).To(HaveLen(2))
+	g.Expect(e.Write(diag.Messages{b})).To(Succeed())
+	g.Expect(e.Write(diag.Messages{a, b})).To(Succeed())
+	g.Expect(listEvents(g, client)).To(HaveLen(3))
+	g.Expect(e.Flush()).To(Succeed())
+}
+
+func TestEventsRetriesFailures(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	client := fake.NewSimpleClientset()
+	fail := true
+	client.PrependReactor("create", "events", func(k8stesting.Action) (bool, runtime.Object, error) {
+		if fail {
+			return true, nil, errors.New("unavailable")
+		}
+		return false, nil, nil
+	})
+	e := NewEvents(client.CoreV1())
+
+	a := newMessage(errorType, "ns", "a")
+	g.Expect(e.Write(diag.Messages{a})).To(MatchError(ContainSubstring("unavailable")))
+	g.Expect(listEvents(g, client)).To(BeEmpty())
+
+	fail = false
+	g.Expect(e.Write(diag.Messages{a})).To(Succeed())
+	g.Expect(listEvents(g, client)).To(HaveLen(1))
+}
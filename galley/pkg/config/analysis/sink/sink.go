@@ -0,0 +1,112 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sink delivers the findings of continuous config analysis to the places they are consumed, e.g. resource
+// statuses, Kubernetes Events, files or webhooks.
+package sink
+
+import (
+	"github.com/hashicorp/go-multierror"
+
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+	"istio.io/istio/galley/pkg/config/processing/snapshotter"
+	"istio.io/istio/galley/pkg/config/scope"
+)
+
+// MessageSink receives the findings of continuous analysis.
+type MessageSink interface {
+	// Write delivers a batch of messages, i.e. the complete set of findings of one analysis run. Sinks may buffer the
+	// batch, or deliver only the changes since the previous one.
+	Write(batch diag.Messages) error
+
+	// Flush delivers anything buffered by previous writes.
+	Flush() error
+}
+
+type combined []MessageSink
+
+// Combine returns a MessageSink that passes each batch to all of the given sinks in turn.
+func Combine(sinks ...MessageSink) MessageSink {
+	return combined(sinks)
+}
+
+// Write implements MessageSink
+func (c combined) Write(batch diag.Messages) error {
+	var errs error
+	for _, s := range c {
+		if err := s.Write(batch); err != nil {
+			errs = multierror.Append(errs, err)
+		}
+	}
+	return errs
+}
+
+// Flush implements MessageSink
+func (c combined) Flush() error {
+	var errs error
+	for _, s := range c {
+		if err := s.Flush(); err != nil {
+			errs = multierror.Append(errs, err)
+		}
+	}
+	return errs
+}
+
+type flusher interface {
+	Flush() error
+}
+
+type statusUpdaterSink struct {
+	u snapshotter.StatusUpdater
+}
+
+// FromStatusUpdater returns a MessageSink that passes each batch to the given StatusUpdater. Flush is passed on if
+// the updater has a Flush method.
+func FromStatusUpdater(u snapshotter.StatusUpdater) MessageSink {
+	return statusUpdaterSink{u: u}
+}
+
+// Write implements MessageSink
+func (s statusUpdaterSink) Write(batch diag.Messages) error {
+	s.u.Update(batch)
+	return nil
+}
+
+// Flush implements MessageSink
+func (s statusUpdaterSink) Flush() error {
+	if f, ok := s.u.(flusher); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+// Updater is a snapshotter.StatusUpdater that writes the messages to a MessageSink. Write errors are logged, as the
+// analysis has no way to act on them.
+type Updater struct {
+	Sink MessageSink
+}
+
+var _ snapshotter.StatusUpdater = &Updater{}
+
+// Update implements snapshotter.StatusUpdater
+func (u *Updater) Update(messages diag.Messages) {
+	if err := u.Sink.Write(messages); err != nil {
+		scope.Analysis.Warnf("Error writing %d analysis messages: %v", len(messages), err)
+	}
+}
+
+// Flush flushes the sink.
+func (u *Updater) Flush() error {
+	return u.Sink.Flush()
+}
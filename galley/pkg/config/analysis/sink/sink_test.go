@@ -0,0 +1,173 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sink
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+	"istio.io/istio/galley/pkg/config/processing/snapshotter"
+	"istio.io/istio/galley/pkg/config/source/kube/rt"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+var (
+	errorType = diag.NewMessageType(diag.Error, "TEST0001", "broken %s")
+	infoType  = diag.NewMessageType(diag.Info, "TEST0002", "note %s")
+)
+
+func newMessage(mt *diag.MessageType, ns, name string) diag.Message {
+	r := &resource.Instance{
+		Origin: &rt.Origin{
+			Collection: collections.IstioNetworkingV1Alpha3Virtualservices.Name(),
+			Kind:       "VirtualService",
+			FullName:   resource.NewFullName(resource.Namespace(ns), resource.LocalName(name)),
+		},
+	}
+	return diag.NewMessage(mt, r, name)
+}
+
+type fakeSink struct {
+	batches []diag.Messages
+	flushed int
+	err     error
+}
+
+func (s *fakeSink) Write(batch diag.Messages) error {
+	s.batches = append(s.batches, batch)
+	return s.err
+}
+
+func (s *fakeSink) Flush() error {
+	s.flushed++
+	return s.err
+}
+
+type flushingUpdater struct {
+	snapshotter.InMemoryStatusUpdater
+	flushed bool
+}
+
+func (u *flushingUpdater) Flush() error {
+	u.flushed = true
+	return nil
+}
+
+func TestCombine(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	s1 := &fakeSink{}
+	s2 := &fakeSink{err: errors.New("unavailable")}
+	s3 := &fakeSink{}
+	c := Combine(s1, s2, s3)
+
+	batch := diag.Messages{newMessage(errorType, "ns", "a")}
+	g.Expect(c.Write(batch)).To(MatchError(ContainSubstring("unavailable")))
+	g.Expect(c.Flush()).To(MatchError(ContainSubstring("unavailable")))
+
+	// A failing sink does not keep the others from receiving the batch
+	for _, s := range []*fakeSink{s1, s2, s3} {
+		g.Expect(s.batches).To(Equal([]diag.Messages{batch}))
+		g.Expect(s.flushed).To(Equal(1))
+	}
+}
+
+func TestFromStatusUpdater(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	u := &flushingUpdater{}
+	s := FromStatusUpdater(u)
+
+	batch := diag.Messages{newMessage(errorType, "ns", "a")}
+	g.Expect(s.Write(batch)).To(Succeed())
+	g.Expect(u.Get()).To(Equal(batch))
+
+	g.Expect(s.Flush()).To(Succeed())
+	g.Expect(u.flushed).To(BeTrue())
+
+	g.Expect(FromStatusUpdater(&snapshotter.InMemoryStatusUpdater{}).Flush()).To(Succeed())
+}
+
+func TestUpdater(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	s := &fakeSink{err: errors.New("unavailable")}
+	u := &Updater{Sink: s}
+
+	batch := diag.Messages{newMessage(errorType, "ns", "a")}
+	u.Update(batch)
+	g.Expect(s.batches).To(Equal([]diag.Messages{batch}))
+	g.Expect(u.Flush()).NotTo(Succeed())
+}
+
+func TestWriter(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	var b bytes.Buffer
+	w := NewWriter(&b)
+
+	g.Expect(w.Write(diag.Messages{newMessage(errorType, "ns", "a"), newMessage(infoType, "ns", "b")})).To(Succeed())
+	g.Expect(w.Write(nil)).To(Succeed())
+	g.Expect(w.Flush()).To(Succeed())
+	g.Expect(b.String()).To(Equal(
+		"Error [TEST0001] (VirtualService a.ns) broken a\n" +
+			"Info [TEST0002] (VirtualService b.ns) note b\n" +
+			"\n" +
+			"\n"))
+}
+
+func TestFile(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	dir, err := ioutil.TempDir("", "sink")
+	g.Expect(err).To(BeNil())
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	path := filepath.Join(dir, "findings.json")
+	f := NewFile(path)
+
+	read := func() []map[string]interface{} {
+		b, err := ioutil.ReadFile(path)
+		g.Expect(err).To(BeNil())
+		var result []map[string]interface{}
+		g.Expect(json.Unmarshal(b, &result)).To(Succeed())
+		return result
+	}
+
+	g.Expect(f.Write(diag.Messages{newMessage(errorType, "ns", "a"), newMessage(infoType, "ns", "b")})).To(Succeed())
+	findings := read()
+	g.Expect(findings).To(HaveLen(2))
+	g.Expect(findings[0]["code"]).To(Equal("TEST0001"))
+	g.Expect(findings[0]["origin"]).To(Equal("VirtualService a.ns"))
+
+	// Each batch replaces the previous one, without leaving temporary files behind
+	g.Expect(f.Write(nil)).To(Succeed())
+	g.Expect(read()).To(BeEmpty())
+
+	files, err := ioutil.ReadDir(dir)
+	g.Expect(err).To(BeNil())
+	g.Expect(files).To(HaveLen(1))
+
+	g.Expect(NewFile(filepath.Join(dir, "missing", "findings.json")).Write(nil)).NotTo(Succeed())
+}
@@ -0,0 +1,110 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sink
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+)
+
+// Writer is a MessageSink that writes each batch to an io.Writer, one message per line, followed by a blank line.
+type Writer struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+var _ MessageSink = &Writer{}
+
+// NewWriter returns a new Writer.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+// Write implements MessageSink
+func (w *Writer) Write(batch diag.Messages) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for i := range batch {
+		if _, err := fmt.Fprintln(w.w, batch[i].String()); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintln(w.w)
+	return err
+}
+
+// Flush implements MessageSink
+func (w *Writer) Flush() error {
+	return nil
+}
+
+// File is a MessageSink that keeps a file up to date with the latest batch, as a JSON list of messages. The file is
+// replaced atomically, so that readers never observe a partially written batch.
+type File struct {
+	mu   sync.Mutex
+	path string
+}
+
+var _ MessageSink = &File{}
+
+// NewFile returns a new File.
+func NewFile(path string) *File {
+	return &File{path: path}
+}
+
+// Write implements MessageSink
+func (f *File) Write(batch diag.Messages) error {
+	if batch == nil {
+		batch = diag.Messages{}
+	}
+	b, err := json.MarshalIndent(batch, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	tmp, err := ioutil.TempFile(filepath.Dir(f.path), "."+filepath.Base(f.path))
+	if err != nil {
+		return err
+	}
+	if _, err = tmp.Write(b); err == nil {
+		err = tmp.Close()
+	} else {
+		_ = tmp.Close()
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), f.path)
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write %s: %v", f.path, err)
+	}
+	return nil
+}
+
+// Flush implements MessageSink
+func (f *File) Flush() error {
+	return nil
+}
@@ -0,0 +1,315 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package analysistest runs a single analyzer on yaml fixtures, and checks the messages it reports. It is meant for
+// the unit tests of analyzers, whether they are part of Istio or not:
+//
+//	analysistest.Expect(t, &virtualservice.DestinationRuleAnalyzer{},
+//		analysistest.Case{Files: []string{"testdata/virtualservice_destinationrules.yaml"}},
+//		analysistest.Message{Type: msg.ReferencedResourceNotFound, Resource: "VirtualService reviews-bogussubset.default"})
+package analysistest
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pmezard/go-difflib/difflib"
+
+	"istio.io/pkg/log"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+	"istio.io/istio/galley/pkg/config/analysis/local"
+	"istio.io/istio/galley/pkg/config/scope"
+	"istio.io/istio/pkg/config/schema"
+	"istio.io/istio/pkg/config/schema/collection"
+)
+
+const (
+	istioNamespace = "istio-system"
+	timeout        = 30 * time.Second
+)
+
+// Case is the input of an analyzer run. Resources are read from yaml files the same way istioctl analyze reads them.
+type Case struct {
+	// Files are yaml files with the resources to analyze.
+	Files []string
+
+	// Content is yaml with additional resources to analyze, e.g. generated by fixtures.Topology.
+	Content []string
+
+	// MeshConfigFile and MeshNetworksFile replace the default mesh configuration. Optional.
+	MeshConfigFile   string
+	MeshNetworksFile string
+
+	// ClusterFiles are the yaml files of the clusters of a multi-cluster analysis, by cluster name. Optional.
+	ClusterFiles map[string][]string
+
+	// ClusterMeshNetworksFiles are the mesh networks configuration files of the clusters, by cluster name. Optional.
+	ClusterMeshNetworksFiles map[string]string
+
+	// ConfigDumpFiles are Envoy config dumps of proxies, with the configuration pushed to them (see
+	// analysis.PushedConfig). Optional.
+	ConfigDumpFiles []string
+
+	// SnapshotFile is an archive written by istioctl analyze --export-snapshot, e.g. to turn the configuration of a
+	// real cluster into a regression test. The files of the case are analyzed on top of it. Optional.
+	SnapshotFile string
+
+	// CustomResources are resource kinds that are not part of the generated metadata, and that the analyzer reads
+	// from the collections named by their CollectionName. Optional.
+	CustomResources []schema.CustomResource
+}
+
+// Result is the outcome of an analyzer run.
+type Result struct {
+	Messages diag.Messages
+
+	// Accessed are the collections the analyzer read, sorted by name.
+	Accessed collection.Names
+}
+
+// Message is the part of a diag.Message that tests usually check: its type, and the friendly name of the resource it
+// was reported on, e.g. "VirtualService reviews.default".
+type Message struct {
+	Type     *diag.MessageType
+	Resource string
+}
+
+// String implements fmt.Stringer
+func (m Message) String() string {
+	return fmt.Sprintf("%s (%s)", m.Type.Code(), m.Resource)
+}
+
+// Run loads the resources of c into an in-memory snapshot and runs a on it. Unless the case has a snapshot, the
+// default resources of a files-only analysis, e.g. the ingress gateway, are added as well.
+func Run(a analysis.Analyzer, c Case) (*Result, error) {
+	var mu sync.Mutex
+	accessed := make(map[collection.Name]bool)
+	cr := func(col collection.Name) {
+		mu.Lock()
+		defer mu.Unlock()
+		accessed[col] = true
+	}
+
+	m, err := schema.MustGet().WithCustomResources(c.CustomResources...)
+	if err != nil {
+		return nil, err
+	}
+	sa := local.NewSourceAnalyzer(m, analysis.Combine("analysistest", a), "", istioNamespace, cr, true, timeout)
+
+	if c.SnapshotFile != "" {
+		f, err := os.Open(c.SnapshotFile)
+		if err != nil {
+			return nil, err
+		}
+		err = sa.AddSnapshotArchive(f)
+		_ = f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("error reading snapshot %s: %v", c.SnapshotFile, err)
+		}
+	}
+
+	if c.MeshConfigFile != "" {
+		if err := sa.AddFileKubeMeshConfig(c.MeshConfigFile); err != nil {
+			return nil, fmt.Errorf("error applying mesh config file %s: %v", c.MeshConfigFile, err)
+		}
+	}
+	if c.MeshNetworksFile != "" {
+		if err := sa.AddFileKubeMeshNetworks(c.MeshNetworksFile); err != nil {
+			return nil, fmt.Errorf("error applying mesh networks file %s: %v", c.MeshNetworksFile, err)
+		}
+	}
+
+	if c.SnapshotFile == "" {
+		if err := sa.AddDefaultResources(); err != nil {
+			return nil, fmt.Errorf("error adding default resources: %v", err)
+		}
+	}
+
+	readers, err := readFiles(c.Files)
+	if err != nil {
+		return nil, err
+	}
+	for i, content := range c.Content {
+		readers = append(readers, local.ReaderSource{Name: fmt.Sprintf("content-%d", i), Reader: strings.NewReader(content)})
+	}
+	if err := sa.AddReaderKubeSource(readers); err != nil {
+		return nil, fmt.Errorf("error adding files: %v", err)
+	}
+
+	for cluster, files := range c.ClusterFiles {
+		readers, err := readFiles(files)
+		if err != nil {
+			return nil, err
+		}
+		if err := sa.AddReaderKubeClusterSource(cluster, readers); err != nil {
+			return nil, fmt.Errorf("error adding files of cluster %s: %v", cluster, err)
+		}
+	}
+	for cluster, f := range c.ClusterMeshNetworksFiles {
+		if err := sa.AddFileKubeClusterMeshNetworks(cluster, f); err != nil {
+			return nil, fmt.Errorf("error applying mesh networks file %s of cluster %s: %v", f, cluster, err)
+		}
+	}
+
+	for _, f := range c.ConfigDumpFiles {
+		if err := sa.AddFileConfigDump(f); err != nil {
+			return nil, fmt.Errorf("error adding config dump file %s: %v", f, err)
+		}
+	}
+
+	// The default processing log level is too chatty for tests
+	prevLogLevel := scope.Processing.GetOutputLevel()
+	scope.Processing.SetOutputLevel(log.ErrorLevel)
+	defer scope.Processing.SetOutputLevel(prevLogLevel)
+
+	result, err := sa.Analyze(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	var cols collection.Names
+	for col := range accessed {
+		cols = append(cols, col)
+	}
+	cols.Sort()
+
+	return &Result{Messages: result.Messages, Accessed: cols}, nil
+}
+
+func readFiles(files []string) ([]local.ReaderSource, error) {
+	var readers []local.ReaderSource
+	for _, f := range files {
+		by, err := ioutil.ReadFile(f)
+		if err != nil {
+			return nil, fmt.Errorf("error reading test file %s: %v", f, err)
+		}
+		readers = append(readers, local.ReaderSource{Name: f, Reader: strings.NewReader(string(by))})
+	}
+	return readers, nil
+}
+
+// Messages returns the type and resource of each of the messages.
+func Messages(msgs diag.Messages) []Message {
+	result := make([]Message, 0, len(msgs))
+	for _, m := range msgs {
+		e := Message{Type: m.Type}
+		if m.Resource != nil {
+			e.Resource = m.Resource.Origin.FriendlyName()
+		}
+		result = append(result, e)
+	}
+	return result
+}
+
+// Expect runs a on c, and fails the test unless a reports exactly the expected messages, in any order. The test also
+// fails if a reads collections that it doesn't declare as inputs, since those would be missing in other runs.
+func Expect(t testing.TB, a analysis.Analyzer, c Case, expected ...Message) {
+	t.Helper()
+
+	result := run(t, a, c)
+
+	actual := Messages(result.Messages)
+	sortMessages(actual)
+	expected = append([]Message{}, expected...)
+	sortMessages(expected)
+	if fmt.Sprint(actual) != fmt.Sprint(expected) {
+		t.Errorf("Unexpected messages from analyzer %s:\n got: %v\nwant: %v\n%s",
+			a.Metadata().Name, actual, expected, Render(result.Messages))
+	}
+}
+
+// ExpectGolden runs a on c, and compares the rendered messages with the contents of goldenFile. If the environment
+// variable REFRESH_GOLDEN is "true", goldenFile is rewritten with the messages instead.
+func ExpectGolden(t testing.TB, a analysis.Analyzer, c Case, goldenFile string) {
+	t.Helper()
+
+	result := run(t, a, c)
+	content := Render(result.Messages)
+
+	if os.Getenv("REFRESH_GOLDEN") == "true" {
+		t.Logf("Refreshing golden file %s", goldenFile)
+		if err := ioutil.WriteFile(goldenFile, []byte(content), 0644); err != nil {
+			t.Fatalf("Error writing golden file %s: %v", goldenFile, err)
+		}
+	}
+
+	golden, err := ioutil.ReadFile(goldenFile)
+	if err != nil {
+		t.Fatalf("Error reading golden file %s: %v", goldenFile, err)
+	}
+	if content != string(golden) {
+		diff, _ := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:        difflib.SplitLines(string(golden)),
+			B:        difflib.SplitLines(content),
+			FromFile: goldenFile,
+			ToFile:   "actual",
+			Context:  2,
+		})
+		t.Errorf("Messages from analyzer %s don't match %s (set REFRESH_GOLDEN=true to update it):\n%s",
+			a.Metadata().Name, goldenFile, diff)
+	}
+}
+
+// Render returns the messages one per line, sorted, as they would be printed by istioctl analyze. The position of
+// the resource in its file is left out, so that golden files don't change whenever a fixture is edited.
+func Render(msgs diag.Messages) string {
+	lines := make([]string, 0, len(msgs))
+	for _, m := range msgs {
+		origin := ""
+		if m.Resource != nil {
+			origin = " (" + m.Resource.Origin.FriendlyName() + ")"
+		}
+		lines = append(lines, fmt.Sprintf("%v [%v]%s %s\n",
+			m.Type.Level(), m.Type.Code(), origin, fmt.Sprintf(m.Type.Template(), m.Parameters...)))
+	}
+	sort.Strings(lines)
+	return strings.Join(lines, "")
+}
+
+// run runs a on c, and fails the test if the run fails or a reads collections it doesn't declare.
+func run(t testing.TB, a analysis.Analyzer, c Case) *Result {
+	t.Helper()
+
+	result, err := Run(a, c)
+	if err != nil {
+		t.Fatalf("Error running analyzer %s: %v", a.Metadata().Name, err)
+	}
+
+	declared := make(map[collection.Name]bool)
+	for _, col := range a.Metadata().Inputs {
+		declared[col] = true
+	}
+	for _, col := range result.Accessed {
+		if !declared[col] {
+			t.Errorf("Analyzer %s reads collection %s, which isn't one of its inputs", a.Metadata().Name, col)
+		}
+	}
+
+	return result
+}
+
+func sortMessages(msgs []Message) {
+	sort.Slice(msgs, func(i, j int) bool { return msgs[i].String() < msgs[j].String() })
+}
@@ -0,0 +1,107 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package analysistest
+
+import (
+	"testing"
+
+	"github.com/gogo/protobuf/types"
+	"github.com/onsi/gomega"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/virtualservice"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+var testCase = Case{Files: []string{"testdata/virtualservice.yaml"}}
+
+func TestRun(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	result, err := Run(&virtualservice.DestinationRuleAnalyzer{}, testCase)
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(Messages(result.Messages)).To(gomega.Equal([]Message{
+		{msg.ReferencedResourceNotFound, "VirtualService reviews-bogussubset.default"},
+	}))
+	g.Expect(result.Accessed).To(gomega.Equal(collection.Names{
+		collections.IstioNetworkingV1Alpha3Destinationrules.Name(),
+		collections.IstioNetworkingV1Alpha3Virtualservices.Name(),
+	}))
+}
+
+func TestRunMissingFile(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	_, err := Run(&virtualservice.DestinationRuleAnalyzer{}, Case{Files: []string{"testdata/bogus.yaml"}})
+	g.Expect(err).NotTo(gomega.BeNil())
+}
+
+var (
+	certificates = schema.CustomResource{Group: "cert-manager.io", Version: "v1", Kind: "Certificate"}
+	issuers      = schema.CustomResource{Group: "cert-manager.io", Version: "v1", Kind: "Issuer"}
+)
+
+// issuerAnalyzer checks that cert-manager Certificates reference an existing Issuer.
+type issuerAnalyzer struct{}
+
+func (a *issuerAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:   "test.IssuerAnalyzer",
+		Inputs: collection.Names{certificates.CollectionName(), issuers.CollectionName()},
+	}
+}
+
+func (a *issuerAnalyzer) Analyze(ctx analysis.Context) {
+	ctx.ForEach(certificates.CollectionName(), func(r *resource.Instance) bool {
+		ref := r.Message.(*types.Struct).GetFields()["issuerRef"].GetStructValue()
+		issuer := ref.GetFields()["name"].GetStringValue()
+		if !ctx.Exists(issuers.CollectionName(), resource.NewFullName(r.Metadata.FullName.Namespace, resource.LocalName(issuer))) {
+			ctx.Report(certificates.CollectionName(), msg.NewReferencedResourceNotFound(r, "issuer", issuer))
+		}
+		return true
+	})
+}
+
+func TestRunCustomResources(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	result, err := Run(&issuerAnalyzer{}, Case{
+		Files:           []string{"testdata/certificates.yaml"},
+		CustomResources: []schema.CustomResource{certificates, issuers},
+	})
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(Messages(result.Messages)).To(gomega.Equal([]Message{
+		{msg.ReferencedResourceNotFound, "Certificate ratings.default"},
+	}))
+
+	_, err = Run(&issuerAnalyzer{}, Case{
+		Files:           []string{"testdata/certificates.yaml"},
+		CustomResources: []schema.CustomResource{{Group: "networking.istio.io", Version: "v1alpha3", Kind: "Gateway"}},
+	})
+	g.Expect(err).NotTo(gomega.BeNil())
+}
+
+func TestExpect(t *testing.T) {
+	Expect(t, &virtualservice.DestinationRuleAnalyzer{}, testCase,
+		Message{msg.ReferencedResourceNotFound, "VirtualService reviews-bogussubset.default"})
+}
+
+func TestExpectGolden(t *testing.T) {
+	ExpectGolden(t, &virtualservice.DestinationRuleAnalyzer{}, testCase, "testdata/virtualservice.golden")
+}
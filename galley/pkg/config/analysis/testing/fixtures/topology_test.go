@@ -0,0 +1,134 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fixtures
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ghodss/yaml"
+	. "github.com/onsi/gomega"
+)
+
+type object struct {
+	Kind     string
+	Metadata struct {
+		Name      string
+		Namespace string
+	}
+}
+
+func parse(t *testing.T, docs string) map[string][]string {
+	t.Helper()
+
+	result := make(map[string][]string)
+	for _, doc := range strings.Split(docs, "\n---\n") {
+		if strings.TrimSpace(doc) == "" {
+			continue
+		}
+		var o object
+		if err := yaml.Unmarshal([]byte(doc), &o); err != nil {
+			t.Fatalf("invalid document: %v\n%s", err, doc)
+		}
+		name := o.Metadata.Name
+		if o.Metadata.Namespace != "" {
+			name = o.Metadata.Namespace + "/" + name
+		}
+		result[o.Kind] = append(result[o.Kind], name)
+	}
+	return result
+}
+
+func TestTopology(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	objects := parse(t, Topology{Namespaces: 2, Services: 3, Versions: 2, Replicas: 2, Gateways: 3}.Generate())
+
+	g.Expect(objects["ConfigMap"]).To(ConsistOf("istio-system/istio-sidecar-injector"))
+	g.Expect(objects["Namespace"]).To(ConsistOf("ns-0", "ns-1"))
+	g.Expect(objects["Service"]).To(HaveLen(2*3 + 3))
+	g.Expect(objects["Endpoints"]).To(HaveLen(2 * 3))
+	g.Expect(objects["DestinationRule"]).To(HaveLen(2 * 3))
+	g.Expect(objects["Deployment"]).To(HaveLen(2 * 3 * 2))
+	g.Expect(objects["Deployment"]).To(ContainElement("ns-1/svc-2-v2"))
+	g.Expect(objects["Pod"]).To(HaveLen(2*3*2*2 + 3))
+	g.Expect(objects["Pod"]).To(ContainElement("ns-1/svc-2-v2-1"))
+	g.Expect(objects["Secret"]).To(ConsistOf(
+		"istio-system/gateway-0-credential", "istio-system/gateway-1-credential", "istio-system/gateway-2-credential"))
+	g.Expect(objects["Gateway"]).To(ConsistOf("ns-0/gateway-0", "ns-1/gateway-1", "ns-0/gateway-2"))
+	g.Expect(objects["VirtualService"]).To(HaveLen(2*3 + 2*3))
+	g.Expect(objects["VirtualService"]).To(ContainElement("ns-1/svc-0-ingress"))
+}
+
+func TestTopologyDefaults(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	objects := parse(t, Topology{}.Generate())
+
+	g.Expect(objects["Namespace"]).To(ConsistOf("ns-0"))
+	g.Expect(objects["Pod"]).To(ConsistOf("ns-0/svc-0-v1-0"))
+	g.Expect(objects["VirtualService"]).To(ConsistOf("ns-0/svc-0"))
+	g.Expect(objects["Gateway"]).To(BeEmpty())
+}
+
+func TestTopologyWeights(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	for count := 1; count <= 7; count++ {
+		total := 0
+		for i := 0; i < count; i++ {
+			total += weight(i, count)
+		}
+		g.Expect(total).To(Equal(100))
+	}
+}
+
+func TestTopologyDefects(t *testing.T) {
+	topology := Topology{Namespaces: 2, Versions: 2, Gateways: 1}
+	clean := topology.Generate()
+
+	cases := []struct {
+		defect   Defect
+		contains string
+		kind     string
+	}{
+		{DefectMissingDestinationHost, "host: svc-0-missing", ""},
+		{DefectMissingSubset, "", ""},
+		{DefectMissingGateway, "- gateway-0-missing", ""},
+		{DefectMissingGatewaySecret, "", "Secret"},
+		{DefectUnnamedServicePort, `name: ""`, ""},
+		{DefectUninjectedNamespace, "", ""},
+		{DefectMissingProxy, "", ""},
+		{DefectProxyImageMismatch, StaleProxyImage, ""},
+	}
+	for _, c := range cases {
+		c := c
+		t.Run(string(c.defect), func(t *testing.T) {
+			g := NewGomegaWithT(t)
+
+			topology.Defects = []Defect{c.defect}
+			docs := topology.Generate()
+
+			g.Expect(docs).NotTo(Equal(clean))
+			if c.contains != "" {
+				g.Expect(docs).To(ContainSubstring(c.contains))
+				g.Expect(clean).NotTo(ContainSubstring(c.contains))
+			}
+			if c.kind != "" {
+				g.Expect(parse(t, docs)[c.kind]).To(HaveLen(len(parse(t, clean)[c.kind]) - 1))
+			}
+		})
+	}
+}
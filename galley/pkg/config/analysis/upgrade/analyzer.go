@@ -0,0 +1,196 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package upgrade
+
+import (
+	"fmt"
+	"strings"
+
+	goversion "github.com/hashicorp/go-version"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/galley/pkg/config/scope"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
+	"istio.io/istio/pkg/util/gogoprotomarshal"
+)
+
+// Analyzer reports configuration that is affected by the incompatible changes up to and including a target Istio
+// version.
+type Analyzer struct {
+	target  string
+	changes []Change
+	inputs  collection.Names
+}
+
+var _ analysis.Analyzer = &Analyzer{}
+
+// NewAnalyzer returns an analyzer checking configuration against the given target Istio version, e.g. "1.8". Only
+// the changes to the available collections are checked, so that the analyzer is not skipped for lack of inputs. If
+// available is nil, all changes are checked.
+func NewAnalyzer(target string, available collection.Names) (*Analyzer, error) {
+	return newAnalyzer(target, Changes, available)
+}
+
+func newAnalyzer(target string, changes []Change, available collection.Names) (*Analyzer, error) {
+	t, err := goversion.NewVersion(target)
+	if err != nil {
+		return nil, fmt.Errorf("invalid target Istio version %q: %v", target, err)
+	}
+
+	var availableCols map[collection.Name]bool
+	if available != nil {
+		availableCols = make(map[collection.Name]bool, len(available))
+		for _, col := range available {
+			availableCols[col] = true
+		}
+	}
+
+	a := &Analyzer{target: target}
+	seen := make(map[collection.Name]bool)
+	for _, c := range changes {
+		v, err := goversion.NewVersion(c.Version)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version %q of change to %s: %v", c.Version, c.Collection, err)
+		}
+		if compareSegments(v.Segments(), t.Segments()) > 0 {
+			continue
+		}
+		if availableCols != nil && !availableCols[c.Collection] {
+			continue
+		}
+
+		a.changes = append(a.changes, c)
+		if !seen[c.Collection] {
+			seen[c.Collection] = true
+			a.inputs = append(a.inputs, c.Collection)
+		}
+	}
+
+	return a, nil
+}
+
+// compareSegments compares versions by their major, minor and patch numbers only, so that pre-releases of a target
+// version (e.g. 1.8-dev) include the changes of that version.
+func compareSegments(a, b []int) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		switch {
+		case a[i] < b[i]:
+			return -1
+		case a[i] > b[i]:
+			return 1
+		}
+	}
+	return 0
+}
+
+// Metadata implements analysis.Analyzer
+func (a *Analyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:        "upgrade.Analyzer",
+		Description: "Checks for configuration that is incompatible with the Istio version to upgrade to",
+		Inputs:      a.inputs,
+	}
+}
+
+// Target returns the Istio version that configuration is checked against.
+func (a *Analyzer) Target() string {
+	return a.target
+}
+
+// Analyze implements analysis.Analyzer
+func (a *Analyzer) Analyze(ctx analysis.Context) {
+	for _, c := range a.changes {
+		c := c
+		ctx.ForEach(c.Collection, func(r *resource.Instance) bool {
+			a.analyzeResource(ctx, c, r)
+			return true
+		})
+	}
+}
+
+func (a *Analyzer) analyzeResource(ctx analysis.Context, c Change, r *resource.Instance) {
+	if c.Kind == RemovedAPI {
+		ctx.Report(c.Collection, msg.NewUpgradeRemovedAPI(r, apiName(c.Collection), c.Version, c.Replacement))
+		return
+	}
+
+	if r.Message == nil {
+		return
+	}
+	spec, err := gogoprotomarshal.ToJSONMap(r.Message)
+	if err != nil {
+		scope.Analysis.Errorf("upgrade.Analyzer: error converting %s %v: %v", c.Collection, r.Metadata.FullName, err)
+		return
+	}
+	values := lookup(spec, strings.Split(c.Field, "."))
+
+	switch c.Kind {
+	case RenamedField:
+		if len(values) > 0 {
+			ctx.Report(c.Collection, msg.NewUpgradeRenamedField(r, c.Field, c.Replacement, c.Version))
+		}
+
+	case ChangedDefault:
+		// Defaults may already have been applied, e.g. to mesh config, so the previous default value counts as unset.
+		relies := len(values) == 0
+		for _, v := range values {
+			if fmt.Sprint(v) == c.OldDefault {
+				relies = true
+			}
+		}
+		if relies {
+			ctx.Report(c.Collection, msg.NewUpgradeChangedDefault(r, c.Field, c.OldDefault, c.NewDefault, c.Version))
+		}
+	}
+}
+
+// lookup returns the values at the given path, traversing lists along the way.
+func lookup(v interface{}, path []string) []interface{} {
+	switch t := v.(type) {
+	case []interface{}:
+		var result []interface{}
+		for _, e := range t {
+			result = append(result, lookup(e, path)...)
+		}
+		return result
+
+	case map[string]interface{}:
+		if len(path) == 0 {
+			return []interface{}{t}
+		}
+		child, ok := t[path[0]]
+		if !ok {
+			return nil
+		}
+		return lookup(child, path[1:])
+
+	default:
+		if len(path) > 0 {
+			return nil
+		}
+		return []interface{}{t}
+	}
+}
+
+func apiName(c collection.Name) string {
+	s, ok := collections.All.Find(c.String())
+	if !ok {
+		return c.String()
+	}
+	return fmt.Sprintf("%s (%s)", s.Resource().Kind(), s.Resource().APIVersion())
+}
@@ -0,0 +1,204 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package upgrade
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/types"
+	. "github.com/onsi/gomega"
+
+	"istio.io/api/networking/v1alpha3"
+	"istio.io/api/policy/v1beta1"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/mesh"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+type testContext struct {
+	resources map[collection.Name][]*resource.Instance
+	reports   []diag.Message
+}
+
+var _ analysis.Context = &testContext{}
+
+// Report implements analysis.Context
+func (ctx *testContext) Report(_ collection.Name, m diag.Message) {
+	ctx.reports = append(ctx.reports, m)
+}
+
+// Find implements analysis.Context
+func (ctx *testContext) Find(collection.Name, resource.FullName) *resource.Instance {
+	return nil
+}
+
+// Exists implements analysis.Context
+func (ctx *testContext) Exists(collection.Name, resource.FullName) bool {
+	return false
+}
+
+// ForEach implements analysis.Context
+func (ctx *testContext) ForEach(col collection.Name, fn analysis.IteratorFn) {
+	for _, r := range ctx.resources[col] {
+		if !fn(r) {
+			return
+		}
+	}
+}
+
+// Canceled implements analysis.Context
+func (ctx *testContext) Canceled() bool {
+	return false
+}
+
+func newInstance(name string, m proto.Message) *resource.Instance {
+	return &resource.Instance{
+		Metadata: resource.Metadata{FullName: resource.NewFullName("ns", resource.LocalName(name))},
+		Message:  m,
+	}
+}
+
+func codes(reports []diag.Message) []string {
+	var result []string
+	for _, m := range reports {
+		result = append(result, m.Type.Code()+" "+m.Resource.Metadata.FullName.Name.String())
+	}
+	return result
+}
+
+func TestAnalyzer(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	vsCol := collections.IstioNetworkingV1Alpha3Virtualservices.Name()
+	meshCol := collections.IstioMeshV1Alpha1MeshConfig.Name()
+	ruleCol := collections.IstioPolicyV1Beta1Rules.Name()
+
+	defaults := mesh.DefaultMeshConfig()
+	defaults.ProtocolDetectionTimeout = types.DurationProto(100 * time.Millisecond)
+	explicit := mesh.DefaultMeshConfig()
+	explicit.ProtocolDetectionTimeout = types.DurationProto(time.Second)
+
+	ctx := &testContext{resources: map[collection.Name][]*resource.Instance{
+		vsCol: {
+			newInstance("mirrored", &v1alpha3.VirtualService{Http: []*v1alpha3.HTTPRoute{
+				{},
+				{MirrorPercent: &types.UInt32Value{Value: 50}},
+			}}),
+			newInstance("current", &v1alpha3.VirtualService{Http: []*v1alpha3.HTTPRoute{
+				{MirrorPercentage: &v1alpha3.Percent{Value: 50}},
+			}}),
+		},
+		meshCol: {
+			newInstance("defaults", &defaults),
+			newInstance("explicit", &explicit),
+		},
+		ruleCol: {
+			newInstance("rule", &v1beta1.Rule{}),
+		},
+	}}
+
+	a, err := NewAnalyzer("1.8", nil)
+	g.Expect(err).To(BeNil())
+	g.Expect(a.Target()).To(Equal("1.8"))
+	g.Expect(a.Metadata().Inputs).To(ContainElement(vsCol))
+	g.Expect(a.Metadata().Inputs).To(ContainElement(meshCol))
+	g.Expect(a.Metadata().Inputs).To(ContainElement(ruleCol))
+
+	a.Analyze(ctx)
+	g.Expect(codes(ctx.reports)).To(ConsistOf(
+		msg.UpgradeRenamedField.Code()+" mirrored",
+		msg.UpgradeChangedDefault.Code()+" defaults",
+		msg.UpgradeRemovedAPI.Code()+" rule",
+	))
+	for _, m := range ctx.reports {
+		if m.Type == msg.UpgradeRemovedAPI {
+			g.Expect(m.Parameters[0]).To(Equal("rule (config.istio.io/v1alpha2)"))
+		}
+	}
+
+	// Only the changes up to the target version apply
+	ctx.reports = nil
+	a, err = NewAnalyzer("1.7-dev", nil)
+	g.Expect(err).To(BeNil())
+	g.Expect(a.Metadata().Inputs).To(ConsistOf(vsCol))
+	a.Analyze(ctx)
+	g.Expect(codes(ctx.reports)).To(ConsistOf(msg.UpgradeRenamedField.Code() + " mirrored"))
+
+	// Changes to unavailable collections are not checked
+	ctx.reports = nil
+	a, err = NewAnalyzer("1.8", collection.Names{vsCol, meshCol})
+	g.Expect(err).To(BeNil())
+	g.Expect(a.Metadata().Inputs).To(ConsistOf(vsCol, meshCol))
+	a.Analyze(ctx)
+	g.Expect(codes(ctx.reports)).To(ConsistOf(
+		msg.UpgradeRenamedField.Code()+" mirrored",
+		msg.UpgradeChangedDefault.Code()+" defaults",
+	))
+
+	ctx.reports = nil
+	a, err = NewAnalyzer("1.5", nil)
+	g.Expect(err).To(BeNil())
+	a.Analyze(ctx)
+	g.Expect(ctx.reports).To(BeEmpty())
+}
+
+func TestNewAnalyzerErrors(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	_, err := NewAnalyzer("latest", nil)
+	g.Expect(err).To(MatchError(ContainSubstring(`invalid target Istio version "latest"`)))
+
+	_, err = newAnalyzer("1.8", []Change{{Version: "next"}}, nil)
+	g.Expect(err).To(MatchError(ContainSubstring(`invalid version "next"`)))
+}
+
+func TestChangesAreValid(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	for _, c := range Changes {
+		_, ok := collections.All.Find(c.Collection.String())
+		g.Expect(ok).To(BeTrue(), "unknown collection %s", c.Collection)
+		if c.Kind != RemovedAPI {
+			g.Expect(c.Field).NotTo(BeEmpty(), "change to %s", c.Collection)
+		}
+	}
+
+	_, err := NewAnalyzer("1.8", nil)
+	g.Expect(err).To(BeNil())
+}
+
+func TestLookup(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	doc := map[string]interface{}{
+		"http": []interface{}{
+			map[string]interface{}{"mirrorPercent": 50.0},
+			map[string]interface{}{},
+			map[string]interface{}{"mirrorPercent": 10.0},
+		},
+		"name": "a",
+	}
+	g.Expect(lookup(doc, []string{"http", "mirrorPercent"})).To(Equal([]interface{}{50.0, 10.0}))
+	g.Expect(lookup(doc, []string{"name"})).To(Equal([]interface{}{"a"}))
+	g.Expect(lookup(doc, []string{"name", "first"})).To(BeEmpty())
+	g.Expect(lookup(doc, []string{"missing"})).To(BeEmpty())
+}
@@ -0,0 +1,100 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package upgrade describes the incompatible changes between Istio releases, and checks configuration against them
+// to find upgrade blockers before upgrading to a target version.
+package upgrade
+
+import (
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+// ChangeKind is the kind of an incompatible change in an Istio release.
+type ChangeKind int
+
+const (
+	// RemovedAPI means that resources of a collection are no longer processed.
+	RemovedAPI ChangeKind = iota
+
+	// RenamedField means that a field is replaced by another one, and is no longer honored.
+	RenamedField
+
+	// ChangedDefault means that the default value of a field changes, so configuration relying on the previous
+	// default behaves differently.
+	ChangedDefault
+)
+
+// Change is an incompatible change that takes effect in an Istio release.
+type Change struct {
+	Kind ChangeKind
+
+	// Version is the Istio release the change takes effect in, e.g. "1.8".
+	Version string
+
+	// Collection is the collection of the affected resources.
+	Collection collection.Name
+
+	// Field is the path of the affected field within the resource spec, as dot separated JSON field names. Lists
+	// along the path are traversed implicitly. Not used for RemovedAPI.
+	Field string
+
+	// Replacement is the field replacing a RenamedField, or a hint on how to migrate off a RemovedAPI.
+	Replacement string
+
+	// OldDefault and NewDefault are the JSON values of a ChangedDefault before and after the change.
+	OldDefault string
+	NewDefault string
+}
+
+const mixerRemovedHint = "Mixer is removed; use telemetry v2 and Envoy based extensions instead"
+
+// Changes lists the incompatible changes of Istio releases that can be detected in configuration. Please keep this
+// list sorted by version.
+var Changes = []Change{
+	{
+		Kind:        RenamedField,
+		Version:     "1.6",
+		Collection:  collections.IstioNetworkingV1Alpha3Virtualservices.Name(),
+		Field:       "http.mirrorPercent",
+		Replacement: "http.mirrorPercentage",
+	},
+	{
+		Kind:       ChangedDefault,
+		Version:    "1.8",
+		Collection: collections.IstioMeshV1Alpha1MeshConfig.Name(),
+		Field:      "protocolDetectionTimeout",
+		OldDefault: "0.100s",
+		NewDefault: "0s",
+	},
+	mixerRemoved(collections.IstioConfigV1Alpha2Adapters),
+	mixerRemoved(collections.IstioConfigV1Alpha2Httpapispecbindings),
+	mixerRemoved(collections.IstioConfigV1Alpha2Httpapispecs),
+	mixerRemoved(collections.IstioConfigV1Alpha2Templates),
+	mixerRemoved(collections.IstioMixerV1ConfigClientQuotaspecbindings),
+	mixerRemoved(collections.IstioMixerV1ConfigClientQuotaspecs),
+	mixerRemoved(collections.IstioPolicyV1Beta1Attributemanifests),
+	mixerRemoved(collections.IstioPolicyV1Beta1Handlers),
+	mixerRemoved(collections.IstioPolicyV1Beta1Instances),
+	mixerRemoved(collections.IstioPolicyV1Beta1Rules),
+}
+
+func mixerRemoved(s collection.Schema) Change {
+	return Change{
+		Kind:        RemovedAPI,
+		Version:     "1.8",
+		Collection:  s.Name(),
+		Replacement: mixerRemovedHint,
+	}
+}
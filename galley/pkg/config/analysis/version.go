@@ -0,0 +1,139 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package analysis
+
+import (
+	"fmt"
+
+	goversion "github.com/hashicorp/go-version"
+
+	"istio.io/istio/galley/pkg/config/scope"
+)
+
+// AppliesTo returns whether an analyzer with this metadata applies to the given Istio control plane version.
+// Pre-release and build suffixes of the version (e.g. 1.6.0-beta.1 or 1.7-dev) are ignored.
+func (m Metadata) AppliesTo(version string) (bool, error) {
+	v, err := goversion.NewVersion(version)
+	if err != nil {
+		return false, fmt.Errorf("invalid Istio version %q: %v", version, err)
+	}
+
+	if m.MinVersion != "" {
+		min, err := parseBound(m.MinVersion)
+		if err != nil {
+			return false, err
+		}
+		if compareSegments(v.Segments(), min) < 0 {
+			return false, nil
+		}
+	}
+
+	if m.MaxVersion != "" {
+		max, err := parseBound(m.MaxVersion)
+		if err != nil {
+			return false, err
+		}
+		if compareSegments(v.Segments(), max) > 0 {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// CompareVersion compares an Istio control plane version with a version bound such as "1.6", considering only the
+// segments given in the bound. It returns -1, 0 or 1 if the version is before, within or after the bound, e.g. 1.6.3 is
+// within 1.6. Pre-release and build suffixes of the version are ignored.
+func CompareVersion(version, bound string) (int, error) {
+	v, err := goversion.NewVersion(version)
+	if err != nil {
+		return 0, fmt.Errorf("invalid Istio version %q: %v", version, err)
+	}
+	b, err := parseBound(bound)
+	if err != nil {
+		return 0, err
+	}
+	return compareSegments(v.Segments(), b), nil
+}
+
+// parseBound returns the segments of a version bound, as many as were specified.
+func parseBound(bound string) ([]int, error) {
+	v, err := goversion.NewVersion(bound)
+	if err != nil || v.Prerelease() != "" || v.Metadata() != "" {
+		return nil, fmt.Errorf("invalid version bound %q", bound)
+	}
+
+	// go-version pads the segments to major.minor.patch, so count the ones that were actually given.
+	n := 1
+	for _, c := range bound {
+		if c == '.' {
+			n++
+		}
+	}
+	segments := v.Segments()
+	if n < len(segments) {
+		segments = segments[:n]
+	}
+	return segments, nil
+}
+
+// compareSegments compares the leading segments of a version with those of a bound.
+func compareSegments(version, bound []int) int {
+	for i, b := range bound {
+		var s int
+		if i < len(version) {
+			s = version[i]
+		}
+		switch {
+		case s < b:
+			return -1
+		case s > b:
+			return 1
+		}
+	}
+	return 0
+}
+
+// RemoveInapplicable removes analyzers that do not apply to the given Istio control plane version, and returns the
+// names of the removed ones. If the version is unknown (empty) or cannot be parsed, all analyzers are kept.
+func (c *CombinedAnalyzer) RemoveInapplicable(version string) []string {
+	if version == "" {
+		return nil
+	}
+	if _, err := goversion.NewVersion(version); err != nil {
+		scope.Analysis.Warnf("Not checking analyzer version bounds, as Istio version %q cannot be parsed: %v", version, err)
+		return nil
+	}
+
+	var enabled []Analyzer
+	var removedNames []string
+	for _, a := range c.analyzers {
+		m := a.Metadata()
+		applies, err := m.AppliesTo(version)
+		if err != nil {
+			scope.Analysis.Errorf("Running analyzer %q regardless of its version bounds: %v", m.Name, err)
+			applies = true
+		}
+		if !applies {
+			scope.Analysis.Infof("Skipping analyzer %q because it does not apply to Istio %s.", m.Name, version)
+			removedNames = append(removedNames, m.Name)
+			continue
+		}
+		enabled = append(enabled, a)
+	}
+
+	c.analyzers = enabled
+	return removedNames
+}
@@ -0,0 +1,127 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package analysis
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+type versionedAnalyzer struct {
+	analyzer
+	min, max string
+}
+
+// Metadata implements Analyzer
+func (a *versionedAnalyzer) Metadata() Metadata {
+	m := a.analyzer.Metadata()
+	m.MinVersion = a.min
+	m.MaxVersion = a.max
+	return m
+}
+
+func TestCompareVersion(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	cases := []struct {
+		version, bound string
+		expected       int
+	}{
+		{version: "1.5.9", bound: "1.6", expected: -1},
+		{version: "1.6.3", bound: "1.6", expected: 0},
+		{version: "1.6.0-beta.1", bound: "1.6.0", expected: 0},
+		{version: "1.7.0", bound: "1.6", expected: 1},
+		{version: "1.6.3", bound: "1.6.2", expected: 1},
+	}
+	for _, c := range cases {
+		result, err := CompareVersion(c.version, c.bound)
+		g.Expect(err).To(BeNil())
+		g.Expect(result).To(Equal(c.expected), "%s against %s", c.version, c.bound)
+	}
+
+	_, err := CompareVersion("latest", "1.6")
+	g.Expect(err).NotTo(BeNil())
+	_, err = CompareVersion("1.6.0", "1.6-alpha")
+	g.Expect(err).NotTo(BeNil())
+}
+
+func TestMetadataAppliesTo(t *testing.T) {
+	cases := []struct {
+		min, max string
+		version  string
+		applies  bool
+		err      bool
+	}{
+		{version: "1.6.0", applies: true},
+		{min: "1.5", version: "1.5.0", applies: true},
+		{min: "1.5", version: "1.4.9", applies: false},
+		{min: "1.5.2", version: "1.5.1", applies: false},
+		{min: "1.5.2", version: "1.5.2", applies: true},
+		{max: "1.5", version: "1.5.9", applies: true},
+		{max: "1.5", version: "1.6.0", applies: false},
+		{max: "1.5.2", version: "1.5.3", applies: false},
+		{min: "1.4", max: "1.5", version: "1.4.6", applies: true},
+		{min: "1.7", version: "1.7-dev", applies: true},
+		{min: "1.7", version: "1.7.0-beta.1", applies: true},
+		{max: "1", version: "1.9.0", applies: true},
+		{max: "1", version: "2.0.0", applies: false},
+		{version: "latest", err: true},
+		{min: "1.5-alpha", version: "1.5.0", err: true},
+		{max: "one", version: "1.5.0", err: true},
+	}
+	for _, c := range cases {
+		c := c
+		t.Run(c.min+"<="+c.version+"<="+c.max, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+
+			m := Metadata{MinVersion: c.min, MaxVersion: c.max}
+			applies, err := m.AppliesTo(c.version)
+			if c.err {
+				g.Expect(err).NotTo(BeNil())
+				return
+			}
+			g.Expect(err).To(BeNil())
+			g.Expect(applies).To(Equal(c.applies))
+		})
+	}
+}
+
+func TestCombinedAnalyzerRemoveInapplicable(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	newAnalyzers := func() *CombinedAnalyzer {
+		return Combine("combined",
+			&versionedAnalyzer{analyzer: analyzer{name: "any"}},
+			&versionedAnalyzer{analyzer: analyzer{name: "old"}, max: "1.4"},
+			&versionedAnalyzer{analyzer: analyzer{name: "new"}, min: "1.6"},
+			&versionedAnalyzer{analyzer: analyzer{name: "broken"}, min: "soon"})
+	}
+
+	a := newAnalyzers()
+	g.Expect(a.RemoveInapplicable("1.5.2")).To(ConsistOf("old", "new"))
+	g.Expect(a.AnalyzerNames()).To(Equal([]string{"any", "broken"}))
+
+	a = newAnalyzers()
+	g.Expect(a.RemoveInapplicable("1.6-dev")).To(ConsistOf("old"))
+	g.Expect(a.AnalyzerNames()).To(Equal([]string{"any", "new", "broken"}))
+
+	// Unknown versions don't gate analyzers
+	for _, version := range []string{"", "latest"} {
+		a = newAnalyzers()
+		g.Expect(a.RemoveInapplicable(version)).To(BeEmpty())
+		g.Expect(a.AnalyzerNames()).To(HaveLen(4))
+	}
+}
@@ -15,6 +15,7 @@
 package collection
 
 import (
+	"fmt"
 	"sync"
 
 	"istio.io/istio/pkg/config/resource"
@@ -31,6 +32,7 @@ type Instance struct {
 	generation  int64
 	resources   map[resource.FullName]*resource.Instance
 	copyOnWrite bool
+	frozen      bool
 }
 
 // New returns a new collection.Instance
@@ -87,6 +89,7 @@ func (c *Instance) ForEach(fn func(e *resource.Instance) bool) {
 func (c *Instance) Set(r *resource.Instance) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	c.checkMutable()
 	c.doCopyOnWrite()
 	c.generation++
 	c.resources[r.Metadata.FullName] = r
@@ -96,6 +99,7 @@ func (c *Instance) Set(r *resource.Instance) {
 func (c *Instance) Remove(n resource.FullName) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	c.checkMutable()
 	c.doCopyOnWrite()
 	c.generation++
 	delete(c.resources, n)
@@ -105,11 +109,33 @@ func (c *Instance) Remove(n resource.FullName) {
 func (c *Instance) Clear() {
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	c.checkMutable()
 	c.doCopyOnWrite()
 	c.generation++
 	c.resources = make(map[resource.FullName]*resource.Instance)
 }
 
+// Freeze the instance, making it immutable. Any attempt to modify a frozen instance panics, which ensures that it can
+// be safely shared across goroutines, e.g. as part of a snapshot. Clones of a frozen instance are not frozen.
+func (c *Instance) Freeze() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.frozen = true
+}
+
+// Frozen returns true if the instance has been frozen.
+func (c *Instance) Frozen() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.frozen
+}
+
+func (c *Instance) checkMutable() {
+	if c.frozen {
+		panic(fmt.Sprintf("collection %v is frozen and cannot be modified", c.schema.Name()))
+	}
+}
+
 func (c *Instance) doCopyOnWrite() { // TODO: we should optimize copy-on write.
 	if !c.copyOnWrite {
 		return
@@ -15,6 +15,7 @@
 package collection_test
 
 import (
+	"sync"
 	"testing"
 
 	. "github.com/onsi/gomega"
@@ -154,3 +155,55 @@ func TestInstance_Get(t *testing.T) {
 	e = inst.Get(data.EntryN2I2V2.Metadata.FullName)
 	g.Expect(e).To(BeNil())
 }
+
+func TestInstance_Freeze(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	inst := collection.New(basicmeta.K8SCollection1)
+	inst.Set(data.EntryN1I1V1)
+	g.Expect(inst.Frozen()).To(BeFalse())
+
+	inst.Freeze()
+	g.Expect(inst.Frozen()).To(BeTrue())
+
+	g.Expect(func() { inst.Set(data.EntryN2I2V2) }).To(Panic())
+	g.Expect(func() { inst.Remove(data.EntryN1I1V1.Metadata.FullName) }).To(Panic())
+	g.Expect(func() { inst.Clear() }).To(Panic())
+	g.Expect(inst.Size()).To(Equal(1))
+	g.Expect(inst.Generation()).To(Equal(int64(1)))
+
+	// Clones of a frozen instance can be modified, without affecting the original.
+	inst2 := inst.Clone()
+	g.Expect(inst2.Frozen()).To(BeFalse())
+	inst2.Set(data.EntryN2I2V2)
+	g.Expect(inst2.Size()).To(Equal(2))
+	g.Expect(inst.Size()).To(Equal(1))
+}
+
+// Clones are handed to other goroutines while the original keeps changing. Run with -race.
+func TestInstance_ConcurrentCloneAndModify(t *testing.T) {
+	inst := collection.New(basicmeta.K8SCollection1)
+	inst.Set(data.EntryN1I1V1)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		c := inst.Clone()
+		c.Freeze()
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				c.ForEach(func(r *resource.Instance) bool {
+					_ = r.Metadata.FullName
+					return true
+				})
+				_ = c.Get(data.EntryN2I2V2.Metadata.FullName)
+			}
+		}()
+
+		inst.Set(data.EntryN2I2V2)
+		inst.Remove(data.EntryN2I2V2.Metadata.FullName)
+	}
+	wg.Wait()
+}
@@ -68,6 +68,13 @@ func (s *Set) Clone() *Set {
 	}
 }
 
+// Freeze all collections in the set. See Instance.Freeze.
+func (s *Set) Freeze() {
+	for _, c := range s.collections {
+		c.Freeze()
+	}
+}
+
 // Names of the collections in the set.
 func (s *Set) Names() collection.Names {
 	result := make([]collection.Name, 0, len(s.collections))
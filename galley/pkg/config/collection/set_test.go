@@ -78,6 +78,20 @@ func TestSet_Clone(t *testing.T) {
 	g.Expect(c).To(BeNil())
 }
 
+func TestSet_Freeze(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	s1 := coll.New(basicmeta.K8SCollection1)
+	s2 := coll.New(basicmeta.Collection2)
+
+	s := coll.NewSetFromCollections([]*coll.Instance{s1, s2})
+	s.Freeze()
+
+	g.Expect(s1.Frozen()).To(BeTrue())
+	g.Expect(s2.Frozen()).To(BeTrue())
+	g.Expect(s.Clone().Collection(basicmeta.K8SCollection1.Name()).Frozen()).To(BeFalse())
+}
+
 func TestSet_Names(t *testing.T) {
 	g := NewGomegaWithT(t)
 
@@ -25,6 +25,8 @@ import (
 	"go.opencensus.io/stats/view"
 	"go.opencensus.io/tag"
 
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+	"istio.io/istio/galley/pkg/config/analysis/score"
 	"istio.io/istio/galley/pkg/config/scope"
 )
 
@@ -33,6 +35,9 @@ const (
 	namespace  = "namespace"
 	name       = "name"
 	version    = "version"
+	code       = "code"
+	level      = "level"
+	analyzer   = "analyzer"
 )
 
 var (
@@ -46,6 +51,12 @@ var (
 	VersionTag tag.Key
 	// StateTypeConfigKeys holds key tags for runtime state metrics.
 	StateTypeConfigKeys []tag.Key
+	// CodeTag holds the code of an analysis message for the context.
+	CodeTag tag.Key
+	// LevelTag holds the level of an analysis message for the context.
+	LevelTag tag.Key
+	// AnalyzerTag holds the name of an analyzer for the context.
+	AnalyzerTag tag.Key
 )
 
 var (
@@ -89,12 +100,56 @@ var (
 		"galley/runtime/state/type_instances_total",
 		"The number of type instances per type URL",
 		stats.UnitDimensionless)
+	analysisMessages = stats.Int64(
+		"galley/analysis/messages",
+		"The number of messages reported by the last config analysis run, per message code and level",
+		stats.UnitDimensionless)
+	analysisHealthScore = stats.Float64(
+		"galley/analysis/health_score",
+		"The config health score computed from the findings of the last config analysis run, per namespace. The "+
+			"mesh-wide score has an empty namespace.",
+		stats.UnitDimensionless)
+	analyzerDurationMs = stats.Int64(
+		"galley/analysis/analyzer_duration_milliseconds",
+		"The time spent in each analyzer per config analysis run",
+		stats.UnitMilliseconds)
+	analyzerMessages = stats.Int64(
+		"galley/analysis/analyzer_messages",
+		"The number of messages reported by each analyzer in the last config analysis run",
+		stats.UnitDimensionless)
+	findingsIntroduced = stats.Int64(
+		"galley/analysis/findings_introduced_total",
+		"The number of config analysis findings that first appeared, per message code",
+		stats.UnitDimensionless)
+	findingsResolved = stats.Int64(
+		"galley/analysis/findings_resolved_total",
+		"The number of config analysis findings that were resolved, per message code",
+		stats.UnitDimensionless)
+	oldestFindingAge = stats.Float64(
+		"galley/analysis/oldest_finding_age_seconds",
+		"The time since the oldest open config analysis finding first appeared, per message code",
+		"s")
 
 	durationDistributionMs = view.Distribution(0, 1, 2, 4, 8, 16, 32, 64, 128, 256, 512, 1024, 2048, 4096, 8193, 16384, 32768, 65536,
 		131072, 262144, 524288, 1048576, 2097152, 4194304, 8388608)
 
 	stateTypeConfigTotal     map[string]*stats.Int64Measure
 	stateTypeCollectionMutex sync.RWMutex
+
+	// analysisMessageKeys holds the code/level combinations reported so far, so that they can be reset to zero once
+	// the messages are resolved.
+	analysisMessageKeys      = make(map[[2]string]struct{})
+	analysisMessageKeysMutex sync.Mutex
+
+	// analysisScoreNamespaces holds the namespaces that had findings so far, so that their score can be reset once
+	// the findings are resolved.
+	analysisScoreNamespaces      = make(map[string]struct{})
+	analysisScoreNamespacesMutex sync.Mutex
+
+	// findingsHistoryCodes holds the codes that had open findings so far, so that the age of their oldest finding can
+	// be reset to zero once the findings are resolved.
+	findingsHistoryCodes      = make(map[string]struct{})
+	findingsHistoryCodesMutex sync.Mutex
 )
 
 // RecordStrategyOnChange event
@@ -184,6 +239,105 @@ func RecordDetailedStateTypeWithContext(ctx context.Context, collection string,
 	stats.Record(ctx, stateTypeConfigTotal[collection].M(int64(count)))
 }
 
+// RecordAnalysisMessages records the number of messages of the last analysis run, per message code and level.
+// Combinations reported by an earlier run that no longer occur are recorded as zero.
+func RecordAnalysisMessages(msgs diag.Messages) {
+	counts := make(map[[2]string]int64)
+	for _, m := range msgs {
+		counts[[2]string{m.Type.Code(), m.Type.Level().String()}]++
+	}
+
+	analysisMessageKeysMutex.Lock()
+	defer analysisMessageKeysMutex.Unlock()
+	for k := range analysisMessageKeys {
+		if _, ok := counts[k]; !ok {
+			counts[k] = 0
+		}
+	}
+	for k, count := range counts {
+		ctx, err := tag.New(context.Background(), tag.Insert(CodeTag, k[0]), tag.Insert(LevelTag, k[1]))
+		if err != nil {
+			scope.Analysis.Errorf("error creating monitoring context for analysis messages: %v", err)
+			continue
+		}
+		stats.Record(ctx, analysisMessages.M(count))
+		analysisMessageKeys[k] = struct{}{}
+	}
+}
+
+// RecordHealthScores records the config health scores of the last analysis run. Namespaces that had findings in an
+// earlier run but no longer do are recorded with score.Max.
+func RecordHealthScores(s score.Summary) {
+	values := map[string]float64{"": s.Mesh.Value}
+	for _, ns := range s.Namespaces {
+		values[ns.Namespace] = ns.Value
+	}
+
+	analysisScoreNamespacesMutex.Lock()
+	defer analysisScoreNamespacesMutex.Unlock()
+	for ns := range analysisScoreNamespaces {
+		if _, ok := values[ns]; !ok {
+			values[ns] = score.Max
+		}
+	}
+	for ns, v := range values {
+		ctx := context.Background()
+		if ns != "" {
+			var err error
+			if ctx, err = tag.New(ctx, tag.Insert(NamespaceTag, ns)); err != nil {
+				scope.Analysis.Errorf("error creating monitoring context for config health score: %v", err)
+				continue
+			}
+			analysisScoreNamespaces[ns] = struct{}{}
+		}
+		stats.Record(ctx, analysisHealthScore.M(v))
+	}
+}
+
+// RecordFindingsHistory records, per message code, the number of findings that first appeared and that were resolved
+// since the last call, and the age of the oldest open finding. Codes that had open findings in an earlier call but no
+// longer do are recorded with an age of zero.
+func RecordFindingsHistory(introduced, resolved map[string]int, oldestOpen map[string]time.Duration) {
+	record := func(code string, ms ...stats.Measurement) {
+		ctx, err := tag.New(context.Background(), tag.Insert(CodeTag, code))
+		if err != nil {
+			scope.Analysis.Errorf("error creating monitoring context for analysis findings history: %v", err)
+			return
+		}
+		stats.Record(ctx, ms...)
+	}
+
+	for code, n := range introduced {
+		record(code, findingsIntroduced.M(int64(n)))
+	}
+	for code, n := range resolved {
+		record(code, findingsResolved.M(int64(n)))
+	}
+
+	findingsHistoryCodesMutex.Lock()
+	defer findingsHistoryCodesMutex.Unlock()
+	for code := range findingsHistoryCodes {
+		if _, ok := oldestOpen[code]; !ok {
+			record(code, oldestFindingAge.M(0))
+			delete(findingsHistoryCodes, code)
+		}
+	}
+	for code, age := range oldestOpen {
+		record(code, oldestFindingAge.M(age.Seconds()))
+		findingsHistoryCodes[code] = struct{}{}
+	}
+}
+
+// RecordAnalyzerRun records the time spent in an analyzer and the number of messages it reported.
+func RecordAnalyzerRun(name string, duration time.Duration, messages int) {
+	ctx, err := tag.New(context.Background(), tag.Insert(AnalyzerTag, name))
+	if err != nil {
+		scope.Analysis.Errorf("error creating monitoring context for analyzer %s: %v", name, err)
+		return
+	}
+	stats.Record(ctx, analyzerDurationMs.M(duration.Nanoseconds()/1e6), analyzerMessages.M(int64(messages)))
+}
+
 func newView(measure stats.Measure, keys []tag.Key, aggregation *view.Aggregation) *view.View {
 	return &view.View{
 		Name:        measure.Name(),
@@ -225,8 +379,21 @@ func init() {
 		panic(err)
 	}
 
+	if CodeTag, err = tag.NewKey(code); err != nil {
+		panic(err)
+	}
+	if LevelTag, err = tag.NewKey(level); err != nil {
+		panic(err)
+	}
+	if AnalyzerTag, err = tag.NewKey(analyzer); err != nil {
+		panic(err)
+	}
+
 	var noKeys []tag.Key
 	collectionKeys := []tag.Key{CollectionTag}
+	analysisKeys := []tag.Key{CodeTag, LevelTag}
+	analyzerKeys := []tag.Key{AnalyzerTag}
+	codeKeys := []tag.Key{CodeTag}
 
 	err = view.Register(
 		newView(strategyOnTimerResetTotal, noKeys, view.Count()),
@@ -239,6 +406,12 @@ func init() {
 		newView(processorEventsPerSnapshot, noKeys, view.Distribution(0, 1, 2, 4, 8, 16, 32, 64, 128, 256)),
 		newView(processorSnapshotLifetimesMs, noKeys, durationDistributionMs),
 		newView(stateTypeInstancesTotal, collectionKeys, view.LastValue()),
+		newView(analysisMessages, analysisKeys, view.LastValue()),
+		newView(analyzerDurationMs, analyzerKeys, durationDistributionMs),
+		newView(analyzerMessages, analyzerKeys, view.LastValue()),
+		newView(findingsIntroduced, codeKeys, view.Sum()),
+		newView(findingsResolved, codeKeys, view.Sum()),
+		newView(oldestFindingAge, codeKeys, view.LastValue()),
 	)
 
 	if err != nil {
@@ -250,4 +423,8 @@ func init() {
 	if err != nil {
 		panic(err)
 	}
+
+	if err = view.Register(newView(analysisHealthScore, []tag.Key{NamespaceTag}, view.LastValue())); err != nil {
+		panic(err)
+	}
 }
@@ -0,0 +1,163 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package monitoring
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"go.opencensus.io/stats/view"
+
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+	"istio.io/istio/galley/pkg/config/analysis/score"
+)
+
+func analysisMessageValues(t *testing.T) map[string]float64 {
+	t.Helper()
+	rows, err := view.RetrieveData(analysisMessages.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	values := make(map[string]float64)
+	for _, r := range rows {
+		var c, l string
+		for _, tg := range r.Tags {
+			switch tg.Key {
+			case CodeTag:
+				c = tg.Value
+			case LevelTag:
+				l = tg.Value
+			}
+		}
+		values[c+"/"+l] = r.Data.(*view.LastValueData).Value
+	}
+	return values
+}
+
+func TestRecordAnalysisMessages(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	errType := diag.NewMessageType(diag.Error, "TEST0001", "broken")
+	warnType := diag.NewMessageType(diag.Warning, "TEST0002", "fishy")
+
+	RecordAnalysisMessages(diag.Messages{
+		diag.NewMessage(errType, nil),
+		diag.NewMessage(errType, nil),
+		diag.NewMessage(warnType, nil),
+	})
+	values := analysisMessageValues(t)
+	g.Expect(values).To(HaveKeyWithValue("TEST0001/Error", 2.0))
+	g.Expect(values).To(HaveKeyWithValue("TEST0002/Warn", 1.0))
+
+	// Resolved messages are reset to zero.
+	RecordAnalysisMessages(diag.Messages{diag.NewMessage(warnType, nil)})
+	values = analysisMessageValues(t)
+	g.Expect(values).To(HaveKeyWithValue("TEST0001/Error", 0.0))
+	g.Expect(values).To(HaveKeyWithValue("TEST0002/Warn", 1.0))
+}
+
+func healthScoreValues(t *testing.T) map[string]float64 {
+	t.Helper()
+	rows, err := view.RetrieveData(analysisHealthScore.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	values := make(map[string]float64)
+	for _, r := range rows {
+		ns := ""
+		for _, tg := range r.Tags {
+			if tg.Key == NamespaceTag {
+				ns = tg.Value
+			}
+		}
+		values[ns] = r.Data.(*view.LastValueData).Value
+	}
+	return values
+}
+
+func TestRecordHealthScores(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	RecordHealthScores(score.Summary{
+		Mesh:       score.Score{Value: 80},
+		Namespaces: []score.Score{{Namespace: "a", Value: 90}, {Namespace: "b", Value: 85}},
+	})
+	values := healthScoreValues(t)
+	g.Expect(values).To(HaveKeyWithValue("", 80.0))
+	g.Expect(values).To(HaveKeyWithValue("a", 90.0))
+	g.Expect(values).To(HaveKeyWithValue("b", 85.0))
+
+	// Namespaces whose findings are resolved are reset to the maximum score.
+	RecordHealthScores(score.Summary{
+		Mesh:       score.Score{Value: 95},
+		Namespaces: []score.Score{{Namespace: "b", Value: 95}},
+	})
+	values = healthScoreValues(t)
+	g.Expect(values).To(HaveKeyWithValue("", 95.0))
+	g.Expect(values).To(HaveKeyWithValue("a", score.Max))
+	g.Expect(values).To(HaveKeyWithValue("b", 95.0))
+}
+
+func codeValues(t *testing.T, name string) map[string]float64 {
+	t.Helper()
+	rows, err := view.RetrieveData(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	values := make(map[string]float64)
+	for _, r := range rows {
+		switch d := r.Data.(type) {
+		case *view.SumData:
+			values[r.Tags[0].Value] = d.Value
+		case *view.LastValueData:
+			values[r.Tags[0].Value] = d.Value
+		}
+	}
+	return values
+}
+
+func TestRecordFindingsHistory(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	RecordFindingsHistory(map[string]int{"TEST0001": 2, "TEST0002": 1}, nil,
+		map[string]time.Duration{"TEST0001": time.Minute, "TEST0002": 0})
+	RecordFindingsHistory(map[string]int{"TEST0001": 1}, map[string]int{"TEST0002": 1},
+		map[string]time.Duration{"TEST0001": 2 * time.Minute})
+
+	g.Expect(codeValues(t, findingsIntroduced.Name())).To(Equal(map[string]float64{"TEST0001": 3, "TEST0002": 1}))
+	g.Expect(codeValues(t, findingsResolved.Name())).To(Equal(map[string]float64{"TEST0002": 1}))
+
+	// Codes whose findings are all resolved are reset to zero.
+	g.Expect(codeValues(t, oldestFindingAge.Name())).To(Equal(map[string]float64{"TEST0001": 120, "TEST0002": 0}))
+}
+
+func TestRecordAnalyzerRun(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	RecordAnalyzerRun("test.Analyzer", 20*time.Millisecond, 3)
+
+	rows, err := view.RetrieveData(analyzerMessages.Name())
+	g.Expect(err).To(BeNil())
+	g.Expect(rows).To(HaveLen(1))
+	g.Expect(rows[0].Tags[0].Value).To(Equal("test.Analyzer"))
+	g.Expect(rows[0].Data.(*view.LastValueData).Value).To(Equal(3.0))
+
+	rows, err = view.RetrieveData(analyzerDurationMs.Name())
+	g.Expect(err).To(BeNil())
+	g.Expect(rows).To(HaveLen(1))
+	g.Expect(rows[0].Data.(*view.DistributionData).Count).To(Equal(int64(1)))
+	g.Expect(rows[0].Data.(*view.DistributionData).Mean).To(Equal(20.0))
+}
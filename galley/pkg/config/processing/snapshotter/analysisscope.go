@@ -0,0 +1,76 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshotter
+
+import (
+	"k8s.io/apimachinery/pkg/labels"
+
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+	"istio.io/istio/pkg/config/resource"
+)
+
+// OutOfScopeNote is the note of messages about resources outside of the analysis scope, when they are kept.
+const OutOfScopeNote = "The resource is outside of the analysis scope."
+
+// AnalysisScope is the part of the configuration that analysis reports messages about. Analyzers still see the whole
+// snapshot, so that resources outside of the scope that resources in it depend on, e.g. gateways in the Istio
+// namespace, are taken into account.
+type AnalysisScope struct {
+	// Namespaces of the resources in scope. If empty, resources in all namespaces are in scope. Cluster-scoped
+	// resources are always in scope.
+	Namespaces []resource.Namespace
+
+	// Selector of the labels of resources in scope. If nil, resources with any labels are in scope.
+	Selector labels.Selector
+
+	// IncludeOutOfScope keeps the messages about resources outside of the scope, with OutOfScopeNote, instead of
+	// dropping them.
+	IncludeOutOfScope bool
+}
+
+// Contains returns whether a message is in scope. Messages that are not about a resource are always in scope.
+func (s *AnalysisScope) Contains(m *diag.Message) bool {
+	if m.Resource == nil {
+		return true
+	}
+	if ns := m.Resource.Origin.Namespace(); ns != "" && len(s.Namespaces) > 0 {
+		found := false
+		for _, n := range s.Namespaces {
+			if n == ns {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return s.Selector == nil || s.Selector.Matches(labels.Set(m.Resource.Metadata.Labels))
+}
+
+// apply drops the messages outside of the scope, or notes them as such if IncludeOutOfScope is set.
+func (s *AnalysisScope) apply(messages diag.Messages) diag.Messages {
+	var result diag.Messages
+	for _, m := range messages {
+		if !s.Contains(&m) {
+			if !s.IncludeOutOfScope {
+				continue
+			}
+			m.Notes = append(append([]string(nil), m.Notes...), OutOfScopeNote)
+		}
+		result = append(result, m)
+	}
+	return result
+}
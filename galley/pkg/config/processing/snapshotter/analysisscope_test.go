@@ -0,0 +1,82 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshotter
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+	"istio.io/istio/galley/pkg/config/source/kube/rt"
+	"istio.io/istio/galley/pkg/config/testing/basicmeta"
+	"istio.io/istio/pkg/config/resource"
+)
+
+func scopeTestMessage(ns, name string, l map[string]string) diag.Message {
+	fullName := resource.NewFullName(resource.Namespace(ns), resource.LocalName(name))
+	r := &resource.Instance{
+		Metadata: resource.Metadata{FullName: fullName, Labels: l},
+		Origin:   &rt.Origin{Collection: basicmeta.K8SCollection1.Name(), FullName: fullName},
+	}
+	return diag.NewMessage(diag.NewMessageType(diag.Error, "T0001", "Template"), r)
+}
+
+func TestAnalysisScopeContains(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	inTeam := scopeTestMessage("team-a", "r1", map[string]string{"team": "a"})
+	otherNamespace := scopeTestMessage("team-b", "r2", map[string]string{"team": "a"})
+	otherLabels := scopeTestMessage("team-a", "r3", map[string]string{"team": "b"})
+	clusterScoped := scopeTestMessage("", "r4", nil)
+	noResource := diag.NewMessage(diag.NewMessageType(diag.Error, "T0001", "Template"), nil)
+
+	s := AnalysisScope{}
+	g.Expect(s.Contains(&otherNamespace)).To(BeTrue())
+	g.Expect(s.Contains(&otherLabels)).To(BeTrue())
+
+	s = AnalysisScope{
+		Namespaces: []resource.Namespace{"team-a"},
+		Selector:   labels.SelectorFromSet(map[string]string{"team": "a"}),
+	}
+	g.Expect(s.Contains(&inTeam)).To(BeTrue())
+	g.Expect(s.Contains(&otherNamespace)).To(BeFalse())
+	g.Expect(s.Contains(&otherLabels)).To(BeFalse())
+	g.Expect(s.Contains(&noResource)).To(BeTrue())
+
+	// Cluster-scoped resources are only restricted by the selector
+	g.Expect(s.Contains(&clusterScoped)).To(BeFalse())
+	s.Selector = nil
+	g.Expect(s.Contains(&clusterScoped)).To(BeTrue())
+}
+
+func TestAnalysisScopeApply(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	in := scopeTestMessage("team-a", "r1", nil)
+	out := scopeTestMessage("istio-system", "r2", nil)
+	out.Notes = []string{"existing note"}
+
+	s := AnalysisScope{Namespaces: []resource.Namespace{"team-a"}}
+	g.Expect(s.apply(diag.Messages{in, out})).To(Equal(diag.Messages{in}))
+
+	s.IncludeOutOfScope = true
+	msgs := s.apply(diag.Messages{in, out})
+	g.Expect(msgs).To(HaveLen(2))
+	g.Expect(msgs[0].Notes).To(BeEmpty())
+	g.Expect(msgs[1].Notes).To(Equal([]string{"existing note", OutOfScopeNote}))
+	g.Expect(out.Notes).To(Equal([]string{"existing note"}))
+}
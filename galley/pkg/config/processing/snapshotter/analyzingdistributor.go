@@ -15,21 +15,34 @@
 package snapshotter
 
 import (
+	gocontext "context"
+	"errors"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"istio.io/api/annotation"
+	"istio.io/api/mesh/v1alpha1"
 
 	"github.com/ryanuber/go-glob"
+	"k8s.io/apimachinery/pkg/labels"
 
 	"istio.io/istio/galley/pkg/config/analysis"
 	"istio.io/istio/galley/pkg/config/analysis/diag"
+	"istio.io/istio/galley/pkg/config/analysis/propagation"
+	"istio.io/istio/galley/pkg/config/analysis/pushed"
+	"istio.io/istio/galley/pkg/config/analysis/score"
 	coll "istio.io/istio/galley/pkg/config/collection"
+	"istio.io/istio/galley/pkg/config/monitoring"
 	"istio.io/istio/galley/pkg/config/scope"
 	"istio.io/istio/pkg/config/resource"
 	"istio.io/istio/pkg/config/schema/collection"
 )
 
+// ErrNoSnapshot is returned by on-demand analysis when no snapshot has been distributed yet.
+var ErrNoSnapshot = errors.New("no configuration snapshot is available for analysis yet")
+
 // CollectionReporterFn is a hook function called whenever a collection is accessed through the AnalyzingDistributor's context
 type CollectionReporterFn func(collection.Name)
 
@@ -39,10 +52,23 @@ type AnalyzingDistributor struct {
 	s AnalyzingDistributorSettings
 
 	analysisMu     sync.Mutex
-	cancelAnalysis chan struct{}
+	cancelAnalysis gocontext.CancelFunc
+	scheduler      *scheduler
+	stopped        bool
 
 	snapshotsMu   sync.RWMutex
 	lastSnapshots map[string]*Snapshot
+
+	profileMu   sync.RWMutex
+	lastProfile *analysis.Profile
+
+	messagesMu   sync.RWMutex
+	lastMessages diag.Messages
+	lastAnalyzed time.Time
+
+	// The generations of the collections analyzed by the last completed run, to tell which collections changed since.
+	generationsMu   sync.Mutex
+	lastGenerations map[collection.Name]int64
 }
 
 var _ Distributor = &AnalyzingDistributor{}
@@ -66,14 +92,70 @@ type AnalyzingDistributorSettings struct {
 	//  and a matching debounce mechanism.
 	TriggerSnapshot string
 
+	// If non-zero, analysis is debounced: the trigger snapshot is distributed immediately, and analysis of the
+	// latest snapshots only starts once no new trigger snapshot has arrived for this duration. This avoids repeatedly
+	// analyzing (and writing status for) rapidly changing config. If AnalysisDebounce and AnalysisMinInterval are
+	// both zero, every trigger snapshot is analyzed before it is distributed.
+	AnalysisDebounce time.Duration
+
+	// If non-zero, debounced analysis starts no later than this duration after the first trigger snapshot of a
+	// burst, even if trigger snapshots keep arriving, e.g. during a large GitOps sync.
+	AnalysisMaxDelay time.Duration
+
+	// If non-zero, analysis runs start at least this duration apart. Trigger snapshots arriving in the meantime are
+	// coalesced into a single run, which bounds the CPU spent on analysis of constantly changing config.
+	AnalysisMinInterval time.Duration
+
 	// An optional hook that will be called whenever a collection is accessed. Useful for testing.
 	CollectionReporter CollectionReporterFn
 
 	// Namespaces that should be analyzed
 	AnalysisNamespaces []resource.Namespace
 
+	// AnalysisSelector, if set, restricts the messages to resources whose labels match it.
+	AnalysisSelector labels.Selector
+
+	// IncludeOutOfScope keeps the messages about resources outside of AnalysisNamespaces and AnalysisSelector, noted
+	// as out of scope, instead of dropping them.
+	IncludeOutOfScope bool
+
 	// Suppressions that suppress a set of matching messages.
 	Suppressions []AnalysisSuppression
+
+	// Profile enables resource accounting of each analysis run. The result of the last completed run is available
+	// through LastProfile.
+	Profile bool
+
+	// An optional hook that is called as each analyzer completes, with the messages it reported after namespace and
+	// suppression filtering. The complete, sorted message set is still delivered to the StatusUpdater at the end.
+	OnAnalyzerDone analysis.AnalyzerDoneFn
+
+	// The parent context of continuous analysis runs. Canceling it stops the analysis in progress. Defaults to
+	// context.Background().
+	Context gocontext.Context
+
+	// An optional tracker of the distribution status of resources to proxies. If set, messages about resources that
+	// have not fully propagated are annotated with their distribution status, and messages about resources that were
+	// rejected outright are replaced by a single ResourceRejected message (see propagation.Correlate).
+	Propagation propagation.Tracker
+
+	// The Istio control plane version that the configuration is checked against, made available to analyzers through
+	// analysis.IstioVersion. Optional.
+	IstioVersion string
+
+	// The clusters of a multi-cluster analysis, made available to analyzers through analysis.Clusters and related
+	// functions. Optional.
+	Clusters analysis.ClusterProvider
+
+	// The configuration pushed to proxies, made available to analyzers through analysis.PushedConfig. Optional.
+	PushedConfig *pushed.Config
+
+	// The weights of findings in the config health score that is recorded as a metric after each run. Defaults to
+	// score.DefaultWeights.
+	ScoreWeights score.Weights
+
+	// The levels of messages by code, overriding the levels of their types. Optional.
+	LevelOverrides map[string]diag.Level
 }
 
 // AnalysisSuppression describes a resource and analysis code to be suppressed
@@ -97,10 +179,18 @@ func NewAnalyzingDistributor(s AnalyzingDistributorSettings) *AnalyzingDistribut
 		s.CollectionReporter = func(collection.Name) {}
 	}
 
-	return &AnalyzingDistributor{
+	d := &AnalyzingDistributor{
 		s:             s,
 		lastSnapshots: make(map[string]*Snapshot),
 	}
+	if s.AnalysisDebounce > 0 || s.AnalysisMinInterval > 0 {
+		d.scheduler = newScheduler(s.AnalysisDebounce, s.AnalysisMaxDelay, s.AnalysisMinInterval, func() {
+			d.analysisMu.Lock()
+			defer d.analysisMu.Unlock()
+			d.startAnalysis(s.TriggerSnapshot, nil)
+		})
+	}
+	return d
 }
 
 // Distribute implements snapshotter.Distributor
@@ -120,24 +210,176 @@ func (d *AnalyzingDistributor) Distribute(name string, s *Snapshot) {
 		return
 	}
 
+	if d.scheduler != nil {
+		// Analysis runs on the combined snapshot at the time the scheduler fires.
+		d.s.Distributor.Distribute(name, s)
+		d.scheduler.trigger()
+		return
+	}
+
 	d.analysisMu.Lock()
 	defer d.analysisMu.Unlock()
 
-	// Cancel the previous analysis session, if it is still working.
+	d.startAnalysis(name, s)
+}
+
+// Stop cancels the pending and in-flight analysis, and stops analyzing later snapshots. Snapshots distributed after
+// Stop are passed on without analysis.
+func (d *AnalyzingDistributor) Stop() {
+	if d.scheduler != nil {
+		d.scheduler.stop()
+	}
+
+	d.analysisMu.Lock()
+	defer d.analysisMu.Unlock()
+
+	d.stopped = true
 	if d.cancelAnalysis != nil {
-		close(d.cancelAnalysis)
+		d.cancelAnalysis()
 		d.cancelAnalysis = nil
 	}
+}
+
+// startAnalysis cancels any in-flight analysis session and starts a new one. If s is not nil, it is distributed
+// once the analysis completes. Must be called with analysisMu held.
+func (d *AnalyzingDistributor) startAnalysis(name string, s *Snapshot) {
+	// After Stop, snapshots are passed on without analysis, also by a scheduled run that fired concurrently with it.
+	if d.stopped {
+		if s != nil {
+			d.s.Distributor.Distribute(name, s)
+		}
+		return
+	}
 
-	namespaces := make(map[resource.Namespace]struct{})
-	for _, ns := range d.s.AnalysisNamespaces {
-		namespaces[ns] = struct{}{}
+	// Cancel the previous analysis session, if it is still working.
+	if d.cancelAnalysis != nil {
+		d.cancelAnalysis()
+		d.cancelAnalysis = nil
 	}
 
 	// start a new analysis session
-	cancelAnalysis := make(chan struct{})
-	d.cancelAnalysis = cancelAnalysis
-	go d.analyzeAndDistribute(cancelAnalysis, name, s, namespaces)
+	parent := d.s.Context
+	if parent == nil {
+		parent = gocontext.Background()
+	}
+	ctx, cancel := gocontext.WithCancel(parent)
+	d.cancelAnalysis = cancel
+	go d.analyzeAndDistribute(ctx, name, s, d.getCombinedSnapshot(), d.scope())
+}
+
+// AnalysisRequest describes an on-demand analysis run.
+type AnalysisRequest struct {
+	// Analyzers to run, by name. If empty, all analyzers are run.
+	Analyzers []string
+
+	// Namespaces whose messages are returned. If empty, the configured analysis namespaces are used.
+	Namespaces []resource.Namespace
+
+	// Selector of the labels of the resources whose messages are returned. If nil, the configured analysis selector
+	// is used.
+	Selector labels.Selector
+
+	// Context, if set, stops the analysis when it is done, e.g. when the client of a service request goes away.
+	Context gocontext.Context
+
+	// OnAnalyzerDone, if set, is called with the filtered messages of each analyzer as it completes.
+	OnAnalyzerDone analysis.AnalyzerDoneFn
+}
+
+// AnalyzeNow runs analysis on demand against the latest combined snapshot, independently of continuous analysis. The
+// returned messages are filtered by namespace and by the configured suppressions, and are not sent to the
+// StatusUpdater.
+func (d *AnalyzingDistributor) AnalyzeNow(r AnalysisRequest) (diag.Messages, error) {
+	a, err := d.s.Analyzer.Subset(r.Analyzers...)
+	if err != nil {
+		return nil, err
+	}
+
+	sn := d.CombinedSnapshot()
+	if sn == nil {
+		return nil, ErrNoSnapshot
+	}
+
+	analysisScope := d.scope()
+	if len(r.Namespaces) > 0 {
+		analysisScope.Namespaces = r.Namespaces
+	}
+	if r.Selector != nil {
+		analysisScope.Selector = r.Selector
+	}
+
+	ctx := &context{
+		sn:                 sn,
+		ctx:                r.Context,
+		collectionReporter: d.s.CollectionReporter,
+		istioVersion:       d.s.IstioVersion,
+		clusters:           d.s.Clusters,
+		pushedConfig:       d.s.PushedConfig,
+	}
+
+	var opts analysis.RunOptions
+	if r.OnAnalyzerDone != nil {
+		opts.OnAnalyzerDone = func(analyzer string, messages diag.Messages) {
+			r.OnAnalyzerDone(analyzer, d.filter(messages, analysisScope))
+		}
+	}
+	a.AnalyzeWithOptions(ctx, opts)
+
+	return d.annotate(d.filter(ctx.reported(), analysisScope).SortedDedupedCopy()), nil
+}
+
+// annotate correlates the messages with the distribution status of the resources, if a propagation tracker is
+// configured.
+func (d *AnalyzingDistributor) annotate(msgs diag.Messages) diag.Messages {
+	if d.s.Propagation == nil {
+		return msgs
+	}
+	return propagation.Annotate(d.s.Propagation, propagation.Correlate(d.s.Propagation, msgs))
+}
+
+// LastProfile returns the profile of the last completed analysis run, or nil if profiling is disabled.
+func (d *AnalyzingDistributor) LastProfile() *analysis.Profile {
+	d.profileMu.RLock()
+	defer d.profileMu.RUnlock()
+	return d.lastProfile
+}
+
+// LastMessages returns the messages of the last completed continuous analysis run and the time it completed. A nil
+// slice and the zero time are returned if no run has completed yet.
+func (d *AnalyzingDistributor) LastMessages() (diag.Messages, time.Time) {
+	d.messagesMu.RLock()
+	defer d.messagesMu.RUnlock()
+	return d.lastMessages, d.lastAnalyzed
+}
+
+// CombinedSnapshot returns a view of the latest snapshots of all analysis snapshot groups, or nil if no snapshot has
+// been distributed yet.
+func (d *AnalyzingDistributor) CombinedSnapshot() *Snapshot {
+	d.snapshotsMu.RLock()
+	empty := len(d.lastSnapshots) == 0
+	d.snapshotsMu.RUnlock()
+
+	if empty {
+		return nil
+	}
+	return d.getCombinedSnapshot()
+}
+
+// AnalysisContext returns the analysis context of the latest combined snapshot, which provides the Istio version,
+// clusters and pushed configuration of the distributor, or nil if no snapshot has been distributed yet. It is meant to
+// be wrapped by the contexts of analyses outside the distributor, e.g. at admission.
+func (d *AnalyzingDistributor) AnalysisContext() analysis.Context {
+	sn := d.CombinedSnapshot()
+	if sn == nil {
+		return nil
+	}
+	return &context{
+		sn:                 sn,
+		collectionReporter: d.s.CollectionReporter,
+		istioVersion:       d.s.IstioVersion,
+		clusters:           d.s.Clusters,
+		pushedConfig:       d.s.PushedConfig,
+	}
 }
 
 func (d *AnalyzingDistributor) isAnalysisSnapshot(s string) bool {
@@ -150,29 +392,96 @@ func (d *AnalyzingDistributor) isAnalysisSnapshot(s string) bool {
 	return false
 }
 
-func (d *AnalyzingDistributor) analyzeAndDistribute(cancelCh chan struct{}, name string, s *Snapshot, namespaces map[resource.Namespace]struct{}) {
+func (d *AnalyzingDistributor) analyzeAndDistribute(goCtx gocontext.Context, name string, s *Snapshot, combined *Snapshot,
+	analysisScope AnalysisScope) {
 	// For analysis, we use a combined snapshot
 	ctx := &context{
-		sn:                 d.getCombinedSnapshot(),
-		cancelCh:           cancelCh,
+		sn:                 combined,
+		ctx:                goCtx,
 		collectionReporter: d.s.CollectionReporter,
+		istioVersion:       d.s.IstioVersion,
+		clusters:           d.s.Clusters,
+		pushedConfig:       d.s.PushedConfig,
+	}
+	generations := combined.generations()
+
+	var profile *analysis.Profile
+	if d.s.Profile {
+		profile = analysis.NewProfile()
+		for _, n := range ctx.sn.set.Names() {
+			profile.SetCollectionCount(n, ctx.sn.set.Collection(n).Size())
+		}
+	}
+
+	opts := analysis.RunOptions{
+		Profile:         profile,
+		Changed:         d.changedSince(generations),
+		OnAnalyzerStats: monitoring.RecordAnalyzerRun,
+	}
+	if d.s.OnAnalyzerDone != nil {
+		opts.OnAnalyzerDone = func(analyzer string, messages diag.Messages) {
+			d.s.OnAnalyzerDone(analyzer, d.filter(messages, analysisScope))
+		}
 	}
 
 	scope.Analysis.Debugf("Beginning analyzing the current snapshot")
-	d.s.Analyzer.Analyze(ctx)
-	scope.Analysis.Debugf("Finished analyzing the current snapshot, found messages: %v", ctx.messages)
+	d.s.Analyzer.AnalyzeWithOptions(ctx, opts)
+	reported := ctx.reported()
+	scope.Analysis.Debugf("Finished analyzing the current snapshot, found messages: %v", reported)
 
-	msgs := filterMessages(ctx.messages, namespaces, d.s.Suppressions)
+	msgs := d.filter(reported, analysisScope)
 	if !ctx.Canceled() {
-		d.s.StatusUpdater.Update(msgs.SortedDedupedCopy())
+		d.generationsMu.Lock()
+		d.lastGenerations = generations
+		d.generationsMu.Unlock()
+
+		if profile != nil {
+			d.profileMu.Lock()
+			d.lastProfile = profile
+			d.profileMu.Unlock()
+		}
+		sorted := d.annotate(msgs.SortedDedupedCopy())
+		d.messagesMu.Lock()
+		d.lastMessages = sorted
+		d.lastAnalyzed = time.Now()
+		d.messagesMu.Unlock()
+		monitoring.RecordAnalysisMessages(sorted)
+		monitoring.RecordHealthScores(score.Compute(sorted, d.s.ScoreWeights))
+		d.s.StatusUpdater.Update(sorted)
 	}
 
-	// Execution only reaches this point for trigger snapshot group
-	d.s.Distributor.Distribute(name, s)
+	// Execution only reaches this point for trigger snapshot group. Debounced analysis has already distributed it.
+	if s != nil {
+		d.s.Distributor.Distribute(name, s)
+	}
+}
+
+// changedSince returns the collections whose generation differs from the last completed analysis run. Nil is returned
+// before the first run completes, as all collections are new then.
+func (d *AnalyzingDistributor) changedSince(generations map[collection.Name]int64) collection.Names {
+	d.generationsMu.Lock()
+	defer d.generationsMu.Unlock()
+
+	if d.lastGenerations == nil {
+		return nil
+	}
+
+	var changed collection.Names
+	for n, g := range generations {
+		if last, ok := d.lastGenerations[n]; !ok || last != g {
+			changed = append(changed, n)
+		}
+	}
+	if len(changed) > 0 {
+		sort.Slice(changed, func(i, j int) bool { return changed[i].String() < changed[j].String() })
+		scope.Analysis.Debugf("Prioritizing analyzers of changed collections: %v", changed)
+	}
+	return changed
 }
 
 // getCombinedSnapshot creates a new snapshot from the last snapshots of each snapshot group
-// Important assumption: the collections in each snapshot don't overlap.
+// Important assumption: the collections in each snapshot don't overlap. Since snapshots are immutable, the combined
+// snapshot can share their collections, and derived indexes are shared between all analyzers of a single run.
 func (d *AnalyzingDistributor) getCombinedSnapshot() *Snapshot {
 	var collections []*coll.Instance
 
@@ -190,25 +499,33 @@ func (d *AnalyzingDistributor) getCombinedSnapshot() *Snapshot {
 	return &Snapshot{set: coll.NewSetFromCollections(collections)}
 }
 
-func filterMessages(messages diag.Messages, namespaces map[resource.Namespace]struct{}, suppressions []AnalysisSuppression) diag.Messages {
-	nsNames := make(map[string]struct{})
-	for k := range namespaces {
-		nsNames[k.String()] = struct{}{}
+// scope returns the configured analysis scope.
+func (d *AnalyzingDistributor) scope() AnalysisScope {
+	return AnalysisScope{
+		Namespaces:        d.s.AnalysisNamespaces,
+		Selector:          d.s.AnalysisSelector,
+		IncludeOutOfScope: d.s.IncludeOutOfScope,
 	}
+}
 
-	var msgs diag.Messages
-FilterMessages:
-	for _, m := range messages {
-		// Only keep messages for resources in namespaces we want to analyze if the
-		// message doesn't have an origin (meaning we can't determine the
-		// namespace). Also kept are cluster-level resources where the namespace is
-		// the empty string. If no such limit is specified, keep them all.
-		if len(namespaces) > 0 && m.Resource != nil && m.Resource.Origin.Namespace() != "" {
-			if _, ok := nsNames[m.Resource.Origin.Namespace().String()]; !ok {
-				continue FilterMessages
-			}
+// filter filters the messages with the scope and suppressions of the distributor, and overrides their levels.
+func (d *AnalyzingDistributor) filter(messages diag.Messages, analysisScope AnalysisScope) diag.Messages {
+	msgs := filterMessages(messages, analysisScope, d.s.Suppressions)
+	if len(d.s.LevelOverrides) == 0 {
+		return msgs
+	}
+	for i, m := range msgs {
+		if level, ok := d.s.LevelOverrides[m.Type.Code()]; ok {
+			msgs[i] = m.WithLevel(level)
 		}
+	}
+	return msgs
+}
 
+func filterMessages(messages diag.Messages, analysisScope AnalysisScope, suppressions []AnalysisSuppression) diag.Messages {
+	var msgs diag.Messages
+FilterMessages:
+	for _, m := range analysisScope.apply(messages) {
 		// Filter out any messages on resources with suppression annotations.
 		if m.Resource != nil && m.Resource.Metadata.Annotations[annotation.GalleyAnalyzeSuppress.Name] != "" {
 			for _, code := range strings.Split(m.Resource.Metadata.Annotations[annotation.GalleyAnalyzeSuppress.Name], ",") {
@@ -237,20 +554,45 @@ FilterMessages:
 	return msgs
 }
 
+// context is the analysis.Context used by the AnalyzingDistributor. It is safe for concurrent use by multiple
+// analyzers.
 type context struct {
 	sn                 *Snapshot
-	cancelCh           chan struct{}
-	messages           diag.Messages
+	ctx                gocontext.Context
 	collectionReporter CollectionReporterFn
+	istioVersion       string
+	clusters           analysis.ClusterProvider
+	pushedConfig       *pushed.Config
+
+	messagesMu sync.Mutex
+	messages   diag.Messages
 }
 
 var _ analysis.Context = &context{}
+var _ analysis.IndexProvider = &context{}
+var _ analysis.VersionProvider = &context{}
+var _ analysis.ClusterProvider = &context{}
+var _ analysis.PushedConfigProvider = &context{}
 
 // Report implements analysis.Context
 func (c *context) Report(_ collection.Name, m diag.Message) {
+	c.messagesMu.Lock()
+	defer c.messagesMu.Unlock()
 	c.messages.Add(m)
 }
 
+// Index implements analysis.IndexProvider
+func (c *context) Index(key string, build func() interface{}) interface{} {
+	return c.sn.Index(key, build)
+}
+
+// reported returns a copy of the messages reported so far.
+func (c *context) reported() diag.Messages {
+	c.messagesMu.Lock()
+	defer c.messagesMu.Unlock()
+	return append(diag.Messages(nil), c.messages...)
+}
+
 // Find implements analysis.Context
 func (c *context) Find(col collection.Name, name resource.FullName) *resource.Instance {
 	c.collectionReporter(col)
@@ -271,10 +613,47 @@ func (c *context) ForEach(col collection.Name, fn analysis.IteratorFn) {
 
 // Canceled implements analysis.Context
 func (c *context) Canceled() bool {
-	select {
-	case <-c.cancelCh:
-		return true
-	default:
-		return false
+	return c.ctx != nil && c.ctx.Err() != nil
+}
+
+// GoContext implements analysis.GoContextProvider
+func (c *context) GoContext() gocontext.Context {
+	if c.ctx == nil {
+		return gocontext.Background()
+	}
+	return c.ctx
+}
+
+// IstioVersion implements analysis.VersionProvider
+func (c *context) IstioVersion() string {
+	return c.istioVersion
+}
+
+// Clusters implements analysis.ClusterProvider
+func (c *context) Clusters() []string {
+	if c.clusters == nil {
+		return nil
 	}
+	return c.clusters.Clusters()
+}
+
+// ResourceClusters implements analysis.ClusterProvider
+func (c *context) ResourceClusters(col collection.Name, name resource.FullName) []string {
+	if c.clusters == nil {
+		return nil
+	}
+	return c.clusters.ResourceClusters(col, name)
+}
+
+// ClusterMeshNetworks implements analysis.ClusterProvider
+func (c *context) ClusterMeshNetworks(cluster string) *v1alpha1.MeshNetworks {
+	if c.clusters == nil {
+		return nil
+	}
+	return c.clusters.ClusterMeshNetworks(cluster)
+}
+
+// PushedConfig implements analysis.PushedConfigProvider
+func (c *context) PushedConfig() *pushed.Config {
+	return c.pushedConfig
 }
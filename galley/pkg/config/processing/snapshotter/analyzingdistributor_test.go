@@ -15,6 +15,7 @@
 package snapshotter
 
 import (
+	gocontext "context"
 	"sync"
 	"testing"
 	"time"
@@ -24,6 +25,7 @@ import (
 	"istio.io/istio/galley/pkg/config/analysis"
 	"istio.io/istio/galley/pkg/config/analysis/diag"
 	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/galley/pkg/config/analysis/propagation"
 	coll "istio.io/istio/galley/pkg/config/collection"
 	"istio.io/istio/galley/pkg/config/source/kube/rt"
 	"istio.io/istio/galley/pkg/config/testing/basicmeta"
@@ -286,6 +288,10 @@ func TestAnalyzeSortsMessages(t *testing.T) {
 	}
 	ad := NewAnalyzingDistributor(settings)
 
+	msgs, analyzed := ad.LastMessages()
+	g.Expect(msgs).To(BeNil())
+	g.Expect(analyzed.IsZero()).To(BeTrue())
+
 	sDefault := getTestSnapshot()
 
 	ad.Distribute(snapshots.Default, sDefault)
@@ -295,6 +301,423 @@ func TestAnalyzeSortsMessages(t *testing.T) {
 	g.Eventually(u.getMessages()).Should(HaveLen(2))
 	g.Eventually(u.getMessages()[0].Resource).Should(Equal(r2))
 	g.Eventually(u.getMessages()[1].Resource).Should(Equal(r1))
+
+	msgs, analyzed = ad.LastMessages()
+	g.Expect(msgs).To(Equal(u.getMessages()))
+	g.Expect(analyzed.IsZero()).To(BeFalse())
+}
+
+type propagationMock map[resource.FullName]propagation.Status
+
+func (p propagationMock) Propagation(r *resource.Instance) (propagation.Status, bool) {
+	s, ok := p[r.Origin.(*rt.Origin).FullName]
+	return s, ok
+}
+
+func TestAnalyzeAnnotatesPropagation(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	u := &updaterMock{waitTimeout: 1 * time.Second}
+	r1 := &resource.Instance{
+		Origin: &rt.Origin{
+			Collection: basicmeta.K8SCollection1.Name(),
+			FullName:   resource.NewFullName("includedNamespace", "r1"),
+		},
+	}
+	r2 := &resource.Instance{
+		Origin: &rt.Origin{
+			Collection: basicmeta.K8SCollection1.Name(),
+			FullName:   resource.NewFullName("includedNamespace", "r2"),
+		},
+	}
+
+	a := &analyzerMock{
+		collectionToAccess: basicmeta.K8SCollection1.Name(),
+		resourcesToReport:  []*resource.Instance{r1, r2},
+	}
+	d := NewInMemoryDistributor()
+
+	settings := AnalyzingDistributorSettings{
+		StatusUpdater:      u,
+		Analyzer:           analysis.Combine("testCombined", a),
+		Distributor:        d,
+		AnalysisSnapshots:  []string{snapshots.Default},
+		TriggerSnapshot:    snapshots.Default,
+		AnalysisNamespaces: []resource.Namespace{"includedNamespace"},
+		Propagation: propagationMock{
+			resource.NewFullName("includedNamespace", "r1"): {Acked: 1, Total: 2, NackedBy: []string{"proxy-a"}},
+			resource.NewFullName("includedNamespace", "r2"): {Acked: 2, Total: 2},
+		},
+	}
+	ad := NewAnalyzingDistributor(settings)
+
+	ad.Distribute(snapshots.Default, getTestSnapshot())
+
+	g.Eventually(u.getMessages).Should(HaveLen(2))
+	msgs := u.getMessages()
+	g.Expect(msgs[0].Resource).To(Equal(r1))
+	g.Expect(msgs[0].Notes).To(Equal([]string{"NACKed by proxy proxy-a"}))
+	g.Expect(msgs[1].Resource).To(Equal(r2))
+	g.Expect(msgs[1].Notes).To(BeEmpty())
+}
+
+func TestAnalyzeRecordsProfile(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	u := &updaterMock{waitTimeout: 1 * time.Second}
+	a := &analyzerMock{
+		collectionToAccess: basicmeta.K8SCollection1.Name(),
+	}
+	d := NewInMemoryDistributor()
+
+	settings := AnalyzingDistributorSettings{
+		StatusUpdater:     u,
+		Analyzer:          analysis.Combine("testCombined", a),
+		Distributor:       d,
+		AnalysisSnapshots: []string{snapshots.Default},
+		TriggerSnapshot:   snapshots.Default,
+		Profile:           true,
+	}
+	ad := NewAnalyzingDistributor(settings)
+	g.Expect(ad.LastProfile()).To(BeNil())
+
+	schemaA := newSchema("a")
+	sDefault := getTestSnapshot(schemaA)
+
+	ad.Distribute(snapshots.Default, sDefault)
+
+	g.Eventually(a.getAnalyzeCalls).Should(ConsistOf(sDefault))
+	g.Eventually(ad.LastProfile).ShouldNot(BeNil())
+
+	p := ad.LastProfile()
+	g.Expect(p.CollectionCounts).To(HaveKeyWithValue(schemaA.Name(), 0))
+	g.Expect(p.Analyzers).To(HaveLen(1))
+}
+
+func TestAnalyzeDebounced(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	u := &updaterMock{waitTimeout: 1 * time.Second}
+	a := &analyzerMock{
+		collectionToAccess: basicmeta.K8SCollection1.Name(),
+	}
+	d := NewInMemoryDistributor()
+
+	settings := AnalyzingDistributorSettings{
+		StatusUpdater:     u,
+		Analyzer:          analysis.Combine("testCombined", a),
+		Distributor:       d,
+		AnalysisSnapshots: []string{snapshots.Default},
+		TriggerSnapshot:   snapshots.Default,
+		AnalysisDebounce:  100 * time.Millisecond,
+	}
+	ad := NewAnalyzingDistributor(settings)
+
+	schemaA := newSchema("a")
+	s1 := getTestSnapshot(schemaA)
+	s2 := getTestSnapshot(schemaA)
+	s3 := getTestSnapshot(schemaA)
+
+	ad.Distribute(snapshots.Default, s1)
+	ad.Distribute(snapshots.Default, s2)
+	ad.Distribute(snapshots.Default, s3)
+
+	// Snapshots are distributed without waiting for analysis
+	g.Expect(d.GetSnapshot(snapshots.Default)).To(Equal(s3))
+
+	// Only a single analysis runs, once the snapshots settle
+	g.Eventually(a.getAnalyzeCalls).Should(HaveLen(1))
+	g.Consistently(a.getAnalyzeCalls, 300*time.Millisecond).Should(HaveLen(1))
+}
+
+func TestAnalyzeMinInterval(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	u := &updaterMock{waitTimeout: 1 * time.Second}
+	a := &analyzerMock{
+		collectionToAccess: basicmeta.K8SCollection1.Name(),
+	}
+	d := NewInMemoryDistributor()
+
+	settings := AnalyzingDistributorSettings{
+		StatusUpdater:       u,
+		Analyzer:            analysis.Combine("testCombined", a),
+		Distributor:         d,
+		AnalysisSnapshots:   []string{snapshots.Default},
+		TriggerSnapshot:     snapshots.Default,
+		AnalysisMinInterval: 300 * time.Millisecond,
+	}
+	ad := NewAnalyzingDistributor(settings)
+
+	schemaA := newSchema("a")
+	s1 := getTestSnapshot(schemaA)
+	s2 := getTestSnapshot(schemaA)
+	s3 := getTestSnapshot(schemaA)
+
+	// The first snapshot is analyzed right away
+	ad.Distribute(snapshots.Default, s1)
+	g.Expect(d.GetSnapshot(snapshots.Default)).To(Equal(s1))
+	g.Eventually(a.getAnalyzeCalls).Should(HaveLen(1))
+
+	// Later ones are distributed right away, but analyzed together once the minimum interval has passed
+	ad.Distribute(snapshots.Default, s2)
+	ad.Distribute(snapshots.Default, s3)
+	g.Expect(d.GetSnapshot(snapshots.Default)).To(Equal(s3))
+	g.Consistently(a.getAnalyzeCalls, 200*time.Millisecond).Should(HaveLen(1))
+	g.Eventually(a.getAnalyzeCalls).Should(HaveLen(2))
+	g.Consistently(a.getAnalyzeCalls, 100*time.Millisecond).Should(HaveLen(2))
+}
+
+func TestAnalyzeStop(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	a := &analyzerMock{
+		collectionToAccess: basicmeta.K8SCollection1.Name(),
+	}
+	d := NewInMemoryDistributor()
+
+	settings := AnalyzingDistributorSettings{
+		StatusUpdater:     &updaterMock{},
+		Analyzer:          analysis.Combine("testCombined", a),
+		Distributor:       d,
+		AnalysisSnapshots: []string{snapshots.Default},
+		TriggerSnapshot:   snapshots.Default,
+		AnalysisDebounce:  100 * time.Millisecond,
+	}
+	ad := NewAnalyzingDistributor(settings)
+
+	schemaA := newSchema("a")
+	s1 := getTestSnapshot(schemaA)
+	s2 := getTestSnapshot(schemaA)
+
+	// The pending run is canceled, and later snapshots are distributed without analysis
+	ad.Distribute(snapshots.Default, s1)
+	ad.Stop()
+	ad.Distribute(snapshots.Default, s2)
+	g.Expect(d.GetSnapshot(snapshots.Default)).To(Equal(s2))
+	g.Consistently(a.getAnalyzeCalls, 300*time.Millisecond).Should(BeEmpty())
+}
+
+type blockingAnalyzerMock struct {
+	started chan struct{}
+	stopped chan error
+}
+
+// Analyze implements Analyzer
+func (a *blockingAnalyzerMock) Analyze(c analysis.Context) {
+	ctx := analysis.GoContext(c)
+	a.started <- struct{}{}
+	<-ctx.Done()
+	a.stopped <- ctx.Err()
+}
+
+// Metadata implements Analyzer
+func (a *blockingAnalyzerMock) Metadata() analysis.Metadata {
+	return analysis.Metadata{Name: "blocking"}
+}
+
+func TestAnalyzeStopsWithContext(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	a := &blockingAnalyzerMock{started: make(chan struct{}, 1), stopped: make(chan error, 1)}
+	ctx, cancel := gocontext.WithCancel(gocontext.Background())
+	settings := AnalyzingDistributorSettings{
+		StatusUpdater:     &updaterMock{},
+		Analyzer:          analysis.Combine("testCombined", a),
+		Distributor:       NewInMemoryDistributor(),
+		AnalysisSnapshots: []string{snapshots.Default},
+		TriggerSnapshot:   snapshots.Default,
+		Context:           ctx,
+	}
+	ad := NewAnalyzingDistributor(settings)
+
+	ad.Distribute(snapshots.Default, getTestSnapshot())
+	g.Eventually(a.started).Should(Receive())
+
+	// Analyzers blocking on the context are released, and the results of the canceled run are dropped
+	cancel()
+	g.Eventually(a.stopped).Should(Receive(Equal(gocontext.Canceled)))
+	g.Consistently(func() time.Time {
+		_, analyzed := ad.LastMessages()
+		return analyzed
+	}, 100*time.Millisecond).Should(BeZero())
+}
+
+type versionAnalyzerMock struct {
+	versions chan string
+}
+
+// Analyze implements Analyzer
+func (a *versionAnalyzerMock) Analyze(c analysis.Context) {
+	a.versions <- analysis.IstioVersion(c)
+}
+
+// Metadata implements Analyzer
+func (a *versionAnalyzerMock) Metadata() analysis.Metadata {
+	return analysis.Metadata{Name: "version"}
+}
+
+func TestAnalyzeProvidesIstioVersion(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	a := &versionAnalyzerMock{versions: make(chan string, 1)}
+	settings := AnalyzingDistributorSettings{
+		StatusUpdater:     &updaterMock{},
+		Analyzer:          analysis.Combine("testCombined", a),
+		Distributor:       NewInMemoryDistributor(),
+		AnalysisSnapshots: []string{snapshots.Default},
+		TriggerSnapshot:   snapshots.Default,
+		IstioVersion:      "1.6.0",
+	}
+	ad := NewAnalyzingDistributor(settings)
+
+	ad.Distribute(snapshots.Default, getTestSnapshot())
+	g.Eventually(a.versions).Should(Receive(Equal("1.6.0")))
+}
+
+type inputAnalyzerMock struct {
+	name  string
+	input collection.Name
+}
+
+// Analyze implements Analyzer
+func (a *inputAnalyzerMock) Analyze(analysis.Context) {}
+
+// Metadata implements Analyzer
+func (a *inputAnalyzerMock) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:   a.name,
+		Inputs: collection.Names{a.input},
+	}
+}
+
+func TestAnalyzePrioritizesChangedCollections(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	schemaA := newSchema("a")
+	schemaB := newSchema("b")
+
+	var mu sync.Mutex
+	var order []string
+	d := NewInMemoryDistributor()
+	settings := AnalyzingDistributorSettings{
+		StatusUpdater: &updaterMock{},
+		Analyzer: analysis.Combine("testCombined",
+			&inputAnalyzerMock{name: "analyzeA", input: schemaA.Name()},
+			&inputAnalyzerMock{name: "analyzeB", input: schemaB.Name()}),
+		Distributor:       d,
+		AnalysisSnapshots: []string{snapshots.Default},
+		TriggerSnapshot:   snapshots.Default,
+		OnAnalyzerDone: func(analyzer string, _ diag.Messages) {
+			mu.Lock()
+			defer mu.Unlock()
+			order = append(order, analyzer)
+		},
+	}
+	ad := NewAnalyzingDistributor(settings)
+	getOrder := func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), order...)
+	}
+
+	colA := coll.New(schemaA)
+	colB := coll.New(schemaB)
+	s1 := &Snapshot{set: coll.NewSetFromCollections([]*coll.Instance{colA.Clone(), colB.Clone()})}
+	ad.Distribute(snapshots.Default, s1)
+	g.Eventually(func() interface{} { return d.GetSnapshot(snapshots.Default) }).Should(Equal(s1))
+	g.Expect(getOrder()).To(Equal([]string{"analyzeA", "analyzeB"}))
+
+	colB.Set(&resource.Instance{Metadata: resource.Metadata{FullName: resource.NewFullName("ns", "r1")}})
+	s2 := &Snapshot{set: coll.NewSetFromCollections([]*coll.Instance{colA.Clone(), colB.Clone()})}
+	ad.Distribute(snapshots.Default, s2)
+	g.Eventually(func() interface{} { return d.GetSnapshot(snapshots.Default) }).Should(Equal(s2))
+	g.Expect(getOrder()).To(Equal([]string{"analyzeA", "analyzeB", "analyzeB", "analyzeA"}))
+}
+
+type reportingAnalyzerMock struct {
+	name              string
+	resourcesToReport []*resource.Instance
+}
+
+// Analyze implements Analyzer
+func (a *reportingAnalyzerMock) Analyze(c analysis.Context) {
+	for _, r := range a.resourcesToReport {
+		c.Report(basicmeta.K8SCollection1.Name(), msg.NewInternalError(r, a.name))
+	}
+}
+
+// Metadata implements Analyzer
+func (a *reportingAnalyzerMock) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:   a.name,
+		Inputs: collection.Names{},
+	}
+}
+
+func TestAnalyzeNow(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	newResource := func(ns, name string) *resource.Instance {
+		return &resource.Instance{
+			Origin: &rt.Origin{
+				Collection: basicmeta.K8SCollection1.Name(),
+				FullName:   resource.NewFullName(resource.Namespace(ns), resource.LocalName(name)),
+			},
+		}
+	}
+	r1 := newResource("ns1", "r1")
+	r2 := newResource("ns2", "r2")
+
+	a1 := &reportingAnalyzerMock{name: "a1", resourcesToReport: []*resource.Instance{r1, r2}}
+	a2 := &reportingAnalyzerMock{name: "a2", resourcesToReport: []*resource.Instance{r2}}
+
+	u := &updaterMock{}
+	settings := AnalyzingDistributorSettings{
+		StatusUpdater:     u,
+		Analyzer:          analysis.Combine("testCombined", a1, a2),
+		Distributor:       NewInMemoryDistributor(),
+		AnalysisSnapshots: []string{snapshots.Default},
+		TriggerSnapshot:   snapshots.Default,
+		AnalysisDebounce:  time.Hour,
+	}
+	ad := NewAnalyzingDistributor(settings)
+
+	_, err := ad.AnalyzeNow(AnalysisRequest{})
+	g.Expect(err).To(Equal(ErrNoSnapshot))
+
+	ad.Distribute(snapshots.Default, getTestSnapshot())
+
+	msgs, err := ad.AnalyzeNow(AnalysisRequest{})
+	g.Expect(err).To(BeNil())
+	g.Expect(msgs).To(HaveLen(3))
+
+	done := make(map[string]int)
+	msgs, err = ad.AnalyzeNow(AnalysisRequest{
+		Analyzers:  []string{"a1"},
+		Namespaces: []resource.Namespace{"ns1"},
+		OnAnalyzerDone: func(analyzer string, messages diag.Messages) {
+			done[analyzer] = len(messages)
+		},
+	})
+	g.Expect(err).To(BeNil())
+	g.Expect(msgs).To(HaveLen(1))
+	g.Expect(msgs[0].Resource).To(Equal(r1))
+	g.Expect(done).To(Equal(map[string]int{"a1": 1}))
+
+	ctx, cancel := gocontext.WithCancel(gocontext.Background())
+	cancel()
+	msgs, err = ad.AnalyzeNow(AnalysisRequest{Context: ctx})
+	g.Expect(err).To(BeNil())
+	g.Expect(msgs).To(BeEmpty())
+
+	_, err = ad.AnalyzeNow(AnalysisRequest{Analyzers: []string{"unknown"}})
+	g.Expect(err).NotTo(BeNil())
+
+	// On-demand analysis does not update status
+	u.m.RLock()
+	defer u.m.RUnlock()
+	g.Expect(u.messages).To(BeNil())
 }
 
 func TestAnalyzeSuppressesMessages(t *testing.T) {
@@ -348,6 +771,47 @@ func TestAnalyzeSuppressesMessages(t *testing.T) {
 	g.Eventually(u.getMessages()[0].Resource).Should(Equal(r2))
 }
 
+func TestAnalyzeOverridesLevels(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	u := &updaterMock{waitTimeout: 1 * time.Second}
+	r := &resource.Instance{
+		Origin: &rt.Origin{
+			Collection: basicmeta.K8SCollection1.Name(),
+			FullName:   resource.NewFullName("includedNamespace", "r1"),
+			Kind:       "Kind1",
+		},
+	}
+
+	a := &analyzerMock{
+		collectionToAccess: basicmeta.K8SCollection1.Name(),
+		resourcesToReport:  []*resource.Instance{r},
+	}
+	d := NewInMemoryDistributor()
+
+	settings := AnalyzingDistributorSettings{
+		StatusUpdater:      u,
+		Analyzer:           analysis.Combine("testCombined", a),
+		Distributor:        d,
+		AnalysisSnapshots:  []string{snapshots.Default},
+		TriggerSnapshot:    snapshots.Default,
+		CollectionReporter: nil,
+		AnalysisNamespaces: []resource.Namespace{"includedNamespace"},
+		LevelOverrides:     map[string]diag.Level{"IST0001": diag.Info}, // InternalError, reported by analyzerMock
+	}
+	ad := NewAnalyzingDistributor(settings)
+
+	sDefault := getTestSnapshot()
+
+	ad.Distribute(snapshots.Default, sDefault)
+
+	g.Eventually(a.getAnalyzeCalls).Should(ConsistOf(sDefault))
+
+	g.Eventually(u.getMessages).Should(HaveLen(1))
+	g.Expect(u.getMessages()[0].Type.Level()).To(Equal(diag.Info))
+	g.Expect(u.getMessages()[0].Type.Code()).To(Equal("IST0001"))
+}
+
 func TestAnalyzeSuppressesMessagesWithWildcards(t *testing.T) {
 	g := NewGomegaWithT(t)
 
@@ -495,6 +959,38 @@ func TestAnalyzeSuppressesMessagesWhenResourceIsAnnotated(t *testing.T) {
 
 }
 
+// Analyzers may share a context and run concurrently. Run with -race.
+func TestContextConcurrentUse(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	ctx := &context{
+		sn:                 getTestSnapshot(basicmeta.K8SCollection1),
+		ctx:                gocontext.Background(),
+		collectionReporter: func(collection.Name) {},
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			idx := analysis.Index(ctx, "count", func() interface{} {
+				count := 0
+				ctx.ForEach(basicmeta.K8SCollection1.Name(), func(*resource.Instance) bool {
+					count++
+					return true
+				})
+				return count
+			})
+			g.Expect(idx).To(Equal(0))
+			ctx.Report(basicmeta.K8SCollection1.Name(), msg.NewInternalError(nil, "msg"))
+		}()
+	}
+	wg.Wait()
+
+	g.Expect(ctx.reported()).To(HaveLen(10))
+}
+
 func getTestSnapshot(schemas ...collection.Schema) *Snapshot {
 	c := make([]*coll.Instance, 0)
 	for _, s := range schemas {
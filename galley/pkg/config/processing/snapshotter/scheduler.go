@@ -0,0 +1,119 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshotter
+
+import (
+	"sync"
+	"time"
+)
+
+// scheduler coalesces bursts of triggers into single runs of a function. A run starts once no trigger has arrived
+// for the debounce period, but no later than maxDelay after the first trigger of a burst. Consecutive runs start at
+// least minInterval apart.
+type scheduler struct {
+	debounce    time.Duration
+	maxDelay    time.Duration
+	minInterval time.Duration
+	run         func()
+	now         func() time.Time
+
+	mu         sync.Mutex
+	timer      *time.Timer
+	generation int64
+	burstStart time.Time
+	lastRun    time.Time
+	stopped    bool
+}
+
+func newScheduler(debounce, maxDelay, minInterval time.Duration, run func()) *scheduler {
+	return &scheduler{
+		debounce:    debounce,
+		maxDelay:    maxDelay,
+		minInterval: minInterval,
+		run:         run,
+		now:         time.Now,
+	}
+}
+
+// trigger schedules a run, or postpones the pending one. It does nothing once the scheduler is stopped.
+func (s *scheduler) trigger() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stopped {
+		return
+	}
+
+	now := s.now()
+	if s.burstStart.IsZero() {
+		s.burstStart = now
+	}
+
+	at := s.next(now)
+	if s.timer != nil {
+		s.timer.Stop()
+	}
+	// A timer that fired before it could be stopped must not run, as the run has been rescheduled.
+	s.generation++
+	generation := s.generation
+	s.timer = time.AfterFunc(at.Sub(now), func() {
+		s.fire(generation)
+	})
+}
+
+// stop cancels the pending run, if any, and ignores later triggers. A run that already started is not waited for.
+func (s *scheduler) stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stopped = true
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	// A timer that fired before it could be stopped must not run either.
+	s.generation++
+}
+
+// next returns the start time of the run for a trigger at the given time. Must be called with mu held.
+func (s *scheduler) next(now time.Time) time.Time {
+	at := now.Add(s.debounce)
+	if s.maxDelay > 0 {
+		if deadline := s.burstStart.Add(s.maxDelay); at.After(deadline) {
+			at = deadline
+		}
+	}
+	if !s.lastRun.IsZero() {
+		if earliest := s.lastRun.Add(s.minInterval); at.Before(earliest) {
+			at = earliest
+		}
+	}
+
+	return at
+}
+
+func (s *scheduler) fire(generation int64) {
+	s.mu.Lock()
+	if generation != s.generation {
+		s.mu.Unlock()
+		return
+	}
+	s.timer = nil
+	s.burstStart = time.Time{}
+	s.lastRun = s.now()
+	s.mu.Unlock()
+
+	s.run()
+}
@@ -0,0 +1,143 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshotter
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func newTestScheduler(debounce, maxDelay, minInterval time.Duration) (*scheduler, *int32) {
+	var runs int32
+	s := newScheduler(debounce, maxDelay, minInterval, func() {
+		atomic.AddInt32(&runs, 1)
+	})
+	return s, &runs
+}
+
+func loadRuns(runs *int32) func() int32 {
+	return func() int32 {
+		return atomic.LoadInt32(runs)
+	}
+}
+
+func TestSchedulerCoalescesBursts(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	s, runs := newTestScheduler(50*time.Millisecond, 0, 0)
+	for i := 0; i < 10; i++ {
+		s.trigger()
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	g.Eventually(loadRuns(runs)).Should(Equal(int32(1)))
+	g.Consistently(loadRuns(runs), 150*time.Millisecond).Should(Equal(int32(1)))
+}
+
+func TestSchedulerMaxDelay(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	// Triggers keep arriving within the debounce period, but the burst is cut off after the maximum delay.
+	s, runs := newTestScheduler(100*time.Millisecond, 200*time.Millisecond, 0)
+	start := time.Now()
+	for time.Since(start) < 600*time.Millisecond {
+		s.trigger()
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	g.Expect(atomic.LoadInt32(runs)).To(BeNumerically(">=", 2))
+}
+
+func TestSchedulerMinInterval(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	s, runs := newTestScheduler(0, 0, 300*time.Millisecond)
+
+	s.trigger()
+	g.Eventually(loadRuns(runs)).Should(Equal(int32(1)))
+
+	// The second run is held back until the minimum interval has passed, and coalesces the triggers meanwhile.
+	s.trigger()
+	s.trigger()
+	g.Consistently(loadRuns(runs), 200*time.Millisecond).Should(Equal(int32(1)))
+	g.Eventually(loadRuns(runs)).Should(Equal(int32(2)))
+	g.Consistently(loadRuns(runs), 100*time.Millisecond).Should(Equal(int32(2)))
+}
+
+func TestSchedulerNext(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	s, _ := newTestScheduler(time.Minute, 3*time.Minute, 10*time.Minute)
+	now := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	// Debounced
+	s.burstStart = now
+	g.Expect(s.next(now)).To(Equal(now.Add(time.Minute)))
+	g.Expect(s.next(now.Add(time.Minute))).To(Equal(now.Add(2 * time.Minute)))
+
+	// Capped by the maximum delay since the start of the burst
+	g.Expect(s.next(now.Add(150 * time.Second))).To(Equal(now.Add(3 * time.Minute)))
+
+	// Held back by the minimum interval since the last run
+	s.lastRun = now.Add(-time.Minute)
+	g.Expect(s.next(now)).To(Equal(now.Add(9 * time.Minute)))
+	s.lastRun = now.Add(-time.Hour)
+	g.Expect(s.next(now)).To(Equal(now.Add(time.Minute)))
+
+	// No maximum delay
+	s.maxDelay = 0
+	g.Expect(s.next(now.Add(time.Hour))).To(Equal(now.Add(61 * time.Minute)))
+}
+
+func TestSchedulerIgnoresStaleTimers(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	s, runs := newTestScheduler(time.Hour, 0, 0)
+	s.trigger()
+	s.mu.Lock()
+	generation := s.generation
+	s.mu.Unlock()
+	s.trigger()
+
+	s.fire(generation)
+	g.Expect(atomic.LoadInt32(runs)).To(Equal(int32(0)))
+
+	s.mu.Lock()
+	s.timer.Stop()
+	s.mu.Unlock()
+}
+
+func TestSchedulerStop(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	s, runs := newTestScheduler(50*time.Millisecond, 0, 0)
+	s.trigger()
+	s.mu.Lock()
+	generation := s.generation
+	s.mu.Unlock()
+
+	// Neither the pending run, nor a timer that fired concurrently, nor later triggers run after stopping.
+	s.stop()
+	s.fire(generation)
+	s.trigger()
+	g.Consistently(loadRuns(runs), 150*time.Millisecond).Should(Equal(int32(0)))
+
+	s.mu.Lock()
+	g.Expect(s.timer).To(BeNil())
+	s.mu.Unlock()
+}
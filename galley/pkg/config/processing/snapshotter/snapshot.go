@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"sync"
 
 	mcp "istio.io/api/mcp/v1alpha1"
 
@@ -30,12 +31,29 @@ import (
 )
 
 // Snapshot is an implementation of MCP's snapshot.Snapshot interface. It also exposes additional query methods
-// for analysis purposes.
+// for analysis purposes. Snapshots are immutable, and can be shared across goroutines.
 type Snapshot struct {
 	set *coll.Set
+
+	indexesMu sync.Mutex
+	indexes   map[string]*derivedIndex
+}
+
+type derivedIndex struct {
+	once  sync.Once
+	value interface{}
 }
 
 var _ snapshot.Snapshot = &Snapshot{}
+var _ analysis.IndexProvider = &Snapshot{}
+
+// NewSnapshot returns a new Snapshot of the given collections. The collections are not cloned, but they are frozen
+// and can't be modified afterwards.
+func NewSnapshot(collections []*coll.Instance) *Snapshot {
+	set := coll.NewSetFromCollections(collections)
+	set.Freeze()
+	return &Snapshot{set: set}
+}
 
 // Resources implements snapshotImpl.Snapshot
 func (s *Snapshot) Resources(col string) []*mcp.Resource {
@@ -83,6 +101,15 @@ func (s *Snapshot) Collections() []string {
 	return result
 }
 
+// generations returns the generation of each collection in the snapshot.
+func (s *Snapshot) generations() map[collection.Name]int64 {
+	result := make(map[collection.Name]int64)
+	for _, n := range s.set.Names() {
+		result[n] = s.set.Collection(n).Generation()
+	}
+	return result
+}
+
 // Find the resource with the given name and collection.
 func (s *Snapshot) Find(cpl collection.Name, name resource.FullName) *resource.Instance {
 	c := s.set.Collection(cpl)
@@ -101,6 +128,26 @@ func (s *Snapshot) ForEach(col collection.Name, fn analysis.IteratorFn) {
 	c.ForEach(fn)
 }
 
+// Index implements analysis.IndexProvider. Indexes are built at most once per snapshot, even when requested
+// concurrently.
+func (s *Snapshot) Index(key string, build func() interface{}) interface{} {
+	s.indexesMu.Lock()
+	if s.indexes == nil {
+		s.indexes = make(map[string]*derivedIndex)
+	}
+	idx, ok := s.indexes[key]
+	if !ok {
+		idx = &derivedIndex{}
+		s.indexes[key] = idx
+	}
+	s.indexesMu.Unlock()
+
+	idx.once.Do(func() {
+		idx.value = build()
+	})
+	return idx.value
+}
+
 // String implements io.Stringer
 func (s *Snapshot) String() string {
 	var b strings.Builder
@@ -15,6 +15,8 @@
 package snapshotter
 
 import (
+	"sync"
+	"sync/atomic"
 	"testing"
 
 	. "github.com/onsi/gomega"
@@ -73,3 +75,57 @@ func TestSnapshot_WrongCollection(t *testing.T) {
 	g.Expect(sn.Version("foo")).To(Equal(""))
 	g.Expect(sn.Resources("foo")).To(BeEmpty())
 }
+
+func TestSnapshot_Immutable(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	c := coll.New(basicmeta.K8SCollection1)
+	c.Set(data.EntryN1I1V1)
+	sn := NewSnapshot([]*coll.Instance{c})
+
+	g.Expect(func() { c.Set(data.EntryN2I2V2) }).To(Panic())
+	g.Expect(sn.Find(basicmeta.K8SCollection1.Name(), data.EntryN2I2V2.Metadata.FullName)).To(BeNil())
+}
+
+// Analyzers request derived indexes concurrently. Run with -race.
+func TestSnapshot_IndexConcurrent(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	c := coll.New(basicmeta.K8SCollection1)
+	c.Set(data.EntryN1I1V1)
+	c.Set(data.EntryN2I2V2)
+	sn := NewSnapshot([]*coll.Instance{c})
+
+	var builds int32
+	build := func() interface{} {
+		atomic.AddInt32(&builds, 1)
+		names := make(map[resource.FullName]bool)
+		sn.ForEach(basicmeta.K8SCollection1.Name(), func(r *resource.Instance) bool {
+			names[r.Metadata.FullName] = true
+			return true
+		})
+		return names
+	}
+
+	var wg sync.WaitGroup
+	results := make([]map[resource.FullName]bool, 20)
+	for i := range results {
+		i := i
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = sn.Index("names", build).(map[resource.FullName]bool)
+		}()
+	}
+	wg.Wait()
+
+	g.Expect(atomic.LoadInt32(&builds)).To(Equal(int32(1)))
+	for _, r := range results {
+		g.Expect(r).To(HaveLen(2))
+		g.Expect(r[data.EntryN1I1V1.Metadata.FullName]).To(BeTrue())
+	}
+
+	// Different keys are built separately
+	g.Expect(sn.Index("other", func() interface{} { return 42 })).To(Equal(42))
+	g.Expect(atomic.LoadInt32(&builds)).To(Equal(int32(1)))
+}
@@ -218,8 +218,7 @@ func (s *Snapshotter) publish(o SnapshotOptions) {
 		collections = append(collections, col)
 	}
 
-	set := coll.NewSetFromCollections(collections)
-	sn := &Snapshot{set: set}
+	sn := NewSnapshot(collections)
 
 	s.markSnapshotTime()
 	atomic.StoreInt64(&s.pendingEvents, 0)
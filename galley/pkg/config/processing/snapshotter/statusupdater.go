@@ -27,6 +27,20 @@ type StatusUpdater interface {
 	Update(messages diag.Messages)
 }
 
+type combinedStatusUpdater []StatusUpdater
+
+// CombineStatusUpdaters returns a StatusUpdater that passes the messages to each of the given updaters in turn.
+func CombineStatusUpdaters(updaters ...StatusUpdater) StatusUpdater {
+	return combinedStatusUpdater(updaters)
+}
+
+// Update implements StatusUpdater
+func (c combinedStatusUpdater) Update(messages diag.Messages) {
+	for _, u := range c {
+		u.Update(messages)
+	}
+}
+
 // InMemoryStatusUpdater is an in-memory implementation of StatusUpdater
 type InMemoryStatusUpdater struct {
 	WaitTimeout time.Duration
@@ -63,7 +77,7 @@ func (u *InMemoryStatusUpdater) Get() diag.Messages {
 }
 
 // WaitForReport blocks until a report is available. Returns nil if a report is available, or an error representing why we couldn't get it.
-func (u *InMemoryStatusUpdater) WaitForReport(cancelCh chan struct{}) error {
+func (u *InMemoryStatusUpdater) WaitForReport(cancelCh <-chan struct{}) error {
 	// Short-circuit to handle the case where Update got called before WaitForReport
 	u.mu.Lock()
 	if u.updated {
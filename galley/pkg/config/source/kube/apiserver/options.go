@@ -19,6 +19,7 @@ import (
 
 	"istio.io/istio/galley/pkg/config/source/kube"
 	"istio.io/istio/galley/pkg/config/source/kube/apiserver/status"
+	"istio.io/istio/galley/pkg/config/source/kube/rt"
 	"istio.io/istio/pkg/config/schema/collection"
 )
 
@@ -34,4 +35,8 @@ type Options struct {
 	StatusController status.Controller
 
 	WatchedNamespaces string
+
+	// Compactor, if set, compacts the watched objects before they enter the informer stores, to reduce the memory
+	// they use, e.g. for the analysis of very large clusters.
+	Compactor *rt.Compactor
 }
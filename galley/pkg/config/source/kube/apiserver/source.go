@@ -127,7 +127,7 @@ func (s *Source) Start() {
 	scope.Source.Infof("Beginning CRD Discovery, to figure out resources that are available...")
 	s.provider = rt.NewProvider(s.options.Client, s.options.WatchedNamespaces, s.options.ResyncPeriod)
 	a := s.provider.GetAdapter(crdKubeResource.Resource())
-	s.crdWatcher = newWatcher(crdKubeResource, a, s.statusCtl)
+	s.crdWatcher = newWatcher(crdKubeResource, a, s.statusCtl, nil)
 	s.crdWatcher.dispatch(event.HandlerFromFn(s.onCrdEvent))
 	s.crdWatcher.start()
 }
@@ -212,7 +212,7 @@ func (s *Source) startWatchers() {
 			scope.Source.Debuga("Source.Start: sending immediate FullSync for: ", r.Name())
 			s.handlers.Handle(event.FullSyncFor(r))
 		} else {
-			col := newWatcher(r, a, s.statusCtl)
+			col := newWatcher(r, a, s.statusCtl, s.options.Compactor)
 			col.dispatch(s.handlers)
 			s.watchers[r.Name()] = col
 		}
@@ -35,17 +35,19 @@ type watcher struct {
 	adapter   *rt.Adapter
 	schema    collection.Schema
 	statusCtl status.Controller
+	compactor *rt.Compactor
 
 	handler event.Handler
 
 	done chan struct{}
 }
 
-func newWatcher(r collection.Schema, a *rt.Adapter, s status.Controller) *watcher {
+func newWatcher(r collection.Schema, a *rt.Adapter, s status.Controller, c *rt.Compactor) *watcher {
 	return &watcher{
 		schema:    r,
 		adapter:   a,
 		statusCtl: s,
+		compactor: c,
 		handler:   event.SentinelHandler(),
 	}
 }
@@ -59,7 +61,7 @@ func (w *watcher) start() {
 
 	scope.Source.Debugf("Starting watcher for %q (%q)", w.schema.Name(), w.schema.Resource().GroupVersionKind())
 
-	informer, err := w.adapter.NewInformer()
+	informer, err := w.adapter.NewInformer(w.compactor)
 	if err != nil {
 		scope.Source.Errorf("unable to start watcher for %q: %v", w.schema.Resource().GroupVersionKind(), err)
 		// Send a FullSync event, even if the informer is not available. This will ensure that the processing backend
@@ -221,6 +221,10 @@ func (s *KubeSource) parseContent(r *collection.Schemas, name, yamlText string)
 		}
 
 		chunk := bytes.TrimSpace(doc)
+		if kubeyaml.IsEmpty(chunk) {
+			// Documents without content, such as the ones Helm renders for disabled templates, are skipped
+			continue
+		}
 		r, err := s.parseChunk(r, name, lineNum, chunk)
 		if err != nil {
 			var uerr *unknownSchemaError
@@ -19,6 +19,7 @@ import (
 
 	. "github.com/onsi/gomega"
 
+	"istio.io/istio/galley/pkg/config/source/kube/rt"
 	"istio.io/istio/galley/pkg/config/testing/basicmeta"
 	"istio.io/istio/galley/pkg/config/testing/data"
 	"istio.io/istio/galley/pkg/config/testing/fixtures"
@@ -347,6 +348,25 @@ func TestKubeSource_CanHandleDocumentSeparatorInComments(t *testing.T) {
 	g.Expect(s.ContentNames()).To(Equal(map[string]struct{}{"foo": {}}))
 }
 
+func TestKubeSource_HelmOutput(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	s, _ := setupKubeSource()
+	s.Start()
+	defer s.Stop()
+
+	// As rendered by helm template, with a disabled template
+	content := "---\n# Source: chart/templates/disabled.yaml\n---\n# Source: chart/templates/kind1.yaml" + data.YamlN1I1V1
+
+	err := s.ApplyContent("-", content)
+	g.Expect(err).To(BeNil())
+
+	actual := s.Get(basicmeta.K8SCollection1.Name()).AllSorted()
+	g.Expect(actual).To(HaveLen(1))
+	fixtures.ExpectEqual(t, removeEntryOrigins(actual)[0], data.EntryN1I1V1)
+	g.Expect(actual[0].Origin.(*rt.Origin).Ref).To(Equal(&rt.Position{Filename: "-", Line: 6}))
+}
+
 func setupKubeSource() (*KubeSource, *fixtures.Accumulator) {
 	s := NewKubeSource(basicmeta.MustGet().KubeCollections())
 
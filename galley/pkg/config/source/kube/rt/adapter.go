@@ -50,9 +50,10 @@ func (p *Adapter) ExtractResource(o interface{}) (proto.Message, error) {
 	return p.extractResource(o)
 }
 
-// NewInformer creates a new k8s informer for resources of this type.
-func (p *Adapter) NewInformer() (cache.SharedInformer, error) {
-	return p.newInformer()
+// NewInformer creates a new k8s informer for resources of this type. If c is not nil, it compacts the objects of the
+// informer, unless the informer is shared through the informer factory of the Provider.
+func (p *Adapter) NewInformer(c *Compactor) (cache.SharedInformer, error) {
+	return p.newInformer(c)
 }
 
 // ParseJSON parses the given JSON into a k8s object of this type.
@@ -104,7 +105,7 @@ func (p *Adapter) JSONToEntry(s string) (*resource.Instance, error) {
 
 type extractObjectFn func(o interface{}) metav1.Object
 type extractResourceFn func(o interface{}) (proto.Message, error)
-type newInformerFn func() (cache.SharedIndexInformer, error)
+type newInformerFn func(c *Compactor) (cache.SharedIndexInformer, error)
 type parseJSONFn func(input []byte) (interface{}, error)
 type getStatusFn func(o interface{}) interface{}
 type isEqualFn func(o1 interface{}, o2 interface{}) bool
@@ -0,0 +1,215 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rt
+
+import (
+	"sync"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+)
+
+// Fields that a Compactor can drop from the objects it compacts.
+const (
+	// ManagedFieldsField is the server side apply bookkeeping of all objects.
+	ManagedFieldsField = "metadata.managedFields"
+
+	// LastAppliedConfigurationField is the annotation kubectl apply stores a copy of the applied object in.
+	LastAppliedConfigurationField = "metadata.annotations[" + lastAppliedConfigurationAnnotation + "]"
+
+	// PodStatusField is the status of Pods.
+	PodStatusField = "Pod.status"
+
+	// PodEnvField is the environment of the containers of Pods.
+	PodEnvField = "Pod.spec.containers.env"
+
+	// PodVolumesField is the volumes of Pods, and the volume mounts of their containers.
+	PodVolumesField = "Pod.spec.volumes"
+)
+
+const lastAppliedConfigurationAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+
+// DroppableFields are all the fields that a Compactor can drop.
+var DroppableFields = []string{
+	ManagedFieldsField,
+	LastAppliedConfigurationField,
+	PodStatusField,
+	PodEnvField,
+	PodVolumesField,
+}
+
+// Compactor reduces the memory used by the objects of a source. It interns the strings that many objects share, such
+// as namespaces and labels, and drops the given fields.
+//
+// Objects are compacted in place, so they must not have been handed out to anyone else yet. Informers use a Compactor
+// through ListerWatcher, which compacts the objects before they enter the informer's store. Interned strings are kept
+// for the lifetime of the Compactor. It is safe for concurrent use.
+type Compactor struct {
+	mu      sync.Mutex
+	strings map[string]string
+	drop    map[string]bool
+}
+
+// NewCompactor returns a Compactor dropping the given fields, which are some of DroppableFields.
+func NewCompactor(drop ...string) *Compactor {
+	c := &Compactor{
+		strings: make(map[string]string),
+		drop:    make(map[string]bool, len(drop)),
+	}
+	for _, f := range drop {
+		c.drop[f] = true
+	}
+	return c
+}
+
+// ListerWatcher returns a ListerWatcher that compacts the objects listed and watched by lw. A nil Compactor returns lw
+// unchanged.
+func (c *Compactor) ListerWatcher(lw cache.ListerWatcher) cache.ListerWatcher {
+	if c == nil {
+		return lw
+	}
+	return &compactingListerWatcher{ListerWatcher: lw, c: c}
+}
+
+// Compact compacts the given object in place.
+func (c *Compactor) Compact(o interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if obj, ok := o.(metav1.Object); ok {
+		c.compactMetadata(obj)
+	}
+
+	if pod, ok := o.(*v1.Pod); ok {
+		c.compactPod(pod)
+	}
+}
+
+func (c *Compactor) compactMetadata(obj metav1.Object) {
+	obj.SetNamespace(c.intern(obj.GetNamespace()))
+	obj.SetGenerateName(c.intern(obj.GetGenerateName()))
+
+	if c.drop[ManagedFieldsField] && obj.GetManagedFields() != nil {
+		obj.SetManagedFields(nil)
+	}
+
+	if l := obj.GetLabels(); l != nil {
+		obj.SetLabels(c.internMap(l, ""))
+	}
+
+	if a := obj.GetAnnotations(); a != nil {
+		var skip string
+		if c.drop[LastAppliedConfigurationField] {
+			skip = lastAppliedConfigurationAnnotation
+		}
+		obj.SetAnnotations(c.internMap(a, skip))
+	}
+
+	if refs := obj.GetOwnerReferences(); refs != nil {
+		for i := range refs {
+			refs[i].APIVersion = c.intern(refs[i].APIVersion)
+			refs[i].Kind = c.intern(refs[i].Kind)
+		}
+		obj.SetOwnerReferences(refs)
+	}
+}
+
+func (c *Compactor) compactPod(pod *v1.Pod) {
+	if c.drop[PodStatusField] {
+		pod.Status = v1.PodStatus{}
+	}
+	if c.drop[PodVolumesField] {
+		pod.Spec.Volumes = nil
+	}
+
+	pod.Spec.NodeName = c.intern(pod.Spec.NodeName)
+	pod.Spec.ServiceAccountName = c.intern(pod.Spec.ServiceAccountName)
+	c.compactContainers(pod.Spec.InitContainers)
+	c.compactContainers(pod.Spec.Containers)
+}
+
+func (c *Compactor) compactContainers(containers []v1.Container) {
+	for i := range containers {
+		container := &containers[i]
+		container.Name = c.intern(container.Name)
+		container.Image = c.intern(container.Image)
+		if c.drop[PodEnvField] {
+			container.Env = nil
+			container.EnvFrom = nil
+		}
+		if c.drop[PodVolumesField] {
+			container.VolumeMounts = nil
+		}
+	}
+}
+
+// internMap returns a copy of m with interned keys and values, without the given key.
+func (c *Compactor) internMap(m map[string]string, skip string) map[string]string {
+	result := make(map[string]string, len(m))
+	for k, v := range m {
+		if k == skip {
+			continue
+		}
+		result[c.intern(k)] = c.intern(v)
+	}
+	return result
+}
+
+func (c *Compactor) intern(s string) string {
+	if s == "" {
+		return s
+	}
+	if interned, ok := c.strings[s]; ok {
+		return interned
+	}
+	c.strings[s] = s
+	return s
+}
+
+type compactingListerWatcher struct {
+	cache.ListerWatcher
+	c *Compactor
+}
+
+// List implements cache.Lister
+func (lw *compactingListerWatcher) List(options metav1.ListOptions) (runtime.Object, error) {
+	list, err := lw.ListerWatcher.List(options)
+	if err != nil {
+		return nil, err
+	}
+	err = meta.EachListItem(list, func(o runtime.Object) error {
+		lw.c.Compact(o)
+		return nil
+	})
+	return list, err
+}
+
+// Watch implements cache.Watcher
+func (lw *compactingListerWatcher) Watch(options metav1.ListOptions) (watch.Interface, error) {
+	w, err := lw.ListerWatcher.Watch(options)
+	if err != nil {
+		return nil, err
+	}
+	return watch.Filter(w, func(e watch.Event) (watch.Event, bool) {
+		if e.Type != watch.Error && e.Object != nil {
+			lw.c.Compact(e.Object)
+		}
+		return e, true
+	}), nil
+}
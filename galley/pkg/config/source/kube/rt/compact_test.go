@@ -0,0 +1,135 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rt_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+
+	"istio.io/istio/galley/pkg/config/source/kube/rt"
+)
+
+func compactTestPod() *coreV1.Pod {
+	return &coreV1.Pod{
+		ObjectMeta: metaV1.ObjectMeta{
+			Name:      "ratings-1",
+			Namespace: "default",
+			Labels:    map[string]string{"app": "ratings"},
+			Annotations: map[string]string{
+				"kubectl.kubernetes.io/last-applied-configuration": "{}",
+				"sidecar.istio.io/status":                          "{}",
+			},
+			ManagedFields: []metaV1.ManagedFieldsEntry{{Manager: "kubectl"}},
+		},
+		Spec: coreV1.PodSpec{
+			Containers: []coreV1.Container{{
+				Name:         "ratings",
+				Image:        "docker.io/istio/examples-bookinfo-ratings-v1:1.15.0",
+				Env:          []coreV1.EnvVar{{Name: "LOG_LEVEL", Value: "debug"}},
+				VolumeMounts: []coreV1.VolumeMount{{Name: "tmp", MountPath: "/tmp"}},
+			}},
+			Volumes: []coreV1.Volume{{Name: "tmp"}},
+		},
+		Status: coreV1.PodStatus{Phase: coreV1.PodRunning},
+	}
+}
+
+func TestCompactDropsFields(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	pod := compactTestPod()
+	rt.NewCompactor(rt.DroppableFields...).Compact(pod)
+
+	g.Expect(pod.ManagedFields).To(BeNil())
+	g.Expect(pod.Annotations).To(Equal(map[string]string{"sidecar.istio.io/status": "{}"}))
+	g.Expect(pod.Labels).To(Equal(map[string]string{"app": "ratings"}))
+	g.Expect(pod.Status).To(Equal(coreV1.PodStatus{}))
+	g.Expect(pod.Spec.Volumes).To(BeNil())
+	g.Expect(pod.Spec.Containers[0].Env).To(BeNil())
+	g.Expect(pod.Spec.Containers[0].VolumeMounts).To(BeNil())
+	g.Expect(pod.Spec.Containers[0].Image).To(Equal("docker.io/istio/examples-bookinfo-ratings-v1:1.15.0"))
+}
+
+func TestCompactKeepsFields(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	pod := compactTestPod()
+	rt.NewCompactor().Compact(pod)
+
+	g.Expect(pod).To(Equal(compactTestPod()))
+}
+
+func TestCompactKeepsCustomResourceSpec(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	u := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "networking.istio.io/v1alpha3",
+		"kind":       "Gateway",
+		"metadata": map[string]interface{}{
+			"name":          "gw",
+			"namespace":     "default",
+			"managedFields": []interface{}{map[string]interface{}{"manager": "kubectl"}},
+		},
+		"spec": map[string]interface{}{"selector": map[string]interface{}{"istio": "ingressgateway"}},
+	}}
+	rt.NewCompactor(rt.DroppableFields...).Compact(u)
+
+	g.Expect(u.Object).To(HaveKey("spec"))
+	g.Expect(u.GetManagedFields()).To(BeNil())
+	g.Expect(u.GetNamespace()).To(Equal("default"))
+}
+
+func TestCompactorListerWatcher(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	w := watch.NewFake()
+	lw := rt.NewCompactor(rt.PodStatusField).ListerWatcher(&cache.ListWatch{
+		ListFunc: func(metaV1.ListOptions) (runtime.Object, error) {
+			return &coreV1.PodList{Items: []coreV1.Pod{*compactTestPod()}}, nil
+		},
+		WatchFunc: func(metaV1.ListOptions) (watch.Interface, error) {
+			return w, nil
+		},
+	})
+
+	list, err := lw.List(metaV1.ListOptions{})
+	g.Expect(err).To(BeNil())
+	g.Expect(list.(*coreV1.PodList).Items[0].Status).To(Equal(coreV1.PodStatus{}))
+
+	watcher, err := lw.Watch(metaV1.ListOptions{})
+	g.Expect(err).To(BeNil())
+	defer watcher.Stop()
+
+	go w.Add(compactTestPod())
+	e := <-watcher.ResultChan()
+	g.Expect(e.Type).To(Equal(watch.Added))
+	g.Expect(e.Object.(*coreV1.Pod).Status).To(Equal(coreV1.PodStatus{}))
+	g.Expect(e.Object.(*coreV1.Pod).Spec.Volumes).NotTo(BeNil())
+}
+
+func TestNilCompactorListerWatcher(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	lw := &cache.ListWatch{}
+	var c *rt.Compactor
+	g.Expect(c.ListerWatcher(lw)).To(BeIdenticalTo(lw))
+}
@@ -55,7 +55,7 @@ func (p *Provider) getDynamicAdapter(r resource.Schema) *Adapter {
 			return pr, nil
 		},
 
-		newInformer: func() (cache.SharedIndexInformer, error) {
+		newInformer: func(c *Compactor) (cache.SharedIndexInformer, error) {
 			d, err := p.GetDynamicResourceInterface(r)
 			if err != nil {
 				return nil, err
@@ -73,7 +73,7 @@ func (p *Provider) getDynamicAdapter(r resource.Schema) *Adapter {
 				}
 			})
 
-			informer := cache.NewSharedIndexInformer(mlw, &unstructured.Unstructured{}, p.resyncPeriod,
+			informer := cache.NewSharedIndexInformer(c.ListerWatcher(mlw), &unstructured.Unstructured{}, p.resyncPeriod,
 				cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
 
 			return informer, nil
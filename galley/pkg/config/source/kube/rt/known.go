@@ -20,6 +20,7 @@ import (
 	"reflect"
 
 	"github.com/gogo/protobuf/proto"
+	admissionv1beta1 "k8s.io/api/admissionregistration/v1beta1"
 	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/api/extensions/v1beta1"
@@ -49,7 +50,7 @@ func (p *Provider) initKnownAdapters() {
 				}
 				return nil, fmt.Errorf("unable to convert to v1.Service: %T", o)
 			},
-			newInformer: func() (cache.SharedIndexInformer, error) {
+			newInformer: func(c *Compactor) (cache.SharedIndexInformer, error) {
 				client, err := p.interfaces.KubeClient()
 				if err != nil {
 					return nil, err
@@ -67,7 +68,7 @@ func (p *Provider) initKnownAdapters() {
 						}
 					})
 
-				informer := cache.NewSharedIndexInformer(mlw, &v1.Service{}, p.resyncPeriod,
+				informer := cache.NewSharedIndexInformer(c.ListerWatcher(mlw), &v1.Service{}, p.resyncPeriod,
 					cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
 
 				return informer, nil
@@ -94,7 +95,7 @@ func (p *Provider) initKnownAdapters() {
 				}
 				return nil, fmt.Errorf("unable to convert to v1.Namespace: %T", o)
 			},
-			newInformer: func() (cache.SharedIndexInformer, error) {
+			newInformer: func(*Compactor) (cache.SharedIndexInformer, error) {
 				informer, err := p.sharedInformerFactory()
 				if err != nil {
 					return nil, err
@@ -124,7 +125,7 @@ func (p *Provider) initKnownAdapters() {
 				}
 				return nil, fmt.Errorf("unable to convert to v1.Node: %T", o)
 			},
-			newInformer: func() (cache.SharedIndexInformer, error) {
+			newInformer: func(*Compactor) (cache.SharedIndexInformer, error) {
 				informer, err := p.sharedInformerFactory()
 				if err != nil {
 					return nil, err
@@ -154,7 +155,7 @@ func (p *Provider) initKnownAdapters() {
 				}
 				return nil, fmt.Errorf("unable to convert to v1.Pod: %T", o)
 			},
-			newInformer: func() (cache.SharedIndexInformer, error) {
+			newInformer: func(c *Compactor) (cache.SharedIndexInformer, error) {
 				client, err := p.interfaces.KubeClient()
 				if err != nil {
 					return nil, err
@@ -172,7 +173,7 @@ func (p *Provider) initKnownAdapters() {
 						}
 					})
 
-				informer := cache.NewSharedIndexInformer(mlw, &v1.Pod{}, p.resyncPeriod,
+				informer := cache.NewSharedIndexInformer(c.ListerWatcher(mlw), &v1.Pod{}, p.resyncPeriod,
 					cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
 
 				return informer, nil
@@ -199,7 +200,7 @@ func (p *Provider) initKnownAdapters() {
 				}
 				return nil, fmt.Errorf("unable to convert to v1.Secret: %T", o)
 			},
-			newInformer: func() (cache.SharedIndexInformer, error) {
+			newInformer: func(c *Compactor) (cache.SharedIndexInformer, error) {
 				client, err := p.interfaces.KubeClient()
 				if err != nil {
 					return nil, err
@@ -217,7 +218,7 @@ func (p *Provider) initKnownAdapters() {
 						}
 					})
 
-				informer := cache.NewSharedIndexInformer(mlw, &v1.Secret{}, p.resyncPeriod,
+				informer := cache.NewSharedIndexInformer(c.ListerWatcher(mlw), &v1.Secret{}, p.resyncPeriod,
 					cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
 
 				return informer, nil
@@ -243,7 +244,7 @@ func (p *Provider) initKnownAdapters() {
 				}
 				return nil, fmt.Errorf("unable to convert to v1.Endpoints: %T", o)
 			},
-			newInformer: func() (cache.SharedIndexInformer, error) {
+			newInformer: func(c *Compactor) (cache.SharedIndexInformer, error) {
 				client, err := p.interfaces.KubeClient()
 				if err != nil {
 					return nil, err
@@ -261,7 +262,7 @@ func (p *Provider) initKnownAdapters() {
 						}
 					})
 
-				informer := cache.NewSharedIndexInformer(mlw, &v1.Endpoints{}, p.resyncPeriod,
+				informer := cache.NewSharedIndexInformer(c.ListerWatcher(mlw), &v1.Endpoints{}, p.resyncPeriod,
 					cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
 
 				return informer, nil
@@ -299,7 +300,7 @@ func (p *Provider) initKnownAdapters() {
 				}
 				return nil, fmt.Errorf("unable to convert to v1beta1.Ingress: %T", o)
 			},
-			newInformer: func() (cache.SharedIndexInformer, error) {
+			newInformer: func(c *Compactor) (cache.SharedIndexInformer, error) {
 				client, err := p.interfaces.KubeClient()
 				if err != nil {
 					return nil, err
@@ -317,7 +318,7 @@ func (p *Provider) initKnownAdapters() {
 						}
 					})
 
-				informer := cache.NewSharedIndexInformer(mlw, &v1beta1.Ingress{}, p.resyncPeriod,
+				informer := cache.NewSharedIndexInformer(c.ListerWatcher(mlw), &v1beta1.Ingress{}, p.resyncPeriod,
 					cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
 
 				return informer, nil
@@ -341,20 +342,20 @@ func (p *Provider) initKnownAdapters() {
 				}
 				return nil, fmt.Errorf("unable to convert to v1beta1.Ingress: %T", o)
 			},
-			newInformer: func() (cache.SharedIndexInformer, error) {
+			newInformer: func(c *Compactor) (cache.SharedIndexInformer, error) {
 				ext, err := p.interfaces.APIExtensionsClientset()
 				if err != nil {
 					return nil, err
 				}
 				inf := cache.NewSharedIndexInformer(
-					&cache.ListWatch{
+					c.ListerWatcher(&cache.ListWatch{
 						ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
 							return ext.ApiextensionsV1beta1().CustomResourceDefinitions().List(context.TODO(), options)
 						},
 						WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
 							return ext.ApiextensionsV1beta1().CustomResourceDefinitions().Watch(context.TODO(), options)
 						},
-					},
+					}),
 					&v1beta12.CustomResourceDefinition{},
 					0,
 					cache.Indexers{})
@@ -382,7 +383,7 @@ func (p *Provider) initKnownAdapters() {
 				}
 				return nil, fmt.Errorf("unable to convert to v1.Deployment: %T", o)
 			},
-			newInformer: func() (cache.SharedIndexInformer, error) {
+			newInformer: func(c *Compactor) (cache.SharedIndexInformer, error) {
 				client, err := p.interfaces.KubeClient()
 				if err != nil {
 					return nil, err
@@ -400,7 +401,7 @@ func (p *Provider) initKnownAdapters() {
 						}
 					})
 
-				informer := cache.NewSharedIndexInformer(mlw, &appsv1.Deployment{}, p.resyncPeriod,
+				informer := cache.NewSharedIndexInformer(c.ListerWatcher(mlw), &appsv1.Deployment{}, p.resyncPeriod,
 					cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
 
 				return informer, nil
@@ -425,7 +426,7 @@ func (p *Provider) initKnownAdapters() {
 				}
 				return nil, fmt.Errorf("unable to convert to v1.ConfigMap: %T", o)
 			},
-			newInformer: func() (cache.SharedIndexInformer, error) {
+			newInformer: func(c *Compactor) (cache.SharedIndexInformer, error) {
 				client, err := p.interfaces.KubeClient()
 				if err != nil {
 					return nil, err
@@ -443,7 +444,7 @@ func (p *Provider) initKnownAdapters() {
 						}
 					})
 
-				informer := cache.NewSharedIndexInformer(mlw, &v1.ConfigMap{}, p.resyncPeriod,
+				informer := cache.NewSharedIndexInformer(c.ListerWatcher(mlw), &v1.ConfigMap{}, p.resyncPeriod,
 					cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
 
 				return informer, nil
@@ -459,6 +460,62 @@ func (p *Provider) initKnownAdapters() {
 			isEqual:   resourceVersionsMatch,
 			isBuiltIn: true,
 		},
+
+		asTypesKey("admissionregistration.k8s.io", "MutatingWebhookConfiguration"): {
+			extractObject: defaultExtractObject,
+			extractResource: func(o interface{}) (proto.Message, error) {
+				if obj, ok := o.(*admissionv1beta1.MutatingWebhookConfiguration); ok {
+					return obj, nil
+				}
+				return nil, fmt.Errorf("unable to convert to v1beta1.MutatingWebhookConfiguration: %T", o)
+			},
+			newInformer: func(*Compactor) (cache.SharedIndexInformer, error) {
+				informer, err := p.sharedInformerFactory()
+				if err != nil {
+					return nil, err
+				}
+
+				return informer.Admissionregistration().V1beta1().MutatingWebhookConfigurations().Informer(), nil
+			},
+			parseJSON: func(input []byte) (interface{}, error) {
+				out := &admissionv1beta1.MutatingWebhookConfiguration{}
+				if _, _, err := deserializer.Decode(input, nil, out); err != nil {
+					return nil, err
+				}
+				return out, nil
+			},
+			getStatus: noStatus,
+			isEqual:   resourceVersionsMatch,
+			isBuiltIn: true,
+		},
+
+		asTypesKey("admissionregistration.k8s.io", "ValidatingWebhookConfiguration"): {
+			extractObject: defaultExtractObject,
+			extractResource: func(o interface{}) (proto.Message, error) {
+				if obj, ok := o.(*admissionv1beta1.ValidatingWebhookConfiguration); ok {
+					return obj, nil
+				}
+				return nil, fmt.Errorf("unable to convert to v1beta1.ValidatingWebhookConfiguration: %T", o)
+			},
+			newInformer: func(*Compactor) (cache.SharedIndexInformer, error) {
+				informer, err := p.sharedInformerFactory()
+				if err != nil {
+					return nil, err
+				}
+
+				return informer.Admissionregistration().V1beta1().ValidatingWebhookConfigurations().Informer(), nil
+			},
+			parseJSON: func(input []byte) (interface{}, error) {
+				out := &admissionv1beta1.ValidatingWebhookConfiguration{}
+				if _, _, err := deserializer.Decode(input, nil, out); err != nil {
+					return nil, err
+				}
+				return out, nil
+			},
+			getStatus: noStatus,
+			isEqual:   resourceVersionsMatch,
+			isBuiltIn: true,
+		},
 	}
 }
 
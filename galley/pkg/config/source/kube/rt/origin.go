@@ -31,9 +31,13 @@ type Origin struct {
 	FullName   resource.FullName
 	Version    resource.Version
 	Ref        resource.Reference
+
+	// Cluster is the name of the cluster the resource was read from, if the analysis spans multiple clusters.
+	Cluster string
 }
 
 var _ resource.Origin = &Origin{}
+var _ resource.ClusterOrigin = &Origin{}
 var _ resource.Reference = &Position{}
 
 // FriendlyName implements resource.Origin
@@ -62,6 +66,16 @@ func (o *Origin) Reference() resource.Reference {
 	return o.Ref
 }
 
+// ClusterName implements resource.ClusterOrigin
+func (o *Origin) ClusterName() string {
+	return o.Cluster
+}
+
+// ResourceVersion implements resource.ClusterOrigin
+func (o *Origin) ResourceVersion() resource.Version {
+	return o.Version
+}
+
 // Position is a representation of the location of a source.
 type Position struct {
 	Filename string // filename, if any
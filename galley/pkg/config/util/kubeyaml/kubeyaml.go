@@ -102,24 +102,20 @@ func (r *YAMLReader) Read() ([]byte, int, error) {
 			return nil, startLine, err
 		}
 
-		// detect beginning of the chunk
-		if !bytes.Equal(line, []byte("\n")) && !bytes.Equal(line, []byte(yamlSeparator)) && !foundStart {
+		// detect beginning of the chunk. Comments are skipped, so that the line of a document rendered by Helm is
+		// that of its content, rather than that of the comment naming its template.
+		if !bytes.Equal(line, []byte("\n")) && !isSeparator(line) && !isComment(line) && !foundStart {
 			startLine = r.currLine
 			foundStart = true
 		}
 
-		sep := len([]byte(separator))
-		if i := bytes.Index(line, []byte(separator)); i == 0 {
-			// We have a potential document terminator
-			i += sep
-			after := line[i:]
-			if len(strings.TrimRightFunc(string(after), unicode.IsSpace)) == 0 {
-				if buffer.Len() != 0 {
-					return buffer.Bytes(), startLine, nil
-				}
-				if err == io.EOF {
-					return nil, startLine, err
-				}
+		if isSeparator(line) {
+			// We have a document terminator
+			if buffer.Len() != 0 {
+				return buffer.Bytes(), startLine, nil
+			}
+			if err == io.EOF {
+				return nil, startLine, err
 			}
 		}
 		if err == io.EOF {
@@ -133,6 +129,31 @@ func (r *YAMLReader) Read() ([]byte, int, error) {
 	}
 }
 
+// IsEmpty returns whether the given document has no content besides whitespace, comments and document separators,
+// as rendered by Helm for templates that are disabled.
+func IsEmpty(doc []byte) bool {
+	for _, line := range bytes.Split(doc, []byte("\n")) {
+		if len(bytes.TrimSpace(line)) != 0 && !isComment(line) && !isSeparator(line) {
+			return false
+		}
+	}
+	return true
+}
+
+// isSeparator returns whether the line is a document separator, which may be followed by a comment.
+func isSeparator(line []byte) bool {
+	if !bytes.HasPrefix(line, []byte(separator)) {
+		return false
+	}
+	after := line[len(separator):]
+	trimmed := bytes.TrimSpace(after)
+	return len(trimmed) == 0 || (unicode.IsSpace(rune(after[0])) && trimmed[0] == '#')
+}
+
+func isComment(line []byte) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(line), []byte("#"))
+}
+
 type LineReader struct {
 	reader *bufio.Reader
 }
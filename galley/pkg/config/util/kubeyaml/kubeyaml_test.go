@@ -125,6 +125,10 @@ func TestLineNumber(t *testing.T) {
 			input:       "---\n\nfoo: bar\n---\n\n\nfoo: baz",
 			lineNumbers: []int{3, 7},
 		},
+		{
+			input:       "---\n# Source: a.yaml\nfoo: bar\n--- # Source: b.yaml\n\nfoo: baz",
+			lineNumbers: []int{3, 6},
+		},
 	}
 	for i, tc := range testCases {
 		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
@@ -144,3 +148,13 @@ func TestLineNumber(t *testing.T) {
 		})
 	}
 }
+
+func TestIsEmpty(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	g.Expect(IsEmpty(nil)).To(BeTrue())
+	g.Expect(IsEmpty([]byte("\n  \n"))).To(BeTrue())
+	g.Expect(IsEmpty([]byte("---\n# Source: chart/templates/disabled.yaml\n"))).To(BeTrue())
+	g.Expect(IsEmpty([]byte("# Source: chart/templates/kind1.yaml\nfoo: bar\n"))).To(BeFalse())
+	g.Expect(IsEmpty([]byte("---#foo\n"))).To(BeFalse())
+}
@@ -15,8 +15,10 @@
 package components
 
 import (
+	"context"
 	"fmt"
 	"net"
+	"os"
 	"strings"
 	"sync"
 	"time"
@@ -32,7 +34,13 @@ import (
 	"istio.io/pkg/log"
 	"istio.io/pkg/version"
 
+	"istio.io/istio/galley/pkg/config/analysis"
 	"istio.io/istio/galley/pkg/config/analysis/analyzers"
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+	"istio.io/istio/galley/pkg/config/analysis/history"
+	"istio.io/istio/galley/pkg/config/analysis/notify"
+	"istio.io/istio/galley/pkg/config/analysis/sink"
+	"istio.io/istio/galley/pkg/config/analysis/upgrade"
 	"istio.io/istio/galley/pkg/config/processing"
 	"istio.io/istio/galley/pkg/config/processing/snapshotter"
 	"istio.io/istio/galley/pkg/config/processor"
@@ -75,6 +83,11 @@ type Processing struct {
 	mcpSource     *source.Server
 	reporter      monitoring.Reporter
 	callOut       *callout
+	analyzerMutex sync.RWMutex
+	analyzer      *snapshotter.AnalyzingDistributor
+	history       *history.Recorder
+	sink          sink.MessageSink
+	stopAnalysis  context.CancelFunc
 	listenerMutex sync.Mutex
 	listener      net.Listener
 	stopCh        chan struct{}
@@ -119,16 +132,74 @@ func (p *Processing) Start() (err error) {
 	var distributor snapshotter.Distributor = snapshotter.NewMCPDistributor(p.mcpCache)
 
 	if p.args.EnableConfigAnalysis {
-		combinedAnalyzer := analyzers.AllCombined()
+		analyzerConfig := &analyzers.Config{}
+		if p.args.ConfigAnalysisConfigFile != "" {
+			if analyzerConfig, err = analyzers.ReadConfigFile(p.args.ConfigAnalysisConfigFile); err != nil {
+				return
+			}
+		}
+		var selected []analysis.Analyzer
+		if selected, err = analyzerConfig.Analyzers(); err != nil {
+			return
+		}
+		var levels map[string]diag.Level
+		if levels, err = analyzerConfig.Levels(); err != nil {
+			return
+		}
+		if p.args.ConfigAnalysisUpgradeTarget != "" {
+			var ua *upgrade.Analyzer
+			if ua, err = upgrade.NewAnalyzer(p.args.ConfigAnalysisUpgradeTarget, colsInSnapshots); err != nil {
+				return
+			}
+			selected = append(selected, ua)
+		}
+		combinedAnalyzer := analysis.Combine("all", selected...)
 		combinedAnalyzer.RemoveSkipped(colsInSnapshots, kubeResources.DisabledCollectionNames(), transformProviders)
+		combinedAnalyzer.RemoveInapplicable(p.args.ConfigAnalysisIstioVersion)
+
+		// Analysis runs continuously here, so avoid re-running analyzers whose inputs did not change since the last run.
+		combinedAnalyzer.SetResultCache(analysis.NewResultCache())
+		combinedAnalyzer.SetLimits(p.args.ConfigAnalysisLimits)
+		combinedAnalyzer.SetParallelism(p.args.ConfigAnalysisParallelism)
+
+		var messageSink sink.MessageSink
+		if messageSink, err = p.createMessageSink(updater); err != nil {
+			return
+		}
+		updater = &sink.Updater{Sink: messageSink}
+
+		var recorder *history.Recorder
+		if p.args.ConfigAnalysisHistoryConfigMap != "" {
+			if recorder, err = p.createHistoryRecorder(); err != nil {
+				return
+			}
+			updater = snapshotter.CombineStatusUpdaters(updater, recorder)
+		}
 
-		distributor = snapshotter.NewAnalyzingDistributor(snapshotter.AnalyzingDistributorSettings{
-			StatusUpdater:     updater,
-			Analyzer:          combinedAnalyzer,
-			Distributor:       distributor,
-			AnalysisSnapshots: p.args.Snapshots,
-			TriggerSnapshot:   p.args.TriggerSnapshot,
+		analysisCtx, stopAnalysis := context.WithCancel(context.Background())
+		analyzer := snapshotter.NewAnalyzingDistributor(snapshotter.AnalyzingDistributorSettings{
+			StatusUpdater:       updater,
+			Analyzer:            combinedAnalyzer,
+			Distributor:         distributor,
+			AnalysisSnapshots:   p.args.Snapshots,
+			TriggerSnapshot:     p.args.TriggerSnapshot,
+			Profile:             p.args.EnableConfigAnalysisProfiling,
+			AnalysisDebounce:    p.args.ConfigAnalysisDebounce,
+			AnalysisMaxDelay:    p.args.ConfigAnalysisMaxDelay,
+			AnalysisMinInterval: p.args.ConfigAnalysisMinInterval,
+			Propagation:         p.args.ConfigAnalysisPropagation,
+			Context:             analysisCtx,
+			IstioVersion:        p.args.ConfigAnalysisIstioVersion,
+			ScoreWeights:        p.args.ConfigAnalysisScoreWeights,
+			LevelOverrides:      levels,
 		})
+		p.analyzerMutex.Lock()
+		p.analyzer = analyzer
+		p.history = recorder
+		p.sink = messageSink
+		p.stopAnalysis = stopAnalysis
+		p.analyzerMutex.Unlock()
+		distributor = analyzer
 	}
 
 	processorSettings := processor.Settings{
@@ -263,6 +334,70 @@ func (p *Processing) ConfigZTopic() fw.Topic {
 	return p.configzTopic
 }
 
+// AnalysisProfile returns the resource accounting profile of the last config analysis run, or nil if analysis
+// profiling is not enabled or no analysis has completed yet.
+func (p *Processing) AnalysisProfile() *analysis.Profile {
+	a := p.getAnalyzer()
+	if a == nil {
+		return nil
+	}
+	return a.LastProfile()
+}
+
+// AnalysisSnapshot returns the latest snapshot of the configuration being analyzed, or nil if config analysis is not
+// enabled or no snapshot is available yet.
+func (p *Processing) AnalysisSnapshot() *snapshotter.Snapshot {
+	a := p.getAnalyzer()
+	if a == nil {
+		return nil
+	}
+	return a.CombinedSnapshot()
+}
+
+// AnalysisContext returns the analysis context of the latest snapshot of the configuration being analyzed, or nil if
+// config analysis is not enabled or no snapshot is available yet.
+func (p *Processing) AnalysisContext() analysis.Context {
+	a := p.getAnalyzer()
+	if a == nil {
+		return nil
+	}
+	return a.AnalysisContext()
+}
+
+// AnalyzeNow runs config analysis on demand against the latest snapshot. snapshotter.ErrNoSnapshot is returned if
+// config analysis is not enabled or no snapshot is available yet.
+func (p *Processing) AnalyzeNow(r snapshotter.AnalysisRequest) (diag.Messages, error) {
+	a := p.getAnalyzer()
+	if a == nil {
+		return nil, snapshotter.ErrNoSnapshot
+	}
+	return a.AnalyzeNow(r)
+}
+
+// LastMessages returns the messages of the last completed continuous analysis run and the time it completed, or nil
+// and the zero time if config analysis is not enabled or no run has completed yet.
+func (p *Processing) LastMessages() (diag.Messages, time.Time) {
+	a := p.getAnalyzer()
+	if a == nil {
+		return nil, time.Time{}
+	}
+	return a.LastMessages()
+}
+
+// AnalysisHistory returns the recorder of the config analysis findings history, or nil if config analysis or the
+// history are not enabled.
+func (p *Processing) AnalysisHistory() *history.Recorder {
+	p.analyzerMutex.RLock()
+	defer p.analyzerMutex.RUnlock()
+	return p.history
+}
+
+func (p *Processing) getAnalyzer() *snapshotter.AnalyzingDistributor {
+	p.analyzerMutex.RLock()
+	defer p.analyzerMutex.RUnlock()
+	return p.analyzer
+}
+
 func (p *Processing) getServerGrpcOptions() []grpc.ServerOption {
 	var grpcOptions []grpc.ServerOption
 	grpcOptions = append(grpcOptions,
@@ -297,6 +432,52 @@ func (p *Processing) getKubeInterfaces() (k kube.Interfaces, err error) {
 	return
 }
 
+func (p *Processing) createHistoryRecorder() (*history.Recorder, error) {
+	k, err := p.getKubeInterfaces()
+	if err != nil {
+		return nil, err
+	}
+	client, err := k.KubeClient()
+	if err != nil {
+		return nil, err
+	}
+
+	cms := client.CoreV1().ConfigMaps(p.args.ConfigAnalysisHistoryNamespace)
+	return history.NewRecorder(history.Options{
+		Store:     history.NewConfigMapStore(cms, p.args.ConfigAnalysisHistoryConfigMap),
+		Retention: p.args.ConfigAnalysisHistoryRetention,
+	}), nil
+}
+
+// createMessageSink creates the sink of the configured kinds that analysis findings are delivered to. Findings are
+// written to resource statuses through the given updater.
+func (p *Processing) createMessageSink(updater snapshotter.StatusUpdater) (sink.MessageSink, error) {
+	deps := sink.Dependencies{
+		Stdout:        os.Stdout,
+		StatusUpdater: updater,
+		Webhook: notify.Options{
+			Interval: p.args.ConfigAnalysisWebhookInterval,
+		},
+	}
+	if p.args.ConfigPath == "" {
+		k, err := p.getKubeInterfaces()
+		if err != nil {
+			return nil, err
+		}
+		client, err := k.KubeClient()
+		if err != nil {
+			return nil, err
+		}
+		deps.Events = client.CoreV1()
+	}
+
+	specs := append([]string(nil), p.args.ConfigAnalysisSinks...)
+	if p.args.ConfigAnalysisWebhookURL != "" {
+		specs = append(specs, sink.KindWebhook+":"+p.args.ConfigAnalysisWebhookURL)
+	}
+	return sink.New(specs, deps)
+}
+
 func (p *Processing) createSourceAndStatusUpdater(schemas collection.Schemas) (
 	src event.Source, updater snapshotter.StatusUpdater, err error) {
 
@@ -347,6 +528,24 @@ func (p *Processing) Stop() {
 		p.runtime = nil
 	}
 
+	p.analyzerMutex.Lock()
+	if p.analyzer != nil {
+		p.analyzer.Stop()
+	}
+	if p.stopAnalysis != nil {
+		p.stopAnalysis()
+		p.stopAnalysis = nil
+	}
+	if p.sink != nil {
+		if err := p.sink.Flush(); err != nil {
+			scope.Warnf("Error flushing config analysis findings: %v", err)
+		}
+	}
+	p.analyzer = nil
+	p.history = nil
+	p.sink = nil
+	p.analyzerMutex.Unlock()
+
 	p.listenerMutex.Lock()
 	if p.listener != nil {
 		_ = p.listener.Close()
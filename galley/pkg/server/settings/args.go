@@ -27,7 +27,12 @@ import (
 
 	"istio.io/pkg/ctrlz"
 	"istio.io/pkg/probe"
+	"istio.io/pkg/version"
 
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/propagation"
+	"istio.io/istio/galley/pkg/config/analysis/score"
+	"istio.io/istio/galley/pkg/config/analysis/sink"
 	"istio.io/istio/galley/pkg/config/util/kuberesource"
 	"istio.io/istio/pkg/config/constants"
 	"istio.io/istio/pkg/config/schema/snapshots"
@@ -136,6 +141,71 @@ type Args struct { // nolint:maligned
 	// Enable Config Analysis service, that will analyze and update CRD status. UseOldProcessor must be set to false.
 	EnableConfigAnalysis bool
 
+	// Enable resource accounting of each config analysis run. Only effective if EnableConfigAnalysis is set.
+	EnableConfigAnalysisProfiling bool
+
+	// If non-zero, config analysis only runs once config has not changed for this duration. Only effective if
+	// EnableConfigAnalysis is set.
+	ConfigAnalysisDebounce time.Duration
+
+	// If non-zero, debounced config analysis runs no later than this duration after the first change of a burst of
+	// changes. Only effective if EnableConfigAnalysis is set.
+	ConfigAnalysisMaxDelay time.Duration
+
+	// If non-zero, config analysis runs start at least this duration apart. Only effective if EnableConfigAnalysis is
+	// set.
+	ConfigAnalysisMinInterval time.Duration
+
+	// The sinks that config analysis findings are delivered to, as specs of the form <kind>[:<argument>], e.g.
+	// "crd", "events" or "file:/var/run/findings.json". See sink.Kinds for the available kinds. Defaults to
+	// writing findings to the status of the resources. Only effective if EnableConfigAnalysis is set.
+	ConfigAnalysisSinks []string
+
+	// The Istio version that config is analyzed for. Analyzers that do not apply to this version are skipped. If
+	// empty, all analyzers run. Defaults to the version of this binary. Only effective if EnableConfigAnalysis is set.
+	ConfigAnalysisIstioVersion string
+
+	// If set, config is also checked for upgrade blockers: APIs, fields and defaults that change in Istio releases up
+	// to and including this version. Only effective if EnableConfigAnalysis is set.
+	ConfigAnalysisUpgradeTarget string
+
+	// If set, newly appeared Error and Warning config analysis findings are POSTed to this webhook, in a Slack
+	// compatible format. Only effective if EnableConfigAnalysis is set.
+	ConfigAnalysisWebhookURL string
+
+	// The minimum time between two config analysis webhook notifications. Defaults to notify.DefaultInterval.
+	ConfigAnalysisWebhookInterval time.Duration
+
+	// If set, the history of config analysis findings is recorded in the ConfigMap of this name, in
+	// ConfigAnalysisHistoryNamespace. Only effective if EnableConfigAnalysis is set.
+	ConfigAnalysisHistoryConfigMap string
+
+	// The namespace of ConfigAnalysisHistoryConfigMap.
+	ConfigAnalysisHistoryNamespace string
+
+	// The time resolved findings are kept in the config analysis history. Defaults to history.DefaultRetention.
+	ConfigAnalysisHistoryRetention time.Duration
+
+	// ConfigAnalysisPropagation, if set, provides the distribution status of resources to proxies. Findings on
+	// resources that have not fully propagated are annotated with it. Only effective if EnableConfigAnalysis is set.
+	ConfigAnalysisPropagation propagation.Tracker
+
+	// Resources exceeding these limits are skipped by the config analyzers. Defaults to analysis.DefaultLimits.
+	ConfigAnalysisLimits analysis.Limits
+
+	// The weights of findings in the config health score metric. Defaults to score.DefaultWeights. Only effective if
+	// EnableConfigAnalysis is set.
+	ConfigAnalysisScoreWeights score.Weights
+
+	// The number of config analyzers that may run concurrently. Analyzers run one after the other if it is below 2.
+	// Only effective if EnableConfigAnalysis is set.
+	ConfigAnalysisParallelism int
+
+	// The path of a file that selects and configures the analyzers of config analysis, and overrides the levels of
+	// messages, in the format of analyzers.Config, e.g. mounted from a ConfigMap. Only effective if
+	// EnableConfigAnalysis is set.
+	ConfigAnalysisConfigFile string
+
 	// DisableResourceReadyCheck disables the CRD readiness check. This
 	// allows Galley to start when not all supported CRD are
 	// registered with the kube-apiserver.
@@ -199,6 +269,9 @@ func DefaultArgs() *Args {
 		PprofPort:                       9094,
 		WatchConfigFiles:                false,
 		EnableConfigAnalysis:            false,
+		ConfigAnalysisLimits:            analysis.DefaultLimits,
+		ConfigAnalysisSinks:             []string{sink.KindCRD},
+		ConfigAnalysisIstioVersion:      version.Info.Version,
 		Liveness: probe.Options{
 			Path:           defaultLivenessProbeFilePath,
 			UpdateInterval: defaultProbeCheckInterval,
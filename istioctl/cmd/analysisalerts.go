@@ -0,0 +1,74 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"istio.io/istio/galley/pkg/config/analysis/alerts"
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+)
+
+// analysisAlertsCmd generates Prometheus alerting rules from the analysis message catalog
+func analysisAlertsCmd() *cobra.Command {
+	var levels []string
+	o := alerts.Options{}
+	cmd := &cobra.Command{
+		Use:   "analysis-alerts",
+		Short: "Generate Prometheus alerting rules for config analysis messages",
+		Long: `Generates a PrometheusRule with one alert per analysis message code, firing when the control plane's
+continuous config analysis has reported messages with that code for some time. Alerts are generated for all messages
+of the selected levels, optionally restricted to a list of codes.`,
+		Example: `
+# Alert on all Error messages reported for 10 minutes
+istioctl experimental analysis-alerts | kubectl apply -f -
+
+# Also alert on warnings, after 30 minutes
+istioctl experimental analysis-alerts --levels Error,Warn --for 30m
+
+# Alert on missing references only, with the rule in the istio-system namespace
+istioctl experimental analysis-alerts --codes IST0101 -n istio-system
+`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			levelMap := diag.GetUppercaseStringToLevelMap()
+			for _, l := range levels {
+				level, ok := levelMap[strings.ToUpper(l)]
+				if !ok {
+					return fmt.Errorf("invalid level %q, must be one of: %s", l, strings.Join(diag.GetAllLevelStrings(), ", "))
+				}
+				o.Levels = append(o.Levels, level)
+			}
+
+			o.Namespace = namespace
+			y, err := alerts.GenerateYAML(o)
+			if err != nil {
+				return err
+			}
+			_, err = cmd.OutOrStdout().Write(y)
+			return err
+		},
+	}
+	cmd.Flags().StringSliceVar(&levels, "levels", []string{diag.Error.String()},
+		fmt.Sprintf("Levels of the messages to alert on. Valid values: %s", strings.Join(diag.GetAllLevelStrings(), ", ")))
+	cmd.Flags().StringSliceVar(&o.Codes, "codes", nil,
+		"Codes of the messages to alert on. If empty, all messages of the selected levels are alerted on.")
+	cmd.Flags().DurationVar(&o.For, "for", alerts.DefaultFor,
+		"Time a message must be reported before its alert fires.")
+	cmd.Flags().StringVar(&o.Name, "name", alerts.DefaultName, "Name of the generated PrometheusRule.")
+	return cmd
+}
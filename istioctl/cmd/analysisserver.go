@@ -0,0 +1,116 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+
+	"istio.io/istio/galley/pkg/config/analysis/analyzers"
+	"istio.io/istio/galley/pkg/config/analysis/service"
+	"istio.io/istio/pkg/config/resource"
+)
+
+// analysisServerCmd serves the analysis of submitted resources over gRPC and HTTP
+func analysisServerCmd() *cobra.Command {
+	var grpcAddr, httpAddr string
+	var timeout time.Duration
+	cmd := &cobra.Command{
+		Use:   "analysis-server",
+		Short: "Serve config analysis of submitted resources over gRPC and HTTP",
+		Long: `Runs a server that analyzes the Kubernetes resources submitted to it, like istioctl analyze analyzes files,
+so that CI systems, editors and GitOps controllers can validate manifests without running istioctl or a control plane.
+
+Over gRPC, resources are submitted with the AnalyzeResources method of the istio.analysis.v1alpha1.AnalysisService.
+Over HTTP, they are POSTed in YAML or JSON format to /analyze, and the results are returned as JSON. The "analyzer"
+query parameter selects the analyzers to run, the "namespace" query parameter sets the namespace of resources that do
+not specify one, and the "code" query parameter restricts the results to the given message codes.`,
+		Example: `
+# Serve analysis over gRPC and HTTP
+istioctl experimental analysis-server --grpc-address :15098 --http-address :15099
+
+# Analyze a manifest with the HTTP server
+curl --data-binary @bookinfo.yaml "localhost:15099/analyze?namespace=bookinfo"
+`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if grpcAddr == "" && httpAddr == "" {
+				return CommandParseError{errors.New("at least one of --grpc-address and --http-address must be set")}
+			}
+
+			ra := service.NewResourceAnalyzer(analyzers.AllCombined(), resource.Namespace(istioNamespace), timeout)
+			ctx, cancel := contextWithInterrupt()
+			defer cancel()
+			return serveAnalysis(ctx, cmd.ErrOrStderr(), ra, grpcAddr, httpAddr)
+		},
+	}
+	cmd.Flags().StringVar(&grpcAddr, "grpc-address", ":15098",
+		"Address to serve the AnalysisService gRPC service on. Not served if empty.")
+	cmd.Flags().StringVar(&httpAddr, "http-address", "",
+		"Address to serve the /analyze HTTP endpoint on. Not served if empty.")
+	cmd.Flags().DurationVar(&timeout, "timeout", service.DefaultResourceTimeout,
+		"The maximum time allowed for the analysis of a request")
+	return cmd
+}
+
+// serveAnalysis serves the analysis of submitted resources on the given addresses until ctx is canceled or a server
+// fails.
+func serveAnalysis(ctx context.Context, w io.Writer, ra *service.ResourceAnalyzer, grpcAddr, httpAddr string) error {
+	errs := make(chan error, 2)
+
+	if grpcAddr != "" {
+		l, err := net.Listen("tcp", grpcAddr)
+		if err != nil {
+			return err
+		}
+		gs := grpc.NewServer()
+		service.New(nil).WithResources(ra).Register(gs)
+		go func() {
+			errs <- gs.Serve(l)
+		}()
+		defer gs.Stop()
+		fmt.Fprintf(w, "Serving analysis over gRPC on %s\n", l.Addr())
+	}
+
+	if httpAddr != "" {
+		l, err := net.Listen("tcp", httpAddr)
+		if err != nil {
+			return err
+		}
+		mux := http.NewServeMux()
+		mux.Handle("/analyze", service.NewResourcesHTTPHandler(ra))
+		hs := &http.Server{Handler: mux}
+		go func() {
+			errs <- hs.Serve(l)
+		}()
+		defer hs.Close()
+		fmt.Fprintf(w, "Serving analysis over HTTP on %s/analyze\n", l.Addr())
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil
+	case err := <-errs:
+		return err
+	}
+}
@@ -15,9 +15,12 @@
 package cmd
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"runtime"
 	"sort"
@@ -30,17 +33,31 @@ import (
 	"github.com/ghodss/yaml"
 	"github.com/mattn/go-isatty"
 	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8s_labels "k8s.io/apimachinery/pkg/labels"
+	kubeSchema "k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/clientcmd"
 
 	"istio.io/pkg/env"
+	"istio.io/pkg/version"
 
 	"istio.io/istio/galley/pkg/config/analysis"
 	"istio.io/istio/galley/pkg/config/analysis/analyzers"
+	"istio.io/istio/galley/pkg/config/analysis/celcheck"
 	"istio.io/istio/galley/pkg/config/analysis/diag"
 	"istio.io/istio/galley/pkg/config/analysis/local"
+	"istio.io/istio/galley/pkg/config/analysis/opa"
+	"istio.io/istio/galley/pkg/config/analysis/orgpolicy"
+	"istio.io/istio/galley/pkg/config/analysis/plugin"
+	"istio.io/istio/galley/pkg/config/analysis/sarif"
+	"istio.io/istio/galley/pkg/config/analysis/score"
+	"istio.io/istio/galley/pkg/config/analysis/upgrade"
 	cfgKube "istio.io/istio/galley/pkg/config/source/kube"
 	"istio.io/istio/istioctl/pkg/util/handlers"
 	"istio.io/istio/pkg/config/resource"
 	"istio.io/istio/pkg/config/schema"
+	"istio.io/istio/pkg/config/schema/collection"
 	"istio.io/istio/pkg/kube"
 )
 
@@ -55,6 +72,7 @@ const (
 	LogOutput       = "log"
 	JSONOutput      = "json"
 	YamlOutput      = "yaml"
+	SarifOutput     = "sarif"
 )
 
 func (f AnalyzerFoundIssuesError) Error() string {
@@ -81,6 +99,35 @@ var (
 	suppress          []string
 	analysisTimeout   time.Duration
 	recursive         bool
+	profile           bool
+	parallelism       int
+	policyFiles       []string
+	ruleFiles         []string
+	checkFiles        []string
+	plugins           []string
+	limits            = analysis.DefaultLimits
+	istioVersion      string
+	upgradeTarget     string
+	enabledAnalyzers  []string
+	disabledAnalyzers []string
+	configFile        string
+	healthScore       bool
+	scoreWeights      []string
+	remoteContexts    []string
+	suggestFixes      bool
+	explainCode       string
+	applyFixes        bool
+	snapshotFile      string
+	exportSnapshot    string
+	customResources   []string
+	aggregateMin      int
+	maxMessages       int
+	diffFindings      bool
+	baselineFile      string
+	scopeNamespaces   []string
+	scopeSelector     string
+	includeOutOfScope bool
+	configDumpFiles   []string
 
 	termEnvVar = env.RegisterStringVar("TERM", "", "Specifies terminal type.  Use 'dumb' to suppress color output")
 
@@ -96,7 +143,7 @@ var (
 // Analyze command
 func Analyze() *cobra.Command {
 	// Validate the output format before doing potentially expensive work to fail earlier
-	msgOutputFormats := map[string]bool{LogOutput: true, JSONOutput: true, YamlOutput: true}
+	msgOutputFormats := map[string]bool{LogOutput: true, JSONOutput: true, YamlOutput: true, SarifOutput: true}
 	var msgOutputFormatKeys []string
 
 	for k := range msgOutputFormats {
@@ -119,6 +166,15 @@ istioctl analyze --recursive my-istio-config/
 # Analyze yaml files without connecting to a live cluster
 istioctl analyze --use-kube=false a.yaml b.yaml my-app-config/
 
+# Analyze the output of helm template or kustomize build, read from stdin, without connecting to a live cluster
+helm template my-chart | istioctl analyze --use-kube=false -
+
+# Analyze yaml files and write the results in SARIF format, e.g. for code scanning in CI
+istioctl analyze --use-kube=false -o sarif my-app-config/ > analysis.sarif
+
+# Analyze the current live cluster, and only report findings about the resources of a team, in its namespaces
+istioctl analyze --scope-namespace team-a --scope-namespace team-a-staging -l team=a
+
 # Analyze the current live cluster and suppress PodMissingProxy for pod mypod in namespace 'testing'.
 istioctl analyze -S "IST0103=Pod mypod.testing"
 
@@ -126,8 +182,41 @@ istioctl analyze -S "IST0103=Pod mypod.testing"
 # and suppress MisplacedAnnotation on deployment foobar in namespace default.
 istioctl analyze -S "IST0103=Pod *.testing" -S "IST0107=Deployment foobar.default"
 
+# Analyze the current live cluster together with the remote clusters of a multi-cluster mesh
+istioctl analyze --remote-context cluster-east --remote-context cluster-west
+
+# Analyze the current live cluster, and print the fixes of the findings as kubectl patch commands
+istioctl analyze --suggest
+
+# Analyze the current live cluster, and apply the fixes of the findings to it
+istioctl analyze --fix
+
+# Export the resources and mesh config of the current live cluster to an archive, e.g. to reproduce findings elsewhere
+istioctl analyze --export-snapshot cluster-snapshot.tar.gz
+
+# Analyze an exported archive without connecting to a live cluster
+istioctl analyze --snapshot cluster-snapshot.tar.gz
+
+# Analyze the current live cluster with proposed changes, and only fail on findings the changes introduce
+istioctl analyze --diff my-app-config/
+
+# Compare the findings of two exported archives
+istioctl analyze --snapshot after.tar.gz --baseline before.tar.gz
+
+# Analyze the current live cluster, and run an external analyzer enforcing organization specific policies
+istioctl analyze --plugin /usr/local/bin/team-policies
+
+# Also pass the cert-manager certificates of the live cluster to the external analyzer
+istioctl analyze --plugin /usr/local/bin/team-policies --custom-resource cert-manager.io/v1/Certificate
+
 # List available analyzers
 istioctl analyze -L
+
+# List available analyzers and the catalog of messages they report, in JSON
+istioctl analyze -L -o json
+
+# Explain the message with code IST0101
+istioctl analyze --explain IST0101
 `,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			msgOutputFormat = strings.ToLower(msgOutputFormat)
@@ -138,16 +227,61 @@ istioctl analyze -L
 				}
 			}
 
+			if applyFixes && !useKube {
+				return CommandParseError{
+					fmt.Errorf("--fix applies fixes to the live cluster and can't be used with --use-kube=false; use --suggest instead"),
+				}
+			}
+			if applyFixes && snapshotFile != "" {
+				return CommandParseError{
+					fmt.Errorf("--fix applies fixes to the live cluster and can't be used with --snapshot; use --suggest instead"),
+				}
+			}
+			if applyFixes && len(remoteContexts) > 0 {
+				return CommandParseError{
+					fmt.Errorf("--fix only applies fixes to the current cluster and can't be used with --remote-context"),
+				}
+			}
+			if baselineFile != "" {
+				diffFindings = true
+			}
+			if applyFixes && diffFindings {
+				return CommandParseError{fmt.Errorf("--fix can't be used with --diff or --baseline")}
+			}
+
+			// The configuration file provides the settings that were not given as flags.
+			var analysisCfg *analysisConfig
+			if configFile != "" {
+				var err error
+				if analysisCfg, err = loadAnalysisConfig(configFile); err != nil {
+					return CommandParseError{err}
+				}
+				if err := analysisCfg.applyToFlags(cmd.Flags()); err != nil {
+					return CommandParseError{err}
+				}
+			}
+
 			if listAnalyzers {
+				if msgOutputFormat == JSONOutput || msgOutputFormat == YamlOutput {
+					return printCatalog(cmd.OutOrStdout(), msgOutputFormat)
+				}
 				fmt.Print(AnalyzersAsString(analyzers.All()))
+				fmt.Print("\nOptional analyzers, enabled with --enable-analyzer:\n")
+				fmt.Print(AnalyzersAsString(analyzers.Optional()))
 				return nil
 			}
 
+			if explainCode != "" {
+				return explainMessage(cmd.OutOrStdout(), explainCode, msgOutputFormat)
+			}
+
 			readers, err := gatherFiles(cmd, args)
 			if err != nil {
 				return err
 			}
-			cancel := make(chan struct{})
+			// Stop the analysis, including any informers watching the cluster, on Ctrl-C.
+			ctx, cancel := contextWithInterrupt()
+			defer cancel()
 
 			// We use the "namespace" arg that's provided as part of root istioctl as a flag for specifying what namespace to use
 			// for file resources that don't have one specified.
@@ -158,8 +292,62 @@ istioctl analyze -L
 				selectedNamespace = ""
 			}
 
-			sa := local.NewSourceAnalyzer(schema.MustGet(), analyzers.AllCombined(),
-				resource.Namespace(selectedNamespace), resource.Namespace(istioNamespace), nil, true, analysisTimeout)
+			selected, err := analyzers.Select(enabledAnalyzers, disabledAnalyzers)
+			if err != nil {
+				return CommandParseError{fmt.Errorf("%v. See istioctl analyze --list-analyzers", err)}
+			}
+			combined := analysis.Combine("all", selected...)
+			var extra []analysis.Analyzer
+			m, customCollections, err := customResourceMetadata(customResources)
+			if err != nil {
+				return err
+			}
+			// Policies, checks and plugins can inspect the custom resources, and any of the collections the built-in
+			// analyzers use.
+			inputs := append(combined.Metadata().Inputs, customCollections...)
+			if len(policyFiles) > 0 {
+				pa, err := opa.LoadPolicyAnalyzer(inputs, policyFiles...)
+				if err != nil {
+					return err
+				}
+				extra = append(extra, pa)
+			}
+			// User defined checks are read from files, and from labeled ConfigMaps when analyzing a live cluster.
+			ca, err := celcheck.LoadCheckAnalyzer(inputs, checkFiles...)
+			if err != nil {
+				return err
+			}
+			extra = append(extra, ca)
+			if len(ruleFiles) > 0 {
+				ra, err := orgpolicy.LoadRuleAnalyzer(ruleFiles...)
+				if err != nil {
+					return err
+				}
+				extra = append(extra, ra)
+			}
+			for _, p := range plugins {
+				extra = append(extra, plugin.NewExecAnalyzer(inputs, p))
+			}
+			if upgradeTarget != "" {
+				ua, err := upgrade.NewAnalyzer(upgradeTarget, local.AnalysisCollections(m))
+				if err != nil {
+					return err
+				}
+				extra = append(extra, ua)
+			}
+			if len(extra) > 0 {
+				combined = analysis.Combine("all", append(selected, extra...)...)
+			}
+			combined.SetLimits(limits)
+			// Allocations are only attributed to the right analyzer if analyzers run one at a time
+			if !profile {
+				combined.SetParallelism(parallelism)
+			}
+			if analysisCfg != nil {
+				if err := combined.Configure(analysisCfg.Analyzers); err != nil {
+					return CommandParseError{err}
+				}
+			}
 
 			// Check for suppressions and add them to our SourceAnalyzer
 			var suppressions []snapshotter.AnalysisSuppression
@@ -178,7 +366,7 @@ istioctl analyze -L
 					}
 				}
 
-				if !codeIsValid {
+				if !codeIsValid && len(policyFiles) == 0 && len(ruleFiles) == 0 && len(checkFiles) == 0 && len(plugins) == 0 {
 					fmt.Fprintf(cmd.ErrOrStderr(), "Warning: Supplied message code '%s' is an unknown message code and will not have any effect.\n", parts[0])
 				}
 				suppressions = append(suppressions, snapshotter.AnalysisSuppression{
@@ -186,32 +374,77 @@ istioctl analyze -L
 					ResourceName: parts[1],
 				})
 			}
-			sa.SetSuppressions(suppressions)
+			analysisScope, err := analysisScopeFromFlags()
+			if err != nil {
+				return CommandParseError{err}
+			}
+			newSourceAnalyzer := func() *local.SourceAnalyzer {
+				sa := local.NewSourceAnalyzer(m, combined,
+					resource.Namespace(selectedNamespace), resource.Namespace(istioNamespace), nil, true, analysisTimeout)
+				sa.SetSuppressions(suppressions)
+				sa.SetScope(analysisScope)
+				// Verbose output includes per-analyzer timings, which are recorded as part of the profile
+				sa.SetProfiling(profile || verbose)
+				return sa
+			}
 
-			// If we're using kube, use that as a base source.
-			if useKube {
+			// If we're using kube, use that as a base source. A snapshot archive replaces the live cluster.
+			var k cfgKube.Interfaces
+			var kubeConfig clientcmd.ClientConfig
+			if useKube && snapshotFile == "" {
 				// Set up the kube client
-				config := kube.BuildClientCmd(kubeconfig, configContext)
-				restConfig, err := config.ClientConfig()
+				kubeConfig = kube.BuildClientCmd(kubeconfig, configContext)
+				restConfig, err := kubeConfig.ClientConfig()
 				if err != nil {
 					return err
 				}
-				k := cfgKube.NewInterfaces(restConfig)
-				sa.AddRunningKubeSource(k)
+				k = cfgKube.NewInterfaces(restConfig)
 			}
 
-			// If we explicitly specify mesh config, use it.
-			// This takes precedence over default mesh config or mesh config from a running Kube instance.
-			if meshCfgFile != "" {
-				_ = sa.AddFileKubeMeshConfig(meshCfgFile)
-			}
+			// addSources adds the resources that the files are analyzed together with: the live cluster or the
+			// snapshot archive, and otherwise the defaults expected to be provided by Istio.
+			addSources := func(sa *local.SourceAnalyzer, k cfgKube.Interfaces, archive string) error {
+				if k != nil {
+					if len(remoteContexts) == 0 {
+						sa.AddRunningKubeSource(k)
+					} else if err := addKubeClusterSources(sa, kubeConfig, k); err != nil {
+						return err
+					}
+				}
 
-			// If we're not using kube (files only), add defaults for some resources we expect to be provided by Istio
-			if !useKube {
-				err := sa.AddDefaultResources()
-				if err != nil {
-					return err
+				if archive != "" {
+					if err := addSnapshotArchive(sa, archive); err != nil {
+						return err
+					}
+				}
+
+				// An explicitly specified version takes precedence over the one detected in a running Kube instance.
+				if istioVersion != "" {
+					sa.SetIstioVersion(istioVersion)
+				}
+
+				// If we explicitly specify mesh config, use it.
+				// This takes precedence over default mesh config or mesh config from a running Kube instance.
+				if meshCfgFile != "" {
+					_ = sa.AddFileKubeMeshConfig(meshCfgFile)
+				}
+
+				for _, f := range configDumpFiles {
+					if err := sa.AddFileConfigDump(f); err != nil {
+						return err
+					}
+				}
+
+				// If we're not using kube (files only), add defaults for some resources we expect to be provided by Istio
+				if !useKube && archive == "" {
+					return sa.AddDefaultResources()
 				}
+				return nil
+			}
+
+			sa := newSourceAnalyzer()
+			if err := addSources(sa, k, snapshotFile); err != nil {
+				return err
 			}
 
 			// If files are provided, treat them (collectively) as a source.
@@ -223,16 +456,30 @@ istioctl analyze -L
 				}
 			}
 
+			if exportSnapshot != "" {
+				if err := writeSnapshotArchive(ctx, sa, exportSnapshot); err != nil {
+					return err
+				}
+				fmt.Fprintf(cmd.ErrOrStderr(), "Wrote analysis snapshot to %s\n", exportSnapshot)
+				return nil
+			}
+
 			// Do the analysis
-			result, err := sa.Analyze(cancel)
+			result, err := sa.Analyze(ctx)
 
 			if err != nil {
 				return err
 			}
+			if analysisCfg != nil {
+				result.Messages = analysisCfg.filter(result.Messages)
+			}
 
 			// Maybe output details about which analyzers ran
 			if verbose {
 				fmt.Fprintf(cmd.ErrOrStderr(), "Analyzed resources in %s\n", analyzeTargetAsString())
+				if v := sa.IstioVersion(); v != "" {
+					fmt.Fprintf(cmd.ErrOrStderr(), "Checked against Istio version %s\n", v)
+				}
 
 				if len(result.SkippedAnalyzers) > 0 {
 					fmt.Fprintln(cmd.ErrOrStderr(), "Skipped analyzers:")
@@ -246,17 +493,79 @@ istioctl analyze -L
 						fmt.Fprintln(cmd.ErrOrStderr(), "\t", a)
 					}
 				}
+				if result.Profile != nil {
+					fmt.Fprintf(cmd.ErrOrStderr(), "Built snapshot of %d resources in %v\n",
+						result.Profile.TotalEntries(), result.SnapshotDuration)
+					fmt.Fprint(cmd.ErrOrStderr(), result.Profile.Timings())
+				}
 				fmt.Fprintln(cmd.ErrOrStderr())
 			}
 
-			// Filter outputMessages by specified level, and append a ref arg to the doc URL
-			var outputMessages diag.Messages
+			// Maybe output the resource accounting profile of the run
+			if profile && result.Profile != nil {
+				fmt.Fprintln(cmd.ErrOrStderr(), "Analysis profile:")
+				fmt.Fprintln(cmd.ErrOrStderr(), result.Profile.String())
+			}
+
+			// Maybe compare the findings with those of the baseline, and only report the changes
+			if diffFindings {
+				bsa := newSourceAnalyzer()
+				if baselineFile != "" {
+					err = addSources(bsa, nil, baselineFile)
+				} else {
+					err = addSources(bsa, k, snapshotFile)
+				}
+				if err != nil {
+					return err
+				}
+				baseline, err := bsa.Analyze(ctx)
+				if err != nil {
+					return err
+				}
+				if analysisCfg != nil {
+					baseline.Messages = analysisCfg.filter(baseline.Messages)
+				}
+
+				d := diag.Diff(baseline.Messages, result.Messages)
+				shown := diag.Difference{
+					New:        aboveOutputThreshold(d.New),
+					Resolved:   aboveOutputThreshold(d.Resolved),
+					Persisting: aboveOutputThreshold(d.Persisting),
+				}
+				if err := printDifference(cmd.OutOrStdout(), shown, msgOutputFormat); err != nil {
+					return err
+				}
+				if msgOutputFormat == LogOutput && len(shown.New)+len(shown.Resolved)+len(shown.Persisting) == 0 {
+					fmt.Fprintf(cmd.ErrOrStderr(), "\u2714 No validation issues found when analyzing %s.\n", analyzeTargetAsString())
+				}
+
+				// Only the new findings are regressions
+				returnError := errorIfMessagesExceedThreshold(d.New)
+				if returnError == nil && parseErrors > 0 {
+					returnError = FileParseError{}
+				}
+				return returnError
+			}
+
+			// Maybe output the config health scores of the findings
+			if healthScore {
+				weights, err := score.ParseWeights(scoreWeights)
+				if err != nil {
+					return CommandParseError{err}
+				}
+				printHealthScores(cmd.ErrOrStderr(), score.Compute(result.Messages, weights))
+			}
+
+			// Filter outputMessages by specified level, and append a ref arg to the doc URL. The reporter collapses
+			// messages of the same type on many resources, and caps the number of messages.
+			reporter := diag.Reporter{AggregateThreshold: aggregateMin, MaxMessages: maxMessages}
 			for _, m := range result.Messages {
 				if m.Type.Level().IsWorseThanOrEqualTo(outputLevel.Level) {
 					m.DocRef = "istioctl-analyze"
-					outputMessages = append(outputMessages, m)
+					reporter.Add(m)
 				}
 			}
+			outputMessages, omitted := reporter.Report()
 
 			switch msgOutputFormat {
 			case LogOutput:
@@ -293,9 +602,33 @@ istioctl analyze -L
 					return err
 				}
 				fmt.Fprintln(cmd.OutOrStdout(), string(yamlOutput))
+			case SarifOutput:
+				sarifOutput, err := sarif.Marshal(outputMessages, "istioctl", version.Info.Version)
+				if err != nil {
+					return err
+				}
+				fmt.Fprintln(cmd.OutOrStdout(), string(sarifOutput))
 			default: // This should never happen since we validate this already
 				panic(fmt.Sprintf("%q not found in output format switch statement post validate?", msgOutputFormat))
 			}
+			if omitted > 0 {
+				fmt.Fprintf(cmd.ErrOrStderr(), "%d more messages were omitted, see --max-messages.\n", omitted)
+			}
+
+			// Maybe print or apply the fixes of the findings. Structured output formats include the fixes, so the
+			// suggestions go to stderr for them.
+			if suggestFixes {
+				w := cmd.OutOrStdout()
+				if msgOutputFormat != LogOutput {
+					w = cmd.ErrOrStderr()
+				}
+				printFixes(w, withAggregated(outputMessages))
+			}
+			if applyFixes {
+				if err := applyMessageFixes(ctx, cmd.ErrOrStderr(), k, withAggregated(outputMessages)); err != nil {
+					return err
+				}
+			}
 
 			// Return code is based on the unfiltered validation message list/parse errors
 			// We're intentionally keeping failure threshold and output threshold decoupled for now
@@ -314,7 +647,7 @@ istioctl analyze -L
 	analysisCmd.PersistentFlags().BoolVar(&colorize, "color", istioctlColorDefault(analysisCmd),
 		"Default true.  Disable with '=false' or set $TERM to dumb")
 	analysisCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false,
-		"Enable verbose output")
+		"Enable verbose output, including the time spent building the snapshot and in each analyzer")
 	analysisCmd.PersistentFlags().Var(&failureLevel, "failure-threshold",
 		fmt.Sprintf("The severity level of analysis at which to set a non-zero exit code. Valid values: %v", diag.GetAllLevelStrings()))
 	analysisCmd.PersistentFlags().Var(&outputLevel, "output-threshold",
@@ -333,9 +666,185 @@ istioctl analyze -L
 		"the duration to wait before failing")
 	analysisCmd.PersistentFlags().BoolVarP(&recursive, "recursive", "R", false,
 		"Process directory arguments recursively. Useful when you want to analyze related manifests organized within the same directory.")
+	analysisCmd.PersistentFlags().BoolVar(&profile, "profile", false,
+		"Print per-collection entry counts, per-analyzer allocations and peak memory of the analysis run to stderr. "+
+			"Analyzers run one at a time when profiling.")
+	analysisCmd.PersistentFlags().IntVar(&parallelism, "parallelism", runtime.NumCPU(),
+		"The number of analyzers that may run concurrently")
+	analysisCmd.PersistentFlags().StringArrayVar(&policyFiles, "policy", []string{},
+		"Evaluate the Rego policies in the given file as part of the analysis. Violations defined under "+
+			opa.ViolationQuery+" are reported with their own message codes. Can be repeated.")
+	analysisCmd.PersistentFlags().StringArrayVar(&ruleFiles, "rules", []string{},
+		"Evaluate the organization policy rules in the given file as part of the analysis. Violations are reported "+
+			"with the rule IDs as message codes. Can be repeated.")
+	analysisCmd.PersistentFlags().StringArrayVar(&checkFiles, "checks", []string{},
+		"Evaluate the CEL checks in the given file as part of the analysis, in addition to the checks in ConfigMaps "+
+			"labeled "+celcheck.ChecksLabel+"=true. Violations are reported with their own message codes. Can be repeated.")
+	analysisCmd.PersistentFlags().StringArrayVar(&plugins, "plugin", []string{},
+		"Run the given executable as an external analyzer. It receives the analyzed resources as JSON on stdin, and "+
+			"writes the violations it found as JSON to stdout. Violations are reported with their own message codes. "+
+			"Can be repeated.")
+	analysisCmd.PersistentFlags().IntVar(&limits.MaxResourceBytes, "max-resource-size", analysis.DefaultLimits.MaxResourceBytes,
+		"Skip resources larger than this many bytes, and report them as too large to analyze. Set to 0 to disable.")
+	analysisCmd.PersistentFlags().IntVar(&limits.MaxListLength, "max-list-length", analysis.DefaultLimits.MaxListLength,
+		"Skip resources containing lists or maps with more entries than this, and report them as too large to analyze. "+
+			"Set to 0 to disable.")
+	analysisCmd.PersistentFlags().StringVar(&istioVersion, "istio-version", "",
+		"The Istio version to check the configuration against. Analyzers that do not apply to this version are skipped. "+
+			"Defaults to the version of the control plane when analyzing a live cluster, or all analyzers otherwise.")
+	analysisCmd.PersistentFlags().StringVar(&upgradeTarget, "upgrade-target", "",
+		"Check the configuration for upgrade blockers: APIs that are removed, fields that are replaced and defaults that "+
+			"change in Istio releases up to and including this version.")
+	analysisCmd.PersistentFlags().StringArrayVar(&enabledAnalyzers, "enable-analyzer", []string{},
+		"The name of an optional analyzer to run in addition to the default ones. Can be repeated. "+
+			"See --list-analyzers for the optional analyzers.")
+	analysisCmd.PersistentFlags().StringArrayVar(&disabledAnalyzers, "disable-analyzer", []string{},
+		"The name of an analyzer not to run. Can be repeated. See --list-analyzers for the analyzers.")
+	analysisCmd.PersistentFlags().BoolVar(&healthScore, "score", false,
+		"Output a config health score, mesh-wide and per namespace, computed from the weights of the findings. "+
+			"A score of 100 means no findings.")
+	analysisCmd.PersistentFlags().StringArrayVar(&scoreWeights, "score-weight", []string{},
+		"The weight of findings in the health score, as <level>=<weight> or <code>=<weight> (e.g. "+
+			"'--score-weight Error=20 --score-weight IST0101=5'). Can be repeated. Defaults to Error=10, Warning=3 "+
+			"and Info=1.")
+	analysisCmd.PersistentFlags().StringVar(&configFile, "config", "",
+		"An analysis configuration file with the settings of the run, such as thresholds, suppressions, severity "+
+			"overrides, ignored namespaces, analyzer parameters and message templates or translations. Flags that "+
+			"are given explicitly take precedence.")
+	analysisCmd.PersistentFlags().StringArrayVar(&remoteContexts, "remote-context", []string{},
+		"The name of a kubeconfig context of a remote cluster to analyze together with the current one, for "+
+			"multi-cluster meshes. Can be repeated.")
+	analysisCmd.PersistentFlags().StringVar(&explainCode, "explain", "",
+		"Explain the message with the given code, e.g. IST0101: its level, template, description and documentation. "+
+			"Suppresses normal execution.")
+	analysisCmd.PersistentFlags().BoolVar(&suggestFixes, "suggest", false,
+		"Print the fixes that analyzers suggest for their findings, as kubectl patch commands.")
+	analysisCmd.PersistentFlags().BoolVar(&applyFixes, "fix", false,
+		"Apply the fixes that analyzers suggest for their findings to the live cluster. Resources read from files "+
+			"are not changed. Can't be used with --remote-context.")
+	analysisCmd.PersistentFlags().StringVar(&snapshotFile, "snapshot", "",
+		"Analyze a snapshot archive written with --export-snapshot instead of the live cluster.")
+	analysisCmd.PersistentFlags().StringVar(&exportSnapshot, "export-snapshot", "",
+		"Write the resources and mesh config that would be analyzed to the given archive instead of analyzing them. "+
+			"The data of secrets is redacted.")
+	analysisCmd.PersistentFlags().StringArrayVar(&customResources, "custom-resource", []string{},
+		"Read the resources of the given kind, e.g. cert-manager.io/v1/Certificate, so that policies, checks and "+
+			"plugins can inspect them. The kind is given as <group>/<version>/<kind>[/<plural>], and its resources "+
+			"are available in the collection <group>/<version>/<plural>. Can be repeated.")
+	analysisCmd.PersistentFlags().IntVar(&aggregateMin, "aggregate-threshold", 0,
+		"Collapse the messages of a type into a single message listing the affected resources when there are at "+
+			"least this many of them. 0 disables aggregation.")
+	analysisCmd.PersistentFlags().IntVar(&maxMessages, "max-messages", 0,
+		"The maximum number of messages to output. The most severe messages are kept. 0 means no limit.")
+	analysisCmd.PersistentFlags().BoolVar(&diffFindings, "diff", false,
+		"Compare the findings with those of the live cluster or snapshot without the given files, and output them "+
+			"as new, resolved and persisting findings. Only new findings cause a non-zero exit code. The SARIF "+
+			"output only includes the new findings.")
+	analysisCmd.PersistentFlags().StringVar(&baselineFile, "baseline", "",
+		"Compare the findings with those of a snapshot archive written with --export-snapshot, as with --diff.")
+	analysisCmd.PersistentFlags().StringArrayVar(&scopeNamespaces, "scope-namespace", []string{},
+		"Only report findings about resources in the given namespace, instead of the namespace given by "+
+			"--namespace. Resources in other namespaces are still analyzed, e.g. the gateways that resources in the "+
+			"namespace refer to. Can be repeated.")
+	analysisCmd.PersistentFlags().StringVarP(&scopeSelector, "selector", "l", "",
+		"Only report findings about resources whose labels match the label selector, e.g. 'team=a'.")
+	analysisCmd.PersistentFlags().BoolVar(&includeOutOfScope, "include-out-of-scope", false,
+		"Also report findings about resources outside of the namespaces and labels given by --namespace, "+
+			"--scope-namespace and --selector, noted as out of scope.")
+	analysisCmd.PersistentFlags().StringArrayVar(&configDumpFiles, "config-dump", []string{},
+		"Analyze the configuration pushed to a proxy together with the resources, from an Envoy config dump as "+
+			"returned by the /config_dump admin endpoint of the proxy or the /debug/config_dump endpoint of istiod. "+
+			"Enables the analyzers comparing the resources with what got programmed. Can be repeated.")
 	return analysisCmd
 }
 
+// analysisScopeFromFlags returns the analysis scope given by --scope-namespace, --selector and
+// --include-out-of-scope.
+func analysisScopeFromFlags() (snapshotter.AnalysisScope, error) {
+	s := snapshotter.AnalysisScope{IncludeOutOfScope: includeOutOfScope}
+	for _, ns := range scopeNamespaces {
+		s.Namespaces = append(s.Namespaces, resource.Namespace(ns))
+	}
+	if scopeSelector != "" {
+		selector, err := k8s_labels.Parse(scopeSelector)
+		if err != nil {
+			return s, fmt.Errorf("invalid selector %q: %v", scopeSelector, err)
+		}
+		s.Selector = selector
+	}
+	return s, nil
+}
+
+// addSnapshotArchive adds the resources of the snapshot archive at path as sources.
+func addSnapshotArchive(sa *local.SourceAnalyzer, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+	if err := sa.AddSnapshotArchive(f); err != nil {
+		return fmt.Errorf("error reading snapshot %s: %v", path, err)
+	}
+	return nil
+}
+
+// writeSnapshotArchive writes the resources of the sources of the analyzer as a snapshot archive to path.
+func writeSnapshotArchive(ctx context.Context, sa *local.SourceAnalyzer, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := sa.ExportSnapshot(ctx, f); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// addKubeClusterSources adds the current cluster and the remote clusters as sources of a multi-cluster analysis. The
+// clusters are named after their kubeconfig contexts.
+func addKubeClusterSources(sa *local.SourceAnalyzer, config clientcmd.ClientConfig, k cfgKube.Interfaces) error {
+	current := configContext
+	if current == "" {
+		raw, err := config.RawConfig()
+		if err != nil {
+			return err
+		}
+		current = raw.CurrentContext
+	}
+	sa.AddRunningKubeClusterSource(current, k)
+
+	for _, remote := range remoteContexts {
+		restConfig, err := kube.BuildClientCmd(kubeconfig, remote).ClientConfig()
+		if err != nil {
+			return fmt.Errorf("error connecting to the cluster of context %q: %v", remote, err)
+		}
+		sa.AddRunningKubeClusterSource(remote, cfgKube.NewInterfaces(restConfig))
+	}
+	return nil
+}
+
+// customResourceMetadata returns the metadata of the analysis, extended with the given custom resources, and the names
+// of the collections that hold them.
+func customResourceMetadata(kinds []string) (*schema.Metadata, collection.Names, error) {
+	var crs []schema.CustomResource
+	var names collection.Names
+	for _, k := range kinds {
+		cr, err := schema.ParseCustomResource(k)
+		if err != nil {
+			return nil, nil, CommandParseError{err}
+		}
+		crs = append(crs, cr)
+		names = append(names, cr.CollectionName())
+	}
+
+	m, err := schema.MustGet().WithCustomResources(crs...)
+	if err != nil {
+		return nil, nil, CommandParseError{err}
+	}
+	return m, names, nil
+}
+
 func gatherFiles(cmd *cobra.Command, args []string) ([]local.ReaderSource, error) {
 	var readers []local.ReaderSource
 	for _, f := range args {
@@ -446,10 +955,49 @@ func renderMessage(m diag.Message) string {
 		if m.Resource.Origin.Reference() != nil {
 			loc = " " + m.Resource.Origin.Reference().String()
 		}
+		if m.FieldPath != "" {
+			loc += " " + m.FieldPath
+		}
 		origin = " (" + m.Resource.Origin.FriendlyName() + loc + ")"
 	}
 	return fmt.Sprintf(
-		"%s%v%s [%v]%s %s", colorPrefix(m), m.Type.Level(), colorSuffix(), m.Type.Code(), origin, fmt.Sprintf(m.Type.Template(), m.Parameters...))
+		"%s%v%s [%v]%s %s%s%s", colorPrefix(m), m.Type.Level(), colorSuffix(), m.Type.Code(), origin, fmt.Sprintf(m.Type.Template(), m.Parameters...),
+		aggregatedAsString(m), notesAsString(m))
+}
+
+// notesAsString lists the notes of the message.
+func notesAsString(m diag.Message) string {
+	if len(m.Notes) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(m.Notes, " ") + ")"
+}
+
+// aggregatedAsString lists the resources of the messages aggregated into the message.
+func aggregatedAsString(m diag.Message) string {
+	if len(m.Aggregated) == 0 {
+		return ""
+	}
+	var origins []string
+	for _, a := range m.Aggregated {
+		if a.Resource != nil {
+			origins = append(origins, a.Resource.Origin.FriendlyName())
+		}
+	}
+	s := fmt.Sprintf(" (and %d more", len(m.Aggregated))
+	if len(origins) > 0 {
+		s += ": " + strings.Join(origins, ", ")
+	}
+	return s + ")"
+}
+
+// withAggregated returns the messages followed by the messages aggregated into them.
+func withAggregated(messages diag.Messages) diag.Messages {
+	var result diag.Messages
+	for _, m := range messages {
+		result = append(append(result, m), m.Aggregated...)
+	}
+	return result
 }
 
 func istioctlColorDefault(cmd *cobra.Command) bool {
@@ -545,9 +1093,231 @@ func AnalyzersAsString(analyzers []analysis.Analyzer) string {
 	return b.String()
 }
 
+// analyzerEntry is the description of an analyzer in the catalog printed by --list-analyzers.
+type analyzerEntry struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Inputs      []string `json:"inputs"`
+	Optional    bool     `json:"optional"`
+}
+
+// printCatalog writes the analyzers and the message catalog in the given structured output format.
+func printCatalog(w io.Writer, format string) error {
+	var entries []analyzerEntry
+	for _, list := range []struct {
+		analyzers []analysis.Analyzer
+		optional  bool
+	}{
+		{analyzers.All(), false},
+		{analyzers.Optional(), true},
+	} {
+		for _, a := range list.analyzers {
+			m := a.Metadata()
+			inputs := make([]string, 0, len(m.Inputs))
+			for _, in := range m.Inputs {
+				inputs = append(inputs, in.String())
+			}
+			entries = append(entries, analyzerEntry{
+				Name:        m.Name,
+				Description: m.Description,
+				Inputs:      inputs,
+				Optional:    list.optional,
+			})
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name < entries[j].Name
+	})
+
+	return printStructured(w, format, map[string]interface{}{
+		"analyzers": entries,
+		"messages":  msg.Catalog(),
+	})
+}
+
+// explainMessage writes the catalog entry of the message with the given code.
+func explainMessage(w io.Writer, code, format string) error {
+	e, ok := msg.Explain(strings.ToUpper(code))
+	if !ok {
+		return CommandParseError{fmt.Errorf("unknown message code %q", code)}
+	}
+	if format == JSONOutput || format == YamlOutput {
+		return printStructured(w, format, e)
+	}
+	fmt.Fprintf(w, "%s %s (%s)\n", e.Code, e.Name, e.Level)
+	fmt.Fprintf(w, "Description: %s\n", e.Description)
+	fmt.Fprintf(w, "Message: %s\n", e.Template)
+	fmt.Fprintf(w, "Documentation: %s\n", e.DocumentationURL)
+	return nil
+}
+
+// printStructured writes the value as indented JSON or as YAML.
+func printStructured(w io.Writer, format string, v interface{}) error {
+	var out []byte
+	var err error
+	if format == YamlOutput {
+		out, err = yaml.Marshal(v)
+	} else {
+		out, err = json.MarshalIndent(v, "", "\t")
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(w, string(out))
+	return nil
+}
+
+// printDifference writes the new, resolved and persisting findings of the difference. The SARIF format only has the
+// new findings.
+func printDifference(w io.Writer, d diag.Difference, format string) error {
+	switch format {
+	case JSONOutput, YamlOutput:
+		return printStructured(w, format, d)
+	case SarifOutput:
+		out, err := sarif.Marshal(d.New, "istioctl", version.Info.Version)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(w, string(out))
+		return nil
+	}
+
+	for _, section := range []struct {
+		title    string
+		messages diag.Messages
+	}{
+		{"New findings", d.New},
+		{"Resolved findings", d.Resolved},
+		{"Persisting findings", d.Persisting},
+	} {
+		if len(section.messages) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "%s:\n", section.title)
+		for _, m := range section.messages {
+			fmt.Fprintln(w, renderMessage(m))
+		}
+	}
+	return nil
+}
+
+// aboveOutputThreshold returns the messages at the output threshold or above, with a ref arg for the doc URL.
+func aboveOutputThreshold(messages diag.Messages) diag.Messages {
+	var result diag.Messages
+	for _, m := range messages {
+		if m.Type.Level().IsWorseThanOrEqualTo(outputLevel.Level) {
+			m.DocRef = "istioctl-analyze"
+			result = append(result, m)
+		}
+	}
+	return result
+}
+
+// printHealthScores writes the mesh-wide and per namespace health scores, with the findings they are computed from.
+func printHealthScores(w io.Writer, s score.Summary) {
+	fmt.Fprintf(w, "Config health score: %.1f%s\n", s.Mesh.Value, findingsAsString(s.Mesh))
+	for _, ns := range s.Namespaces {
+		fmt.Fprintf(w, "\t%s: %.1f%s\n", ns.Namespace, ns.Value, findingsAsString(ns))
+	}
+}
+
+func findingsAsString(s score.Score) string {
+	var counts []string
+	for _, l := range []diag.Level{diag.Error, diag.Warning, diag.Info} {
+		if n := s.Findings[l.String()]; n > 0 {
+			counts = append(counts, fmt.Sprintf("%d %s", n, l))
+		}
+	}
+	if len(counts) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(counts, ", ") + ")"
+}
+
+// printFixes prints the fixes of the messages as kubectl patch commands.
+func printFixes(w io.Writer, messages diag.Messages) {
+	for _, m := range messages {
+		if m.Fix == nil || m.Resource == nil {
+			continue
+		}
+		fmt.Fprintf(w, "# [%s] %s: %s\n", m.Type.Code(), m.Resource.Origin.FriendlyName(), m.Fix.Description)
+		fmt.Fprintln(w, patchCommand(m.Resource, m.Fix.Patch))
+	}
+}
+
+// patchCommand returns the kubectl command that applies the merge patch to the resource.
+func patchCommand(r *resource.Instance, patch string) string {
+	s := r.Metadata.Schema
+	kind := s.Plural()
+	if s.Group() != "" {
+		kind += "." + s.Group()
+	}
+	namespace := ""
+	if ns := r.Metadata.FullName.Namespace; ns != "" {
+		namespace = " -n " + ns.String()
+	}
+	return fmt.Sprintf("kubectl patch %s %s%s --type merge -p '%s'", kind, r.Metadata.FullName.Name, namespace, patch)
+}
+
+// applyMessageFixes applies the fixes of the messages to the resources of the live cluster. The patch of a fix is
+// computed from the analyzed resource, so at most one fix is applied to a resource; the others are found again by
+// the next run.
+func applyMessageFixes(ctx context.Context, w io.Writer, k cfgKube.Interfaces, messages diag.Messages) error {
+	d, err := k.DynamicInterface()
+	if err != nil {
+		return err
+	}
+
+	fixed := make(map[*resource.Instance]bool)
+	for _, m := range messages {
+		r := m.Resource
+		if m.Fix == nil || r == nil {
+			continue
+		}
+		name := r.Origin.FriendlyName()
+		if r.Origin.Reference() != nil {
+			fmt.Fprintf(w, "Not fixing %s: it was read from %s, not from the cluster\n", name, r.Origin.Reference())
+			continue
+		}
+		if fixed[r] {
+			fmt.Fprintf(w, "Not fixing [%s] on %s yet: another fix was applied to it, analyze again to apply this one\n",
+				m.Type.Code(), name)
+			continue
+		}
+
+		s := r.Metadata.Schema
+		gvr := kubeSchema.GroupVersionResource{Group: s.Group(), Version: s.Version(), Resource: s.Plural()}
+		_, err := d.Resource(gvr).Namespace(r.Metadata.FullName.Namespace.String()).Patch(
+			ctx, r.Metadata.FullName.Name.String(), types.MergePatchType, []byte(m.Fix.Patch), metav1.PatchOptions{})
+		if err != nil {
+			return fmt.Errorf("error fixing [%s] on %s: %v", m.Type.Code(), name, err)
+		}
+		fixed[r] = true
+		fmt.Fprintf(w, "Fixed [%s] on %s: %s\n", m.Type.Code(), name, m.Fix.Description)
+	}
+	return nil
+}
+
 func analyzeTargetAsString() string {
 	if allNamespaces {
 		return "all namespaces"
 	}
 	return fmt.Sprintf("namespace: %s", selectedNamespace)
 }
+
+// contextWithInterrupt returns a context that is canceled when the process is interrupted, or when the returned
+// function is called.
+func contextWithInterrupt() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt)
+	go func() {
+		defer signal.Stop(signals)
+		select {
+		case <-signals:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
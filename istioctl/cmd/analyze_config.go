@@ -0,0 +1,258 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+
+	"github.com/ghodss/yaml"
+	"github.com/spf13/pflag"
+
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/resource"
+)
+
+// analysisConfig is the content of an analysis configuration file, as given with --config. It carries the settings of
+// an analysis run in one file that can be kept under version control, so that CI and local runs behave the same, e.g.:
+//
+//   failureThreshold: Error
+//   suppressions:
+//   - "IST0103=Pod *.testing"
+//   enableAnalyzers:
+//   - destinationrule.ConnectionPoolAnalyzer
+//   disableAnalyzers:
+//   - deprecation.DeprecationAnalyzer
+//   ignoredNamespaces:
+//   - sandbox
+//   severityOverrides:
+//     IST0118: Error
+//   analyzers:
+//     destinationrule.OutlierDetectionAnalyzer:
+//       maxBaseEjectionTime: 10m
+//   templates:
+//     IST0101: "Referenced %s not found: %q. See https://wiki.example.com/runbooks/IST0101"
+//   language: de
+//   translations:
+//     de:
+//       IST0101: "Referenzierte Ressource %s nicht gefunden: %q"
+type analysisConfig struct {
+	// Settings that are also available as flags. Flags that are set explicitly take precedence, except for the
+	// suppressions, which are combined. Relative file paths are relative to the configuration file.
+	FailureThreshold string   `json:"failureThreshold,omitempty"`
+	OutputThreshold  string   `json:"outputThreshold,omitempty"`
+	Suppressions     []string `json:"suppressions,omitempty"`
+	EnableAnalyzers  []string `json:"enableAnalyzers,omitempty"`
+	DisableAnalyzers []string `json:"disableAnalyzers,omitempty"`
+	IstioVersion     string   `json:"istioVersion,omitempty"`
+	MeshConfigFile   string   `json:"meshConfigFile,omitempty"`
+	Policies         []string `json:"policies,omitempty"`
+	Rules            []string `json:"rules,omitempty"`
+	Checks           []string `json:"checks,omitempty"`
+	ScoreWeights     []string `json:"scoreWeights,omitempty"`
+	// AggregateThreshold and MaxMessages limit the size of the output of large clusters.
+	AggregateThreshold int `json:"aggregateThreshold,omitempty"`
+	MaxMessages        int `json:"maxMessages,omitempty"`
+
+	// IgnoredNamespaces are the namespaces whose resources are not reported on.
+	IgnoredNamespaces []string `json:"ignoredNamespaces,omitempty"`
+	// SeverityOverrides changes the level of messages, by message code.
+	SeverityOverrides map[string]string `json:"severityOverrides,omitempty"`
+	// Analyzers holds the parameters of analyzers, by analyzer name.
+	Analyzers map[string]map[string]string `json:"analyzers,omitempty"`
+	// Templates overrides the templates of messages, by message code, e.g. to add links to internal runbooks. The
+	// codes of the messages stay the same.
+	Templates map[string]string `json:"templates,omitempty"`
+	// Language selects the translations to use, which take precedence over Templates.
+	Language string `json:"language,omitempty"`
+	// Translations holds the templates of messages in other languages, by language and message code.
+	Translations map[string]map[string]string `json:"translations,omitempty"`
+
+	// Message types with overridden levels or templates, by code and level
+	overrides map[string]*diag.MessageType
+	levels    map[string]diag.Level
+	templates map[string]string
+}
+
+// loadAnalysisConfig reads an analysis configuration file. Unknown fields are rejected, so that misspelled settings
+// are not silently ignored.
+func loadAnalysisConfig(path string) (*analysisConfig, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading analysis config %q: %v", path, err)
+	}
+	js, err := yaml.YAMLToJSON(b)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing analysis config %q: %v", path, err)
+	}
+
+	c := &analysisConfig{}
+	d := json.NewDecoder(bytes.NewReader(js))
+	d.DisallowUnknownFields()
+	if err := d.Decode(c); err != nil {
+		return nil, fmt.Errorf("error parsing analysis config %q: %v", path, err)
+	}
+
+	c.levels = make(map[string]diag.Level, len(c.SeverityOverrides))
+	for code, level := range c.SeverityOverrides {
+		l, err := LevelFromString(level)
+		if err != nil {
+			return nil, fmt.Errorf("invalid severity override for %s in analysis config %q: %v", code, path, err)
+		}
+		c.levels[code] = l
+	}
+
+	translations, ok := c.Translations[c.Language]
+	if c.Language != "" && !ok {
+		return nil, fmt.Errorf("no translations for language %q in analysis config %q", c.Language, path)
+	}
+	c.templates = make(map[string]string, len(c.Templates)+len(translations))
+	for _, templates := range []map[string]string{c.Templates, translations} {
+		for code, template := range templates {
+			if err := msg.CheckTemplate(code, template); err != nil {
+				return nil, fmt.Errorf("invalid template for %s in analysis config %q: %v", code, path, err)
+			}
+			c.templates[code] = template
+		}
+	}
+	c.overrides = make(map[string]*diag.MessageType)
+
+	dir := filepath.Dir(path)
+	c.MeshConfigFile = resolvePath(dir, c.MeshConfigFile)
+	for _, paths := range [][]string{c.Policies, c.Rules, c.Checks} {
+		for i := range paths {
+			paths[i] = resolvePath(dir, paths[i])
+		}
+	}
+	return c, nil
+}
+
+func resolvePath(dir, path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+// applyToFlags sets the flags that were not set explicitly from the configuration.
+func (c *analysisConfig) applyToFlags(flags *pflag.FlagSet) error {
+	set := func(name string, values ...string) error {
+		if flags.Changed(name) {
+			return nil
+		}
+		for _, v := range values {
+			if err := flags.Set(name, v); err != nil {
+				return fmt.Errorf("invalid %s in analysis config: %v", name, err)
+			}
+		}
+		return nil
+	}
+
+	for name, values := range map[string][]string{
+		"failure-threshold":   nonEmpty(c.FailureThreshold),
+		"output-threshold":    nonEmpty(c.OutputThreshold),
+		"enable-analyzer":     c.EnableAnalyzers,
+		"disable-analyzer":    c.DisableAnalyzers,
+		"istio-version":       nonEmpty(c.IstioVersion),
+		"meshConfigFile":      nonEmpty(c.MeshConfigFile),
+		"policy":              c.Policies,
+		"rules":               c.Rules,
+		"checks":              c.Checks,
+		"score-weight":        c.ScoreWeights,
+		"aggregate-threshold": nonZero(c.AggregateThreshold),
+		"max-messages":        nonZero(c.MaxMessages),
+	} {
+		if err := set(name, values...); err != nil {
+			return err
+		}
+	}
+
+	// Suppressions from the flags add to the ones from the configuration.
+	for _, s := range c.Suppressions {
+		if err := flags.Set("suppress", s); err != nil {
+			return fmt.Errorf("invalid suppress in analysis config: %v", err)
+		}
+	}
+	return nil
+}
+
+func nonEmpty(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return []string{s}
+}
+
+func nonZero(i int) []string {
+	if i == 0 {
+		return nil
+	}
+	return []string{strconv.Itoa(i)}
+}
+
+// filter drops the messages on resources in ignored namespaces, and applies the severity and template overrides.
+func (c *analysisConfig) filter(messages diag.Messages) diag.Messages {
+	ignored := make(map[resource.Namespace]bool, len(c.IgnoredNamespaces))
+	for _, ns := range c.IgnoredNamespaces {
+		ignored[resource.Namespace(ns)] = true
+	}
+
+	result := make(diag.Messages, 0, len(messages))
+	for _, m := range messages {
+		if m.Resource != nil && ignored[m.Resource.Metadata.FullName.Namespace] {
+			continue
+		}
+		m.Type = c.messageType(m.Type)
+		if len(m.Aggregated) > 0 {
+			aggregated := make(diag.Messages, len(m.Aggregated))
+			for i, a := range m.Aggregated {
+				a.Type = c.messageType(a.Type)
+				aggregated[i] = a
+			}
+			m.Aggregated = aggregated
+		}
+		result = append(result, m)
+	}
+	return result
+}
+
+// messageType returns the given message type with the level and template overrides of the configuration applied.
+func (c *analysisConfig) messageType(t *diag.MessageType) *diag.MessageType {
+	level, overrideLevel := c.levels[t.Code()]
+	template, overrideTemplate := c.templates[t.Code()]
+	if !overrideLevel && !overrideTemplate {
+		return t
+	}
+	if !overrideLevel {
+		level = t.Level()
+	}
+	if !overrideTemplate {
+		template = t.Template()
+	}
+
+	// Messages of a type can be reported at another level than the default of the type, so the level is part of the key
+	key := t.Code() + "/" + level.String()
+	mt, ok := c.overrides[key]
+	if !ok {
+		mt = diag.NewMessageType(level, t.Code(), template)
+		c.overrides[key] = mt
+	}
+	return mt
+}
@@ -0,0 +1,229 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/spf13/pflag"
+
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+	"istio.io/istio/galley/pkg/config/source/kube/rt"
+	"istio.io/istio/pkg/config/resource"
+)
+
+func writeAnalysisConfig(g *GomegaWithT, dir, content string) string {
+	path := filepath.Join(dir, "analysis-config.yaml")
+	g.Expect(ioutil.WriteFile(path, []byte(content), 0644)).To(Succeed())
+	return path
+}
+
+func TestLoadAnalysisConfig(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	dir, err := ioutil.TempDir("", "analyze")
+	g.Expect(err).To(BeNil())
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	c, err := loadAnalysisConfig(writeAnalysisConfig(g, dir, `
+failureThreshold: Error
+policies:
+- policies/gateways.rego
+- /etc/policies/all.rego
+meshConfigFile: mesh.yaml
+severityOverrides:
+  IST0118: Warning
+analyzers:
+  destinationrule.OutlierDetectionAnalyzer:
+    maxBaseEjectionTime: 10m
+`))
+	g.Expect(err).To(BeNil())
+	g.Expect(c.FailureThreshold).To(Equal("Error"))
+	g.Expect(c.Policies).To(Equal([]string{filepath.Join(dir, "policies/gateways.rego"), "/etc/policies/all.rego"}))
+	g.Expect(c.MeshConfigFile).To(Equal(filepath.Join(dir, "mesh.yaml")))
+	g.Expect(c.levels).To(Equal(map[string]diag.Level{"IST0118": diag.Warning}))
+	g.Expect(c.Analyzers).To(Equal(map[string]map[string]string{
+		"destinationrule.OutlierDetectionAnalyzer": {"maxBaseEjectionTime": "10m"},
+	}))
+
+	_, err = loadAnalysisConfig(writeAnalysisConfig(g, dir, "failureTreshold: Error\n"))
+	g.Expect(err).To(MatchError(ContainSubstring(`unknown field "failureTreshold"`)))
+
+	_, err = loadAnalysisConfig(writeAnalysisConfig(g, dir, "severityOverrides:\n  IST0118: Fatal\n"))
+	g.Expect(err).To(MatchError(ContainSubstring("invalid severity override for IST0118")))
+
+	_, err = loadAnalysisConfig(filepath.Join(dir, "missing.yaml"))
+	g.Expect(err).NotTo(BeNil())
+}
+
+func TestLoadAnalysisConfigTemplates(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	dir, err := ioutil.TempDir("", "analyze")
+	g.Expect(err).To(BeNil())
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	c, err := loadAnalysisConfig(writeAnalysisConfig(g, dir, `
+templates:
+  IST0101: "Referenced %s not found: %q. See https://wiki.example.com/IST0101"
+  IST0118: "Port name %s (port: %d, targetPort: %s) doesn't follow the naming convention. See https://wiki.example.com/IST0118"
+language: de
+translations:
+  de:
+    IST0101: "Referenzierte Ressource %s nicht gefunden: %q"
+  fr:
+    IST0101: "Ressource %s introuvable : %q"
+`))
+	g.Expect(err).To(BeNil())
+	g.Expect(c.templates).To(Equal(map[string]string{
+		"IST0101": "Referenzierte Ressource %s nicht gefunden: %q",
+		"IST0118": "Port name %s (port: %d, targetPort: %s) doesn't follow the naming convention. See https://wiki.example.com/IST0118",
+	}))
+
+	_, err = loadAnalysisConfig(writeAnalysisConfig(g, dir, "templates:\n  IST0101: \"Referenced %s not found\"\n"))
+	g.Expect(err).To(MatchError(ContainSubstring("invalid template for IST0101")))
+
+	_, err = loadAnalysisConfig(writeAnalysisConfig(g, dir, "templates:\n  IST9999: \"%s\"\n"))
+	g.Expect(err).To(MatchError(ContainSubstring("unknown message code")))
+
+	_, err = loadAnalysisConfig(writeAnalysisConfig(g, dir, "language: de\n"))
+	g.Expect(err).To(MatchError(ContainSubstring(`no translations for language "de"`)))
+}
+
+func TestAnalysisConfigApplyToFlags(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	var (
+		failure   = messageThreshold{diag.Warning}
+		output    = messageThreshold{diag.Info}
+		suppress  []string
+		policies  []string
+		analyzers []string
+		disabled  []string
+	)
+	flags := pflag.NewFlagSet("analyze", pflag.ContinueOnError)
+	flags.Var(&failure, "failure-threshold", "")
+	flags.Var(&output, "output-threshold", "")
+	flags.StringArrayVar(&suppress, "suppress", []string{}, "")
+	flags.StringArrayVar(&policies, "policy", []string{}, "")
+	flags.StringArrayVar(&analyzers, "enable-analyzer", []string{}, "")
+	flags.StringArrayVar(&disabled, "disable-analyzer", []string{}, "")
+	flags.String("istio-version", "", "")
+	flags.String("meshConfigFile", "", "")
+	flags.StringArray("rules", []string{}, "")
+	flags.StringArray("checks", []string{}, "")
+	flags.StringArray("score-weight", []string{}, "")
+	flags.Int("aggregate-threshold", 0, "")
+	flags.Int("max-messages", 0, "")
+	g.Expect(flags.Parse([]string{"--output-threshold", "Warning", "--policy=cli.rego", "--suppress", "IST0102=*"})).
+		To(Succeed())
+
+	c := &analysisConfig{
+		FailureThreshold: "Error",
+		OutputThreshold:  "Error",
+		Suppressions:     []string{"IST0103=Pod *.testing"},
+		Policies:         []string{"config.rego"},
+		EnableAnalyzers:  []string{"a", "b"},
+		DisableAnalyzers: []string{"c"},
+		MaxMessages:      100,
+	}
+	g.Expect(c.applyToFlags(flags)).To(Succeed())
+
+	// Explicit flags take precedence, except for suppressions, which are combined
+	g.Expect(failure.Level).To(Equal(diag.Error))
+	g.Expect(output.Level).To(Equal(diag.Warning))
+	g.Expect(policies).To(Equal([]string{"cli.rego"}))
+	g.Expect(analyzers).To(Equal([]string{"a", "b"}))
+	g.Expect(disabled).To(Equal([]string{"c"}))
+	g.Expect(suppress).To(Equal([]string{"IST0102=*", "IST0103=Pod *.testing"}))
+	g.Expect(flags.GetInt("max-messages")).To(Equal(100))
+	g.Expect(flags.Changed("aggregate-threshold")).To(BeFalse())
+
+	flags = pflag.NewFlagSet("analyze", pflag.ContinueOnError)
+	flags.Var(&messageThreshold{diag.Warning}, "failure-threshold", "")
+	c = &analysisConfig{FailureThreshold: "Fatal"}
+	g.Expect(c.applyToFlags(flags)).To(MatchError(ContainSubstring("invalid failure-threshold")))
+}
+
+func TestAnalysisConfigFilter(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	infoType := diag.NewMessageType(diag.Info, "IST0118", "port %s")
+	warningType := diag.NewMessageType(diag.Warning, "IST0101", "missing %s")
+	newMessage := func(mt *diag.MessageType, ns string) diag.Message {
+		r := &resource.Instance{
+			Metadata: resource.Metadata{FullName: resource.NewFullName(resource.Namespace(ns), "a")},
+			Origin:   &rt.Origin{Kind: "Service", FullName: resource.NewFullName(resource.Namespace(ns), "a")},
+		}
+		return diag.NewMessage(mt, r, ns)
+	}
+
+	c := &analysisConfig{
+		IgnoredNamespaces: []string{"sandbox"},
+		levels:            map[string]diag.Level{"IST0118": diag.Error},
+		overrides:         make(map[string]*diag.MessageType),
+	}
+	result := c.filter(diag.Messages{
+		newMessage(infoType, "default"),
+		newMessage(warningType, "sandbox"),
+		newMessage(warningType, "default"),
+		newMessage(infoType, "other"),
+	})
+
+	g.Expect(result).To(HaveLen(3))
+	g.Expect(result[0].Type.Level()).To(Equal(diag.Error))
+	g.Expect(result[0].Type.Code()).To(Equal("IST0118"))
+	g.Expect(result[0].Type.Template()).To(Equal("port %s"))
+	g.Expect(result[1].Type).To(Equal(warningType))
+	g.Expect(result[2].Type).To(BeIdenticalTo(result[0].Type))
+
+	// The original message types are left alone
+	g.Expect(infoType.Level()).To(Equal(diag.Info))
+}
+
+func TestAnalysisConfigFilterTemplates(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	mt := diag.NewMessageType(diag.Warning, "IST0101", "missing %s")
+	r := &resource.Instance{
+		Metadata: resource.Metadata{FullName: resource.NewFullName("default", "a")},
+		Origin:   &rt.Origin{Kind: "Service", FullName: resource.NewFullName("default", "a")},
+	}
+	aggregated := diag.NewMessage(mt, r, "b")
+	aggregated.Aggregated = diag.Messages{diag.NewMessage(mt, r, "c")}
+
+	c := &analysisConfig{
+		templates: map[string]string{"IST0101": "%s fehlt"},
+		overrides: make(map[string]*diag.MessageType),
+	}
+	result := c.filter(diag.Messages{
+		diag.NewMessage(mt, r, "a"),
+		diag.NewMessage(mt, r, "a").WithLevel(diag.Info),
+		aggregated,
+	})
+
+	g.Expect(result).To(HaveLen(3))
+	g.Expect(result[0].String()).To(Equal("Warning [IST0101] (Service a.default) a fehlt"))
+	g.Expect(result[1].String()).To(Equal("Info [IST0101] (Service a.default) a fehlt"))
+	g.Expect(result[2].Aggregated[0].Type).To(BeIdenticalTo(result[0].Type))
+
+	// The original messages are left alone
+	g.Expect(aggregated.Aggregated[0].Type).To(BeIdenticalTo(mt))
+	g.Expect(mt.Template()).To(Equal("missing %s"))
+}
@@ -15,9 +15,15 @@
 package cmd
 
 import (
+	"bytes"
 	"testing"
 
 	"istio.io/istio/galley/pkg/config/analysis/diag"
+	"istio.io/istio/galley/pkg/config/analysis/score"
+	"istio.io/istio/galley/pkg/config/processing/snapshotter"
+	"istio.io/istio/galley/pkg/config/source/kube/rt"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collections"
 
 	. "github.com/onsi/gomega"
 )
@@ -63,3 +69,176 @@ func TestNoErrorIfMessageLevelsBelowThreshold(t *testing.T) {
 
 	g.Expect(err).To(BeNil())
 }
+
+func TestOptionalAnalyzers(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	result, err := optionalAnalyzers([]string{"destinationrule.ConnectionPoolAnalyzer"})
+	g.Expect(err).To(BeNil())
+	g.Expect(result).To(HaveLen(1))
+	g.Expect(result[0].Metadata().Name).To(Equal("destinationrule.ConnectionPoolAnalyzer"))
+
+	result, err = optionalAnalyzers(nil)
+	g.Expect(err).To(BeNil())
+	g.Expect(result).To(BeEmpty())
+
+	_, err = optionalAnalyzers([]string{"virtualservice.GatewayAnalyzer"})
+	g.Expect(err).To(BeAssignableToTypeOf(CommandParseError{}))
+}
+
+func TestPrintHealthScores(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	var b bytes.Buffer
+	printHealthScores(&b, score.Summary{
+		Mesh: score.Score{Value: 76.9, Findings: map[string]int{"Error": 2, "Info": 1}},
+		Namespaces: []score.Score{
+			{Namespace: "default", Value: 83.3, Findings: map[string]int{"Error": 2}},
+		},
+	})
+	g.Expect(b.String()).To(Equal("Config health score: 76.9 (2 Error, 1 Info)\n\tdefault: 83.3 (2 Error)\n"))
+
+	b.Reset()
+	printHealthScores(&b, score.Compute(nil, score.DefaultWeights))
+	g.Expect(b.String()).To(Equal("Config health score: 100.0\n"))
+}
+
+func TestPrintFixes(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	name := resource.NewFullName("httpbin", "gateway")
+	r := &resource.Instance{
+		Metadata: resource.Metadata{Schema: collections.IstioNetworkingV1Alpha3Gateways.Resource(), FullName: name},
+		Origin:   &rt.Origin{Kind: "Gateway", FullName: name},
+	}
+	mt := diag.NewMessageType(diag.Error, "IST0101", "Referenced %s not found: %q")
+	withFix := diag.NewMessage(mt, r, "credentialName", "credential").WithFix("Qualify credentialName credential",
+		`{"spec":{"servers":[{"tls":{"credentialName":"httpbin/credential"}}]}}`)
+	withoutFix := diag.NewMessage(mt, r, "selector", "istio=ingressgateway")
+
+	var b bytes.Buffer
+	printFixes(&b, diag.Messages{withFix, withoutFix})
+	g.Expect(b.String()).To(Equal("# [IST0101] Gateway gateway.httpbin: Qualify credentialName credential\n" +
+		"kubectl patch gateways.networking.istio.io gateway -n httpbin --type merge " +
+		`-p '{"spec":{"servers":[{"tls":{"credentialName":"httpbin/credential"}}]}}'` + "\n"))
+}
+
+func TestRenderAggregatedMessage(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	instance := func(name string) *resource.Instance {
+		fullName := resource.NewFullName("default", resource.LocalName(name))
+		return &resource.Instance{
+			Metadata: resource.Metadata{FullName: fullName},
+			Origin:   &rt.Origin{Kind: "Pod", FullName: fullName},
+		}
+	}
+	mt := diag.NewMessageType(diag.Warning, "IST0103", "The pod is missing the Istio proxy.")
+	m := diag.NewMessage(mt, instance("a"))
+	m.Aggregated = diag.Messages{diag.NewMessage(mt, instance("b")), diag.NewMessage(mt, instance("c"))}
+
+	colorize = false
+	g.Expect(renderMessage(m)).To(Equal(
+		"Warn [IST0103] (Pod a.default) The pod is missing the Istio proxy. (and 2 more: Pod b.default, Pod c.default)"))
+	g.Expect(withAggregated(diag.Messages{m})).To(HaveLen(3))
+}
+
+func TestExplainMessage(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	var b bytes.Buffer
+	g.Expect(explainMessage(&b, "ist0101", LogOutput)).To(Succeed())
+	g.Expect(b.String()).To(Equal("IST0101 ReferencedResourceNotFound (Error)\n" +
+		"Description: A resource being referenced does not exist.\n" +
+		"Message: Referenced %s not found: %q\n" +
+		"Documentation: https://istio.io/docs/reference/config/analysis/IST0101\n"))
+
+	b.Reset()
+	g.Expect(explainMessage(&b, "IST0101", JSONOutput)).To(Succeed())
+	g.Expect(b.String()).To(ContainSubstring(`"code": "IST0101"`))
+
+	g.Expect(explainMessage(&b, "IST9999", LogOutput)).To(BeAssignableToTypeOf(CommandParseError{}))
+}
+
+func TestPrintDifference(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	instance := func(name string) *resource.Instance {
+		fullName := resource.NewFullName("default", resource.LocalName(name))
+		return &resource.Instance{
+			Metadata: resource.Metadata{FullName: fullName},
+			Origin:   &rt.Origin{Kind: "Pod", FullName: fullName},
+		}
+	}
+	mt := diag.NewMessageType(diag.Warning, "IST0103", "The pod is missing the Istio proxy.")
+	d := diag.Diff(
+		diag.Messages{diag.NewMessage(mt, instance("a")), diag.NewMessage(mt, instance("b"))},
+		diag.Messages{diag.NewMessage(mt, instance("b")), diag.NewMessage(mt, instance("c"))})
+
+	colorize = false
+	var b bytes.Buffer
+	g.Expect(printDifference(&b, d, LogOutput)).To(Succeed())
+	g.Expect(b.String()).To(Equal("New findings:\n" +
+		"Warn [IST0103] (Pod c.default) The pod is missing the Istio proxy.\n" +
+		"Resolved findings:\n" +
+		"Warn [IST0103] (Pod a.default) The pod is missing the Istio proxy.\n" +
+		"Persisting findings:\n" +
+		"Warn [IST0103] (Pod b.default) The pod is missing the Istio proxy.\n"))
+
+	b.Reset()
+	g.Expect(printDifference(&b, d, JSONOutput)).To(Succeed())
+	g.Expect(b.String()).To(ContainSubstring(`"resolved": [`))
+}
+
+func TestAnalysisScopeFromFlags(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	scopeNamespaces = []string{"team-a", "team-a-staging"}
+	scopeSelector = "team=a"
+	includeOutOfScope = true
+	defer func() {
+		scopeNamespaces, scopeSelector, includeOutOfScope = nil, "", false
+	}()
+
+	s, err := analysisScopeFromFlags()
+	g.Expect(err).To(BeNil())
+	g.Expect(s.Namespaces).To(Equal([]resource.Namespace{"team-a", "team-a-staging"}))
+	g.Expect(s.Selector.String()).To(Equal("team=a"))
+	g.Expect(s.IncludeOutOfScope).To(BeTrue())
+
+	scopeSelector = "team in a"
+	_, err = analysisScopeFromFlags()
+	g.Expect(err).NotTo(BeNil())
+}
+
+func TestRenderMessageNotes(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	fullName := resource.NewFullName("istio-system", "gateway")
+	r := &resource.Instance{
+		Metadata: resource.Metadata{FullName: fullName},
+		Origin:   &rt.Origin{Kind: "Gateway", FullName: fullName},
+	}
+	m := diag.NewMessage(diag.NewMessageType(diag.Error, "IST0101", "Referenced %s not found: %q"), r, "selector", "app=a")
+	m.Notes = []string{snapshotter.OutOfScopeNote}
+
+	colorize = false
+	g.Expect(renderMessage(m)).To(Equal(`Error [IST0101] (Gateway gateway.istio-system) Referenced selector not found: ` +
+		`"app=a" (The resource is outside of the analysis scope.)`))
+}
+
+func TestRenderMessageFieldPath(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	fullName := resource.NewFullName("istio-system", "gateway")
+	r := &resource.Instance{
+		Metadata: resource.Metadata{FullName: fullName},
+		Origin:   &rt.Origin{Kind: "Gateway", FullName: fullName, Ref: &rt.Position{Filename: "gateway.yaml", Line: 3}},
+	}
+	m := diag.NewMessage(diag.NewMessageType(diag.Error, "IST0101", "Referenced %s not found: %q"), r, "credentialName",
+		"cert").WithFieldPath("spec.servers[2].tls.credentialName")
+
+	colorize = false
+	g.Expect(renderMessage(m)).To(Equal(`Error [IST0101] (Gateway gateway.istio-system gateway.yaml:3 ` +
+		`spec.servers[2].tls.credentialName) Referenced credentialName not found: "cert"`))
+}
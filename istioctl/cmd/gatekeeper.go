@@ -0,0 +1,62 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"istio.io/istio/galley/pkg/config/analysis/gatekeeper"
+)
+
+// gatekeeperCmd generates Gatekeeper constraints from analyzers
+func gatekeeperCmd() *cobra.Command {
+	var list bool
+	cmd := &cobra.Command{
+		Use:   "gatekeeper [<analyzer>...]",
+		Short: "Generate Gatekeeper constraints from Istio analyzers",
+		Long: `Generates Gatekeeper ConstraintTemplates and Constraints that enforce the checks of the named analyzers at
+admission time. Only analyzers that check single resources in isolation are supported. If no analyzers are named,
+all supported analyzers are converted.`,
+		Example: `
+# Enforce all supported analyzers with Gatekeeper
+istioctl experimental gatekeeper | kubectl apply -f -
+
+# Enforce Istio port naming only
+istioctl experimental gatekeeper service.PortNameAnalyzer
+
+# List the analyzers that can be converted
+istioctl experimental gatekeeper --list
+`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if list {
+				fmt.Fprintln(cmd.OutOrStdout(), strings.Join(gatekeeper.Supported(), "\n"))
+				return nil
+			}
+
+			y, err := gatekeeper.GenerateYAML(args...)
+			if err != nil {
+				return err
+			}
+			_, err = cmd.OutOrStdout().Write(y)
+			return err
+		},
+	}
+	cmd.Flags().BoolVarP(&list, "list", "L", false,
+		"List the analyzers that can be converted to Gatekeeper constraints.")
+	return cmd
+}
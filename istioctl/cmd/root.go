@@ -149,6 +149,9 @@ debug and diagnose their Istio mesh.
 	experimentalCmd.AddCommand(softGraduatedCmd(Analyze()))
 	experimentalCmd.AddCommand(vmBootstrapCommand())
 	experimentalCmd.AddCommand(waitCmd())
+	experimentalCmd.AddCommand(gatekeeperCmd())
+	experimentalCmd.AddCommand(analysisAlertsCmd())
+	experimentalCmd.AddCommand(analysisServerCmd())
 
 	postInstallCmd.AddCommand(Webhook())
 	experimentalCmd.AddCommand(postInstallCmd)
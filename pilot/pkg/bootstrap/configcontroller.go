@@ -17,6 +17,7 @@ package bootstrap
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"net/url"
 	"os"
 	"path"
@@ -26,6 +27,9 @@ import (
 
 	"istio.io/istio/pilot/pkg/status"
 
+	"istio.io/istio/galley/pkg/config/analysis/history"
+	"istio.io/istio/galley/pkg/config/analysis/score"
+	analysisservice "istio.io/istio/galley/pkg/config/analysis/service"
 	"istio.io/istio/galley/pkg/server/components"
 	"istio.io/istio/galley/pkg/server/settings"
 	"istio.io/istio/pilot/pkg/leaderelection"
@@ -310,9 +314,63 @@ func (s *Server) initInprocessAnalysisController(args *PilotArgs) error {
 	processingArgs.EnableServer = false
 	processingArgs.MeshConfigFile = args.Mesh.ConfigFile
 	processingArgs.EnableConfigAnalysis = true
+	processingArgs.EnableConfigAnalysisProfiling = features.EnableAnalysisProfiling
+	processingArgs.ConfigAnalysisDebounce = features.AnalysisDebounce
+	processingArgs.ConfigAnalysisMaxDelay = features.AnalysisMaxDelay
+	processingArgs.ConfigAnalysisMinInterval = features.AnalysisMinInterval
+	processingArgs.ConfigAnalysisSinks = strings.Split(features.AnalysisSinks, ",")
+	processingArgs.ConfigAnalysisUpgradeTarget = features.AnalysisUpgradeTarget
+	weights, err := score.ParseWeights(strings.Split(features.AnalysisScoreWeights, ","))
+	if err != nil {
+		return fmt.Errorf("invalid PILOT_ANALYSIS_SCORE_WEIGHTS: %v", err)
+	}
+	processingArgs.ConfigAnalysisScoreWeights = weights
+	processingArgs.ConfigAnalysisParallelism = features.AnalysisParallelism
+	processingArgs.ConfigAnalysisConfigFile = features.AnalysisConfigFile
+	processingArgs.ConfigAnalysisWebhookURL = features.AnalysisWebhookURL
+	processingArgs.ConfigAnalysisWebhookInterval = features.AnalysisWebhookInterval
+	processingArgs.ConfigAnalysisHistoryConfigMap = features.AnalysisHistoryConfigMap
+	processingArgs.ConfigAnalysisHistoryNamespace = args.Namespace
+	processingArgs.ConfigAnalysisHistoryRetention = features.AnalysisHistoryRetention
+	if features.EnableStatus {
+		// Annotate findings with the distribution status reported by all istiod instances, to tell misconfiguration
+		// apart from config that failed to propagate.
+		tracker := &status.DistributionController{ReadOnly: true}
+		s.addTerminatingStartFunc(func(stop <-chan struct{}) error {
+			tracker.Start(s.kubeConfig, args.Namespace, stop)
+			return nil
+		})
+		processingArgs.ConfigAnalysisPropagation = tracker
+	}
 
 	processing := components.NewProcessing(processingArgs)
+	s.analysisProcessing = processing
+
+	s.httpMux.Handle("/debug/analysis", analysisservice.NewHTTPHandler(processing))
+	s.httpMux.HandleFunc("/debug/analysis_history", func(w http.ResponseWriter, req *http.Request) {
+		r := processing.AnalysisHistory()
+		if r == nil {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte("no analysis history available\n"))
+			return
+		}
+		history.NewHTTPHandler(r).ServeHTTP(w, req)
+	})
+
+	if features.EnableAnalysisProfiling {
+		s.httpMux.HandleFunc("/debug/analysis_profile", func(w http.ResponseWriter, _ *http.Request) {
+			p := processing.AnalysisProfile()
+			if p == nil {
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte("no analysis profile available\n"))
+				return
+			}
+			_, _ = w.Write([]byte(p.String()))
+		})
+	}
 
+	// Analysis shares the status lock with older control plane deployments, so that replicas of different versions
+	// don't both write analysis results to resource status during upgrades.
 	s.addStartFunc(func(stop <-chan struct{}) error {
 		go leaderelection.
 			NewLeaderElection(args.Namespace, args.PodName, leaderelection.StatusController, s.kubeClient).
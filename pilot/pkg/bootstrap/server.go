@@ -51,6 +51,8 @@ import (
 	"istio.io/pkg/log"
 	"istio.io/pkg/version"
 
+	analysisservice "istio.io/istio/galley/pkg/config/analysis/service"
+	"istio.io/istio/galley/pkg/server/components"
 	"istio.io/istio/pilot/pkg/features"
 	"istio.io/istio/pilot/pkg/leaderelection"
 	"istio.io/istio/pilot/pkg/model"
@@ -166,6 +168,9 @@ type Server struct {
 
 	// duration used for graceful shutdown.
 	shutdownDuration time.Duration
+
+	// analysisProcessing runs config analysis in process, if PILOT_ENABLE_ANALYSIS is set.
+	analysisProcessing *components.Processing
 }
 
 // NewServer creates a new Server instance based on the provided arguments.
@@ -518,6 +523,15 @@ func (s *Server) initGrpcServer(options *istiokeepalive.Options) {
 	reflection.Register(s.grpcServer)
 }
 
+// registerAnalysisService registers the config analysis service with the given gRPC server, if enabled. Findings name
+// resources of all namespaces and runs are expensive, so it must only be registered with the secure gRPC server.
+func (s *Server) registerAnalysisService(gs *grpc.Server) {
+	if !features.EnableAnalysisService || s.analysisProcessing == nil {
+		return
+	}
+	analysisservice.New(s.analysisProcessing).Register(gs)
+}
+
 // initDNSServer initializes gRPC DNS Server for DNS resolutions.
 func (s *Server) initDNSServer(args *PilotArgs) {
 	if dns.DNSAddr.Get() != "" {
@@ -610,6 +624,7 @@ func (s *Server) initSecureDiscoveryService(args *PilotArgs, port string) error
 
 	s.secureGrpcServer = grpc.NewServer(opts...)
 	s.EnvoyXdsServer.Register(s.secureGrpcServer)
+	s.registerAnalysisService(s.secureGrpcServer)
 	reflection.Register(s.secureGrpcServer)
 
 	s.addStartFunc(func(stop <-chan struct{}) error {
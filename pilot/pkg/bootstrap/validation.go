@@ -22,8 +22,12 @@ import (
 	"istio.io/pkg/env"
 	"istio.io/pkg/log"
 
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/admission"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers"
 	"istio.io/istio/galley/pkg/config/source/kube"
 	"istio.io/istio/mixer/pkg/validate"
+	"istio.io/istio/pilot/pkg/features"
 	"istio.io/istio/pilot/pkg/leaderelection"
 	"istio.io/istio/pkg/config/schema/collections"
 	"istio.io/istio/pkg/webhooks/validation/controller"
@@ -53,6 +57,35 @@ func (s *Server) initConfigValidation(args *PilotArgs) error {
 		DomainSuffix:   args.Config.ControllerOptions.DomainSuffix,
 		Mux:            s.httpsMux,
 	}
+	if features.EnableAnalysisAdmissionWarnings && s.analysisProcessing != nil {
+		log.Info("enabling config analysis at admission")
+		// Admission runs the analyzers of the background analysis, with the same parameters and levels, so that
+		// analyzers that are disabled or messages that are downgraded there don't deny writes.
+		analyzerConfig := &analyzers.Config{}
+		if features.AnalysisConfigFile != "" {
+			var err error
+			if analyzerConfig, err = analyzers.ReadConfigFile(features.AnalysisConfigFile); err != nil {
+				return err
+			}
+		}
+		selected, err := analyzerConfig.Analyzers()
+		if err != nil {
+			return err
+		}
+		levels, err := analyzerConfig.Levels()
+		if err != nil {
+			return err
+		}
+		params.Analyzer = admission.New(admission.Options{
+			Analyzers:      selected,
+			Snapshot:       s.analysisProcessing.AnalysisSnapshot,
+			Context:        s.analysisProcessing.AnalysisContext,
+			LevelOverrides: levels,
+			Limits:         analysis.DefaultLimits,
+			DenyOnError:    features.AnalysisAdmissionDenyOnError,
+			FastOnly:       features.AnalysisAdmissionFastOnly,
+		})
+	}
 	whServer, err := server.New(params)
 	if err != nil {
 		return err
@@ -241,6 +241,126 @@ var (
 			"Istio Resources",
 	).Get()
 
+	AnalysisDebounce = env.RegisterDurationVar(
+		"PILOT_ANALYSIS_DEBOUNCE",
+		time.Second,
+		"The quiet period after a config change before pilot re-runs analysis, if PILOT_ENABLE_ANALYSIS is set. "+
+			"Set to 0 to analyze every config change.",
+	).Get()
+
+	AnalysisMaxDelay = env.RegisterDurationVar(
+		"PILOT_ANALYSIS_MAX_DELAY",
+		10*time.Second,
+		"The maximum time a burst of config changes, e.g. a large GitOps sync, can delay analysis beyond "+
+			"PILOT_ANALYSIS_DEBOUNCE. Set to 0 to wait until config settles.",
+	).Get()
+
+	AnalysisMinInterval = env.RegisterDurationVar(
+		"PILOT_ANALYSIS_MIN_INTERVAL",
+		5*time.Second,
+		"The minimum time between the start of two analysis runs, if PILOT_ENABLE_ANALYSIS is set. Config changes "+
+			"in the meantime are analyzed together in the next run.",
+	).Get()
+
+	AnalysisUpgradeTarget = env.RegisterStringVar(
+		"PILOT_ANALYSIS_UPGRADE_TARGET",
+		"",
+		"If set to an Istio version, e.g. 1.8, analysis also reports config that is incompatible with that version, "+
+			"so that upgrade blockers are noticed as they accumulate. Requires PILOT_ENABLE_ANALYSIS.",
+	).Get()
+
+	EnableAnalysisProfiling = env.RegisterBoolVar(
+		"PILOT_ENABLE_ANALYSIS_PROFILING",
+		false,
+		"If enabled, pilot will record per-collection entry counts, per-analyzer allocations and peak memory of "+
+			"each analysis run, and serve the last profile on /debug/analysis_profile. Requires PILOT_ENABLE_ANALYSIS.",
+	).Get()
+
+	EnableAnalysisAdmissionWarnings = env.RegisterBoolVar(
+		"PILOT_ENABLE_ANALYSIS_ADMISSION_WARNINGS",
+		false,
+		"If enabled, the validation webhook will run the relevant analyzers against incoming resources and return "+
+			"their findings as admission warnings. Requires PILOT_ENABLE_ANALYSIS.",
+	).Get()
+
+	AnalysisAdmissionDenyOnError = env.RegisterBoolVar(
+		"PILOT_ANALYSIS_ADMISSION_DENY_ON_ERROR",
+		false,
+		"If enabled along with PILOT_ENABLE_ANALYSIS_ADMISSION_WARNINGS, resources with Error level analysis "+
+			"findings are rejected rather than admitted with a warning.",
+	).Get()
+
+	AnalysisAdmissionFastOnly = env.RegisterBoolVar(
+		"PILOT_ANALYSIS_ADMISSION_FAST_ONLY",
+		true,
+		"If enabled along with PILOT_ENABLE_ANALYSIS_ADMISSION_WARNINGS, only the analyzers that can check the "+
+			"submitted resource against indexes of the cluster configuration run at admission time.",
+	).Get()
+
+	AnalysisSinks = env.RegisterStringVar(
+		"PILOT_ANALYSIS_SINKS",
+		"crd",
+		"Comma separated list of the sinks that analysis findings are delivered to. Each sink is one of stdout, "+
+			"file:<path>, crd (the status of the resources), events (Kubernetes Events) or webhook:<url>. "+
+			"Requires PILOT_ENABLE_ANALYSIS.",
+	).Get()
+
+	AnalysisScoreWeights = env.RegisterStringVar(
+		"PILOT_ANALYSIS_SCORE_WEIGHTS",
+		"",
+		"Comma separated list of the weights of analysis findings in the config health score metric, as "+
+			"<level>=<weight> or <code>=<weight>, e.g. Error=20,IST0101=5. Levels that are not given default to "+
+			"Error=10, Warning=3 and Info=1. Requires PILOT_ENABLE_ANALYSIS.",
+	).Get()
+
+	AnalysisParallelism = env.RegisterIntVar(
+		"PILOT_ANALYSIS_PARALLELISM",
+		1,
+		"The number of analyzers that may run concurrently, if PILOT_ENABLE_ANALYSIS is set. Higher values "+
+			"shorten analysis runs on large configurations, at the cost of more CPU spent on analysis at once.",
+	).Get()
+
+	AnalysisWebhookURL = env.RegisterStringVar(
+		"PILOT_ANALYSIS_WEBHOOK_URL",
+		"",
+		"If set, newly appeared Error and Warning analysis findings are POSTed to this URL in a Slack compatible "+
+			"format. Requires PILOT_ENABLE_ANALYSIS.",
+	).Get()
+
+	AnalysisWebhookInterval = env.RegisterDurationVar(
+		"PILOT_ANALYSIS_WEBHOOK_INTERVAL",
+		time.Minute,
+		"The minimum time between two notifications to PILOT_ANALYSIS_WEBHOOK_URL. Findings that appear in the "+
+			"meantime are batched into the next notification.",
+	).Get()
+
+	AnalysisConfigFile = env.RegisterStringVar(
+		"PILOT_ANALYSIS_CONFIG_FILE",
+		"",
+		"The path of a file, e.g. mounted from a ConfigMap, that enables and disables analyzers, sets their "+
+			"parameters and overrides the severity of messages. Requires PILOT_ENABLE_ANALYSIS.",
+	).Get()
+
+	AnalysisHistoryConfigMap = env.RegisterStringVar(
+		"PILOT_ANALYSIS_HISTORY_CONFIGMAP",
+		"",
+		"If set, the history of analysis findings, i.e. when each finding first appeared and when it was resolved, "+
+			"is recorded in the ConfigMap of this name in the istiod namespace. Requires PILOT_ENABLE_ANALYSIS.",
+	).Get()
+
+	AnalysisHistoryRetention = env.RegisterDurationVar(
+		"PILOT_ANALYSIS_HISTORY_RETENTION",
+		7*24*time.Hour,
+		"The time resolved findings are kept in PILOT_ANALYSIS_HISTORY_CONFIGMAP.",
+	).Get()
+
+	EnableAnalysisService = env.RegisterBoolVar(
+		"PILOT_ENABLE_ANALYSIS_SERVICE",
+		false,
+		"If enabled, istiod will serve the config analysis gRPC service on its secure discovery port, so that tools "+
+			"can request fresh analysis results. Requires PILOT_ENABLE_ANALYSIS.",
+	).Get()
+
 	EnableStatus = env.RegisterBoolVar(
 		"PILOT_ENABLE_STATUS",
 		false,
@@ -242,7 +242,11 @@ func (s *DiscoveryServer) StreamAggregatedResources(stream ads.AggregatedDiscove
 				defer s.removeCon(con.ConID)
 			}
 			if s.StatusReporter != nil {
-				s.StatusReporter.RegisterEvent(con.ConID, discReq.TypeUrl, discReq.ResponseNonce)
+				if discReq.ErrorDetail != nil {
+					s.StatusReporter.RegisterNack(con.ConID, discReq.TypeUrl, discReq.ResponseNonce)
+				} else {
+					s.StatusReporter.RegisterEvent(con.ConID, discReq.TypeUrl, discReq.ResponseNonce)
+				}
 			}
 
 			// Based on node metadata a different generator was selected, use it instead of the default
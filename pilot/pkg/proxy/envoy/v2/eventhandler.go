@@ -19,6 +19,8 @@ package v2
 type DistributionStatusCache interface {
 	// RegisterEvent notifies the implementer of an xDS ACK, and must be non-blocking
 	RegisterEvent(conID string, xdsType string, nonce string)
+	// RegisterNack notifies the implementer of an xDS NACK, and must be non-blocking
+	RegisterNack(conID string, xdsType string, nonce string)
 	RegisterDisconnect(s string, urls []string)
 	QueryLastNonce(conID string, xdsType string) (noncePrefix string)
 }
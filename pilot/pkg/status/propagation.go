@@ -0,0 +1,49 @@
+// Copyright 2020 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package status
+
+import (
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"istio.io/istio/galley/pkg/config/analysis/propagation"
+	"istio.io/istio/pkg/config/resource"
+)
+
+// Propagation implements propagation.Tracker, so that config analysis can annotate its findings with the distribution
+// status of the resources they are reported on.
+func (c *DistributionController) Propagation(r *resource.Instance) (propagation.Status, bool) {
+	if r.Metadata.Schema == nil {
+		return propagation.Status{}, false
+	}
+	res := Resource{
+		GroupVersionResource: schema.GroupVersionResource{
+			Group:    r.Metadata.Schema.Group(),
+			Version:  r.Metadata.Schema.Version(),
+			Resource: r.Metadata.Schema.Plural(),
+		},
+		Namespace:       string(r.Metadata.FullName.Namespace),
+		Name:            string(r.Metadata.FullName.Name),
+		ResourceVersion: string(r.Metadata.Version),
+	}
+	progress, nackedBy, ok := c.Distribution(res)
+	if !ok {
+		return propagation.Status{}, false
+	}
+	return propagation.Status{
+		Acked:    progress.AckedInstances,
+		Total:    progress.TotalInstances,
+		NackedBy: nackedBy,
+	}, true
+}
@@ -30,6 +30,8 @@ type DistributionReport struct {
 	Reporter            string         `json:"reporter"`
 	DataPlaneCount      int            `json:"dataPlaneCount"`
 	InProgressResources map[string]int `json:"inProgressResources"`
+	// NackedResources lists, per in progress resource, the proxies that rejected it.
+	NackedResources map[string][]string `json:"nackedResources,omitempty"`
 }
 
 func (r *DistributionReport) SetProgress(resource fmt.Stringer, progress int) {
@@ -16,6 +16,8 @@ package status
 
 import (
 	"context"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -50,10 +52,17 @@ type inProgressEntry struct {
 	completedIterations int
 }
 
+// nackEntry is a version of the config that a dataplane has rejected.
+type nackEntry struct {
+	conID   string
+	version string
+}
+
 type Reporter struct {
 	mu                     sync.RWMutex
 	status                 map[string]string
 	reverseStatus          map[string][]string
+	nacks                  map[string]nackEntry
 	dirty                  bool
 	inProgressResources    map[string]*inProgressEntry
 	client                 v1.ConfigMapInterface
@@ -83,6 +92,7 @@ func (r *Reporter) Start(clientSet kubernetes.Interface, namespace string, store
 	r.distributionEventQueue = make(chan distributionEvent, 10^5)
 	r.status = make(map[string]string)
 	r.reverseStatus = make(map[string][]string)
+	r.nacks = make(map[string]nackEntry)
 	r.inProgressResources = make(map[string]*inProgressEntry)
 	go r.readFromEventQueue()
 	if !writeMode {
@@ -158,10 +168,46 @@ func (r *Reporter) buildReport() (DistributionReport, []Resource) {
 				// TODO: do deletes propagate through this thing?
 			}
 		}
+		if nackedBy := r.nackedBy(res); len(nackedBy) > 0 {
+			if out.NackedResources == nil {
+				out.NackedResources = map[string][]string{}
+			}
+			out.NackedResources[key] = nackedBy
+			// make sure the leader learns about the resource even if no dataplane has accepted it.
+			if _, ok := out.InProgressResources[key]; !ok {
+				out.InProgressResources[key] = 0
+			}
+		}
 	}
 	return out, finishedResources
 }
 
+// nackedBy returns the proxies whose latest response rejected a config version containing this version of the
+// resource.  Must have read lock before calling.
+func (r *Reporter) nackedBy(res Resource) []string {
+	proxies := map[string]struct{}{}
+	for _, nack := range r.nacks {
+		dpVersion, err := r.store.GetResourceAtVersion(nack.version, res.ToModelKey())
+		if err == nil && dpVersion == res.ResourceVersion {
+			proxies[proxyFromConID(nack.conID)] = struct{}{}
+		}
+	}
+	out := make([]string, 0, len(proxies))
+	for p := range proxies {
+		out = append(out, p)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// proxyFromConID strips the connection counter from an xDS connection ID, leaving the ID of the proxy.
+func proxyFromConID(conID string) string {
+	if i := strings.LastIndex(conID, "-"); i > 0 {
+		return conID[:i]
+	}
+	return conID
+}
+
 // For efficiency, we don't want to be checking on resources that have already reached 100% distribution.
 // When this happens, we remove them from our watch list.
 func (r *Reporter) removeCompletedResource(completedResources []Resource) {
@@ -242,6 +288,7 @@ type distributionEvent struct {
 	conID   string
 	xdsType string
 	nonce   string
+	nack    bool
 }
 
 func (r *Reporter) QueryLastNonce(conID string, xdsType string) string {
@@ -264,10 +311,26 @@ func (r *Reporter) RegisterEvent(conID string, xdsType string, nonce string) {
 	}
 }
 
+// Register that a dataplane has rejected a new version of the config.  The dataplane keeps running the
+// version it last acknowledged, so only the rejection itself is recorded.
+func (r *Reporter) RegisterNack(conID string, xdsType string, nonce string) {
+	d := distributionEvent{nonce: nonce, xdsType: xdsType, conID: conID, nack: true}
+	select {
+	case r.distributionEventQueue <- d:
+		return
+	default:
+		scope.Errorf("Distribution Event Queue overwhelmed, status will be invalid.")
+	}
+}
+
 func (r *Reporter) readFromEventQueue() {
 	for ev := range r.distributionEventQueue {
 		// TODO might need to batch this to prevent lock contention
-		r.processEvent(ev.conID, ev.xdsType, ev.nonce)
+		if ev.nack {
+			r.processNack(ev.conID, ev.xdsType, ev.nonce)
+		} else {
+			r.processEvent(ev.conID, ev.xdsType, ev.nonce)
+		}
 	}
 
 }
@@ -277,17 +340,31 @@ func (r *Reporter) processEvent(conID string, xdsType string, nonce string) {
 	r.dirty = true
 	key := conID + xdsType // TODO: delimit?
 	r.deleteKeyFromReverseMap(key)
-	var version string
-	if len(nonce) > 12 {
-		version = nonce[:v2.VersionLen]
-	} else {
-		version = nonce
-	}
+	delete(r.nacks, key)
+	version := nonceVersion(nonce)
 	// touch
 	r.status[key] = version
 	r.reverseStatus[version] = append(r.reverseStatus[version], key)
 }
 
+func (r *Reporter) processNack(conID string, xdsType string, nonce string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.dirty = true
+	if r.nacks == nil {
+		r.nacks = make(map[string]nackEntry)
+	}
+	r.nacks[conID+xdsType] = nackEntry{conID: conID, version: nonceVersion(nonce)}
+}
+
+// nonceVersion returns the config version a nonce was generated for.
+func nonceVersion(nonce string) string {
+	if len(nonce) > 12 {
+		return nonce[:v2.VersionLen]
+	}
+	return nonce
+}
+
 // This is a helper function for keeping our reverseStatus map in step with status.
 // must have write lock before calling.
 func (r *Reporter) deleteKeyFromReverseMap(key string) {
@@ -315,5 +392,6 @@ func (r *Reporter) RegisterDisconnect(conID string, xdsTypes []string) {
 		key := conID + xdsType // TODO: delimit?
 		r.deleteKeyFromReverseMap(key)
 		delete(r.status, key)
+		delete(r.nacks, key)
 	}
 }
@@ -133,3 +133,47 @@ func TestBuildReport(t *testing.T) {
 	}))
 	Expect(r.inProgressResources).NotTo(ContainElement(resources[0]))
 }
+
+func TestBuildReportNacks(t *testing.T) {
+	RegisterTestingT(t)
+	r := initReporterWithoutStarting()
+	r.store = model.NewFakeStore()
+	l := ledger.Make(time.Minute)
+	res := &model.Config{
+		ConfigMeta: model.ConfigMeta{
+			Namespace:       "default",
+			Name:            "foo",
+			ResourceVersion: "1",
+		},
+	}
+	col := collections.IstioNetworkingV1Alpha3Virtualservices.Resource()
+	res.Group = col.Group()
+	res.Version = col.Version()
+	res.Type = col.Kind()
+	_, err := l.Put(res.Key(), res.ResourceVersion)
+	Expect(err).NotTo(HaveOccurred())
+	r.AddInProgressResource(*res)
+	v1 := l.RootHash()
+	r.processEvent("proxyA-1", "", v1)
+	r.processEvent("proxyB-2", "", v1)
+
+	// version 2 is accepted by one proxy and rejected by the other, which keeps running version 1.
+	res.ResourceVersion = "2"
+	_, err = l.Put(res.Key(), res.ResourceVersion)
+	Expect(err).NotTo(HaveOccurred())
+	r.AddInProgressResource(*res)
+	r.processEvent("proxyA-1", "", l.RootHash())
+	r.processNack("proxyB-2", "", l.RootHash())
+	Expect(r.store.SetLedger(l)).To(Succeed())
+
+	key := ResourceFromModelConfig(*res).String()
+	rpt, _ := r.buildReport()
+	Expect(rpt.InProgressResources).To(Equal(map[string]int{key: 1}))
+	Expect(rpt.NackedResources).To(Equal(map[string][]string{key: {"proxyB"}}))
+
+	// a later ACK clears the NACK.
+	r.processEvent("proxyB-2", "", l.RootHash())
+	rpt, _ = r.buildReport()
+	Expect(rpt.InProgressResources).To(Equal(map[string]int{key: 2}))
+	Expect(rpt.NackedResources).To(BeEmpty())
+}
@@ -17,6 +17,7 @@ package status
 import (
 	"context"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
@@ -54,6 +55,7 @@ func (p *Progress) PlusEquals(p2 Progress) {
 type DistributionController struct {
 	mu               sync.RWMutex
 	CurrentState     map[Resource]map[string]Progress
+	nacks            map[Resource]map[string][]string
 	ObservationTime  map[string]time.Time
 	UpdateInterval   time.Duration
 	client           dynamic.Interface
@@ -63,6 +65,8 @@ type DistributionController struct {
 	StaleInterval    time.Duration
 	QPS              float32
 	Burst            int
+	// ReadOnly controllers only aggregate distribution reports for Distribution queries, and do not write status.
+	ReadOnly bool
 }
 
 func (c *DistributionController) Start(restConfig *rest.Config, namespace string, stop <-chan struct{}) {
@@ -78,17 +82,22 @@ func (c *DistributionController) Start(restConfig *rest.Config, namespace string
 	if c.clock == nil {
 		c.clock = clock.RealClock{}
 	}
+	c.mu.Lock()
 	c.CurrentState = make(map[Resource]map[string]Progress)
+	c.nacks = make(map[Resource]map[string][]string)
 	c.ObservationTime = make(map[string]time.Time)
+	c.mu.Unlock()
 	c.knownResources = make(map[schema.GroupVersionResource]dynamic.NamespaceableResourceInterface)
 
-	// client-go defaults to 5 QPS, with 10 Boost, which is insufficient for updating status on all the config
-	// in the mesh.  These values can be configured using environment variables for tuning (see pilot/pkg/features)
-	restConfig.QPS = c.QPS
-	restConfig.Burst = c.Burst
-	var err error
-	if c.client, err = dynamic.NewForConfig(restConfig); err != nil {
-		scope.Fatalf("Could not connect to kubernetes: %s", err)
+	if !c.ReadOnly {
+		// client-go defaults to 5 QPS, with 10 Boost, which is insufficient for updating status on all the config
+		// in the mesh.  These values can be configured using environment variables for tuning (see pilot/pkg/features)
+		restConfig.QPS = c.QPS
+		restConfig.Burst = c.Burst
+		var err error
+		if c.client, err = dynamic.NewForConfig(restConfig); err != nil {
+			scope.Fatalf("Could not connect to kubernetes: %s", err)
+		}
 	}
 	// create watch
 	i := informers.NewSharedInformerFactoryWithOptions(kubernetes.NewForConfigOrDie(restConfig), 1*time.Minute,
@@ -110,7 +119,12 @@ func (c *DistributionController) Start(restConfig *rest.Config, namespace string
 			case <-ctx.Done():
 				return
 			case <-t:
-				staleReporters := c.writeAllStatus(ctx)
+				var staleReporters []string
+				if c.ReadOnly {
+					staleReporters = c.findStaleReporters()
+				} else {
+					staleReporters = c.writeAllStatus(ctx)
+				}
 				if len(staleReporters) > 0 {
 					c.removeStaleReporters(staleReporters)
 				}
@@ -128,10 +142,67 @@ func (c *DistributionController) handleReport(d DistributionReport) {
 			c.CurrentState[res] = make(map[string]Progress)
 		}
 		c.CurrentState[res][d.Reporter] = Progress{d.InProgressResources[resstr], d.DataPlaneCount}
+		if nackedBy := d.NackedResources[resstr]; len(nackedBy) > 0 {
+			if _, ok := c.nacks[res]; !ok {
+				c.nacks[res] = make(map[string][]string)
+			}
+			c.nacks[res][d.Reporter] = nackedBy
+		} else if nacks, ok := c.nacks[res]; ok {
+			delete(nacks, d.Reporter)
+		}
+		if c.ReadOnly {
+			// nothing prunes old versions without a status writer, so drop them once a new one is reported.
+			c.pruneOtherVersions(res)
+		}
 	}
 	c.ObservationTime[d.Reporter] = c.clock.Now()
 }
 
+// Distribution returns the distribution progress of the given resource version, aggregated over the reporters that
+// are not stale, and the proxies that rejected it.  ok is false if no reporter has reported on the resource.
+func (c *DistributionController) Distribution(res Resource) (progress Progress, nackedBy []string, ok bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	fractions, ok := c.CurrentState[res]
+	if !ok {
+		return Progress{}, nil, false
+	}
+	ok = false
+	for reporter, w := range fractions {
+		if c.clock.Since(c.ObservationTime[reporter]) > c.StaleInterval {
+			continue
+		}
+		progress.PlusEquals(w)
+		nackedBy = append(nackedBy, c.nacks[res][reporter]...)
+		ok = true
+	}
+	sort.Strings(nackedBy)
+	return progress, nackedBy, ok
+}
+
+// pruneOtherVersions removes the state of all versions of the given resource but this one.  Must have write lock
+// before calling.
+func (c *DistributionController) pruneOtherVersions(res Resource) {
+	for other := range c.CurrentState {
+		if other.ResourceVersion != res.ResourceVersion && other.GroupVersionResource == res.GroupVersionResource &&
+			other.Namespace == res.Namespace && other.Name == res.Name {
+			delete(c.CurrentState, other)
+			delete(c.nacks, other)
+		}
+	}
+}
+
+func (c *DistributionController) findStaleReporters() (staleReporters []string) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for reporter, observed := range c.ObservationTime {
+		if c.clock.Since(observed) > c.StaleInterval {
+			staleReporters = append(staleReporters, reporter)
+		}
+	}
+	return
+}
+
 func (c *DistributionController) writeAllStatus(ctx context.Context) (staleReporters []string) {
 	defer c.mu.RUnlock()
 	c.mu.RLock()
@@ -205,6 +276,7 @@ func (c *DistributionController) pruneOldVersion(config Resource) {
 	defer c.mu.Unlock()
 	c.mu.Lock()
 	delete(c.CurrentState, config)
+	delete(c.nacks, config)
 }
 
 func (c *DistributionController) removeStaleReporters(staleReporters []string) {
@@ -216,6 +288,16 @@ func (c *DistributionController) removeStaleReporters(staleReporters []string) {
 		}
 		c.CurrentState[key] = fractions
 	}
+	for _, nacks := range c.nacks {
+		for _, staleReporter := range staleReporters {
+			delete(nacks, staleReporter)
+		}
+	}
+	if c.ReadOnly {
+		for _, staleReporter := range staleReporters {
+			delete(c.ObservationTime, staleReporter)
+		}
+	}
 }
 
 func GetTypedStatus(in interface{}) (out IstioStatus, err error) {
@@ -18,9 +18,15 @@ import (
 	"encoding/json"
 	"reflect"
 	"testing"
+	"time"
 
+	. "github.com/onsi/gomega"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/utils/clock"
+
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collections"
 )
 
 var statusStillPropagating = IstioStatus{
@@ -179,3 +185,74 @@ func Test_getTypedStatus(t *testing.T) {
 		})
 	}
 }
+
+func TestDistribution(t *testing.T) {
+	RegisterTestingT(t)
+	c := &DistributionController{
+		CurrentState:    make(map[Resource]map[string]Progress),
+		nacks:           make(map[Resource]map[string][]string),
+		ObservationTime: make(map[string]time.Time),
+		StaleInterval:   time.Minute,
+		clock:           clock.RealClock{},
+		ReadOnly:        true,
+	}
+	col := collections.IstioNetworkingV1Alpha3Virtualservices.Resource()
+	v1res := Resource{
+		GroupVersionResource: schema.GroupVersionResource{Group: col.Group(), Version: col.Version(), Resource: col.Plural()},
+		Namespace:            "default",
+		Name:                 "foo",
+		ResourceVersion:      "1",
+	}
+	v2res := v1res
+	v2res.ResourceVersion = "2"
+
+	_, _, ok := c.Distribution(v1res)
+	Expect(ok).To(BeFalse())
+
+	c.handleReport(DistributionReport{
+		Reporter:            "pilot-a",
+		DataPlaneCount:      2,
+		InProgressResources: map[string]int{v1res.String(): 1},
+		NackedResources:     map[string][]string{v1res.String(): {"sidecar~10.0.0.1~b.default~default.svc.cluster.local"}},
+	})
+	c.handleReport(DistributionReport{
+		Reporter:            "pilot-b",
+		DataPlaneCount:      3,
+		InProgressResources: map[string]int{v1res.String(): 3},
+	})
+	progress, nackedBy, ok := c.Distribution(v1res)
+	Expect(ok).To(BeTrue())
+	Expect(progress).To(Equal(Progress{AckedInstances: 4, TotalInstances: 5}))
+	Expect(nackedBy).To(Equal([]string{"sidecar~10.0.0.1~b.default~default.svc.cluster.local"}))
+
+	// The analysis resource is looked up by its schema, name and version.
+	s, ok := c.Propagation(&resource.Instance{
+		Metadata: resource.Metadata{
+			Schema:   col,
+			FullName: resource.NewFullName("default", "foo"),
+			Version:  "1",
+		},
+	})
+	Expect(ok).To(BeTrue())
+	Expect(s.Acked).To(Equal(4))
+	Expect(s.Total).To(Equal(5))
+	Expect(s.NackedBy).To(HaveLen(1))
+
+	// A newer version replaces the older one in read only mode.
+	c.handleReport(DistributionReport{
+		Reporter:            "pilot-a",
+		DataPlaneCount:      2,
+		InProgressResources: map[string]int{v2res.String(): 2},
+	})
+	_, _, ok = c.Distribution(v1res)
+	Expect(ok).To(BeFalse())
+	progress, nackedBy, ok = c.Distribution(v2res)
+	Expect(ok).To(BeTrue())
+	Expect(progress).To(Equal(Progress{AckedInstances: 2, TotalInstances: 2}))
+	Expect(nackedBy).To(BeEmpty())
+
+	// Stale reporters are ignored.
+	c.ObservationTime["pilot-a"] = time.Now().Add(-time.Hour)
+	_, _, ok = c.Distribution(v2res)
+	Expect(ok).To(BeFalse())
+}
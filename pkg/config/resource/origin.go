@@ -27,3 +27,16 @@ type Origin interface {
 type Reference interface {
 	String() string
 }
+
+// ClusterOrigin is optionally implemented by origins that know the cluster a resource was read from.
+type ClusterOrigin interface {
+	Origin
+
+	// ClusterName returns the name of the cluster the resource was read from, or the empty string if it is unknown,
+	// e.g. because the resource was not read from a cluster, or only a single cluster is analyzed.
+	ClusterName() string
+
+	// ResourceVersion returns the resourceVersion of the resource in the cluster it was read from, or the empty
+	// string if it has none.
+	ResourceVersion() Version
+}
@@ -54,6 +54,7 @@ func TestIsValidName_Valid(t *testing.T) {
 		"a0_9",
 		"a0_9/fruj_",
 		"abc/def",
+		"cert-manager.io/v1/certificates",
 	}
 
 	for _, d := range data {
@@ -73,6 +74,8 @@ func TestIsValidName_Invalid(t *testing.T) {
 		"a/",
 		"$a/bc",
 		"z//a",
+		"-a",
+		"a/-b",
 	}
 
 	for _, d := range data {
@@ -407,6 +407,42 @@ var (
 		}.MustBuild(),
 	}.MustBuild()
 
+	// K8SAdmissionregistrationK8SIoV1Beta1Mutatingwebhookconfigurations describes the
+	// collection k8s/admissionregistration.k8s.io/v1beta1/mutatingwebhookconfigurations
+	K8SAdmissionregistrationK8SIoV1Beta1Mutatingwebhookconfigurations = collection.Builder{
+		Name:         "k8s/admissionregistration.k8s.io/v1beta1/mutatingwebhookconfigurations",
+		VariableName: "K8SAdmissionregistrationK8SIoV1Beta1Mutatingwebhookconfigurations",
+		Disabled:     false,
+		Resource: resource.Builder{
+			Group:         "admissionregistration.k8s.io",
+			Kind:          "MutatingWebhookConfiguration",
+			Plural:        "mutatingwebhookconfigurations",
+			Version:       "v1beta1",
+			Proto:         "k8s.io.api.admissionregistration.v1beta1.MutatingWebhookConfiguration",
+			ProtoPackage:  "k8s.io/api/admissionregistration/v1beta1",
+			ClusterScoped: true,
+			ValidateProto: validation.EmptyValidate,
+		}.MustBuild(),
+	}.MustBuild()
+
+	// K8SAdmissionregistrationK8SIoV1Beta1Validatingwebhookconfigurations describes the
+	// collection k8s/admissionregistration.k8s.io/v1beta1/validatingwebhookconfigurations
+	K8SAdmissionregistrationK8SIoV1Beta1Validatingwebhookconfigurations = collection.Builder{
+		Name:         "k8s/admissionregistration.k8s.io/v1beta1/validatingwebhookconfigurations",
+		VariableName: "K8SAdmissionregistrationK8SIoV1Beta1Validatingwebhookconfigurations",
+		Disabled:     false,
+		Resource: resource.Builder{
+			Group:         "admissionregistration.k8s.io",
+			Kind:          "ValidatingWebhookConfiguration",
+			Plural:        "validatingwebhookconfigurations",
+			Version:       "v1beta1",
+			Proto:         "k8s.io.api.admissionregistration.v1beta1.ValidatingWebhookConfiguration",
+			ProtoPackage:  "k8s.io/api/admissionregistration/v1beta1",
+			ClusterScoped: true,
+			ValidateProto: validation.EmptyValidate,
+		}.MustBuild(),
+	}.MustBuild()
+
 	// K8SApiextensionsK8SIoV1Beta1Customresourcedefinitions describes the
 	// collection k8s/apiextensions.k8s.io/v1beta1/customresourcedefinitions
 	K8SApiextensionsK8SIoV1Beta1Customresourcedefinitions = collection.Builder{
@@ -759,6 +795,60 @@ var (
 		}.MustBuild(),
 	}.MustBuild()
 
+	// K8SGatewayNetworkingK8SIoV1Beta1Gatewayclasses describes the collection
+	// k8s/gateway.networking.k8s.io/v1beta1/gatewayclasses
+	K8SGatewayNetworkingK8SIoV1Beta1Gatewayclasses = collection.Builder{
+		Name:         "k8s/gateway.networking.k8s.io/v1beta1/gatewayclasses",
+		VariableName: "K8SGatewayNetworkingK8SIoV1Beta1Gatewayclasses",
+		Disabled:     false,
+		Resource: resource.Builder{
+			Group:         "gateway.networking.k8s.io",
+			Kind:          "GatewayClass",
+			Plural:        "gatewayclasses",
+			Version:       "v1beta1",
+			Proto:         "google.protobuf.Struct",
+			ProtoPackage:  "github.com/gogo/protobuf/types",
+			ClusterScoped: true,
+			ValidateProto: validation.EmptyValidate,
+		}.MustBuild(),
+	}.MustBuild()
+
+	// K8SGatewayNetworkingK8SIoV1Beta1Gateways describes the collection
+	// k8s/gateway.networking.k8s.io/v1beta1/gateways
+	K8SGatewayNetworkingK8SIoV1Beta1Gateways = collection.Builder{
+		Name:         "k8s/gateway.networking.k8s.io/v1beta1/gateways",
+		VariableName: "K8SGatewayNetworkingK8SIoV1Beta1Gateways",
+		Disabled:     false,
+		Resource: resource.Builder{
+			Group:         "gateway.networking.k8s.io",
+			Kind:          "Gateway",
+			Plural:        "gateways",
+			Version:       "v1beta1",
+			Proto:         "google.protobuf.Struct",
+			ProtoPackage:  "github.com/gogo/protobuf/types",
+			ClusterScoped: false,
+			ValidateProto: validation.EmptyValidate,
+		}.MustBuild(),
+	}.MustBuild()
+
+	// K8SGatewayNetworkingK8SIoV1Beta1Httproutes describes the collection
+	// k8s/gateway.networking.k8s.io/v1beta1/httproutes
+	K8SGatewayNetworkingK8SIoV1Beta1Httproutes = collection.Builder{
+		Name:         "k8s/gateway.networking.k8s.io/v1beta1/httproutes",
+		VariableName: "K8SGatewayNetworkingK8SIoV1Beta1Httproutes",
+		Disabled:     false,
+		Resource: resource.Builder{
+			Group:         "gateway.networking.k8s.io",
+			Kind:          "HTTPRoute",
+			Plural:        "httproutes",
+			Version:       "v1beta1",
+			Proto:         "google.protobuf.Struct",
+			ProtoPackage:  "github.com/gogo/protobuf/types",
+			ClusterScoped: false,
+			ValidateProto: validation.EmptyValidate,
+		}.MustBuild(),
+	}.MustBuild()
+
 	// K8SNetworkingIstioIoV1Alpha3Destinationrules describes the collection
 	// k8s/networking.istio.io/v1alpha3/destinationrules
 	K8SNetworkingIstioIoV1Alpha3Destinationrules = collection.Builder{
@@ -1053,6 +1143,8 @@ var (
 		MustAdd(IstioSecurityV1Beta1Authorizationpolicies).
 		MustAdd(IstioSecurityV1Beta1Peerauthentications).
 		MustAdd(IstioSecurityV1Beta1Requestauthentications).
+		MustAdd(K8SAdmissionregistrationK8SIoV1Beta1Mutatingwebhookconfigurations).
+		MustAdd(K8SAdmissionregistrationK8SIoV1Beta1Validatingwebhookconfigurations).
 		MustAdd(K8SApiextensionsK8SIoV1Beta1Customresourcedefinitions).
 		MustAdd(K8SAppsV1Deployments).
 		MustAdd(K8SConfigIstioIoV1Alpha2Adapters).
@@ -1073,6 +1165,9 @@ var (
 		MustAdd(K8SCoreV1Secrets).
 		MustAdd(K8SCoreV1Services).
 		MustAdd(K8SExtensionsV1Beta1Ingresses).
+		MustAdd(K8SGatewayNetworkingK8SIoV1Beta1Gatewayclasses).
+		MustAdd(K8SGatewayNetworkingK8SIoV1Beta1Gateways).
+		MustAdd(K8SGatewayNetworkingK8SIoV1Beta1Httproutes).
 		MustAdd(K8SNetworkingIstioIoV1Alpha3Destinationrules).
 		MustAdd(K8SNetworkingIstioIoV1Alpha3Envoyfilters).
 		MustAdd(K8SNetworkingIstioIoV1Alpha3Gateways).
@@ -1118,6 +1213,8 @@ var (
 
 	// Kube contains only kubernetes collections.
 	Kube = collection.NewSchemasBuilder().
+		MustAdd(K8SAdmissionregistrationK8SIoV1Beta1Mutatingwebhookconfigurations).
+		MustAdd(K8SAdmissionregistrationK8SIoV1Beta1Validatingwebhookconfigurations).
 		MustAdd(K8SApiextensionsK8SIoV1Beta1Customresourcedefinitions).
 		MustAdd(K8SAppsV1Deployments).
 		MustAdd(K8SConfigIstioIoV1Alpha2Adapters).
@@ -1138,6 +1235,9 @@ var (
 		MustAdd(K8SCoreV1Secrets).
 		MustAdd(K8SCoreV1Services).
 		MustAdd(K8SExtensionsV1Beta1Ingresses).
+		MustAdd(K8SGatewayNetworkingK8SIoV1Beta1Gatewayclasses).
+		MustAdd(K8SGatewayNetworkingK8SIoV1Beta1Gateways).
+		MustAdd(K8SGatewayNetworkingK8SIoV1Beta1Httproutes).
 		MustAdd(K8SNetworkingIstioIoV1Alpha3Destinationrules).
 		MustAdd(K8SNetworkingIstioIoV1Alpha3Envoyfilters).
 		MustAdd(K8SNetworkingIstioIoV1Alpha3Gateways).
@@ -24,6 +24,9 @@ import (
 	// Register protos in "istio.io/api/security/v1beta1"
 	_ "istio.io/api/security/v1beta1"
 
+	// Register protos in "k8s.io/api/admissionregistration/v1beta1"
+	_ "k8s.io/api/admissionregistration/v1beta1"
+
 	// Register protos in "k8s.io/api/apps/v1"
 	_ "k8s.io/api/apps/v1"
 
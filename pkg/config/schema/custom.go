@@ -0,0 +1,204 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schema
+
+import (
+	"fmt"
+	"strings"
+
+	// Registers google.protobuf.Struct, which holds the specs of custom resources.
+	_ "github.com/gogo/protobuf/types"
+	"k8s.io/apimachinery/pkg/api/meta"
+	kubeSchema "k8s.io/apimachinery/pkg/runtime/schema"
+
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/resource"
+	"istio.io/istio/pkg/config/validation"
+)
+
+const (
+	customResourceProto        = "google.protobuf.Struct"
+	customResourceProtoPackage = "github.com/gogo/protobuf/types"
+)
+
+// CustomResource describes a Kubernetes resource kind that is not part of the generated metadata, e.g. the custom
+// resources of a third-party project such as cert-manager.
+type CustomResource struct {
+	Group   string
+	Version string
+	Kind    string
+
+	// Plural is the plural name of the resource, as used in API paths. If empty, it is guessed from the kind.
+	Plural string
+
+	ClusterScoped bool
+}
+
+// ParseCustomResource parses a custom resource in the form <group>/<version>/<kind>[/<plural>], e.g.
+// cert-manager.io/v1/Certificate.
+func ParseCustomResource(s string) (CustomResource, error) {
+	parts := strings.Split(s, "/")
+	if len(parts) != 3 && len(parts) != 4 {
+		return CustomResource{}, fmt.Errorf("invalid custom resource %q, expected <group>/<version>/<kind>[/<plural>]", s)
+	}
+
+	cr := CustomResource{
+		Group:   parts[0],
+		Version: parts[1],
+		Kind:    parts[2],
+	}
+	if len(parts) == 4 {
+		cr.Plural = parts[3]
+	}
+	if err := cr.validate(); err != nil {
+		return CustomResource{}, err
+	}
+	return cr, nil
+}
+
+// CollectionName returns the name of the collection that holds the resources after transformation. Analyzers use it
+// to declare the resources as an input.
+func (c CustomResource) CollectionName() collection.Name {
+	return collection.NewName(strings.Join([]string{c.Group, c.Version, c.plural()}, "/"))
+}
+
+// KubeCollectionName returns the name of the collection that the resources are read into from Kubernetes.
+func (c CustomResource) KubeCollectionName() collection.Name {
+	return collection.NewName(kubeCollectionPrefix + c.CollectionName().String())
+}
+
+func (c CustomResource) plural() string {
+	if c.Plural != "" {
+		return c.Plural
+	}
+	plural, _ := meta.UnsafeGuessKindToResource(kubeSchema.GroupVersionKind{
+		Group:   c.Group,
+		Version: c.Version,
+		Kind:    c.Kind,
+	})
+	return plural.Resource
+}
+
+func (c CustomResource) validate() error {
+	if c.Group == "" || c.Version == "" || c.Kind == "" {
+		return fmt.Errorf("custom resource %s/%s/%s: group, version and kind are required", c.Group, c.Version, c.Kind)
+	}
+	if name := strings.Join([]string{c.Group, c.Version, c.plural()}, "/"); !collection.IsValidName(name) {
+		return fmt.Errorf("custom resource %s/%s/%s: invalid collection name %q", c.Group, c.Version, c.Kind, name)
+	}
+	return nil
+}
+
+// WithCustomResources returns a copy of the metadata that additionally contains the given custom resources. Each of
+// them gets a Kubernetes collection that is directly transformed into the collection named by CollectionName, which
+// is part of all snapshots. As no proto type is compiled in for custom resources, their specs are read into a
+// google.protobuf.Struct.
+func (m *Metadata) WithCustomResources(crs ...CustomResource) (*Metadata, error) {
+	var added []collection.Schema
+	var kubeAdded []collection.Schema
+	var names collection.Names
+	mapping := make(map[collection.Name]collection.Name)
+	for _, cr := range crs {
+		if err := cr.validate(); err != nil {
+			return nil, err
+		}
+
+		gvk := resource.GroupVersionKind{Group: cr.Group, Version: cr.Version, Kind: cr.Kind}
+		if _, found := m.kubeCollections.FindByGroupVersionKind(gvk); found {
+			return nil, fmt.Errorf("custom resource %s is already known", gvk)
+		}
+
+		r, err := resource.Builder{
+			ClusterScoped: cr.ClusterScoped,
+			Kind:          cr.Kind,
+			Plural:        cr.plural(),
+			Group:         cr.Group,
+			Version:       cr.Version,
+			Proto:         customResourceProto,
+			ProtoPackage:  customResourceProtoPackage,
+			ValidateProto: validation.EmptyValidate,
+		}.Build()
+		if err != nil {
+			return nil, err
+		}
+
+		kubeCol, err := collection.Builder{Name: cr.KubeCollectionName().String(), Resource: r}.Build()
+		if err != nil {
+			return nil, err
+		}
+		col, err := collection.Builder{Name: cr.CollectionName().String(), Resource: r}.Build()
+		if err != nil {
+			return nil, err
+		}
+
+		kubeAdded = append(kubeAdded, kubeCol)
+		added = append(added, kubeCol, col)
+		mapping[kubeCol.Name()] = col.Name()
+		names = append(names, col.Name())
+	}
+
+	collections, err := addSchemas(m.collections, added)
+	if err != nil {
+		return nil, err
+	}
+	kubeCollections, err := addSchemas(m.kubeCollections, kubeAdded)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots := make(map[string]*Snapshot, len(m.snapshots))
+	for name, s := range m.snapshots {
+		sn := &Snapshot{
+			Name:        s.Name,
+			Strategy:    s.Strategy,
+			Collections: append(append([]collection.Name{}, s.Collections...), names...),
+		}
+		snapshots[name] = sn
+	}
+
+	var transforms []TransformSettings
+	direct := false
+	for _, t := range m.transformSettings {
+		if d, ok := t.(*DirectTransformSettings); ok && !direct {
+			merged := d.Mapping()
+			for from, to := range mapping {
+				merged[from] = to
+			}
+			t = &DirectTransformSettings{mapping: merged}
+			direct = true
+		}
+		transforms = append(transforms, t)
+	}
+	if !direct {
+		transforms = append(transforms, &DirectTransformSettings{mapping: mapping})
+	}
+
+	return &Metadata{
+		collections:       collections,
+		kubeCollections:   kubeCollections,
+		snapshots:         snapshots,
+		transformSettings: transforms,
+	}, nil
+}
+
+func addSchemas(s collection.Schemas, toAdd []collection.Schema) (collection.Schemas, error) {
+	b := collection.NewSchemasBuilder()
+	for _, c := range append(s.All(), toAdd...) {
+		if err := b.Add(c); err != nil {
+			return collection.Schemas{}, err
+		}
+	}
+	return b.Build(), nil
+}
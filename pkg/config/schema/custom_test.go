@@ -0,0 +1,100 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schema
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"istio.io/istio/pkg/config/schema/collection"
+)
+
+func TestParseCustomResource(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	cr, err := ParseCustomResource("cert-manager.io/v1/Certificate")
+	g.Expect(err).To(BeNil())
+	g.Expect(cr).To(Equal(CustomResource{Group: "cert-manager.io", Version: "v1", Kind: "Certificate"}))
+	g.Expect(cr.CollectionName()).To(Equal(collection.NewName("cert-manager.io/v1/certificates")))
+	g.Expect(cr.KubeCollectionName()).To(Equal(collection.NewName("k8s/cert-manager.io/v1/certificates")))
+
+	cr, err = ParseCustomResource("gateway.networking.k8s.io/v1alpha1/GatewayClass/gatewayclasses")
+	g.Expect(err).To(BeNil())
+	g.Expect(cr.CollectionName()).To(Equal(collection.NewName("gateway.networking.k8s.io/v1alpha1/gatewayclasses")))
+
+	for _, s := range []string{"", "Certificate", "cert-manager.io/Certificate", "cert-manager.io//Certificate",
+		"cert-manager.io/v1/Certificate/certificates/extra", "$/v1/Certificate"} {
+		_, err := ParseCustomResource(s)
+		g.Expect(err).NotTo(BeNil(), s)
+	}
+}
+
+func TestWithCustomResources(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	s, err := ParseAndBuild(input)
+	g.Expect(err).To(BeNil())
+
+	cert := CustomResource{Group: "cert-manager.io", Version: "v1", Kind: "Certificate"}
+	class := CustomResource{Group: "gateway.networking.k8s.io", Version: "v1alpha1", Kind: "GatewayClass", ClusterScoped: true}
+	m, err := s.WithCustomResources(cert, class)
+	g.Expect(err).To(BeNil())
+
+	g.Expect(m.KubeCollections().CollectionNames()).To(Equal(collection.Names{
+		collection.NewName("k8s/cert-manager.io/v1/certificates"),
+		collection.NewName("k8s/gateway.networking.k8s.io/v1alpha1/gatewayclasses"),
+		collection.NewName("k8s/networking.istio.io/v1alpha3/virtualservices"),
+	}))
+	col := m.AllCollections().MustFind("cert-manager.io/v1/certificates")
+	g.Expect(col.Resource().GroupVersionKind().String()).To(Equal("cert-manager.io/v1/Certificate"))
+	g.Expect(col.Resource().Proto()).To(Equal("google.protobuf.Struct"))
+	g.Expect(col.Resource().MustNewProtoInstance()).NotTo(BeNil())
+	g.Expect(m.AllCollections().MustFind("gateway.networking.k8s.io/v1alpha1/gatewayclasses").Resource().IsClusterScoped()).
+		To(BeTrue())
+
+	g.Expect(m.AllCollectionsInSnapshots([]string{"default"})).To(Equal([]string{
+		"cert-manager.io/v1/certificates",
+		"gateway.networking.k8s.io/v1alpha1/gatewayclasses",
+		"istio/networking.istio.io/v1alpha3/virtualservices",
+	}))
+	g.Expect(m.DirectTransformSettings().Mapping()).To(Equal(map[collection.Name]collection.Name{
+		collection.NewName("k8s/networking.istio.io/v1alpha3/virtualservices"): collection.NewName("istio/networking.istio.io/v1alpha3/virtualservices"),
+		cert.KubeCollectionName():  cert.CollectionName(),
+		class.KubeCollectionName(): class.CollectionName(),
+	}))
+
+	// The original metadata is unchanged
+	g.Expect(s.AllCollections().All()).To(HaveLen(2))
+	g.Expect(s.AllCollectionsInSnapshots([]string{"default"})).To(HaveLen(1))
+	g.Expect(s.DirectTransformSettings().Mapping()).To(HaveLen(1))
+}
+
+func TestWithCustomResources_Error(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	s, err := ParseAndBuild(input)
+	g.Expect(err).To(BeNil())
+
+	_, err = s.WithCustomResources(CustomResource{Group: "networking.istio.io", Version: "v1alpha3", Kind: "VirtualService"})
+	g.Expect(err).NotTo(BeNil())
+
+	_, err = s.WithCustomResources(CustomResource{Group: "cert-manager.io", Kind: "Certificate"})
+	g.Expect(err).NotTo(BeNil())
+
+	cert := CustomResource{Group: "cert-manager.io", Version: "v1", Kind: "Certificate"}
+	_, err = s.WithCustomResources(cert, cert)
+	g.Expect(err).NotTo(BeNil())
+}
@@ -180,6 +180,14 @@ collections:
   ### K8s collections ###
 
   # Built-in K8s collections
+  - name: "k8s/admissionregistration.k8s.io/v1beta1/mutatingwebhookconfigurations"
+    kind: "MutatingWebhookConfiguration"
+    group: "admissionregistration.k8s.io"
+
+  - name: "k8s/admissionregistration.k8s.io/v1beta1/validatingwebhookconfigurations"
+    kind: "ValidatingWebhookConfiguration"
+    group: "admissionregistration.k8s.io"
+
   - name: "k8s/apiextensions.k8s.io/v1beta1/customresourcedefinitions"
     kind: "CustomResourceDefinition"
     group: "apiextensions.k8s.io"
@@ -220,6 +228,18 @@ collections:
     kind: "Ingress"
     group: "extensions"
 
+  - name: "k8s/gateway.networking.k8s.io/v1beta1/gatewayclasses"
+    kind: "GatewayClass"
+    group: "gateway.networking.k8s.io"
+
+  - name: "k8s/gateway.networking.k8s.io/v1beta1/gateways"
+    kind: "Gateway"
+    group: "gateway.networking.k8s.io"
+
+  - name: "k8s/gateway.networking.k8s.io/v1beta1/httproutes"
+    kind: "HTTPRoute"
+    group: "gateway.networking.k8s.io"
+
   - kind: "GatewayClass"
     name: "k8s/service_apis/v1alpha1/gatewayclasses"
     group: "networking.x.k8s.io"
@@ -364,17 +384,44 @@ snapshots:
       - "istio/networking/v1alpha3/serviceentries"
       - "istio/networking/v1alpha3/sidecars"
       - "istio/networking/v1alpha3/virtualservices"
+      - "istio/networking/v1alpha3/workloadentries"
+      - "istio/security/v1beta1/authorizationpolicies"
+      - "istio/security/v1beta1/peerauthentications"
+      - "istio/security/v1beta1/requestauthentications"
+      - "k8s/admissionregistration.k8s.io/v1beta1/mutatingwebhookconfigurations"
+      - "k8s/admissionregistration.k8s.io/v1beta1/validatingwebhookconfigurations"
       - "k8s/apiextensions.k8s.io/v1beta1/customresourcedefinitions"
       - "k8s/apps/v1/deployments"
       - "k8s/core/v1/namespaces"
+      - "k8s/core/v1/nodes"
       - "k8s/core/v1/pods"
       - "k8s/core/v1/secrets"
       - "k8s/core/v1/services"
       - "k8s/core/v1/configmaps"
+      - "k8s/extensions/v1beta1/ingresses"
+      - "k8s/gateway.networking.k8s.io/v1beta1/gatewayclasses"
+      - "k8s/gateway.networking.k8s.io/v1beta1/gateways"
+      - "k8s/gateway.networking.k8s.io/v1beta1/httproutes"
 
 # Configuration for resource types.
 resources:
   # Kubernetes specific configuration.
+  - kind: "MutatingWebhookConfiguration"
+    plural: "mutatingwebhookconfigurations"
+    group: "admissionregistration.k8s.io"
+    version: "v1beta1"
+    clusterScoped: true
+    proto: "k8s.io.api.admissionregistration.v1beta1.MutatingWebhookConfiguration"
+    protoPackage: "k8s.io/api/admissionregistration/v1beta1"
+
+  - kind: "ValidatingWebhookConfiguration"
+    plural: "validatingwebhookconfigurations"
+    group: "admissionregistration.k8s.io"
+    version: "v1beta1"
+    clusterScoped: true
+    proto: "k8s.io.api.admissionregistration.v1beta1.ValidatingWebhookConfiguration"
+    protoPackage: "k8s.io/api/admissionregistration/v1beta1"
+
   - kind: "CustomResourceDefinition"
     plural: "CustomResourceDefinitions"
     group: "apiextensions.k8s.io"
@@ -440,6 +487,31 @@ resources:
     proto: "k8s.io.api.extensions.v1beta1.IngressSpec"
     protoPackage: "k8s.io/api/extensions/v1beta1"
 
+  # Kubernetes Gateway API resources. There are no proto definitions for them, so their specs are read as structs.
+  - kind: "GatewayClass"
+    plural: "gatewayclasses"
+    group: "gateway.networking.k8s.io"
+    version: "v1beta1"
+    clusterScoped: true
+    proto: "google.protobuf.Struct"
+    protoPackage: "github.com/gogo/protobuf/types"
+
+  - kind: "Gateway"
+    plural: "gateways"
+    group: "gateway.networking.k8s.io"
+    version: "v1beta1"
+    proto: "google.protobuf.Struct"
+    protoPackage: "github.com/gogo/protobuf/types"
+    # ValidateGateway validates Istio gateways
+    validate: "EmptyValidate"
+
+  - kind: "HTTPRoute"
+    plural: "httproutes"
+    group: "gateway.networking.k8s.io"
+    version: "v1beta1"
+    proto: "google.protobuf.Struct"
+    protoPackage: "github.com/gogo/protobuf/types"
+
   - Kind: "GatewayClass"
     plural: "gatewayclasses"
     group: "networking.x.k8s.io"
@@ -652,6 +724,8 @@ resources:
 transforms:
   - type: direct
     mapping:
+      "k8s/admissionregistration.k8s.io/v1beta1/mutatingwebhookconfigurations": "k8s/admissionregistration.k8s.io/v1beta1/mutatingwebhookconfigurations"
+      "k8s/admissionregistration.k8s.io/v1beta1/validatingwebhookconfigurations": "k8s/admissionregistration.k8s.io/v1beta1/validatingwebhookconfigurations"
       "k8s/apiextensions.k8s.io/v1beta1/customresourcedefinitions": "k8s/apiextensions.k8s.io/v1beta1/customresourcedefinitions"
       "k8s/config.istio.io/v1alpha2/adapters": "istio/config/v1alpha2/adapters"
       "k8s/config.istio.io/v1alpha2/attributemanifests": "istio/policy/v1beta1/attributemanifests"
@@ -675,10 +749,15 @@ transforms:
       "k8s/security.istio.io/v1beta1/peerauthentications": "istio/security/v1beta1/peerauthentications"
       "k8s/apps/v1/deployments": "k8s/apps/v1/deployments"
       "k8s/core/v1/namespaces": "k8s/core/v1/namespaces"
+      "k8s/core/v1/nodes": "k8s/core/v1/nodes"
       "k8s/core/v1/pods": "k8s/core/v1/pods"
       "k8s/core/v1/secrets": "k8s/core/v1/secrets"
       "k8s/core/v1/services": "k8s/core/v1/services"
       "k8s/core/v1/configmaps": "k8s/core/v1/configmaps"
+      "k8s/extensions/v1beta1/ingresses": "k8s/extensions/v1beta1/ingresses"
+      "k8s/gateway.networking.k8s.io/v1beta1/gatewayclasses": "k8s/gateway.networking.k8s.io/v1beta1/gatewayclasses"
+      "k8s/gateway.networking.k8s.io/v1beta1/gateways": "k8s/gateway.networking.k8s.io/v1beta1/gateways"
+      "k8s/gateway.networking.k8s.io/v1beta1/httproutes": "k8s/gateway.networking.k8s.io/v1beta1/httproutes"
       "istio/mesh/v1alpha1/MeshConfig": "istio/mesh/v1alpha1/MeshConfig"
       "istio/mesh/v1alpha1/MeshNetworks": "istio/mesh/v1alpha1/MeshNetworks"
 `)
@@ -34,6 +34,7 @@ import (
 
 	"istio.io/istio/mixer/pkg/config/store"
 	"istio.io/istio/pilot/pkg/config/kube/crd"
+	configresource "istio.io/istio/pkg/config/resource"
 	"istio.io/istio/pkg/config/schema/collection"
 	"istio.io/istio/pkg/config/schema/collections"
 	"istio.io/istio/pkg/config/schema/resource"
@@ -80,6 +81,17 @@ type Options struct {
 
 	// Use an existing mux instead of creating our own.
 	Mux *http.ServeMux
+
+	// Analyzer, if set, analyzes valid Istio resources against the existing configuration as they are admitted. Its
+	// findings are returned to the client as admission warnings.
+	Analyzer AdmissionAnalyzer
+}
+
+// AdmissionAnalyzer analyzes an incoming resource in the context of the existing configuration.
+type AdmissionAnalyzer interface {
+	// AnalyzeAdmission returns warnings about the given resource. A non-nil error indicates that the resource
+	// should be denied.
+	AnalyzeAdmission(s collection.Schema, r *configresource.Instance) ([]string, error)
 }
 
 // String produces a stringified version of the arguments for debugging.
@@ -107,6 +119,8 @@ type Webhook struct {
 
 	// mixer
 	validator store.BackendValidator
+
+	analyzer AdmissionAnalyzer
 }
 
 // New creates a new instance of the admission webhook server.
@@ -118,6 +132,7 @@ func New(p Options) (*Webhook, error) {
 	wh := &Webhook{
 		schemas:   p.Schemas,
 		validator: p.MixerValidator,
+		analyzer:  p.Analyzer,
 	}
 
 	p.Mux.HandleFunc("/validate", wh.serveValidate)
@@ -152,7 +167,25 @@ func toAdmissionResponse(err error) *kubeApiAdmission.AdmissionResponse {
 
 type admitFunc func(*kubeApiAdmission.AdmissionRequest) *kubeApiAdmission.AdmissionResponse
 
+// warnFunc returns warnings for an admitted request, or an error if the request should be denied after all.
+type warnFunc func(*kubeApiAdmission.AdmissionRequest) ([]string, error)
+
+// admissionResponse extends the v1beta1 AdmissionResponse with the warnings field. Warnings were added to the
+// admission API in Kubernetes 1.19 and are not yet part of the vendored API types. Older API servers ignore the field.
+type admissionResponse struct {
+	*kubeApiAdmission.AdmissionResponse
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+type admissionReview struct {
+	Response *admissionResponse `json:"response,omitempty"`
+}
+
 func serve(w http.ResponseWriter, r *http.Request, admit admitFunc) {
+	serveWithWarnings(w, r, admit, nil)
+}
+
+func serveWithWarnings(w http.ResponseWriter, r *http.Request, admit admitFunc, warn warnFunc) {
 	var body []byte
 	if r.Body != nil {
 		if data, err := ioutil.ReadAll(r.Body); err == nil {
@@ -181,9 +214,18 @@ func serve(w http.ResponseWriter, r *http.Request, admit admitFunc) {
 		reviewResponse = admit(ar.Request)
 	}
 
-	response := kubeApiAdmission.AdmissionReview{}
+	var warnings []string
+	if reviewResponse != nil && reviewResponse.Allowed && warn != nil && ar.Request != nil {
+		var err error
+		if warnings, err = warn(ar.Request); err != nil {
+			reportValidationFailed(ar.Request, reasonAnalysisError)
+			reviewResponse = toAdmissionResponse(err)
+		}
+	}
+
+	response := admissionReview{}
 	if reviewResponse != nil {
-		response.Response = reviewResponse
+		response.Response = &admissionResponse{AdmissionResponse: reviewResponse, Warnings: warnings}
 		if ar.Request != nil {
 			response.Response.UID = ar.Request.UID
 		}
@@ -202,7 +244,7 @@ func serve(w http.ResponseWriter, r *http.Request, admit admitFunc) {
 }
 
 func (wh *Webhook) serveAdmitPilot(w http.ResponseWriter, r *http.Request) {
-	serve(w, r, wh.admitPilot)
+	serveWithWarnings(w, r, wh.admitPilot, wh.warnFunc())
 }
 
 func (wh *Webhook) serveAdmitMixer(w http.ResponseWriter, r *http.Request) {
@@ -210,7 +252,64 @@ func (wh *Webhook) serveAdmitMixer(w http.ResponseWriter, r *http.Request) {
 }
 
 func (wh *Webhook) serveValidate(w http.ResponseWriter, r *http.Request) {
-	serve(w, r, wh.validate)
+	serveWithWarnings(w, r, wh.validate, wh.warnFunc())
+}
+
+func (wh *Webhook) warnFunc() warnFunc {
+	if wh.analyzer == nil {
+		return nil
+	}
+	return wh.analyze
+}
+
+// analyze runs the configured AdmissionAnalyzer against an already validated Istio resource.
+func (wh *Webhook) analyze(request *kubeApiAdmission.AdmissionRequest) ([]string, error) {
+	switch request.Operation {
+	case kubeApiAdmission.Create, kubeApiAdmission.Update:
+	default:
+		return nil, nil
+	}
+
+	var obj crd.IstioKind
+	if err := yaml.Unmarshal(request.Object.Raw, &obj); err != nil {
+		return nil, nil
+	}
+
+	gvk := obj.GroupVersionKind()
+	if gvk.Group == "networking.istio.io" && gvk.Version == "v1beta1" {
+		gvk.Version = "v1alpha3"
+	}
+	s, exists := wh.schemas.FindByGroupVersionKind(resource.FromKubernetesGVK(&gvk))
+	if !exists {
+		// Not a Pilot resource (e.g. mixer config), nothing to analyze.
+		return nil, nil
+	}
+
+	out, err := crd.ConvertObject(s, &obj, wh.domainSuffix)
+	if err != nil {
+		return nil, nil
+	}
+
+	ns := out.Namespace
+	if ns == "" {
+		ns = request.Namespace
+	}
+	r := &configresource.Instance{
+		Metadata: configresource.Metadata{
+			Schema:      s.Resource(),
+			FullName:    configresource.NewFullName(configresource.Namespace(ns), configresource.LocalName(out.Name)),
+			Version:     configresource.Version(out.ResourceVersion),
+			Labels:      out.Labels,
+			Annotations: out.Annotations,
+		},
+		Message: out.Spec,
+	}
+
+	warnings, err := wh.analyzer.AnalyzeAdmission(s, r)
+	if err != nil {
+		scope.Infof("configuration analysis failed: %v", err)
+	}
+	return warnings, err
 }
 
 func (wh *Webhook) validate(request *kubeApiAdmission.AdmissionRequest) *kubeApiAdmission.AdmissionResponse {
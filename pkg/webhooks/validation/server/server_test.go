@@ -24,6 +24,7 @@ import (
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strconv"
 	"strings"
 	"testing"
@@ -536,6 +537,68 @@ func TestServe(t *testing.T) {
 	}
 }
 
+func TestServeWithWarnings(t *testing.T) {
+	validReview := makeTestReview(t, true)
+
+	cases := []struct {
+		name         string
+		warnings     []string
+		err          error
+		wantAllowed  bool
+		wantWarnings []string
+	}{
+		{
+			name:        "no warnings",
+			wantAllowed: true,
+		},
+		{
+			name:         "warnings",
+			warnings:     []string{"Error [IST0101] (Gateway gw.default) Referenced credentialName not found"},
+			wantAllowed:  true,
+			wantWarnings: []string{"Error [IST0101] (Gateway gw.default) Referenced credentialName not found"},
+		},
+		{
+			name:        "denied",
+			err:         errors.New("configuration analysis failed"),
+			wantAllowed: false,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(fmt.Sprintf("[%d] %s", i, c.name), func(t *testing.T) {
+			req := httptest.NewRequest("POST", "http://validator", bytes.NewReader(validReview))
+			req.Header.Add("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			serveWithWarnings(w, req, func(*kubeApiAdmission.AdmissionRequest) *kubeApiAdmission.AdmissionResponse {
+				return &kubeApiAdmission.AdmissionResponse{Allowed: true}
+			}, func(*kubeApiAdmission.AdmissionRequest) ([]string, error) {
+				return c.warnings, c.err
+			})
+
+			gotBody, err := ioutil.ReadAll(w.Result().Body)
+			if err != nil {
+				t.Fatalf("could not read body: %v", err)
+			}
+			var gotReview struct {
+				Response struct {
+					Allowed  bool     `json:"allowed"`
+					Warnings []string `json:"warnings"`
+				} `json:"response"`
+			}
+			if err := json.Unmarshal(gotBody, &gotReview); err != nil {
+				t.Fatalf("could not decode response body: %v", err)
+			}
+			if gotReview.Response.Allowed != c.wantAllowed {
+				t.Fatalf("Allowed is wrong: got %v want %v", gotReview.Response.Allowed, c.wantAllowed)
+			}
+			if !reflect.DeepEqual(gotReview.Response.Warnings, c.wantWarnings) {
+				t.Fatalf("Warnings are wrong: got %v want %v", gotReview.Response.Warnings, c.wantWarnings)
+			}
+		})
+	}
+}
+
 // scenario is a common struct used by many tests in this context.
 type scenario struct {
 	wrapFunc      func(*Options)